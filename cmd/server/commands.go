@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"sss/internal/config"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// subcommand 离线管理子命令，直接操作元数据库和文件存储（需先停止服务）
+type subcommand struct {
+	usage string
+	run   func(env *commandEnv, args []string) error
+}
+
+// subcommands 子命令表
+var subcommands = map[string]subcommand{
+	"create-bucket":  {usage: "create-bucket [-public] <name>  创建桶", run: cmdCreateBucket},
+	"list-keys":      {usage: "list-keys                       列出 API Key 及其权限", run: cmdListKeys},
+	"reset-password": {usage: "reset-password [-password p]    重置管理员密码（未指定时从标准输入读取）", run: cmdResetPassword},
+	"gc":             {usage: "gc [-dry-run] [-max-upload-age 24h]  清理孤立文件和过期分片上传", run: cmdGC},
+}
+
+// commandEnv 子命令运行环境
+type commandEnv struct {
+	metadata  *storage.MetadataStore
+	filestore *storage.FileStore
+	stdin     io.Reader
+	stdout    io.Writer
+}
+
+// isSubcommand 第一个参数不是 flag 时视为子命令
+func isSubcommand(args []string) bool {
+	return len(args) > 0 && !strings.HasPrefix(args[0], "-")
+}
+
+// runSubcommand 解析并执行子命令，返回进程退出码
+func runSubcommand(args []string) int {
+	name := args[0]
+	if name == "help" {
+		printSubcommandUsage(os.Stdout)
+		return 0
+	}
+	cmd, ok := subcommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知子命令: %s\n\n", name)
+		printSubcommandUsage(os.Stderr)
+		return 2
+	}
+
+	// 各子命令共享存储相关参数，其余参数由子命令自行解析
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	dbPath := fs.String("db", "./data/metadata.db", "数据库路径")
+	dataPath := fs.String("data", "./data/buckets", "数据存储路径")
+	pathLayout := fs.String("layout", "prefix", "对象文件路径布局 (prefix/hashed)")
+	logLevel := fs.String("log", "warn", "日志级别 (debug/info/warn/error)")
+	rest, err := parseSharedFlags(fs, args[1:])
+	if err != nil {
+		return 2
+	}
+	utils.InitLogger(*logLevel)
+
+	env, closeEnv, err := openCommandEnv(*dbPath, *dataPath, *pathLayout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 1
+	}
+	defer closeEnv()
+
+	if err := cmd.run(env, rest); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// parseSharedFlags 从参数中提取共享参数，未识别的参数原样交给子命令
+func parseSharedFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var shared, rest []string
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		name, _, hasValue := strings.Cut(name, "=")
+		if !strings.HasPrefix(args[i], "-") || fs.Lookup(name) == nil {
+			rest = append(rest, args[i])
+			continue
+		}
+		shared = append(shared, args[i])
+		if !hasValue && i+1 < len(args) {
+			i++
+			shared = append(shared, args[i])
+		}
+	}
+	return rest, fs.Parse(shared)
+}
+
+// openCommandEnv 打开已有的元数据库和文件存储，数据库不存在时报错而不是新建
+func openCommandEnv(dbPath, dataPath, pathLayout string) (*commandEnv, func(), error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, nil, fmt.Errorf("数据库不存在: %s", dbPath)
+	}
+	metadata, err := storage.NewMetadataStore(dbPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("初始化数据库失败: %v", err)
+	}
+
+	cfg := config.NewDefault()
+	cfg.Storage.DBPath = dbPath
+	cfg.Storage.DataPath = dataPath
+	cfg.Storage.PathLayout = pathLayout
+	config.LoadFromDB(metadata)
+
+	filestore, err := storage.NewFileStore(dataPath)
+	if err == nil {
+		err = filestore.SetPathLayout(pathLayout)
+	}
+	if err != nil {
+		metadata.Close()
+		return nil, nil, fmt.Errorf("初始化文件存储失败: %v", err)
+	}
+
+	env := &commandEnv{metadata: metadata, filestore: filestore, stdin: os.Stdin, stdout: os.Stdout}
+	return env, func() { metadata.Close() }, nil
+}
+
+// printSubcommandUsage 输出子命令帮助
+func printSubcommandUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: sss <子命令> [-db path] [-data path] [参数]")
+	fmt.Fprintln(w, "子命令直接操作数据库和数据目录，执行前请先停止服务。")
+	fmt.Fprintln(w)
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", subcommands[name].usage)
+	}
+}
+
+// cmdCreateBucket 创建桶
+func cmdCreateBucket(env *commandEnv, args []string) error {
+	fs := flag.NewFlagSet("create-bucket", flag.ContinueOnError)
+	public := fs.Bool("public", false, "创建为公有桶")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("需要指定一个桶名")
+	}
+	name := fs.Arg(0)
+	if !utils.IsValidBucketName(name) {
+		return fmt.Errorf("无效的桶名: %s", name)
+	}
+
+	existing, err := env.metadata.GetBucket(name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("桶已存在: %s", name)
+	}
+	if err := env.metadata.CreateBucket(name); err != nil {
+		return err
+	}
+	if err := env.filestore.CreateBucket(name); err != nil {
+		return fmt.Errorf("创建存储目录失败: %v", err)
+	}
+	if *public {
+		if err := env.metadata.UpdateBucketPublic(name, true); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(env.stdout, "已创建桶 %s (public=%v)\n", name, *public)
+	return nil
+}
+
+// cmdListKeys 列出 API Key 及其权限（不输出 Secret）
+func cmdListKeys(env *commandEnv, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("多余的参数: %s", strings.Join(args, " "))
+	}
+	keys, err := env.metadata.ListAPIKeysWithPermissions()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACCESS KEY ID\tENABLED\tCREATED\tPERMISSIONS\tDESCRIPTION")
+	for _, k := range keys {
+		perms := make([]string, 0, len(k.Permissions))
+		for _, p := range k.Permissions {
+			mode := ""
+			if p.CanRead {
+				mode += "r"
+			}
+			if p.CanWrite {
+				mode += "w"
+			}
+			perms = append(perms, p.BucketName+":"+mode)
+		}
+		fmt.Fprintf(tw, "%s\t%v\t%s\t%s\t%s\n", k.AccessKeyID, k.Enabled,
+			k.CreatedAt.Format(time.RFC3339), strings.Join(perms, ","), k.Description)
+	}
+	return tw.Flush()
+}
+
+// cmdResetPassword 重置管理员密码
+func cmdResetPassword(env *commandEnv, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	password := fs.String("password", "", "新密码（避免出现在 shell 历史中可省略，改为从标准输入读取）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !env.metadata.IsInstalled() {
+		return errors.New("系统尚未安装，请通过 Web 界面完成初始化")
+	}
+
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "新密码: ")
+		line, err := bufio.NewReader(env.stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("读取密码失败: %v", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+	if err := env.metadata.SetAdminPassword(*password); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "管理员 %s 的密码已重置\n", env.metadata.GetAdminUsername())
+	return nil
+}
+
+// cmdGC 清理孤立文件和过期分片上传
+func cmdGC(env *commandEnv, args []string) error {
+	fs := flag.NewFlagSet("gc", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "只统计，不删除")
+	maxUploadAge := fs.Duration("max-upload-age", 24*time.Hour, "未完成分片上传的过期时间")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *maxUploadAge <= 0 {
+		return errors.New("-max-upload-age 必须大于 0")
+	}
+
+	result, err := storage.RunGC(env.filestore, env.metadata, *maxUploadAge, *dryRun)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "孤立文件: %d (%d bytes)\n", result.OrphanCount, result.OrphanSize)
+	fmt.Fprintf(env.stdout, "过期上传: %d (%d bytes)\n", result.ExpiredCount, result.ExpiredPartSize)
+	if *dryRun {
+		fmt.Fprintln(env.stdout, "dry-run：未删除任何内容")
+	} else {
+		fmt.Fprintln(env.stdout, "已清理")
+	}
+	return nil
+}
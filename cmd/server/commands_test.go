@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sss/internal/config"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// setupCommandEnv 在临时目录中创建数据库后按子命令的方式打开，输出写入返回的缓冲
+func setupCommandEnv(t *testing.T) (*commandEnv, string, *bytes.Buffer) {
+	t.Helper()
+	utils.InitLogger("warn")
+	// openCommandEnv 会替换全局配置
+	saved := config.Global
+	t.Cleanup(func() { config.Global = saved })
+
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "metadata.db")
+	dataPath := filepath.Join(tmpDir, "buckets")
+	metadata, err := storage.NewMetadataStore(dbPath)
+	if err != nil {
+		t.Fatalf("创建数据库失败: %v", err)
+	}
+	metadata.Close()
+
+	env, closeEnv, err := openCommandEnv(dbPath, dataPath, "prefix")
+	if err != nil {
+		t.Fatalf("打开运行环境失败: %v", err)
+	}
+	t.Cleanup(closeEnv)
+	out := &bytes.Buffer{}
+	env.stdout = out
+	env.stdin = strings.NewReader("")
+	return env, dataPath, out
+}
+
+// TestParseSharedFlags 测试共享参数与子命令参数的拆分
+func TestParseSharedFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantDB   string
+		wantRest []string
+		wantErr  bool
+	}{
+		{"无参数", nil, "default.db", nil, false},
+		{"空格分隔的值", []string{"-db", "x.db", "-public", "b"}, "x.db", []string{"-public", "b"}, false},
+		{"等号形式", []string{"--db=y.db", "b"}, "y.db", []string{"b"}, false},
+		{"子命令参数在前", []string{"-dry-run", "-db", "z.db"}, "z.db", []string{"-dry-run"}, false},
+		{"共享参数缺少值", []string{"-db"}, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(&bytes.Buffer{})
+			db := fs.String("db", "default.db", "")
+			rest, err := parseSharedFlags(fs, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *db != tt.wantDB || strings.Join(rest, " ") != strings.Join(tt.wantRest, " ") {
+				t.Errorf("db=%s rest=%v, 期望 db=%s rest=%v", *db, rest, tt.wantDB, tt.wantRest)
+			}
+		})
+	}
+}
+
+// TestOpenCommandEnvMissingDB 测试数据库不存在时报错而不是新建
+func TestOpenCommandEnvMissingDB(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "missing.db")
+	if _, _, err := openCommandEnv(dbPath, t.TempDir(), "prefix"); err == nil {
+		t.Fatal("数据库不存在时应返回错误")
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Error("不应创建数据库文件")
+	}
+}
+
+// TestRunSubcommand 测试子命令分发的退出码
+func TestRunSubcommand(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.db")
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"帮助", []string{"help"}, 0},
+		{"未知子命令", []string{"no-such-command"}, 2},
+		{"数据库不存在", []string{"list-keys", "-db", missing}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runSubcommand(tt.args); got != tt.want {
+				t.Errorf("退出码 %d, 期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCmdCreateBucket 测试 create-bucket 子命令
+func TestCmdCreateBucket(t *testing.T) {
+	env, dataPath, out := setupCommandEnv(t)
+	if err := env.metadata.CreateBucket("existing-bucket"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantErr    string
+		wantPublic bool
+	}{
+		{"私有桶", []string{"private-bucket"}, "", false},
+		{"公有桶", []string{"-public", "public-bucket"}, "", true},
+		{"缺少桶名", nil, "需要指定一个桶名", false},
+		{"多个桶名", []string{"a-bucket", "b-bucket"}, "需要指定一个桶名", false},
+		{"无效桶名", []string{"Invalid_Bucket"}, "无效的桶名", false},
+		{"桶已存在", []string{"existing-bucket"}, "桶已存在", false},
+		{"未知参数", []string{"-unknown", "x-bucket"}, "flag provided but not defined", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			err := cmdCreateBucket(env, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("应返回错误 %q: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("创建桶失败: %v", err)
+			}
+			name := tt.args[len(tt.args)-1]
+			b, _ := env.metadata.GetBucket(name)
+			if b == nil || b.IsPublic != tt.wantPublic {
+				t.Fatalf("桶状态错误: %+v", b)
+			}
+			if info, err := os.Stat(filepath.Join(dataPath, name)); err != nil || !info.IsDir() {
+				t.Errorf("应创建存储目录: %v", err)
+			}
+			if !strings.Contains(out.String(), name) {
+				t.Errorf("输出错误: %s", out.String())
+			}
+		})
+	}
+}
+
+// TestCmdListKeys 测试 list-keys 子命令
+func TestCmdListKeys(t *testing.T) {
+	env, _, out := setupCommandEnv(t)
+
+	t.Run("无 Key 时只输出表头", func(t *testing.T) {
+		out.Reset()
+		if err := cmdListKeys(env, nil); err != nil {
+			t.Fatalf("列出失败: %v", err)
+		}
+		if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], "ACCESS KEY ID") {
+			t.Errorf("输出错误: %q", out.String())
+		}
+	})
+
+	key, err := env.metadata.CreateAPIKey("backup job")
+	if err != nil {
+		t.Fatalf("创建 API Key 失败: %v", err)
+	}
+	env.metadata.SetAPIKeyPermission(&storage.APIKeyPermission{AccessKeyID: key.AccessKeyID, BucketName: "logs", CanRead: true, CanWrite: true})
+	env.metadata.SetAPIKeyPermission(&storage.APIKeyPermission{AccessKeyID: key.AccessKeyID, BucketName: "public", CanRead: true})
+
+	t.Run("列出权限且不输出 Secret", func(t *testing.T) {
+		out.Reset()
+		if err := cmdListKeys(env, nil); err != nil {
+			t.Fatalf("列出失败: %v", err)
+		}
+		got := out.String()
+		for _, want := range []string{key.AccessKeyID, "logs:rw", "public:r", "backup job"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("输出缺少 %q: %s", want, got)
+			}
+		}
+		if strings.Contains(got, key.SecretAccessKey) {
+			t.Error("不应输出 Secret")
+		}
+	})
+
+	t.Run("多余参数", func(t *testing.T) {
+		if err := cmdListKeys(env, []string{"extra"}); err == nil || !strings.Contains(err.Error(), "多余的参数") {
+			t.Errorf("应返回错误: %v", err)
+		}
+	})
+}
+
+// TestCmdResetPassword 测试 reset-password 子命令
+func TestCmdResetPassword(t *testing.T) {
+	env, _, out := setupCommandEnv(t)
+	const oldPassword = "OldPassw0rd"
+
+	t.Run("未安装时拒绝", func(t *testing.T) {
+		if err := cmdResetPassword(env, []string{"-password", "NewPassw0rd"}); err == nil || !strings.Contains(err.Error(), "尚未安装") {
+			t.Errorf("应返回错误: %v", err)
+		}
+		if env.metadata.VerifyAdminPassword("NewPassw0rd") {
+			t.Error("未安装时不应设置密码")
+		}
+	})
+
+	if err := env.metadata.SetAdminPassword(oldPassword); err != nil {
+		t.Fatalf("设置密码失败: %v", err)
+	}
+	if err := env.metadata.SetInstalled(); err != nil {
+		t.Fatalf("设置安装状态失败: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		password string // 期望生效的密码
+		wantErr  bool
+	}{
+		{"参数指定密码", []string{"-password", "FlagPassw0rd"}, "", "FlagPassw0rd", false},
+		{"从标准输入读取", nil, "StdinPassw0rd\r\n", "StdinPassw0rd", false},
+		{"标准输入无换行", nil, "EofPassw0rd", "EofPassw0rd", false},
+		{"密码过短", []string{"-password", "Sh0rt"}, "", "", true},
+		{"缺少大写字母", []string{"-password", "lowercase1"}, "", "", true},
+		{"标准输入为空", nil, "", "", true},
+		{"未知参数", []string{"-unknown"}, "", "", true},
+	}
+	current := oldPassword
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			env.stdin = strings.NewReader(tt.stdin)
+			err := cmdResetPassword(env, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("应返回错误")
+				}
+				if !env.metadata.VerifyAdminPassword(current) {
+					t.Error("失败时原密码应保持有效")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("重置密码失败: %v", err)
+			}
+			if !env.metadata.VerifyAdminPassword(tt.password) {
+				t.Error("新密码应生效")
+			}
+			if env.metadata.VerifyAdminPassword(current) {
+				t.Error("旧密码应失效")
+			}
+			if !strings.Contains(out.String(), "密码已重置") {
+				t.Errorf("输出错误: %s", out.String())
+			}
+			current = tt.password
+		})
+	}
+}
+
+// TestCmdGC 测试 gc 子命令
+func TestCmdGC(t *testing.T) {
+	env, dataPath, out := setupCommandEnv(t)
+	if err := env.metadata.CreateBucket("gc-bucket"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+	if err := env.filestore.CreateBucket("gc-bucket"); err != nil {
+		t.Fatalf("创建存储目录失败: %v", err)
+	}
+	orphan := filepath.Join(dataPath, "gc-bucket", "orphan.txt")
+	if err := os.WriteFile(orphan, []byte("orphan"), 0644); err != nil {
+		t.Fatalf("写入孤立文件失败: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantErr    string
+		wantOut    string
+		wantOrphan bool // 执行后孤立文件是否仍存在
+	}{
+		{"过期时间无效", []string{"-max-upload-age", "0s"}, "必须大于 0", "", true},
+		{"未知参数", []string{"-unknown"}, "flag provided but not defined", "", true},
+		{"dry-run 只统计", []string{"-dry-run"}, "", "孤立文件: 1 (6 bytes)", true},
+		{"清理孤立文件", nil, "", "已清理", false},
+		{"再次执行无可清理内容", nil, "", "孤立文件: 0 (0 bytes)", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			err := cmdGC(env, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("应返回错误 %q: %v", tt.wantErr, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("gc 失败: %v", err)
+				}
+				if !strings.Contains(out.String(), tt.wantOut) {
+					t.Errorf("输出缺少 %q: %s", tt.wantOut, out.String())
+				}
+			}
+			if _, err := os.Stat(orphan); (err == nil) != tt.wantOrphan {
+				t.Errorf("孤立文件存在状态错误: %v", err)
+			}
+		})
+	}
+}
@@ -2,12 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
-	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -19,20 +20,65 @@ import (
 )
 
 func main() {
+	// 离线管理子命令（如 sss create-bucket），执行后直接退出
+	if isSubcommand(os.Args[1:]) {
+		os.Exit(runSubcommand(os.Args[1:]))
+	}
+
 	// 命令行参数（运行时不可修改的配置）
-	host := flag.String("host", "0.0.0.0", "监听地址")
+	host := flag.String("host", "0.0.0.0", "监听地址，IPv6 如 :: 或 [::1]")
 	port := flag.Int("port", 8080, "监听端口")
+	network := flag.String("network", config.NetworkDualStack, "监听地址族 (tcp 双栈/tcp4/tcp6)，通配地址在 tcp 下同时接受 IPv4 和 IPv6")
 	dbPath := flag.String("db", "./data/metadata.db", "数据库路径")
 	dataPath := flag.String("data", "./data/buckets", "数据存储路径")
 	logLevel := flag.String("log", "info", "日志级别 (debug/info/warn/error)")
+	pathLayout := flag.String("layout", "prefix", "对象文件路径布局 (prefix/hashed)，仅影响新写入的对象")
+	encryptionKeyFile := flag.String("encryption-key-file", "", "对象静态加密口令文件，指定后新写入的对象以 AES-256 加密保存，口令不可更换")
+	tlsCert := flag.String("tls-cert", "", "TLS 证书文件路径（与 -tls-key 同时指定时启用 HTTPS）")
+	tlsKey := flag.String("tls-key", "", "TLS 私钥文件路径")
+	tlsMinVersion := flag.String("tls-min-version", "1.2", "最低 TLS 版本 (1.2/1.3)")
+	tlsCiphers := flag.String("tls-ciphers", "", "TLS 1.2 加密套件白名单，逗号分隔（如 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256）")
+	http2 := flag.Bool("http2", true, "启用 HTTP/2（TLS 连接）")
+	h2c := flag.Bool("h2c", false, "在明文连接上接受 HTTP/2（h2c），用于反向代理之后")
+	http2MaxStreams := flag.Int("http2-max-streams", config.DefaultHTTP2MaxStreams, "每个 HTTP/2 连接的最大并发流数量")
+	compression := flag.String("compression", config.DefaultCompression, "启用的响应压缩算法 (br/gzip)，按优先级逗号分隔，none 关闭压缩")
+	gzipLevel := flag.Int("gzip-level", config.DefaultGzipLevel, "gzip 压缩级别 (1-9)")
+	brotliLevel := flag.Int("brotli-level", config.DefaultBrotliLevel, "brotli 压缩级别 (0-11)")
+	vhostDomains := flag.String("vhost-domains", "", "虚拟主机风格访问的域名，逗号分隔（如 s3.example.com），<bucket>.<domain> 的请求按该桶处理")
+	drainTimeout := flag.Duration("drain-timeout", storage.DefaultDrainTimeout, "关闭时等待后台任务（复制队列、迁移等）退出的最长时间")
+	maxConns := flag.Int("max-conns", 0, "最大并发连接数，超出的连接上的请求返回 503，0 表示不限制")
+	maxConnsPerIP := flag.Int("max-conns-per-ip", 0, "单个客户端 IP 的最大并发连接数（按 TCP 直连地址），0 表示不限制")
+	minDownloadRate := flag.Int64("min-download-rate", 0, "下载最低速度（字节/秒），客户端在 -min-download-window 内低于该速度时中止下载，0 表示不检查")
+	minDownloadWindow := flag.Duration("min-download-window", config.DefaultMinDownloadWindow, "计算下载最低速度的时间窗口")
+	relayout := flag.Bool("relayout", false, "把已有对象文件迁移到 -layout 指定的布局后退出（需先停止服务，可中断后重新执行）")
+	relayoutDryRun := flag.Bool("relayout-dry-run", false, "与 -relayout 一起使用，只统计待迁移对象，不移动文件")
 	flag.Parse()
 
 	// 1. 创建默认配置并应用命令行参数
 	cfg := config.NewDefault()
 	cfg.Server.Host = *host
 	cfg.Server.Port = *port
+	cfg.Server.Network = *network
+	cfg.Server.TLSCert = *tlsCert
+	cfg.Server.TLSKey = *tlsKey
+	cfg.Server.TLSMinVersion = *tlsMinVersion
+	cfg.Server.TLSCipherSuites = *tlsCiphers
+	cfg.Server.HTTP2 = *http2
+	cfg.Server.H2C = *h2c
+	cfg.Server.HTTP2MaxStreams = *http2MaxStreams
+	cfg.Server.Compression = *compression
+	cfg.Server.GzipLevel = *gzipLevel
+	cfg.Server.BrotliLevel = *brotliLevel
+	cfg.Server.VirtualHostDomains = *vhostDomains
+	cfg.Server.DrainTimeout = *drainTimeout
+	cfg.Server.MaxConns = *maxConns
+	cfg.Server.MaxConnsPerIP = *maxConnsPerIP
+	cfg.Server.MinDownloadRate = *minDownloadRate
+	cfg.Server.MinDownloadWindow = *minDownloadWindow
 	cfg.Storage.DBPath = *dbPath
 	cfg.Storage.DataPath = *dataPath
+	cfg.Storage.PathLayout = *pathLayout
+	cfg.Storage.EncryptionKeyFile = *encryptionKeyFile
 	cfg.Log.Level = *logLevel
 
 	// 初始化日志
@@ -77,6 +123,24 @@ func main() {
 		utils.Error("初始化文件存储失败", "error", err)
 		os.Exit(1)
 	}
+	if err := filestore.SetPathLayout(config.Global.Storage.PathLayout); err != nil {
+		utils.Error("无效的路径布局", "layout", config.Global.Storage.PathLayout, "error", err)
+		os.Exit(1)
+	}
+	if err := setupEncryption(filestore, metadata, config.Global.Storage.EncryptionKeyFile); err != nil {
+		utils.Error("初始化对象静态加密失败", "error", err)
+		os.Exit(1)
+	}
+
+	// 5.1 离线迁移已有对象的路径布局，完成后退出
+	if *relayout {
+		code := runRelayout(filestore, metadata, *relayoutDryRun)
+		metadata.Close()
+		os.Exit(code)
+	}
+
+	// 5.2 初始化生命周期过期服务
+	storage.InitLifecycleService(metadata, filestore, time.Duration(config.Global.Storage.LifecycleInterval)*time.Minute)
 
 	// 6. 初始化 API Key 缓存
 	auth.InitAPIKeyCache(metadata)
@@ -85,8 +149,17 @@ func main() {
 	// 7. 创建服务器
 	server := api.NewServer(metadata, filestore)
 
+	// 7.1 启动后台任务，统一在关闭时停止
+	jobs := storage.NewBackgroundJobs()
+	server.StartBackgroundJobs(jobs)
+	jobs.OnShutdown("geo-stats", func(ctx context.Context) error {
+		// 刷新 GeoStats 缓冲区
+		storage.GetGeoStatsService().Stop()
+		return nil
+	})
+
 	// 8. 显示启动信息
-	addr := fmt.Sprintf("%s:%d", config.Global.Server.Host, config.Global.Server.Port)
+	addr := config.Global.Server.ListenAddr()
 
 	if metadata.IsInstalled() {
 		utils.Info("系统已安装", "admin", config.Global.Auth.AdminUsername)
@@ -98,19 +171,71 @@ func main() {
 	}
 
 	// 9. 启动 HTTP 服务（带超时设置）
-	// 使用 gzip 中间件包装 server，对文本资源进行压缩
+	// 使用压缩中间件包装 server，按客户端支持的算法对文本资源进行压缩
+	algorithms, err := config.Global.Server.CompressionAlgorithms()
+	if err != nil {
+		utils.Error("响应压缩配置无效", "error", err)
+		os.Exit(1)
+	}
 	httpServer := &http.Server{
-		Addr:         addr,
-		Handler:      utils.GzipHandler(server),
+		Addr: addr,
+		Handler: utils.CompressHandler(server, utils.CompressionOptions{
+			Algorithms:  algorithms,
+			GzipLevel:   config.Global.Server.GzipLevel,
+			BrotliLevel: config.Global.Server.BrotliLevel,
+		}),
 		ReadTimeout:  60 * time.Second,
 		WriteTimeout: 60 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
-	// 启动服务器（非阻塞）
+	// 9.1 HTTP/2 与 h2c
+	if err := config.Global.Server.ApplyHTTPProtocols(httpServer); err != nil {
+		utils.Error("HTTP/2 配置无效", "error", err)
+		os.Exit(1)
+	}
+
+	// 9.2 内置 TLS（弱配置拒绝启动）
+	useTLS := config.Global.Server.TLSEnabled()
+	if useTLS {
+		tlsConfig, err := config.Global.Server.BuildTLSConfig()
+		if err != nil {
+			utils.Error("TLS 配置无效", "error", err)
+			os.Exit(1)
+		}
+		httpServer.TLSConfig = tlsConfig
+	}
+
+	// 9.3 先绑定端口，地址或地址族无效时直接退出
+	if err := config.Global.Server.ValidateConnLimits(); err != nil {
+		utils.Error("连接保护配置无效", "error", err)
+		os.Exit(1)
+	}
+	listener, err := config.Global.Server.Listen()
+	if err != nil {
+		utils.Error("监听失败", "address", addr, "network", config.Global.Server.Network, "error", err)
+		os.Exit(1)
+	}
+
+	// 9.4 并发连接数上限，超出的连接上的请求返回 503
+	listener = utils.LimitConnections(httpServer, listener, config.Global.Server.MaxConns, config.Global.Server.MaxConnsPerIP)
+	if config.Global.Server.MaxConns > 0 || config.Global.Server.MaxConnsPerIP > 0 {
+		utils.Info("连接数上限已启用", "max_conns", config.Global.Server.MaxConns, "max_conns_per_ip", config.Global.Server.MaxConnsPerIP)
+	}
+
+	// 启动服务器（非阻塞），初始化已完成，就绪探针开始返回 200
+	server.SetReady(true)
 	go func() {
-		utils.Info("服务器启动", "address", addr, "region", config.Global.Server.Region)
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		utils.Info("服务器启动", "address", listener.Addr().String(), "network", config.Global.Server.Network,
+			"region", config.Global.Server.Region, "tls", useTLS,
+			"http2", config.Global.Server.HTTP2, "h2c", config.Global.Server.H2C)
+		var err error
+		if useTLS {
+			err = httpServer.ServeTLS(listener, "", "")
+		} else {
+			err = httpServer.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			utils.Error("服务器异常", "error", err)
 			os.Exit(1)
 		}
@@ -121,6 +246,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-quit
 	utils.Info("收到终止信号，正在关闭服务器...", "signal", sig.String())
+	server.SetReady(false)
 
 	// 11. 优雅关闭（等待最多 30 秒处理完当前请求）
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -131,8 +257,59 @@ func main() {
 		os.Exit(1)
 	}
 
-	// 停止 GeoStats 服务（刷新缓冲区）
-	storage.GetGeoStatsService().Stop()
+	// 12. 停止后台任务，等待复制队列等排空
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), config.Global.Server.DrainTimeout)
+	defer drainCancel()
+	if err := jobs.Shutdown(drainCtx); err != nil {
+		utils.Warn("后台任务未能完全停止", "error", err)
+	}
 
 	utils.Info("服务器已安全关闭")
 }
+
+// runRelayout 执行路径布局迁移并输出进度，返回进程退出码
+func runRelayout(filestore *storage.FileStore, metadata *storage.MetadataStore, dryRun bool) int {
+	utils.Info("开始迁移对象路径布局", "layout", filestore.PathLayout(), "dry_run", dryRun)
+	result, err := storage.RelayoutObjects(filestore, metadata, dryRun, func(done, total int) {
+		if done%1000 == 0 || done == total {
+			utils.Info("迁移进度", "done", done, "total", total)
+		}
+	})
+	if err != nil {
+		utils.Error("路径布局迁移失败", "error", err)
+		return 1
+	}
+	for _, e := range result.Errors {
+		utils.Warn("对象迁移失败", "detail", e)
+	}
+	utils.Info("路径布局迁移完成", "total", result.Total, "moved", result.Moved,
+		"skipped", result.Skipped, "failed", result.Failed, "dry_run", dryRun)
+	if result.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// setupEncryption 从口令文件启用对象静态加密，并核对口令与之前使用的是否一致
+// 口令从文件读取，避免出现在进程参数中
+func setupEncryption(filestore *storage.FileStore, metadata *storage.MetadataStore, keyFile string) error {
+	if keyFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	passphrase := strings.TrimSpace(string(data))
+	if passphrase == "" {
+		return errors.New("encryption key file is empty")
+	}
+	if err := filestore.SetEncryptionPassphrase(passphrase); err != nil {
+		return err
+	}
+	if err := metadata.CheckEncryptionKey(filestore); err != nil {
+		return err
+	}
+	utils.Info("对象静态加密已启用")
+	return nil
+}
@@ -1,15 +1,20 @@
 package admin
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/json"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"sss/internal/auth"
 	"sss/internal/config"
 	"sss/internal/storage"
 	"sss/internal/utils"
@@ -70,16 +75,42 @@ func TestHandleAPIKeys(t *testing.T) {
 			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusOK, rec.Code)
 		}
 
-		var keys []APIKeyResponse
-		if err := json.Unmarshal(rec.Body.Bytes(), &keys); err != nil {
+		var resp APIKeyListResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
 			t.Fatalf("解析响应失败: %v", err)
 		}
 
-		if len(keys) == 0 {
+		if len(resp.Keys) == 0 || resp.Total == 0 {
 			t.Error("应该至少有一个密钥")
 		}
 	})
 
+	t.Run("分页与筛选", func(t *testing.T) {
+		token := sessionStore.CreateSession()
+		for i := 0; i < 3; i++ {
+			handler.metadata.CreateAPIKey("paged key")
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/apikeys?description=paged&enabled=true&page=2&limit=2", nil)
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+		handler.handleAPIKeys(rec, req)
+
+		var resp APIKeyListResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if resp.Total != 3 || len(resp.Keys) != 1 || resp.Page != 2 || resp.Limit != 2 {
+			t.Errorf("分页结果错误: %+v", resp)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/admin/apikeys?sort=secret", nil)
+		req.Header.Set("X-Admin-Token", token)
+		rec = httptest.NewRecorder()
+		handler.handleAPIKeys(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("无效排序字段应返回400: %d", rec.Code)
+		}
+	})
+
 	t.Run("无效方法返回405", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		req := httptest.NewRequest(http.MethodPatch, "/api/admin/apikeys", nil)
@@ -116,6 +147,28 @@ func TestHandleAPIKeyDetail(t *testing.T) {
 		}
 	})
 
+	t.Run("详情包含预签名用量", func(t *testing.T) {
+		handler.metadata.ConsumePresignQuota(key.AccessKeyID, 2, 10, time.Hour, time.Now())
+		oldWindow := config.Global.Security.PresignWindowMinutes
+		config.Global.Security.PresignWindowMinutes = 60
+		defer func() { config.Global.Security.PresignWindowMinutes = oldWindow }()
+
+		token := sessionStore.CreateSession()
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/apikeys/"+key.AccessKeyID, nil)
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+
+		handler.handleAPIKeyDetail(rec, req, key.AccessKeyID)
+
+		var resp APIKeyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if resp.PresignUsage == nil || resp.PresignUsage.Count != 2 {
+			t.Errorf("预签名用量应为 2: %+v", resp.PresignUsage)
+		}
+	})
+
 	t.Run("更新密钥描述", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		newDesc := "updated description"
@@ -139,6 +192,62 @@ func TestHandleAPIKeyDetail(t *testing.T) {
 		}
 	})
 
+	t.Run("设置命名空间", func(t *testing.T) {
+		token := sessionStore.CreateSession()
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/apikeys/"+key.AccessKeyID, bytes.NewBufferString(`{"namespace":"tenant-a-"}`))
+		req.Header.Set("X-Admin-Token", token)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.handleAPIKeyDetail(rec, req, key.AccessKeyID)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: 期望 %d, 实际 %d", http.StatusOK, rec.Code)
+		}
+		var resp APIKeyResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if resp.Namespace != "tenant-a-" {
+			t.Errorf("命名空间未更新: 实际 %q", resp.Namespace)
+		}
+
+		// 非法命名空间
+		req = httptest.NewRequest(http.MethodPut, "/api/admin/apikeys/"+key.AccessKeyID, bytes.NewBufferString(`{"namespace":"Tenant/A"}`))
+		req.Header.Set("X-Admin-Token", token)
+		rec = httptest.NewRecorder()
+		handler.handleAPIKeyDetail(rec, req, key.AccessKeyID)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("非法命名空间应返回 400, 实际 %d", rec.Code)
+		}
+
+		// 与已有命名空间互为前缀
+		req = httptest.NewRequest(http.MethodPost, "/api/admin/apikeys", bytes.NewBufferString(`{"description":"b","namespace":"tenant-a-b"}`))
+		req.Header.Set("X-Admin-Token", token)
+		rec = httptest.NewRecorder()
+		handler.handleAPIKeys(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("互为前缀的命名空间应返回 409, 实际 %d", rec.Code)
+		}
+		req = httptest.NewRequest(http.MethodPost, "/api/admin/apikeys", bytes.NewBufferString(`{"description":"b","namespace":"tenant-ab"}`))
+		req.Header.Set("X-Admin-Token", token)
+		rec = httptest.NewRecorder()
+		handler.handleAPIKeys(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("不冲突的命名空间应创建成功, 实际 %d", rec.Code)
+		}
+		var created APIKeyResponse
+		json.Unmarshal(rec.Body.Bytes(), &created)
+		handler.metadata.DeleteAPIKey(created.AccessKeyID)
+
+		// 清空命名空间，避免影响后续用例
+		req = httptest.NewRequest(http.MethodPut, "/api/admin/apikeys/"+key.AccessKeyID, bytes.NewBufferString(`{"namespace":""}`))
+		req.Header.Set("X-Admin-Token", token)
+		rec = httptest.NewRecorder()
+		handler.handleAPIKeyDetail(rec, req, key.AccessKeyID)
+		if k, _ := handler.metadata.GetAPIKey(key.AccessKeyID); k == nil || k.Namespace != "" {
+			t.Errorf("命名空间应已清空")
+		}
+	})
+
 	t.Run("禁用密钥", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		body := `{"enabled":false}`
@@ -219,6 +328,57 @@ func TestHandleAPIKeyDetail(t *testing.T) {
 		}
 	})
 
+	t.Run("测试密钥有效权限", func(t *testing.T) {
+		auth.InitAPIKeyCache(handler.metadata)
+		handler.metadata.CreateBucket("keytest-readable")
+		handler.metadata.CreateBucket("keytest-hidden")
+		testKey, _ := handler.metadata.CreateAPIKey("round trip key")
+		handler.metadata.SetAPIKeyPermission(&storage.APIKeyPermission{
+			AccessKeyID: testKey.AccessKeyID,
+			BucketName:  "keytest-readable",
+			CanRead:     true,
+		})
+		auth.ReloadAPIKeyCache()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/apikeys/"+testKey.AccessKeyID+"/test", nil)
+		rec := httptest.NewRecorder()
+		handler.handleAPIKeyDetail(rec, req, testKey.AccessKeyID+"/test")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: 期望 %d, 实际 %d, body: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp APIKeyTestResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if !resp.SignatureOK {
+			t.Error("启用的密钥应通过签名往返验证")
+		}
+		if len(resp.Buckets) != 1 || resp.Buckets[0].Bucket != "keytest-readable" ||
+			!resp.Buckets[0].CanRead || resp.Buckets[0].CanWrite {
+			t.Errorf("有效权限不正确: %+v", resp.Buckets)
+		}
+
+		// 禁用后签名验证失败，且不再有任何桶权限
+		handler.metadata.UpdateAPIKeyEnabled(testKey.AccessKeyID, false)
+		auth.ReloadAPIKeyCache()
+		rec = httptest.NewRecorder()
+		handler.handleAPIKeyDetail(rec, req, testKey.AccessKeyID+"/test")
+		resp = APIKeyTestResponse{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if resp.SignatureOK || resp.Enabled || len(resp.Buckets) != 0 {
+			t.Errorf("禁用的密钥不应通过验证: %+v", resp)
+		}
+
+		// 指定不存在的桶
+		req = httptest.NewRequest(http.MethodPost, "/api/admin/apikeys/"+testKey.AccessKeyID+"/test?bucket=missing", nil)
+		rec = httptest.NewRecorder()
+		handler.handleAPIKeyDetail(rec, req, testKey.AccessKeyID+"/test")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
 	t.Run("删除密钥", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		// 创建一个新密钥用于删除
@@ -490,6 +650,96 @@ func TestAdminObjectsHandler(t *testing.T) {
 			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusOK, rec.Code)
 		}
 	})
+
+	t.Run("带delimiter返回prefixes", func(t *testing.T) {
+		for _, key := range []string{"root.txt", "docs/a.txt", "docs/sub/b.txt", "images/c.png"} {
+			handler.metadata.PutObject(&storage.Object{Bucket: "obj-test-bucket", Key: key, Size: 1, LastModified: time.Now()})
+		}
+
+		list := func(query string) ([]string, []string) {
+			token := sessionStore.CreateSession()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/buckets/obj-test-bucket/objects"+query, nil)
+			req.Header.Set("X-Admin-Token", token)
+			rec := httptest.NewRecorder()
+			handler.adminObjectsHandler(rec, req, "obj-test-bucket")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("状态码错误: 期望 %d, 实际 %d", http.StatusOK, rec.Code)
+			}
+			var resp struct {
+				Objects  []AdminObjectInfo `json:"objects"`
+				Prefixes []string          `json:"prefixes"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			keys := make([]string, 0, len(resp.Objects))
+			for _, o := range resp.Objects {
+				keys = append(keys, o.Key)
+			}
+			return keys, resp.Prefixes
+		}
+
+		keys, prefixes := list("?delimiter=/")
+		if strings.Join(keys, ",") != "root.txt" || strings.Join(prefixes, ",") != "docs/,images/" {
+			t.Errorf("根目录分组错误: objects=%v prefixes=%v", keys, prefixes)
+		}
+
+		keys, prefixes = list("?prefix=docs/&delimiter=/")
+		if strings.Join(keys, ",") != "docs/a.txt" || strings.Join(prefixes, ",") != "docs/sub/" {
+			t.Errorf("子目录分组错误: objects=%v prefixes=%v", keys, prefixes)
+		}
+
+		// 默认平铺
+		keys, prefixes = list("")
+		if len(keys) != 4 || prefixes == nil || len(prefixes) != 0 {
+			t.Errorf("平铺列出错误: objects=%v prefixes=%v", keys, prefixes)
+		}
+	})
+
+	t.Run("按大小降序排序", func(t *testing.T) {
+		for key, size := range map[string]int64{"sorted/a": 30, "sorted/b": 10, "sorted/c": 20} {
+			handler.metadata.PutObject(&storage.Object{Bucket: "obj-test-bucket", Key: key, Size: size, LastModified: time.Now()})
+		}
+
+		token := sessionStore.CreateSession()
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/buckets/obj-test-bucket/objects?prefix=sorted/&sort=size&order=desc", nil)
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+		handler.adminObjectsHandler(rec, req, "obj-test-bucket")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: 期望 %d, 实际 %d", http.StatusOK, rec.Code)
+		}
+
+		var resp struct {
+			Objects []AdminObjectInfo `json:"objects"`
+			Sort    string            `json:"sort"`
+			Order   string            `json:"order"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		keys := make([]string, 0, len(resp.Objects))
+		for _, o := range resp.Objects {
+			keys = append(keys, o.Key)
+		}
+		if strings.Join(keys, ",") != "sorted/a,sorted/c,sorted/b" {
+			t.Errorf("排序结果错误: %v", keys)
+		}
+		if resp.Sort != "size" || resp.Order != "desc" {
+			t.Errorf("响应排序字段错误: sort=%s order=%s", resp.Sort, resp.Order)
+		}
+	})
+
+	t.Run("无效排序字段", func(t *testing.T) {
+		token := sessionStore.CreateSession()
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/buckets/obj-test-bucket/objects?sort=etag", nil)
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+		handler.adminObjectsHandler(rec, req, "obj-test-bucket")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusBadRequest, rec.Code)
+		}
+	})
 }
 
 func TestAdminDeleteObject(t *testing.T) {
@@ -569,6 +819,267 @@ func TestAdminDeleteObject(t *testing.T) {
 	})
 }
 
+// TestAdminDeleteObjectUndo 测试删除撤销窗口与一次性撤销令牌
+func TestAdminDeleteObjectUndo(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+	oldGrace := config.Global.Storage.DeleteGraceSeconds
+	config.Global.Storage.DeleteGraceSeconds = 60
+	defer func() { config.Global.Storage.DeleteGraceSeconds = oldGrace }()
+
+	bucketName := "undo-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	handler.filestore.CreateBucket(bucketName)
+	put := func(content string) *storage.Object {
+		storagePath, etag, _ := handler.filestore.PutObject(bucketName, "doc.txt", strings.NewReader(content), int64(len(content)))
+		obj := &storage.Object{Bucket: bucketName, Key: "doc.txt", Size: int64(len(content)), ETag: etag,
+			ContentType: "text/plain", LastModified: time.Now(), StoragePath: storagePath}
+		handler.metadata.PutObject(obj)
+		return obj
+	}
+	del := func() string {
+		rec := httptest.NewRecorder()
+		handler.adminDeleteObject(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/buckets/"+bucketName+"/objects?key=doc.txt", nil), bucketName)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("删除失败: %d", rec.Code)
+		}
+		var resp struct {
+			UndoToken string `json:"undo_token"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if resp.UndoToken == "" {
+			t.Fatal("开启撤销窗口时应返回 undo_token")
+		}
+		return resp.UndoToken
+	}
+	undo := func(bucket, token string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/buckets/"+bucket+"/undo-delete", strings.NewReader(`{"token":"`+token+`"}`))
+		handler.adminUndoDelete(rec, req, bucket)
+		return rec.Code
+	}
+
+	obj := put("version one")
+	token := del()
+	if got, _ := handler.metadata.GetObject(bucketName, "doc.txt"); got != nil {
+		t.Error("删除后对象应立即不可见")
+	}
+	if _, err := os.Stat(obj.StoragePath); err != nil {
+		t.Fatalf("撤销窗口内文件应保留: %v", err)
+	}
+
+	if code := undo("other-bucket", token); code != http.StatusNotFound {
+		t.Errorf("其他桶使用令牌应返回 404: %d", code)
+	}
+	if code := undo(bucketName, token); code != http.StatusOK {
+		t.Fatalf("撤销应成功: %d", code)
+	}
+	if got, _ := handler.metadata.GetObject(bucketName, "doc.txt"); got == nil || got.ETag != obj.ETag {
+		t.Fatalf("撤销后对象应恢复: %+v", got)
+	}
+	if code := undo(bucketName, token); code != http.StatusNotFound {
+		t.Errorf("令牌只能使用一次: %d", code)
+	}
+
+	// 窗口结束后文件被删除，令牌失效
+	token = del()
+	if err := handler.ShutdownPendingDeletes(context.Background()); err != nil {
+		t.Fatalf("完成待删除对象失败: %v", err)
+	}
+	if _, err := os.Stat(obj.StoragePath); !os.IsNotExist(err) {
+		t.Errorf("窗口结束后文件应被删除: %v", err)
+	}
+	if code := undo(bucketName, token); code != http.StatusNotFound {
+		t.Errorf("过期令牌应返回 404: %d", code)
+	}
+
+	// 窗口内同名对象被重新写入：不能撤销，也不能删除新文件
+	put("version one")
+	token = del()
+	newObj := put("version two")
+	if code := undo(bucketName, token); code != http.StatusConflict {
+		t.Errorf("同名对象已重新写入时撤销应返回 409: %d", code)
+	}
+	token = del()
+	put("version three")
+	handler.ShutdownPendingDeletes(context.Background())
+	if _, err := os.Stat(newObj.StoragePath); err != nil {
+		t.Errorf("重新写入的文件不应被删除: %v", err)
+	}
+}
+
+// TestAdminBucketContentTypes 测试桶内容类型限制配置与管理员上传校验
+func TestAdminBucketContentTypes(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "ct-test-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	handler.filestore.CreateBucket(bucketName)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/buckets/"+bucketName+"/content-types", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/content-types")
+		return rec
+	}
+
+	t.Run("默认不限制", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/buckets/"+bucketName+"/content-types", nil)
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/content-types")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d", rec.Code)
+		}
+		var resp map[string]interface{}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if resp["mode"] != "" {
+			t.Errorf("默认模式应为空: %v", resp["mode"])
+		}
+	})
+
+	t.Run("无效配置被拒绝", func(t *testing.T) {
+		if rec := put(`{"mode":"deny","patterns":["image/*"]}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("无效模式应返回400: %d", rec.Code)
+		}
+		if rec := put(`{"mode":"allow","patterns":[]}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("allow模式缺少类型应返回400: %d", rec.Code)
+		}
+		if rec := put(`{"mode":"block","patterns":["html"]}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("无效类型模式应返回400: %d", rec.Code)
+		}
+	})
+
+	t.Run("设置允许列表", func(t *testing.T) {
+		rec := put(`{"mode":"allow","patterns":["Image/*"," text/plain "],"sniff":true}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+		bucket, _ := handler.metadata.GetBucket(bucketName)
+		if bucket.ContentTypeMode != "allow" || bucket.ContentTypes != "image/*,text/plain" || !bucket.ContentTypeSniff {
+			t.Errorf("配置未保存: %+v", bucket)
+		}
+	})
+
+	upload := func(key, contentType string, data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		h := make(map[string][]string)
+		h["Content-Disposition"] = []string{`form-data; name="file"; filename="f"`}
+		h["Content-Type"] = []string{contentType}
+		part, _ := writer.CreatePart(h)
+		part.Write(data)
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/buckets/"+bucketName+"/upload?key="+key, &body)
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		handler.adminUploadObject(rec, req, bucketName)
+		return rec
+	}
+
+	t.Run("允许的类型上传成功", func(t *testing.T) {
+		if rec := upload("notes.txt", "text/plain", []byte("hello")); rec.Code != http.StatusOK {
+			t.Errorf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("不允许的类型被拒绝", func(t *testing.T) {
+		if rec := upload("doc.pdf", "application/pdf", []byte("%PDF-1.4")); rec.Code != http.StatusBadRequest {
+			t.Errorf("状态码错误: 期望 400, 实际 %d", rec.Code)
+		}
+		if obj, _ := handler.metadata.GetObject(bucketName, "doc.pdf"); obj != nil {
+			t.Error("被拒绝的对象不应被保存")
+		}
+	})
+
+	t.Run("嗅探拒绝伪装类型", func(t *testing.T) {
+		if rec := upload("fake.png", "image/png", []byte("<html><body>x</body></html>")); rec.Code != http.StatusBadRequest {
+			t.Errorf("伪装为图片的HTML应被拒绝: %d", rec.Code)
+		}
+	})
+}
+
+// TestAdminBucketKeyDenylist 测试桶保留键配置，管理员上传不受限制
+func TestAdminBucketKeyDenylist(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "deny-test-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	handler.filestore.CreateBucket(bucketName)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/admin/buckets/"+bucketName+"/key-denylist", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/key-denylist")
+		return rec
+	}
+
+	t.Run("无效模式被拒绝", func(t *testing.T) {
+		if rec := do(http.MethodPut, `{"patterns":["[abc"]}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("非法 glob 应返回400: %d", rec.Code)
+		}
+	})
+
+	t.Run("设置并读取", func(t *testing.T) {
+		rec := do(http.MethodPut, `{"patterns":[" index.html ","private/*","index.html",""]}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+		bucket, _ := handler.metadata.GetBucket(bucketName)
+		if bucket.KeyDenylist != "index.html\nprivate/*" {
+			t.Errorf("配置未保存: %q", bucket.KeyDenylist)
+		}
+
+		rec = do(http.MethodGet, "")
+		var resp struct {
+			Patterns []string `json:"patterns"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if len(resp.Patterns) != 2 || resp.Patterns[0] != "index.html" {
+			t.Errorf("读取结果错误: %v", resp.Patterns)
+		}
+	})
+
+	t.Run("管理员上传保留键", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", "index.html")
+		part.Write([]byte("<html></html>"))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/buckets/"+bucketName+"/upload?key=index.html", &body)
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		handler.adminUploadObject(rec, req, bucketName)
+		if rec.Code != http.StatusOK {
+			t.Errorf("管理员上传不应受保留键限制: %d, body: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("清空", func(t *testing.T) {
+		if rec := do(http.MethodPut, `{"patterns":[]}`); rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d", rec.Code)
+		}
+		bucket, _ := handler.metadata.GetBucket(bucketName)
+		if bucket.KeyDenylist != "" {
+			t.Errorf("应已清空: %q", bucket.KeyDenylist)
+		}
+	})
+}
+
 func TestAdminUploadObject(t *testing.T) {
 	handler, cleanup := setupAdminTestHandler(t)
 	defer cleanup()
@@ -698,6 +1209,21 @@ func TestAdminDownloadObject(t *testing.T) {
 		}
 	})
 
+	t.Run("中文文件名", func(t *testing.T) {
+		key := "报告/年度总结.txt"
+		handler.metadata.PutObject(&storage.Object{Bucket: bucketName, Key: key, Size: obj.Size, ETag: etag,
+			ContentType: "text/plain", StoragePath: storagePath})
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/buckets/"+bucketName+"/download?key="+url.QueryEscape(key), nil)
+		rec := httptest.NewRecorder()
+
+		handler.adminDownloadObject(rec, req, bucketName)
+
+		want := `attachment; filename="____.txt"; filename*=UTF-8''%E5%B9%B4%E5%BA%A6%E6%80%BB%E7%BB%93.txt`
+		if got := rec.Header().Get("Content-Disposition"); got != want {
+			t.Errorf("Content-Disposition 错误: got %q, want %q", got, want)
+		}
+	})
+
 	t.Run("缺少key参数", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		req := httptest.NewRequest(http.MethodGet, "/api/admin/buckets/"+bucketName+"/download", nil)
@@ -841,7 +1367,97 @@ func TestBatchDeleteObjects(t *testing.T) {
 	})
 }
 
-func TestBatchDownloadObjects(t *testing.T) {
+// TestBatchTagObjects 测试按 key 列表或前缀批量设置和移除对象标签
+func TestBatchTagObjects(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "batch-tag-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	for _, key := range []string{"logs/a.txt", "logs/b.txt", "data/c.txt"} {
+		handler.metadata.PutObject(&storage.Object{Bucket: bucketName, Key: key, Size: 1, ETag: "e", StoragePath: "/tmp/" + key})
+	}
+	handler.metadata.PutObjectTags(bucketName, "logs/a.txt", map[string]string{"owner": "alice"})
+
+	do := func(action, body string) (*httptest.ResponseRecorder, BatchTagResult) {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/buckets/"+bucketName+"/batch/"+action, bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/batch/"+action)
+		var result BatchTagResult
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		return rec, result
+	}
+	tagsOf := func(key string) map[string]string {
+		obj, _ := handler.metadata.GetObject(bucketName, key)
+		return obj.Tags
+	}
+
+	t.Run("按前缀合并标签", func(t *testing.T) {
+		rec, result := do("tag", `{"prefix":"logs/","tags":{"project":"x","owner":"bob"}}`)
+		if rec.Code != http.StatusOK || result.UpdatedCount != 2 || result.FailedCount != 0 {
+			t.Fatalf("结果错误: %d %s", rec.Code, rec.Body.String())
+		}
+		if tags := tagsOf("logs/a.txt"); len(tags) != 2 || tags["owner"] != "bob" || tags["project"] != "x" {
+			t.Errorf("标签应合并且同名覆盖: %v", tags)
+		}
+		if tags := tagsOf("data/c.txt"); len(tags) != 0 {
+			t.Errorf("前缀外的对象不应修改: %v", tags)
+		}
+	})
+
+	t.Run("按 key 列表并过滤路径遍历", func(t *testing.T) {
+		_, result := do("tag", `{"keys":["data/c.txt","../evil.txt","missing.txt"],"tags":{"cost":"team-a"}}`)
+		if result.UpdatedCount != 1 || result.FailedCount != 2 || len(result.FailedKeys) != 2 {
+			t.Errorf("结果错误: %+v", result)
+		}
+		if tagsOf("data/c.txt")["cost"] != "team-a" {
+			t.Error("标签未设置")
+		}
+	})
+
+	t.Run("参数校验", func(t *testing.T) {
+		for _, body := range []string{
+			`{"tags":{"a":"b"}}`,
+			`{"keys":["data/c.txt"],"prefix":"logs/","tags":{"a":"b"}}`,
+			`{"keys":["data/c.txt"]}`,
+			`{"keys":["data/c.txt"],"tags":{"aws:x":"b"}}`,
+		} {
+			if rec, _ := do("tag", body); rec.Code != http.StatusBadRequest {
+				t.Errorf("%s 应返回400: %d", body, rec.Code)
+			}
+		}
+	})
+
+	t.Run("超过标签数上限的对象失败", func(t *testing.T) {
+		tags := make([]string, 0, storage.MaxObjectTags)
+		for i := 0; i < storage.MaxObjectTags; i++ {
+			tags = append(tags, `"k`+string(rune('a'+i))+`":"v"`)
+		}
+		_, result := do("tag", `{"keys":["data/c.txt"],"tags":{`+strings.Join(tags, ",")+`}}`)
+		if result.UpdatedCount != 0 || result.FailedCount != 1 {
+			t.Errorf("合并后超过 10 个标签应失败: %+v", result)
+		}
+	})
+
+	t.Run("移除指定标签和全部标签", func(t *testing.T) {
+		_, result := do("untag", `{"prefix":"logs/","tag_keys":["owner"]}`)
+		if result.UpdatedCount != 2 {
+			t.Errorf("结果错误: %+v", result)
+		}
+		if tags := tagsOf("logs/a.txt"); len(tags) != 1 || tags["project"] != "x" {
+			t.Errorf("应只移除 owner: %v", tags)
+		}
+		do("untag", `{"keys":["logs/a.txt"]}`)
+		if tags := tagsOf("logs/a.txt"); len(tags) != 0 {
+			t.Errorf("应清除全部标签: %v", tags)
+		}
+	})
+}
+
+func TestBatchDownloadObjects(t *testing.T) {
 	handler, cleanup := setupAdminTestHandler(t)
 	defer cleanup()
 
@@ -887,6 +1503,36 @@ func TestBatchDownloadObjects(t *testing.T) {
 		}
 	})
 
+	t.Run("去掉前缀并处理冲突", func(t *testing.T) {
+		for _, key := range []string{"export/a/report.csv", "export/b/report.csv", "export/summary.txt"} {
+			content := []byte(key)
+			storagePath, etag, _ := handler.filestore.PutObject(bucketName, key, bytes.NewReader(content), int64(len(content)))
+			handler.metadata.PutObject(&storage.Object{
+				Bucket: bucketName, Key: key, Size: int64(len(content)), ETag: etag, StoragePath: storagePath,
+			})
+		}
+
+		token := sessionStore.CreateSession()
+		body := `{"keys":["export/a/report.csv","export/b/report.csv","export/summary.txt"],"stripPrefix":"export/"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/buckets/"+bucketName+"/batch/download", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+
+		handler.batchDownloadObjects(rec, req, bucketName)
+
+		zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if err != nil {
+			t.Fatalf("解析 ZIP 失败: %v", err)
+		}
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		if strings.Join(names, "|") != "a/report.csv|b/report.csv|summary.txt" {
+			t.Errorf("条目名称错误: %v", names)
+		}
+	})
+
 	t.Run("空keys被拒绝", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		body := `{"keys":[]}`
@@ -920,6 +1566,80 @@ func TestBatchDownloadObjects(t *testing.T) {
 // 审计日志测试
 // ============================================================================
 
+// TestAdminObjectAudit 管理后台对象操作应记录审计日志
+func TestAdminObjectAudit(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "audit-obj-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	handler.filestore.CreateBucket(bucketName)
+
+	upload := func(key string) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", key)
+		part.Write([]byte("audit content"))
+		writer.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/buckets/"+bucketName+"/upload?key="+key, &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		handler.adminUploadObject(rec, req, bucketName)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("上传失败: %d, body: %s", rec.Code, rec.Body.String())
+		}
+	}
+	upload("a.txt")
+	upload("a.txt")
+
+	rec := httptest.NewRecorder()
+	handler.adminCopyObject(rec, httptest.NewRequest(http.MethodPost, "/api/admin/buckets/"+bucketName+"/copy",
+		strings.NewReader(`{"source_key":"a.txt","dest_key":"b.txt"}`)), bucketName)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("复制失败: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.adminDeleteObject(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/buckets/"+bucketName+"/objects?key=a.txt", nil), bucketName)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("删除失败: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.batchDeleteObjects(rec, httptest.NewRequest(http.MethodPost, "/api/admin/buckets/"+bucketName+"/batch-delete",
+		strings.NewReader(`{"keys":["b.txt","missing.txt"]}`)), bucketName)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("批量删除失败: %d", rec.Code)
+	}
+
+	tests := []struct {
+		action   storage.AuditAction
+		resource string
+		success  bool
+	}{
+		{storage.AuditActionObjectUpload, bucketName + "/a.txt", true},
+		{storage.AuditActionObjectOverwrite, bucketName + "/a.txt", true},
+		{storage.AuditActionObjectCopy, bucketName + "/b.txt", true},
+		{storage.AuditActionObjectDelete, bucketName + "/a.txt", true},
+		{storage.AuditActionBatchDelete, bucketName, false},
+	}
+	for _, tt := range tests {
+		logs, total, err := handler.metadata.QueryAuditLogs(&storage.AuditLogQuery{Action: tt.action})
+		if err != nil {
+			t.Fatalf("查询审计日志失败: %v", err)
+		}
+		if total != 1 {
+			t.Errorf("%s: 期望 1 条日志, 实际 %d", tt.action, total)
+			continue
+		}
+		if logs[0].Resource != tt.resource || logs[0].Success != tt.success {
+			t.Errorf("%s: 日志内容错误: %+v", tt.action, logs[0])
+		}
+	}
+}
+
 func TestHandleAuditLogs(t *testing.T) {
 	handler, cleanup := setupAdminTestHandler(t)
 	defer cleanup()
@@ -998,6 +1718,31 @@ func TestHandleAuditLogs(t *testing.T) {
 		}
 	})
 
+	t.Run("按别名和多个操作过滤", func(t *testing.T) {
+		handler.metadata.WriteAuditLog(&storage.AuditLog{Action: storage.AuditActionLoginFailed, Actor: "admin"})
+		handler.metadata.WriteAuditLog(&storage.AuditLog{Action: storage.AuditActionSettingsUpdate, Actor: "admin", Success: true})
+
+		query := func(action string) AuditLogResponse {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/audit?action="+action, nil)
+			req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+			rec := httptest.NewRecorder()
+			handler.handleAuditLogs(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("状态码错误: %d", rec.Code)
+			}
+			var resp AuditLogResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			return resp
+		}
+
+		if resp := query("login_failure"); resp.Total != 1 || resp.Logs[0].Action != storage.AuditActionLoginFailed {
+			t.Errorf("login_failure 别名应匹配 login_failed: %+v", resp.Logs)
+		}
+		if resp := query("settings_change,bucket_create"); resp.Total != 2 {
+			t.Errorf("多个操作应合并查询: 期望 2, 实际 %d", resp.Total)
+		}
+	})
+
 	t.Run("方法限制", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		req := httptest.NewRequest(http.MethodPost, "/api/admin/audit", nil)
@@ -1012,6 +1757,60 @@ func TestHandleAuditLogs(t *testing.T) {
 	})
 }
 
+// TestHandleObjectHistory 测试对象审计时间线查询
+func TestHandleObjectHistory(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	base := time.Now().Add(-time.Hour)
+	for i, action := range []storage.AuditAction{storage.AuditActionObjectUpload, storage.AuditActionObjectOverwrite, storage.AuditActionObjectDelete} {
+		handler.metadata.WriteAuditLog(&storage.AuditLog{
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			Action:    action,
+			Actor:     "admin",
+			Resource:  "history-bucket/report.pdf",
+			Success:   true,
+		})
+	}
+	handler.metadata.WriteAuditLog(&storage.AuditLog{
+		Action:   storage.AuditActionObjectUpload,
+		Resource: "history-bucket/report.pdf.old",
+		Success:  true,
+	})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit/object?"+query, nil)
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleObjectHistory(rec, req)
+		return rec
+	}
+
+	if rec := get("bucket=history-bucket"); rec.Code != http.StatusBadRequest {
+		t.Errorf("缺少 key 应返回400: %d", rec.Code)
+	}
+
+	rec := get("bucket=history-bucket&key=report.pdf")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var resp AuditLogResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Total != 3 || len(resp.Logs) != 3 {
+		t.Fatalf("期望 3 条记录: %+v", resp)
+	}
+	if resp.Logs[0].Action != storage.AuditActionObjectUpload || resp.Logs[2].Action != storage.AuditActionObjectDelete {
+		t.Errorf("应按时间正序: %s ... %s", resp.Logs[0].Action, resp.Logs[2].Action)
+	}
+
+	rec = get("bucket=history-bucket&key=missing.txt")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"logs":[]`) {
+		t.Errorf("无记录应返回空列表: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
 func TestHandleAuditStats(t *testing.T) {
 	handler, cleanup := setupAdminTestHandler(t)
 	defer cleanup()
@@ -1109,6 +1908,112 @@ func TestHandleSettings(t *testing.T) {
 		}
 	})
 
+	t.Run("安全响应头设置", func(t *testing.T) {
+		token := sessionStore.CreateSession()
+		body := `{"frame_options":"ALLOW-FROM x"}`
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+		handler.handleSettings(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("无效frame_options应被拒绝: %d", rec.Code)
+		}
+
+		body = `{"frame_options":"","content_security_policy":"default-src 'self'","header_nosniff":false}`
+		req = httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", token)
+		rec = httptest.NewRecorder()
+		handler.handleSettings(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: 期望 %d, 实际 %d, body: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if config.Global.Security.FrameOptions != "" || config.Global.Security.HeaderNoSniff {
+			t.Errorf("运行时配置未更新: %+v", config.Global.Security)
+		}
+		if v, _ := handler.metadata.GetSetting(storage.SettingSecurityFrameOptions); v != config.HeaderDisabled {
+			t.Errorf("关闭的安全头应以 off 持久化: %q", v)
+		}
+
+		config.Global.Security.FrameOptions = "DENY"
+		config.Global.Security.HeaderNoSniff = true
+		config.Global.Security.ContentSecurityPolicy = ""
+	})
+
+	t.Run("追踪ID请求头设置", func(t *testing.T) {
+		token := sessionStore.CreateSession()
+		for _, body := range []string{`{"trace_header":"X Trace"}`, `{"trace_header":"x-amz-request-id"}`} {
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(body))
+			req.Header.Set("X-Admin-Token", token)
+			rec := httptest.NewRecorder()
+			handler.handleSettings(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("无效请求头 %s 应被拒绝: %d", body, rec.Code)
+			}
+		}
+
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(`{"trace_header":"x-correlation-id"}`))
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+		handler.handleSettings(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+		if config.Global.Security.TraceHeader != "X-Correlation-Id" {
+			t.Errorf("请求头名称应规范化: %q", config.Global.Security.TraceHeader)
+		}
+		config.Global.Security.TraceHeader = "X-Request-Id"
+	})
+
+	t.Run("通配符来源与凭证互斥", func(t *testing.T) {
+		token := sessionStore.CreateSession()
+		body := `{"cors_origin":"*","cors_allow_credentials":true}`
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", token)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.handleSettings(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("白名单来源开启凭证", func(t *testing.T) {
+		token := sessionStore.CreateSession()
+		body := `{"cors_origin":"https://a.example.com, https://b.example.com","cors_allow_credentials":true}`
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", token)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.handleSettings(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: 期望 %d, 实际 %d, body: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp SettingsResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if !resp.Security.CORSAllowCredentials {
+			t.Error("cors_allow_credentials 应该为 true")
+		}
+		if v, _ := handler.metadata.GetSetting(storage.SettingSecurityCORSAllowCredentials); v != "true" {
+			t.Errorf("设置未持久化: %q", v)
+		}
+
+		// 凭证开启时不能切换回通配符
+		req = httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(`{"cors_origin":"*"}`))
+		req.Header.Set("X-Admin-Token", token)
+		rec = httptest.NewRecorder()
+		handler.handleSettings(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusBadRequest, rec.Code)
+		}
+
+		config.Global.Security.CORSOrigin = "*"
+		config.Global.Security.CORSAllowCredentials = false
+	})
+
 	t.Run("方法限制", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		req := httptest.NewRequest(http.MethodDelete, "/api/admin/settings", nil)
@@ -1123,6 +2028,59 @@ func TestHandleSettings(t *testing.T) {
 	})
 }
 
+// TestHandleEffectiveConfig 测试生效配置接口不输出密钥
+func TestHandleEffectiveConfig(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	if config.Global == nil {
+		config.NewDefault()
+	}
+	oldRegion, oldSecret := config.Global.Server.Region, config.Global.Auth.SecretAccessKey
+	config.Global.Server.Region = "eu-west-1"
+	config.Global.Auth.SecretAccessKey = "effective-config-secret"
+	defer func() {
+		config.Global.Server.Region = oldRegion
+		config.Global.Auth.SecretAccessKey = oldSecret
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/settings/effective", nil)
+	req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: 期望 %d, 实际 %d", http.StatusOK, rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "effective-config-secret") {
+		t.Fatal("响应中不应包含密钥")
+	}
+	var resp EffectiveConfigResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	found := map[string]config.EffectiveSetting{}
+	for _, s := range resp.Settings {
+		found[s.Key] = s
+	}
+	if s := found["server.region"]; s.Value != "eu-west-1" || s.Source != config.SourceDB {
+		t.Errorf("region 错误: %+v", s)
+	}
+	if s := found["auth.secret_access_key"]; s.Value != config.RedactedValue {
+		t.Errorf("密钥应脱敏: %+v", s)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/admin/settings/effective", nil)
+	req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
 func TestHandleChangePassword(t *testing.T) {
 	handler, cleanup := setupAdminTestHandler(t)
 	defer cleanup()
@@ -1249,6 +2207,92 @@ func TestHandleStorageStats(t *testing.T) {
 	})
 }
 
+// TestHandleStatsRecompute 测试重建存储统计计数器
+func TestHandleStatsRecompute(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	handler.metadata.CreateBucket("recompute-bucket")
+	handler.metadata.PutObject(&storage.Object{Bucket: "recompute-bucket", Key: "a.txt", Size: 42, ETag: "etag",
+		ContentType: "text/plain", LastModified: time.Now(), StoragePath: "/tmp/a.txt"})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/stats/recompute", nil)
+	req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+	rec := httptest.NewRecorder()
+	handler.handleStatsRecompute(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var result storage.ObjectCounterRecompute
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if result.TotalObjects != 1 || result.TotalSize != 42 || result.ObjectDrift != 0 || result.SizeDrift != 0 {
+		t.Errorf("计数器无偏差时结果错误: %+v", result)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/stats/recompute", nil)
+	rec = httptest.NewRecorder()
+	handler.handleStatsRecompute(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET 应返回405: %d", rec.Code)
+	}
+}
+
+// TestHandleEtagRederive 测试后台 ETag 重算接口
+func TestHandleEtagRederive(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	handler.metadata.CreateBucket("etag-bucket")
+	path, _, err := handler.filestore.PutObject("etag-bucket", "imported.txt", strings.NewReader("imported"), 8)
+	if err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	handler.metadata.PutObject(&storage.Object{Bucket: "etag-bucket", Key: "imported.txt", Size: 8, ETag: "placeholder",
+		ContentType: "text/plain", LastModified: time.Now(), StoragePath: path})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/storage/etags", strings.NewReader(`{"fix":true}`))
+	rec := httptest.NewRecorder()
+	handler.handleEtagRederive(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := handler.etags.Wait(ctx); err != nil {
+		t.Fatalf("等待任务失败: %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/storage/etags", nil)
+	rec = httptest.NewRecorder()
+	handler.handleEtagRederive(rec, req)
+	var status storage.EtagRederiveStatus
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	if !status.Done || status.Fixed != 1 || len(status.Corrections) != 1 || !status.Corrections[0].Fixed {
+		t.Errorf("任务结果错误: %+v", status)
+	}
+	if obj, _ := handler.metadata.GetObject("etag-bucket", "imported.txt"); obj.ETag == "placeholder" {
+		t.Error("ETag 应被修正")
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/admin/storage/etags", nil)
+	rec = httptest.NewRecorder()
+	handler.handleEtagRederive(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("没有运行中的任务时取消应返回 409: %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/storage/etags", strings.NewReader(`{"rate":-1}`))
+	rec = httptest.NewRecorder()
+	handler.handleEtagRederive(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("负数速率应返回 400: %d", rec.Code)
+	}
+}
+
 func TestHandleRecentObjects(t *testing.T) {
 	handler, cleanup := setupAdminTestHandler(t)
 	defer cleanup()
@@ -1347,36 +2391,144 @@ func TestHandleGC(t *testing.T) {
 	})
 }
 
-// ============================================================================
-// 完整性检查测试
-// ============================================================================
-
-func TestHandleIntegrity(t *testing.T) {
+// TestHandleOrphanSweep 测试孤立文件清理接口
+func TestHandleOrphanSweep(t *testing.T) {
 	handler, cleanup := setupAdminTestHandler(t)
 	defer cleanup()
 
-	setupInstalledSystem(t, handler)
+	handler.metadata.CreateBucket("sweep-bucket")
+	path, _, err := handler.filestore.PutObject("sweep-bucket", "orphan.txt", strings.NewReader("orphan"), 6)
+	if err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(path, old, old)
 
-	t.Run("检查完整性", func(t *testing.T) {
-		token := sessionStore.CreateSession()
-		req := httptest.NewRequest(http.MethodGet, "/api/admin/integrity", nil)
-		req.Header.Set("X-Admin-Token", token)
+	sweep := func(method, url, body string) (*httptest.ResponseRecorder, storage.OrphanSweepResult) {
 		rec := httptest.NewRecorder()
+		handler.handleOrphanSweep(rec, httptest.NewRequest(method, url, bytes.NewBufferString(body)))
+		var result storage.OrphanSweepResult
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		return rec, result
+	}
 
-		handler.handleIntegrity(rec, req)
+	rec, result := sweep(http.MethodGet, "/api/admin/storage/orphans", "")
+	if rec.Code != http.StatusOK || !result.DryRun || result.OrphanCount != 1 {
+		t.Fatalf("预览结果错误: %d %s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal("预览不应删除文件")
+	}
 
-		if rec.Code != http.StatusOK {
-			t.Errorf("状态码错误: 期望 %d, 实际 %d, body: %s", http.StatusOK, rec.Code, rec.Body.String())
-		}
-	})
+	handler.orphanMu.Lock()
+	rec, _ = sweep(http.MethodPost, "/api/admin/storage/orphans", "")
+	handler.orphanMu.Unlock()
+	if rec.Code != http.StatusConflict {
+		t.Errorf("清理运行中应返回409: %d", rec.Code)
+	}
 
-	t.Run("检查完整性（验证ETag）", func(t *testing.T) {
-		token := sessionStore.CreateSession()
-		req := httptest.NewRequest(http.MethodGet, "/api/admin/integrity?verify_etag=true&limit=10", nil)
-		req.Header.Set("X-Admin-Token", token)
-		rec := httptest.NewRecorder()
+	rec, _ = sweep(http.MethodPost, "/api/admin/storage/orphans", `{"rate":-1}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("负数参数应返回400: %d", rec.Code)
+	}
 
-		handler.handleIntegrity(rec, req)
+	rec, result = sweep(http.MethodPost, "/api/admin/storage/orphans", `{"limit":100,"rate":50}`)
+	if rec.Code != http.StatusOK || result.Deleted != 1 || !result.Done {
+		t.Fatalf("清理结果错误: %d %s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("孤立文件应已删除")
+	}
+	if cursor, _ := handler.metadata.OrphanSweepCursor(); cursor != "" {
+		t.Errorf("遍历完成后游标应清空: %q", cursor)
+	}
+	logs, _, _ := handler.metadata.QueryAuditLogs(&storage.AuditLogQuery{Action: storage.AuditActionOrphanSweep})
+	if len(logs) != 1 {
+		t.Errorf("应记录审计日志: %d", len(logs))
+	}
+}
+
+// TestHandleBucketMetrics 测试按桶请求统计接口
+func TestHandleBucketMetrics(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	collector := storage.GetBucketMetrics()
+	collector.Reset()
+	collector.Record("stats-bucket", http.StatusOK, 10, 20, 0)
+
+	rec := httptest.NewRecorder()
+	handler.handleBucketMetrics(rec, httptest.NewRequest(http.MethodGet, "/api/admin/stats/buckets", nil))
+	var resp BucketMetricsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(resp.Buckets) != 1 || resp.Buckets[0].Bucket != "stats-bucket" || resp.Buckets[0].BytesOut != 20 {
+		t.Errorf("统计结果错误: %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.handleBucketMetrics(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/stats/buckets", nil))
+	if rec.Code != http.StatusOK || len(collector.Snapshot()) != 0 {
+		t.Errorf("清空统计失败: %d", rec.Code)
+	}
+}
+
+func TestHandleDownloadStats(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	now := time.Now()
+	handler.metadata.RecordAnonymousDownload("dl-bucket", "a.zip", 30, true, now)
+	handler.metadata.RecordAnonymousDownload("dl-bucket", "b.zip", 10, true, now)
+	handler.metadata.RecordAnonymousDownload("dl-bucket", "b.zip", 10, true, now)
+	config.Global.Storage.AnonymousDailyBytes = 1000
+	defer func() { config.Global.Storage.AnonymousDailyBytes = 0 }()
+
+	rec := httptest.NewRecorder()
+	handler.handleDownloadStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/stats/downloads?bucket=dl-bucket", nil))
+	var resp DownloadStatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.TodayAnonymousBytes != 50 || resp.DailyLimit != 1000 {
+		t.Errorf("流量统计错误: %+v", resp)
+	}
+	if len(resp.Objects) != 2 || resp.Objects[0].Key != "b.zip" || resp.Objects[0].Downloads != 2 {
+		t.Errorf("下载排序错误: %+v", resp.Objects)
+	}
+}
+
+// ============================================================================
+// 完整性检查测试
+// ============================================================================
+
+func TestHandleIntegrity(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	t.Run("检查完整性", func(t *testing.T) {
+		token := sessionStore.CreateSession()
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/integrity", nil)
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+
+		handler.handleIntegrity(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("状态码错误: 期望 %d, 实际 %d, body: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("检查完整性（验证ETag）", func(t *testing.T) {
+		token := sessionStore.CreateSession()
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/integrity?verify_etag=true&limit=10", nil)
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+
+		handler.handleIntegrity(rec, req)
 
 		if rec.Code != http.StatusOK {
 			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusOK, rec.Code)
@@ -1398,6 +2550,51 @@ func TestHandleIntegrity(t *testing.T) {
 		}
 	})
 
+	t.Run("并行与限速参数", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/integrity?workers=4&buffer_kb=16&rate=1000", nil)
+		rec := httptest.NewRecorder()
+
+		handler.handleIntegrity(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("状态码错误: 期望 %d, 实际 %d, body: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/admin/integrity?rate=-1", nil)
+		rec = httptest.NewRecorder()
+		handler.handleIntegrity(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("非法 rate 应返回400: %d", rec.Code)
+		}
+	})
+
+	t.Run("检查进度与互斥", func(t *testing.T) {
+		handler.integrity.TryStart()
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/integrity", nil)
+		rec := httptest.NewRecorder()
+		handler.handleIntegrity(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("检查运行中应返回409: %d", rec.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/admin/storage/integrity/progress", nil)
+		rec = httptest.NewRecorder()
+		handler.handleIntegrityProgress(rec, req)
+		var info storage.IntegrityProgressInfo
+		json.Unmarshal(rec.Body.Bytes(), &info)
+		if rec.Code != http.StatusOK || !info.Running {
+			t.Errorf("进度应显示运行中: %d %s", rec.Code, rec.Body.String())
+		}
+		handler.integrity.Finish()
+
+		rec = httptest.NewRecorder()
+		handler.handleIntegrityProgress(rec, req)
+		json.Unmarshal(rec.Body.Bytes(), &info)
+		if info.Running {
+			t.Error("结束后进度不应显示运行中")
+		}
+	})
+
 	t.Run("方法限制", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		req := httptest.NewRequest(http.MethodDelete, "/api/admin/integrity", nil)
@@ -1439,6 +2636,33 @@ func TestContainsDuplicate(t *testing.T) {
 	}
 }
 
+func TestArchiveEntryNames(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []string
+		stripPrefix   string
+		preservePaths bool
+		expected      []string
+	}{
+		{"默认只保留文件名", []string{"a/x.txt", "b/y.txt"}, "", false, []string{"x.txt", "y.txt"}},
+		{"同名文件使用完整路径", []string{"a/x.txt", "b/x.txt", "c.txt"}, "", false, []string{"a/x.txt", "b/x.txt", "c.txt"}},
+		{"保留完整路径", []string{"a/x.txt", "b/x.txt"}, "", true, []string{"a/x.txt", "b/x.txt"}},
+		{"去掉前缀保留路径", []string{"data/2024/x.txt", "data/2025/x.txt"}, "data/", true, []string{"2024/x.txt", "2025/x.txt"}},
+		{"去掉前缀后冲突退回去前缀路径", []string{"data/2024/x.txt", "data/2025/x.txt", "data/y.txt"}, "data", false, []string{"2024/x.txt", "2025/x.txt", "y.txt"}},
+		{"去掉前缀后仍冲突使用完整key", []string{"data/x.txt", "x.txt"}, "data/", true, []string{"data/x.txt", "x.txt"}},
+		{"key等于前缀保留文件名", []string{"data/"}, "data/", true, []string{"data"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := archiveEntryNames(tc.keys, tc.stripPrefix, tc.preservePaths)
+			if strings.Join(got, "|") != strings.Join(tc.expected, "|") {
+				t.Errorf("期望 %v, 实际 %v", tc.expected, got)
+			}
+		})
+	}
+}
+
 func TestParseInt(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -2045,6 +3269,83 @@ func TestUpdateSettingsEnhanced(t *testing.T) {
 		}
 	})
 
+	t.Run("更新key_leading_slash", func(t *testing.T) {
+		defer func() { config.Global.Storage.LeadingSlash = config.LeadingSlashNormalize }()
+		for body, want := range map[string]int{
+			`{"key_leading_slash":"reject"}`:   http.StatusOK,
+			`{"key_leading_slash":"collapse"}`: http.StatusBadRequest,
+		} {
+			token := sessionStore.CreateSession()
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(body))
+			req.Header.Set("X-Admin-Token", token)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			handler.handleSettings(rec, req)
+
+			if rec.Code != want {
+				t.Errorf("%s 状态码错误: 期望 %d, 实际 %d", body, want, rec.Code)
+			}
+		}
+		if config.Global.Storage.LeadingSlash != config.LeadingSlashReject {
+			t.Errorf("LeadingSlash 未更新: %q", config.Global.Storage.LeadingSlash)
+		}
+		if v, _ := handler.metadata.GetSetting(storage.SettingStorageLeadingSlash); v != config.LeadingSlashReject {
+			t.Errorf("设置未持久化: %q", v)
+		}
+	})
+
+	t.Run("更新folder_markers", func(t *testing.T) {
+		defer func() { config.Global.Storage.FolderMarkers = config.FolderMarkersObject }()
+		for body, want := range map[string]int{
+			`{"folder_markers":"placeholder"}`: http.StatusOK,
+			`{"folder_markers":"hidden"}`:      http.StatusBadRequest,
+		} {
+			token := sessionStore.CreateSession()
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(body))
+			req.Header.Set("X-Admin-Token", token)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			handler.handleSettings(rec, req)
+
+			if rec.Code != want {
+				t.Errorf("%s 状态码错误: 期望 %d, 实际 %d", body, want, rec.Code)
+			}
+		}
+		if !config.Global.Storage.FolderPlaceholders() {
+			t.Errorf("FolderMarkers 未更新: %q", config.Global.Storage.FolderMarkers)
+		}
+		if v, _ := handler.metadata.GetSetting(storage.SettingStorageFolderMarkers); v != config.FolderMarkersPlaceholder {
+			t.Errorf("设置未持久化: %q", v)
+		}
+	})
+
+	t.Run("更新anonymous_daily_bytes", func(t *testing.T) {
+		defer func() { config.Global.Storage.AnonymousDailyBytes = 0 }()
+		for body, want := range map[string]int{
+			`{"anonymous_daily_bytes":1073741824}`: http.StatusOK,
+			`{"anonymous_daily_bytes":-1}`:         http.StatusBadRequest,
+		} {
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewBufferString(body))
+			req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			handler.handleSettings(rec, req)
+
+			if rec.Code != want {
+				t.Errorf("%s 状态码错误: 期望 %d, 实际 %d", body, want, rec.Code)
+			}
+		}
+		if config.Global.Storage.AnonymousDailyBytes != 1073741824 {
+			t.Errorf("AnonymousDailyBytes 未更新: %d", config.Global.Storage.AnonymousDailyBytes)
+		}
+		if v, _ := handler.metadata.GetSetting(storage.SettingStorageAnonymousCap); v != "1073741824" {
+			t.Errorf("设置未持久化: %q", v)
+		}
+	})
+
 	t.Run("无效JSON被拒绝", func(t *testing.T) {
 		token := sessionStore.CreateSession()
 		body := `{invalid json}`
@@ -3033,3 +4334,621 @@ func TestLogoutEnhanced(t *testing.T) {
 		}
 	})
 }
+
+// TestAdminBucketReplication 测试桶复制配置接口
+func TestAdminBucketReplication(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "repl-test-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	handler.filestore.CreateBucket(bucketName)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/admin/buckets/"+bucketName+"/replication", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/replication")
+		return rec
+	}
+
+	t.Run("参数校验", func(t *testing.T) {
+		if rec := do(http.MethodPut, `{"endpoint":"http://dr:9000","accessKey":"ak"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("缺少目标桶应返回400: %d", rec.Code)
+		}
+		if rec := do(http.MethodPut, `{"endpoint":"http://dr:9000","accessKey":"ak","targetBucket":"dst"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("首次配置缺少 secretKey 应返回400: %d", rec.Code)
+		}
+	})
+
+	t.Run("设置并读取配置", func(t *testing.T) {
+		rec := do(http.MethodPut, `{"endpoint":"http://dr:9000","accessKey":"ak","secretKey":"replica-secret","targetBucket":"dst"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+
+		rec = do(http.MethodGet, "")
+		if strings.Contains(rec.Body.String(), `"replica-secret"`) {
+			t.Error("响应不应包含 secretKey")
+		}
+		var resp map[string]interface{}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if resp["configured"] != true {
+			t.Errorf("应已配置: %v", resp)
+		}
+	})
+
+	t.Run("更新时保留原 Secret", func(t *testing.T) {
+		rec := do(http.MethodPut, `{"endpoint":"http://dr:9000","accessKey":"ak","targetBucket":"dst2","enabled":false}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+		cfg, _ := handler.metadata.GetReplicationConfig(bucketName)
+		if cfg == nil || cfg.SecretKey != "replica-secret" || cfg.TargetBucket != "dst2" || cfg.Enabled {
+			t.Errorf("配置更新错误: %+v", cfg)
+		}
+	})
+
+	t.Run("复制状态", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/replication", nil)
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleReplicationStatus(rec, req)
+		var resp struct {
+			Replication []storage.ReplicationStatus `json:"replication"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if len(resp.Replication) != 1 || resp.Replication[0].TargetBucket != "dst2" {
+			t.Errorf("状态错误: %+v", resp.Replication)
+		}
+	})
+
+	t.Run("删除配置", func(t *testing.T) {
+		if rec := do(http.MethodDelete, ""); rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d", rec.Code)
+		}
+		if cfg, _ := handler.metadata.GetReplicationConfig(bucketName); cfg != nil {
+			t.Error("配置应已删除")
+		}
+	})
+}
+
+// TestAdminBucketOrigin 测试桶回源配置接口
+func TestAdminBucketOrigin(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "origin-test-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	handler.filestore.CreateBucket(bucketName)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/admin/buckets/"+bucketName+"/origin", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/origin")
+		return rec
+	}
+
+	if rec := do(http.MethodPut, `{"endpoint":"http://old:9000","accessKey":"ak"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("缺少源桶应返回400: %d", rec.Code)
+	}
+
+	rec := do(http.MethodPut, `{"endpoint":"http://old:9000","accessKey":"ak","secretKey":"origin-secret","sourceBucket":"legacy","sourcePrefix":"site/"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodGet, "")
+	if strings.Contains(rec.Body.String(), "origin-secret") || !strings.Contains(rec.Body.String(), `"sourcePrefix":"site/"`) {
+		t.Errorf("响应错误: %s", rec.Body.String())
+	}
+
+	// 更新时保留原 Secret
+	if rec := do(http.MethodPut, `{"endpoint":"http://old:9000","accessKey":"ak","sourceBucket":"legacy","enabled":false}`); rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d", rec.Code)
+	}
+	cfg, _ := handler.metadata.GetOriginConfig(bucketName)
+	if cfg == nil || cfg.SecretKey != "origin-secret" || cfg.Enabled {
+		t.Errorf("配置更新错误: %+v", cfg)
+	}
+
+	if rec := do(http.MethodDelete, ""); rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d", rec.Code)
+	}
+	if cfg, _ := handler.metadata.GetOriginConfig(bucketName); cfg != nil {
+		t.Error("配置应已删除")
+	}
+}
+
+// TestAdminBucketNotificationTest 测试向桶的通知目标发送测试事件
+func TestAdminBucketNotificationTest(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "notify-test-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	handler.filestore.CreateBucket(bucketName)
+
+	var received []storage.ObjectEvent
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event storage.ObjectEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		received = append(received, event)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/admin/buckets/"+bucketName+"/"+path, bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/"+path)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "notification/test", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("未配置通知时应返回404: %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "notification", `{"targets":[{"url":"`+hook.URL+`","events":["s3:ObjectRemoved:*"]}]}`); rec.Code != http.StatusOK {
+		t.Fatalf("设置通知失败: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "notification/test", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET 应返回405: %d", rec.Code)
+	}
+
+	rec := do(http.MethodPost, "notification/test", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Event   string                           `json:"event"`
+		Results []storage.NotificationTestResult `json:"results"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Event != storage.EventTest || len(resp.Results) != 1 || !resp.Results[0].Success || resp.Results[0].StatusCode != http.StatusNoContent {
+		t.Errorf("响应错误: %s", rec.Body.String())
+	}
+	if len(received) != 1 || received[0].EventName != storage.EventTest || received[0].Bucket != bucketName {
+		t.Errorf("目标应同步收到测试事件: %+v", received)
+	}
+}
+
+// TestAdminBucketUsage 测试桶前缀用量统计接口
+func TestAdminBucketUsage(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "usage-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	for key, size := range map[string]int64{"a/1": 10, "a/2": 20, "b/1": 50, "c": 1} {
+		handler.metadata.PutObject(&storage.Object{Bucket: bucketName, Key: key, Size: size, ETag: "e", StoragePath: "/p/" + key})
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/buckets/"+bucketName+"/usage"+query, nil)
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/usage")
+		return rec
+	}
+
+	t.Run("按第一级分组", func(t *testing.T) {
+		rec := get("?limit=2")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+		var resp BucketUsageResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if resp.TotalObjects != 4 || resp.TotalSize != 81 {
+			t.Errorf("总量错误: %+v", resp)
+		}
+		if len(resp.Groups) != 2 || resp.Groups[0].Prefix != "b/" || resp.Groups[1].Prefix != "a/" || resp.Groups[1].ObjectCount != 2 {
+			t.Errorf("分组错误: %+v", resp.Groups)
+		}
+	})
+
+	t.Run("无效深度", func(t *testing.T) {
+		if rec := get("?depth=0"); rec.Code != http.StatusBadRequest {
+			t.Errorf("期望 400, 实际 %d", rec.Code)
+		}
+	})
+}
+
+// TestAdminBucketImmutability 测试桶对象不可变窗口配置，管理员删除不受限制
+func TestAdminBucketImmutability(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "worm-admin-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	handler.filestore.CreateBucket(bucketName)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/buckets/"+bucketName+"/immutability", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/immutability")
+		return rec
+	}
+
+	if rec := put(`{"minutes":-1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("负数应返回400: %d", rec.Code)
+	}
+	if rec := put(`{"minutes":15}`); rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	bucket, _ := handler.metadata.GetBucket(bucketName)
+	if bucket.ImmutableMinutes != 15 {
+		t.Errorf("配置未保存: %d", bucket.ImmutableMinutes)
+	}
+
+	path, etag, _ := handler.filestore.PutObject(bucketName, "fresh.txt", strings.NewReader("x"), 1)
+	handler.metadata.PutObject(&storage.Object{Bucket: bucketName, Key: "fresh.txt", Size: 1, ETag: etag, StoragePath: path, LastModified: time.Now().UTC()})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/buckets/"+bucketName+"/objects?key=fresh.txt", nil)
+	req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+	rec := httptest.NewRecorder()
+	handler.adminDeleteObject(rec, req, bucketName)
+	if rec.Code != http.StatusOK {
+		t.Errorf("管理员删除应绕过不可变窗口: %d, body: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestAdminBucketReadAge 测试桶最大可读天数管理接口
+func TestAdminBucketReadAge(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "read-age-bucket"
+	handler.metadata.CreateBucket(bucketName)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/buckets/"+bucketName+"/read-age", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/read-age")
+		return rec
+	}
+
+	for _, body := range []string{`{"days":-1}`, `{"days":100000}`, `{"days":7,"basis":"accessed"}`} {
+		if rec := put(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s 应返回400: %d", body, rec.Code)
+		}
+	}
+
+	rec := put(`{"days":30}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var resp BucketReadAgeResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Days != 30 || resp.Basis != storage.ReadAgeBasisModified || !strings.Contains(resp.Warning, "NOT deleted") {
+		t.Errorf("响应错误: %+v", resp)
+	}
+	bucket, _ := handler.metadata.GetBucket(bucketName)
+	if bucket.MaxReadAgeDays != 30 {
+		t.Errorf("配置未保存: %d", bucket.MaxReadAgeDays)
+	}
+
+	rec = put(`{"days":30,"basis":"created"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if bucket, _ := handler.metadata.GetBucket(bucketName); bucket.ReadAgeBasis != storage.ReadAgeBasisCreated {
+		t.Errorf("计算基准未保存: %q", bucket.ReadAgeBasis)
+	}
+
+	rec = put(`{"days":0}`)
+	resp = BucketReadAgeResponse{}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || resp.Days != 0 || resp.Warning != "" {
+		t.Errorf("关闭后不应有警告: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestAdminBucketAllowedMethods 测试桶允许的 HTTP 方法管理接口
+func TestAdminBucketAllowedMethods(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "methods-bucket"
+	handler.metadata.CreateBucket(bucketName)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/buckets/"+bucketName+"/allowed-methods", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/allowed-methods")
+		return rec
+	}
+
+	if rec := put(`{"methods":["GET","PATCH"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("不支持的方法应返回400: %d", rec.Code)
+	}
+
+	rec := put(`{"methods":["get"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var resp BucketMethodsRequest
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if strings.Join(resp.Methods, ",") != "GET,HEAD" {
+		t.Errorf("响应错误: %+v", resp)
+	}
+	bucket, _ := handler.metadata.GetBucket(bucketName)
+	if bucket.AllowedMethods != "GET,HEAD" {
+		t.Errorf("配置未保存: %q", bucket.AllowedMethods)
+	}
+
+	rec = put(`{"methods":[]}`)
+	resp = BucketMethodsRequest{}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || resp.Methods == nil || len(resp.Methods) != 0 {
+		t.Errorf("清空后应不限制: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestAdminBucketReadOnly 测试桶只读管理接口
+func TestAdminBucketReadOnly(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "frozen-bucket"
+	handler.metadata.CreateBucket(bucketName)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/admin/buckets/"+bucketName+"/read-only", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/read-only")
+		return rec
+	}
+
+	rec := do(http.MethodPut, `{"read_only":true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if bucket, _ := handler.metadata.GetBucket(bucketName); !bucket.ReadOnly {
+		t.Error("只读配置未保存")
+	}
+
+	rec = do(http.MethodGet, "")
+	var resp BucketReadOnlyRequest
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || !resp.ReadOnly {
+		t.Errorf("查询结果错误: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodPut, `{"read_only":false}`); rec.Code != http.StatusOK {
+		t.Errorf("解除只读失败: %d", rec.Code)
+	}
+	if bucket, _ := handler.metadata.GetBucket(bucketName); bucket.ReadOnly {
+		t.Error("只读未解除")
+	}
+}
+
+// TestAdminBucketPrefixRewrites 测试桶对象键前缀改写管理接口
+func TestAdminBucketPrefixRewrites(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "rewrite-bucket"
+	handler.metadata.CreateBucket(bucketName)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/buckets/"+bucketName+"/prefix-rewrites", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/prefix-rewrites")
+		return rec
+	}
+
+	if rec := put(`{"rules":[{"from":"v1/","to":"v1/new/"}]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("会被再次改写的规则应返回400: %d", rec.Code)
+	}
+
+	rec := put(`{"rules":[{"from":"v1/","to":"legacy/"}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	bucket, _ := handler.metadata.GetBucket(bucketName)
+	if len(bucket.PrefixRewrites) != 1 || bucket.RewriteKey("v1/a") != "legacy/a" {
+		t.Errorf("配置未保存: %+v", bucket.PrefixRewrites)
+	}
+
+	rec = put(`{"rules":[]}`)
+	bucket, _ = handler.metadata.GetBucket(bucketName)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"rules":[]`) || len(bucket.PrefixRewrites) != 0 {
+		t.Errorf("清空后应关闭改写: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestAdminBucketTransform 测试桶图片按需缩放管理接口
+func TestAdminBucketTransform(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "transform-bucket"
+	handler.metadata.CreateBucket(bucketName)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/buckets/"+bucketName+"/transform", bytes.NewBufferString(`{"enabled":true}`))
+	req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+	rec := httptest.NewRecorder()
+	handler.handleAdminBucketOps(rec, req, bucketName+"/transform")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	bucket, _ := handler.metadata.GetBucket(bucketName)
+	if !bucket.ImageTransform {
+		t.Error("配置未保存")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/buckets/"+bucketName+"/transform", nil)
+	req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+	rec = httptest.NewRecorder()
+	handler.handleAdminBucketOps(rec, req, bucketName+"/transform")
+	var resp BucketTransformRequest
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || !resp.Enabled {
+		t.Errorf("响应错误: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestAdminObjectChecksum 测试按需计算对象校验和接口
+func TestAdminObjectChecksum(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "checksum-admin-bucket"
+	handler.metadata.CreateBucket(bucketName)
+	storagePath, etag, _ := handler.filestore.PutObject(bucketName, "a.txt", strings.NewReader("hello"), 5)
+	handler.metadata.PutObject(&storage.Object{Bucket: bucketName, Key: "a.txt", Size: 5, ETag: etag, StoragePath: storagePath})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/buckets/"+bucketName+"/checksum?"+query, nil)
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/checksum")
+		return rec
+	}
+
+	for _, cached := range []bool{false, true} {
+		rec := get("key=a.txt")
+		var resp ObjectChecksumResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusOK || resp.ObjectChecksum == nil || resp.Cached != cached ||
+			resp.Algorithm != storage.ChecksumSHA256 || resp.Value != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+			t.Errorf("响应错误: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	for query, status := range map[string]int{
+		"key=a.txt&algorithm=CRC32C": http.StatusOK,
+		"key=a.txt&algorithm=sha1":   http.StatusBadRequest,
+		"":                           http.StatusBadRequest,
+		"key=missing.txt":            http.StatusNotFound,
+	} {
+		if rec := get(query); rec.Code != status {
+			t.Errorf("%q 期望 %d，实际 %d", query, status, rec.Code)
+		}
+	}
+}
+
+// TestAdminBucketDefaultHeaders 测试桶默认响应头管理接口
+func TestAdminBucketDefaultHeaders(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "headers-admin-bucket"
+	handler.metadata.CreateBucket(bucketName)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/buckets/"+bucketName+"/default-headers", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/default-headers")
+		return rec
+	}
+
+	for _, body := range []string{
+		`{"headers":{"X-Custom":"1"}}`,
+		`{"headers":{"Cache-Control":"  "}}`,
+		`{"headers":{"Cache-Control":"a\r\nSet-Cookie: x"}}`,
+	} {
+		if rec := put(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s 应返回400: %d", body, rec.Code)
+		}
+	}
+
+	if rec := put(`{"headers":{"cache-control":"public, max-age=3600"}}`); rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	bucket, _ := handler.metadata.GetBucket(bucketName)
+	if bucket.DefaultHeaders["Cache-Control"] != "public, max-age=3600" {
+		t.Errorf("配置未保存或名称未规范化: %v", bucket.DefaultHeaders)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/buckets/"+bucketName+"/default-headers", nil)
+	req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+	rec := httptest.NewRecorder()
+	handler.handleAdminBucketOps(rec, req, bucketName+"/default-headers")
+	if !strings.Contains(rec.Body.String(), `"Cache-Control":"public, max-age=3600"`) {
+		t.Errorf("GET 响应错误: %s", rec.Body.String())
+	}
+
+	if rec := put(`{"headers":{}}`); rec.Code != http.StatusOK {
+		t.Fatalf("清除失败: %d", rec.Code)
+	}
+	if bucket, _ = handler.metadata.GetBucket(bucketName); len(bucket.DefaultHeaders) != 0 {
+		t.Errorf("默认响应头应已清除: %v", bucket.DefaultHeaders)
+	}
+}
+
+// TestAdminBucketWebsite 测试桶静态网站管理接口
+func TestAdminBucketWebsite(t *testing.T) {
+	handler, cleanup := setupAdminTestHandler(t)
+	defer cleanup()
+
+	setupInstalledSystem(t, handler)
+
+	bucketName := "website-admin-bucket"
+	handler.metadata.CreateBucket(bucketName)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/buckets/"+bucketName+"/website", bytes.NewBufferString(body))
+		req.Header.Set("X-Admin-Token", sessionStore.CreateSession())
+		rec := httptest.NewRecorder()
+		handler.handleAdminBucketOps(rec, req, bucketName+"/website")
+		return rec
+	}
+
+	for _, body := range []string{
+		`{"index_document":"","spa":true}`,
+		`{"index_document":"/index.html"}`,
+		`{"index_document":"../index.html"}`,
+		`{"index_document":"docs/"}`,
+	} {
+		if rec := put(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s 应返回400: %d", body, rec.Code)
+		}
+	}
+
+	if rec := put(`{"index_document":"index.html","spa":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	bucket, _ := handler.metadata.GetBucket(bucketName)
+	if bucket.WebsiteIndex != "index.html" || !bucket.WebsiteSPA {
+		t.Errorf("配置未保存: %+v", bucket)
+	}
+
+	if rec := put(`{"index_document":""}`); rec.Code != http.StatusOK {
+		t.Fatalf("关闭失败: %d", rec.Code)
+	}
+	if bucket, _ = handler.metadata.GetBucket(bucketName); bucket.WebsiteIndex != "" || bucket.WebsiteSPA {
+		t.Errorf("网站配置应已关闭: %+v", bucket)
+	}
+}
@@ -3,10 +3,12 @@ package admin
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"sss/internal/auth"
+	"sss/internal/config"
 	"sss/internal/storage"
 	"sss/internal/utils"
 )
@@ -14,6 +16,7 @@ import (
 // CreateAPIKeyRequest 创建 API Key 请求
 type CreateAPIKeyRequest struct {
 	Description string `json:"description"`
+	Namespace   string `json:"namespace,omitempty"` // 桶命名空间，可选
 }
 
 // APIKeyResponse API Key 响应
@@ -23,13 +26,24 @@ type APIKeyResponse struct {
 	Description     string                     `json:"description"`
 	CreatedAt       string                     `json:"created_at"`
 	Enabled         bool                       `json:"enabled"`
+	Namespace       string                     `json:"namespace"`
 	Permissions     []storage.APIKeyPermission `json:"permissions"`
+	PresignUsage    *storage.PresignUsage      `json:"presign_usage,omitempty"` // 当前窗口预签名用量，仅详情返回
+}
+
+// APIKeyListResponse API Key 分页列表响应
+type APIKeyListResponse struct {
+	Keys  []APIKeyResponse `json:"keys"`
+	Total int              `json:"total"`
+	Page  int              `json:"page"`
+	Limit int              `json:"limit"`
 }
 
 // UpdateAPIKeyRequest 更新 API Key 请求
 type UpdateAPIKeyRequest struct {
 	Description *string `json:"description,omitempty"`
 	Enabled     *bool   `json:"enabled,omitempty"`
+	Namespace   *string `json:"namespace,omitempty"` // 空字符串表示取消命名空间
 }
 
 // SetPermissionRequest 设置权限请求
@@ -44,6 +58,21 @@ type DeletePermissionRequest struct {
 	BucketName string `json:"bucket_name"`
 }
 
+// APIKeyBucketAccess 单个桶上的有效权限
+type APIKeyBucketAccess struct {
+	Bucket   string `json:"bucket"`
+	CanRead  bool   `json:"can_read"`
+	CanWrite bool   `json:"can_write"`
+}
+
+// APIKeyTestResponse API Key 自检结果
+type APIKeyTestResponse struct {
+	AccessKeyID string               `json:"access_key_id"`
+	Enabled     bool                 `json:"enabled"`
+	SignatureOK bool                 `json:"signature_ok"`
+	Buckets     []APIKeyBucketAccess `json:"buckets"`
+}
+
 // handleAPIKeys 处理 API Keys 列表/创建
 func (h *Handler) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -56,9 +85,41 @@ func (h *Handler) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// listAPIKeys 列出所有 API Keys
+// listAPIKeys 分页列出 API Keys
+// GET /api/admin/apikeys?page=1&limit=50&description=xxx&enabled=true&sort=created&order=asc
 func (h *Handler) listAPIKeys(w http.ResponseWriter, r *http.Request) {
-	keys, err := h.metadata.ListAPIKeys()
+	q := r.URL.Query()
+	query := &storage.APIKeyQuery{
+		Description: q.Get("description"),
+		Sort:        q.Get("sort"),
+		Ascending:   q.Get("order") == "asc",
+	}
+	if enabled := q.Get("enabled"); enabled != "" {
+		b := enabled == "true" || enabled == "1"
+		query.Enabled = &b
+	}
+
+	// 分页参数
+	page := 1
+	if p := q.Get("page"); p != "" {
+		if v, err := strconv.Atoi(p); err == nil && v > 0 {
+			page = v
+		}
+	}
+	query.Limit = 50
+	if limit := q.Get("limit"); limit != "" {
+		if v, err := strconv.Atoi(limit); err == nil && v > 0 && v <= 100 {
+			query.Limit = v
+		}
+	}
+	query.Offset = (page - 1) * query.Limit
+
+	if query.Sort != "" && query.Sort != "created" {
+		utils.WriteErrorResponse(w, "InvalidParameter", "sort must be 'created'", http.StatusBadRequest)
+		return
+	}
+
+	keys, total, err := h.metadata.QueryAPIKeys(query)
 	if err != nil {
 		utils.Error("list api keys failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
@@ -73,11 +134,17 @@ func (h *Handler) listAPIKeys(w http.ResponseWriter, r *http.Request) {
 			Description: key.Description,
 			CreatedAt:   key.CreatedAt.Format(time.RFC3339),
 			Enabled:     key.Enabled,
+			Namespace:   key.Namespace,
 			Permissions: perms,
 		})
 	}
 
-	utils.WriteJSONResponse(w, result)
+	utils.WriteJSONResponse(w, APIKeyListResponse{
+		Keys:  result,
+		Total: total,
+		Page:  page,
+		Limit: query.Limit,
+	})
 }
 
 // createAPIKey 创建 API Key
@@ -87,6 +154,13 @@ func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
 		return
 	}
+	if req.Namespace != "" && !storage.IsValidNamespace(req.Namespace) {
+		writeInvalidNamespace(w)
+		return
+	}
+	if req.Namespace != "" && !h.checkNamespaceOverlap(w, req.Namespace, "") {
+		return
+	}
 
 	key, err := h.metadata.CreateAPIKey(req.Description)
 	if err != nil {
@@ -94,6 +168,13 @@ func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
 		return
 	}
+	if req.Namespace != "" {
+		if err := h.metadata.UpdateAPIKeyNamespace(key.AccessKeyID, req.Namespace); err != nil {
+			utils.Error("set api key namespace failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+	}
 
 	// 刷新缓存
 	auth.ReloadAPIKeyCache()
@@ -101,6 +182,7 @@ func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
 	// 记录审计日志
 	h.Audit(r, storage.AuditActionAPIKeyCreate, "admin", key.AccessKeyID, true, map[string]string{
 		"description": req.Description,
+		"namespace":   req.Namespace,
 	})
 
 	utils.WriteJSONResponse(w, APIKeyResponse{
@@ -109,6 +191,7 @@ func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
 		Description:     key.Description,
 		CreatedAt:       key.CreatedAt.Format(time.RFC3339),
 		Enabled:         key.Enabled,
+		Namespace:       req.Namespace,
 		Permissions:     []storage.APIKeyPermission{},
 	})
 }
@@ -144,7 +227,7 @@ func (h *Handler) handleAPIKeyDetail(w http.ResponseWriter, r *http.Request, pat
 			utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
 		}
 	} else {
-		// /api/admin/apikeys/{id}/permissions 或 /reset-secret
+		// /api/admin/apikeys/{id}/permissions、/reset-secret 或 /test
 		action := parts[1]
 		switch action {
 		case "permissions":
@@ -162,6 +245,12 @@ func (h *Handler) handleAPIKeyDetail(w http.ResponseWriter, r *http.Request, pat
 			} else {
 				utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
 			}
+		case "test":
+			if r.Method == http.MethodPost {
+				h.testAPIKey(w, r, key)
+			} else {
+				utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+			}
 		default:
 			utils.WriteErrorResponse(w, "NotFound", "API endpoint not found", http.StatusNotFound)
 		}
@@ -179,12 +268,23 @@ func (h *Handler) getAPIKey(w http.ResponseWriter, r *http.Request, accessKeyID
 
 	perms, _ := h.metadata.GetAPIKeyPermissions(accessKeyID)
 
+	window := time.Duration(config.Global.Security.PresignWindowMinutes) * time.Minute
+	if window <= 0 {
+		window = time.Hour
+	}
+	usage, err := h.metadata.GetPresignUsage(accessKeyID, config.Global.Security.PresignLimit, window, time.Now())
+	if err != nil {
+		utils.Warn("get presign usage failed", "access_key_id", accessKeyID, "error", err)
+	}
+
 	utils.WriteJSONResponse(w, APIKeyResponse{
-		AccessKeyID: key.AccessKeyID,
-		Description: key.Description,
-		CreatedAt:   key.CreatedAt.Format(time.RFC3339),
-		Enabled:     key.Enabled,
-		Permissions: perms,
+		AccessKeyID:  key.AccessKeyID,
+		Description:  key.Description,
+		CreatedAt:    key.CreatedAt.Format(time.RFC3339),
+		Enabled:      key.Enabled,
+		Namespace:    key.Namespace,
+		Permissions:  perms,
+		PresignUsage: usage,
 	})
 }
 
@@ -195,6 +295,13 @@ func (h *Handler) updateAPIKey(w http.ResponseWriter, r *http.Request, accessKey
 		utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
 		return
 	}
+	if req.Namespace != nil && *req.Namespace != "" && !storage.IsValidNamespace(*req.Namespace) {
+		writeInvalidNamespace(w)
+		return
+	}
+	if req.Namespace != nil && *req.Namespace != "" && !h.checkNamespaceOverlap(w, *req.Namespace, accessKeyID) {
+		return
+	}
 
 	if req.Description != nil {
 		if err := h.metadata.UpdateAPIKeyDescription(accessKeyID, *req.Description); err != nil {
@@ -212,15 +319,48 @@ func (h *Handler) updateAPIKey(w http.ResponseWriter, r *http.Request, accessKey
 		}
 	}
 
+	var details interface{}
+	if req.Namespace != nil {
+		if err := h.metadata.UpdateAPIKeyNamespace(accessKeyID, *req.Namespace); err != nil {
+			utils.Error("update api key namespace failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		details = map[string]string{"namespace": *req.Namespace}
+	}
+
 	// 刷新缓存
 	auth.ReloadAPIKeyCache()
 
 	// 记录审计日志
-	h.Audit(r, storage.AuditActionAPIKeyUpdate, "admin", accessKeyID, true, nil)
+	h.Audit(r, storage.AuditActionAPIKeyUpdate, "admin", accessKeyID, true, details)
 
 	h.getAPIKey(w, r, accessKeyID)
 }
 
+// writeInvalidNamespace 命名空间格式错误
+func writeInvalidNamespace(w http.ResponseWriter) {
+	utils.WriteErrorResponse(w, "InvalidParameter",
+		"namespace must be 1-"+strconv.Itoa(storage.MaxNamespaceLength)+" lowercase letters, digits or hyphens, starting with a letter or digit",
+		http.StatusBadRequest)
+}
+
+// checkNamespaceOverlap 拒绝与其他 Key 的命名空间互为前缀的命名空间，否则一方可访问另一方的桶
+func (h *Handler) checkNamespaceOverlap(w http.ResponseWriter, namespace, accessKeyID string) bool {
+	existing, err := h.metadata.FindOverlappingNamespace(namespace, accessKeyID)
+	if err != nil {
+		utils.Error("check api key namespace failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return false
+	}
+	if existing != "" {
+		utils.WriteErrorResponse(w, "NamespaceConflict",
+			"namespace overlaps with existing namespace "+strconv.Quote(existing), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
 // deleteAPIKey 删除 API Key
 func (h *Handler) deleteAPIKey(w http.ResponseWriter, r *http.Request, accessKeyID string) {
 	if err := h.metadata.DeleteAPIKey(accessKeyID); err != nil {
@@ -357,6 +497,58 @@ func (h *Handler) resetAPIKeySecret(w http.ResponseWriter, r *http.Request, acce
 		Description:     key.Description,
 		CreatedAt:       key.CreatedAt.Format(time.RFC3339),
 		Enabled:         key.Enabled,
+		Namespace:       key.Namespace,
 		Permissions:     perms,
 	})
 }
+
+// testAPIKey 用内部签名往返验证 API Key，并报告其在各桶上的有效权限
+// POST /api/admin/apikeys/{id}/test?bucket=xxx
+// 不传 bucket 时返回所有至少可读或可写的桶
+func (h *Handler) testAPIKey(w http.ResponseWriter, r *http.Request, key *storage.APIKey) {
+	var buckets []storage.Bucket
+	if name := r.URL.Query().Get("bucket"); name != "" {
+		bucket, err := h.metadata.GetBucket(name)
+		if err != nil {
+			utils.Error("get bucket failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		if bucket == nil {
+			utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "")
+			return
+		}
+		buckets = []storage.Bucket{*bucket}
+	} else {
+		var err error
+		buckets, err = h.metadata.ListBuckets()
+		if err != nil {
+			utils.Error("list buckets failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+	}
+
+	resp := APIKeyTestResponse{
+		AccessKeyID: key.AccessKeyID,
+		Enabled:     key.Enabled,
+		SignatureOK: auth.VerifyKeyRoundTrip(key.AccessKeyID),
+		Buckets:     []APIKeyBucketAccess{},
+	}
+	for _, b := range buckets {
+		// 带命名空间的 Key 无法访问命名空间之外的桶
+		if !strings.HasPrefix(b.Name, storage.NamespacePrefix(key.Namespace)) {
+			continue
+		}
+		access := APIKeyBucketAccess{
+			Bucket:   b.Name,
+			CanRead:  auth.CheckBucketPermission(key.AccessKeyID, b.Name, false),
+			CanWrite: auth.CheckBucketPermission(key.AccessKeyID, b.Name, true),
+		}
+		if access.CanRead || access.CanWrite {
+			resp.Buckets = append(resp.Buckets, access)
+		}
+	}
+
+	utils.WriteJSONResponse(w, resp)
+}
@@ -28,8 +28,9 @@ func (h *Handler) handleAuditLogs(w http.ResponseWriter, r *http.Request) {
 	query := &storage.AuditLogQuery{}
 
 	// 解析查询参数
+	// action 支持逗号分隔多个操作及别名（如 login_failure、settings_change）
 	if action := r.URL.Query().Get("action"); action != "" {
-		query.Action = storage.AuditAction(action)
+		query.Actions = storage.ParseAuditActions(action)
 	}
 	if actor := r.URL.Query().Get("actor"); actor != "" {
 		query.Actor = actor
@@ -37,6 +38,9 @@ func (h *Handler) handleAuditLogs(w http.ResponseWriter, r *http.Request) {
 	if ip := r.URL.Query().Get("ip"); ip != "" {
 		query.IP = ip
 	}
+	if traceID := r.URL.Query().Get("trace_id"); traceID != "" {
+		query.TraceID = traceID
+	}
 	if resource := r.URL.Query().Get("resource"); resource != "" {
 		query.Resource = resource
 	}
@@ -85,6 +89,59 @@ func (h *Handler) handleAuditLogs(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleObjectHistory 查询单个对象的审计时间线（上传、覆盖、复制、删除等），按时间正序
+// GET /api/admin/audit/object?bucket=&key=&page=&limit=
+// 只匹配资源为 bucket/key 的记录，批量删除等以桶为资源的记录不包含在内
+func (h *Handler) handleObjectHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	key := r.URL.Query().Get("key")
+	if bucket == "" || key == "" {
+		utils.WriteErrorResponse(w, "InvalidParameter", "bucket and key are required", http.StatusBadRequest)
+		return
+	}
+
+	page := 1
+	if p := r.URL.Query().Get("page"); p != "" {
+		if v, err := strconv.Atoi(p); err == nil && v > 0 {
+			page = v
+		}
+	}
+	query := &storage.AuditLogQuery{
+		Resource:      bucket + "/" + key,
+		ExactResource: true,
+		Ascending:     true,
+		Limit:         50,
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		if v, err := strconv.Atoi(limit); err == nil && v > 0 && v <= 100 {
+			query.Limit = v
+		}
+	}
+	query.Offset = (page - 1) * query.Limit
+
+	logs, total, err := h.metadata.QueryAuditLogs(query)
+	if err != nil {
+		utils.Error("查询对象审计历史失败", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	if logs == nil {
+		logs = []storage.AuditLog{}
+	}
+
+	utils.WriteJSONResponse(w, AuditLogResponse{
+		Logs:  logs,
+		Total: total,
+		Limit: query.Limit,
+		Page:  page,
+	})
+}
+
 // handleAuditStats 获取审计统计
 func (h *Handler) handleAuditStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -140,6 +197,8 @@ func (h *Handler) Audit(r *http.Request, action storage.AuditAction, actor, reso
 		Detail:      detailStr,
 		Success:     success,
 		UserAgent:   utils.GetUserAgent(r),
+		RequestID:   utils.GetRequestID(r),
+		TraceID:     utils.GetTraceID(r),
 	}
 
 	if err := h.metadata.WriteAuditLog(log); err != nil {
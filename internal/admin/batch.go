@@ -4,9 +4,12 @@ import (
 	"archive/zip"
 	"io"
 	"net/http"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"sss/internal/storage"
 	"sss/internal/utils"
 )
 
@@ -22,9 +25,26 @@ type BatchDeleteResult struct {
 	FailedKeys   []string `json:"failed_keys"`   // 失败的 key 列表
 }
 
+// BatchTagRequest 批量设置/移除标签请求，keys 与 prefix 二选一
+type BatchTagRequest struct {
+	Keys    []string          `json:"keys"`     // 要修改的 key 列表
+	Prefix  string            `json:"prefix"`   // 按前缀选择对象（最多 1000 个）
+	Tags    map[string]string `json:"tags"`     // 批量打标签：与已有标签合并，同名覆盖
+	TagKeys []string          `json:"tag_keys"` // 批量移除标签：要移除的标签键，为空时清除全部标签
+}
+
+// BatchTagResult 批量标签操作结果
+type BatchTagResult struct {
+	UpdatedCount int      `json:"updated_count"` // 成功修改数量
+	FailedCount  int      `json:"failed_count"`  // 失败数量
+	FailedKeys   []string `json:"failed_keys"`   // 失败的 key 列表
+}
+
 // BatchDownloadRequest 批量下载请求
 type BatchDownloadRequest struct {
-	Keys []string `json:"keys"` // 要下载的 key 列表
+	Keys          []string `json:"keys"`          // 要下载的 key 列表
+	PreservePaths bool     `json:"preservePaths"` // 保留完整路径（默认只保留文件名）
+	StripPrefix   string   `json:"stripPrefix"`   // 从条目名称中去掉的公共前缀
 }
 
 // batchDeleteObjects 批量删除对象
@@ -82,13 +102,137 @@ func (h *Handler) batchDeleteObjects(w http.ResponseWriter, r *http.Request, buc
 			result.FailedKeys = append(result.FailedKeys, key)
 			continue
 		}
+		h.Replicate(bucketName, key, storage.ReplicationOpDelete)
 
 		result.DeletedCount++
 	}
 
+	h.Audit(r, storage.AuditActionBatchDelete, "admin", bucketName, result.FailedCount == 0, map[string]interface{}{
+		"deleted": result.DeletedCount,
+		"failed":  result.FailedCount,
+	})
+	utils.WriteJSONResponse(w, result)
+}
+
+// batchTagObjects 批量设置或移除对象标签，remove 为 true 时移除
+func (h *Handler) batchTagObjects(w http.ResponseWriter, r *http.Request, bucketName string, remove bool) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	var req BatchTagRequest
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+		return
+	}
+
+	if (len(req.Keys) == 0) == (req.Prefix == "") {
+		utils.WriteErrorResponse(w, "InvalidParameter", "exactly one of keys and prefix is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Keys) > 1000 {
+		utils.WriteErrorResponse(w, "InvalidParameter", "Maximum 1000 keys per request", http.StatusBadRequest)
+		return
+	}
+	if !remove {
+		if len(req.Tags) == 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "tags is required", http.StatusBadRequest)
+			return
+		}
+		if _, err := storage.ValidateObjectTags(objectTagList(req.Tags)); err != nil {
+			utils.WriteErrorResponse(w, "InvalidTag", err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	keys := req.Keys
+	if req.Prefix != "" {
+		list, err := h.metadata.ListObjects(bucketName, req.Prefix, "", "", 1000)
+		if err != nil {
+			utils.Error("list objects for batch tagging failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		for _, obj := range list.Contents {
+			keys = append(keys, obj.Key)
+		}
+	}
+
+	result := BatchTagResult{
+		FailedKeys: make([]string, 0),
+	}
+	for _, key := range keys {
+		// 安全检查：防止路径遍历
+		if strings.Contains(key, "..") {
+			result.FailedCount++
+			result.FailedKeys = append(result.FailedKeys, key)
+			continue
+		}
+
+		obj, err := h.metadata.GetObject(bucketName, key)
+		if err != nil || obj == nil {
+			result.FailedCount++
+			result.FailedKeys = append(result.FailedKeys, key)
+			continue
+		}
+
+		tags := make(map[string]string, len(obj.Tags)+len(req.Tags))
+		for k, v := range obj.Tags {
+			tags[k] = v
+		}
+		if remove {
+			if len(req.TagKeys) == 0 {
+				tags = nil
+			}
+			for _, k := range req.TagKeys {
+				delete(tags, k)
+			}
+		} else {
+			for k, v := range req.Tags {
+				tags[k] = v
+			}
+			// 合并后可能超过每个对象的标签数上限
+			if len(tags) > storage.MaxObjectTags {
+				result.FailedCount++
+				result.FailedKeys = append(result.FailedKeys, key)
+				continue
+			}
+		}
+
+		if ok, err := h.metadata.PutObjectTags(bucketName, key, tags); err != nil || !ok {
+			if err != nil {
+				utils.Error("batch tag object failed", "key", key, "error", err)
+			}
+			result.FailedCount++
+			result.FailedKeys = append(result.FailedKeys, key)
+			continue
+		}
+		result.UpdatedCount++
+	}
+
+	action := storage.AuditActionBatchTag
+	if remove {
+		action = storage.AuditActionBatchUntag
+	}
+	h.Audit(r, action, "admin", bucketName, result.FailedCount == 0, map[string]interface{}{
+		"updated": result.UpdatedCount,
+		"failed":  result.FailedCount,
+		"prefix":  req.Prefix,
+	})
 	utils.WriteJSONResponse(w, result)
 }
 
+// objectTagList 将标签映射按键排序转换为列表，便于校验
+func objectTagList(tags map[string]string) []storage.ObjectTag {
+	list := make([]storage.ObjectTag, 0, len(tags))
+	for k, v := range tags {
+		list = append(list, storage.ObjectTag{Key: k, Value: v})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
 // batchDownloadObjects 批量下载对象（打包为 ZIP）
 func (h *Handler) batchDownloadObjects(w http.ResponseWriter, r *http.Request, bucketName string) {
 	if r.Method != http.MethodPost {
@@ -115,18 +259,22 @@ func (h *Handler) batchDownloadObjects(w http.ResponseWriter, r *http.Request, b
 
 	// 设置响应头
 	w.Header().Set("Content-Type", "application/zip")
-	w.Header().Set("Content-Disposition", "attachment; filename=\""+bucketName+"-batch.zip\"")
+	w.Header().Set("Content-Disposition", utils.ContentDisposition("attachment", bucketName+"-batch.zip"))
 
 	// 创建 ZIP 写入器
 	zipWriter := zip.NewWriter(w)
 	defer zipWriter.Close()
 
+	// 安全检查：防止路径遍历
+	keys := make([]string, 0, len(req.Keys))
 	for _, key := range req.Keys {
-		// 安全检查：防止路径遍历
-		if strings.Contains(key, "..") {
-			continue
+		if !strings.Contains(key, "..") {
+			keys = append(keys, key)
 		}
+	}
+	names := archiveEntryNames(keys, req.StripPrefix, req.PreservePaths)
 
+	for i, key := range keys {
 		// 获取对象元数据
 		obj, err := h.metadata.GetObject(bucketName, key)
 		if err != nil || obj == nil {
@@ -135,16 +283,11 @@ func (h *Handler) batchDownloadObjects(w http.ResponseWriter, r *http.Request, b
 
 		// 创建 ZIP 条目
 		header := &zip.FileHeader{
-			Name:     filepath.Base(key), // 使用文件名而非完整路径
+			Name:     names[i],
 			Method:   zip.Deflate,
 			Modified: obj.LastModified,
 		}
 
-		// 如果有同名文件，使用完整路径
-		if containsDuplicate(req.Keys, key) {
-			header.Name = key
-		}
-
 		zipEntry, err := zipWriter.CreateHeader(header)
 		if err != nil {
 			utils.Error("create zip entry failed", "key", key, "error", err)
@@ -152,7 +295,7 @@ func (h *Handler) batchDownloadObjects(w http.ResponseWriter, r *http.Request, b
 		}
 
 		// 读取并写入文件内容
-		reader, err := h.filestore.GetObject(obj.StoragePath)
+		reader, err := h.filestore.OpenObject(obj)
 		if err != nil {
 			utils.Error("read file for zip failed", "key", key, "error", err)
 			continue
@@ -166,6 +309,68 @@ func (h *Handler) batchDownloadObjects(w http.ResponseWriter, r *http.Request, b
 	}
 }
 
+// archiveEntryNames 计算压缩包条目名称
+// 先去掉 stripPrefix，再按 preservePaths 决定保留路径或只保留文件名；
+// 命名冲突的条目依次退回到去前缀后的路径、完整 key，保证名称唯一
+func archiveEntryNames(keys []string, stripPrefix string, preservePaths bool) []string {
+	stripped := make([]string, len(keys))
+	for i, key := range keys {
+		name := key
+		if stripPrefix != "" && strings.HasPrefix(key, stripPrefix) {
+			name = strings.TrimLeft(strings.TrimPrefix(key, stripPrefix), "/")
+		}
+		if name == "" {
+			name = path.Base(key)
+		}
+		stripped[i] = name
+	}
+
+	names := make([]string, len(keys))
+	levels := make([]int, len(keys)) // 0: 转换后名称 1: 去前缀路径 2: 完整 key
+	for i := range keys {
+		if preservePaths {
+			names[i] = stripped[i]
+			levels[i] = 1
+		} else {
+			names[i] = path.Base(stripped[i])
+		}
+	}
+
+	for {
+		var conflicts []int
+		for i := range names {
+			if levels[i] < 2 && isDuplicateName(names, names[i], levels[i] == 0) {
+				conflicts = append(conflicts, i)
+			}
+		}
+		if len(conflicts) == 0 {
+			return names
+		}
+		for _, i := range conflicts {
+			levels[i]++
+			if levels[i] == 1 {
+				names[i] = stripped[i]
+			} else {
+				names[i] = keys[i]
+			}
+		}
+	}
+}
+
+// isDuplicateName 检查名称是否冲突，只保留文件名时按文件名比较
+func isDuplicateName(names []string, name string, baseOnly bool) bool {
+	if baseOnly {
+		return containsDuplicate(names, name)
+	}
+	count := 0
+	for _, n := range names {
+		if n == name {
+			count++
+		}
+	}
+	return count > 1
+}
+
 // containsDuplicate 检查是否有同名文件
 func containsDuplicate(keys []string, currentKey string) bool {
 	baseName := filepath.Base(currentKey)
@@ -1,19 +1,39 @@
 package admin
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"sss/internal/config"
 	"sss/internal/storage"
 	"sss/internal/utils"
 )
 
 // AdminBucketInfo 管理员 API 桶信息
 type AdminBucketInfo struct {
-	Name         string `json:"name"`
-	CreationDate string `json:"creation_date"`
-	IsPublic     bool   `json:"is_public"`
+	Name             string                  `json:"name"`
+	CreationDate     string                  `json:"creation_date"`
+	IsPublic         bool                    `json:"is_public"`
+	ContentTypeMode  string                  `json:"content_type_mode"`
+	ContentTypes     string                  `json:"content_types"`
+	ContentTypeSniff bool                    `json:"content_type_sniff"`
+	KeyDenylist      []string                `json:"key_denylist"`
+	ImmutableMinutes int                     `json:"immutable_minutes"`
+	MaxReadAgeDays   int                     `json:"max_read_age_days"`
+	ReadAgeBasis     string                  `json:"read_age_basis"`
+	ImageTransform   bool                    `json:"image_transform"`
+	AllowedMethods   []string                `json:"allowed_methods"`
+	ReadOnly         bool                    `json:"read_only"`
+	PrefixRewrites   []storage.PrefixRewrite `json:"prefix_rewrites"`
+	DefaultHeaders   map[string]string       `json:"default_headers"`
+	WebsiteIndex     string                  `json:"website_index"`
+	WebsiteSPA       bool                    `json:"website_spa"`
+	Versioning       bool                    `json:"versioning"`
+	Policy           *storage.BucketPolicy   `json:"policy"`
+	LifecycleRules   []storage.LifecycleRule `json:"lifecycle_rules"`
+	QuotaBytes       int64                   `json:"quota_bytes"`
 }
 
 // CreateBucketRequest 创建桶请求
@@ -26,6 +46,114 @@ type SetBucketPublicRequest struct {
 	IsPublic bool `json:"is_public"`
 }
 
+// BucketContentTypesRequest 设置桶内容类型限制请求
+type BucketContentTypesRequest struct {
+	Mode     string   `json:"mode"`     // allow/block，空表示不限制
+	Patterns []string `json:"patterns"` // 类型模式，如 image/*
+	Sniff    bool     `json:"sniff"`    // 是否嗅探实际内容
+}
+
+// BucketImmutabilityRequest 设置桶对象不可变窗口请求
+type BucketImmutabilityRequest struct {
+	Minutes int `json:"minutes"` // 0 表示关闭
+}
+
+// BucketReadAgeRequest 设置桶最大可读天数请求
+type BucketReadAgeRequest struct {
+	Days  int    `json:"days"`  // 0 表示关闭
+	Basis string `json:"basis"` // modified（默认）或 created
+}
+
+// BucketReadAgeResponse 桶最大可读天数响应
+type BucketReadAgeResponse struct {
+	Days    int    `json:"days"`
+	Basis   string `json:"basis"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// BucketTransformRequest 设置桶图片按需缩放请求/响应
+type BucketTransformRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// BucketReadOnlyRequest 设置桶只读请求/响应
+type BucketReadOnlyRequest struct {
+	ReadOnly bool `json:"read_only"`
+}
+
+// BucketQuotaRequest 设置桶存储配额请求，0 表示不限制
+type BucketQuotaRequest struct {
+	QuotaBytes int64 `json:"quota_bytes"`
+}
+
+// BucketQuotaResponse 桶存储配额及当前对象总大小
+type BucketQuotaResponse struct {
+	QuotaBytes int64 `json:"quota_bytes"`
+	UsedBytes  int64 `json:"used_bytes"`
+}
+
+// BucketVersioningRequest 设置桶版本控制请求/响应
+type BucketVersioningRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// BucketPolicyRequest 设置桶策略请求/响应，Policy 为 null 表示删除
+type BucketPolicyRequest struct {
+	Policy *storage.BucketPolicy `json:"policy"`
+}
+
+// BucketLifecycleRequest 设置桶生命周期规则请求/响应，空列表表示删除
+type BucketLifecycleRequest struct {
+	Rules []storage.LifecycleRule `json:"rules"`
+}
+
+// ObjectVersionInfo 对象版本信息
+type ObjectVersionInfo struct {
+	VersionID    string `json:"version_id"`
+	IsLatest     bool   `json:"is_latest"`
+	DeleteMarker bool   `json:"delete_marker"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+// BucketMethodsRequest 设置桶允许的 HTTP 方法请求/响应
+type BucketMethodsRequest struct {
+	Methods []string `json:"methods"` // 如 GET、HEAD，空表示不限制
+}
+
+// BucketPrefixRewritesRequest 设置桶对象键前缀改写请求/响应
+type BucketPrefixRewritesRequest struct {
+	Rules []storage.PrefixRewrite `json:"rules"` // 为空表示关闭
+}
+
+// readAgeWarning 提示该限制只阻止读取，不会删除数据
+const readAgeWarning = "max read age only blocks GET/HEAD via the S3 API (410 Gone); objects are NOT deleted and still consume storage, archive or delete them externally"
+
+// readAgeBasis 返回最大可读天数的计算基准，空为 modified
+func readAgeBasis(basis string) string {
+	if basis == "" {
+		return storage.ReadAgeBasisModified
+	}
+	return basis
+}
+
+// BucketDefaultHeadersRequest 设置桶默认响应头请求
+type BucketDefaultHeadersRequest struct {
+	Headers map[string]string `json:"headers"` // 为空表示清除
+}
+
+// BucketWebsiteRequest 设置桶静态网站请求
+type BucketWebsiteRequest struct {
+	IndexDocument string `json:"index_document"` // 空表示关闭
+	SPA           bool   `json:"spa"`            // 对象不存在时返回索引文档
+}
+
+// BucketKeyDenylistRequest 设置桶保留键请求
+type BucketKeyDenylistRequest struct {
+	Patterns []string `json:"patterns"` // glob 模式，如 index.html、private/*
+}
+
 // handleAdminBucketsAPI 管理员桶列表/创建 API
 func (h *Handler) handleAdminBucketsAPI(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -50,9 +178,27 @@ func (h *Handler) adminListBuckets(w http.ResponseWriter, r *http.Request) {
 	result := make([]AdminBucketInfo, 0, len(buckets))
 	for _, b := range buckets {
 		result = append(result, AdminBucketInfo{
-			Name:         b.Name,
-			CreationDate: b.CreationDate.Format(time.RFC3339),
-			IsPublic:     b.IsPublic,
+			Name:             b.Name,
+			CreationDate:     b.CreationDate.Format(time.RFC3339),
+			IsPublic:         b.IsPublic,
+			ContentTypeMode:  b.ContentTypeMode,
+			ContentTypes:     b.ContentTypes,
+			ContentTypeSniff: b.ContentTypeSniff,
+			KeyDenylist:      nonNilStrings(storage.ParseKeyPatterns(b.KeyDenylist)),
+			ImmutableMinutes: b.ImmutableMinutes,
+			MaxReadAgeDays:   b.MaxReadAgeDays,
+			ReadAgeBasis:     readAgeBasis(b.ReadAgeBasis),
+			ImageTransform:   b.ImageTransform,
+			AllowedMethods:   bucketAllowedMethods(&b),
+			ReadOnly:         b.ReadOnly,
+			PrefixRewrites:   nonNilRewrites(b.PrefixRewrites),
+			DefaultHeaders:   nonNilHeaders(b.DefaultHeaders),
+			WebsiteIndex:     b.WebsiteIndex,
+			WebsiteSPA:       b.WebsiteSPA,
+			Versioning:       b.VersioningEnabled,
+			Policy:           b.Policy,
+			LifecycleRules:   b.LifecycleRules,
+			QuotaBytes:       b.QuotaBytes,
 		})
 	}
 
@@ -90,6 +236,20 @@ func (h *Handler) adminCreateBucket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 检查桶数量上限
+	if maxBuckets := config.Global.Storage.MaxBuckets; maxBuckets > 0 {
+		count, err := h.metadata.CountBuckets()
+		if err != nil {
+			utils.Error("count buckets failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		if count >= maxBuckets {
+			utils.WriteErrorResponse(w, "TooManyBuckets", "Bucket limit reached", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// 创建桶
 	if err := h.metadata.CreateBucket(req.Name); err != nil {
 		utils.Error("create bucket failed", "error", err)
@@ -143,9 +303,27 @@ func (h *Handler) handleAdminBucketOps(w http.ResponseWriter, r *http.Request, p
 		case http.MethodGet:
 			// 获取桶详情
 			utils.WriteJSONResponse(w, AdminBucketInfo{
-				Name:         bucket.Name,
-				CreationDate: bucket.CreationDate.Format(time.RFC3339),
-				IsPublic:     bucket.IsPublic,
+				Name:             bucket.Name,
+				CreationDate:     bucket.CreationDate.Format(time.RFC3339),
+				IsPublic:         bucket.IsPublic,
+				ContentTypeMode:  bucket.ContentTypeMode,
+				ContentTypes:     bucket.ContentTypes,
+				ContentTypeSniff: bucket.ContentTypeSniff,
+				KeyDenylist:      nonNilStrings(storage.ParseKeyPatterns(bucket.KeyDenylist)),
+				ImmutableMinutes: bucket.ImmutableMinutes,
+				MaxReadAgeDays:   bucket.MaxReadAgeDays,
+				ReadAgeBasis:     readAgeBasis(bucket.ReadAgeBasis),
+				ImageTransform:   bucket.ImageTransform,
+				AllowedMethods:   bucketAllowedMethods(bucket),
+				ReadOnly:         bucket.ReadOnly,
+				PrefixRewrites:   nonNilRewrites(bucket.PrefixRewrites),
+				DefaultHeaders:   nonNilHeaders(bucket.DefaultHeaders),
+				WebsiteIndex:     bucket.WebsiteIndex,
+				WebsiteSPA:       bucket.WebsiteSPA,
+				Versioning:       bucket.VersioningEnabled,
+				Policy:           bucket.Policy,
+				LifecycleRules:   bucket.LifecycleRules,
+				QuotaBytes:       bucket.QuotaBytes,
 			})
 		case http.MethodPut:
 			// 更新桶设置（公开状态）
@@ -175,18 +353,68 @@ func (h *Handler) handleAdminBucketOps(w http.ResponseWriter, r *http.Request, p
 		switch action {
 		case "public":
 			h.adminSetBucketPublic(w, r, bucketName)
+		case "content-types":
+			h.adminBucketContentTypes(w, r, bucket)
+		case "key-denylist":
+			h.adminBucketKeyDenylist(w, r, bucket)
+		case "immutability":
+			h.adminBucketImmutability(w, r, bucket)
+		case "read-age":
+			h.adminBucketReadAge(w, r, bucket)
+		case "transform":
+			h.adminBucketTransform(w, r, bucket)
+		case "allowed-methods":
+			h.adminBucketAllowedMethods(w, r, bucket)
+		case "read-only":
+			h.adminBucketReadOnly(w, r, bucket)
+		case "versioning":
+			h.adminBucketVersioning(w, r, bucket)
+		case "quota":
+			h.adminBucketQuota(w, r, bucket)
+		case "versions":
+			h.adminObjectVersions(w, r, bucketName)
+		case "uploads":
+			h.adminBucketUploads(w, r, bucketName)
+		case "policy":
+			h.adminBucketPolicy(w, r, bucket)
+		case "lifecycle":
+			h.adminBucketLifecycle(w, r, bucket)
+		case "prefix-rewrites":
+			h.adminBucketPrefixRewrites(w, r, bucket)
+		case "default-headers":
+			h.adminBucketDefaultHeaders(w, r, bucket)
+		case "website":
+			h.adminBucketWebsite(w, r, bucket)
+		case "replication":
+			h.handleBucketReplication(w, r, bucketName)
+		case "notification":
+			h.handleBucketNotification(w, r, bucketName)
+		case "notification/test":
+			h.handleBucketNotificationTest(w, r, bucketName)
+		case "origin":
+			h.handleBucketOrigin(w, r, bucketName)
+		case "usage":
+			h.adminBucketUsage(w, r, bucketName)
 		case "objects":
 			h.adminObjectsHandler(w, r, bucketName)
+		case "undo-delete":
+			h.adminUndoDelete(w, r, bucketName)
 		case "upload":
 			h.adminUploadObject(w, r, bucketName)
 		case "download":
 			h.adminDownloadObject(w, r, bucketName)
+		case "checksum":
+			h.adminObjectChecksum(w, r, bucketName)
 		case "copy":
 			h.adminCopyObject(w, r, bucketName)
 		case "search":
 			h.adminSearchObjects(w, r, bucketName)
 		case "batch/delete":
 			h.batchDeleteObjects(w, r, bucketName)
+		case "batch/tag":
+			h.batchTagObjects(w, r, bucketName, false)
+		case "batch/untag":
+			h.batchTagObjects(w, r, bucketName, true)
 		case "batch/download":
 			h.batchDownloadObjects(w, r, bucketName)
 		case "preview":
@@ -198,7 +426,38 @@ func (h *Handler) handleAdminBucketOps(w http.ResponseWriter, r *http.Request, p
 }
 
 // adminDeleteBucket 删除桶
+// 指定 force=true 且 confirm 等于桶名时先清空桶再删除
 func (h *Handler) adminDeleteBucket(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if force, ok := ForceDeleteRequested(r, bucketName); force {
+		if !ok {
+			utils.WriteErrorResponse(w, "InvalidParameter", "confirm must equal the bucket name for force delete", http.StatusBadRequest)
+			return
+		}
+		bucket, err := h.metadata.GetBucket(bucketName)
+		if err != nil {
+			utils.Error("get bucket failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		if bucket == nil {
+			utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "")
+			return
+		}
+		purge, err := h.ForceDeleteBucket(r, bucket, "admin", false)
+		if err != nil {
+			utils.Error("force delete bucket failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"success":         true,
+			"deleted_objects": purge.Objects,
+			"deleted_bytes":   purge.Bytes,
+			"aborted_uploads": len(purge.UploadIDs),
+		})
+		return
+	}
+
 	if err := h.metadata.DeleteBucket(bucketName); err != nil {
 		if strings.Contains(err.Error(), "not empty") {
 			utils.WriteErrorResponse(w, "BucketNotEmpty", "Bucket is not empty", http.StatusConflict)
@@ -211,6 +470,9 @@ func (h *Handler) adminDeleteBucket(w http.ResponseWriter, r *http.Request, buck
 
 	// 删除存储目录
 	h.filestore.DeleteBucket(bucketName)
+	h.replicator.ForgetBucket(bucketName)
+	h.notifier.ForgetBucket(bucketName)
+	h.origins.ForgetBucket(bucketName)
 
 	// 记录审计日志
 	h.Audit(r, storage.AuditActionBucketDelete, "admin", bucketName, true, nil)
@@ -218,6 +480,54 @@ func (h *Handler) adminDeleteBucket(w http.ResponseWriter, r *http.Request, buck
 	utils.WriteJSONResponse(w, map[string]bool{"success": true})
 }
 
+// ForceDeleteRequested 判断请求是否要求强制删除桶，ok 表示 confirm 参数与桶名一致
+func ForceDeleteRequested(r *http.Request, bucketName string) (force, ok bool) {
+	q := r.URL.Query()
+	if q.Get("force") != "true" {
+		return false, false
+	}
+	return true, q.Get("confirm") == bucketName
+}
+
+// ForceDeleteBucket 清空并删除桶：对象、未完成的分片上传及其磁盘文件，并记录删除数量到审计日志
+// 元数据在一个事务中删除，之后迟到的写入会被拒绝；respectImmutability 为 true 时
+// 桶中存在处于不可变窗口内的对象则整体放弃并返回 storage.ErrBucketImmutable
+func (h *Handler) ForceDeleteBucket(r *http.Request, bucket *storage.Bucket, actor string, respectImmutability bool) (*storage.BucketPurge, error) {
+	var immutableSince time.Time
+	if respectImmutability && bucket.ImmutableMinutes > 0 {
+		immutableSince = time.Now().Add(-time.Duration(bucket.ImmutableMinutes) * time.Minute)
+	}
+	purge, err := h.metadata.ForceDeleteBucket(bucket.Name, immutableSince)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range purge.StoragePaths {
+		if err := h.filestore.DeleteObject(path); err != nil {
+			utils.Warn("delete object file failed", "path", path, "error", err)
+		}
+	}
+	for _, uploadID := range purge.UploadIDs {
+		if err := h.filestore.AbortMultipartUpload(uploadID); err != nil {
+			utils.Warn("abort multipart upload failed", "upload_id", uploadID, "error", err)
+		}
+	}
+	if err := h.filestore.DeleteBucket(bucket.Name); err != nil {
+		utils.Warn("delete bucket directory failed", "bucket", bucket.Name, "error", err)
+	}
+	h.replicator.ForgetBucket(bucket.Name)
+	h.notifier.ForgetBucket(bucket.Name)
+	h.origins.ForgetBucket(bucket.Name)
+
+	h.Audit(r, storage.AuditActionBucketDelete, actor, bucket.Name, true, map[string]interface{}{
+		"force":           true,
+		"deleted_objects": purge.Objects,
+		"deleted_bytes":   purge.Bytes,
+		"aborted_uploads": len(purge.UploadIDs),
+	})
+	return purge, nil
+}
+
 // adminSetBucketPublic 设置桶公开状态
 func (h *Handler) adminSetBucketPublic(w http.ResponseWriter, r *http.Request, bucketName string) {
 	switch r.Method {
@@ -246,3 +556,561 @@ func (h *Handler) adminSetBucketPublic(w http.ResponseWriter, r *http.Request, b
 		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
 	}
 }
+
+// adminBucketContentTypes 获取/设置桶内容类型限制
+// GET/PUT /api/admin/buckets/{bucket}/content-types
+func (h *Handler) adminBucketContentTypes(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"mode":     bucket.ContentTypeMode,
+			"patterns": nonNilStrings(storage.ParseContentTypePatterns(bucket.ContentTypes)),
+			"sniff":    bucket.ContentTypeSniff,
+		})
+	case http.MethodPut:
+		var req BucketContentTypesRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if !storage.IsValidContentTypeMode(req.Mode) {
+			utils.WriteErrorResponse(w, "InvalidParameter", "mode must be allow, block or empty", http.StatusBadRequest)
+			return
+		}
+		patterns := storage.ParseContentTypePatterns(strings.Join(req.Patterns, ","))
+		for _, p := range patterns {
+			if !strings.Contains(p, "/") && p != "*" {
+				utils.WriteErrorResponse(w, "InvalidParameter", "Invalid content type pattern: "+p, http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Mode == storage.ContentTypeModeAllow && len(patterns) == 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "allow mode requires at least one pattern", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.metadata.UpdateBucketContentTypes(bucket.Name, req.Mode, strings.Join(patterns, ","), req.Sniff); err != nil {
+			utils.Error("update bucket content types failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetContentTypes, "admin", bucket.Name, true, map[string]interface{}{
+			"mode":     req.Mode,
+			"patterns": patterns,
+			"sniff":    req.Sniff,
+		})
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"mode":     req.Mode,
+			"patterns": nonNilStrings(patterns),
+			"sniff":    req.Sniff,
+		})
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketKeyDenylist 获取/设置桶保留键列表
+// GET/PUT /api/admin/buckets/{bucket}/key-denylist
+func (h *Handler) adminBucketKeyDenylist(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"patterns": nonNilStrings(storage.ParseKeyPatterns(bucket.KeyDenylist)),
+		})
+	case http.MethodPut:
+		var req BucketKeyDenylistRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		patterns := storage.ParseKeyPatterns(strings.Join(req.Patterns, "\n"))
+		for _, p := range patterns {
+			if !storage.IsValidKeyPattern(p) {
+				utils.WriteErrorResponse(w, "InvalidParameter", "Invalid key pattern: "+p, http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := h.metadata.UpdateBucketKeyDenylist(bucket.Name, strings.Join(patterns, "\n")); err != nil {
+			utils.Error("update bucket key denylist failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetKeyDenylist, "admin", bucket.Name, true, map[string]interface{}{
+			"patterns": patterns,
+		})
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"patterns": nonNilStrings(patterns),
+		})
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketImmutability 获取/设置桶对象不可变窗口
+// GET/PUT /api/admin/buckets/{bucket}/immutability
+func (h *Handler) adminBucketImmutability(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, map[string]int{"minutes": bucket.ImmutableMinutes})
+	case http.MethodPut:
+		var req BucketImmutabilityRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if req.Minutes < 0 || req.Minutes > storage.MaxImmutableMinutes {
+			utils.WriteErrorResponse(w, "InvalidParameter", fmt.Sprintf("minutes must be between 0 and %d", storage.MaxImmutableMinutes), http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.UpdateBucketImmutability(bucket.Name, req.Minutes); err != nil {
+			utils.Error("update bucket immutability failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetImmutability, "admin", bucket.Name, true, map[string]interface{}{
+			"minutes": req.Minutes,
+		})
+		utils.WriteJSONResponse(w, map[string]int{"minutes": req.Minutes})
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketReadAge 获取/设置桶最大可读天数
+// GET/PUT /api/admin/buckets/{bucket}/read-age
+func (h *Handler) adminBucketReadAge(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		resp := BucketReadAgeResponse{Days: bucket.MaxReadAgeDays, Basis: readAgeBasis(bucket.ReadAgeBasis)}
+		if resp.Days > 0 {
+			resp.Warning = readAgeWarning
+		}
+		utils.WriteJSONResponse(w, resp)
+	case http.MethodPut:
+		var req BucketReadAgeRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if req.Days < 0 || req.Days > storage.MaxReadAgeDaysLimit {
+			utils.WriteErrorResponse(w, "InvalidParameter", fmt.Sprintf("days must be between 0 and %d", storage.MaxReadAgeDaysLimit), http.StatusBadRequest)
+			return
+		}
+		if !storage.ValidReadAgeBasis(req.Basis) {
+			utils.WriteErrorResponse(w, "InvalidParameter", "basis must be modified or created", http.StatusBadRequest)
+			return
+		}
+		req.Basis = readAgeBasis(req.Basis)
+		if err := h.metadata.UpdateBucketMaxReadAge(bucket.Name, req.Days, req.Basis); err != nil {
+			utils.Error("update bucket max read age failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetReadAge, "admin", bucket.Name, true, map[string]interface{}{
+			"days":  req.Days,
+			"basis": req.Basis,
+		})
+		resp := BucketReadAgeResponse{Days: req.Days, Basis: req.Basis}
+		if resp.Days > 0 {
+			resp.Warning = readAgeWarning
+		}
+		utils.WriteJSONResponse(w, resp)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketAllowedMethods 获取/设置桶允许的 HTTP 方法
+// GET/PUT /api/admin/buckets/{bucket}/allowed-methods
+func (h *Handler) adminBucketAllowedMethods(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, BucketMethodsRequest{Methods: bucketAllowedMethods(bucket)})
+	case http.MethodPut:
+		var req BucketMethodsRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		methods, err := storage.ParseAllowedMethods(strings.Join(req.Methods, ","))
+		if err != nil {
+			utils.WriteErrorResponse(w, "InvalidParameter", "methods must be a subset of "+strings.Join(storage.BucketMethods, ", "), http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.UpdateBucketAllowedMethods(bucket.Name, strings.Join(methods, ",")); err != nil {
+			utils.Error("update bucket allowed methods failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetMethods, "admin", bucket.Name, true, map[string]interface{}{
+			"methods": methods,
+		})
+		utils.WriteJSONResponse(w, BucketMethodsRequest{Methods: nonNilStrings(methods)})
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketReadOnly 获取/设置桶只读（冻结），只读时 S3 API 拒绝写入和删除，管理后台操作不受限制
+// GET/PUT /api/admin/buckets/{bucket}/read-only
+func (h *Handler) adminBucketReadOnly(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, BucketReadOnlyRequest{ReadOnly: bucket.ReadOnly})
+	case http.MethodPut:
+		var req BucketReadOnlyRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if err := h.metadata.UpdateBucketReadOnly(bucket.Name, req.ReadOnly); err != nil {
+			utils.Error("update bucket read only failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetReadOnly, "admin", bucket.Name, true, map[string]interface{}{
+			"read_only": req.ReadOnly,
+		})
+		utils.WriteJSONResponse(w, req)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketQuota 获取/设置桶存储配额，超出配额的 S3 写入返回 507，管理后台上传不受限制
+// GET/PUT /api/admin/buckets/{bucket}/quota
+func (h *Handler) adminBucketQuota(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		used, err := h.metadata.BucketUsage(bucket.Name)
+		if err != nil {
+			utils.Error("get bucket usage failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		utils.WriteJSONResponse(w, BucketQuotaResponse{QuotaBytes: bucket.QuotaBytes, UsedBytes: used})
+	case http.MethodPut:
+		var req BucketQuotaRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if req.QuotaBytes < 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "quota_bytes must not be negative", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.UpdateBucketQuota(bucket.Name, req.QuotaBytes); err != nil {
+			utils.Error("update bucket quota failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetQuota, "admin", bucket.Name, true, map[string]interface{}{
+			"quota_bytes": req.QuotaBytes,
+		})
+		utils.WriteJSONResponse(w, req)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketVersioning 获取/设置桶版本控制，关闭后已有版本保留
+// GET/PUT /api/admin/buckets/{bucket}/versioning
+func (h *Handler) adminBucketVersioning(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, BucketVersioningRequest{Enabled: bucket.VersioningEnabled})
+	case http.MethodPut:
+		var req BucketVersioningRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if err := h.metadata.UpdateBucketVersioning(bucket.Name, req.Enabled); err != nil {
+			utils.Error("update bucket versioning failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetVersioning, "admin", bucket.Name, true, map[string]interface{}{
+			"enabled": req.Enabled,
+		})
+		utils.WriteJSONResponse(w, req)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketPolicy 获取/设置/删除桶策略
+// GET/PUT/DELETE /api/admin/buckets/{bucket}/policy
+func (h *Handler) adminBucketPolicy(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, BucketPolicyRequest{Policy: bucket.Policy})
+	case http.MethodPut, http.MethodDelete:
+		var req BucketPolicyRequest
+		if r.Method == http.MethodPut {
+			if err := utils.ParseJSONBody(r, &req); err != nil {
+				utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+				return
+			}
+		}
+		if req.Policy != nil {
+			if err := storage.ValidateBucketPolicy(bucket.Name, req.Policy); err != nil {
+				utils.WriteErrorResponse(w, "InvalidParameter", err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if err := h.metadata.SetBucketPolicy(bucket.Name, req.Policy); err != nil {
+			utils.Error("update bucket policy failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		statements := 0
+		if req.Policy != nil {
+			statements = len(req.Policy.Statement)
+		}
+		h.Audit(r, storage.AuditActionBucketSetPolicy, "admin", bucket.Name, true, map[string]interface{}{
+			"statements": statements,
+		})
+		utils.WriteJSONResponse(w, req)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminObjectVersions 列出对象的全部版本，最新的在前
+// GET /api/admin/buckets/{bucket}/versions?key=
+func (h *Handler) adminObjectVersions(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		utils.WriteErrorResponse(w, "InvalidParameter", "key is required", http.StatusBadRequest)
+		return
+	}
+	versions, err := h.metadata.ListObjectVersions(bucketName, key)
+	if err != nil {
+		utils.Error("list object versions failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	result := make([]ObjectVersionInfo, 0, len(versions))
+	for _, v := range versions {
+		result = append(result, ObjectVersionInfo{
+			VersionID:    v.VersionIDOrNull(),
+			IsLatest:     v.IsLatest,
+			DeleteMarker: v.DeleteMarker,
+			Size:         v.Size,
+			ETag:         v.ETag,
+			LastModified: v.LastModified.Format(time.RFC3339),
+		})
+	}
+	utils.WriteJSONResponse(w, result)
+}
+
+// adminBucketUploads 列出桶内未完成的多段上传，便于在垃圾回收前确认要中止哪些上传
+// GET /api/admin/buckets/{bucket}/uploads?prefix=&key_marker=&upload_id_marker=&limit=
+func (h *Handler) adminBucketUploads(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+	query := r.URL.Query()
+	q := &storage.MultipartUploadListQuery{
+		Bucket:         bucketName,
+		Prefix:         query.Get("prefix"),
+		KeyMarker:      query.Get("key_marker"),
+		UploadIDMarker: query.Get("upload_id_marker"),
+		MaxUploads:     100,
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := parseInt(limitStr); err == nil && l > 0 && l <= 1000 {
+			q.MaxUploads = l
+		}
+	}
+	list, err := h.metadata.ListMultipartUploads(q)
+	if err != nil {
+		utils.Error("list multipart uploads failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	uploads := list.Uploads
+	if uploads == nil {
+		uploads = []storage.MultipartUploadInfo{}
+	}
+	result := map[string]interface{}{
+		"uploads":      uploads,
+		"is_truncated": list.IsTruncated,
+	}
+	if list.IsTruncated {
+		result["next_key_marker"] = list.NextKeyMarker
+		result["next_upload_id_marker"] = list.NextUploadIDMarker
+	}
+	utils.WriteJSONResponse(w, result)
+}
+
+// bucketAllowedMethods 返回桶允许的 HTTP 方法，未限制时为空列表
+func bucketAllowedMethods(b *storage.Bucket) []string {
+	methods, _ := storage.ParseAllowedMethods(b.AllowedMethods)
+	return nonNilStrings(methods)
+}
+
+// adminBucketPrefixRewrites 获取/设置桶对象键前缀改写规则
+// GET/PUT /api/admin/buckets/{bucket}/prefix-rewrites
+func (h *Handler) adminBucketPrefixRewrites(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, BucketPrefixRewritesRequest{Rules: nonNilRewrites(bucket.PrefixRewrites)})
+	case http.MethodPut:
+		var req BucketPrefixRewritesRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if err := storage.ValidatePrefixRewrites(req.Rules); err != nil {
+			utils.WriteErrorResponse(w, "InvalidParameter", err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.UpdateBucketPrefixRewrites(bucket.Name, req.Rules); err != nil {
+			utils.Error("update bucket prefix rewrites failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetRewrites, "admin", bucket.Name, true, map[string]interface{}{
+			"rules": req.Rules,
+		})
+		utils.WriteJSONResponse(w, BucketPrefixRewritesRequest{Rules: nonNilRewrites(req.Rules)})
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketTransform 获取/设置桶图片按需缩放
+// GET/PUT /api/admin/buckets/{bucket}/transform
+func (h *Handler) adminBucketTransform(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, BucketTransformRequest{Enabled: bucket.ImageTransform})
+	case http.MethodPut:
+		var req BucketTransformRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if err := h.metadata.UpdateBucketImageTransform(bucket.Name, req.Enabled); err != nil {
+			utils.Error("update bucket image transform failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetTransform, "admin", bucket.Name, true, map[string]interface{}{
+			"enabled": req.Enabled,
+		})
+		utils.WriteJSONResponse(w, req)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketDefaultHeaders 获取/设置桶默认响应头
+// GET/PUT /api/admin/buckets/{bucket}/default-headers
+func (h *Handler) adminBucketDefaultHeaders(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, map[string]interface{}{"headers": nonNilHeaders(bucket.DefaultHeaders)})
+	case http.MethodPut:
+		var req BucketDefaultHeadersRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		headers := make(map[string]string, len(req.Headers))
+		for name, value := range req.Headers {
+			canonical := storage.CanonicalObjectHeader(name)
+			if canonical == "" {
+				utils.WriteErrorResponse(w, "InvalidParameter", "Unsupported header: "+name+", allowed: "+strings.Join(storage.ObjectHeaderNames(), ", "), http.StatusBadRequest)
+				return
+			}
+			value = strings.TrimSpace(value)
+			if value == "" || len(value) > storage.MaxObjectHeaderValueLength || strings.ContainsAny(value, "\r\n") {
+				utils.WriteErrorResponse(w, "InvalidParameter", "Invalid value for header: "+canonical, http.StatusBadRequest)
+				return
+			}
+			headers[canonical] = value
+		}
+
+		if err := h.metadata.UpdateBucketDefaultHeaders(bucket.Name, headers); err != nil {
+			utils.Error("update bucket default headers failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetHeaders, "admin", bucket.Name, true, map[string]interface{}{
+			"headers": headers,
+		})
+		utils.WriteJSONResponse(w, map[string]interface{}{"headers": headers})
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketWebsite 获取/设置桶静态网站（索引文档与单页应用回退）
+// GET/PUT /api/admin/buckets/{bucket}/website
+func (h *Handler) adminBucketWebsite(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, BucketWebsiteRequest{IndexDocument: bucket.WebsiteIndex, SPA: bucket.WebsiteSPA})
+	case http.MethodPut:
+		var req BucketWebsiteRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		req.IndexDocument = strings.TrimSpace(req.IndexDocument)
+		if req.IndexDocument != "" && !storage.IsValidIndexDocument(req.IndexDocument) {
+			utils.WriteErrorResponse(w, "InvalidParameter", "Invalid index document: "+req.IndexDocument, http.StatusBadRequest)
+			return
+		}
+		if req.SPA && req.IndexDocument == "" {
+			utils.WriteErrorResponse(w, "InvalidParameter", "spa requires index_document", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.metadata.UpdateBucketWebsite(bucket.Name, req.IndexDocument, req.SPA); err != nil {
+			utils.Error("update bucket website failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetWebsite, "admin", bucket.Name, true, map[string]interface{}{
+			"index_document": req.IndexDocument,
+			"spa":            req.SPA,
+		})
+		utils.WriteJSONResponse(w, req)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// nonNilHeaders 保证 JSON 输出为对象而非 null
+func nonNilHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return map[string]string{}
+	}
+	return headers
+}
+
+// nonNilStrings 保证 JSON 输出为数组而非 null
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// nonNilRewrites 保证 JSON 输出为数组而非 null
+func nonNilRewrites(rules []storage.PrefixRewrite) []storage.PrefixRewrite {
+	if rules == nil {
+		return []storage.PrefixRewrite{}
+	}
+	return rules
+}
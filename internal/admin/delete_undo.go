@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// pendingDelete 撤销窗口内尚未物理删除的对象
+type pendingDelete struct {
+	obj       *storage.Object
+	expiresAt time.Time
+	timer     *time.Timer
+}
+
+// pendingDeletes 管理界面延迟删除：元数据立即删除，文件在撤销窗口结束后由后台删除
+// 令牌一次性有效，撤销或窗口结束后即失效
+type pendingDeletes struct {
+	mu      sync.Mutex
+	pending map[string]*pendingDelete
+}
+
+// UndoDeleteRequest 撤销删除请求
+type UndoDeleteRequest struct {
+	Token string `json:"token"`
+}
+
+// deleteObjectWithGrace 删除对象元数据并推迟删除文件，返回撤销令牌和过期时间
+func (h *Handler) deleteObjectWithGrace(obj *storage.Object, grace time.Duration) (string, time.Time) {
+	token := utils.GenerateID(16)
+	expiresAt := time.Now().Add(grace)
+
+	h.deletes.mu.Lock()
+	defer h.deletes.mu.Unlock()
+	if h.deletes.pending == nil {
+		h.deletes.pending = make(map[string]*pendingDelete)
+	}
+	h.deletes.pending[token] = &pendingDelete{
+		obj:       obj,
+		expiresAt: expiresAt,
+		timer:     time.AfterFunc(grace, func() { h.finalizeDelete(token) }),
+	}
+	return token, expiresAt
+}
+
+// takePendingDelete 取出并移除令牌对应的待删除记录，令牌只能使用一次
+func (h *Handler) takePendingDelete(token string) *pendingDelete {
+	h.deletes.mu.Lock()
+	defer h.deletes.mu.Unlock()
+	p, ok := h.deletes.pending[token]
+	if !ok {
+		return nil
+	}
+	delete(h.deletes.pending, token)
+	p.timer.Stop()
+	return p
+}
+
+// finalizeDelete 撤销窗口结束，删除文件并同步到复制目标
+func (h *Handler) finalizeDelete(token string) {
+	p := h.takePendingDelete(token)
+	if p == nil {
+		return
+	}
+	obj := p.obj
+	// 窗口内同名对象被重新写入时文件路径相同，不能删除
+	current, err := h.metadata.GetObject(obj.Bucket, obj.Key)
+	if err != nil {
+		utils.Error("get object for delayed delete failed", "key", obj.Key, "error", err)
+		return
+	}
+	if current != nil && current.StoragePath == obj.StoragePath {
+		return
+	}
+	if err := h.filestore.DeleteObject(obj.StoragePath); err != nil {
+		utils.Error("delete file failed", "key", obj.Key, "error", err)
+	}
+	if current == nil {
+		h.Replicate(obj.Bucket, obj.Key, storage.ReplicationOpDelete)
+	}
+}
+
+// ShutdownPendingDeletes 服务关闭时立即完成所有待删除对象
+func (h *Handler) ShutdownPendingDeletes(ctx context.Context) error {
+	h.deletes.mu.Lock()
+	tokens := make([]string, 0, len(h.deletes.pending))
+	for token := range h.deletes.pending {
+		tokens = append(tokens, token)
+	}
+	h.deletes.mu.Unlock()
+
+	for _, token := range tokens {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h.finalizeDelete(token)
+	}
+	return nil
+}
+
+// adminUndoDelete 撤销窗口内恢复被删除的对象
+// POST /api/admin/buckets/{bucket}/undo-delete
+func (h *Handler) adminUndoDelete(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+	var req UndoDeleteRequest
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+		return
+	}
+
+	h.deletes.mu.Lock()
+	p, ok := h.deletes.pending[req.Token]
+	valid := ok && p.obj.Bucket == bucketName && time.Now().Before(p.expiresAt)
+	h.deletes.mu.Unlock()
+	if !valid {
+		utils.WriteErrorResponse(w, "InvalidToken", "The undo token is invalid, already used or expired", http.StatusNotFound)
+		return
+	}
+	// 与后台删除竞争时以先取出者为准
+	if p = h.takePendingDelete(req.Token); p == nil {
+		utils.WriteErrorResponse(w, "InvalidToken", "The undo token is invalid, already used or expired", http.StatusNotFound)
+		return
+	}
+
+	obj := p.obj
+	restored, err := h.metadata.RestoreObject(obj)
+	if err != nil {
+		utils.Error("restore object failed", "key", obj.Key, "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	if !restored {
+		// 同名对象已被重新写入，原文件已被覆盖，无法恢复
+		utils.WriteErrorResponse(w, "ObjectOverwritten", "The key was written again after the delete and cannot be restored", http.StatusConflict)
+		return
+	}
+	h.Audit(r, storage.AuditActionObjectUndoDelete, "admin", bucketName+"/"+obj.Key, true, map[string]interface{}{
+		"size": obj.Size,
+		"etag": obj.ETag,
+	})
+
+	utils.WriteJSONResponse(w, map[string]interface{}{"success": true, "key": obj.Key})
+}
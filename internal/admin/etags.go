@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// EtagRederiveRequest ETag 重算请求
+type EtagRederiveRequest struct {
+	Fix      bool    `json:"fix"`       // 是否修正不一致的 ETag，默认只报告
+	Rate     float64 `json:"rate"`      // 每秒最多校验的对象数，0 表示不限
+	BufferKB int     `json:"buffer_kb"` // 读缓冲大小（KB）
+	Restart  bool    `json:"restart"`   // 忽略已保存的游标，从头开始
+}
+
+// handleEtagRederive 按磁盘文件重算所有对象的 ETag（用于绕过 API 批量导入数据后的修正）
+// GET: 查看任务进度与不一致记录
+// POST: 启动后台任务，默认从上次中断的位置继续
+// DELETE: 取消任务，已处理的位置保留在游标中
+func (h *Handler) handleEtagRederive(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, h.etags.Status())
+	case http.MethodPost:
+		h.startEtagRederive(w, r)
+	case http.MethodDelete:
+		if !h.etags.Cancel() {
+			utils.WriteErrorResponse(w, "NoRunningJob", "No etag rederive job is running", http.StatusConflict)
+			return
+		}
+		utils.WriteJSONResponse(w, h.etags.Status())
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// startEtagRederive 启动 ETag 重算任务
+func (h *Handler) startEtagRederive(w http.ResponseWriter, r *http.Request) {
+	var req EtagRederiveRequest
+	if r.ContentLength > 0 {
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+	}
+	if req.Rate < 0 || req.BufferKB < 0 {
+		utils.WriteErrorResponse(w, "InvalidParameter", "rate and buffer_kb must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	err := h.etags.Start(storage.EtagRederiveOptions{
+		Fix:        req.Fix,
+		RateLimit:  req.Rate,
+		BufferSize: req.BufferKB * 1024,
+		Restart:    req.Restart,
+	})
+	if errors.Is(err, storage.ErrEtagRederiveRunning) {
+		utils.WriteErrorResponse(w, "EtagRederiveRunning", "An etag rederive job is already running", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utils.Error("start etag rederive failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+
+	status := h.etags.Status()
+	h.Audit(r, storage.AuditActionEtagRederive, "admin", "system", true, map[string]interface{}{
+		"fix":     req.Fix,
+		"rate":    req.Rate,
+		"restart": req.Restart,
+		"cursor":  status.Cursor,
+	})
+	utils.WriteJSONResponse(w, status)
+}
+
+// ShutdownEtagRederive 服务关闭时停止 ETag 重算任务，下次启动后可继续
+func (h *Handler) ShutdownEtagRederive(ctx context.Context) error {
+	return h.etags.Shutdown(ctx)
+}
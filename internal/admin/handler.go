@@ -3,6 +3,7 @@ package admin
 import (
 	"net/http"
 	"strings"
+	"sync"
 
 	"sss/internal/storage"
 	"sss/internal/utils"
@@ -10,15 +11,27 @@ import (
 
 // Handler 管理后台处理器
 type Handler struct {
-	metadata  *storage.MetadataStore
-	filestore *storage.FileStore
+	metadata   *storage.MetadataStore
+	filestore  *storage.FileStore
+	replicator *storage.Replicator
+	notifier   *storage.Notifier
+	origins    *storage.OriginFetcher
+	integrity  *storage.IntegrityProgress
+	etags      *storage.EtagRederiver
+	orphanMu   sync.Mutex // 同一时间只允许一个孤立文件清理
+	deletes    pendingDeletes
 }
 
 // NewHandler 创建管理后台处理器
 func NewHandler(metadata *storage.MetadataStore, filestore *storage.FileStore) *Handler {
 	return &Handler{
-		metadata:  metadata,
-		filestore: filestore,
+		metadata:   metadata,
+		filestore:  filestore,
+		replicator: storage.NewReplicator(metadata, filestore),
+		notifier:   storage.NewNotifier(metadata),
+		origins:    storage.NewOriginFetcher(metadata, filestore),
+		integrity:  &storage.IntegrityProgress{},
+		etags:      storage.NewEtagRederiver(metadata, filestore),
 	}
 }
 
@@ -59,26 +72,46 @@ func (h *Handler) route(w http.ResponseWriter, r *http.Request) {
 		h.handleAPIKeys(w, r)
 	case strings.HasPrefix(path, "apikeys/"):
 		h.handleAPIKeyDetail(w, r, strings.TrimPrefix(path, "apikeys/"))
+	case path == "sts/token":
+		h.handleSTSToken(w, r)
 	case path == "buckets":
 		h.handleAdminBucketsAPI(w, r)
 	case strings.HasPrefix(path, "buckets/"):
 		h.handleAdminBucketOps(w, r, strings.TrimPrefix(path, "buckets/"))
 	case path == "stats/overview":
 		h.handleStorageStats(w, r)
+	case path == "stats/recompute":
+		h.handleStatsRecompute(w, r)
 	case path == "stats/recent":
 		h.handleRecentObjects(w, r)
+	case path == "stats/buckets":
+		h.handleBucketMetrics(w, r)
+	case path == "stats/downloads":
+		h.handleDownloadStats(w, r)
 	case path == "storage/gc":
 		h.handleGC(w, r)
+	case path == "storage/orphans":
+		h.handleOrphanSweep(w, r)
 	case path == "storage/integrity":
 		h.handleIntegrity(w, r)
+	case path == "storage/integrity/progress":
+		h.handleIntegrityProgress(w, r)
+	case path == "storage/lifecycle":
+		h.handleLifecycle(w, r)
+	case path == "storage/etags":
+		h.handleEtagRederive(w, r)
 	case path == "migrate":
 		h.handleMigrateAPI(w, r)
 	case strings.HasPrefix(path, "migrate/"):
 		h.handleMigrateJob(w, r, strings.TrimPrefix(path, "migrate/"))
+	case path == "replication":
+		h.handleReplicationStatus(w, r)
 	case path == "audit":
 		h.handleAuditLogs(w, r)
 	case path == "audit/stats":
 		h.handleAuditStats(w, r)
+	case path == "audit/object":
+		h.handleObjectHistory(w, r)
 	case path == "settings":
 		h.handleSettings(w, r)
 	case path == "settings/password":
@@ -87,6 +120,8 @@ func (h *Handler) route(w http.ResponseWriter, r *http.Request) {
 		h.handleGeoIP(w, r)
 	case path == "settings/check-update":
 		h.handleCheckUpdate(w, r)
+	case path == "settings/effective":
+		h.handleEffectiveConfig(w, r)
 	case path == "geo-stats/config":
 		h.handleGeoStatsConfig(w, r)
 	case path == "geo-stats/data":
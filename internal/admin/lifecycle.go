@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// AttachLifecycle 让生命周期过期与完整性检查互斥，并将过期删除同步到复制目标（供 S3 API 调用）
+func (h *Handler) AttachLifecycle(service *storage.LifecycleService) {
+	service.SetIntegrityProgress(h.integrity)
+	service.OnDelete(func(obj *storage.Object) {
+		h.Replicate(obj.Bucket, obj.Key, storage.ReplicationOpDelete)
+	})
+}
+
+// handleLifecycle 生命周期过期服务
+// GET /api/admin/storage/lifecycle 返回扫描间隔和最近一轮统计
+// POST /api/admin/storage/lifecycle 立即执行一轮扫描
+func (h *Handler) handleLifecycle(w http.ResponseWriter, r *http.Request) {
+	service := storage.GetLifecycleService()
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, service.Status())
+	case http.MethodPost:
+		stats := service.Run(time.Now())
+		if stats == nil {
+			utils.WriteErrorResponse(w, "LifecycleRunning", "A lifecycle expiration is already running", http.StatusConflict)
+			return
+		}
+		if stats.Deferred {
+			utils.WriteErrorResponse(w, "IntegrityCheckRunning", "An integrity check is running, try again later", http.StatusConflict)
+			return
+		}
+		utils.WriteJSONResponse(w, stats)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// adminBucketLifecycle 获取/设置桶生命周期规则
+// GET/PUT /api/admin/buckets/{bucket}/lifecycle
+func (h *Handler) adminBucketLifecycle(w http.ResponseWriter, r *http.Request, bucket *storage.Bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		rules := bucket.LifecycleRules
+		if rules == nil {
+			rules = []storage.LifecycleRule{}
+		}
+		utils.WriteJSONResponse(w, BucketLifecycleRequest{Rules: rules})
+	case http.MethodPut:
+		var req BucketLifecycleRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if err := storage.ValidateLifecycleRules(req.Rules); err != nil {
+			utils.WriteErrorResponse(w, "InvalidParameter", err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Rules == nil {
+			req.Rules = []storage.LifecycleRule{}
+		}
+		if err := h.metadata.UpdateBucketLifecycle(bucket.Name, req.Rules); err != nil {
+			utils.Error("update bucket lifecycle failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionBucketSetLifecycle, "admin", bucket.Name, true, map[string]interface{}{
+			"rules": len(req.Rules),
+		})
+		utils.WriteJSONResponse(w, req)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
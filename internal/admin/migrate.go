@@ -19,6 +19,8 @@ type MigrateRequest struct {
 	TargetBucket    string `json:"targetBucket"`
 	TargetPrefix    string `json:"targetPrefix"`
 	OverwriteExist  bool   `json:"overwriteExist"`
+	MaxRetries      *int   `json:"maxRetries"` // 未指定时使用默认值
+	RetryDelayMs    int    `json:"retryDelayMs"`
 }
 
 // handleMigrateAPI 处理迁移 API
@@ -68,6 +70,11 @@ func (h *Handler) createMigrateJob(w http.ResponseWriter, r *http.Request) {
 		TargetBucket:    req.TargetBucket,
 		TargetPrefix:    req.TargetPrefix,
 		OverwriteExist:  req.OverwriteExist,
+		MaxRetries:      storage.DefaultMigrateMaxRetries,
+		RetryDelayMs:    req.RetryDelayMs,
+	}
+	if req.MaxRetries != nil {
+		cfg.MaxRetries = *req.MaxRetries
 	}
 
 	mgr := storage.GetMigrateManager(h.metadata, h.filestore)
@@ -76,6 +83,13 @@ func (h *Handler) createMigrateJob(w http.ResponseWriter, r *http.Request) {
 		utils.WriteErrorResponse(w, "MigrationError", err.Error(), http.StatusBadRequest)
 		return
 	}
+	h.Audit(r, storage.AuditActionMigrateCreate, "admin", cfg.TargetBucket, true, map[string]interface{}{
+		"jobId":          jobID,
+		"sourceEndpoint": cfg.SourceEndpoint,
+		"sourceBucket":   cfg.SourceBucket,
+		"sourcePrefix":   cfg.SourcePrefix,
+		"targetPrefix":   cfg.TargetPrefix,
+	})
 
 	utils.WriteJSONResponse(w, map[string]interface{}{
 		"success": true,
@@ -183,6 +197,7 @@ func (h *Handler) cancelMigrateJob(w http.ResponseWriter, r *http.Request, jobID
 		utils.WriteErrorResponse(w, "CancelError", err.Error(), http.StatusBadRequest)
 		return
 	}
+	h.Audit(r, storage.AuditActionMigrateCancel, "admin", jobID, true, nil)
 
 	utils.WriteJSONResponse(w, map[string]bool{"success": true})
 }
@@ -195,6 +210,7 @@ func (h *Handler) deleteMigrateJob(w http.ResponseWriter, r *http.Request, jobID
 		utils.WriteErrorResponse(w, "DeleteError", err.Error(), http.StatusBadRequest)
 		return
 	}
+	h.Audit(r, storage.AuditActionMigrateDelete, "admin", jobID, true, nil)
 
 	utils.WriteJSONResponse(w, map[string]bool{"success": true})
 }
@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// NotificationRequest 设置桶事件通知请求
+type NotificationRequest struct {
+	Targets []storage.NotificationTarget `json:"targets"`
+}
+
+// NotifyObjectCreated 对象写入成功后提交事件通知（供 S3 API 调用）
+func (h *Handler) NotifyObjectCreated(eventName string, obj *storage.Object) {
+	h.notifier.Enqueue(storage.ObjectEvent{
+		EventName: eventName,
+		Bucket:    obj.Bucket,
+		Key:       obj.Key,
+		Size:      obj.Size,
+		ETag:      obj.ETag,
+		VersionID: obj.VersionID,
+	})
+}
+
+// NotifyObjectRemoved 对象删除成功后提交事件通知（供 S3 API 调用），versionID 为删除标记或被删除版本的 ID
+func (h *Handler) NotifyObjectRemoved(eventName, bucket, key, versionID string) {
+	h.notifier.Enqueue(storage.ObjectEvent{
+		EventName: eventName,
+		Bucket:    bucket,
+		Key:       key,
+		VersionID: versionID,
+	})
+}
+
+// ForgetNotifications 桶删除后清理事件通知配置缓存（供 S3 API 调用）
+func (h *Handler) ForgetNotifications(bucket string) {
+	h.notifier.ForgetBucket(bucket)
+}
+
+// ShutdownNotifications 服务关闭时等待事件队列投递完成
+func (h *Handler) ShutdownNotifications(ctx context.Context) error {
+	return h.notifier.Shutdown(ctx)
+}
+
+// handleBucketNotification 获取/设置/删除桶事件通知配置
+// GET/PUT/DELETE /api/admin/buckets/{bucket}/notification
+func (h *Handler) handleBucketNotification(w http.ResponseWriter, r *http.Request, bucketName string) {
+	switch r.Method {
+	case http.MethodGet:
+		cfg, err := h.metadata.GetNotificationConfig(bucketName)
+		if err != nil {
+			utils.Error("get notification config failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		if cfg == nil {
+			utils.WriteJSONResponse(w, map[string]interface{}{"configured": false})
+			return
+		}
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"configured": true,
+			"config":     cfg,
+		})
+
+	case http.MethodPut:
+		var req NotificationRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		cfg := &storage.NotificationConfig{Bucket: bucketName, Targets: req.Targets}
+		if len(cfg.Targets) == 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "at least one target is required", http.StatusBadRequest)
+			return
+		}
+		if err := storage.ValidateNotificationConfig(cfg); err != nil {
+			utils.WriteErrorResponse(w, "InvalidParameter", err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.notifier.SetConfig(cfg); err != nil {
+			utils.Error("save notification config failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+
+		h.Audit(r, storage.AuditActionNotificationSet, "admin", bucketName, true, map[string]interface{}{
+			"targets": len(cfg.Targets),
+		})
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"success": true,
+			"config":  cfg,
+		})
+
+	case http.MethodDelete:
+		if err := h.notifier.DeleteConfig(bucketName); err != nil {
+			utils.Error("delete notification config failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionNotificationDelete, "admin", bucketName, true, nil)
+		utils.WriteJSONResponse(w, map[string]bool{"success": true})
+
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// handleBucketNotificationTest 向桶的每个通知目标同步发送测试事件并返回各目标的状态码、耗时和错误
+// POST /api/admin/buckets/{bucket}/notification/test
+func (h *Handler) handleBucketNotificationTest(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+	results := h.notifier.SendTestEvent(bucketName)
+	if results == nil {
+		utils.WriteErrorResponse(w, "NotFound", "bucket has no notification configuration", http.StatusNotFound)
+		return
+	}
+	utils.WriteJSONResponse(w, map[string]interface{}{
+		"event":   storage.EventTest,
+		"results": results,
+	})
+}
@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"sss/internal/config"
 	"sss/internal/storage"
 	"sss/internal/utils"
 )
@@ -17,6 +20,7 @@ type AdminObjectInfo struct {
 	Key          string `json:"key"`
 	Size         int64  `json:"size"`
 	LastModified string `json:"last_modified"`
+	CreatedAt    string `json:"created_at"` // 首次写入时间，覆盖时不变
 	ETag         string `json:"etag"`
 }
 
@@ -33,33 +37,86 @@ func (h *Handler) adminObjectsHandler(w http.ResponseWriter, r *http.Request, bu
 }
 
 // adminListObjects 列出桶中的对象
+// GET /api/admin/buckets/{bucket}/objects?prefix=&marker=&delimiter=&sort=key|size|modified&order=asc|desc
 func (h *Handler) adminListObjects(w http.ResponseWriter, r *http.Request, bucketName string) {
-	prefix := r.URL.Query().Get("prefix")
-	marker := r.URL.Query().Get("marker")
-	maxKeys := 100
+	q := r.URL.Query()
+	sort, err := storage.ParseObjectSort(q.Get("sort"), q.Get("order"))
+	if err != nil {
+		utils.WriteErrorResponse(w, "InvalidParameter", err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	result, err := h.metadata.ListObjects(bucketName, prefix, marker, "", maxKeys)
+	// 对象按页读取后逐个编码输出，不在内存中构建完整列表；公共前缀数量远小于对象数，收集后随分页信息一起写在列表之后
+	started := false
+	begin := func() {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"objects":[`)
+		}
+	}
+	sep := ""
+	prefixes := []string{}
+	result, err := h.metadata.WalkObjects(&storage.ObjectListQuery{
+		Bucket: bucketName,
+		Prefix: q.Get("prefix"),
+		Marker: q.Get("marker"),
+		// 指定 delimiter 时按"文件夹"分组，默认平铺列出
+		Delimiter:         q.Get("delimiter"),
+		MaxKeys:           100,
+		Sort:              sort,
+		HideFolderMarkers: config.Global.Storage.FolderPlaceholders(),
+		Deadline:          config.Global.Storage.ListDeadline(time.Now()),
+	}, storage.ObjectListVisitor{
+		Object: func(obj *storage.Object) error {
+			data, err := json.Marshal(AdminObjectInfo{
+				Key:          obj.Key,
+				Size:         obj.Size,
+				LastModified: obj.LastModified.Format(time.RFC3339),
+				CreatedAt:    obj.CreatedAt.Format(time.RFC3339),
+				ETag:         obj.ETag,
+			})
+			if err != nil {
+				return err
+			}
+			begin()
+			io.WriteString(w, sep)
+			sep = ","
+			_, err = w.Write(data)
+			return err
+		},
+		CommonPrefix: func(prefix string) error {
+			prefixes = append(prefixes, prefix)
+			return nil
+		},
+	})
+	if err != nil && started {
+		// 响应已开始输出，只能中断，客户端会收到不完整的 JSON
+		utils.Warn("stream list objects failed", "bucket", bucketName, "error", err)
+		return
+	}
+	if err == storage.ErrMarkerNotFound {
+		utils.WriteErrorResponse(w, "InvalidParameter", "marker no longer exists, restart the listing", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		utils.Error("list objects failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
 		return
 	}
 
-	objects := make([]AdminObjectInfo, 0, len(result.Contents))
-	for _, obj := range result.Contents {
-		objects = append(objects, AdminObjectInfo{
-			Key:          obj.Key,
-			Size:         obj.Size,
-			LastModified: obj.LastModified.Format(time.RFC3339),
-			ETag:         obj.ETag,
-		})
-	}
-
-	utils.WriteJSONResponse(w, map[string]interface{}{
-		"objects":      objects,
-		"is_truncated": result.IsTruncated,
-		"next_marker":  result.NextMarker,
-	})
+	tail, _ := json.Marshal(struct {
+		Prefixes    []string `json:"prefixes"`
+		IsTruncated bool     `json:"is_truncated"`
+		NextMarker  string   `json:"next_marker"`
+		Sort        string   `json:"sort"`
+		Order       string   `json:"order"`
+	}{prefixes, result.IsTruncated, result.NextMarker, sort.Field, sort.Order()})
+	begin()
+	// 去掉左花括号，与 objects 字段拼接为同一个 JSON 对象
+	io.WriteString(w, "],")
+	w.Write(tail[1:])
+	io.WriteString(w, "\n")
 }
 
 // adminDeleteObject 删除单个对象
@@ -89,6 +146,27 @@ func (h *Handler) adminDeleteObject(w http.ResponseWriter, r *http.Request, buck
 		return
 	}
 
+	// 配置了撤销窗口时只删除元数据，文件在窗口结束后由后台删除
+	if grace := config.Global.Storage.DeleteGraceSeconds; grace > 0 {
+		if err := h.metadata.DeleteObject(bucketName, key); err != nil {
+			utils.Error("delete metadata failed", "key", key, "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		token, expiresAt := h.deleteObjectWithGrace(obj, time.Duration(grace)*time.Second)
+		h.Audit(r, storage.AuditActionObjectDelete, "admin", bucketName+"/"+key, true, map[string]interface{}{
+			"size":         obj.Size,
+			"etag":         obj.ETag,
+			"graceSeconds": grace,
+		})
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"success":         true,
+			"undo_token":      token,
+			"undo_expires_at": expiresAt.UTC().Format(time.RFC3339),
+		})
+		return
+	}
+
 	// 删除文件
 	if err := h.filestore.DeleteObject(obj.StoragePath); err != nil {
 		utils.Error("delete file failed", "key", key, "error", err)
@@ -100,6 +178,11 @@ func (h *Handler) adminDeleteObject(w http.ResponseWriter, r *http.Request, buck
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
 		return
 	}
+	h.Replicate(bucketName, key, storage.ReplicationOpDelete)
+	h.Audit(r, storage.AuditActionObjectDelete, "admin", bucketName+"/"+key, true, map[string]interface{}{
+		"size": obj.Size,
+		"etag": obj.ETag,
+	})
 
 	utils.WriteJSONResponse(w, map[string]bool{"success": true})
 }
@@ -143,8 +226,40 @@ func (h *Handler) adminUploadObject(w http.ResponseWriter, r *http.Request, buck
 		contentType = "application/octet-stream"
 	}
 
-	// 保存文件
-	storagePath, etag, err := h.filestore.PutObject(bucketName, key, file, header.Size)
+	// 校验桶的内容类型限制
+	bucket, err := h.metadata.GetBucket(bucketName)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	body, err := bucket.CheckContentType(contentType, file)
+	if err != nil {
+		if err == storage.ErrContentTypeNotAllowed {
+			utils.WriteErrorResponse(w, "ContentTypeNotAllowed", "Content type is not allowed in this bucket", http.StatusBadRequest)
+		} else {
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		}
+		return
+	}
+
+	// 记录是否覆盖已存在的对象，用于审计
+	existing, err := h.metadata.GetObject(bucketName, key)
+	if err != nil {
+		utils.Error("check existing object failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+
+	// 保存文件，启用静态加密时加密保存
+	staged, err := h.filestore.PutObjectStaged(bucketName, key, "", body, 0)
+	if err == nil {
+		err = staged.Commit()
+	}
+	if errors.Is(err, storage.ErrInsufficientStorage) {
+		utils.WriteErrorResponse(w, "InsufficientStorage", "Not enough free disk space on the server", http.StatusInsufficientStorage)
+		return
+	}
 	if err != nil {
 		utils.Error("save uploaded file failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
@@ -152,22 +267,35 @@ func (h *Handler) adminUploadObject(w http.ResponseWriter, r *http.Request, buck
 	}
 
 	// 保存元数据
+	etag := staged.ETag
 	obj := &storage.Object{
 		Bucket:       bucketName,
 		Key:          key,
 		Size:         header.Size,
 		ETag:         etag,
 		ContentType:  contentType,
-		StoragePath:  storagePath,
+		StoragePath:  staged.Path,
 		LastModified: time.Now(),
+
+		EncryptionNonce: staged.Nonce,
 	}
 	if err := h.metadata.PutObject(obj); err != nil {
 		utils.Error("save object metadata failed", "error", err)
 		// 回滚：删除已上传的文件
-		h.filestore.DeleteObject(storagePath)
+		h.filestore.DeleteObject(staged.Path)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
 		return
 	}
+	h.Replicate(bucketName, key, storage.ReplicationOpPut)
+
+	action := storage.AuditActionObjectUpload
+	if existing != nil {
+		action = storage.AuditActionObjectOverwrite
+	}
+	h.Audit(r, action, "admin", bucketName+"/"+key, true, map[string]interface{}{
+		"size": header.Size,
+		"etag": etag,
+	})
 
 	utils.WriteJSONResponse(w, map[string]interface{}{
 		"success": true,
@@ -220,8 +348,15 @@ func (h *Handler) adminCopyObject(w http.ResponseWriter, r *http.Request, bucket
 		return
 	}
 
-	// 复制文件
-	newStoragePath, newETag, err := h.filestore.CopyObject(srcObj.StoragePath, bucketName, req.DestKey)
+	// 复制文件，加密对象解密后按目标重新加密
+	staged, err := h.filestore.CopyObjectStaged(srcObj, bucketName, req.DestKey, "")
+	if err == nil {
+		err = staged.Commit()
+	}
+	if errors.Is(err, storage.ErrInsufficientStorage) {
+		utils.WriteErrorResponse(w, "InsufficientStorage", "Not enough free disk space on the server", http.StatusInsufficientStorage)
+		return
+	}
 	if err != nil {
 		utils.Error("copy file failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
@@ -229,22 +364,30 @@ func (h *Handler) adminCopyObject(w http.ResponseWriter, r *http.Request, bucket
 	}
 
 	// 创建新对象元数据
+	newETag := staged.ETag
 	newObj := &storage.Object{
 		Bucket:       bucketName,
 		Key:          req.DestKey,
 		Size:         srcObj.Size,
 		ETag:         newETag,
 		ContentType:  srcObj.ContentType,
-		StoragePath:  newStoragePath,
+		StoragePath:  staged.Path,
 		LastModified: time.Now(),
+
+		EncryptionNonce: staged.Nonce,
 	}
 	if err := h.metadata.PutObject(newObj); err != nil {
 		utils.Error("save copied object metadata failed", "error", err)
 		// 回滚：删除已复制的文件
-		h.filestore.DeleteObject(newStoragePath)
+		h.filestore.DeleteObject(staged.Path)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
 		return
 	}
+	h.Replicate(bucketName, req.DestKey, storage.ReplicationOpPut)
+	h.Audit(r, storage.AuditActionObjectCopy, "admin", bucketName+"/"+req.DestKey, true, map[string]interface{}{
+		"source": req.SourceKey,
+		"size":   srcObj.Size,
+	})
 
 	utils.WriteJSONResponse(w, map[string]interface{}{
 		"success":    true,
@@ -269,7 +412,13 @@ func (h *Handler) adminSearchObjects(w http.ResponseWriter, r *http.Request, buc
 		return
 	}
 
-	results, err := h.metadata.SearchObjects(bucketName, keyword, 100)
+	sort, err := storage.ParseObjectSort(r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
+	if err != nil {
+		utils.WriteErrorResponse(w, "InvalidParameter", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.metadata.SearchObjects(bucketName, keyword, 100, sort)
 	if err != nil {
 		utils.Error("search objects failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
@@ -282,6 +431,7 @@ func (h *Handler) adminSearchObjects(w http.ResponseWriter, r *http.Request, buc
 			Key:          obj.Key,
 			Size:         obj.Size,
 			LastModified: obj.LastModified.Format(time.RFC3339),
+			CreatedAt:    obj.CreatedAt.Format(time.RFC3339),
 			ETag:         obj.ETag,
 		})
 	}
@@ -289,6 +439,8 @@ func (h *Handler) adminSearchObjects(w http.ResponseWriter, r *http.Request, buc
 	utils.WriteJSONResponse(w, map[string]interface{}{
 		"objects": objects,
 		"count":   len(objects),
+		"sort":    sort.Field,
+		"order":   sort.Order(),
 	})
 }
 
@@ -325,7 +477,7 @@ func (h *Handler) adminDownloadObject(w http.ResponseWriter, r *http.Request, bu
 	}
 
 	// 读取文件
-	file, err := h.filestore.GetObject(obj.StoragePath)
+	file, err := h.filestore.OpenObject(obj)
 	if err != nil {
 		utils.Error("read file for download failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
@@ -336,10 +488,60 @@ func (h *Handler) adminDownloadObject(w http.ResponseWriter, r *http.Request, bu
 	// 设置响应头
 	fileName := filepath.Base(key)
 	w.Header().Set("Content-Type", obj.ContentType)
-	w.Header().Set("Content-Disposition", "attachment; filename=\""+fileName+"\"")
+	w.Header().Set("Content-Disposition", utils.ContentDisposition("attachment", fileName))
 	w.Header().Set("Content-Length", fmt.Sprintf("%d", obj.Size))
 	w.Header().Set("ETag", obj.ETag)
 
 	// 发送文件内容
 	io.Copy(w, file)
 }
+
+// ObjectChecksumResponse 对象校验和响应
+type ObjectChecksumResponse struct {
+	*storage.ObjectChecksum
+	Size   int64 `json:"size"`
+	Cached bool  `json:"cached"` // 是否为缓存结果
+}
+
+// adminObjectChecksum 服务端流式计算对象校验和并缓存，无需下载对象即可校验
+// GET /api/admin/buckets/{bucket}/checksum?key=xxx&algorithm=sha256[&refresh=true]
+func (h *Handler) adminObjectChecksum(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	query := r.URL.Query()
+	key := query.Get("key")
+	if key == "" {
+		utils.WriteErrorResponse(w, "MissingParameter", "Missing 'key' parameter", http.StatusBadRequest)
+		return
+	}
+	algorithm := storage.ChecksumSHA256
+	if v := query.Get("algorithm"); v != "" {
+		var err error
+		if algorithm, err = storage.NormalizeChecksumAlgorithm(v); err != nil {
+			utils.WriteErrorResponse(w, "InvalidParameter", "algorithm must be one of md5, sha256, crc32c", http.StatusBadRequest)
+			return
+		}
+	}
+
+	obj, err := h.metadata.GetObject(bucketName, key)
+	if err != nil {
+		utils.Error("get object for checksum failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	if obj == nil {
+		utils.WriteError(w, utils.ErrNoSuchKey, http.StatusNotFound, "")
+		return
+	}
+
+	checksum, cached, err := h.metadata.ComputeObjectChecksum(h.filestore, obj, algorithm, query.Get("refresh") == "true")
+	if err != nil {
+		utils.Error("compute object checksum failed", "bucket", bucketName, "key", key, "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	utils.WriteJSONResponse(w, ObjectChecksumResponse{ObjectChecksum: checksum, Size: obj.Size, Cached: cached})
+}
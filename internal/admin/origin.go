@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"net/http"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// OriginRequest 设置桶回源请求
+type OriginRequest struct {
+	Endpoint     string `json:"endpoint"`
+	AccessKey    string `json:"accessKey"`
+	SecretKey    string `json:"secretKey"` // 更新时留空则保留原值
+	Region       string `json:"region"`
+	SourceBucket string `json:"sourceBucket"`
+	SourcePrefix string `json:"sourcePrefix"`
+	Enabled      *bool  `json:"enabled"` // 默认启用
+}
+
+// FetchFromOrigin 本地对象不存在时从桶的回源配置拉取（供 S3 API 调用）
+func (h *Handler) FetchFromOrigin(bucket, key string, maxSize int64) (*storage.Object, error) {
+	return h.origins.Fetch(bucket, key, maxSize)
+}
+
+// ForgetOrigin 桶删除后清理回源状态（供 S3 API 调用）
+func (h *Handler) ForgetOrigin(bucket string) {
+	h.origins.ForgetBucket(bucket)
+}
+
+// handleBucketOrigin 获取/设置/删除桶回源配置
+// GET/PUT/DELETE /api/admin/buckets/{bucket}/origin
+func (h *Handler) handleBucketOrigin(w http.ResponseWriter, r *http.Request, bucketName string) {
+	switch r.Method {
+	case http.MethodGet:
+		cfg, err := h.metadata.GetOriginConfig(bucketName)
+		if err != nil {
+			utils.Error("get origin config failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		if cfg == nil {
+			utils.WriteJSONResponse(w, map[string]interface{}{"configured": false})
+			return
+		}
+		cfg.SecretKey = ""
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"configured": true,
+			"config":     cfg,
+		})
+
+	case http.MethodPut:
+		var req OriginRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if req.Endpoint == "" || req.AccessKey == "" || req.SourceBucket == "" {
+			utils.WriteErrorResponse(w, "InvalidParameter", "endpoint, accessKey and sourceBucket are required", http.StatusBadRequest)
+			return
+		}
+
+		secret := req.SecretKey
+		if secret == "" {
+			existing, err := h.metadata.GetOriginConfig(bucketName)
+			if err != nil {
+				utils.Error("get origin config failed", "error", err)
+				utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+				return
+			}
+			if existing == nil {
+				utils.WriteErrorResponse(w, "InvalidParameter", "secretKey is required", http.StatusBadRequest)
+				return
+			}
+			secret = existing.SecretKey
+		}
+
+		enabled := true
+		if req.Enabled != nil {
+			enabled = *req.Enabled
+		}
+		cfg := &storage.OriginConfig{
+			Bucket:       bucketName,
+			Endpoint:     req.Endpoint,
+			AccessKey:    req.AccessKey,
+			SecretKey:    secret,
+			Region:       req.Region,
+			SourceBucket: req.SourceBucket,
+			SourcePrefix: req.SourcePrefix,
+			Enabled:      enabled,
+		}
+		if err := h.origins.SetConfig(cfg); err != nil {
+			utils.Error("save origin config failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+
+		h.Audit(r, storage.AuditActionOriginSet, "admin", bucketName, true, map[string]interface{}{
+			"endpoint":     cfg.Endpoint,
+			"sourceBucket": cfg.SourceBucket,
+			"sourcePrefix": cfg.SourcePrefix,
+			"enabled":      cfg.Enabled,
+		})
+		cfg.SecretKey = ""
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"success": true,
+			"config":  cfg,
+		})
+
+	case http.MethodDelete:
+		if err := h.origins.DeleteConfig(bucketName); err != nil {
+			utils.Error("delete origin config failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionOriginDelete, "admin", bucketName, true, nil)
+		utils.WriteJSONResponse(w, map[string]bool{"success": true})
+
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// OrphanSweepRequest 孤立文件清理请求
+type OrphanSweepRequest struct {
+	Limit         int     `json:"limit"`           // 本次最多检查的文件数
+	BatchSize     int     `json:"batch_size"`      // 每批核对的文件数
+	Rate          float64 `json:"rate"`            // 每秒最多删除的文件数，0 表示不限
+	MinAgeMinutes int     `json:"min_age_minutes"` // 只清理早于该分钟数的文件（默认 60）
+	Restart       bool    `json:"restart"`         // 忽略已保存的游标，从头开始
+	DryRun        bool    `json:"dry_run"`         // 仅预览不删除
+}
+
+// handleOrphanSweep 处理孤立文件清理（磁盘上存在但没有元数据记录的文件）
+// GET: 预览，不删除也不推进游标
+// POST: 从上次的游标继续清理一批
+func (h *Handler) handleOrphanSweep(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.previewOrphanSweep(w, r)
+	case http.MethodPost:
+		h.executeOrphanSweep(w, r)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// previewOrphanSweep 预览孤立文件
+func (h *Handler) previewOrphanSweep(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := storage.OrphanSweepOptions{DryRun: true}
+	if _, ok := query["cursor"]; ok {
+		opts.Cursor = query.Get("cursor")
+	} else {
+		cursor, err := h.metadata.OrphanSweepCursor()
+		if err != nil {
+			utils.Error("get orphan sweep cursor failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		opts.Cursor = cursor
+	}
+	if l, err := parseInt(query.Get("limit")); err == nil && l > 0 {
+		opts.Limit = l
+	}
+	if b, err := parseInt(query.Get("batch")); err == nil && b > 0 {
+		opts.BatchSize = b
+	}
+	if m, err := parseInt(query.Get("min_age")); err == nil && m > 0 {
+		opts.MinAge = time.Duration(m) * time.Minute
+	}
+
+	result, ok := h.runOrphanSweep(w, opts)
+	if !ok {
+		return
+	}
+	utils.WriteJSONResponse(w, result)
+}
+
+// executeOrphanSweep 执行孤立文件清理并保存续扫游标
+func (h *Handler) executeOrphanSweep(w http.ResponseWriter, r *http.Request) {
+	var req OrphanSweepRequest
+	if r.ContentLength > 0 {
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+	}
+	if req.Limit < 0 || req.BatchSize < 0 || req.Rate < 0 || req.MinAgeMinutes < 0 {
+		utils.WriteErrorResponse(w, "InvalidParameter", "limit, batch_size, rate and min_age_minutes must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	opts := storage.OrphanSweepOptions{
+		Limit:     req.Limit,
+		BatchSize: req.BatchSize,
+		RateLimit: req.Rate,
+		MinAge:    time.Duration(req.MinAgeMinutes) * time.Minute,
+		DryRun:    req.DryRun,
+	}
+	if !req.Restart {
+		cursor, err := h.metadata.OrphanSweepCursor()
+		if err != nil {
+			utils.Error("get orphan sweep cursor failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		opts.Cursor = cursor
+	}
+
+	result, ok := h.runOrphanSweep(w, opts)
+	if !ok {
+		return
+	}
+	if !req.DryRun {
+		if err := h.metadata.SaveOrphanSweepCursor(result.NextCursor); err != nil {
+			utils.Error("save orphan sweep cursor failed", "error", err)
+		}
+		h.Audit(r, storage.AuditActionOrphanSweep, "admin", "system", true, map[string]interface{}{
+			"cursor":      result.Cursor,
+			"nextCursor":  result.NextCursor,
+			"scanned":     result.Scanned,
+			"orphanCount": result.OrphanCount,
+			"orphanSize":  result.OrphanSize,
+			"deleted":     result.Deleted,
+		})
+	}
+	utils.WriteJSONResponse(w, result)
+}
+
+// runOrphanSweep 执行清理，同一时间只允许一个清理任务
+func (h *Handler) runOrphanSweep(w http.ResponseWriter, opts storage.OrphanSweepOptions) (*storage.OrphanSweepResult, bool) {
+	if !h.orphanMu.TryLock() {
+		utils.WriteErrorResponse(w, "OrphanSweepRunning", "An orphan sweep is already running", http.StatusConflict)
+		return nil, false
+	}
+	defer h.orphanMu.Unlock()
+
+	result, err := storage.SweepOrphanFiles(h.filestore, h.metadata, opts)
+	if err != nil {
+		utils.Error("orphan sweep failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return nil, false
+	}
+	return result, true
+}
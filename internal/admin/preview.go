@@ -167,7 +167,7 @@ func (h *Handler) handleTextPreview(w http.ResponseWriter, resp *PreviewResponse
 	}
 
 	// 读取文件内容
-	file, err := h.filestore.GetObject(obj.StoragePath)
+	file, err := h.filestore.OpenObject(obj)
 	if err != nil {
 		utils.Error("open file for preview failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
@@ -0,0 +1,143 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// ReplicationRequest 设置桶复制请求
+type ReplicationRequest struct {
+	Endpoint     string `json:"endpoint"`
+	AccessKey    string `json:"accessKey"`
+	SecretKey    string `json:"secretKey"` // 更新时留空则保留原值
+	Region       string `json:"region"`
+	TargetBucket string `json:"targetBucket"`
+	Enabled      *bool  `json:"enabled"` // 默认启用
+}
+
+// Replicate 写操作成功后提交复制任务（供 S3 API 调用）
+func (h *Handler) Replicate(bucket, key, op string) {
+	h.replicator.Enqueue(bucket, key, op)
+}
+
+// ForgetReplication 桶删除后清理复制状态（供 S3 API 调用）
+func (h *Handler) ForgetReplication(bucket string) {
+	h.replicator.ForgetBucket(bucket)
+}
+
+// ShutdownReplication 服务关闭时等待复制队列排空
+func (h *Handler) ShutdownReplication(ctx context.Context) error {
+	return h.replicator.Shutdown(ctx)
+}
+
+// handleReplicationStatus 获取所有桶的复制状态（积压、延迟、失败）
+// GET /api/admin/replication
+func (h *Handler) handleReplicationStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+	status, err := h.replicator.Status()
+	if err != nil {
+		utils.Error("get replication status failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	utils.WriteJSONResponse(w, map[string]interface{}{
+		"replication": status,
+	})
+}
+
+// handleBucketReplication 获取/设置/删除桶复制配置
+// GET/PUT/DELETE /api/admin/buckets/{bucket}/replication
+func (h *Handler) handleBucketReplication(w http.ResponseWriter, r *http.Request, bucketName string) {
+	switch r.Method {
+	case http.MethodGet:
+		cfg, err := h.metadata.GetReplicationConfig(bucketName)
+		if err != nil {
+			utils.Error("get replication config failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		if cfg == nil {
+			utils.WriteJSONResponse(w, map[string]interface{}{"configured": false})
+			return
+		}
+		cfg.SecretKey = ""
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"configured": true,
+			"config":     cfg,
+		})
+
+	case http.MethodPut:
+		var req ReplicationRequest
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+			return
+		}
+		if req.Endpoint == "" || req.AccessKey == "" || req.TargetBucket == "" {
+			utils.WriteErrorResponse(w, "InvalidParameter", "endpoint, accessKey and targetBucket are required", http.StatusBadRequest)
+			return
+		}
+
+		secret := req.SecretKey
+		if secret == "" {
+			existing, err := h.metadata.GetReplicationConfig(bucketName)
+			if err != nil {
+				utils.Error("get replication config failed", "error", err)
+				utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+				return
+			}
+			if existing == nil {
+				utils.WriteErrorResponse(w, "InvalidParameter", "secretKey is required", http.StatusBadRequest)
+				return
+			}
+			secret = existing.SecretKey
+		}
+
+		enabled := true
+		if req.Enabled != nil {
+			enabled = *req.Enabled
+		}
+		cfg := &storage.ReplicationConfig{
+			Bucket:       bucketName,
+			Endpoint:     req.Endpoint,
+			AccessKey:    req.AccessKey,
+			SecretKey:    secret,
+			Region:       req.Region,
+			TargetBucket: req.TargetBucket,
+			Enabled:      enabled,
+		}
+		if err := h.replicator.SetConfig(cfg); err != nil {
+			utils.Error("save replication config failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+
+		h.Audit(r, storage.AuditActionReplicationSet, "admin", bucketName, true, map[string]interface{}{
+			"endpoint":     cfg.Endpoint,
+			"targetBucket": cfg.TargetBucket,
+			"enabled":      cfg.Enabled,
+		})
+		cfg.SecretKey = ""
+		utils.WriteJSONResponse(w, map[string]interface{}{
+			"success": true,
+			"config":  cfg,
+		})
+
+	case http.MethodDelete:
+		if err := h.replicator.DeleteConfig(bucketName); err != nil {
+			utils.Error("delete replication config failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		h.Audit(r, storage.AuditActionReplicationDelete, "admin", bucketName, true, nil)
+		utils.WriteJSONResponse(w, map[string]bool{"success": true})
+
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"sss/internal/config"
 	"sss/internal/storage"
@@ -24,9 +25,23 @@ type SettingsResponse struct {
 
 // SecuritySettings 安全设置（可在线修改）
 type SecuritySettings struct {
-	CORSOrigin     string `json:"cors_origin"`     // CORS 允许的来源，默认 "*"
-	PresignScheme  string `json:"presign_scheme"`  // 预签名URL协议，"http" 或 "https"
-	TrustedProxies string `json:"trusted_proxies"` // 信任的代理 IP/CIDR，逗号分隔
+	CORSOrigin           string `json:"cors_origin"`            // CORS 允许的来源，默认 "*"，逗号分隔为白名单
+	CORSAllowCredentials bool   `json:"cors_allow_credentials"` // 是否允许携带凭证（与 "*" 互斥）
+	PresignScheme        string `json:"presign_scheme"`         // 预签名URL协议，"http" 或 "https"
+	TrustedProxies       string `json:"trusted_proxies"`        // 信任的代理 IP/CIDR，逗号分隔
+	AuditOverwrite       bool   `json:"audit_overwrite"`        // 是否记录对象覆盖审计日志
+	AuditReadPercent     int    `json:"audit_read_percent"`     // 读操作审计采样百分比，0 表示关闭
+	PresignLimit         int    `json:"presign_limit"`          // 每个 Access Key 每个窗口的预签名数上限，0 表示不限制
+	PresignWindowMinutes int    `json:"presign_window_minutes"` // 预签名计数时间窗口（分钟）
+
+	// 安全响应头（字符串为空表示不发送）
+	HeaderNoSniff         bool   `json:"header_nosniff"`          // X-Content-Type-Options: nosniff
+	ReferrerPolicy        string `json:"referrer_policy"`         // Referrer-Policy
+	FrameOptions          string `json:"frame_options"`           // X-Frame-Options
+	ContentSecurityPolicy string `json:"content_security_policy"` // Content-Security-Policy
+	HeadersOnPublic       bool   `json:"headers_on_public"`       // 公有桶对象是否附加安全头
+
+	TraceHeader string `json:"trace_header"` // 客户端追踪 ID 请求头，空表示不启用
 }
 
 // RuntimeSettings 运行时参数（启动时确定，不可在线修改）
@@ -35,13 +50,42 @@ type RuntimeSettings struct {
 	Port     int    `json:"port"`      // 监听端口
 	DataPath string `json:"data_path"` // 数据目录
 	DBPath   string `json:"db_path"`   // 数据库路径
+	Layout   string `json:"layout"`    // 对象文件路径布局
+
+	Encryption bool `json:"encryption"` // 新写入的对象是否静态加密
 }
 
 // StorageSettings 存储设置（可在线修改）
 type StorageSettings struct {
-	Region        string `json:"region"`          // S3 区域
-	MaxObjectSize int64  `json:"max_object_size"` // 最大对象大小
-	MaxUploadSize int64  `json:"max_upload_size"` // 最大上传大小
+	Region        string `json:"region"`             // S3 区域
+	MaxObjectSize int64  `json:"max_object_size"`    // 最大对象大小
+	MaxUploadSize int64  `json:"max_upload_size"`    // 最大上传大小
+	MaxBuckets    int    `json:"max_buckets"`        // 最大桶数量，0 表示不限制
+	AutoCreate    bool   `json:"auto_create_bucket"` // PUT 对象时自动创建桶
+	LeadingSlash  string `json:"key_leading_slash"`  // 对象键前导斜杠处理 normalize/reject
+	FolderMarkers string `json:"folder_markers"`     // 以 / 结尾的零字节对象处理 object/placeholder
+	Maintenance   bool   `json:"maintenance"`        // 维护模式，就绪探针返回 503
+
+	MetricsMaxBuckets int  `json:"metrics_max_buckets"` // 按桶请求统计的桶数上限，0 表示不限制
+	PrometheusEnabled bool `json:"prometheus_enabled"`  // 是否开放无需认证的 /metrics
+	MaxMetadataSize   int  `json:"max_metadata_size"`   // 用户元数据总大小上限（字节），0 表示不限制
+
+	AnonymousDailyBytes int64 `json:"anonymous_daily_bytes"`      // 公有桶匿名下载每日流量上限（字节），0 表示不限制
+	IdempotencyWindow   int   `json:"idempotency_window_minutes"` // PUT 幂等键保留时间（分钟），0 表示忽略幂等键
+
+	MaxUploadParts       int `json:"max_upload_parts"`       // 单个未完成上传保留的分片数上限，0 表示不限制
+	MaxIncompleteUploads int `json:"max_incomplete_uploads"` // 每个桶未完成上传数上限，0 表示不限制
+
+	MinPartSize int64 `json:"min_part_size"` // 除最后一个外每个分片的最小大小（字节），0 表示不检查
+
+	MinFreeSpace int64 `json:"min_free_bytes"` // 数据盘最低可用空间（字节），0 表示不检查
+	FreeSpace    int64 `json:"free_bytes"`     // 数据盘当前可用空间（字节），-1 表示无法获取
+
+	ListTimeBudget int `json:"list_time_budget_ms"` // 单次列举的扫描时间预算（毫秒），0 表示不限制
+
+	DeleteGraceSeconds int `json:"delete_grace_seconds"` // 管理界面删除对象的撤销窗口（秒），0 表示立即删除
+
+	LifecycleInterval int `json:"lifecycle_interval_minutes"` // 生命周期过期扫描间隔（分钟），0 表示暂停
 }
 
 // SystemInfo 系统信息
@@ -73,6 +117,9 @@ func (h *Handler) getSettings(w http.ResponseWriter, r *http.Request) {
 		Port:     config.Global.Server.Port,
 		DataPath: config.Global.Storage.DataPath,
 		DBPath:   config.Global.Storage.DBPath,
+		Layout:   h.filestore.PathLayout(),
+
+		Encryption: h.filestore.EncryptionEnabled(),
 	}
 
 	// 存储设置（可在线修改）
@@ -80,13 +127,61 @@ func (h *Handler) getSettings(w http.ResponseWriter, r *http.Request) {
 		Region:        config.Global.Server.Region,
 		MaxObjectSize: config.Global.Storage.MaxObjectSize,
 		MaxUploadSize: config.Global.Storage.MaxUploadSize,
+		MaxBuckets:    config.Global.Storage.MaxBuckets,
+		AutoCreate:    config.Global.Storage.AutoCreate,
+		LeadingSlash:  config.Global.Storage.LeadingSlash,
+		FolderMarkers: config.Global.Storage.FolderMarkers,
+		Maintenance:   config.Global.Server.Maintenance,
+
+		MetricsMaxBuckets: config.Global.Server.MetricsMaxBuckets,
+		PrometheusEnabled: config.Global.Server.PrometheusEnabled,
+		MaxMetadataSize:   config.Global.Storage.MaxMetadataSize,
+
+		AnonymousDailyBytes: config.Global.Storage.AnonymousDailyBytes,
+		IdempotencyWindow:   config.Global.Storage.IdempotencyWindow,
+
+		MaxUploadParts:       config.Global.Storage.MaxUploadParts,
+		MaxIncompleteUploads: config.Global.Storage.MaxIncompleteUploads,
+
+		MinPartSize: config.Global.Storage.MinPartSize,
+
+		MinFreeSpace: config.Global.Storage.MinFreeSpace,
+		FreeSpace:    -1,
+
+		ListTimeBudget: config.Global.Storage.ListTimeBudget,
+
+		DeleteGraceSeconds: config.Global.Storage.DeleteGraceSeconds,
+
+		LifecycleInterval: config.Global.Storage.LifecycleInterval,
+	}
+	if free, err := h.filestore.FreeSpace(); err == nil {
+		storage_.FreeSpace = free
+	}
+	if storage_.LeadingSlash == "" {
+		storage_.LeadingSlash = config.LeadingSlashNormalize
+	}
+	if storage_.FolderMarkers == "" {
+		storage_.FolderMarkers = config.FolderMarkersObject
 	}
 
 	// 安全设置（可在线修改）
 	security := SecuritySettings{
-		CORSOrigin:     config.Global.Security.CORSOrigin,
-		PresignScheme:  config.Global.Security.PresignScheme,
-		TrustedProxies: config.Global.Security.TrustedProxies,
+		CORSOrigin:           config.Global.Security.CORSOrigin,
+		CORSAllowCredentials: config.Global.Security.CORSAllowCredentials,
+		PresignScheme:        config.Global.Security.PresignScheme,
+		TrustedProxies:       config.Global.Security.TrustedProxies,
+		AuditOverwrite:       config.Global.Security.AuditOverwrite,
+		AuditReadPercent:     config.Global.Security.AuditReadPercent,
+		PresignLimit:         config.Global.Security.PresignLimit,
+		PresignWindowMinutes: config.Global.Security.PresignWindowMinutes,
+
+		HeaderNoSniff:         config.Global.Security.HeaderNoSniff,
+		ReferrerPolicy:        config.Global.Security.ReferrerPolicy,
+		FrameOptions:          config.Global.Security.FrameOptions,
+		ContentSecurityPolicy: config.Global.Security.ContentSecurityPolicy,
+		HeadersOnPublic:       config.Global.Security.HeadersOnPublic,
+
+		TraceHeader: config.Global.Security.TraceHeader,
 	}
 	// 确保有默认值
 	if security.CORSOrigin == "" {
@@ -117,12 +212,42 @@ func (h *Handler) getSettings(w http.ResponseWriter, r *http.Request) {
 
 // UpdateSettingsRequest 更新设置请求（只包含可修改的字段）
 type UpdateSettingsRequest struct {
-	Region         *string `json:"region,omitempty"`
-	MaxObjectSize  *int64  `json:"max_object_size,omitempty"`
-	MaxUploadSize  *int64  `json:"max_upload_size,omitempty"`
-	CORSOrigin     *string `json:"cors_origin,omitempty"`
-	PresignScheme  *string `json:"presign_scheme,omitempty"`
-	TrustedProxies *string `json:"trusted_proxies,omitempty"`
+	Region               *string `json:"region,omitempty"`
+	MaxObjectSize        *int64  `json:"max_object_size,omitempty"`
+	MaxUploadSize        *int64  `json:"max_upload_size,omitempty"`
+	MaxBuckets           *int    `json:"max_buckets,omitempty"`
+	AutoCreateBucket     *bool   `json:"auto_create_bucket,omitempty"`
+	KeyLeadingSlash      *string `json:"key_leading_slash,omitempty"`
+	FolderMarkers        *string `json:"folder_markers,omitempty"`
+	Maintenance          *bool   `json:"maintenance,omitempty"`
+	MetricsMaxBuckets    *int    `json:"metrics_max_buckets,omitempty"`
+	PrometheusEnabled    *bool   `json:"prometheus_enabled,omitempty"`
+	MaxMetadataSize      *int    `json:"max_metadata_size,omitempty"`
+	AnonymousDailyBytes  *int64  `json:"anonymous_daily_bytes,omitempty"`
+	IdempotencyWindow    *int    `json:"idempotency_window_minutes,omitempty"`
+	MaxUploadParts       *int    `json:"max_upload_parts,omitempty"`
+	MaxIncompleteUploads *int    `json:"max_incomplete_uploads,omitempty"`
+	MinPartSize          *int64  `json:"min_part_size,omitempty"`
+	MinFreeSpace         *int64  `json:"min_free_bytes,omitempty"`
+	ListTimeBudget       *int    `json:"list_time_budget_ms,omitempty"`
+	DeleteGraceSeconds   *int    `json:"delete_grace_seconds,omitempty"`
+	LifecycleInterval    *int    `json:"lifecycle_interval_minutes,omitempty"`
+	CORSOrigin           *string `json:"cors_origin,omitempty"`
+	CORSAllowCredentials *bool   `json:"cors_allow_credentials,omitempty"`
+	PresignScheme        *string `json:"presign_scheme,omitempty"`
+	TrustedProxies       *string `json:"trusted_proxies,omitempty"`
+	AuditOverwrite       *bool   `json:"audit_overwrite,omitempty"`
+	AuditReadPercent     *int    `json:"audit_read_percent,omitempty"`
+	PresignLimit         *int    `json:"presign_limit,omitempty"`
+	PresignWindowMinutes *int    `json:"presign_window_minutes,omitempty"`
+
+	HeaderNoSniff         *bool   `json:"header_nosniff,omitempty"`
+	ReferrerPolicy        *string `json:"referrer_policy,omitempty"`
+	FrameOptions          *string `json:"frame_options,omitempty"`
+	ContentSecurityPolicy *string `json:"content_security_policy,omitempty"`
+	HeadersOnPublic       *bool   `json:"headers_on_public,omitempty"`
+
+	TraceHeader *string `json:"trace_header,omitempty"`
 }
 
 // updateSettings 更新系统设置
@@ -133,6 +258,36 @@ func (h *Handler) updateSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 校验 CORS：通配符来源与携带凭证互斥
+	effectiveOrigin := config.Global.Security.CORSOrigin
+	if req.CORSOrigin != nil {
+		effectiveOrigin = *req.CORSOrigin
+	}
+	effectiveCredentials := config.Global.Security.CORSAllowCredentials
+	if req.CORSAllowCredentials != nil {
+		effectiveCredentials = *req.CORSAllowCredentials
+	}
+	if effectiveCredentials && config.IsWildcardCORSOrigin(effectiveOrigin) {
+		utils.WriteErrorResponse(w, "InvalidParameter", "cors_allow_credentials 不能与通配符来源 '*' 同时使用", http.StatusBadRequest)
+		return
+	}
+
+	// 校验 X-Frame-Options
+	if req.FrameOptions != nil {
+		switch strings.ToUpper(strings.TrimSpace(*req.FrameOptions)) {
+		case "", config.HeaderDisabled, "OFF", "DENY", "SAMEORIGIN":
+		default:
+			utils.WriteErrorResponse(w, "InvalidParameter", "frame_options 必须是 'DENY'、'SAMEORIGIN' 或空", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// 校验追踪 ID 请求头名称
+	if req.TraceHeader != nil && !isValidHeaderName(strings.TrimSpace(*req.TraceHeader)) {
+		utils.WriteErrorResponse(w, "InvalidParameter", "trace_header 不是有效的请求头名称", http.StatusBadRequest)
+		return
+	}
+
 	// 更新 S3 区域
 	if req.Region != nil && *req.Region != "" {
 		if err := h.metadata.SetSetting(storage.SettingServerRegion, *req.Region); err != nil {
@@ -142,6 +297,33 @@ func (h *Handler) updateSettings(w http.ResponseWriter, r *http.Request) {
 		config.Global.Server.Region = *req.Region
 	}
 
+	// 更新维护模式
+	if req.Maintenance != nil {
+		if err := h.metadata.SetSetting(storage.SettingServerMaintenance, strconv.FormatBool(*req.Maintenance)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Server.Maintenance = *req.Maintenance
+	}
+
+	// 更新按桶统计的桶数上限（0 表示不限制）
+	if req.MetricsMaxBuckets != nil && *req.MetricsMaxBuckets >= 0 {
+		if err := h.metadata.SetSetting(storage.SettingServerMetricsMaxBuckets, strconv.Itoa(*req.MetricsMaxBuckets)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Server.MetricsMaxBuckets = *req.MetricsMaxBuckets
+	}
+
+	// 开关 Prometheus /metrics
+	if req.PrometheusEnabled != nil {
+		if err := h.metadata.SetSetting(storage.SettingServerPrometheusEnabled, strconv.FormatBool(*req.PrometheusEnabled)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Server.PrometheusEnabled = *req.PrometheusEnabled
+	}
+
 	// 更新最大对象大小
 	if req.MaxObjectSize != nil && *req.MaxObjectSize > 0 {
 		if err := h.metadata.SetSetting(storage.SettingStorageMaxObjectSize, strconv.FormatInt(*req.MaxObjectSize, 10)); err != nil {
@@ -160,10 +342,191 @@ func (h *Handler) updateSettings(w http.ResponseWriter, r *http.Request) {
 		config.Global.Storage.MaxUploadSize = *req.MaxUploadSize
 	}
 
+	// 更新最大桶数量（0 表示不限制）
+	if req.MaxBuckets != nil {
+		if *req.MaxBuckets < 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "max_buckets 不能为负数", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageMaxBuckets, strconv.Itoa(*req.MaxBuckets)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.MaxBuckets = *req.MaxBuckets
+	}
+
+	// 更新用户元数据大小上限（0 表示不限制）
+	if req.MaxMetadataSize != nil {
+		if *req.MaxMetadataSize < 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "max_metadata_size 不能为负数", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageMaxMetadata, strconv.Itoa(*req.MaxMetadataSize)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.MaxMetadataSize = *req.MaxMetadataSize
+	}
+
+	// 更新匿名下载每日流量上限（0 表示不限制）
+	if req.AnonymousDailyBytes != nil {
+		if *req.AnonymousDailyBytes < 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "anonymous_daily_bytes 不能为负数", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageAnonymousCap, strconv.FormatInt(*req.AnonymousDailyBytes, 10)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.AnonymousDailyBytes = *req.AnonymousDailyBytes
+	}
+
+	// 更新 PUT 幂等键保留时间（0 表示忽略幂等键）
+	if req.IdempotencyWindow != nil {
+		if *req.IdempotencyWindow < 0 || *req.IdempotencyWindow > config.MaxIdempotencyWindow {
+			utils.WriteErrorResponse(w, "InvalidParameter", "idempotency_window_minutes 必须在 0 到 "+strconv.Itoa(config.MaxIdempotencyWindow)+" 之间", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageIdempotency, strconv.Itoa(*req.IdempotencyWindow)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.IdempotencyWindow = *req.IdempotencyWindow
+	}
+
+	// 更新单个未完成上传保留的分片数上限（0 表示不限制）
+	if req.MaxUploadParts != nil {
+		if *req.MaxUploadParts < 0 || *req.MaxUploadParts > 10000 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "max_upload_parts 必须在 0 到 10000 之间", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageMaxUploadParts, strconv.Itoa(*req.MaxUploadParts)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.MaxUploadParts = *req.MaxUploadParts
+	}
+
+	// 更新每个桶未完成上传数上限（0 表示不限制）
+	if req.MaxIncompleteUploads != nil {
+		if *req.MaxIncompleteUploads < 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "max_incomplete_uploads 不能为负数", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageMaxIncompleteUploads, strconv.Itoa(*req.MaxIncompleteUploads)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.MaxIncompleteUploads = *req.MaxIncompleteUploads
+	}
+
+	// 更新分片最小大小（0 表示不检查）
+	if req.MinPartSize != nil {
+		if *req.MinPartSize < 0 || *req.MinPartSize > config.MaxMinPartSize {
+			utils.WriteErrorResponse(w, "InvalidParameter", "min_part_size 必须在 0 到 5GiB 之间", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageMinPartSize, strconv.FormatInt(*req.MinPartSize, 10)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.MinPartSize = *req.MinPartSize
+	}
+
+	// 更新数据盘最低可用空间（0 表示不检查）
+	if req.MinFreeSpace != nil {
+		if *req.MinFreeSpace < 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "min_free_bytes 不能为负数", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageMinFreeSpace, strconv.FormatInt(*req.MinFreeSpace, 10)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.MinFreeSpace = *req.MinFreeSpace
+	}
+
+	// 更新列举时间预算（0 表示不限制）
+	if req.ListTimeBudget != nil {
+		if *req.ListTimeBudget < 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "list_time_budget_ms 不能为负数", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageListTimeBudget, strconv.Itoa(*req.ListTimeBudget)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.ListTimeBudget = *req.ListTimeBudget
+	}
+
+	// 更新删除撤销窗口（0 表示立即删除）
+	if req.DeleteGraceSeconds != nil {
+		if *req.DeleteGraceSeconds < 0 || *req.DeleteGraceSeconds > config.MaxDeleteGraceSeconds {
+			utils.WriteErrorResponse(w, "InvalidParameter", "delete_grace_seconds 必须在 0 到 300 之间", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageDeleteGrace, strconv.Itoa(*req.DeleteGraceSeconds)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.DeleteGraceSeconds = *req.DeleteGraceSeconds
+	}
+
+	// 更新生命周期过期扫描间隔（0 表示暂停）
+	if req.LifecycleInterval != nil {
+		if *req.LifecycleInterval < 0 || *req.LifecycleInterval > config.MaxLifecycleInterval {
+			utils.WriteErrorResponse(w, "InvalidParameter", "lifecycle_interval_minutes 必须在 0 到 1440 之间", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageLifecycleInterval, strconv.Itoa(*req.LifecycleInterval)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.LifecycleInterval = *req.LifecycleInterval
+		storage.GetLifecycleService().SetInterval(time.Duration(*req.LifecycleInterval) * time.Minute)
+	}
+
+	// 更新自动建桶开关
+	if req.AutoCreateBucket != nil {
+		if err := h.metadata.SetSetting(storage.SettingStorageAutoCreate, strconv.FormatBool(*req.AutoCreateBucket)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.AutoCreate = *req.AutoCreateBucket
+	}
+
+	// 更新对象键前导斜杠处理策略
+	if req.KeyLeadingSlash != nil {
+		mode := *req.KeyLeadingSlash
+		if mode != config.LeadingSlashNormalize && mode != config.LeadingSlashReject {
+			utils.WriteErrorResponse(w, "InvalidParameter", "key_leading_slash 必须是 normalize 或 reject", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageLeadingSlash, mode); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.LeadingSlash = mode
+	}
+
+	// 更新文件夹占位对象处理策略
+	if req.FolderMarkers != nil {
+		mode := *req.FolderMarkers
+		if mode != config.FolderMarkersObject && mode != config.FolderMarkersPlaceholder {
+			utils.WriteErrorResponse(w, "InvalidParameter", "folder_markers 必须是 object 或 placeholder", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingStorageFolderMarkers, mode); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Storage.FolderMarkers = mode
+	}
+
 	// 更新 CORS 来源
 	if req.CORSOrigin != nil {
 		// 允许设置为空（将使用默认值 "*"），或设置为具体值
-		corsOrigin := *req.CORSOrigin
+		corsOrigin := strings.TrimSpace(*req.CORSOrigin)
 		if corsOrigin == "" {
 			corsOrigin = "*"
 		}
@@ -174,6 +537,15 @@ func (h *Handler) updateSettings(w http.ResponseWriter, r *http.Request) {
 		config.Global.Security.CORSOrigin = corsOrigin
 	}
 
+	// 更新 CORS 凭证
+	if req.CORSAllowCredentials != nil {
+		if err := h.metadata.SetSetting(storage.SettingSecurityCORSAllowCredentials, strconv.FormatBool(*req.CORSAllowCredentials)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.CORSAllowCredentials = *req.CORSAllowCredentials
+	}
+
 	// 更新预签名URL协议
 	if req.PresignScheme != nil && *req.PresignScheme != "" {
 		scheme := *req.PresignScheme
@@ -201,6 +573,101 @@ func (h *Handler) updateSettings(w http.ResponseWriter, r *http.Request) {
 		utils.ReloadTrustedProxies(trustedProxies)
 	}
 
+	// 更新对象覆盖审计开关
+	if req.AuditOverwrite != nil {
+		if err := h.metadata.SetSetting(storage.SettingSecurityAuditOverwrite, strconv.FormatBool(*req.AuditOverwrite)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.AuditOverwrite = *req.AuditOverwrite
+	}
+
+	// 更新读操作审计采样百分比
+	if req.AuditReadPercent != nil {
+		if *req.AuditReadPercent < 0 || *req.AuditReadPercent > 100 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "audit_read_percent 必须在 0 到 100 之间", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingSecurityAuditReadPercent, strconv.Itoa(*req.AuditReadPercent)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.AuditReadPercent = *req.AuditReadPercent
+	}
+
+	// 更新预签名数量限制
+	if req.PresignLimit != nil {
+		if *req.PresignLimit < 0 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "presign_limit 不能为负数", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingSecurityPresignLimit, strconv.Itoa(*req.PresignLimit)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.PresignLimit = *req.PresignLimit
+	}
+	if req.PresignWindowMinutes != nil {
+		if *req.PresignWindowMinutes < 1 || *req.PresignWindowMinutes > 7*24*60 {
+			utils.WriteErrorResponse(w, "InvalidParameter", "presign_window_minutes 必须在 1 到 10080 之间", http.StatusBadRequest)
+			return
+		}
+		if err := h.metadata.SetSetting(storage.SettingSecurityPresignWindow, strconv.Itoa(*req.PresignWindowMinutes)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.PresignWindowMinutes = *req.PresignWindowMinutes
+	}
+
+	// 更新安全响应头
+	if req.HeaderNoSniff != nil {
+		if err := h.metadata.SetSetting(storage.SettingSecurityHeaderNoSniff, strconv.FormatBool(*req.HeaderNoSniff)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.HeaderNoSniff = *req.HeaderNoSniff
+	}
+	if req.ReferrerPolicy != nil {
+		value, err := h.saveHeaderSetting(storage.SettingSecurityReferrerPolicy, *req.ReferrerPolicy)
+		if err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.ReferrerPolicy = value
+	}
+	if req.FrameOptions != nil {
+		value, err := h.saveHeaderSetting(storage.SettingSecurityFrameOptions, strings.ToUpper(*req.FrameOptions))
+		if err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.FrameOptions = value
+	}
+	if req.ContentSecurityPolicy != nil {
+		value, err := h.saveHeaderSetting(storage.SettingSecurityCSP, *req.ContentSecurityPolicy)
+		if err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.ContentSecurityPolicy = value
+	}
+	if req.HeadersOnPublic != nil {
+		if err := h.metadata.SetSetting(storage.SettingSecurityHeadersOnPublic, strconv.FormatBool(*req.HeadersOnPublic)); err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.HeadersOnPublic = *req.HeadersOnPublic
+	}
+
+	if req.TraceHeader != nil {
+		value, err := h.saveHeaderSetting(storage.SettingSecurityTraceHeader, http.CanonicalHeaderKey(strings.TrimSpace(*req.TraceHeader)))
+		if err != nil {
+			utils.WriteErrorResponse(w, "InternalError", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		config.Global.Security.TraceHeader = value
+	}
+
 	// 记录审计日志
 	h.Audit(r, storage.AuditActionSettingsUpdate, "admin", "system", true, "更新系统设置")
 
@@ -208,6 +675,39 @@ func (h *Handler) updateSettings(w http.ResponseWriter, r *http.Request) {
 	h.getSettings(w, r)
 }
 
+// saveHeaderSetting 保存安全响应头设置，空值以 "off" 持久化以区分未设置（默认值）
+func (h *Handler) saveHeaderSetting(key, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, config.HeaderDisabled) {
+		value = ""
+	}
+	stored := value
+	if stored == "" {
+		stored = config.HeaderDisabled
+	}
+	if err := h.metadata.SetSetting(key, stored); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// isValidHeaderName 校验请求头名称（空或 "off" 表示不启用）
+func isValidHeaderName(name string) bool {
+	if name == "" || strings.EqualFold(name, config.HeaderDisabled) {
+		return true
+	}
+	if strings.EqualFold(name, "x-amz-request-id") {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
 // handleChangePassword 修改管理员密码
 func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -404,3 +904,18 @@ func (h *Handler) handleCheckUpdate(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteJSONResponse(w, result)
 }
+
+// EffectiveConfigResponse 生效配置响应
+type EffectiveConfigResponse struct {
+	Settings []config.EffectiveSetting `json:"settings"`
+}
+
+// handleEffectiveConfig 返回合并默认值、命令行参数和数据库后的生效配置（密钥和密码已脱敏）
+// GET /api/admin/settings/effective
+func (h *Handler) handleEffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+	utils.WriteJSONResponse(w, EffectiveConfigResponse{Settings: config.Global.Effective()})
+}
@@ -1,9 +1,12 @@
 package admin
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"sss/internal/config"
 	"sss/internal/storage"
 	"sss/internal/utils"
 )
@@ -18,6 +21,9 @@ type GCRequest struct {
 type IntegrityRequest struct {
 	VerifyEtag bool                     `json:"verify_etag"` // 是否验证 ETag
 	Limit      int                      `json:"limit"`       // 检查数量限制
+	Workers    int                      `json:"workers"`     // 并行 worker 数
+	BufferKB   int                      `json:"buffer_kb"`   // 每个 worker 的读缓冲（KB）
+	Rate       float64                  `json:"rate"`        // 每秒最多检查的文件数
 	Repair     bool                     `json:"repair"`      // 是否执行修复
 	Issues     []storage.IntegrityIssue `json:"issues"`      // 需要修复的问题列表
 }
@@ -43,11 +49,35 @@ func (h *Handler) handleStorageStats(w http.ResponseWriter, r *http.Request) {
 		"stats":           stats,
 		"disk_usage":      diskSize,
 		"disk_file_count": fileCount,
+		"notifications":   h.notifier.Stats(),
 	}
 
 	utils.WriteJSONResponse(w, response)
 }
 
+// handleStatsRecompute 按 objects 表重建对象数/总大小计数器，修复计数偏差
+// POST /api/admin/stats/recompute
+func (h *Handler) handleStatsRecompute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	result, err := h.metadata.RecomputeObjectCounters()
+	if err != nil {
+		utils.Error("recompute object counters failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	h.Audit(r, storage.AuditActionStatsRecompute, "admin", "system", true, map[string]interface{}{
+		"totalObjects": result.TotalObjects,
+		"totalSize":    result.TotalSize,
+		"objectDrift":  result.ObjectDrift,
+		"sizeDrift":    result.SizeDrift,
+	})
+	utils.WriteJSONResponse(w, result)
+}
+
 // handleRecentObjects 获取最近上传的对象
 func (h *Handler) handleRecentObjects(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -75,6 +105,7 @@ func (h *Handler) handleRecentObjects(w http.ResponseWriter, r *http.Request) {
 			Key:          obj.Bucket + "/" + obj.Key,
 			Size:         obj.Size,
 			LastModified: obj.LastModified.Format(time.RFC3339),
+			CreatedAt:    obj.CreatedAt.Format(time.RFC3339),
 			ETag:         obj.ETag,
 		})
 	}
@@ -82,6 +113,139 @@ func (h *Handler) handleRecentObjects(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSONResponse(w, result)
 }
 
+// BucketUsageResponse 桶前缀用量响应
+type BucketUsageResponse struct {
+	Bucket       string                `json:"bucket"`
+	Prefix       string                `json:"prefix"`
+	Delimiter    string                `json:"delimiter"`
+	Depth        int                   `json:"depth"`
+	TotalObjects int                   `json:"total_objects"`
+	TotalSize    int64                 `json:"total_size"`
+	Groups       []storage.PrefixUsage `json:"groups"`
+}
+
+// adminBucketUsage 按前缀分组统计桶用量
+// GET /api/admin/buckets/{bucket}/usage?prefix=&delimiter=/&depth=1&limit=
+func (h *Handler) adminBucketUsage(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	query := r.URL.Query()
+	delimiter := query.Get("delimiter")
+	if delimiter == "" {
+		delimiter = "/"
+	}
+	depth := 1
+	if v := query.Get("depth"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 1 || d > storage.MaxPrefixUsageDepth {
+			utils.WriteErrorResponse(w, "InvalidParameter", fmt.Sprintf("depth must be between 1 and %d", storage.MaxPrefixUsageDepth), http.StatusBadRequest)
+			return
+		}
+		depth = d
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	groups, err := h.metadata.GetPrefixUsage(bucketName, query.Get("prefix"), delimiter, depth, limit)
+	if err != nil {
+		utils.Error("get prefix usage failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+
+	// 分组结果可能被 limit 截断，总量单独统计
+	totalObjects, totalSize, err := h.metadata.GetPrefixTotals(bucketName, query.Get("prefix"))
+	if err != nil {
+		utils.Error("get prefix totals failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+
+	resp := BucketUsageResponse{
+		Bucket:       bucketName,
+		Prefix:       query.Get("prefix"),
+		Delimiter:    delimiter,
+		Depth:        depth,
+		TotalObjects: totalObjects,
+		TotalSize:    totalSize,
+		Groups:       groups,
+	}
+	if resp.Groups == nil {
+		resp.Groups = []storage.PrefixUsage{}
+	}
+	utils.WriteJSONResponse(w, resp)
+}
+
+// BucketMetricsResponse 按桶请求统计响应
+type BucketMetricsResponse struct {
+	Since      time.Time               `json:"since"`       // 统计开始时间
+	MaxBuckets int                     `json:"max_buckets"` // 单独统计的桶数上限
+	Buckets    []storage.BucketMetrics `json:"buckets"`
+}
+
+// handleBucketMetrics 获取按桶的请求数、流量和错误率
+// GET: 返回统计；DELETE: 清空统计
+func (h *Handler) handleBucketMetrics(w http.ResponseWriter, r *http.Request) {
+	collector := storage.GetBucketMetrics()
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJSONResponse(w, BucketMetricsResponse{
+			Since:      collector.StartedAt(),
+			MaxBuckets: config.Global.Server.MetricsMaxBuckets,
+			Buckets:    collector.Snapshot(),
+		})
+	case http.MethodDelete:
+		collector.Reset()
+		utils.WriteJSONResponse(w, map[string]bool{"success": true})
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// DownloadStatsResponse 匿名下载统计响应
+type DownloadStatsResponse struct {
+	TodayAnonymousBytes int64                        `json:"today_anonymous_bytes"` // 今日（UTC）匿名传出字节数
+	DailyLimit          int64                        `json:"daily_limit"`           // 每日匿名流量上限，0 表示不限制
+	Objects             []storage.ObjectDownloadStat `json:"objects"`
+}
+
+// handleDownloadStats 按下载次数列出公有桶对象的匿名下载统计
+// GET /api/admin/stats/downloads?bucket=&limit=
+func (h *Handler) handleDownloadStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := parseInt(limitStr); err == nil && l > 0 && l <= 1000 {
+			limit = l
+		}
+	}
+
+	objects, err := h.metadata.ListObjectDownloads(r.URL.Query().Get("bucket"), limit)
+	if err != nil {
+		utils.Error("list object downloads failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	today, err := h.metadata.AnonymousBytesOn(time.Now())
+	if err != nil {
+		utils.Error("read anonymous bandwidth failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+
+	utils.WriteJSONResponse(w, DownloadStatsResponse{
+		TodayAnonymousBytes: today,
+		DailyLimit:          config.Global.Storage.AnonymousDailyBytes,
+		Objects:             objects,
+	})
+}
+
 // parseInt 解析整数
 func parseInt(s string) (int, error) {
 	var n int
@@ -151,6 +315,13 @@ func (h *Handler) executeGC(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
 		return
 	}
+	if !req.DryRun {
+		h.Audit(r, storage.AuditActionGCExecute, "admin", "system", true, map[string]interface{}{
+			"orphanCount":  result.OrphanCount,
+			"orphanSize":   result.OrphanSize,
+			"expiredCount": result.ExpiredCount,
+		})
+	}
 
 	utils.WriteJSONResponse(w, result)
 }
@@ -171,25 +342,66 @@ func (h *Handler) handleIntegrity(w http.ResponseWriter, r *http.Request) {
 
 // checkIntegrity 检查数据完整性
 func (h *Handler) checkIntegrity(w http.ResponseWriter, r *http.Request) {
-	// 是否验证 ETag（默认不验证，因为计算 MD5 较慢）
-	verifyEtag := r.URL.Query().Get("verify_etag") == "true"
-
-	// 检查数量限制（默认 1000）
-	limit := 1000
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+	query := r.URL.Query()
+	opts := storage.IntegrityOptions{
+		// 是否验证 ETag（默认不验证，因为计算 MD5 较慢）
+		VerifyEtag: query.Get("verify_etag") == "true",
+		// 检查数量限制（默认 1000）
+		Limit: 1000,
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
 		if l, err := parseInt(limitStr); err == nil && l > 0 {
-			limit = l
+			opts.Limit = l
+		}
+	}
+	if workers, err := parseInt(query.Get("workers")); err == nil {
+		opts.Workers = workers
+	}
+	if bufferKB, err := parseInt(query.Get("buffer_kb")); err == nil {
+		opts.BufferSize = bufferKB * 1024
+	}
+	if rateStr := query.Get("rate"); rateStr != "" {
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil || rate < 0 {
+			utils.WriteErrorResponse(w, "InvalidArgument", "rate must be a non-negative number", http.StatusBadRequest)
+			return
 		}
+		opts.RateLimit = rate
+	}
+
+	result, ok := h.runIntegrityCheck(w, opts)
+	if !ok {
+		return
+	}
+
+	utils.WriteJSONResponse(w, result)
+}
+
+// runIntegrityCheck 执行检查并记录进度，同一时间只允许一个检查
+func (h *Handler) runIntegrityCheck(w http.ResponseWriter, opts storage.IntegrityOptions) (*storage.IntegrityResult, bool) {
+	if !h.integrity.TryStart() {
+		utils.WriteErrorResponse(w, "IntegrityCheckRunning", "An integrity check or lifecycle expiration is already running", http.StatusConflict)
+		return nil, false
 	}
+	defer h.integrity.Finish()
 
-	result, err := storage.CheckIntegrity(h.filestore, h.metadata, verifyEtag, limit)
+	opts.Progress = h.integrity
+	result, err := storage.CheckIntegrityWithOptions(h.filestore, h.metadata, opts)
 	if err != nil {
 		utils.Error("integrity check failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
-		return
+		return nil, false
 	}
+	return result, true
+}
 
-	utils.WriteJSONResponse(w, result)
+// handleIntegrityProgress 获取当前完整性检查进度
+func (h *Handler) handleIntegrityProgress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+	utils.WriteJSONResponse(w, h.integrity.Snapshot())
 }
 
 // repairIntegrity 修复完整性问题
@@ -202,10 +414,14 @@ func (h *Handler) repairIntegrity(w http.ResponseWriter, r *http.Request) {
 
 	// 如果没有提供问题列表，先扫描
 	if len(req.Issues) == 0 {
-		scanResult, err := storage.CheckIntegrity(h.filestore, h.metadata, req.VerifyEtag, req.Limit)
-		if err != nil {
-			utils.Error("integrity scan for repair failed", "error", err)
-			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		scanResult, ok := h.runIntegrityCheck(w, storage.IntegrityOptions{
+			VerifyEtag: req.VerifyEtag,
+			Limit:      req.Limit,
+			Workers:    req.Workers,
+			BufferSize: req.BufferKB * 1024,
+			RateLimit:  req.Rate,
+		})
+		if !ok {
 			return
 		}
 		req.Issues = scanResult.Issues
@@ -218,6 +434,9 @@ func (h *Handler) repairIntegrity(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
 		return
 	}
+	h.Audit(r, storage.AuditActionIntegrityRepair, "admin", "system", true, map[string]interface{}{
+		"issues": len(req.Issues),
+	})
 
 	utils.WriteJSONResponse(w, result)
 }
@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"sss/internal/auth"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// TemporaryCredentialRequest 签发临时凭证请求
+type TemporaryCredentialRequest struct {
+	BucketName      string `json:"bucket_name"` // 桶名，"*" 表示所有桶
+	CanRead         bool   `json:"can_read"`
+	CanWrite        bool   `json:"can_write"`
+	DurationSeconds int    `json:"duration_seconds,omitempty"` // 有效期，默认 3600，范围 900-43200
+}
+
+// handleSTSToken 签发临时凭证
+// POST /api/admin/sts/token
+func (h *Handler) handleSTSToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	var req TemporaryCredentialRequest
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+		return
+	}
+
+	if req.BucketName == "" {
+		utils.WriteErrorResponse(w, "InvalidParameter", "bucket_name is required", http.StatusBadRequest)
+		return
+	}
+	if !req.CanRead && !req.CanWrite {
+		utils.WriteErrorResponse(w, "InvalidParameter", "at least one of can_read and can_write is required", http.StatusBadRequest)
+		return
+	}
+
+	duration := storage.DefaultSessionDuration
+	if req.DurationSeconds != 0 {
+		duration = time.Duration(req.DurationSeconds) * time.Second
+		if duration < storage.MinSessionDuration || duration > storage.MaxSessionDuration {
+			utils.WriteErrorResponse(w, "InvalidParameter", "duration_seconds must be between 900 and 43200", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// 验证桶名（如果不是通配符）
+	if req.BucketName != "*" {
+		bucket, err := h.metadata.GetBucket(req.BucketName)
+		if err != nil {
+			utils.Error("check bucket failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+			return
+		}
+		if bucket == nil {
+			utils.WriteErrorResponse(w, "InvalidParameter", "Bucket does not exist", http.StatusBadRequest)
+			return
+		}
+	}
+
+	cred := auth.IssueTemporaryCredential(req.BucketName, req.CanRead, req.CanWrite, duration)
+	if cred == nil {
+		utils.Error("issue temporary credential failed", "error", "api key cache not initialized")
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+
+	h.Audit(r, storage.AuditActionSTSIssue, "admin", cred.AccessKeyID, true, map[string]interface{}{
+		"bucket":     cred.Bucket,
+		"can_read":   cred.CanRead,
+		"can_write":  cred.CanWrite,
+		"expiration": cred.Expiration.Format(time.RFC3339),
+	})
+
+	utils.WriteJSONResponse(w, cred)
+}
@@ -0,0 +1,336 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"sss/internal/auth"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// S3 ACL 授权对象和权限
+const (
+	aclAllUsersURI   = "http://acs.amazonaws.com/groups/global/AllUsers"
+	aclXSINamespace  = "http://www.w3.org/2001/XMLSchema-instance"
+	aclRead          = "READ"
+	aclWrite         = "WRITE"
+	aclFullControl   = "FULL_CONTROL"
+	maxACLBodySize   = 64 * 1024
+	aclCannedPrivate = "private"
+	aclCannedPublic  = "public-read"
+)
+
+// errMalformedGrant x-amz-grant-* 头格式错误
+var errMalformedGrant = errors.New("malformed grant header")
+
+// AccessControlPolicy GetBucketAcl 响应 / PutBucketAcl 请求
+type AccessControlPolicy struct {
+	XMLName xml.Name `xml:"AccessControlPolicy"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Owner   Owner    `xml:"Owner"`
+	Grants  []Grant  `xml:"AccessControlList>Grant"`
+}
+
+// Grant ACL 授权项
+type Grant struct {
+	Grantee    Grantee `xml:"Grantee"`
+	Permission string  `xml:"Permission"`
+}
+
+// Grantee 授权对象，解析时按 ID/URI/EmailAddress 判断类型（xsi:type 带命名空间前缀，不参与解析）
+type Grantee struct {
+	XMLNS        string `xml:"xmlns:xsi,attr,omitempty"`
+	Type         string `xml:"xsi:type,attr,omitempty"`
+	ID           string `xml:"ID,omitempty"`
+	DisplayName  string `xml:"DisplayName,omitempty"`
+	URI          string `xml:"URI,omitempty"`
+	EmailAddress string `xml:"EmailAddress,omitempty"`
+}
+
+// bucketACL 可由内部模型表示的桶 ACL：公开读 + 按 API Key 授予的读写权限
+type bucketACL struct {
+	public bool
+	perms  map[string]*storage.APIKeyPermission // access_key_id -> 权限
+}
+
+// aclGrantHeaders x-amz-grant-* 请求头与对应的权限，*-acp 无法表示
+var aclGrantHeaders = []struct{ header, permission string }{
+	{"x-amz-grant-read", aclRead},
+	{"x-amz-grant-write", aclWrite},
+	{"x-amz-grant-full-control", aclFullControl},
+	{"x-amz-grant-read-acp", "READ_ACP"},
+	{"x-amz-grant-write-acp", "WRITE_ACP"},
+}
+
+// handleACL 处理 ?acl 请求
+// 对象不单独保存 ACL，GET 返回所在桶的 ACL，PUT 不支持
+func (s *Server) handleACL(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	resource := "/" + bucket
+	if key != "" {
+		resource += "/" + key
+	}
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if b == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, resource)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		// ACL 中包含 API Key ID，公有桶也不向匿名请求公开
+		if isAnonymousRequest(r) {
+			utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, resource)
+			return
+		}
+		if key != "" {
+			obj, err := s.getLiveObject(bucket, key)
+			if err != nil {
+				utils.Error("get object metadata failed", "error", err)
+				utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+				return
+			}
+			if obj == nil {
+				utils.WriteError(w, utils.ErrNoSuchKey, http.StatusNotFound, resource)
+				return
+			}
+		}
+		s.handleGetBucketACL(w, b, resource)
+	case http.MethodPut:
+		if key != "" {
+			unsupported := utils.ErrUnsupportedACL
+			unsupported.Message = "Object ACLs are not supported, set the bucket ACL instead"
+			utils.WriteError(w, unsupported, http.StatusNotImplemented, resource)
+			return
+		}
+		s.handlePutBucketACL(w, r, b)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, resource)
+	}
+}
+
+// handleGetBucketACL 返回桶的当前 ACL：所有者完全控制，公有桶附加 AllUsers READ，以及授予该桶的 API Key 权限
+func (s *Server) handleGetBucketACL(w http.ResponseWriter, b *storage.Bucket, resource string) {
+	perms, err := s.metadata.ListBucketPermissions(b.Name)
+	if err != nil {
+		utils.Error("list bucket permissions failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+
+	owner := Owner{ID: bucketOwnerID(), DisplayName: "sss-user"}
+	policy := AccessControlPolicy{
+		Xmlns:  "http://s3.amazonaws.com/doc/2006-03-01/",
+		Owner:  owner,
+		Grants: []Grant{{Grantee: canonicalGrantee(owner.ID, owner.DisplayName), Permission: aclFullControl}},
+	}
+	if b.IsPublic {
+		policy.Grants = append(policy.Grants, Grant{
+			Grantee:    Grantee{XMLNS: aclXSINamespace, Type: "Group", URI: aclAllUsersURI},
+			Permission: aclRead,
+		})
+	}
+	for _, perm := range perms {
+		var permission string
+		switch {
+		case perm.CanRead && perm.CanWrite:
+			permission = aclFullControl
+		case perm.CanRead:
+			permission = aclRead
+		case perm.CanWrite:
+			permission = aclWrite
+		default:
+			continue
+		}
+		policy.Grants = append(policy.Grants, Grant{Grantee: canonicalGrantee(perm.AccessKeyID, ""), Permission: permission})
+	}
+	utils.WriteXML(w, http.StatusOK, policy)
+}
+
+// handlePutBucketACL 设置桶 ACL
+// x-amz-acl 预设 ACL 只切换公开状态；ACL 请求体或 x-amz-grant-* 头替换授予该桶的 API Key 权限
+// 会改变其他 Key 的访问权限，因此只有桶所有者（管理员 Key）可以调用
+func (s *Server) handlePutBucketACL(w http.ResponseWriter, r *http.Request, b *storage.Bucket) {
+	resource := "/" + b.Name
+	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	if accessKeyID == "" || accessKeyID != bucketOwnerID() {
+		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, resource)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxACLBodySize+1))
+	if err != nil || len(body) > maxACLBodySize {
+		utils.WriteError(w, utils.ErrMalformedACL, http.StatusBadRequest, resource)
+		return
+	}
+	hasBody := len(strings.TrimSpace(string(body))) > 0
+	hasGrantHeaders := false
+	for _, h := range aclGrantHeaders {
+		if r.Header.Get(h.header) != "" {
+			hasGrantHeaders = true
+		}
+	}
+	canned := r.Header.Get("x-amz-acl")
+	sources := 0
+	for _, set := range []bool{canned != "", hasGrantHeaders, hasBody} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		invalid := utils.ErrInvalidArgument
+		invalid.Message = "Specify exactly one of the x-amz-acl header, x-amz-grant-* headers or an AccessControlPolicy body"
+		utils.WriteError(w, invalid, http.StatusBadRequest, resource)
+		return
+	}
+
+	if canned != "" {
+		if canned != aclCannedPrivate && canned != aclCannedPublic {
+			unsupported := utils.ErrUnsupportedACL
+			unsupported.Message = "Unsupported canned ACL " + canned + ", only private and public-read are supported"
+			utils.WriteError(w, unsupported, http.StatusNotImplemented, resource)
+			return
+		}
+		isPublic := canned == aclCannedPublic
+		if err := s.metadata.UpdateBucketPublic(b.Name, isPublic); err != nil {
+			utils.Error("update bucket public failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+			return
+		}
+		s.adminHandler.Audit(r, storage.AuditActionBucketSetACL, accessKeyID, b.Name, true, map[string]interface{}{
+			"canned": canned,
+		})
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var grants []Grant
+	if hasGrantHeaders {
+		grants, err = parseGrantHeaders(r.Header)
+	} else {
+		var policy AccessControlPolicy
+		if err = xml.Unmarshal(body, &policy); err == nil {
+			grants = policy.Grants
+		}
+	}
+	if err != nil {
+		utils.WriteError(w, utils.ErrMalformedACL, http.StatusBadRequest, resource)
+		return
+	}
+
+	acl, s3err, status := s.resolveGrants(b.Name, grants)
+	if s3err != nil {
+		utils.WriteError(w, *s3err, status, resource)
+		return
+	}
+	perms := make([]storage.APIKeyPermission, 0, len(acl.perms))
+	grantees := make([]string, 0, len(acl.perms))
+	for id, perm := range acl.perms {
+		perms = append(perms, *perm)
+		grantees = append(grantees, id)
+	}
+	if err := s.metadata.ReplaceBucketACL(b.Name, acl.public, perms); err != nil {
+		utils.Error("replace bucket acl failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if err := auth.ReloadAPIKeyCache(); err != nil {
+		utils.Error("reload api key cache failed", "error", err)
+	}
+	s.adminHandler.Audit(r, storage.AuditActionBucketSetACL, accessKeyID, b.Name, true, map[string]interface{}{
+		"public":   acl.public,
+		"grantees": grantees,
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+// resolveGrants 将授权项映射为内部模型，无法表示的授权返回 501，未知的 API Key 返回 400
+func (s *Server) resolveGrants(bucket string, grants []Grant) (*bucketACL, *utils.S3Error, int) {
+	acl := &bucketACL{perms: make(map[string]*storage.APIKeyPermission)}
+	unsupported := func(detail string) (*bucketACL, *utils.S3Error, int) {
+		e := utils.ErrUnsupportedACL
+		e.Message += " (" + detail + ")"
+		return nil, &e, http.StatusNotImplemented
+	}
+	owner := bucketOwnerID()
+	for _, g := range grants {
+		permission := strings.TrimSpace(g.Permission)
+		switch {
+		case g.Grantee.URI != "":
+			if g.Grantee.URI != aclAllUsersURI || permission != aclRead {
+				return unsupported(g.Grantee.URI + " " + permission)
+			}
+			acl.public = true
+		case g.Grantee.EmailAddress != "":
+			return unsupported("email grantee " + g.Grantee.EmailAddress)
+		case g.Grantee.ID != "":
+			if permission != aclRead && permission != aclWrite && permission != aclFullControl {
+				return unsupported("permission " + permission)
+			}
+			// 所有者始终拥有完全控制，无需保存
+			if g.Grantee.ID == owner {
+				continue
+			}
+			key, err := s.metadata.GetAPIKey(g.Grantee.ID)
+			if err != nil || key == nil {
+				e := utils.ErrUnknownGrantee
+				e.Message += ": " + g.Grantee.ID
+				return nil, &e, http.StatusBadRequest
+			}
+			perm := acl.perms[g.Grantee.ID]
+			if perm == nil {
+				perm = &storage.APIKeyPermission{AccessKeyID: g.Grantee.ID, BucketName: bucket}
+				acl.perms[g.Grantee.ID] = perm
+			}
+			perm.CanRead = perm.CanRead || permission != aclWrite
+			perm.CanWrite = perm.CanWrite || permission != aclRead
+		default:
+			return unsupported("grantee without ID or URI")
+		}
+	}
+	return acl, nil, 0
+}
+
+// parseGrantHeaders 解析 x-amz-grant-* 头，格式如 id="AKID", uri="http://acs.amazonaws.com/groups/global/AllUsers"
+func parseGrantHeaders(h http.Header) ([]Grant, error) {
+	var grants []Grant
+	for _, gh := range aclGrantHeaders {
+		value := h.Get(gh.header)
+		if value == "" {
+			continue
+		}
+		for _, item := range strings.Split(value, ",") {
+			typ, v, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if !ok {
+				return nil, errMalformedGrant
+			}
+			v = strings.Trim(strings.TrimSpace(v), `"`)
+			var grantee Grantee
+			switch strings.ToLower(strings.TrimSpace(typ)) {
+			case "id":
+				grantee.ID = v
+			case "uri":
+				grantee.URI = v
+			case "emailaddress":
+				grantee.EmailAddress = v
+			default:
+				return nil, errMalformedGrant
+			}
+			grants = append(grants, Grant{Grantee: grantee, Permission: gh.permission})
+		}
+	}
+	return grants, nil
+}
+
+// canonicalGrantee 按 API Key ID 构造授权对象
+func canonicalGrantee(id, displayName string) Grantee {
+	return Grantee{XMLNS: aclXSINamespace, Type: "CanonicalUser", ID: id, DisplayName: displayName}
+}
@@ -2,15 +2,18 @@ package api
 
 import (
 	"context"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 
 	appconfig "sss/internal/config"
 	"sss/internal/auth"
@@ -387,6 +390,604 @@ func TestDisabledAPIKey(t *testing.T) {
 	}
 }
 
+// TestAPIKeyNamespace 测试带命名空间的 API Key 只能访问自身命名空间下的桶
+func TestAPIKeyNamespace(t *testing.T) {
+	utils.InitLogger("warn")
+
+	tmpDir, err := os.MkdirTemp("", "sss-ns-test-*")
+	if err != nil {
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	metadata, err := storage.NewMetadataStore(tmpDir + "/metadata.db")
+	if err != nil {
+		t.Fatalf("创建元数据存储失败: %v", err)
+	}
+	defer metadata.Close()
+
+	filestore, err := storage.NewFileStore(tmpDir + "/data")
+	if err != nil {
+		t.Fatalf("创建文件存储失败: %v", err)
+	}
+
+	adminAccessKey := "ADMIN_ACCESS_KEY_12345"
+	adminSecretKey := "ADMIN_SECRET_KEY_1234567890ABCDEFGHIJ"
+	appconfig.Global = &appconfig.Config{
+		Auth: appconfig.AuthConfig{
+			AccessKeyID:     adminAccessKey,
+			SecretAccessKey: adminSecretKey,
+		},
+		Server: appconfig.ServerConfig{
+			Host:   "localhost",
+			Port:   8080,
+			Region: "us-east-1",
+		},
+	}
+	auth.InitAPIKeyCache(metadata)
+
+	server := NewServer(metadata, filestore)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	ctx := context.Background()
+
+	adminClient, _ := createClientWithCredentials(ts.URL, adminAccessKey, adminSecretKey)
+	for _, name := range []string{"tenant-a-photos", "tenant-b-photos"} {
+		if _, err := adminClient.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(name)}); err != nil {
+			t.Fatalf("管理员创建bucket失败: %v", err)
+		}
+	}
+
+	// 租户 A 拥有通配符权限，但被限制在 tenant-a- 命名空间内
+	tenantKey, err := metadata.CreateAPIKey("tenant a")
+	if err != nil {
+		t.Fatalf("创建API Key失败: %v", err)
+	}
+	metadata.UpdateAPIKeyNamespace(tenantKey.AccessKeyID, "tenant-a-")
+	metadata.SetAPIKeyPermission(&storage.APIKeyPermission{
+		AccessKeyID: tenantKey.AccessKeyID,
+		BucketName:  "*",
+		CanRead:     true,
+		CanWrite:    true,
+	})
+	auth.ReloadAPIKeyCache()
+	tenantClient, _ := createClientWithCredentials(ts.URL, tenantKey.AccessKeyID, tenantKey.SecretAccessKey)
+
+	t.Run("写入映射到实际桶", func(t *testing.T) {
+		_, err := tenantClient.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String("photos"),
+			Key:    aws.String("x.jpg"),
+			Body:   strings.NewReader("tenant a photo"),
+		})
+		if err != nil {
+			t.Fatalf("PutObject失败: %v", err)
+		}
+		obj, _ := metadata.GetObject("tenant-a-photos", "x.jpg")
+		if obj == nil {
+			t.Fatal("对象应写入 tenant-a-photos")
+		}
+	})
+
+	t.Run("列表去掉前缀", func(t *testing.T) {
+		buckets, err := tenantClient.ListBuckets(ctx, &s3.ListBucketsInput{})
+		if err != nil {
+			t.Fatalf("ListBuckets失败: %v", err)
+		}
+		if len(buckets.Buckets) != 1 || aws.ToString(buckets.Buckets[0].Name) != "photos" {
+			t.Errorf("应只看到 photos, got %+v", buckets.Buckets)
+		}
+
+		objects, err := tenantClient.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: aws.String("photos")})
+		if err != nil {
+			t.Fatalf("ListObjectsV2失败: %v", err)
+		}
+		if aws.ToString(objects.Name) != "photos" || len(objects.Contents) != 1 {
+			t.Errorf("列表结果错误: name=%s contents=%d", aws.ToString(objects.Name), len(objects.Contents))
+		}
+	})
+
+	t.Run("无法访问其他租户的桶", func(t *testing.T) {
+		_, err := tenantClient.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: aws.String("tenant-b-photos")})
+		if err == nil || !strings.Contains(err.Error(), "NoSuchBucket") {
+			t.Errorf("应返回 NoSuchBucket, got %v", err)
+		}
+	})
+
+	t.Run("预签名URL使用不带前缀的桶名", func(t *testing.T) {
+		presigned := auth.GeneratePresignedURLWithOptions("GET", "photos", "x.jpg", &auth.PresignOptions{
+			Expires:     time.Minute,
+			AccessKeyID: tenantKey.AccessKeyID,
+		})
+		if strings.Contains(presigned, "tenant-a-") {
+			t.Errorf("预签名URL不应包含命名空间: %s", presigned)
+		}
+		req := httptest.NewRequest(http.MethodGet, presigned, nil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != "tenant a photo" {
+			t.Errorf("预签名下载失败: status=%d body=%s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("命名空间与桶名之间固定使用分隔符", func(t *testing.T) {
+		for _, name := range []string{"acme-docs", "acme2-docs"} {
+			if _, err := adminClient.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(name)}); err != nil {
+				t.Fatalf("管理员创建bucket失败: %v", err)
+			}
+		}
+		acmeKey, _ := metadata.CreateAPIKey("acme")
+		metadata.UpdateAPIKeyNamespace(acmeKey.AccessKeyID, "acme")
+		metadata.SetAPIKeyPermission(&storage.APIKeyPermission{
+			AccessKeyID: acmeKey.AccessKeyID,
+			BucketName:  "*",
+			CanRead:     true,
+			CanWrite:    true,
+		})
+		auth.ReloadAPIKeyCache()
+		acmeClient, _ := createClientWithCredentials(ts.URL, acmeKey.AccessKeyID, acmeKey.SecretAccessKey)
+
+		buckets, err := acmeClient.ListBuckets(ctx, &s3.ListBucketsInput{})
+		if err != nil {
+			t.Fatalf("ListBuckets失败: %v", err)
+		}
+		if len(buckets.Buckets) != 1 || aws.ToString(buckets.Buckets[0].Name) != "docs" {
+			t.Errorf("应只看到 docs, got %+v", buckets.Buckets)
+		}
+		// 2-docs 映射为 acme-2-docs，而不是 acme2-docs
+		_, err = acmeClient.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: aws.String("2-docs")})
+		if err == nil || !strings.Contains(err.Error(), "NoSuchBucket") {
+			t.Errorf("不应访问 acme2 命名空间的桶, got %v", err)
+		}
+	})
+}
+
+// TestBucketACL 测试 PutBucketAcl/GetBucketAcl 与公开状态、API Key 权限的映射
+func TestBucketACL(t *testing.T) {
+	utils.InitLogger("warn")
+
+	tmpDir, err := os.MkdirTemp("", "sss-acl-test-*")
+	if err != nil {
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	metadata, err := storage.NewMetadataStore(tmpDir + "/metadata.db")
+	if err != nil {
+		t.Fatalf("创建元数据存储失败: %v", err)
+	}
+	defer metadata.Close()
+
+	filestore, err := storage.NewFileStore(tmpDir + "/data")
+	if err != nil {
+		t.Fatalf("创建文件存储失败: %v", err)
+	}
+
+	adminAccessKey := "ADMIN_ACCESS_KEY_12345"
+	adminSecretKey := "ADMIN_SECRET_KEY_1234567890ABCDEFGHIJ"
+	appconfig.Global = &appconfig.Config{
+		Auth: appconfig.AuthConfig{
+			AccessKeyID:     adminAccessKey,
+			SecretAccessKey: adminSecretKey,
+		},
+		Server: appconfig.ServerConfig{
+			Host:   "localhost",
+			Port:   8080,
+			Region: "us-east-1",
+		},
+	}
+	auth.InitAPIKeyCache(metadata)
+
+	server := NewServer(metadata, filestore)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	ctx := context.Background()
+
+	adminClient, _ := createClientWithCredentials(ts.URL, adminAccessKey, adminSecretKey)
+	if _, err := adminClient.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String("acl-bucket")}); err != nil {
+		t.Fatalf("管理员创建bucket失败: %v", err)
+	}
+	if _, err := adminClient.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String("acl-bucket"),
+		Key:    aws.String("a.txt"),
+		Body:   strings.NewReader("acl"),
+	}); err != nil {
+		t.Fatalf("PutObject失败: %v", err)
+	}
+
+	readerKey, err := metadata.CreateAPIKey("acl reader")
+	if err != nil {
+		t.Fatalf("创建API Key失败: %v", err)
+	}
+	auth.ReloadAPIKeyCache()
+	readerClient, _ := createClientWithCredentials(ts.URL, readerKey.AccessKeyID, readerKey.SecretAccessKey)
+
+	t.Run("预设ACL切换公开状态", func(t *testing.T) {
+		if _, err := adminClient.PutBucketAcl(ctx, &s3.PutBucketAclInput{
+			Bucket: aws.String("acl-bucket"),
+			ACL:    types.BucketCannedACLPublicRead,
+		}); err != nil {
+			t.Fatalf("PutBucketAcl失败: %v", err)
+		}
+		if b, _ := metadata.GetBucket("acl-bucket"); b == nil || !b.IsPublic {
+			t.Error("public-read 后桶应为公开")
+		}
+		if _, err := adminClient.PutBucketAcl(ctx, &s3.PutBucketAclInput{
+			Bucket: aws.String("acl-bucket"),
+			ACL:    types.BucketCannedACLPrivate,
+		}); err != nil {
+			t.Fatalf("PutBucketAcl失败: %v", err)
+		}
+		if b, _ := metadata.GetBucket("acl-bucket"); b == nil || b.IsPublic {
+			t.Error("private 后桶应为私有")
+		}
+	})
+
+	t.Run("授权替换API Key权限", func(t *testing.T) {
+		_, err := adminClient.PutBucketAcl(ctx, &s3.PutBucketAclInput{
+			Bucket:    aws.String("acl-bucket"),
+			GrantRead: aws.String(`id="` + readerKey.AccessKeyID + `", uri="http://acs.amazonaws.com/groups/global/AllUsers"`),
+		})
+		if err != nil {
+			t.Fatalf("PutBucketAcl失败: %v", err)
+		}
+		if b, _ := metadata.GetBucket("acl-bucket"); b == nil || !b.IsPublic {
+			t.Error("AllUsers READ 应使桶公开")
+		}
+		if _, err := readerClient.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("acl-bucket"),
+			Key:    aws.String("a.txt"),
+		}); err != nil {
+			t.Errorf("被授予 READ 的 Key 应可读取: %v", err)
+		}
+		if _, err := readerClient.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String("acl-bucket"),
+			Key:    aws.String("b.txt"),
+			Body:   strings.NewReader("denied"),
+		}); err == nil {
+			t.Error("仅 READ 的 Key 不应可写")
+		}
+
+		out, err := readerClient.GetBucketAcl(ctx, &s3.GetBucketAclInput{Bucket: aws.String("acl-bucket")})
+		if err != nil {
+			t.Fatalf("GetBucketAcl失败: %v", err)
+		}
+		grants := map[string]types.Permission{}
+		for _, g := range out.Grants {
+			id := aws.ToString(g.Grantee.ID)
+			if id == "" {
+				id = aws.ToString(g.Grantee.URI)
+			}
+			grants[id] = g.Permission
+		}
+		if grants[adminAccessKey] != types.PermissionFullControl ||
+			grants[readerKey.AccessKeyID] != types.PermissionRead ||
+			grants["http://acs.amazonaws.com/groups/global/AllUsers"] != types.PermissionRead {
+			t.Errorf("ACL 授权错误: %+v", grants)
+		}
+
+		if _, err := readerClient.GetObjectAcl(ctx, &s3.GetObjectAclInput{
+			Bucket: aws.String("acl-bucket"),
+			Key:    aws.String("a.txt"),
+		}); err != nil {
+			t.Errorf("对象 ACL 应返回桶 ACL: %v", err)
+		}
+
+		// 请求体形式替换为完全控制并撤销公开
+		_, err = adminClient.PutBucketAcl(ctx, &s3.PutBucketAclInput{
+			Bucket: aws.String("acl-bucket"),
+			AccessControlPolicy: &types.AccessControlPolicy{
+				Owner: &types.Owner{ID: aws.String(adminAccessKey)},
+				Grants: []types.Grant{{
+					Grantee:    &types.Grantee{Type: types.TypeCanonicalUser, ID: aws.String(readerKey.AccessKeyID)},
+					Permission: types.PermissionFullControl,
+				}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("PutBucketAcl失败: %v", err)
+		}
+		perms, _ := metadata.ListBucketPermissions("acl-bucket")
+		if len(perms) != 1 || !perms[0].CanRead || !perms[0].CanWrite {
+			t.Errorf("FULL_CONTROL 应映射为读写权限: %+v", perms)
+		}
+		if b, _ := metadata.GetBucket("acl-bucket"); b == nil || b.IsPublic {
+			t.Error("未包含 AllUsers 时桶应为私有")
+		}
+	})
+
+	t.Run("无法表示的授权返回501", func(t *testing.T) {
+		for name, input := range map[string]*s3.PutBucketAclInput{
+			"认证用户组":    {GrantRead: aws.String(`uri="http://acs.amazonaws.com/groups/global/AuthenticatedUsers"`)},
+			"READ_ACP": {GrantReadACP: aws.String(`id="` + readerKey.AccessKeyID + `"`)},
+			"预设ACL":    {ACL: types.BucketCannedACLPublicReadWrite},
+		} {
+			input.Bucket = aws.String("acl-bucket")
+			_, err := adminClient.PutBucketAcl(ctx, input)
+			if err == nil || !strings.Contains(err.Error(), "501") {
+				t.Errorf("%s 应返回501, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("未知授权对象返回400", func(t *testing.T) {
+		_, err := adminClient.PutBucketAcl(ctx, &s3.PutBucketAclInput{
+			Bucket:    aws.String("acl-bucket"),
+			GrantRead: aws.String(`id="UNKNOWN_KEY"`),
+		})
+		if err == nil || !strings.Contains(err.Error(), "400") {
+			t.Errorf("应返回400, got %v", err)
+		}
+	})
+
+	t.Run("非所有者不能修改ACL", func(t *testing.T) {
+		_, err := readerClient.PutBucketAcl(ctx, &s3.PutBucketAclInput{
+			Bucket: aws.String("acl-bucket"),
+			ACL:    types.BucketCannedACLPublicRead,
+		})
+		if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+			t.Errorf("应返回 AccessDenied, got %v", err)
+		}
+	})
+
+	t.Run("对象ACL不支持修改", func(t *testing.T) {
+		_, err := adminClient.PutObjectAcl(ctx, &s3.PutObjectAclInput{
+			Bucket: aws.String("acl-bucket"),
+			Key:    aws.String("a.txt"),
+			ACL:    types.ObjectCannedACLPublicRead,
+		})
+		if err == nil || !strings.Contains(err.Error(), "501") {
+			t.Errorf("应返回501, got %v", err)
+		}
+		obj, _ := metadata.GetObject("acl-bucket", "a.txt")
+		if obj == nil || obj.Size != 3 {
+			t.Error("对象不应被覆盖")
+		}
+	})
+}
+
+// TestBucketPolicy 测试桶策略：匿名前缀读取、只写 Key、Deny 优先
+func TestBucketPolicy(t *testing.T) {
+	utils.InitLogger("warn")
+
+	tmpDir, err := os.MkdirTemp("", "sss-policy-test-*")
+	if err != nil {
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	metadata, err := storage.NewMetadataStore(tmpDir + "/metadata.db")
+	if err != nil {
+		t.Fatalf("创建元数据存储失败: %v", err)
+	}
+	defer metadata.Close()
+
+	filestore, err := storage.NewFileStore(tmpDir + "/data")
+	if err != nil {
+		t.Fatalf("创建文件存储失败: %v", err)
+	}
+
+	adminAccessKey := "ADMIN_ACCESS_KEY_12345"
+	adminSecretKey := "ADMIN_SECRET_KEY_1234567890ABCDEFGHIJ"
+	appconfig.Global = &appconfig.Config{
+		Auth: appconfig.AuthConfig{
+			AccessKeyID:     adminAccessKey,
+			SecretAccessKey: adminSecretKey,
+		},
+		Server: appconfig.ServerConfig{
+			Host:   "localhost",
+			Port:   8080,
+			Region: "us-east-1",
+		},
+	}
+	auth.InitAPIKeyCache(metadata)
+
+	server := NewServer(metadata, filestore)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	ctx := context.Background()
+
+	adminClient, _ := createClientWithCredentials(ts.URL, adminAccessKey, adminSecretKey)
+	if _, err := adminClient.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String("policy-bucket")}); err != nil {
+		t.Fatalf("管理员创建bucket失败: %v", err)
+	}
+	for _, key := range []string{"public/a.txt", "private/b.txt"} {
+		if _, err := adminClient.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String("policy-bucket"),
+			Key:    aws.String(key),
+			Body:   strings.NewReader("data"),
+		}); err != nil {
+			t.Fatalf("PutObject失败: %v", err)
+		}
+	}
+
+	uploaderKey, err := metadata.CreateAPIKey("policy uploader")
+	if err != nil {
+		t.Fatalf("创建API Key失败: %v", err)
+	}
+	auth.ReloadAPIKeyCache()
+	uploaderClient, _ := createClientWithCredentials(ts.URL, uploaderKey.AccessKeyID, uploaderKey.SecretAccessKey)
+
+	policy := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::policy-bucket/public/*"},
+		{"Effect":"Allow","Principal":{"AWS":["` + uploaderKey.AccessKeyID + `"]},"Action":["s3:PutObject"],"Resource":"arn:aws:s3:::policy-bucket/*"},
+		{"Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::policy-bucket/public/secret*"}
+	]}`
+
+	t.Run("非所有者不能设置策略", func(t *testing.T) {
+		_, err := uploaderClient.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+			Bucket: aws.String("policy-bucket"),
+			Policy: aws.String(policy),
+		})
+		if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+			t.Errorf("应返回 AccessDenied, got %v", err)
+		}
+	})
+
+	t.Run("不合法的策略返回400", func(t *testing.T) {
+		for name, doc := range map[string]string{
+			"其他桶的资源": `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::other/*"}]}`,
+			"中间通配符":  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::policy-bucket/*/a"}]}`,
+			"不支持的操作": `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:PutBucketAcl","Resource":"arn:aws:s3:::policy-bucket"}]}`,
+			"非JSON":   `not json`,
+		} {
+			_, err := adminClient.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+				Bucket: aws.String("policy-bucket"),
+				Policy: aws.String(doc),
+			})
+			if err == nil || !strings.Contains(err.Error(), "MalformedPolicy") {
+				t.Errorf("%s 应返回 MalformedPolicy, got %v", name, err)
+			}
+		}
+	})
+
+	if _, err := adminClient.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String("policy-bucket"),
+		Policy: aws.String(policy),
+	}); err != nil {
+		t.Fatalf("PutBucketPolicy失败: %v", err)
+	}
+
+	t.Run("读取策略", func(t *testing.T) {
+		out, err := adminClient.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String("policy-bucket")})
+		if err != nil {
+			t.Fatalf("GetBucketPolicy失败: %v", err)
+		}
+		if !strings.Contains(aws.ToString(out.Policy), uploaderKey.AccessKeyID) {
+			t.Errorf("策略内容错误: %s", aws.ToString(out.Policy))
+		}
+	})
+
+	t.Run("匿名只能读取允许的前缀", func(t *testing.T) {
+		for key, want := range map[string]int{
+			"public/a.txt":       http.StatusOK,
+			"private/b.txt":      http.StatusForbidden,
+			"public/secret.txt":  http.StatusForbidden,
+			"policy-bucket-list": http.StatusForbidden,
+		} {
+			url := ts.URL + "/policy-bucket/" + key
+			if key == "policy-bucket-list" {
+				url = ts.URL + "/policy-bucket"
+			}
+			resp, err := http.Get(url)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != want {
+				t.Errorf("%s: 期望 %d, 实际 %d", key, want, resp.StatusCode)
+			}
+		}
+		resp, err := http.Post(ts.URL+"/policy-bucket/public/new.txt", "text/plain", strings.NewReader("x"))
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 400 {
+			t.Errorf("匿名写入应被拒绝, 实际 %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Key按策略只写", func(t *testing.T) {
+		if _, err := uploaderClient.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String("policy-bucket"),
+			Key:    aws.String("uploads/c.txt"),
+			Body:   strings.NewReader("upload"),
+		}); err != nil {
+			t.Errorf("策略允许的 Key 应可写入: %v", err)
+		}
+		if _, err := uploaderClient.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("policy-bucket"),
+			Key:    aws.String("private/b.txt"),
+		}); err == nil {
+			t.Error("只写的 Key 不应可读取")
+		}
+		if _, err := uploaderClient.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String("policy-bucket"),
+			Key:    aws.String("uploads/c.txt"),
+		}); err == nil {
+			t.Error("只写的 Key 不应可删除")
+		}
+	})
+
+	t.Run("Deny优先于Key权限和公开开关", func(t *testing.T) {
+		if _, err := adminClient.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String("policy-bucket"),
+			Key:    aws.String("public/secret.txt"),
+			Body:   strings.NewReader("secret"),
+		}); err != nil {
+			t.Fatalf("管理员 Key 不受策略限制: %v", err)
+		}
+		if err := metadata.SetAPIKeyPermission(&storage.APIKeyPermission{
+			AccessKeyID: uploaderKey.AccessKeyID,
+			BucketName:  "policy-bucket",
+			CanRead:     true,
+			CanWrite:    true,
+		}); err != nil {
+			t.Fatalf("设置权限失败: %v", err)
+		}
+		auth.ReloadAPIKeyCache()
+		metadata.UpdateBucketPublic("policy-bucket", true)
+		defer metadata.UpdateBucketPublic("policy-bucket", false)
+
+		if _, err := uploaderClient.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("policy-bucket"),
+			Key:    aws.String("private/b.txt"),
+		}); err != nil {
+			t.Errorf("未命中策略时按 Key 权限: %v", err)
+		}
+		if _, err := uploaderClient.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("policy-bucket"),
+			Key:    aws.String("public/secret.txt"),
+		}); err == nil {
+			t.Error("Deny 应优先于 Key 权限")
+		}
+		resp, err := http.Get(ts.URL + "/policy-bucket/public/secret.txt")
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Deny 应优先于公开开关, 实际 %d", resp.StatusCode)
+		}
+		resp, err = http.Get(ts.URL + "/policy-bucket/private/b.txt")
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("未命中策略时公开开关仍然有效, 实际 %d", resp.StatusCode)
+		}
+
+		out, err := uploaderClient.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String("policy-bucket"),
+			Delete: &types.Delete{Objects: []types.ObjectIdentifier{
+				{Key: aws.String("public/secret.txt")},
+				{Key: aws.String("uploads/c.txt")},
+			}},
+		})
+		if err != nil {
+			t.Fatalf("DeleteObjects失败: %v", err)
+		}
+		if len(out.Errors) != 1 || aws.ToString(out.Errors[0].Key) != "public/secret.txt" ||
+			aws.ToString(out.Errors[0].Code) != "AccessDenied" {
+			t.Errorf("被拒绝的键应单独返回 AccessDenied: %+v", out.Errors)
+		}
+	})
+
+	t.Run("删除策略", func(t *testing.T) {
+		if _, err := adminClient.DeleteBucketPolicy(ctx, &s3.DeleteBucketPolicyInput{Bucket: aws.String("policy-bucket")}); err != nil {
+			t.Fatalf("DeleteBucketPolicy失败: %v", err)
+		}
+		_, err := adminClient.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String("policy-bucket")})
+		if err == nil || !strings.Contains(err.Error(), "NoSuchBucketPolicy") {
+			t.Errorf("应返回 NoSuchBucketPolicy, got %v", err)
+		}
+		if p, _ := metadata.GetBucketPolicy("policy-bucket"); p != nil {
+			t.Error("策略应已删除")
+		}
+	})
+}
+
 // createClientWithCredentials 创建带指定凭证的S3客户端
 func createClientWithCredentials(endpoint, accessKey, secretKey string) (*s3.Client, error) {
 	cfg, err := config.LoadDefaultConfig(context.TODO(),
@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http/httptest"
 	"os"
@@ -237,6 +238,352 @@ func TestAWSSDKListObjects(t *testing.T) {
 	}
 }
 
+// TestAWSSDKListObjectsV1V2 使用AWS SDK分别按 V1（Marker）和 V2（ContinuationToken）分页列举
+func TestAWSSDKListObjectsV1V2(t *testing.T) {
+	ts, cleanup := setupAWSSDKTest(t)
+	defer cleanup()
+
+	client, err := createS3Client(ts.URL)
+	if err != nil {
+		t.Fatalf("创建S3客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	bucket := aws.String("list-v1-v2-bucket")
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: bucket}); err != nil {
+		t.Fatalf("CreateBucket失败: %v", err)
+	}
+	for _, key := range []string{"docs/a.txt", "docs/b.txt", "docs/c.txt", "docs/sub/d.txt", "other.txt"} {
+		if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: bucket, Key: aws.String(key), Body: strings.NewReader(key),
+		}); err != nil {
+			t.Fatalf("PutObject %q 失败: %v", key, err)
+		}
+	}
+
+	t.Run("V1按Marker分页", func(t *testing.T) {
+		var keys []string
+		var prefixes []string
+		marker := ""
+		for page := 0; page < 10; page++ {
+			input := &s3.ListObjectsInput{
+				Bucket: bucket, Prefix: aws.String("docs/"), Delimiter: aws.String("/"), MaxKeys: aws.Int32(2),
+			}
+			if marker != "" {
+				input.Marker = aws.String(marker)
+			}
+			out, err := client.ListObjects(ctx, input)
+			if err != nil {
+				t.Fatalf("ListObjects失败: %v", err)
+			}
+			if aws.ToString(out.Prefix) != "docs/" || aws.ToString(out.Delimiter) != "/" || aws.ToInt32(out.MaxKeys) != 2 {
+				t.Errorf("V1 回显参数错误: prefix=%q delimiter=%q max-keys=%d",
+					aws.ToString(out.Prefix), aws.ToString(out.Delimiter), aws.ToInt32(out.MaxKeys))
+			}
+			if aws.ToString(out.Marker) != marker {
+				t.Errorf("Marker 回显错误: got %q want %q", aws.ToString(out.Marker), marker)
+			}
+			for _, obj := range out.Contents {
+				keys = append(keys, aws.ToString(obj.Key))
+			}
+			for _, p := range out.CommonPrefixes {
+				prefixes = append(prefixes, aws.ToString(p.Prefix))
+			}
+			if !aws.ToBool(out.IsTruncated) {
+				break
+			}
+			marker = aws.ToString(out.NextMarker)
+			if marker == "" {
+				t.Fatal("截断的 V1 响应缺少 NextMarker")
+			}
+		}
+		if got := strings.Join(keys, ","); got != "docs/a.txt,docs/b.txt,docs/c.txt" {
+			t.Errorf("V1 分页结果错误: %s", got)
+		}
+		if got := strings.Join(prefixes, ","); got != "docs/sub/" {
+			t.Errorf("V1 CommonPrefixes 错误: %s", got)
+		}
+	})
+
+	t.Run("V2按ContinuationToken分页", func(t *testing.T) {
+		var keys []string
+		var token *string
+		pages := 0
+		for ; pages < 10; pages++ {
+			out, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+				Bucket: bucket, MaxKeys: aws.Int32(2), ContinuationToken: token,
+			})
+			if err != nil {
+				t.Fatalf("ListObjectsV2失败: %v", err)
+			}
+			if int(aws.ToInt32(out.KeyCount)) != len(out.Contents) {
+				t.Errorf("KeyCount 与 Contents 数量不一致: %d != %d", aws.ToInt32(out.KeyCount), len(out.Contents))
+			}
+			if aws.ToString(out.ContinuationToken) != aws.ToString(token) {
+				t.Errorf("ContinuationToken 回显错误: got %q want %q", aws.ToString(out.ContinuationToken), aws.ToString(token))
+			}
+			for _, obj := range out.Contents {
+				keys = append(keys, aws.ToString(obj.Key))
+			}
+			if !aws.ToBool(out.IsTruncated) {
+				break
+			}
+			if out.NextContinuationToken == nil {
+				t.Fatal("截断的 V2 响应缺少 NextContinuationToken")
+			}
+			token = out.NextContinuationToken
+		}
+		if pages != 2 {
+			t.Errorf("5 个对象每页 2 个应分 3 页: got %d", pages+1)
+		}
+		if got := strings.Join(keys, ","); got != "docs/a.txt,docs/b.txt,docs/c.txt,docs/sub/d.txt,other.txt" {
+			t.Errorf("V2 分页结果错误: %s", got)
+		}
+	})
+
+	t.Run("V2按Delimiter分组", func(t *testing.T) {
+		out, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: bucket, Delimiter: aws.String("/")})
+		if err != nil {
+			t.Fatalf("ListObjectsV2失败: %v", err)
+		}
+		if aws.ToString(out.Delimiter) != "/" {
+			t.Errorf("V2 Delimiter 回显错误: %q", aws.ToString(out.Delimiter))
+		}
+		if len(out.Contents) != 1 || aws.ToString(out.Contents[0].Key) != "other.txt" {
+			t.Errorf("V2 Contents 错误: %d", len(out.Contents))
+		}
+		if len(out.CommonPrefixes) != 1 || aws.ToString(out.CommonPrefixes[0].Prefix) != "docs/" {
+			t.Errorf("V2 CommonPrefixes 错误: %d", len(out.CommonPrefixes))
+		}
+	})
+}
+
+// TestAWSSDKListObjectsTrickyQuery 使用AWS SDK测试带空格、星号和中文的查询签名
+func TestAWSSDKListObjectsTrickyQuery(t *testing.T) {
+	ts, cleanup := setupAWSSDKTest(t)
+	defer cleanup()
+
+	client, err := createS3Client(ts.URL)
+	if err != nil {
+		t.Fatalf("创建S3客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String("tricky-query-bucket")}); err != nil {
+		t.Fatalf("CreateBucket失败: %v", err)
+	}
+
+	key := "dir a b/报告 1.txt"
+	if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String("tricky-query-bucket"),
+		Key:    aws.String(key),
+		Body:   strings.NewReader("tricky"),
+	}); err != nil {
+		t.Fatalf("PutObject失败: %v", err)
+	}
+
+	output, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:     aws.String("tricky-query-bucket"),
+		Prefix:     aws.String("dir a b/报告"),
+		StartAfter: aws.String("dir a b/*"),
+	})
+	if err != nil {
+		t.Fatalf("特殊字符查询签名验证失败: %v", err)
+	}
+	if len(output.Contents) != 1 || aws.ToString(output.Contents[0].Key) != key {
+		t.Errorf("列出结果错误: %+v", output.Contents)
+	}
+}
+
+// TestAWSSDKLeadingSlashKeys 测试前导斜杠对象键的存取一致性
+func TestAWSSDKLeadingSlashKeys(t *testing.T) {
+	ts, cleanup := setupAWSSDKTest(t)
+	defer cleanup()
+
+	client, err := createS3Client(ts.URL)
+	if err != nil {
+		t.Fatalf("创建S3客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	bucket := aws.String("leading-slash-bucket")
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: bucket}); err != nil {
+		t.Fatalf("CreateBucket失败: %v", err)
+	}
+
+	t.Run("normalize", func(t *testing.T) {
+		appconfig.Global.Storage.LeadingSlash = appconfig.LeadingSlashNormalize
+		for _, key := range []string{"/leading", "//double"} {
+			if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket: bucket, Key: aws.String(key), Body: strings.NewReader("data " + key),
+			}); err != nil {
+				t.Fatalf("PutObject %q 失败: %v", key, err)
+			}
+
+			// 原始键与去除斜杠后的键都能读取到同一对象
+			for _, k := range []string{key, strings.TrimLeft(key, "/")} {
+				out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: bucket, Key: aws.String(k)})
+				if err != nil {
+					t.Fatalf("GetObject %q 失败: %v", k, err)
+				}
+				body, _ := io.ReadAll(out.Body)
+				out.Body.Close()
+				if string(body) != "data "+key {
+					t.Errorf("GetObject %q 内容错误: %q", k, body)
+				}
+			}
+		}
+
+		list, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: bucket, Prefix: aws.String("/")})
+		if err != nil {
+			t.Fatalf("ListObjectsV2失败: %v", err)
+		}
+		var keys []string
+		for _, obj := range list.Contents {
+			keys = append(keys, aws.ToString(obj.Key))
+		}
+		if strings.Join(keys, ",") != "double,leading" {
+			t.Errorf("列出的键应为去除前导斜杠后的键: %v", keys)
+		}
+
+		for _, key := range []string{"/leading", "//double"} {
+			if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: bucket, Key: aws.String(key)}); err != nil {
+				t.Fatalf("DeleteObject %q 失败: %v", key, err)
+			}
+		}
+		list, err = client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: bucket})
+		if err != nil {
+			t.Fatalf("ListObjectsV2失败: %v", err)
+		}
+		if len(list.Contents) != 0 {
+			t.Errorf("删除后不应有对象: %d", len(list.Contents))
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		appconfig.Global.Storage.LeadingSlash = appconfig.LeadingSlashReject
+		defer func() { appconfig.Global.Storage.LeadingSlash = "" }()
+
+		for _, key := range []string{"/leading", "//double"} {
+			_, err := client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket: bucket, Key: aws.String(key), Body: strings.NewReader("data"),
+			})
+			if err == nil || !strings.Contains(err.Error(), "InvalidArgument") {
+				t.Errorf("PutObject %q 应返回 InvalidArgument: %v", key, err)
+			}
+			if _, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: bucket, Key: aws.String(key)}); err == nil {
+				t.Errorf("GetObject %q 应失败", key)
+			}
+		}
+
+		// 普通键不受影响
+		if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: bucket, Key: aws.String("plain"), Body: strings.NewReader("data"),
+		}); err != nil {
+			t.Errorf("普通键上传失败: %v", err)
+		}
+	})
+}
+
+// TestAWSSDKFolderMarkers 测试以 / 结尾的零字节对象在两种策略下的一致性
+func TestAWSSDKFolderMarkers(t *testing.T) {
+	ts, cleanup := setupAWSSDKTest(t)
+	defer cleanup()
+
+	client, err := createS3Client(ts.URL)
+	if err != nil {
+		t.Fatalf("创建S3客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	bucket := aws.String("folder-marker-bucket")
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: bucket}); err != nil {
+		t.Fatalf("CreateBucket失败: %v", err)
+	}
+	for key, body := range map[string]string{"photos/": "", "photos/a.jpg": "jpg"} {
+		if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: bucket, Key: aws.String(key), Body: strings.NewReader(body),
+		}); err != nil {
+			t.Fatalf("PutObject %q 失败: %v", key, err)
+		}
+	}
+
+	listKeys := func(prefix, delimiter string) (string, string) {
+		input := &s3.ListObjectsV2Input{Bucket: bucket, Prefix: aws.String(prefix)}
+		if delimiter != "" {
+			input.Delimiter = aws.String(delimiter)
+		}
+		list, err := client.ListObjectsV2(ctx, input)
+		if err != nil {
+			t.Fatalf("ListObjectsV2失败: %v", err)
+		}
+		var keys, prefixes []string
+		for _, obj := range list.Contents {
+			keys = append(keys, aws.ToString(obj.Key))
+		}
+		for _, p := range list.CommonPrefixes {
+			prefixes = append(prefixes, aws.ToString(p.Prefix))
+		}
+		return strings.Join(keys, ","), strings.Join(prefixes, ",")
+	}
+
+	t.Run("object", func(t *testing.T) {
+		appconfig.Global.Storage.FolderMarkers = appconfig.FolderMarkersObject
+		if keys, _ := listKeys("photos/", ""); keys != "photos/,photos/a.jpg" {
+			t.Errorf("object 模式应列出占位对象: %s", keys)
+		}
+	})
+
+	t.Run("placeholder", func(t *testing.T) {
+		appconfig.Global.Storage.FolderMarkers = appconfig.FolderMarkersPlaceholder
+		defer func() { appconfig.Global.Storage.FolderMarkers = appconfig.FolderMarkersObject }()
+
+		if keys, _ := listKeys("photos/", ""); keys != "photos/a.jpg" {
+			t.Errorf("placeholder 模式不应列出占位对象: %s", keys)
+		}
+		if keys, prefixes := listKeys("", "/"); keys != "" || prefixes != "photos/" {
+			t.Errorf("占位对象应以 CommonPrefixes 出现: keys=%s prefixes=%s", keys, prefixes)
+		}
+
+		// 新建空目录：仅有占位对象时同样以 CommonPrefixes 出现，GET 返回空内容
+		if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: bucket, Key: aws.String("empty/"), Body: strings.NewReader(""),
+		}); err != nil {
+			t.Fatalf("PutObject 占位对象失败: %v", err)
+		}
+		if _, prefixes := listKeys("", "/"); prefixes != "empty/,photos/" {
+			t.Errorf("空目录应以 CommonPrefixes 出现: %s", prefixes)
+		}
+		out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: bucket, Key: aws.String("empty/")})
+		if err != nil {
+			t.Fatalf("GetObject 占位对象失败: %v", err)
+		}
+		body, _ := io.ReadAll(out.Body)
+		out.Body.Close()
+		if len(body) != 0 || aws.ToString(out.ContentType) != "application/x-directory" {
+			t.Errorf("占位对象内容错误: %q %s", body, aws.ToString(out.ContentType))
+		}
+
+		// 以 / 结尾的非空上传被拒绝
+		_, err = client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: bucket, Key: aws.String("notes/"), Body: strings.NewReader("data"),
+		})
+		if err == nil || !strings.Contains(err.Error(), "InvalidArgument") {
+			t.Errorf("非空占位上传应返回 InvalidArgument: %v", err)
+		}
+
+		// 删除占位对象后空目录消失，目录下的对象不受影响
+		for _, key := range []string{"empty/", "photos/"} {
+			if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: bucket, Key: aws.String(key)}); err != nil {
+				t.Fatalf("DeleteObject %q 失败: %v", key, err)
+			}
+		}
+		if _, prefixes := listKeys("", "/"); prefixes != "photos/" {
+			t.Errorf("删除占位对象后目录列出错误: %s", prefixes)
+		}
+	})
+}
+
 // TestAWSSDKDeleteObject 使用AWS SDK测试DeleteObject
 func TestAWSSDKDeleteObject(t *testing.T) {
 	ts, cleanup := setupAWSSDKTest(t)
@@ -468,3 +815,340 @@ func TestAWSSDKMultipartUpload(t *testing.T) {
 		t.Log("CompleteMultipartUpload成功!")
 	}
 }
+
+// TestAWSSDKObjectTagging 使用AWS SDK测试对象标签
+func TestAWSSDKObjectTagging(t *testing.T) {
+	ts, cleanup := setupAWSSDKTest(t)
+	defer cleanup()
+
+	client, err := createS3Client(ts.URL)
+	if err != nil {
+		t.Fatalf("创建S3客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	bucket, key := aws.String("tagging-bucket"), aws.String("report.csv")
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: bucket}); err != nil {
+		t.Fatalf("CreateBucket失败: %v", err)
+	}
+	if _, err := client.PutObject(ctx, &s3.PutObjectInput{Bucket: bucket, Key: key, Body: strings.NewReader("a,b")}); err != nil {
+		t.Fatalf("PutObject失败: %v", err)
+	}
+
+	_, err = client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket: bucket, Key: key,
+		Tagging: &s3Types.Tagging{TagSet: []s3Types.Tag{
+			{Key: aws.String("stage"), Value: aws.String("raw")},
+			{Key: aws.String("owner"), Value: aws.String("etl")},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("PutObjectTagging失败: %v", err)
+	}
+
+	tagging, err := client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{Bucket: bucket, Key: key})
+	if err != nil {
+		t.Fatalf("GetObjectTagging失败: %v", err)
+	}
+	var got []string
+	for _, tag := range tagging.TagSet {
+		got = append(got, aws.ToString(tag.Key)+"="+aws.ToString(tag.Value))
+	}
+	if strings.Join(got, ",") != "owner=etl,stage=raw" {
+		t.Errorf("标签不匹配: %v", got)
+	}
+
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: bucket, Key: key})
+	if err != nil {
+		t.Fatalf("GetObject失败: %v", err)
+	}
+	out.Body.Close()
+	if aws.ToInt32(out.TagCount) != 2 {
+		t.Errorf("x-amz-tagging-count 应为 2: %d", aws.ToInt32(out.TagCount))
+	}
+
+	// 超过 10 个标签被拒绝
+	var tooMany []s3Types.Tag
+	for i := 0; i < 11; i++ {
+		tooMany = append(tooMany, s3Types.Tag{Key: aws.String(fmt.Sprintf("k%d", i)), Value: aws.String("v")})
+	}
+	_, err = client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{Bucket: bucket, Key: key, Tagging: &s3Types.Tagging{TagSet: tooMany}})
+	if err == nil || !strings.Contains(err.Error(), "InvalidTag") {
+		t.Errorf("超过 10 个标签应返回 InvalidTag: %v", err)
+	}
+
+	_, err = client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket: bucket, Key: aws.String("missing.csv"),
+		Tagging: &s3Types.Tagging{TagSet: []s3Types.Tag{{Key: aws.String("k"), Value: aws.String("v")}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "NoSuchKey") {
+		t.Errorf("不存在的对象应返回 NoSuchKey: %v", err)
+	}
+
+	if _, err := client.DeleteObjectTagging(ctx, &s3.DeleteObjectTaggingInput{Bucket: bucket, Key: key}); err != nil {
+		t.Fatalf("DeleteObjectTagging失败: %v", err)
+	}
+	tagging, err = client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{Bucket: bucket, Key: key})
+	if err != nil || len(tagging.TagSet) != 0 {
+		t.Errorf("删除后标签应为空: %v %v", err, tagging)
+	}
+}
+
+// TestAWSSDKDeleteObjects 使用AWS SDK测试批量删除
+func TestAWSSDKDeleteObjects(t *testing.T) {
+	ts, cleanup := setupAWSSDKTest(t)
+	defer cleanup()
+
+	client, err := createS3Client(ts.URL)
+	if err != nil {
+		t.Fatalf("创建S3客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	bucket := aws.String("delete-objects-bucket")
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: bucket}); err != nil {
+		t.Fatalf("CreateBucket失败: %v", err)
+	}
+	for _, key := range []string{"a.txt", "b.txt", "dir/c.txt"} {
+		if _, err := client.PutObject(ctx, &s3.PutObjectInput{Bucket: bucket, Key: aws.String(key), Body: strings.NewReader(key)}); err != nil {
+			t.Fatalf("PutObject %q 失败: %v", key, err)
+		}
+	}
+
+	out, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: bucket,
+		Delete: &s3Types.Delete{Objects: []s3Types.ObjectIdentifier{
+			{Key: aws.String("a.txt")},
+			{Key: aws.String("missing.txt")},
+			{Key: aws.String("../etc/passwd")},
+			{Key: aws.String("b.txt"), VersionId: aws.String("v2")},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("DeleteObjects失败: %v", err)
+	}
+	var deleted, failed []string
+	for _, d := range out.Deleted {
+		deleted = append(deleted, aws.ToString(d.Key))
+	}
+	for _, e := range out.Errors {
+		failed = append(failed, aws.ToString(e.Key)+":"+aws.ToString(e.Code))
+	}
+	if strings.Join(deleted, ",") != "a.txt,missing.txt" {
+		t.Errorf("Deleted 不匹配: %v", deleted)
+	}
+	if strings.Join(failed, ",") != "../etc/passwd:InvalidArgument,b.txt:NoSuchVersion" {
+		t.Errorf("Errors 不匹配: %v", failed)
+	}
+
+	// Quiet 模式只返回失败项
+	out, err = client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: bucket,
+		Delete: &s3Types.Delete{Quiet: aws.Bool(true), Objects: []s3Types.ObjectIdentifier{
+			{Key: aws.String("b.txt")}, {Key: aws.String("dir/c.txt")},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("DeleteObjects Quiet 失败: %v", err)
+	}
+	if len(out.Deleted) != 0 || len(out.Errors) != 0 {
+		t.Errorf("Quiet 模式不应返回成功项: %d %d", len(out.Deleted), len(out.Errors))
+	}
+
+	list, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: bucket})
+	if err != nil {
+		t.Fatalf("ListObjectsV2失败: %v", err)
+	}
+	if len(list.Contents) != 0 {
+		t.Errorf("所有对象应已删除: %d", len(list.Contents))
+	}
+
+	// 超过 1000 个键被拒绝
+	var tooMany []s3Types.ObjectIdentifier
+	for i := 0; i <= 1000; i++ {
+		tooMany = append(tooMany, s3Types.ObjectIdentifier{Key: aws.String(fmt.Sprintf("k%d", i))})
+	}
+	_, err = client.DeleteObjects(ctx, &s3.DeleteObjectsInput{Bucket: bucket, Delete: &s3Types.Delete{Objects: tooMany}})
+	if err == nil || !strings.Contains(err.Error(), "MalformedXML") {
+		t.Errorf("超过 1000 个键应返回 MalformedXML: %v", err)
+	}
+}
+
+// TestAWSSDKObjectVersioning 使用AWS SDK测试对象版本控制
+func TestAWSSDKObjectVersioning(t *testing.T) {
+	ts, cleanup := setupAWSSDKTest(t)
+	defer cleanup()
+
+	client, err := createS3Client(ts.URL)
+	if err != nil {
+		t.Fatalf("创建S3客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	bucket := aws.String("versioning-bucket")
+	key := aws.String("doc.txt")
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: bucket}); err != nil {
+		t.Fatalf("CreateBucket失败: %v", err)
+	}
+	status, err := client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: bucket})
+	if err != nil || status.Status != "" {
+		t.Fatalf("新建桶不应启用版本控制: %v, %v", status.Status, err)
+	}
+	if _, err := client.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+		Bucket:                  bucket,
+		VersioningConfiguration: &s3Types.VersioningConfiguration{Status: s3Types.BucketVersioningStatusEnabled},
+	}); err != nil {
+		t.Fatalf("PutBucketVersioning失败: %v", err)
+	}
+	if status, _ := client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: bucket}); status.Status != s3Types.BucketVersioningStatusEnabled {
+		t.Fatalf("版本控制应已启用: %v", status.Status)
+	}
+
+	var versionIDs []string
+	for _, body := range []string{"v1", "v2"} {
+		out, err := client.PutObject(ctx, &s3.PutObjectInput{Bucket: bucket, Key: key, Body: strings.NewReader(body)})
+		if err != nil {
+			t.Fatalf("PutObject失败: %v", err)
+		}
+		if aws.ToString(out.VersionId) == "" {
+			t.Fatal("PutObject 应返回版本ID")
+		}
+		versionIDs = append(versionIDs, aws.ToString(out.VersionId))
+	}
+
+	read := func(versionID string) (string, error) {
+		input := &s3.GetObjectInput{Bucket: bucket, Key: key}
+		if versionID != "" {
+			input.VersionId = aws.String(versionID)
+		}
+		out, err := client.GetObject(ctx, input)
+		if err != nil {
+			return "", err
+		}
+		defer out.Body.Close()
+		data, err := io.ReadAll(out.Body)
+		return string(data), err
+	}
+	if got, err := read(""); err != nil || got != "v2" {
+		t.Errorf("当前版本: %q, %v", got, err)
+	}
+	if got, err := read(versionIDs[0]); err != nil || got != "v1" {
+		t.Errorf("旧版本应可读取: %q, %v", got, err)
+	}
+	if _, err := read("0123456789abcdef"); err == nil || !strings.Contains(err.Error(), "NoSuchVersion") {
+		t.Errorf("不存在的版本应返回 NoSuchVersion: %v", err)
+	}
+
+	// 删除写入删除标记，旧版本保留
+	del, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: bucket, Key: key})
+	if err != nil {
+		t.Fatalf("DeleteObject失败: %v", err)
+	}
+	if !aws.ToBool(del.DeleteMarker) || aws.ToString(del.VersionId) == "" {
+		t.Fatalf("应返回删除标记: %v %v", aws.ToBool(del.DeleteMarker), aws.ToString(del.VersionId))
+	}
+	if _, err := read(""); err == nil {
+		t.Error("删除后读取当前版本应失败")
+	}
+	if got, err := read(versionIDs[1]); err != nil || got != "v2" {
+		t.Errorf("删除后旧版本应可读取: %q, %v", got, err)
+	}
+
+	// 删除删除标记后对象恢复
+	if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: bucket, Key: key, VersionId: del.VersionId}); err != nil {
+		t.Fatalf("删除删除标记失败: %v", err)
+	}
+	if got, err := read(""); err != nil || got != "v2" {
+		t.Errorf("删除标记移除后应恢复 v2: %q, %v", got, err)
+	}
+
+	// 永久删除当前版本后上一个版本成为当前版本
+	if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: bucket, Key: key, VersionId: aws.String(versionIDs[1])}); err != nil {
+		t.Fatalf("删除版本失败: %v", err)
+	}
+	if got, err := read(""); err != nil || got != "v1" {
+		t.Errorf("删除 v2 后应恢复 v1: %q, %v", got, err)
+	}
+}
+
+// TestAWSSDKBucketLifecycle 使用AWS SDK测试桶生命周期配置
+func TestAWSSDKBucketLifecycle(t *testing.T) {
+	ts, cleanup := setupAWSSDKTest(t)
+	defer cleanup()
+
+	client, err := createS3Client(ts.URL)
+	if err != nil {
+		t.Fatalf("创建S3客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	bucket := aws.String("lifecycle-bucket")
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: bucket}); err != nil {
+		t.Fatalf("CreateBucket失败: %v", err)
+	}
+	_, err = client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: bucket})
+	if err == nil || !strings.Contains(err.Error(), "NoSuchLifecycleConfiguration") {
+		t.Fatalf("未配置时应返回 NoSuchLifecycleConfiguration: %v", err)
+	}
+
+	if _, err := client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: bucket,
+		LifecycleConfiguration: &s3Types.BucketLifecycleConfiguration{Rules: []s3Types.LifecycleRule{
+			{
+				ID:         aws.String("logs"),
+				Filter:     &s3Types.LifecycleRuleFilter{Prefix: aws.String("logs/")},
+				Status:     s3Types.ExpirationStatusEnabled,
+				Expiration: &s3Types.LifecycleExpiration{Days: aws.Int32(30)},
+			},
+			{
+				ID:         aws.String("tmp"),
+				Filter:     &s3Types.LifecycleRuleFilter{Prefix: aws.String("tmp/")},
+				Status:     s3Types.ExpirationStatusDisabled,
+				Expiration: &s3Types.LifecycleExpiration{Days: aws.Int32(1)},
+			},
+		}},
+	}); err != nil {
+		t.Fatalf("PutBucketLifecycleConfiguration失败: %v", err)
+	}
+
+	out, err := client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: bucket})
+	if err != nil {
+		t.Fatalf("GetBucketLifecycleConfiguration失败: %v", err)
+	}
+	if len(out.Rules) != 2 {
+		t.Fatalf("应返回 2 条规则: %d", len(out.Rules))
+	}
+	rule := out.Rules[0]
+	if aws.ToString(rule.ID) != "logs" || rule.Filter == nil || aws.ToString(rule.Filter.Prefix) != "logs/" ||
+		rule.Status != s3Types.ExpirationStatusEnabled || aws.ToInt32(rule.Expiration.Days) != 30 {
+		t.Errorf("规则内容错误: %+v", rule)
+	}
+	if out.Rules[1].Status != s3Types.ExpirationStatusDisabled {
+		t.Errorf("第二条规则应为 Disabled: %v", out.Rules[1].Status)
+	}
+
+	// 不支持的规则返回 NotImplemented
+	_, err = client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: bucket,
+		LifecycleConfiguration: &s3Types.BucketLifecycleConfiguration{Rules: []s3Types.LifecycleRule{{
+			ID:     aws.String("archive"),
+			Filter: &s3Types.LifecycleRuleFilter{Prefix: aws.String("")},
+			Status: s3Types.ExpirationStatusEnabled,
+			Transitions: []s3Types.Transition{{
+				Days:         aws.Int32(30),
+				StorageClass: s3Types.TransitionStorageClassGlacier,
+			}},
+		}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "NotImplemented") {
+		t.Errorf("Transition 应返回 NotImplemented: %v", err)
+	}
+
+	if _, err := client.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{Bucket: bucket}); err != nil {
+		t.Fatalf("DeleteBucketLifecycle失败: %v", err)
+	}
+	_, err = client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: bucket})
+	if err == nil || !strings.Contains(err.Error(), "NoSuchLifecycleConfiguration") {
+		t.Errorf("删除后应返回 NoSuchLifecycleConfiguration: %v", err)
+	}
+}
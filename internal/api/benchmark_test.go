@@ -1,11 +1,14 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	appconfig "sss/internal/config"
 	"sss/internal/auth"
@@ -117,3 +120,80 @@ func BenchmarkGetObjectOnly(b *testing.B) {
 		}
 	})
 }
+
+// heapSamplingWriter 丢弃响应内容，输出过程中定期采样堆内存峰值
+type heapSamplingWriter struct {
+	header   http.Header
+	status   int
+	writes   int
+	bytes    int64
+	peakHeap uint64
+}
+
+func (h *heapSamplingWriter) Header() http.Header { return h.header }
+
+func (h *heapSamplingWriter) WriteHeader(status int) { h.status = status }
+
+func (h *heapSamplingWriter) Write(p []byte) (int, error) {
+	h.writes++
+	h.bytes += int64(len(p))
+	// GC 后的堆大小只包含仍被引用的数据，完整列表留在内存中时会在这里体现
+	if h.writes%64 == 1 {
+		var m runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&m)
+		if m.HeapAlloc > h.peakHeap {
+			h.peakHeap = m.HeapAlloc
+		}
+	}
+	return len(p), nil
+}
+
+// BenchmarkListObjects100k 测试 10 万对象列表的流式输出，内存占用不随对象数增长
+func BenchmarkListObjects100k(b *testing.B) {
+	server, cleanup := setupBenchmark(b)
+	defer cleanup()
+
+	const total = 100000
+	now := time.Now()
+	for i := 0; i < total; i++ {
+		server.metadata.PutObject(&storage.Object{
+			Bucket:       "bench-bucket",
+			Key:          fmt.Sprintf("logs/2024/%08d.log", i),
+			Size:         4096,
+			ETag:         "d41d8cd98f00b204e9800998ecf8427e",
+			ContentType:  "text/plain",
+			LastModified: now,
+			StoragePath:  "/tmp/unused",
+		})
+	}
+	handler := utils.GzipHandler(server)
+
+	// 完整列表约占用数十 MB，流式输出时堆增量应远小于此
+	const maxHeapGrowth = 8 << 20
+	var peak uint64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/bench-bucket?list-type=2&max-keys=200000", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := &heapSamplingWriter{header: http.Header{}}
+
+		var before runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		handler.ServeHTTP(w, req)
+
+		if w.status != http.StatusOK || w.header.Get("Content-Encoding") != "gzip" {
+			b.Fatalf("列表响应异常: status=%d encoding=%q", w.status, w.header.Get("Content-Encoding"))
+		}
+		if w.peakHeap > before.HeapAlloc && w.peakHeap-before.HeapAlloc > peak {
+			peak = w.peakHeap - before.HeapAlloc
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(peak)/(1<<20), "heap-growth-MB")
+	if peak > maxHeapGrowth {
+		b.Fatalf("列表输出期间堆内存增长 %d 字节，超过上限 %d", peak, maxHeapGrowth)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"sss/internal/admin"
 	"sss/internal/config"
+	"sss/internal/storage"
 	"sss/internal/utils"
 )
 
@@ -45,7 +47,7 @@ func (s *Server) handleListBuckets(w http.ResponseWriter, r *http.Request) {
 	result := ListAllMyBucketsResult{
 		Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/",
 		Owner: Owner{
-			ID:          config.Global.Auth.AccessKeyID,
+			ID:          bucketOwnerID(),
 			DisplayName: "sss-user",
 		},
 		Buckets: Buckets{
@@ -53,9 +55,14 @@ func (s *Server) handleListBuckets(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	// 带命名空间的 Key 只能看到命名空间下的桶，返回时去掉前缀
+	ns := requestNamespace(r)
 	for _, b := range buckets {
+		if !strings.HasPrefix(b.Name, ns) {
+			continue
+		}
 		result.Buckets.Bucket = append(result.Buckets.Bucket, BucketInfo{
-			Name:         b.Name,
+			Name:         strings.TrimPrefix(b.Name, ns),
 			CreationDate: b.CreationDate.UTC().Format(time.RFC3339),
 		})
 	}
@@ -65,6 +72,10 @@ func (s *Server) handleListBuckets(w http.ResponseWriter, r *http.Request) {
 
 // handleCreateBucket 创建存储桶
 func (s *Server) handleCreateBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	if !s.checkBucketLimit(w, bucket) {
+		return
+	}
+
 	// 直接尝试创建，依赖数据库 PRIMARY KEY 约束处理冲突
 	if err := s.metadata.CreateBucket(bucket); err != nil {
 		// 检查是否是重复键错误（桶已存在）
@@ -86,10 +97,78 @@ func (s *Server) handleCreateBucket(w http.ResponseWriter, r *http.Request, buck
 		return
 	}
 
-	w.Header().Set("Location", "/"+bucket)
+	w.Header().Set("Location", "/"+displayBucket(r, bucket))
 	w.WriteHeader(http.StatusOK)
 }
 
+// checkBucketLimit 检查桶数量上限，超出时写入错误响应
+func (s *Server) checkBucketLimit(w http.ResponseWriter, bucket string) bool {
+	maxBuckets := config.Global.Storage.MaxBuckets
+	if maxBuckets <= 0 {
+		return true
+	}
+	count, err := s.metadata.CountBuckets()
+	if err != nil {
+		utils.Error("count buckets failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+		return false
+	}
+	if count >= maxBuckets {
+		utils.WriteError(w, utils.ErrTooManyBuckets, http.StatusBadRequest, "/"+bucket)
+		return false
+	}
+	return true
+}
+
+// autoCreateBucket 上传对象时隐式创建不存在的桶（需开启 AutoCreate），返回创建后的桶
+// 带命名空间的 API Key 只能在命名空间下建桶，桶名缺少前缀时补上；失败时已写入错误响应并返回 false
+func (s *Server) autoCreateBucket(w http.ResponseWriter, r *http.Request, bucket string) (*storage.Bucket, bool) {
+	ns := requestNamespace(r)
+	if ns == "" {
+		_, ns = withKeyNamespace(r)
+	}
+	if !strings.HasPrefix(bucket, ns) {
+		bucket = ns + bucket
+	}
+	if !utils.IsValidBucketName(bucket) {
+		utils.WriteError(w, utils.ErrInvalidBucketName, http.StatusBadRequest, "/"+bucket)
+		return nil, false
+	}
+	if !s.checkBucketLimit(w, bucket) {
+		return nil, false
+	}
+
+	if err := s.metadata.CreateBucket(bucket); err != nil {
+		// 并发请求可能已创建该桶
+		if existing, _ := s.metadata.GetBucket(bucket); existing != nil {
+			return existing, true
+		}
+		utils.Error("auto create bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+		return nil, false
+	}
+	if err := s.filestore.CreateBucket(bucket); err != nil {
+		utils.Error("create bucket directory failed", "error", err)
+		s.metadata.DeleteBucket(bucket) // 回滚
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+		return nil, false
+	}
+	created, err := s.metadata.GetBucket(bucket)
+	if err != nil || created == nil {
+		utils.Error("get auto created bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+		return nil, false
+	}
+
+	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	utils.Info("bucket auto created", "bucket", bucket, "access_key", accessKeyID)
+	s.adminHandler.Audit(r, storage.AuditActionBucketCreate, accessKeyID, bucket, true, map[string]interface{}{
+		"implicit": true,
+		"trigger":  "put_object",
+	})
+	return created, true
+}
+
 // handleDeleteBucket 删除存储桶
 func (s *Server) handleDeleteBucket(w http.ResponseWriter, r *http.Request, bucket string) {
 	// 检查是否存在
@@ -104,6 +183,26 @@ func (s *Server) handleDeleteBucket(w http.ResponseWriter, r *http.Request, buck
 		return
 	}
 
+	// 强制删除：force=true 且 confirm 等于桶名时先清空桶再删除，仍受不可变窗口约束
+	if force, ok := admin.ForceDeleteRequested(r, bucket); force {
+		if !ok {
+			utils.WriteError(w, utils.ErrForceDeleteConfirm, http.StatusBadRequest, "/"+bucket)
+			return
+		}
+		accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+		if _, err := s.adminHandler.ForceDeleteBucket(r, existing, accessKeyID, true); err != nil {
+			if err == storage.ErrBucketImmutable {
+				utils.WriteError(w, utils.ErrObjectImmutable, http.StatusForbidden, "/"+bucket)
+			} else {
+				utils.Error("force delete bucket failed", "error", err)
+				utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	// 删除元数据（会检查是否为空）
 	if err := s.metadata.DeleteBucket(bucket); err != nil {
 		if err.Error() == "bucket not empty" {
@@ -119,6 +218,9 @@ func (s *Server) handleDeleteBucket(w http.ResponseWriter, r *http.Request, buck
 	if err := s.filestore.DeleteBucket(bucket); err != nil {
 		utils.Error("delete bucket directory failed", "error", err)
 	}
+	s.adminHandler.ForgetReplication(bucket)
+	s.adminHandler.ForgetNotifications(bucket)
+	s.adminHandler.ForgetOrigin(bucket)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -140,7 +242,34 @@ func (s *Server) handleHeadBucket(w http.ResponseWriter, r *http.Request, bucket
 	w.WriteHeader(http.StatusOK)
 }
 
-// ListBucketResult ListObjects V1 响应
+// LocationConstraint GetBucketLocation 响应，us-east-1 按 S3 规范返回空元素
+type LocationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Region  string   `xml:",chardata"`
+}
+
+// handleGetBucketLocation 返回桶所在区域（即配置的区域），rclone 等客户端初始化时用于探测区域
+func (s *Server) handleGetBucketLocation(w http.ResponseWriter, r *http.Request, bucket string) {
+	existing, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+		return
+	}
+	if existing == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+bucket)
+		return
+	}
+
+	location := LocationConstraint{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/"}
+	if region := config.Global.Server.Region; region != "us-east-1" {
+		location.Region = region
+	}
+	utils.WriteXML(w, http.StatusOK, location)
+}
+
+// ListBucketResult ListObjects V1 响应（响应由 listResultStream 流式输出，结构体用于描述格式和解析）
 type ListBucketResult struct {
 	XMLName        xml.Name       `xml:"ListBucketResult"`
 	Xmlns          string         `xml:"xmlns,attr"`
@@ -148,7 +277,9 @@ type ListBucketResult struct {
 	Prefix         string         `xml:"Prefix"`
 	Marker         string         `xml:"Marker"`
 	MaxKeys        int            `xml:"MaxKeys"`
+	Delimiter      string         `xml:"Delimiter,omitempty"`
 	IsTruncated    bool           `xml:"IsTruncated"`
+	NextMarker     string         `xml:"NextMarker,omitempty"`
 	Contents       []ObjectInfo   `xml:"Contents"`
 	CommonPrefixes []CommonPrefix `xml:"CommonPrefixes,omitempty"`
 }
@@ -161,6 +292,7 @@ type ListBucketResultV2 struct {
 	Prefix                string         `xml:"Prefix"`
 	KeyCount              int            `xml:"KeyCount"`
 	MaxKeys               int            `xml:"MaxKeys"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
 	IsTruncated           bool           `xml:"IsTruncated"`
 	Contents              []ObjectInfo   `xml:"Contents"`
 	CommonPrefixes        []CommonPrefix `xml:"CommonPrefixes,omitempty"`
@@ -197,96 +329,104 @@ func (s *Server) handleListObjects(w http.ResponseWriter, r *http.Request, bucke
 
 	query := r.URL.Query()
 	prefix := query.Get("prefix")
+	// 存储的键不含前导斜杠，normalize 模式下前缀同样去除
+	if config.Global.Storage.LeadingSlash != config.LeadingSlashReject {
+		prefix = strings.TrimLeft(prefix, "/")
+	}
 	delimiter := query.Get("delimiter")
 	maxKeysStr := query.Get("max-keys")
 	maxKeys := 1000
 	if maxKeysStr != "" {
-		if n, err := strconv.Atoi(maxKeysStr); err == nil && n > 0 {
-			maxKeys = n
+		n, err := strconv.Atoi(maxKeysStr)
+		if err != nil || n < 0 {
+			utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, "/"+bucket)
+			return
 		}
+		maxKeys = n
 	}
 
-	// 判断是 V1 还是 V2
+	// 判断是 V1 还是 V2，两者都按页读取后流式输出，不在内存中构建完整列表
+	var stream *listResultStream
+	var marker string
+	var tail func(result *storage.ListObjectsResult) []xmlField
 	if query.Get("list-type") == "2" {
 		// V2
 		continuationToken := query.Get("continuation-token")
 		startAfter := query.Get("start-after")
-		marker := continuationToken
+		marker = continuationToken
 		if marker == "" {
 			marker = startAfter
 		}
 
-		result, err := s.metadata.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
-		if err != nil {
-			utils.Error("list objects failed", "error", err)
-			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
-			return
+		head := []xmlField{{"Name", displayBucket(r, bucket)}, {"Prefix", prefix}, {"MaxKeys", maxKeys}}
+		if continuationToken != "" {
+			head = append(head, xmlField{"ContinuationToken", continuationToken})
 		}
-
-		response := ListBucketResultV2{
-			Xmlns:             "http://s3.amazonaws.com/doc/2006-03-01/",
-			Name:              bucket,
-			Prefix:            prefix,
-			KeyCount:          result.KeyCount,
-			MaxKeys:           maxKeys,
-			IsTruncated:       result.IsTruncated,
-			ContinuationToken: continuationToken,
-			StartAfter:        startAfter,
+		if startAfter != "" {
+			head = append(head, xmlField{"StartAfter", startAfter})
 		}
-
-		if result.IsTruncated {
-			response.NextContinuationToken = result.NextMarker
+		if delimiter != "" {
+			head = append(head, xmlField{"Delimiter", delimiter})
 		}
-
-		for _, obj := range result.Contents {
-			response.Contents = append(response.Contents, ObjectInfo{
-				Key:          obj.Key,
-				LastModified: obj.LastModified.UTC().Format(time.RFC3339),
-				ETag:         `"` + obj.ETag + `"`,
-				Size:         obj.Size,
-				StorageClass: "STANDARD",
-			})
+		stream = newListResultStream(w, head)
+		tail = func(result *storage.ListObjectsResult) []xmlField {
+			fields := []xmlField{{"KeyCount", result.KeyCount}, {"IsTruncated", result.IsTruncated}}
+			if result.IsTruncated {
+				fields = append(fields, xmlField{"NextContinuationToken", result.NextMarker})
+			}
+			return fields
 		}
-
-		for _, p := range result.CommonPrefixes {
-			response.CommonPrefixes = append(response.CommonPrefixes, CommonPrefix{Prefix: p})
-		}
-
-		utils.WriteXML(w, http.StatusOK, response)
 	} else {
 		// V1
-		marker := query.Get("marker")
-
-		result, err := s.metadata.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
-		if err != nil {
-			utils.Error("list objects failed", "error", err)
-			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
-			return
+		marker = query.Get("marker")
+		head := []xmlField{{"Name", displayBucket(r, bucket)}, {"Prefix", prefix}, {"Marker", marker}, {"MaxKeys", maxKeys}}
+		if delimiter != "" {
+			head = append(head, xmlField{"Delimiter", delimiter})
 		}
-
-		response := ListBucketResult{
-			Xmlns:       "http://s3.amazonaws.com/doc/2006-03-01/",
-			Name:        bucket,
-			Prefix:      prefix,
-			Marker:      marker,
-			MaxKeys:     maxKeys,
-			IsTruncated: result.IsTruncated,
+		stream = newListResultStream(w, head)
+		tail = func(result *storage.ListObjectsResult) []xmlField {
+			fields := []xmlField{{"IsTruncated", result.IsTruncated}}
+			// 超时截断时最后扫描的键可能未出现在 Contents 中，客户端需要 NextMarker 才能正确续传
+			if result.IsTruncated {
+				fields = append(fields, xmlField{"NextMarker", result.NextMarker})
+			}
+			return fields
 		}
+	}
 
-		for _, obj := range result.Contents {
-			response.Contents = append(response.Contents, ObjectInfo{
-				Key:          obj.Key,
-				LastModified: obj.LastModified.UTC().Format(time.RFC3339),
-				ETag:         `"` + obj.ETag + `"`,
-				Size:         obj.Size,
-				StorageClass: "STANDARD",
-			})
+	// max-keys=0 时与 S3 一致返回空列表，不扫描对象
+	result := &storage.ListObjectsResult{}
+	if maxKeys > 0 {
+		result, err = s.walkObjects(bucket, prefix, marker, delimiter, maxKeys, stream.visitor())
+	}
+	if err == nil {
+		if result.BudgetExceeded {
+			utils.Info("list objects exceeded time budget, returning partial result",
+				"bucket", bucket, "prefix", prefix, "keys", result.KeyCount, "next_marker", result.NextMarker)
 		}
-
-		for _, p := range result.CommonPrefixes {
-			response.CommonPrefixes = append(response.CommonPrefixes, CommonPrefix{Prefix: p})
+		err = stream.finish(tail(result))
+	}
+	if err != nil {
+		if stream.started {
+			// 响应头已发出，只能中断输出，客户端会收到不完整的 XML
+			utils.Warn("stream list objects failed", "bucket", bucket, "error", err)
+			return
 		}
-
-		utils.WriteXML(w, http.StatusOK, response)
+		utils.Error("list objects failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
 	}
 }
+
+// walkObjects 按文件夹占位策略流式列出对象，占位模式下占位对象只以 CommonPrefixes 出现
+// 配置了列举时间预算时超时返回部分结果，IsTruncated 为 true 且续传标记指向最后扫描的键
+func (s *Server) walkObjects(bucket, prefix, marker, delimiter string, maxKeys int, v storage.ObjectListVisitor) (*storage.ListObjectsResult, error) {
+	return s.metadata.WalkObjects(&storage.ObjectListQuery{
+		Bucket:            bucket,
+		Prefix:            prefix,
+		Marker:            marker,
+		Delimiter:         delimiter,
+		MaxKeys:           maxKeys,
+		HideFolderMarkers: config.Global.Storage.FolderPlaceholders(),
+		Deadline:          config.Global.Storage.ListDeadline(time.Now()),
+	}, v)
+}
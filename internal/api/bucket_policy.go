@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// handleBucketPolicy 处理 ?policy 请求（GetBucketPolicy/PutBucketPolicy/DeleteBucketPolicy）
+// 策略中包含 API Key，读取和修改都只有桶所有者（管理员 Key）可以调用
+func (s *Server) handleBucketPolicy(w http.ResponseWriter, r *http.Request, bucket string) {
+	resource := "/" + bucket
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if b == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, resource)
+		return
+	}
+	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	if accessKeyID == "" || accessKeyID != bucketOwnerID() {
+		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, resource)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if b.Policy == nil {
+			utils.WriteError(w, utils.ErrNoSuchBucketPolicy, http.StatusNotFound, resource)
+			return
+		}
+		utils.WriteJSONResponse(w, b.Policy)
+	case http.MethodPut:
+		body, err := io.ReadAll(io.LimitReader(r.Body, storage.MaxBucketPolicySize+1))
+		if err != nil {
+			utils.WriteError(w, utils.ErrMalformedPolicy, http.StatusBadRequest, resource)
+			return
+		}
+		if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+			utils.WriteError(w, utils.ErrMalformedPolicy, http.StatusBadRequest, resource)
+			return
+		}
+		policy, err := storage.ParseBucketPolicy(bucket, body)
+		if err != nil {
+			e := utils.ErrMalformedPolicy
+			e.Message = err.Error()
+			utils.WriteError(w, e, http.StatusBadRequest, resource)
+			return
+		}
+		if err := s.metadata.SetBucketPolicy(bucket, policy); err != nil {
+			utils.Error("update bucket policy failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+			return
+		}
+		s.adminHandler.Audit(r, storage.AuditActionBucketSetPolicy, accessKeyID, bucket, true, map[string]interface{}{
+			"statements": len(policy.Statement),
+		})
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if err := s.metadata.SetBucketPolicy(bucket, nil); err != nil {
+			utils.Error("delete bucket policy failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+			return
+		}
+		if b.Policy != nil {
+			s.adminHandler.Audit(r, storage.AuditActionBucketSetPolicy, accessKeyID, bucket, true, map[string]interface{}{
+				"statements": 0,
+			})
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, resource)
+	}
+}
+
+// policyAction 返回请求对应的桶策略操作，桶子资源、多段上传管理等不受桶策略控制的请求返回空
+func policyAction(r *http.Request, key string) string {
+	query := r.URL.Query()
+	for _, sub := range []string{"acl", "tagging", "versioning", "policy", "lifecycle", "delete"} {
+		if query.Has(sub) {
+			return ""
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if key == "" {
+			if query.Has("uploads") {
+				return ""
+			}
+			return storage.PolicyActionListBucket
+		}
+		if query.Has("uploadId") {
+			return ""
+		}
+		return storage.PolicyActionGetObject
+	case http.MethodPut, http.MethodPost:
+		if key == "" {
+			return ""
+		}
+		if isSelectRequest(r) {
+			return storage.PolicyActionGetObject
+		}
+		// 多段上传的初始化、上传分段和完成都属于 PutObject
+		return storage.PolicyActionPutObject
+	case http.MethodDelete:
+		if key == "" || query.Has("uploadId") {
+			return ""
+		}
+		return storage.PolicyActionDeleteObject
+	}
+	return ""
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -241,6 +242,39 @@ func TestHandleDeleteBucket(t *testing.T) {
 		}
 	})
 
+	t.Run("强制删除非空桶", func(t *testing.T) {
+		bucketName := "bucket-force-delete"
+		createTestBucket(t, server, bucketName)
+		storagePath, etag, _ := server.filestore.PutObject(bucketName, "a.txt", strings.NewReader("data"), 4)
+		server.metadata.PutObject(&storage.Object{Bucket: bucketName, Key: "a.txt", Size: 4, ETag: etag, StoragePath: storagePath, LastModified: time.Now()})
+
+		// 普通删除返回 409
+		w := httptest.NewRecorder()
+		server.handleDeleteBucket(w, httptest.NewRequest("DELETE", "/"+bucketName, nil), bucketName)
+		if w.Code != http.StatusConflict {
+			t.Fatalf("非空桶应返回409: got %d", w.Code)
+		}
+
+		// confirm 与桶名不一致时拒绝
+		w = httptest.NewRecorder()
+		server.handleDeleteBucket(w, httptest.NewRequest("DELETE", "/"+bucketName+"?force=true&confirm=other", nil), bucketName)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("confirm 不一致应返回400: got %d", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		server.handleDeleteBucket(w, httptest.NewRequest("DELETE", "/"+bucketName+"?force=true&confirm="+bucketName, nil), bucketName)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("强制删除失败: got %d, body: %s", w.Code, w.Body.String())
+		}
+		if b, _ := server.metadata.GetBucket(bucketName); b != nil {
+			t.Error("桶应已删除")
+		}
+		if _, err := os.Stat(storagePath); !os.IsNotExist(err) {
+			t.Errorf("对象文件应已删除: %v", err)
+		}
+	})
+
 	t.Run("删除不存在的桶", func(t *testing.T) {
 		req := httptest.NewRequest("DELETE", "/non-existent", nil)
 		w := httptest.NewRecorder()
@@ -290,6 +324,73 @@ func TestHandleHeadBucket(t *testing.T) {
 	})
 }
 
+// TestHandleGetBucketLocation 测试获取桶区域
+func TestHandleGetBucketLocation(t *testing.T) {
+	server, cleanup := setupBucketTestServer(t)
+	defer cleanup()
+
+	bucketName := "location-bucket"
+	createTestBucket(t, server, bucketName)
+
+	getLocation := func(t *testing.T, region string) LocationConstraint {
+		t.Helper()
+		oldRegion := config.Global.Server.Region
+		config.Global.Server.Region = region
+		defer func() { config.Global.Server.Region = oldRegion }()
+
+		req := httptest.NewRequest("GET", "/"+bucketName+"?location", nil)
+		w := httptest.NewRecorder()
+		server.handleGetBucketLocation(w, req, bucketName)
+		if w.Code != http.StatusOK {
+			t.Fatalf("状态码不正确: got %d, want %d", w.Code, http.StatusOK)
+		}
+		var location LocationConstraint
+		if err := xml.Unmarshal(w.Body.Bytes(), &location); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		return location
+	}
+
+	t.Run("us-east-1返回空元素", func(t *testing.T) {
+		if location := getLocation(t, "us-east-1"); location.Region != "" {
+			t.Errorf("us-east-1 应返回空元素: got %q", location.Region)
+		}
+	})
+
+	t.Run("其他区域", func(t *testing.T) {
+		if location := getLocation(t, "eu-west-1"); location.Region != "eu-west-1" {
+			t.Errorf("区域不正确: got %q, want eu-west-1", location.Region)
+		}
+	})
+
+	t.Run("桶不存在", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/non-existent?location", nil)
+		w := httptest.NewRecorder()
+		server.handleGetBucketLocation(w, req, "non-existent")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("应该返回404: got %d", w.Code)
+		}
+	})
+
+	t.Run("匿名访问与列举权限一致", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/"+bucketName+"?location", nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("私有桶匿名访问应返回403: got %d", w.Code)
+		}
+
+		server.metadata.UpdateBucketPublic(bucketName, true)
+		defer server.metadata.UpdateBucketPublic(bucketName, false)
+		req = httptest.NewRequest("GET", "/"+bucketName+"?location", nil)
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "LocationConstraint") {
+			t.Errorf("公有桶匿名访问应成功: got %d %s", w.Code, w.Body.String())
+		}
+	})
+}
+
 // TestHandleListObjects 测试列举对象
 func TestHandleListObjects(t *testing.T) {
 	server, cleanup := setupBucketTestServer(t)
@@ -436,6 +537,53 @@ func TestHandleListObjectsWithParams(t *testing.T) {
 			t.Errorf("ContinuationToken不匹配: got %s", result.ContinuationToken)
 		}
 	})
+
+	t.Run("V1截断时返回NextMarker", func(t *testing.T) {
+		for _, key := range []string{"m1.txt", "m2.txt"} {
+			server.metadata.PutObject(&storage.Object{Bucket: bucketName, Key: key, Size: 1, ETag: "test", StoragePath: "/path/" + key})
+		}
+		req := httptest.NewRequest("GET", "/"+bucketName+"?max-keys=1", nil)
+		w := httptest.NewRecorder()
+		server.handleListObjects(w, req, bucketName)
+
+		var result ListBucketResult
+		if err := xml.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if !result.IsTruncated || result.NextMarker != "m1.txt" {
+			t.Errorf("截断响应错误: truncated=%v next=%q", result.IsTruncated, result.NextMarker)
+		}
+	})
+}
+
+// TestHandleListObjectsMaxKeys 测试 max-keys 的校验和 0 值
+func TestHandleListObjectsMaxKeys(t *testing.T) {
+	server, cleanup := setupBucketTestServer(t)
+	defer cleanup()
+
+	bucketName := "max-keys-bucket"
+	createTestBucket(t, server, bucketName)
+	server.metadata.PutObject(&storage.Object{Bucket: bucketName, Key: "a.txt", Size: 1, ETag: "test", StoragePath: "/path/a.txt"})
+
+	for _, value := range []string{"abc", "-1"} {
+		req := httptest.NewRequest("GET", "/"+bucketName+"?max-keys="+value, nil)
+		w := httptest.NewRecorder()
+		server.handleListObjects(w, req, bucketName)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "InvalidArgument") {
+			t.Errorf("max-keys=%s 应返回 InvalidArgument: %d %s", value, w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/"+bucketName+"?list-type=2&max-keys=0", nil)
+	w := httptest.NewRecorder()
+	server.handleListObjects(w, req, bucketName)
+	var result ListBucketResultV2
+	if err := xml.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if result.MaxKeys != 0 || result.KeyCount != 0 || len(result.Contents) != 0 || result.IsTruncated {
+		t.Errorf("max-keys=0 应返回空列表: %+v", result)
+	}
 }
 
 // TestListBucketResultXML 测试XML序列化
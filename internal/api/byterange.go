@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+
+	"sss/internal/config"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// maxByteRanges 一个 Range 请求最多的范围数，超过时按整个对象处理
+const maxByteRanges = 32
+
+// byteRange Range 请求中的一个字节范围，start 和 end 都包含在内
+type byteRange struct {
+	start, end int64
+}
+
+// length 范围的字节数
+func (br byteRange) length() int64 {
+	return br.end - br.start + 1
+}
+
+// contentRange 返回范围对应的 Content-Range 头
+func (br byteRange) contentRange(size int64) string {
+	return "bytes " + strconv.FormatInt(br.start, 10) + "-" + strconv.FormatInt(br.end, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// parseByteRange 解析单个范围（first-last、first- 或后缀 -N），end 超过对象大小时截断
+// 范围不可满足时返回 false
+func parseByteRange(spec string, size int64) (byteRange, bool) {
+	br := byteRange{start: 0, end: size - 1}
+	parts := strings.Split(strings.TrimSpace(spec), "-")
+	if len(parts) == 2 && parts[0] == "" && parts[1] != "" {
+		// 后缀范围 bytes=-N：最后 N 个字节，N 超过对象大小时返回整个对象
+		suffix, err := strconv.ParseInt(parts[1], 10, 64)
+		if err == nil && suffix > 0 {
+			if suffix < size {
+				br.start = size - suffix
+			}
+		} else if err == nil {
+			// bytes=-0 不可满足
+			br.start = size
+		}
+	} else if len(parts) == 2 {
+		if parts[0] != "" {
+			parsedStart, err := strconv.ParseInt(parts[0], 10, 64)
+			if err == nil && parsedStart >= 0 {
+				br.start = parsedStart
+			}
+		}
+		if parts[1] != "" {
+			parsedEnd, err := strconv.ParseInt(parts[1], 10, 64)
+			if err == nil && parsedEnd >= 0 {
+				br.end = parsedEnd
+			}
+		}
+	}
+	if br.end >= size {
+		br.end = size - 1
+	}
+	return br, br.start <= br.end
+}
+
+// parseRangeHeader 解析 Range 请求头，多个范围以逗号分隔，不可满足的范围被丢弃
+// 所有范围都不可满足时返回 false（416）；不是 bytes= 格式或范围过多时按整个对象处理
+func parseRangeHeader(header string, size int64) ([]byteRange, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	specs := strings.Split(spec, ",")
+	if !ok || len(specs) > maxByteRanges {
+		return []byteRange{{start: 0, end: size - 1}}, true
+	}
+	var ranges []byteRange
+	for _, s := range specs {
+		if br, ok := parseByteRange(s, size); ok {
+			ranges = append(ranges, br)
+		}
+	}
+	return ranges, len(ranges) > 0
+}
+
+// byteCounter 只统计写入的字节数
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// writeByteRanges 以 multipart/byteranges 格式返回多个范围，每段带自己的 Content-Type 和 Content-Range
+func writeByteRanges(w http.ResponseWriter, r *http.Request, file storage.ObjectReader, obj *storage.Object, ranges []byteRange) {
+	mw := multipart.NewWriter(w)
+	partHeader := func(br byteRange) textproto.MIMEHeader {
+		return textproto.MIMEHeader{
+			"Content-Type":  {obj.ContentType},
+			"Content-Range": {br.contentRange(obj.Size)},
+		}
+	}
+
+	// 用相同的分隔符写一遍分段头，预先算出 Content-Length
+	var counter byteCounter
+	cw := multipart.NewWriter(&counter)
+	cw.SetBoundary(mw.Boundary())
+	length := int64(0)
+	for _, br := range ranges {
+		cw.CreatePart(partHeader(br))
+		length += br.length()
+	}
+	cw.Close()
+	length += int64(counter)
+
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	w.WriteHeader(http.StatusPartialContent)
+
+	for _, br := range ranges {
+		if _, err := mw.CreatePart(partHeader(br)); err != nil {
+			utils.Debug("write range part failed", "error", err)
+			return
+		}
+		if _, err := file.Seek(br.start, 0); err != nil {
+			utils.Error("seek file failed", "error", err)
+			return
+		}
+		// 分段写入器不做缓冲，数据直接写入响应以保留下载限速
+		if !copyObjectBody(w, r, file, obj, br.length()) {
+			return
+		}
+	}
+	mw.Close()
+}
+
+// copyObjectBody 把对象文件的 n 字节写入响应，失败时记录日志并返回 false
+func copyObjectBody(w http.ResponseWriter, r *http.Request, file storage.ObjectReader, obj *storage.Object, n int64) bool {
+	// 配置了下载最低速度时，接收过慢的客户端被中止，避免长期占用连接
+	server := config.Global.Server
+	if _, err := utils.CopyWithMinRate(w, file, n, server.MinDownloadRate, server.MinDownloadWindow); err != nil {
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			utils.Warn("abort slow download", "bucket", obj.Bucket, "key", obj.Key, "ip", utils.GetClientIP(r))
+			return false
+		}
+		// 客户端可能已断开连接，只记录日志
+		utils.Debug("copy to response failed", "error", err)
+		return false
+	}
+	return true
+}
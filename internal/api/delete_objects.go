@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// DeleteObjects 请求限制
+const (
+	maxDeleteObjects     = 1000            // 单次最多删除的对象数，与 S3 一致
+	maxDeleteObjectsBody = 2 * 1024 * 1024 // 请求体大小上限
+)
+
+// DeleteObjectsRequest DeleteObjects 请求体
+type DeleteObjectsRequest struct {
+	XMLName xml.Name                `xml:"Delete"`
+	Quiet   bool                    `xml:"Quiet"`
+	Objects []DeleteObjectsIdentity `xml:"Object"`
+}
+
+// DeleteObjectsIdentity 待删除的对象
+type DeleteObjectsIdentity struct {
+	Key       string `xml:"Key"`
+	VersionID string `xml:"VersionId,omitempty"`
+}
+
+// DeleteResult DeleteObjects 响应
+type DeleteResult struct {
+	XMLName xml.Name              `xml:"DeleteResult"`
+	Xmlns   string                `xml:"xmlns,attr"`
+	Deleted []DeletedObject       `xml:"Deleted"`
+	Errors  []DeleteObjectsFailed `xml:"Error"`
+}
+
+// DeletedObject 删除成功的对象，启用版本控制的桶中写入或删除了删除标记时返回 DeleteMarker
+type DeletedObject struct {
+	Key                   string `xml:"Key"`
+	VersionID             string `xml:"VersionId,omitempty"`
+	DeleteMarker          bool   `xml:"DeleteMarker,omitempty"`
+	DeleteMarkerVersionID string `xml:"DeleteMarkerVersionId,omitempty"`
+}
+
+// DeleteObjectsFailed 删除失败的对象
+type DeleteObjectsFailed struct {
+	Key       string `xml:"Key"`
+	VersionID string `xml:"VersionId,omitempty"`
+	Code      string `xml:"Code"`
+	Message   string `xml:"Message"`
+}
+
+// handleDeleteObjects 批量删除对象
+// POST /{bucket}?delete，每个键单独返回结果，Quiet 模式只返回失败项
+func (s *Server) handleDeleteObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	resource := "/" + bucket
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if b == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, resource)
+		return
+	}
+	if !checkReadOnly(w, b, resource) {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxDeleteObjectsBody+1))
+	if err != nil || len(body) > maxDeleteObjectsBody {
+		utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, resource)
+		return
+	}
+	var req DeleteObjectsRequest
+	if err := xml.Unmarshal(body, &req); err != nil || len(req.Objects) == 0 || len(req.Objects) > maxDeleteObjects {
+		utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, resource)
+		return
+	}
+
+	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	result := DeleteResult{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/"}
+	for _, o := range req.Objects {
+		if deleted, failed := s.deleteObjectEntry(b, accessKeyID, o); failed != nil {
+			result.Errors = append(result.Errors, *failed)
+		} else if !req.Quiet {
+			result.Deleted = append(result.Deleted, deleted)
+		}
+	}
+	utils.WriteXML(w, http.StatusOK, result)
+}
+
+// deleteObjectEntry 删除单个对象或指定版本，失败时返回错误项；不存在的对象与 S3 一致视为删除成功
+// 桶策略拒绝 principal 删除的键返回 AccessDenied
+func (s *Server) deleteObjectEntry(b *storage.Bucket, principal string, o DeleteObjectsIdentity) (DeletedObject, *DeleteObjectsFailed) {
+	deleted := DeletedObject{Key: o.Key, VersionID: o.VersionID}
+	fail := func(e utils.S3Error) (DeletedObject, *DeleteObjectsFailed) {
+		return deleted, &DeleteObjectsFailed{Key: o.Key, VersionID: o.VersionID, Code: e.Code, Message: e.Message}
+	}
+	// 安全检查：防止路径遍历
+	if o.Key == "" || strings.Contains(o.Key, "..") {
+		return fail(utils.ErrInvalidArgument)
+	}
+	key, ok := normalizeObjectKey(o.Key)
+	if !ok {
+		return fail(utils.ErrLeadingSlashKey)
+	}
+	key = b.RewriteKey(key)
+	if principal != bucketOwnerID() && b.PolicyDecision(principal, storage.PolicyActionDeleteObject, key) == storage.PolicyDeny {
+		return fail(utils.ErrAccessDenied)
+	}
+
+	if o.VersionID != "" {
+		v, err := s.deleteObjectVersion(b, key, o.VersionID)
+		if errors.Is(err, errVersionImmutable) {
+			return fail(utils.ErrObjectImmutable)
+		}
+		if err != nil {
+			utils.Error("delete object version failed", "key", key, "error", err)
+			return fail(utils.ErrInternalError)
+		}
+		// 版本 "null" 与不存在的对象一样视为删除成功
+		if v == nil && o.VersionID != storage.NullVersionID {
+			return fail(utils.ErrNoSuchVersion)
+		}
+		if v != nil && v.DeleteMarker {
+			deleted.DeleteMarker = true
+			deleted.DeleteMarkerVersionID = o.VersionID
+		}
+		return deleted, nil
+	}
+
+	obj, err := s.metadata.GetObject(b.Name, key)
+	if err != nil {
+		utils.Error("get object metadata failed", "key", key, "error", err)
+		return fail(utils.ErrInternalError)
+	}
+	if obj != nil && b.ImmutableRemaining(obj, time.Now()) > 0 {
+		return fail(utils.ErrObjectImmutable)
+	}
+	// 启用版本控制的桶写入删除标记，文件保留
+	if b.VersioningEnabled {
+		markerID := utils.GenerateID(16)
+		if _, err := s.metadata.PutDeleteMarker(b.Name, key, markerID, "", -1); err != nil {
+			utils.Error("put delete marker failed", "key", key, "error", err)
+			return fail(utils.ErrInternalError)
+		}
+		if obj != nil {
+			s.adminHandler.Replicate(b.Name, key, storage.ReplicationOpDelete)
+		}
+		s.adminHandler.NotifyObjectRemoved(storage.EventObjectRemovedDeleteMarkerCreated, b.Name, key, markerID)
+		deleted.DeleteMarker = true
+		deleted.DeleteMarkerVersionID = markerID
+		return deleted, nil
+	}
+	if obj == nil {
+		return deleted, nil
+	}
+
+	if err := s.filestore.DeleteObject(obj.StoragePath); err != nil {
+		utils.Warn("delete object file failed", "key", key, "error", err)
+	}
+	if err := s.metadata.DeleteObject(b.Name, key); err != nil {
+		utils.Error("delete object metadata failed", "key", key, "error", err)
+		return fail(utils.ErrInternalError)
+	}
+	s.adminHandler.Replicate(b.Name, key, storage.ReplicationOpDelete)
+	s.adminHandler.NotifyObjectRemoved(storage.EventObjectRemovedDelete, b.Name, key, "")
+	return deleted, nil
+}
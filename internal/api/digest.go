@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sss/internal/auth"
+	"sss/internal/utils"
+)
+
+var (
+	// errBadDigest 请求体与 Content-MD5 不一致
+	errBadDigest = errors.New("content-md5 does not match the payload")
+	// errContentSHA256Mismatch 请求体与 x-amz-content-sha256 不一致
+	errContentSHA256Mismatch = errors.New("x-amz-content-sha256 does not match the payload")
+)
+
+// digestReader 读取请求体时计算摘要，读到结尾时与请求头中的值比较，不一致时返回错误代替 io.EOF
+// 写入方因此会中止并丢弃临时文件，不会留下不完整的对象
+type digestReader struct {
+	r         io.Reader
+	md5       hash.Hash
+	wantMD5   []byte
+	sha256    hash.Hash
+	wantSHA   []byte
+	verifyErr error
+}
+
+func (d *digestReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if n > 0 {
+		if d.md5 != nil {
+			d.md5.Write(p[:n])
+		}
+		if d.sha256 != nil {
+			d.sha256.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		if verr := d.verify(); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
+
+// verify 比较摘要，只在第一次读到结尾时计算
+func (d *digestReader) verify() error {
+	if d.verifyErr != nil || d.md5 == nil && d.sha256 == nil {
+		return d.verifyErr
+	}
+	if d.md5 != nil && !bytes.Equal(d.md5.Sum(nil), d.wantMD5) {
+		d.verifyErr = errBadDigest
+	} else if d.sha256 != nil && !bytes.Equal(d.sha256.Sum(nil), d.wantSHA) {
+		d.verifyErr = errContentSHA256Mismatch
+	}
+	d.md5, d.sha256 = nil, nil
+	return d.verifyErr
+}
+
+// verifyPayloadDigest 按 Content-MD5 和 x-amz-content-sha256 包装请求体，写入时校验摘要
+// x-amz-content-sha256 只在是 64 位十六进制哈希时校验（UNSIGNED-PAYLOAD 等不校验）
+// Content-MD5 不是 base64 编码的 16 字节时返回 400 InvalidDigest
+func verifyPayloadDigest(w http.ResponseWriter, r *http.Request, body io.Reader, resource string) (io.Reader, bool) {
+	d := &digestReader{r: body}
+	if header := r.Header.Get("Content-MD5"); header != "" {
+		sum, err := base64.StdEncoding.DecodeString(header)
+		if err != nil || len(sum) != md5.Size {
+			utils.WriteError(w, utils.ErrInvalidDigest, http.StatusBadRequest, resource)
+			return nil, false
+		}
+		d.md5, d.wantMD5 = md5.New(), sum
+	}
+	if header := r.Header.Get("X-Amz-Content-Sha256"); len(header) == 2*sha256.Size {
+		if sum, err := hex.DecodeString(header); err == nil {
+			d.sha256, d.wantSHA = sha256.New(), sum
+		}
+	}
+	if d.md5 == nil && d.sha256 == nil {
+		return body, true
+	}
+	return d, true
+}
+
+// decodeAWSChunked 请求体为 aws-chunked 流式分块格式时换成解码后的真实数据
+// Content-Length 换成 x-amz-decoded-content-length，大小限制按真实数据检查；保存的 Content-Encoding 去掉 aws-chunked
+// STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER 等不支持的格式返回 501 NotImplemented
+func decodeAWSChunked(w http.ResponseWriter, r *http.Request, resource string) bool {
+	if auth.IsUnsupportedStreamingPayload(r) {
+		utils.WriteError(w, utils.ErrUnsupportedStreaming, http.StatusNotImplemented, resource)
+		return false
+	}
+	if !auth.IsStreamingPayload(r) {
+		return true
+	}
+	decodedLength, err := strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+	if err != nil || decodedLength < 0 {
+		e := utils.ErrInvalidArgument
+		e.Message = "x-amz-decoded-content-length is required for aws-chunked uploads"
+		utils.WriteError(w, e, http.StatusBadRequest, resource)
+		return false
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{auth.NewChunkedReader(r, decodedLength), r.Body}
+	r.ContentLength = decodedLength
+
+	var encodings []string
+	for _, v := range strings.Split(r.Header.Get("Content-Encoding"), ",") {
+		if v = strings.TrimSpace(v); v != "" && !strings.EqualFold(v, "aws-chunked") {
+			encodings = append(encodings, v)
+		}
+	}
+	if len(encodings) > 0 {
+		r.Header.Set("Content-Encoding", strings.Join(encodings, ","))
+	} else {
+		r.Header.Del("Content-Encoding")
+	}
+	return true
+}
+
+// writeDigestError 摘要不一致时返回 400 BadDigest 或 XAmzContentSHA256Mismatch，
+// aws-chunked 分块签名不一致返回 403 SignatureDoesNotMatch，分块格式错误返回 400 IncompleteBody，其他错误返回 false 由调用方处理
+func writeDigestError(w http.ResponseWriter, err error, resource string) bool {
+	switch {
+	case errors.Is(err, errBadDigest):
+		utils.WriteError(w, utils.ErrBadDigest, http.StatusBadRequest, resource)
+	case errors.Is(err, errContentSHA256Mismatch):
+		utils.WriteError(w, utils.ErrContentSHA256Mismatch, http.StatusBadRequest, resource)
+	case errors.Is(err, auth.ErrChunkSignatureMismatch):
+		utils.WriteError(w, utils.ErrSignatureDoesNotMatch, http.StatusForbidden, resource)
+	case errors.Is(err, auth.ErrMalformedChunk):
+		utils.WriteError(w, utils.ErrIncompleteBody, http.StatusBadRequest, resource)
+	default:
+		return false
+	}
+	return true
+}
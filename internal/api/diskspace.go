@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"sss/internal/config"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// checkFreeSpace 写入前检查数据盘可用空间，写入后将低于最低可用空间时返回 507
+// 未知长度的上传只要求当前可用空间不低于阈值；无法查询可用空间时不拦截
+func (s *Server) checkFreeSpace(w http.ResponseWriter, r *http.Request, resource string) bool {
+	minFree := config.Global.Storage.MinFreeSpace
+	if minFree <= 0 {
+		return true
+	}
+	free, err := s.filestore.FreeSpace()
+	if err != nil {
+		return true
+	}
+	need := minFree
+	if r.ContentLength > 0 {
+		need += r.ContentLength
+	}
+	if free < need {
+		utils.Warn("reject upload on low disk space", "free", free, "need", need, "resource", resource)
+		utils.WriteError(w, utils.ErrInsufficientStorage, http.StatusInsufficientStorage, resource)
+		return false
+	}
+	return true
+}
+
+// writeNoSpaceError 写入因磁盘空间不足失败时返回 507，其他错误返回 false 由调用方处理
+func writeNoSpaceError(w http.ResponseWriter, err error, resource string) bool {
+	if !errors.Is(err, storage.ErrInsufficientStorage) {
+		return false
+	}
+	utils.Error("disk full while writing", "resource", resource, "error", err)
+	utils.WriteError(w, utils.ErrInsufficientStorage, http.StatusInsufficientStorage, resource)
+	return true
+}
+
+// checkBucketQuota 写入前按 Content-Length 预检桶配额，写入后将超出配额时返回 507
+// 未知长度的上传在写入元数据时（PutObjectIf 写锁内）再检查；查询失败时不拦截，由写入时的检查兜底
+func (s *Server) checkBucketQuota(w http.ResponseWriter, r *http.Request, b *storage.Bucket, key, resource string) bool {
+	if b == nil || b.QuotaBytes <= 0 || r.ContentLength <= 0 {
+		return true
+	}
+	err := s.metadata.CheckBucketQuota(b.Name, key, r.ContentLength)
+	return !writeQuotaError(w, err, resource)
+}
+
+// writeQuotaError 写入因超出桶配额被拒绝时返回 507，其他错误返回 false 由调用方处理
+func writeQuotaError(w http.ResponseWriter, err error, resource string) bool {
+	if !errors.Is(err, storage.ErrQuotaExceeded) {
+		return false
+	}
+	utils.Warn("reject write over bucket quota", "resource", resource)
+	utils.WriteError(w, utils.ErrQuotaExceeded, http.StatusInsufficientStorage, resource)
+	return true
+}
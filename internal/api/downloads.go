@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"sss/internal/auth"
+	"sss/internal/config"
+	"sss/internal/utils"
+)
+
+// isAnonymousRequest 公有桶请求是否为匿名访问（未携带有效签名）
+// 公有桶跳过认证，这里单独校验签名，携带有效签名的请求不受匿名流量上限限制
+func isAnonymousRequest(r *http.Request) bool {
+	if r.Header.Get("Authorization") == "" && r.URL.Query().Get("X-Amz-Signature") == "" {
+		return true
+	}
+	_, ok := auth.VerifyRequestAndGetAccessKey(r)
+	return !ok
+}
+
+// checkAnonymousBandwidth 检查当日匿名流量是否已超出上限，超出则返回 429 并附带距重置的秒数
+func (s *Server) checkAnonymousBandwidth(w http.ResponseWriter, r *http.Request) bool {
+	limit := config.Global.Storage.AnonymousDailyBytes
+	if limit <= 0 {
+		return true
+	}
+	now := time.Now()
+	used, err := s.metadata.AnonymousBytesOn(now)
+	if err != nil {
+		// 统计不可用时放行，避免误伤公开下载
+		utils.Warn("read anonymous bandwidth failed", "error", err)
+		return true
+	}
+	if used < limit {
+		return true
+	}
+
+	reset := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	w.Header().Set("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())+1))
+	utils.WriteError(w, utils.ErrAnonymousBandwidth, http.StatusTooManyRequests, r.URL.Path)
+	return false
+}
+
+// withAnonymousDownload 统计匿名请求的传出流量和对象下载次数，返回请求结束时调用的记录函数
+// 只有完整下载（200）计为一次下载，Range 请求只累计流量
+func (s *Server) withAnonymousDownload(w http.ResponseWriter, r *http.Request, bucket, key string) (http.ResponseWriter, func()) {
+	mw := &metricsResponseWriter{ResponseWriter: w}
+	return mw, func() {
+		counted := r.Method == http.MethodGet && key != "" && mw.status == http.StatusOK
+		if mw.bytesOut == 0 && !counted {
+			return
+		}
+		// 错误响应只计入流量，不产生对象统计
+		objectKey := key
+		if mw.status != http.StatusOK && mw.status != http.StatusPartialContent {
+			objectKey = ""
+		}
+		if err := s.metadata.RecordAnonymousDownload(bucket, objectKey, mw.bytesOut, counted, time.Now()); err != nil {
+			utils.Warn("record anonymous download failed", "bucket", bucket, "key", key, "error", err)
+		}
+	}
+}
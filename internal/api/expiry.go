@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"sss/internal/auth"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// errInvalidExpiresAt x-amz-expires-at 格式错误或不在未来
+var errInvalidExpiresAt = errors.New("invalid x-amz-expires-at")
+
+// parseExpiresAt 解析 x-amz-expires-at（RFC 3339 或 HTTP 日期），必须晚于当前时间
+// 请求头不存在时返回 nil
+func parseExpiresAt(r *http.Request, now time.Time) (*time.Time, error) {
+	v := r.Header.Get("x-amz-expires-at")
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = http.ParseTime(v); err != nil {
+			return nil, errInvalidExpiresAt
+		}
+	}
+	if !t.After(now) {
+		return nil, errInvalidExpiresAt
+	}
+	t = t.UTC().Truncate(time.Second)
+	return &t, nil
+}
+
+// StartBackgroundJobs 注册 S3 服务的后台任务：定期清理过期对象和过期临时凭证，关闭时停止生命周期过期扫描、完成待删除对象、排空复制队列并停止迁移和 ETag 重算任务
+func (s *Server) StartBackgroundJobs(jobs *storage.BackgroundJobs) {
+	jobs.Every("expiry-sweeper", storage.DefaultExpirySweepInterval, func(ctx context.Context) {
+		s.sweepExpiredObjects(time.Now())
+	})
+	jobs.Every("sts-sweeper", time.Minute, func(ctx context.Context) {
+		if n := auth.SweepTemporaryCredentials(time.Now()); n > 0 {
+			utils.Debug("swept expired temporary credentials", "count", n)
+		}
+	})
+	// 生命周期过期删除同样同步到复制目标，并在复制队列排空前停止
+	s.adminHandler.AttachLifecycle(storage.GetLifecycleService())
+	jobs.OnShutdown("lifecycle", func(ctx context.Context) error {
+		storage.GetLifecycleService().Stop()
+		return nil
+	})
+	// 管理界面的延迟删除须先于复制队列完成，其删除操作才会被复制
+	jobs.OnShutdown("pending-deletes", s.adminHandler.ShutdownPendingDeletes)
+	// 过期清理退出后再排空复制队列，清理产生的删除操作也会被复制
+	jobs.OnShutdown("replication", s.adminHandler.ShutdownReplication)
+	jobs.OnShutdown("notifications", s.adminHandler.ShutdownNotifications)
+	jobs.OnShutdown("migration", storage.GetMigrateManager(s.metadata, s.filestore).Shutdown)
+	jobs.OnShutdown("etag-rederive", s.adminHandler.ShutdownEtagRederive)
+}
+
+// sweepExpiredObjects 执行一轮过期对象和过期幂等键清理
+func (s *Server) sweepExpiredObjects(now time.Time) {
+	deleted, err := storage.SweepExpiredObjects(s.metadata, s.filestore, now)
+	for _, obj := range deleted {
+		s.adminHandler.Replicate(obj.Bucket, obj.Key, storage.ReplicationOpDelete)
+	}
+	if len(deleted) > 0 {
+		utils.Info("expired objects deleted", "count", len(deleted))
+	}
+	if err != nil {
+		utils.Error("sweep expired objects failed", "error", err)
+	}
+	// 顺带清理过期的 PUT 幂等键记录
+	if _, err := s.metadata.CleanExpiredPutIdempotency(now); err != nil {
+		utils.Error("clean idempotency records failed", "error", err)
+	}
+}
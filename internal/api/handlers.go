@@ -2,8 +2,11 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"sss/internal/admin"
@@ -27,6 +30,8 @@ type Server struct {
 	filestore    *storage.FileStore
 	adminHandler *admin.Handler
 	mux          *http.ServeMux
+	ready        atomic.Bool  // 启动完成且未进入关闭流程
+	httpMetrics  *httpMetrics // 请求数、耗时和流量统计，由 /metrics 输出
 }
 
 // NewServer 创建服务器
@@ -36,6 +41,7 @@ func NewServer(metadata *storage.MetadataStore, filestore *storage.FileStore) *S
 		filestore:    filestore,
 		adminHandler: admin.NewHandler(metadata, filestore),
 		mux:          http.NewServeMux(),
+		httpMetrics:  &httpMetrics{},
 	}
 	s.setupRoutes()
 	return s
@@ -43,41 +49,165 @@ func NewServer(metadata *storage.MetadataStore, filestore *storage.FileStore) *S
 
 // setupRoutes 设置路由
 func (s *Server) setupRoutes() {
+	// Web管理界面API端点（/api/presign、/api/bucket/ 等）也经由 handleRequest 分发，须先通过 S3 认证
 	s.mux.HandleFunc("/", s.handleRequest)
-	// Web管理界面API端点
-	s.mux.HandleFunc("/api/presign", s.handlePresign)
-	s.mux.HandleFunc("/api/bucket/", s.handleBucketAPI)
 }
 
 // ServeHTTP 实现 http.Handler
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// 统计请求数、耗时和上下行流量，由 /metrics 输出
+	rec := newRequestRecorder(w, r)
+	defer rec.finish(s.httpMetrics, r.Method, time.Now())
+	w = rec
+
 	// 添加通用头部
 	w.Header().Set("Server", "SSS")
-	w.Header().Set("x-amz-request-id", utils.GenerateRequestID())
+	requestID := utils.GenerateRequestID()
+	w.Header().Set("x-amz-request-id", requestID)
 
-	// CORS 支持（使用可配置的来源）
-	corsOrigin := "*"
-	if cfg := config.Global; cfg != nil && cfg.Security.CORSOrigin != "" {
-		corsOrigin = cfg.Security.CORSOrigin
+	// 客户端追踪 ID：回显到响应头，并写入访问日志和审计日志
+	var traceHeader, traceID string
+	if cfg := config.Global; cfg != nil {
+		traceHeader = cfg.Security.TraceHeader
+	}
+	if traceHeader != "" {
+		if traceID = utils.SanitizeTraceID(r.Header.Get(traceHeader)); traceID != "" {
+			w.Header().Set(traceHeader, traceID)
+		}
 	}
-	w.Header().Set("Access-Control-Allow-Origin", corsOrigin)
-	w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, POST, DELETE, HEAD, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
-	w.Header().Set("Access-Control-Expose-Headers", "ETag, x-amz-request-id, x-amz-id-2")
+	r = utils.WithRequestIDs(r, requestID, traceID)
+
+	// CORS 支持（使用可配置的来源）
+	setCORSHeaders(w, r)
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	utils.Info("request", "method", r.Method, "path", r.URL.Path, "query", r.URL.RawQuery)
+	utils.Info("request", "method", r.Method, "path", r.URL.Path, "query", r.URL.RawQuery,
+		"request_id", requestID, "trace_id", traceID)
 
 	// 记录 GeoStats（仅对 S3 API 请求，排除静态资源和管理 API）
 	s.recordGeoStats(r)
 
+	// 虚拟主机风格（<bucket>.<domain>/key）：整个路径都是对象键，不经过管理界面和静态文件路由
+	if r, ok := virtualHostRequest(r); ok {
+		s.handleS3Request(w, r)
+		return
+	}
+
+	// ServeMux 会把含 "//" 的路径 307 重定向到清理后的路径，对象键交由 handleRequest 按策略处理
+	if strings.Contains(r.URL.Path, "//") && !strings.HasPrefix(r.URL.Path, "/api/") {
+		s.handleRequest(w, r)
+		return
+	}
+
 	s.mux.ServeHTTP(w, r)
 }
 
+// virtualHostRequest 把虚拟主机风格请求改写为路径风格（/{bucket}{path}），签名仍按原始路径校验
+func virtualHostRequest(r *http.Request) (*http.Request, bool) {
+	cfg := config.Global
+	if cfg == nil {
+		return r, false
+	}
+	bucket, ok := cfg.Server.VirtualHostBucket(r.Host)
+	if !ok {
+		return r, false
+	}
+	signed := r.URL.Path
+	if signed == "" {
+		signed = "/"
+	}
+	r = auth.WithSignedPath(r, signed)
+	u := *r.URL
+	u.Path = "/" + bucket + signed
+	if u.RawPath != "" {
+		u.RawPath = "/" + bucket + u.RawPath
+	}
+	r.URL = &u
+	utils.Debug("virtual host request", "host", r.Host, "bucket", bucket, "path", u.Path)
+	return r, true
+}
+
+// setCORSHeaders 设置 CORS 响应头
+// 通配符来源直接返回 "*"；白名单模式下反射匹配的请求 Origin 并附加 Vary: Origin
+func setCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	var security config.SecurityConfig
+	if cfg := config.Global; cfg != nil {
+		security = cfg.Security
+	}
+
+	h := w.Header()
+	h.Set("Access-Control-Allow-Methods", "GET, PUT, POST, DELETE, HEAD, OPTIONS")
+	exposeHeaders := "ETag, x-amz-request-id, x-amz-id-2"
+	if security.TraceHeader != "" {
+		exposeHeaders += ", " + security.TraceHeader
+	}
+	h.Set("Access-Control-Expose-Headers", exposeHeaders)
+
+	if config.IsWildcardCORSOrigin(security.CORSOrigin) {
+		// 通配符与凭证互斥，不返回 Allow-Credentials
+		h.Set("Access-Control-Allow-Origin", "*")
+		h.Set("Access-Control-Allow-Headers", "*")
+		return
+	}
+
+	// 响应内容随 Origin 变化，避免缓存混用
+	h.Add("Vary", "Origin")
+
+	origins := security.CORSOrigins()
+	allowOrigin := ""
+	if origin := r.Header.Get("Origin"); origin != "" {
+		for _, o := range origins {
+			if o == origin {
+				allowOrigin = origin
+				break
+			}
+		}
+	} else if len(origins) == 1 {
+		// 非浏览器请求：保持单一来源的旧行为
+		allowOrigin = origins[0]
+	}
+	if allowOrigin == "" {
+		return
+	}
+
+	h.Set("Access-Control-Allow-Origin", allowOrigin)
+	if security.CORSAllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+		// 携带凭证时 "*" 不被视为通配符，需要反射请求头
+		if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", reqHeaders)
+			h.Add("Vary", "Access-Control-Request-Headers")
+			return
+		}
+	}
+	h.Set("Access-Control-Allow-Headers", "*")
+}
+
+// setSecurityHeaders 设置浏览器安全响应头（按配置逐项开关）
+func setSecurityHeaders(w http.ResponseWriter) {
+	cfg := config.Global
+	if cfg == nil {
+		return
+	}
+	h := w.Header()
+	if cfg.Security.HeaderNoSniff {
+		h.Set("X-Content-Type-Options", "nosniff")
+	}
+	if cfg.Security.ReferrerPolicy != "" {
+		h.Set("Referrer-Policy", cfg.Security.ReferrerPolicy)
+	}
+	if cfg.Security.FrameOptions != "" {
+		h.Set("X-Frame-Options", cfg.Security.FrameOptions)
+	}
+	if cfg.Security.ContentSecurityPolicy != "" {
+		h.Set("Content-Security-Policy", cfg.Security.ContentSecurityPolicy)
+	}
+}
+
 // recordGeoStats 记录地理位置统计
 func (s *Server) recordGeoStats(r *http.Request) {
 	// 检查是否应该记录这个请求
@@ -85,7 +215,7 @@ func (s *Server) recordGeoStats(r *http.Request) {
 
 	// 排除静态资源和管理 API
 	if strings.HasPrefix(path, "/assets/") ||
-		strings.HasPrefix(path, "/admin") ||
+		isAdminSPAPath(path) ||
 		strings.HasPrefix(path, "/api/admin/") ||
 		strings.HasPrefix(path, "/api/setup") ||
 		strings.HasPrefix(path, "/api/health") ||
@@ -142,6 +272,12 @@ func isRootStaticFile(path string) bool {
 
 // handleRequest 处理请求
 func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
+	// Prometheus 抓取端点，无需认证，需在设置中开启
+	if isPrometheusRequest(r, config.Global.Server.PrometheusEnabled) {
+		s.handlePrometheusMetrics(w, r)
+		return
+	}
+
 	// 1. 检查是否是静态文件请求
 	// 对于根路径，优先检查是否有 S3 签名头，有则处理为 API 请求
 	if r.URL.Path == "/" {
@@ -162,7 +298,15 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	} else if strings.HasPrefix(r.URL.Path, "/assets/") {
 		s.serveStatic(w, r)
 		return
-	} else if strings.HasPrefix(r.URL.Path, "/admin") {
+	} else if r.URL.Path == "/admin" && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		// 统一使用带斜杠的入口，保证相对路径解析一致
+		target := "/admin/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	} else if isAdminSPAPath(r.URL.Path) {
 		// 管理界面 SPA 路由，返回 index.html 让前端路由处理
 		s.serveStatic(w, r)
 		return
@@ -175,9 +319,16 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	// 2. 检查是否是API管理路径
 	if strings.HasPrefix(r.URL.Path, "/api/") {
 		// 健康检查端点 - 不需要认证
-		if r.URL.Path == "/api/health" {
+		switch r.URL.Path {
+		case "/api/health":
 			s.handleHealth(w, r)
 			return
+		case "/api/health/live":
+			s.handleLiveness(w, r)
+			return
+		case "/api/health/ready":
+			s.handleReadiness(w, r)
+			return
 		}
 		// 安装相关 API 和管理员 API - 委托给 adminHandler
 		if strings.HasPrefix(r.URL.Path, "/api/setup") || strings.HasPrefix(r.URL.Path, "/api/admin/") {
@@ -189,8 +340,16 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		if !ok {
 			return
 		}
-		r = newReq
+		r, _ = withKeyNamespace(newReq)
 		// 交给API处理器
+		if r.URL.Path == "/api/presign/batch" {
+			s.handlePresignBatch(w, r)
+			return
+		}
+		if r.URL.Path == "/api/presign/post" {
+			s.handlePresignPost(w, r)
+			return
+		}
 		if strings.HasPrefix(r.URL.Path, "/api/presign") {
 			s.handlePresign(w, r)
 			return
@@ -202,6 +361,11 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 3. S3 API 处理
+	s.handleS3Request(w, r)
+}
+
+// handleS3Request 处理 S3 API 请求，路径为 /{bucket}/{key}
+func (s *Server) handleS3Request(w http.ResponseWriter, r *http.Request) {
 	// 解析路径获取bucket
 	path := strings.TrimPrefix(r.URL.Path, "/")
 	parts := strings.SplitN(path, "/", 2)
@@ -209,16 +373,63 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	if len(parts) >= 1 && parts[0] != "" {
 		bucket = parts[0]
 	}
+	// 浏览器表单上传的凭证在表单字段中，读取表单后才能认证
+	if isPostObjectRequest(r, parts) {
+		s.handlePostObject(w, r, bucket)
+		return
+	}
+	// 带命名空间的 API Key 只能访问命名空间下的桶，路径中的桶名加上前缀后才是实际桶名
+	r, ns := withKeyNamespace(r)
+	if bucket != "" {
+		bucket = ns + bucket
+	}
+
+	// 按桶统计请求数、流量和错误率
+	if bucket != "" {
+		var record func()
+		w, record = withBucketMetrics(w, r, bucket)
+		defer record()
+	}
+
+	// 4. 桶允许的 HTTP 方法，先于认证检查，权限配置错误的密钥也无法绕过
+	var bucketInfo *storage.Bucket
+	if bucket != "" {
+		bucketInfo, _ = s.metadata.GetBucket(bucket)
+		if !bucketInfo.AllowsMethod(r.Method) {
+			w.Header().Set("Allow", bucketInfo.AllowedMethods)
+			utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "/"+bucket)
+			return
+		}
+	}
 
-	// 4. 认证检查
+	// 对象键在认证前解析，桶策略按实际存取的键匹配资源
+	key := ""
+	if len(parts) >= 2 {
+		key = parts[1]
+	}
+	// 按配置处理前导斜杠，保证写入的键与读取、列举的键一致（不合法的键在认证后返回错误）
+	keyValid := true
+	if key != "" {
+		key, keyValid = normalizeObjectKey(key)
+	}
+	// 桶配置的前缀改写（迁移期间旧路径映射到新路径），读写都使用改写后的键
+	if rewritten := bucketInfo.RewriteKey(key); keyValid && key != "" && rewritten != key {
+		utils.Debug("rewrite object key", "bucket", bucket, "key", key, "rewritten", rewritten)
+		key = rewritten
+	}
+
+	// 5. 认证检查
 	var isPublicAccess bool
 	if bucket != "" {
-		// 检查桶是否为公有（只对GET/HEAD请求）
+		// 检查桶是否为公有或桶策略允许匿名读取（只对GET/HEAD请求），桶策略的 Deny 优先于公开开关
 		if r.Method == http.MethodGet || r.Method == http.MethodHead {
-			if bucketInfo, err := s.metadata.GetBucket(bucket); err == nil && bucketInfo != nil && bucketInfo.IsPublic {
+			if bucketInfo.AllowsAnonymousRead(policyAction(r, key), key) {
 				// 公有桶的GET/HEAD请求跳过认证
 				utils.Debug("public bucket access", "bucket", bucket, "method", r.Method)
 				isPublicAccess = true
+				if config.Global.Security.HeadersOnPublic {
+					setSecurityHeaders(w)
+				}
 			}
 		}
 
@@ -231,8 +442,12 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 			r = newReq
 
 			// 检查桶权限（创建/删除桶只有旧配置的管理员 Key 能操作）
-			needWrite := r.Method != http.MethodGet && r.Method != http.MethodHead
-			if !s.checkBucketPermission(r, w, bucket, needWrite) {
+			needWrite := r.Method != http.MethodGet && r.Method != http.MethodHead && !isSelectRequest(r)
+			if !s.checkBucketPermission(r, w, bucketInfo, bucket, key, needWrite) {
+				return
+			}
+			// 写操作校验 x-amz-expected-bucket-owner，防止误操作其他环境
+			if needWrite && !checkExpectedBucketOwner(r, w) {
 				return
 			}
 		}
@@ -245,10 +460,26 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		r = newReq
 	}
 
-	// 重新解析路径（之前的bucket已经获取了）
-	key := ""
-	if len(parts) >= 2 {
-		key = parts[1]
+	if !keyValid {
+		utils.WriteError(w, utils.ErrLeadingSlashKey, http.StatusBadRequest, r.URL.Path)
+		return
+	}
+
+	// 公有桶匿名访问：检查每日流量上限，并统计流量和下载次数
+	if isPublicAccess && isAnonymousRequest(r) {
+		if !s.checkAnonymousBandwidth(w, r) {
+			return
+		}
+		var record func()
+		w, record = s.withAnonymousDownload(w, r, bucket, key)
+		defer record()
+	}
+
+	// 读操作审计（高频，按配置的百分比采样）
+	if action := readAuditAction(r, bucket, key); action != "" && sampleReadAudit() {
+		var record func()
+		w, record = s.withReadAudit(w, r, action, bucket, key)
+		defer record()
 	}
 
 	// 检查是否是多段上传相关操作
@@ -260,6 +491,34 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	case r.Method == "GET" && bucket == "":
 		s.handleListBuckets(w, r)
 
+	// GetBucketAcl/PutBucketAcl - GET/PUT /{bucket}?acl，对象 ACL 沿用所在桶
+	case query.Has("acl") && bucket != "":
+		s.handleACL(w, r, bucket, key)
+
+	// GetObjectTagging/PutObjectTagging/DeleteObjectTagging - /{bucket}/{key}?tagging
+	case query.Has("tagging") && bucket != "":
+		s.handleObjectTagging(w, r, bucket, key)
+
+	// GetBucketVersioning/PutBucketVersioning - GET/PUT /{bucket}?versioning
+	case query.Has("versioning") && bucket != "" && key == "":
+		s.handleBucketVersioning(w, r, bucket)
+
+	// GetBucketPolicy/PutBucketPolicy/DeleteBucketPolicy - /{bucket}?policy
+	case query.Has("policy") && bucket != "" && key == "":
+		s.handleBucketPolicy(w, r, bucket)
+
+	// GetBucketLifecycleConfiguration/PutBucketLifecycleConfiguration/DeleteBucketLifecycle - /{bucket}?lifecycle
+	case query.Has("lifecycle") && bucket != "" && key == "":
+		s.handleBucketLifecycle(w, r, bucket)
+
+	// GetBucketLocation - GET /{bucket}?location，与列举对象需要相同的读权限
+	case r.Method == "GET" && query.Has("location") && bucket != "" && key == "":
+		s.handleGetBucketLocation(w, r, bucket)
+
+	// DeleteObjects - POST /{bucket}?delete
+	case r.Method == "POST" && query.Has("delete") && bucket != "" && key == "":
+		s.handleDeleteObjects(w, r, bucket)
+
 	// CreateBucket - PUT /{bucket}
 	case r.Method == "PUT" && bucket != "" && key == "":
 		s.handleCreateBucket(w, r, bucket)
@@ -273,24 +532,33 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		s.handleHeadBucket(w, r, bucket)
 
 	// ListObjects - GET /{bucket}
+	// 匿名访问静态网站桶根目录时返回索引文档
 	case r.Method == "GET" && bucket != "" && key == "":
+		if isPublicAccess && r.URL.RawQuery == "" && s.isWebsiteBucket(bucket) {
+			s.handleGetObject(w, r, bucket, key)
+			return
+		}
 		s.handleListObjects(w, r, bucket)
 
+	// SelectObjectContent - POST /{bucket}/{key}?select&select-type=2
+	case isSelectRequest(r) && key != "":
+		s.handleSelectObjectContent(w, r, bucket, key)
+
 	// Multipart Upload 操作
 	case query.Has("uploads"):
 		if r.Method == "POST" && key != "" {
 			// InitiateMultipartUpload
 			s.handleInitiateMultipartUpload(w, r, bucket, key)
 		} else if r.Method == "GET" {
-			// ListMultipartUploads (暂未实现)
-			w.WriteHeader(http.StatusNotImplemented)
+			// ListMultipartUploads
+			s.handleListMultipartUploads(w, r, bucket)
 		}
 
 	case query.Get("uploadId") != "":
 		uploadID := query.Get("uploadId")
 		switch r.Method {
 		case "PUT":
-			// UploadPart
+			// UploadPart / UploadPartCopy
 			s.handleUploadPart(w, r, bucket, key, uploadID)
 		case "POST":
 			// CompleteMultipartUpload
@@ -346,6 +614,35 @@ type PresignResponse struct {
 	Expires int    `json:"expires"`
 }
 
+// maxPresignBatch 单次批量预签名最多的条目数
+const maxPresignBatch = 100
+
+// PresignBatchItem 批量预签名单项结果，失败时 Error/Message 非空且不含 URL
+type PresignBatchItem struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	*PresignResponse
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// presignError 预签名参数错误，s3 为 true 时按 S3 XML 格式返回
+type presignError struct {
+	status  int
+	code    string
+	message string
+	s3      bool
+}
+
+// writePresignError 写入预签名错误响应
+func writePresignError(w http.ResponseWriter, e *presignError) {
+	if e.s3 {
+		utils.WriteError(w, utils.S3Error{Code: e.code, Message: e.message}, e.status, "")
+		return
+	}
+	utils.WriteErrorResponse(w, e.code, e.message, e.status)
+}
+
 // handlePresign 处理预签名URL生成请求
 func (s *Server) handlePresign(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -362,38 +659,142 @@ func (s *Server) handlePresign(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.checkPresignLimit(w, r, 1) {
+		return
+	}
+
+	resp, perr := s.presign(r, req, nil)
+	if perr != nil {
+		writePresignError(w, perr)
+		return
+	}
+	utils.WriteJSONResponse(w, resp)
+}
+
+// handlePresignBatch 批量生成预签名URL
+// POST /api/presign/batch，请求体为 PresignRequest 数组，逐条校验调用者的桶权限和参数，单条失败不影响其他条目
+func (s *Server) handlePresignBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1024*1024) // 最大1MB
+
+	var reqs []PresignRequest
+	if err := utils.ParseJSONBody(r, &reqs); err != nil {
+		utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+		return
+	}
+	if len(reqs) == 0 {
+		utils.WriteErrorResponse(w, "MissingRequiredParameter", "at least one entry is required", http.StatusBadRequest)
+		return
+	}
+	if len(reqs) > maxPresignBatch {
+		utils.WriteErrorResponse(w, "TooManyEntries", fmt.Sprintf("at most %d entries per request", maxPresignBatch), http.StatusBadRequest)
+		return
+	}
+	// 批量请求按条目数占用额度，超出时整批拒绝
+	if !s.checkPresignLimit(w, r, len(reqs)) {
+		return
+	}
+
+	// 同一批次内的桶只查询一次
+	buckets := make(map[string]*storage.Bucket)
+	results := make([]PresignBatchItem, len(reqs))
+	for i, req := range reqs {
+		results[i] = PresignBatchItem{Bucket: req.Bucket, Key: req.Key}
+		resp, perr := s.presign(r, req, buckets)
+		if perr != nil {
+			results[i].Error = perr.code
+			results[i].Message = perr.message
+			continue
+		}
+		results[i].PresignResponse = resp
+	}
+	utils.WriteJSONResponse(w, results)
+}
+
+// checkPresignLimit 按 Access Key 占用 n 次预签名额度，超出当前窗口上限时返回 429 并附带距重置的秒数
+// 启用上限时，请求上下文中没有已认证的 Access Key 则返回 403
+func (s *Server) checkPresignLimit(w http.ResponseWriter, r *http.Request, n int) bool {
+	limit := config.Global.Security.PresignLimit
+	if limit <= 0 {
+		return true
+	}
+	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	if accessKeyID == "" {
+		// 无法计数的请求一律拒绝，否则未认证的请求可绕过上限
+		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, r.URL.Path)
+		return false
+	}
+	window := time.Duration(config.Global.Security.PresignWindowMinutes) * time.Minute
+	if window <= 0 {
+		window = time.Hour
+	}
+	now := time.Now()
+	usage, ok, err := s.metadata.ConsumePresignQuota(accessKeyID, n, limit, window, now)
+	if err != nil {
+		// 计数不可用时放行，避免影响正常签发
+		utils.Warn("consume presign quota failed", "access_key_id", accessKeyID, "error", err)
+		return true
+	}
+	if ok {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(usage.ResetAt.Sub(now).Seconds())+1))
+	utils.WriteError(w, utils.ErrPresignLimit, http.StatusTooManyRequests, r.URL.Path)
+	return false
+}
+
+// presign 校验单个预签名请求并生成 URL；buckets 非空时缓存桶查询结果
+func (s *Server) presign(r *http.Request, req PresignRequest, buckets map[string]*storage.Bucket) (*PresignResponse, *presignError) {
 	// 验证请求参数
 	if req.Bucket == "" || req.Key == "" {
-		utils.WriteErrorResponse(w, "MissingRequiredParameter", "bucket and key are required", http.StatusBadRequest)
-		return
+		return nil, &presignError{http.StatusBadRequest, "MissingRequiredParameter", "bucket and key are required", false}
 	}
 
 	// 验证bucket和key的安全性
 	if strings.Contains(req.Bucket, "..") || strings.ContainsAny(req.Bucket, "/\\") {
-		utils.WriteErrorResponse(w, "InvalidBucketName", "Invalid bucket name", http.StatusBadRequest)
-		return
+		return nil, &presignError{http.StatusBadRequest, "InvalidBucketName", "Invalid bucket name", false}
 	}
 	if strings.Contains(req.Key, "..") || strings.HasPrefix(req.Key, "/") {
-		utils.WriteErrorResponse(w, "InvalidKey", "Invalid object key", http.StatusBadRequest)
-		return
+		return nil, &presignError{http.StatusBadRequest, "InvalidKey", "Invalid object key", false}
 	}
 
 	// 检查存储桶是否存在
-	bucket, err := s.metadata.GetBucket(req.Bucket)
-	if err != nil {
-		utils.Error("check bucket failed", "error", err)
-		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
-		return
+	ns := requestNamespace(r)
+	bucket, cached := buckets[ns+req.Bucket]
+	if !cached {
+		var err error
+		bucket, err = s.metadata.GetBucket(ns + req.Bucket)
+		if err != nil {
+			utils.Error("check bucket failed", "error", err)
+			return nil, &presignError{http.StatusInternalServerError, utils.ErrInternalError.Code, utils.ErrInternalError.Message, true}
+		}
+		if buckets != nil {
+			buckets[ns+req.Bucket] = bucket
+		}
 	}
 	if bucket == nil {
-		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "")
-		return
+		return nil, &presignError{http.StatusNotFound, utils.ErrNoSuchBucket.Code, utils.ErrNoSuchBucket.Message, true}
 	}
 
 	// 设置默认值
 	if req.Method == "" {
 		req.Method = "PUT"
 	}
+	req.Method = strings.ToUpper(req.Method)
+
+	// 只能为自己有权限的桶签发，且用自身密钥签名，URL 使用时仍按签发者的权限校验
+	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	needWrite := req.Method != http.MethodGet && req.Method != http.MethodHead
+	if !auth.CheckBucketPermission(accessKeyID, ns+req.Bucket, needWrite) {
+		return nil, &presignError{http.StatusForbidden, utils.ErrAccessDenied.Code, utils.ErrAccessDenied.Message, true}
+	}
+	if !auth.CanPresign(accessKeyID) {
+		return nil, &presignError{http.StatusForbidden, utils.ErrAccessDenied.Code, "Presigned URLs cannot be issued with temporary credentials", true}
+	}
 	if req.ExpiresMinutes == 0 {
 		req.ExpiresMinutes = 60 // 默认1小时
 	}
@@ -416,17 +817,17 @@ func (s *Server) handlePresign(w http.ResponseWriter, r *http.Request) {
 		opts.ContentType = req.ContentType
 	}
 
+	// 用签发者自身的密钥签名；带命名空间时 URL 中保留不带前缀的桶名，访问时再映射到实际桶
+	opts.AccessKeyID = accessKeyID
+
 	// 生成预签名URL
 	url := auth.GeneratePresignedURLWithOptions(req.Method, req.Bucket, req.Key, opts)
 
-	// 构建响应
-	resp := PresignResponse{
+	return &PresignResponse{
 		URL:     url,
 		Method:  req.Method,
 		Expires: req.ExpiresMinutes * 60, // 转换为秒
-	}
-
-	utils.WriteJSONResponse(w, resp)
+	}, nil
 }
 
 // BucketPublicRequest 设置桶公有/私有请求
@@ -443,9 +844,20 @@ func (s *Server) handleBucketAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bucketName := pathParts[2]
+	bucketName := requestNamespace(r) + pathParts[2]
 	action := pathParts[3]
 
+	// 修改公有状态与设置桶 ACL 一样仅限桶所有者，其余操作需要读权限
+	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	allowed := auth.CheckBucketPermission(accessKeyID, bucketName, false)
+	if action == "public" && r.Method == http.MethodPut {
+		allowed = accessKeyID != "" && accessKeyID == bucketOwnerID()
+	}
+	if !allowed {
+		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, r.URL.Path)
+		return
+	}
+
 	switch action {
 	case "public":
 		s.handleBucketPublicAPI(w, r, bucketName)
@@ -511,7 +923,7 @@ func (s *Server) handleBucketPublicAPI(w http.ResponseWriter, r *http.Request, b
 }
 
 // handleBucketSearchAPI 处理对象模糊搜索 API
-// GET /api/bucket/{bucket}/search?q={keyword}
+// GET /api/bucket/{bucket}/search?q={keyword}&sort=key|size|modified&order=asc|desc
 func (s *Server) handleBucketSearchAPI(w http.ResponseWriter, r *http.Request, bucketName string) {
 	if r.Method != http.MethodGet {
 		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
@@ -524,6 +936,11 @@ func (s *Server) handleBucketSearchAPI(w http.ResponseWriter, r *http.Request, b
 		utils.WriteErrorResponse(w, "MissingParameter", "Missing 'q' parameter", http.StatusBadRequest)
 		return
 	}
+	sort, err := storage.ParseObjectSort(r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
+	if err != nil {
+		utils.WriteErrorResponse(w, "InvalidParameter", err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// 检查桶是否存在
 	bucket, err := s.metadata.GetBucket(bucketName)
@@ -538,7 +955,7 @@ func (s *Server) handleBucketSearchAPI(w http.ResponseWriter, r *http.Request, b
 	}
 
 	// 执行搜索
-	objects, err := s.metadata.SearchObjects(bucketName, keyword, 100)
+	objects, err := s.metadata.SearchObjects(bucketName, keyword, 100, sort)
 	if err != nil {
 		utils.Error("search objects failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
@@ -566,6 +983,8 @@ func (s *Server) handleBucketSearchAPI(w http.ResponseWriter, r *http.Request, b
 		"keyword": keyword,
 		"count":   len(results),
 		"objects": results,
+		"sort":    sort.Field,
+		"order":   sort.Order(),
 	})
 }
 
@@ -634,8 +1053,18 @@ func (s *Server) checkAuth(r *http.Request, w http.ResponseWriter) (*http.Reques
 	// 验证认证信息并获取 Access Key ID
 	accessKeyID, ok := auth.VerifyRequestAndGetAccessKey(r)
 	if !ok {
-		if hasAuthHeader {
+		if auth.SessionTokenExpired(r) {
+			// 临时凭证已过期，与签名错误区分开
+			utils.WriteError(w, utils.ErrExpiredToken, http.StatusForbidden, r.URL.Path)
+		} else if hasAuthHeader {
 			utils.WriteError(w, utils.ErrSignatureDoesNotMatch, http.StatusForbidden, r.URL.Path)
+		} else if expiresAt, expired := auth.PresignedURLExpired(r); expired {
+			// 预签名 URL 已过期，与签名错误区分开
+			e := utils.ErrRequestExpired
+			e.XAmzExpires = r.URL.Query().Get("X-Amz-Expires")
+			e.Expires = expiresAt.UTC().Format(time.RFC3339)
+			e.ServerTime = time.Now().UTC().Format(time.RFC3339)
+			utils.WriteError(w, e, http.StatusForbidden, r.URL.Path)
 		} else {
 			utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, r.URL.Path)
 		}
@@ -648,13 +1077,29 @@ func (s *Server) checkAuth(r *http.Request, w http.ResponseWriter) (*http.Reques
 }
 
 // checkBucketPermission 检查桶访问权限
-func (s *Server) checkBucketPermission(r *http.Request, w http.ResponseWriter, bucket string, needWrite bool) bool {
+// 桶策略先于 API Key 权限判定：命中 Deny 拒绝，命中 Allow 放行，都未命中时按 API Key 权限；管理员 Key 不受桶策略限制
+func (s *Server) checkBucketPermission(r *http.Request, w http.ResponseWriter, b *storage.Bucket, bucket, key string, needWrite bool) bool {
+	return s.checkBucketAction(r, w, b, bucket, key, policyAction(r, key), needWrite)
+}
+
+// checkBucketAction 按指定的桶策略操作检查桶访问权限，用于与请求本身不同的访问（如复制源对象的读取）
+func (s *Server) checkBucketAction(r *http.Request, w http.ResponseWriter, b *storage.Bucket, bucket, key, action string, needWrite bool) bool {
 	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
 	if accessKeyID == "" {
 		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, r.URL.Path)
 		return false
 	}
 
+	if accessKeyID != bucketOwnerID() {
+		switch b.PolicyDecision(accessKeyID, action, key) {
+		case storage.PolicyDeny:
+			utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, r.URL.Path)
+			return false
+		case storage.PolicyAllow:
+			return true
+		}
+	}
+
 	if !auth.CheckBucketPermission(accessKeyID, bucket, needWrite) {
 		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, r.URL.Path)
 		return false
@@ -662,10 +1107,20 @@ func (s *Server) checkBucketPermission(r *http.Request, w http.ResponseWriter, b
 	return true
 }
 
-// handleHealth 健康检查端点 - 不需要认证
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	utils.WriteJSONResponse(w, map[string]interface{}{
-		"status":  "ok",
-		"version": "1.1.0",
-	})
+// bucketOwnerID 桶所有者 ID（单账户模式，与 ListBuckets 返回的 Owner.ID 一致）
+func bucketOwnerID() string {
+	return config.Global.Auth.AccessKeyID
+}
+
+// checkExpectedBucketOwner 校验 x-amz-expected-bucket-owner / x-amz-source-expected-bucket-owner
+// 请求头不存在时忽略，不匹配时返回 403
+func checkExpectedBucketOwner(r *http.Request, w http.ResponseWriter) bool {
+	owner := bucketOwnerID()
+	for _, header := range []string{"x-amz-expected-bucket-owner", "x-amz-source-expected-bucket-owner"} {
+		if expected := r.Header.Get(header); expected != "" && expected != owner {
+			utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, r.URL.Path)
+			return false
+		}
+	}
+	return true
 }
@@ -2,12 +2,18 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"sss/internal/auth"
 	"sss/internal/config"
 	"sss/internal/storage"
 	"sss/internal/utils"
@@ -138,6 +144,126 @@ func TestServeHTTP(t *testing.T) {
 	})
 }
 
+// TestTraceIDPassthrough 测试客户端追踪 ID 透传
+func TestTraceIDPassthrough(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	original := config.Global.Security
+	defer func() { config.Global.Security = original }()
+	config.Global.Security.TraceHeader = "X-Request-Id"
+
+	t.Run("回显追踪ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/no-such-bucket", nil)
+		req.Header.Set("X-Request-Id", "trace-123\n")
+		rec := httptest.NewRecorder()
+
+		server.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("X-Request-Id"); got != "trace-123" {
+			t.Errorf("追踪ID回显错误: %q", got)
+		}
+		requestID := rec.Header().Get("x-amz-request-id")
+		if requestID == "" || requestID == "trace-123" {
+			t.Errorf("x-amz-request-id 应由服务端生成: %q", requestID)
+		}
+		if !strings.Contains(rec.Body.String(), "<RequestId>"+requestID+"</RequestId>") {
+			t.Errorf("错误响应中的 RequestId 应与响应头一致: %s", rec.Body.String())
+		}
+		if !strings.Contains(rec.Header().Get("Access-Control-Expose-Headers"), "X-Request-Id") {
+			t.Errorf("追踪头应暴露给浏览器: %q", rec.Header().Get("Access-Control-Expose-Headers"))
+		}
+	})
+
+	t.Run("未携带时不回显", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		if got := rec.Header().Get("X-Request-Id"); got != "" {
+			t.Errorf("不应回显追踪头: %q", got)
+		}
+	})
+
+	t.Run("关闭后忽略", func(t *testing.T) {
+		config.Global.Security.TraceHeader = ""
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("X-Request-Id", "trace-123")
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		if got := rec.Header().Get("X-Request-Id"); got != "" {
+			t.Errorf("关闭后不应回显: %q", got)
+		}
+	})
+}
+
+// TestCORSAllowlist 测试CORS白名单与凭证
+func TestCORSAllowlist(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	original := config.Global.Security
+	defer func() { config.Global.Security = original }()
+
+	config.Global.Security.CORSOrigin = "https://a.example.com,https://b.example.com"
+	config.Global.Security.CORSAllowCredentials = true
+
+	t.Run("匹配的Origin被反射", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://b.example.com")
+		req.Header.Set("Access-Control-Request-Headers", "authorization,content-type")
+		rec := httptest.NewRecorder()
+
+		server.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://b.example.com" {
+			t.Errorf("Allow-Origin错误: %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("Allow-Credentials错误: %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "authorization,content-type" {
+			t.Errorf("Allow-Headers错误: %q", got)
+		}
+		if got := rec.Header().Values("Vary"); len(got) == 0 || got[0] != "Origin" {
+			t.Errorf("Vary错误: %v", got)
+		}
+	})
+
+	t.Run("不匹配的Origin不返回CORS来源", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		rec := httptest.NewRecorder()
+
+		server.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("不应返回Allow-Origin: %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("不应返回Allow-Credentials: %q", got)
+		}
+		if got := rec.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("Vary错误: %q", got)
+		}
+	})
+
+	t.Run("通配符来源忽略凭证", func(t *testing.T) {
+		config.Global.Security.CORSOrigin = "*"
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://a.example.com")
+		rec := httptest.NewRecorder()
+
+		server.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("Allow-Origin错误: %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("通配符不应返回Allow-Credentials: %q", got)
+		}
+	})
+}
+
 // TestIsRootStaticFile 测试isRootStaticFile函数
 func TestIsRootStaticFile(t *testing.T) {
 	testCases := []struct {
@@ -205,6 +331,162 @@ func TestHandleHealth(t *testing.T) {
 	})
 }
 
+// TestHealthProbes 测试存活与就绪探针
+func TestHealthProbes(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	probe := func(path string) (int, map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s 解析响应失败: %v", path, err)
+		}
+		return rec.Code, body
+	}
+
+	t.Run("启动完成前未就绪", func(t *testing.T) {
+		if code, _ := probe("/api/health/live"); code != http.StatusOK {
+			t.Errorf("存活探针应返回200: %d", code)
+		}
+		code, body := probe("/api/health/ready")
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("启动中就绪探针应返回503: %d", code)
+		}
+		if checks, _ := body["checks"].(map[string]interface{}); checks["startup"] == "ok" {
+			t.Errorf("startup 检查应失败: %v", body)
+		}
+		if code, body := probe("/api/health"); code != http.StatusOK || body["ready"] != false {
+			t.Errorf("兼容端点应返回200且 ready=false: %d %v", code, body)
+		}
+	})
+
+	server.SetReady(true)
+
+	t.Run("就绪", func(t *testing.T) {
+		if code, body := probe("/api/health/ready"); code != http.StatusOK {
+			t.Errorf("就绪探针应返回200: %d %v", code, body)
+		}
+		if _, body := probe("/api/health"); body["ready"] != true {
+			t.Errorf("兼容端点 ready 应为 true: %v", body)
+		}
+	})
+
+	t.Run("维护模式", func(t *testing.T) {
+		config.Global.Server.Maintenance = true
+		defer func() { config.Global.Server.Maintenance = false }()
+
+		code, body := probe("/api/health/ready")
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("维护模式应返回503: %d", code)
+		}
+		if checks, _ := body["checks"].(map[string]interface{}); checks["maintenance"] != "enabled" {
+			t.Errorf("maintenance 检查结果错误: %v", body)
+		}
+		if code, _ := probe("/api/health/live"); code != http.StatusOK {
+			t.Errorf("维护模式下存活探针仍应返回200: %d", code)
+		}
+	})
+
+	t.Run("磁盘空间不足", func(t *testing.T) {
+		config.Global.Storage.MinFreeSpace = 1 << 62
+		defer func() { config.Global.Storage.MinFreeSpace = 0 }()
+
+		code, body := probe("/api/health/ready")
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("可用空间低于下限应返回503: %d", code)
+		}
+		if checks, _ := body["checks"].(map[string]interface{}); checks["disk_space"] == "ok" {
+			t.Errorf("disk_space 检查应失败: %v", body)
+		}
+
+		req := httptest.NewRequest(http.MethodPut, "/bucket/key", strings.NewReader("data"))
+		rec := httptest.NewRecorder()
+		if server.checkFreeSpace(rec, req, "/bucket/key") {
+			t.Fatal("可用空间不足时应拒绝写入")
+		}
+		if rec.Code != http.StatusInsufficientStorage || !strings.Contains(rec.Body.String(), "InsufficientStorage") {
+			t.Errorf("应返回507 InsufficientStorage: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("数据库不可用", func(t *testing.T) {
+		server.metadata.Close()
+		if code, _ := probe("/api/health/ready"); code != http.StatusServiceUnavailable {
+			t.Errorf("数据库关闭后应返回503: %d", code)
+		}
+	})
+}
+
+// TestBucketAllowedMethods 测试桶允许的 HTTP 方法先于认证生效
+func TestBucketAllowedMethods(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	server.metadata.CreateBucket("readonly")
+	server.metadata.UpdateBucketPublic("readonly", true)
+	if err := server.metadata.UpdateBucketAllowedMethods("readonly", "GET,HEAD"); err != nil {
+		t.Fatalf("设置允许方法失败: %v", err)
+	}
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete, http.MethodPost} {
+		req := httptest.NewRequest(method, "/readonly/file.txt", strings.NewReader("data"))
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s 应在认证前返回405: %d %s", method, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Allow"); got != "GET,HEAD" {
+			t.Errorf("%s Allow 头错误: %q", method, got)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/readonly?list-type=2", nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("允许的方法不应受影响: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestBucketPrefixRewrites 测试桶对象键前缀改写
+func TestBucketPrefixRewrites(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "site", "legacy/a.txt", []byte("legacy content"))
+	server.metadata.UpdateBucketPublic("site", true)
+	if err := server.metadata.UpdateBucketPrefixRewrites("site", []storage.PrefixRewrite{{From: "v1/", To: "legacy/"}}); err != nil {
+		t.Fatalf("设置前缀改写失败: %v", err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("/site/v1/a.txt"); rec.Code != http.StatusOK || rec.Body.String() != "legacy content" {
+		t.Errorf("旧路径应读取到改写后的对象: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := get("/site/legacy/a.txt"); rec.Code != http.StatusOK {
+		t.Errorf("新路径应仍可直接访问: %d", rec.Code)
+	}
+
+	// 复制源同样按源桶规则改写
+	req := httptest.NewRequest(http.MethodPut, "/site/copy.txt", nil)
+	req.Header.Set("x-amz-copy-source", "/site/v1/a.txt")
+	rec := httptest.NewRecorder()
+	server.handleCopyObject(rec, req, "site", "copy.txt")
+	if rec.Code != http.StatusOK {
+		t.Errorf("复制旧路径对象失败: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
 // TestHandlePresign 测试预签名URL生成
 func TestHandlePresign(t *testing.T) {
 	server, cleanup := setupHandlersTestServer(t)
@@ -220,6 +502,7 @@ func TestHandlePresign(t *testing.T) {
 
 		for _, method := range methods {
 			req := httptest.NewRequest(method, "/api/presign", nil)
+			req = asAdmin(t, req)
 			rec := httptest.NewRecorder()
 
 			server.handlePresign(rec, req)
@@ -244,6 +527,7 @@ func TestHandlePresign(t *testing.T) {
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
 				req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader(tc.body))
+				req = asAdmin(t, req)
 				req.Header.Set("Content-Type", "application/json")
 				rec := httptest.NewRecorder()
 
@@ -265,6 +549,7 @@ func TestHandlePresign(t *testing.T) {
 
 		for _, body := range testCases {
 			req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader(body))
+			req = asAdmin(t, req)
 			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
 
@@ -284,6 +569,7 @@ func TestHandlePresign(t *testing.T) {
 
 		for _, body := range testCases {
 			req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader(body))
+			req = asAdmin(t, req)
 			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
 
@@ -298,6 +584,7 @@ func TestHandlePresign(t *testing.T) {
 	t.Run("桶不存在", func(t *testing.T) {
 		body := `{"bucket": "nonexistent-bucket", "key": "test.txt"}`
 		req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader(body))
+		req = asAdmin(t, req)
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -311,6 +598,7 @@ func TestHandlePresign(t *testing.T) {
 	t.Run("成功生成预签名URL", func(t *testing.T) {
 		body := `{"bucket": "presign-bucket", "key": "test.txt", "method": "PUT", "expiresMinutes": 30}`
 		req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader(body))
+		req = asAdmin(t, req)
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -339,6 +627,7 @@ func TestHandlePresign(t *testing.T) {
 	t.Run("默认值处理", func(t *testing.T) {
 		body := `{"bucket": "presign-bucket", "key": "test.txt"}`
 		req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader(body))
+		req = asAdmin(t, req)
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -367,6 +656,7 @@ func TestHandlePresign(t *testing.T) {
 		// 请求超过7天的过期时间
 		body := `{"bucket": "presign-bucket", "key": "test.txt", "expiresMinutes": 20160}`
 		req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader(body))
+		req = asAdmin(t, req)
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -390,6 +680,7 @@ func TestHandlePresign(t *testing.T) {
 
 	t.Run("JSON解析错误", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader("invalid json"))
+		req = asAdmin(t, req)
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -420,6 +711,7 @@ func TestHandleBucketAPI(t *testing.T) {
 
 		for _, path := range invalidPaths {
 			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req = asAdmin(t, req)
 			rec := httptest.NewRecorder()
 
 			server.handleBucketAPI(rec, req)
@@ -432,6 +724,7 @@ func TestHandleBucketAPI(t *testing.T) {
 
 	t.Run("无效的action", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/bucket/api-test-bucket/invalid-action", nil)
+		req = asAdmin(t, req)
 		rec := httptest.NewRecorder()
 
 		server.handleBucketAPI(rec, req)
@@ -771,6 +1064,152 @@ func TestHandleBucketHeadObjectAPI(t *testing.T) {
 	})
 }
 
+// TestAdminSPARouting 测试管理界面 SPA 深链接与静态资源路由
+func TestAdminSPARouting(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	originalFS := staticFS
+	defer func() { staticFS = originalFS }()
+	staticFS = fstest.MapFS{
+		"index.html":    {Data: []byte("<html>spa</html>")},
+		"favicon.svg":   {Data: []byte("<svg/>")},
+		"assets/app.js": {Data: []byte("console.log(1)")},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"admin深链接返回index", "/admin/buckets", http.StatusOK, "spa"},
+		{"admin多级深链接返回index", "/admin/bucket/test/objects", http.StatusOK, "spa"},
+		{"admin下未知文件返回index", "/admin/unknown.png", http.StatusOK, "spa"},
+		{"真实资源直接返回", "/assets/app.js", http.StatusOK, "console.log(1)"},
+		{"缺失资源返回404", "/assets/missing.js", http.StatusNotFound, ""},
+		{"根目录静态文件", "/favicon.svg", http.StatusOK, "<svg/>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			server.handleRequest(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("状态码错误: 期望 %d, 实际 %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("响应内容错误: %q", rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("无斜杠入口重定向", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin?lang=en", nil)
+		rec := httptest.NewRecorder()
+
+		server.handleRequest(rec, req)
+
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusMovedPermanently, rec.Code)
+		}
+		if loc := rec.Header().Get("Location"); loc != "/admin/?lang=en" {
+			t.Errorf("Location错误: %q", loc)
+		}
+	})
+
+	t.Run("admin前缀的桶名不走SPA", func(t *testing.T) {
+		if isAdminSPAPath("/admin-data/file.txt") {
+			t.Error("/admin-data 不应被识别为管理界面路径")
+		}
+		req := httptest.NewRequest(http.MethodGet, "/admin-data/file.txt", nil)
+		rec := httptest.NewRecorder()
+
+		server.handleRequest(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+// TestSecurityHeaders 测试浏览器安全响应头
+func TestSecurityHeaders(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	original := config.Global.Security
+	originalFS := staticFS
+	defer func() {
+		config.Global.Security = original
+		staticFS = originalFS
+	}()
+	staticFS = fstest.MapFS{"index.html": {Data: []byte("<html></html>")}}
+
+	server.metadata.CreateBucket("headers-bucket")
+	server.metadata.UpdateBucketPublic("headers-bucket", true)
+	storagePath, etag, _ := server.filestore.PutObject("headers-bucket", "a.txt", strings.NewReader("a"), 1)
+	server.metadata.PutObject(&storage.Object{Bucket: "headers-bucket", Key: "a.txt", Size: 1, ETag: etag, StoragePath: storagePath})
+
+	defaults := func() {
+		config.Global.Security.HeaderNoSniff = true
+		config.Global.Security.ReferrerPolicy = "strict-origin-when-cross-origin"
+		config.Global.Security.FrameOptions = "DENY"
+		config.Global.Security.ContentSecurityPolicy = ""
+		config.Global.Security.HeadersOnPublic = false
+	}
+	get := func(path string) http.Header {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		server.handleRequest(rec, req)
+		return rec.Header()
+	}
+
+	t.Run("管理界面默认安全头", func(t *testing.T) {
+		defaults()
+		h := get("/admin/")
+		if h.Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("X-Content-Type-Options错误: %q", h.Get("X-Content-Type-Options"))
+		}
+		if h.Get("Referrer-Policy") != "strict-origin-when-cross-origin" {
+			t.Errorf("Referrer-Policy错误: %q", h.Get("Referrer-Policy"))
+		}
+		if h.Get("X-Frame-Options") != "DENY" {
+			t.Errorf("X-Frame-Options错误: %q", h.Get("X-Frame-Options"))
+		}
+		if h.Get("Content-Security-Policy") != "" {
+			t.Error("默认不应发送CSP")
+		}
+	})
+
+	t.Run("可配置CSP与关闭单项", func(t *testing.T) {
+		config.Global.Security.ContentSecurityPolicy = "default-src 'self'"
+		config.Global.Security.FrameOptions = ""
+		config.Global.Security.HeaderNoSniff = false
+		h := get("/admin/")
+		if h.Get("Content-Security-Policy") != "default-src 'self'" {
+			t.Errorf("CSP错误: %q", h.Get("Content-Security-Policy"))
+		}
+		if h.Get("X-Frame-Options") != "" || h.Get("X-Content-Type-Options") != "" {
+			t.Error("关闭的安全头不应发送")
+		}
+	})
+
+	t.Run("公有桶对象按开关附加", func(t *testing.T) {
+		defaults()
+		if h := get("/headers-bucket/a.txt"); h.Get("X-Content-Type-Options") != "" {
+			t.Error("默认不应对公有对象附加安全头")
+		}
+		config.Global.Security.HeadersOnPublic = true
+		if h := get("/headers-bucket/a.txt"); h.Get("X-Content-Type-Options") != "nosniff" {
+			t.Error("开启后应对公有对象附加安全头")
+		}
+	})
+}
+
 // TestHandleRequest_Routing 测试请求路由分发
 func TestHandleRequest_Routing(t *testing.T) {
 	server, cleanup := setupHandlersTestServer(t)
@@ -907,6 +1346,31 @@ func TestCheckAuth(t *testing.T) {
 		if rec.Code != http.StatusForbidden {
 			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusForbidden, rec.Code)
 		}
+		if strings.Contains(rec.Body.String(), "Request has expired") {
+			t.Error("非过期的预签名错误不应返回过期信息")
+		}
+	})
+
+	t.Run("过期的预签名URL返回过期信息", func(t *testing.T) {
+		past := time.Now().Add(-2 * time.Hour).UTC().Format("20060102T150405Z")
+		req := httptest.NewRequest(http.MethodGet, "/test-bucket/key?X-Amz-Signature=abc&X-Amz-Date="+past+"&X-Amz-Expires=3600", nil)
+		rec := httptest.NewRecorder()
+
+		_, ok := server.checkAuth(req, rec)
+
+		if ok {
+			t.Error("过期预签名应该返回失败")
+		}
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("状态码错误: 期望 %d, 实际 %d", http.StatusForbidden, rec.Code)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "<Code>AccessDenied</Code>") || !strings.Contains(body, "Request has expired") {
+			t.Errorf("响应应包含过期信息: %s", body)
+		}
+		if !strings.Contains(body, "<X-Amz-Expires>3600</X-Amz-Expires>") || !strings.Contains(body, "<Expires>") || !strings.Contains(body, "<ServerTime>") {
+			t.Errorf("响应应包含过期时间: %s", body)
+		}
 	})
 }
 
@@ -1173,7 +1637,7 @@ func TestCheckBucketPermission(t *testing.T) {
 		// 不设置上下文中的accessKeyID
 		rec := httptest.NewRecorder()
 
-		result := server.checkBucketPermission(req, rec, "test-bucket", false)
+		result := server.checkBucketPermission(req, rec, nil, "test-bucket", "", false)
 
 		if result {
 			t.Error("无accessKeyID应该返回false")
@@ -1217,6 +1681,142 @@ func TestHandleDeleteBucket_NonEmpty(t *testing.T) {
 	})
 }
 
+// TestHandlePresignBatch 测试批量预签名
+func TestHandlePresignBatch(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	if err := server.metadata.CreateBucket("presign-bucket"); err != nil {
+		t.Fatalf("创建测试桶失败: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/presign/batch", strings.NewReader(body))
+		req = asAdmin(t, req)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		server.handlePresignBatch(rec, req)
+		return rec
+	}
+
+	t.Run("逐条校验", func(t *testing.T) {
+		rec := post(`[
+			{"bucket": "presign-bucket", "key": "a.txt", "method": "GET", "expiresMinutes": 10},
+			{"bucket": "presign-bucket", "key": "../etc/passwd", "method": "GET"},
+			{"bucket": "missing-bucket", "key": "b.txt", "method": "GET"},
+			{"bucket": "presign-bucket", "key": "b.txt"}
+		]`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: 期望 %d, 实际 %d, body: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var results []PresignBatchItem
+		if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if len(results) != 4 {
+			t.Fatalf("结果数量错误: %d", len(results))
+		}
+		if results[0].PresignResponse == nil || results[0].URL == "" || results[0].Method != "GET" || results[0].Expires != 600 {
+			t.Errorf("第一条应成功: %+v", results[0])
+		}
+		if results[1].Error != "InvalidKey" || results[1].PresignResponse != nil {
+			t.Errorf("路径穿越应单独报错: %+v", results[1])
+		}
+		if results[2].Error != "NoSuchBucket" || results[2].Key != "b.txt" {
+			t.Errorf("桶不存在应单独报错: %+v", results[2])
+		}
+		if results[3].Error != "" || results[3].Method != "PUT" {
+			t.Errorf("第四条应成功并使用默认方法: %+v", results[3])
+		}
+	})
+
+	t.Run("按Key权限逐条校验", func(t *testing.T) {
+		if err := server.metadata.CreateBucket("private-bucket"); err != nil {
+			t.Fatalf("创建测试桶失败: %v", err)
+		}
+		auth.InitAPIKeyCache(server.metadata)
+		key, err := server.metadata.CreateAPIKey("batch read-only")
+		if err != nil {
+			t.Fatalf("创建 API Key 失败: %v", err)
+		}
+		server.metadata.SetAPIKeyPermission(&storage.APIKeyPermission{AccessKeyID: key.AccessKeyID, BucketName: "presign-bucket", CanRead: true})
+		auth.ReloadAPIKeyCache()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/presign/batch", strings.NewReader(`[
+			{"bucket": "presign-bucket", "key": "a.txt", "method": "GET"},
+			{"bucket": "presign-bucket", "key": "a.txt", "method": "PUT"},
+			{"bucket": "private-bucket", "key": "a.txt", "method": "GET"}
+		]`))
+		req = req.WithContext(context.WithValue(req.Context(), ContextKeyAccessKeyID, key.AccessKeyID))
+		rec := httptest.NewRecorder()
+		server.handlePresignBatch(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: 期望 %d, 实际 %d, body: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var results []PresignBatchItem
+		if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("结果数量错误: %d", len(results))
+		}
+		if results[0].PresignResponse == nil || !strings.Contains(results[0].URL, "X-Amz-Credential="+key.AccessKeyID) {
+			t.Errorf("有读权限的条目应成功并用调用者的 Key 签名: %+v", results[0])
+		}
+		if results[1].Error != "AccessDenied" || results[1].PresignResponse != nil {
+			t.Errorf("只读 Key 签发 PUT 应单独拒绝: %+v", results[1])
+		}
+		if results[2].Error != "AccessDenied" || results[2].PresignResponse != nil {
+			t.Errorf("无权限的桶应单独拒绝: %+v", results[2])
+		}
+	})
+
+	t.Run("数量限制", func(t *testing.T) {
+		entries := make([]string, maxPresignBatch+1)
+		for i := range entries {
+			entries[i] = `{"bucket": "presign-bucket", "key": "k"}`
+		}
+		if rec := post("[" + strings.Join(entries, ",") + "]"); rec.Code != http.StatusBadRequest {
+			t.Errorf("超出上限应返回 400: %d", rec.Code)
+		}
+		if rec := post(`[]`); rec.Code != http.StatusBadRequest {
+			t.Errorf("空列表应返回 400: %d", rec.Code)
+		}
+	})
+}
+
+// TestPresignRequiresAuth 测试预签名和桶 API 端点未认证时被拒绝，不会用管理员密钥签发
+func TestPresignRequiresAuth(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	if err := server.metadata.CreateBucket("presign-bucket"); err != nil {
+		t.Fatalf("创建测试桶失败: %v", err)
+	}
+
+	cases := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/api/presign/batch", `[{"bucket": "presign-bucket", "key": "a.txt", "method": "GET"}]`},
+		{http.MethodPost, "/api/presign", `{"bucket": "presign-bucket", "key": "a.txt", "method": "GET"}`},
+		{http.MethodGet, "/api/bucket/presign-bucket/search?q=a", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized && rec.Code != http.StatusForbidden {
+				t.Errorf("未认证请求应返回 401/403: %d, body: %s", rec.Code, rec.Body.String())
+			}
+			if strings.Contains(rec.Body.String(), "X-Amz-Signature") {
+				t.Error("未认证请求不应拿到签名 URL")
+			}
+		})
+	}
+}
+
 // TestPresignRequestWithContentType 测试带ContentType的预签名请求
 func TestPresignRequestWithContentType(t *testing.T) {
 	server, cleanup := setupHandlersTestServer(t)
@@ -1227,6 +1827,7 @@ func TestPresignRequestWithContentType(t *testing.T) {
 	t.Run("带ContentType生成预签名URL", func(t *testing.T) {
 		body := `{"bucket": "content-type-bucket", "key": "image.png", "method": "PUT", "contentType": "image/png"}`
 		req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader(body))
+		req = asAdmin(t, req)
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -1249,6 +1850,7 @@ func TestPresignRequestWithContentType(t *testing.T) {
 	t.Run("带MaxSizeMB生成预签名URL", func(t *testing.T) {
 		body := `{"bucket": "content-type-bucket", "key": "large.bin", "method": "PUT", "maxSizeMB": 100}`
 		req := httptest.NewRequest(http.MethodPost, "/api/presign", strings.NewReader(body))
+		req = asAdmin(t, req)
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -1260,6 +1862,96 @@ func TestPresignRequestWithContentType(t *testing.T) {
 	})
 }
 
+// TestPresignLimit 测试按 Access Key 限制预签名数量
+func TestPresignLimit(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	server.metadata.CreateBucket("limit-bucket")
+	oldLimit, oldWindow := config.Global.Security.PresignLimit, config.Global.Security.PresignWindowMinutes
+	config.Global.Security.PresignLimit = 3
+	config.Global.Security.PresignWindowMinutes = 60
+	defer func() {
+		config.Global.Security.PresignLimit, config.Global.Security.PresignWindowMinutes = oldLimit, oldWindow
+	}()
+
+	// 只能用有桶权限的长期 API Key 签发
+	auth.InitAPIKeyCache(server.metadata)
+	newKey := func() string {
+		key, err := server.metadata.CreateAPIKey("presign limit")
+		if err != nil {
+			t.Fatalf("创建 API Key 失败: %v", err)
+		}
+		server.metadata.SetAPIKeyPermission(&storage.APIKeyPermission{AccessKeyID: key.AccessKeyID, BucketName: "limit-bucket", CanRead: true, CanWrite: true})
+		return key.AccessKeyID
+	}
+	keyA, keyB := newKey(), newKey()
+	auth.ReloadAPIKeyCache()
+
+	presign := func(accessKeyID, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), ContextKeyAccessKeyID, accessKeyID))
+		rec := httptest.NewRecorder()
+		if path == "/api/presign/batch" {
+			server.handlePresignBatch(rec, req)
+		} else {
+			server.handlePresign(rec, req)
+		}
+		return rec
+	}
+	single := `{"bucket": "limit-bucket", "key": "a.txt"}`
+
+	for i := 0; i < 2; i++ {
+		if rec := presign(keyA, "/api/presign", single); rec.Code != http.StatusOK {
+			t.Fatalf("第 %d 次签发应成功: %d", i+1, rec.Code)
+		}
+	}
+
+	// 批量请求按条目数计数，超出时整批拒绝
+	batch := `[{"bucket": "limit-bucket", "key": "b.txt"}, {"bucket": "limit-bucket", "key": "c.txt"}]`
+	rec := presign(keyA, "/api/presign/batch", batch)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("超出上限的批量请求应返回 429: %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("429 响应应带 Retry-After")
+	}
+
+	if rec := presign(keyA, "/api/presign", single); rec.Code != http.StatusOK {
+		t.Fatalf("被拒绝的批量请求不应占用额度: %d", rec.Code)
+	}
+	if rec := presign(keyA, "/api/presign", single); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("达到上限后应返回 429: %d", rec.Code)
+	}
+
+	// 其他 Key 不受影响
+	if rec := presign(keyB, "/api/presign", single); rec.Code != http.StatusOK {
+		t.Fatalf("其他 Key 应可签发: %d", rec.Code)
+	}
+
+	// 没有已认证 Key 的请求不能绕过上限
+	if rec := presign("", "/api/presign", single); rec.Code != http.StatusForbidden {
+		t.Errorf("缺少 Access Key 的单条请求应返回 403: %d", rec.Code)
+	}
+	if rec := presign("", "/api/presign/batch", batch); rec.Code != http.StatusForbidden {
+		t.Errorf("缺少 Access Key 的批量请求应返回 403: %d", rec.Code)
+	}
+
+	usage, err := server.metadata.GetPresignUsage(keyA, 3, time.Hour, time.Now())
+	if err != nil {
+		t.Fatalf("读取用量失败: %v", err)
+	}
+	if usage.Count != 3 {
+		t.Errorf("第一个 Key 的用量应为 3: %d", usage.Count)
+	}
+
+	// 关闭限制后不再计数
+	config.Global.Security.PresignLimit = 0
+	if rec := presign(keyA, "/api/presign", single); rec.Code != http.StatusOK {
+		t.Errorf("关闭限制后应可签发: %d", rec.Code)
+	}
+}
+
 // TestIsEmbedMode 测试嵌入模式检查
 func TestIsEmbedMode(t *testing.T) {
 	// IsEmbedMode 应该返回 useEmbed 变量的值
@@ -1322,3 +2014,276 @@ func BenchmarkCheckAuth(b *testing.B) {
 		}
 	})
 }
+
+// TestBucketMetrics 测试按桶请求统计
+func TestBucketMetrics(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	server.metadata.CreateBucket("metrics-bucket")
+	server.metadata.UpdateBucketPublic("metrics-bucket", true)
+	data := "hello metrics"
+	path, etag, _ := server.filestore.PutObject("metrics-bucket", "a.txt", strings.NewReader(data), int64(len(data)))
+	server.metadata.PutObject(&storage.Object{Bucket: "metrics-bucket", Key: "a.txt", Size: int64(len(data)), ETag: etag, StoragePath: path})
+
+	collector := storage.GetBucketMetrics()
+	collector.Reset()
+
+	for _, p := range []string{"/metrics-bucket/a.txt", "/metrics-bucket/missing.txt"} {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, p, nil))
+	}
+	// 未认证的写请求
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/metrics-bucket/b.txt", strings.NewReader("body")))
+
+	var m storage.BucketMetrics
+	for _, item := range collector.Snapshot() {
+		if item.Bucket == "metrics-bucket" {
+			m = item
+		}
+	}
+	if m.Requests != 3 || m.Errors != 2 {
+		t.Errorf("请求数或错误数错误: %+v", m)
+	}
+	if m.BytesOut < int64(len(data)) {
+		t.Errorf("响应字节数错误: %+v", m)
+	}
+}
+
+// TestPrometheusMetrics 测试 Prometheus /metrics 输出
+func TestPrometheusMetrics(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	server.metadata.CreateBucket("prom-bucket")
+	server.metadata.UpdateBucketPublic("prom-bucket", true)
+	data := "hello prometheus"
+	path, etag, _ := server.filestore.PutObject("prom-bucket", "a.txt", strings.NewReader(data), int64(len(data)))
+	server.metadata.PutObject(&storage.Object{Bucket: "prom-bucket", Key: "a.txt", Size: int64(len(data)), ETag: etag, StoragePath: path})
+	server.metadata.CreateBucket("prom-empty")
+
+	scrape := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rec
+	}
+
+	// 默认关闭
+	old := config.Global.Server.PrometheusEnabled
+	config.Global.Server.PrometheusEnabled = false
+	defer func() { config.Global.Server.PrometheusEnabled = old }()
+	if rec := scrape(); strings.Contains(rec.Body.String(), "sss_http_requests_total") {
+		t.Fatal("未开启时不应输出指标")
+	}
+
+	config.Global.Server.PrometheusEnabled = true
+	for _, p := range []string{"/prom-bucket/a.txt", "/prom-bucket/missing.txt"} {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/prom-bucket/b.txt", strings.NewReader("upload")))
+
+	rec := scrape()
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("响应错误: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`sss_http_requests_total{method="GET",code="200"} `,
+		`sss_http_requests_total{method="GET",code="404"} 1`,
+		`sss_http_requests_total{method="PUT",code="403"} 1`,
+		`sss_request_duration_seconds_bucket{le="+Inf"} `,
+		"sss_request_duration_seconds_count ",
+		`sss_objects{bucket="prom-bucket"} 1`,
+		`sss_objects{bucket="prom-empty"} 0`,
+		`sss_storage_bytes{bucket="prom-bucket"} 16`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("缺少指标 %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "sss_download_bytes_total 0\n") {
+		t.Error("下载字节数应大于 0")
+	}
+
+	// 上传字节数按处理器实际读取的请求体统计
+	m := &httpMetrics{}
+	req := httptest.NewRequest(http.MethodPut, "/prom-bucket/c.txt", strings.NewReader("upload"))
+	recorder := newRequestRecorder(httptest.NewRecorder(), req)
+	io.ReadAll(req.Body)
+	recorder.WriteHeader(http.StatusCreated)
+	recorder.finish(m, req.Method, time.Now())
+	if m.uploadBytes.Load() != 6 || m.requests[promMethodIndex("PUT")][http.StatusCreated].Load() != 1 {
+		t.Errorf("请求体字节数或状态码统计错误: %d", m.uploadBytes.Load())
+	}
+
+	// 带签名或查询参数的请求仍按 S3 请求处理
+	req = httptest.NewRequest(http.MethodGet, "/metrics?list-type=2", nil)
+	if isPrometheusRequest(req, true) {
+		t.Error("带查询参数的请求不应被当作抓取请求")
+	}
+}
+
+// TestPresignedPost 测试预签名 POST 策略生成和浏览器表单上传
+func TestPresignedPost(t *testing.T) {
+	server, cleanup := setupHandlersTestServer(t)
+	defer cleanup()
+
+	oldKey, oldSecret := config.Global.Auth.AccessKeyID, config.Global.Auth.SecretAccessKey
+	config.Global.Auth.AccessKeyID, config.Global.Auth.SecretAccessKey = "post-admin", "post-secret"
+	defer func() { config.Global.Auth.AccessKeyID, config.Global.Auth.SecretAccessKey = oldKey, oldSecret }()
+
+	server.metadata.CreateBucket("form-bucket")
+
+	presign := func(body string) PresignPostResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/presign/post", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), ContextKeyAccessKeyID, "post-admin"))
+		rec := httptest.NewRecorder()
+		server.handlePresignPost(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("生成 POST 策略失败: %d %s", rec.Code, rec.Body.String())
+		}
+		var resp PresignPostResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		return resp
+	}
+
+	// post 按 fields 构造表单并提交，文件字段放在最后
+	post := func(fields map[string]string, filename, content string) *httptest.ResponseRecorder {
+		t.Helper()
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for k, v := range fields {
+			mw.WriteField(k, v)
+		}
+		fw, _ := mw.CreateFormFile("file", filename)
+		fw.Write([]byte(content))
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/form-bucket", &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	with := func(fields map[string]string, k, v string) map[string]string {
+		out := make(map[string]string, len(fields)+1)
+		for name, value := range fields {
+			out[name] = value
+		}
+		out[k] = v
+		return out
+	}
+
+	resp := presign(`{"bucket":"form-bucket","key":"uploads/${filename}","minSize":2,"maxSizeMB":1,"contentType":"text/plain"}`)
+	if !strings.HasSuffix(resp.URL, "/form-bucket") {
+		t.Errorf("表单地址错误: %s", resp.URL)
+	}
+	for _, name := range []string{"key", "policy", "x-amz-credential", "x-amz-date", "x-amz-signature", "x-amz-algorithm"} {
+		if resp.Fields[name] == "" {
+			t.Errorf("缺少字段 %s: %v", name, resp.Fields)
+		}
+	}
+
+	t.Run("上传成功", func(t *testing.T) {
+		rec := post(resp.Fields, "hello.txt", "hello form")
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("上传失败: %d %s", rec.Code, rec.Body.String())
+		}
+		obj, _ := server.metadata.GetObject("form-bucket", "uploads/hello.txt")
+		if obj == nil || obj.Size != 10 || obj.ContentType != "text/plain" {
+			t.Fatalf("对象元数据错误: %+v", obj)
+		}
+		if rec.Header().Get("ETag") != `"`+obj.ETag+`"` {
+			t.Errorf("ETag 错误: %s", rec.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("策略条件", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			fields map[string]string
+			file   string
+			status int
+			code   string
+		}{
+			{"键前缀不符", with(resp.Fields, "key", "other/${filename}"), "data", http.StatusForbidden, "AccessDenied"},
+			{"内容类型不符", with(resp.Fields, "Content-Type", "text/html"), "data", http.StatusForbidden, "AccessDenied"},
+			{"策略外字段", with(resp.Fields, "Cache-Control", "no-cache"), "data", http.StatusForbidden, "AccessDenied"},
+			{"文件过小", resp.Fields, "a", http.StatusBadRequest, "EntityTooSmall"},
+			{"文件过大", resp.Fields, strings.Repeat("a", 1024*1024+1), http.StatusBadRequest, "EntityTooLarge"},
+			{"签名错误", with(resp.Fields, "x-amz-signature", strings.Repeat("0", 64)), "data", http.StatusForbidden, "SignatureDoesNotMatch"},
+			{"策略被篡改", with(resp.Fields, "policy", resp.Fields["policy"]+"AA"), "data", http.StatusForbidden, "SignatureDoesNotMatch"},
+			{"缺少策略", with(resp.Fields, "policy", ""), "data", http.StatusForbidden, "AccessDenied"},
+		}
+		for _, tc := range cases {
+			rec := post(tc.fields, "x.txt", tc.file)
+			if rec.Code != tc.status || !strings.Contains(rec.Body.String(), tc.code) {
+				t.Errorf("%s: 期望 %d %s, 实际 %d %s", tc.name, tc.status, tc.code, rec.Code, rec.Body.String())
+			}
+		}
+		if obj, _ := server.metadata.GetObject("form-bucket", "uploads/x.txt"); obj != nil {
+			t.Errorf("失败的上传不应写入对象: %+v", obj)
+		}
+	})
+
+	t.Run("策略过期", func(t *testing.T) {
+		expired := presign(`{"bucket":"form-bucket","key":"late.txt","expiresMinutes":-1}`)
+		rec := post(expired.Fields, "late.txt", "data")
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "Policy expired") {
+			t.Errorf("过期策略应被拒绝: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("只读桶拒绝", func(t *testing.T) {
+		server.metadata.UpdateBucketReadOnly("form-bucket", true)
+		defer server.metadata.UpdateBucketReadOnly("form-bucket", false)
+		rec := post(resp.Fields, "ro.txt", "data")
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("只读桶应拒绝表单上传: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("签发权限", func(t *testing.T) {
+		auth.InitAPIKeyCache(server.metadata)
+		newKey := func(canWrite bool) string {
+			key, err := server.metadata.CreateAPIKey("presign post")
+			if err != nil {
+				t.Fatalf("创建 API Key 失败: %v", err)
+			}
+			server.metadata.SetAPIKeyPermission(&storage.APIKeyPermission{AccessKeyID: key.AccessKeyID, BucketName: "form-bucket", CanRead: true, CanWrite: canWrite})
+			return key.AccessKeyID
+		}
+		readKey, writeKey := newKey(false), newKey(true)
+		auth.ReloadAPIKeyCache()
+		sts := auth.IssueTemporaryCredential("form-bucket", true, true, time.Hour)
+
+		presignAs := func(accessKeyID string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/api/presign/post", strings.NewReader(`{"bucket":"form-bucket","key":"k.txt"}`))
+			req = req.WithContext(context.WithValue(req.Context(), ContextKeyAccessKeyID, accessKeyID))
+			rec := httptest.NewRecorder()
+			server.handlePresignPost(rec, req)
+			return rec
+		}
+		if rec := presignAs(readKey); rec.Code != http.StatusForbidden {
+			t.Errorf("只读 Key 不能签发表单上传策略: %d %s", rec.Code, rec.Body.String())
+		}
+		if rec := presignAs(sts.AccessKeyID); rec.Code != http.StatusForbidden {
+			t.Errorf("临时凭证不能签发表单上传策略: %d %s", rec.Code, rec.Body.String())
+		}
+		rec := presignAs(writeKey)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("有写权限的 Key 应能签发: %d %s", rec.Code, rec.Body.String())
+		}
+		var keyResp PresignPostResponse
+		json.Unmarshal(rec.Body.Bytes(), &keyResp)
+		if !strings.HasPrefix(keyResp.Fields["x-amz-credential"], writeKey+"/") {
+			t.Errorf("应使用调用者的 Key 签名: %s", keyResp.Fields["x-amz-credential"])
+		}
+		if rec := post(keyResp.Fields, "k.txt", "data"); rec.Code != http.StatusNoContent {
+			t.Errorf("调用者签发的策略应可上传: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sss/internal/config"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// SetReady 标记服务是否可以接收流量，启动完成后置为 true，关闭前置为 false
+func (s *Server) SetReady(ready bool) {
+	s.ready.Store(ready)
+}
+
+// readinessChecks 执行就绪检查，返回各项结果及是否全部通过
+func (s *Server) readinessChecks() (map[string]string, bool) {
+	checks := map[string]string{
+		"startup":     "ok",
+		"database":    "ok",
+		"storage":     "ok",
+		"disk_space":  "ok",
+		"maintenance": "ok",
+	}
+	ready := true
+	if !s.ready.Load() {
+		checks["startup"] = "not ready"
+		ready = false
+	}
+	if err := s.metadata.Ping(); err != nil {
+		utils.Warn("readiness database check failed", "error", err)
+		checks["database"] = err.Error()
+		ready = false
+	}
+	if err := s.filestore.CheckWritable(); err != nil {
+		utils.Warn("readiness storage check failed", "error", err)
+		checks["storage"] = err.Error()
+		ready = false
+	}
+	if msg := s.diskSpaceCheck(); msg != "" {
+		checks["disk_space"] = msg
+		ready = false
+	}
+	if config.Global != nil && config.Global.Server.Maintenance {
+		checks["maintenance"] = "enabled"
+		ready = false
+	}
+	return checks, ready
+}
+
+// diskSpaceCheck 最近写入因磁盘已满失败，或可用空间低于最低可用空间时返回原因
+func (s *Server) diskSpaceCheck() string {
+	if at, recent := s.filestore.RecentNoSpace(storage.NoSpaceWindow); recent {
+		return "no space left on device at " + at.UTC().Format(time.RFC3339)
+	}
+	if config.Global == nil || config.Global.Storage.MinFreeSpace <= 0 {
+		return ""
+	}
+	free, err := s.filestore.FreeSpace()
+	if err != nil || free >= config.Global.Storage.MinFreeSpace {
+		return ""
+	}
+	return fmt.Sprintf("low free space: %d bytes available, minimum %d", free, config.Global.Storage.MinFreeSpace)
+}
+
+// handleHealth 健康检查端点（兼容旧版）- 不需要认证
+// 始终返回 200，ready 字段反映就绪检查结果
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	checks, ready := s.readinessChecks()
+	utils.WriteJSONResponse(w, map[string]interface{}{
+		"status":  "ok",
+		"version": "1.1.0",
+		"ready":   ready,
+		"checks":  checks,
+	})
+}
+
+// handleLiveness 存活探针，进程运行即返回 200
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSONResponse(w, map[string]string{"status": "ok"})
+}
+
+// handleReadiness 就绪探针，启动中、数据库或数据目录异常、磁盘空间不足、维护模式时返回 503
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	checks, ready := s.readinessChecks()
+	if !ready {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "not ready", "checks": checks})
+		return
+	}
+	utils.WriteJSONResponse(w, map[string]interface{}{"status": "ready", "checks": checks})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"sss/internal/config"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// IdempotencyKeyHeader PUT 幂等键请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyKey 返回请求中的幂等键，未开启或未携带时返回空
+func idempotencyKey(r *http.Request) string {
+	if config.Global.Storage.IdempotencyWindow <= 0 {
+		return ""
+	}
+	return r.Header.Get(IdempotencyKeyHeader)
+}
+
+// validIdempotencyKey 幂等键长度受限且只能包含可见 ASCII 字符
+func validIdempotencyKey(key string) bool {
+	if len(key) > storage.MaxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// replayIdempotentPut 窗口内重复使用幂等键时直接返回首次结果，返回 true 表示已响应
+// 请求体与首次写入一致时不再落盘，不一致或用于其他对象时返回 409
+func (s *Server) replayIdempotentPut(w http.ResponseWriter, r *http.Request, bucket, key, idemKey string) bool {
+	resource := "/" + bucket + "/" + key
+	if !validIdempotencyKey(idemKey) {
+		utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, resource)
+		return true
+	}
+
+	owner, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	rec, err := s.metadata.GetPutIdempotency(owner, idemKey, time.Now())
+	if err != nil {
+		utils.Error("get idempotency record failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return true
+	}
+	if rec == nil {
+		return false
+	}
+	if rec.Bucket != bucket || rec.Key != key {
+		utils.WriteError(w, utils.ErrIdempotencyConflict, http.StatusConflict, resource)
+		return true
+	}
+	if r.ContentLength >= 0 && r.ContentLength != rec.Size {
+		utils.WriteError(w, utils.ErrIdempotencyConflict, http.StatusConflict, resource)
+		return true
+	}
+
+	// 只计算摘要不写盘，多读一个字节用于判断长度是否一致
+	hash := md5.New()
+	n, err := io.Copy(hash, io.LimitReader(r.Body, rec.Size+1))
+	if err != nil {
+		utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, resource)
+		return true
+	}
+	if n != rec.Size || hex.EncodeToString(hash.Sum(nil)) != rec.ETag {
+		utils.WriteError(w, utils.ErrIdempotencyConflict, http.StatusConflict, resource)
+		return true
+	}
+
+	w.Header().Set("ETag", `"`+rec.ETag+`"`)
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(http.StatusOK)
+	return true
+}
+
+// recordIdempotentPut 记录幂等键对应的写入结果，失败只记录日志
+func (s *Server) recordIdempotentPut(r *http.Request, bucket, key, idemKey string, obj *storage.Object) {
+	owner, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	window := time.Duration(config.Global.Storage.IdempotencyWindow) * time.Minute
+	err := s.metadata.SavePutIdempotency(&storage.PutIdempotencyRecord{
+		Owner:          owner,
+		IdempotencyKey: idemKey,
+		Bucket:         bucket,
+		Key:            key,
+		ETag:           obj.ETag,
+		Size:           obj.Size,
+		ExpiresAt:      time.Now().Add(window),
+	})
+	if err != nil {
+		utils.Warn("save idempotency record failed", "bucket", bucket, "key", key, "error", err)
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// maxLifecycleBodySize PutBucketLifecycleConfiguration 请求体大小上限
+const maxLifecycleBodySize = 256 * 1024
+
+// S3 生命周期规则状态
+const (
+	lifecycleEnabled  = "Enabled"
+	lifecycleDisabled = "Disabled"
+)
+
+// xmlElement 只用于判断元素是否出现
+type xmlElement struct{}
+
+// LifecycleConfiguration GetBucketLifecycleConfiguration 响应 / PutBucketLifecycleConfiguration 请求
+type LifecycleConfiguration struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Xmlns   string          `xml:"xmlns,attr,omitempty"`
+	Rules   []LifecycleRule `xml:"Rule"`
+}
+
+// LifecycleRule 生命周期规则，只支持前缀过滤和按天数过期
+type LifecycleRule struct {
+	ID         string               `xml:"ID,omitempty"`
+	Prefix     *string              `xml:"Prefix,omitempty"` // 旧格式，直接写在 Rule 下
+	Filter     *LifecycleFilter     `xml:"Filter,omitempty"`
+	Status     string               `xml:"Status"`
+	Expiration *LifecycleExpiration `xml:"Expiration,omitempty"`
+
+	// 以下元素不支持，出现时返回 NotImplemented
+	Transitions                    []xmlElement `xml:"Transition"`
+	NoncurrentVersionExpiration    *xmlElement  `xml:"NoncurrentVersionExpiration"`
+	NoncurrentVersionTransitions   []xmlElement `xml:"NoncurrentVersionTransition"`
+	AbortIncompleteMultipartUpload *xmlElement  `xml:"AbortIncompleteMultipartUpload"`
+}
+
+// LifecycleFilter 规则过滤条件
+type LifecycleFilter struct {
+	Prefix string `xml:"Prefix"`
+
+	// 以下过滤条件不支持
+	And                   *xmlElement `xml:"And"`
+	Tag                   *xmlElement `xml:"Tag"`
+	ObjectSizeGreaterThan *xmlElement `xml:"ObjectSizeGreaterThan"`
+	ObjectSizeLessThan    *xmlElement `xml:"ObjectSizeLessThan"`
+}
+
+// LifecycleExpiration 过期设置
+type LifecycleExpiration struct {
+	Days *int `xml:"Days,omitempty"`
+
+	// 以下设置不支持
+	Date                      *xmlElement `xml:"Date"`
+	ExpiredObjectDeleteMarker *xmlElement `xml:"ExpiredObjectDeleteMarker"`
+}
+
+// unsupported 判断规则是否使用了不支持的元素
+func (r *LifecycleRule) unsupported() bool {
+	if len(r.Transitions) > 0 || r.NoncurrentVersionExpiration != nil ||
+		len(r.NoncurrentVersionTransitions) > 0 || r.AbortIncompleteMultipartUpload != nil {
+		return true
+	}
+	if f := r.Filter; f != nil && (f.And != nil || f.Tag != nil || f.ObjectSizeGreaterThan != nil || f.ObjectSizeLessThan != nil) {
+		return true
+	}
+	if e := r.Expiration; e != nil && (e.Date != nil || e.ExpiredObjectDeleteMarker != nil) {
+		return true
+	}
+	return false
+}
+
+// toStorageRules 将 S3 格式的规则转换为存储格式，格式错误时返回 MalformedXML，使用不支持的元素时返回 NotImplemented
+func (c *LifecycleConfiguration) toStorageRules() ([]storage.LifecycleRule, *utils.S3Error, int) {
+	if len(c.Rules) == 0 {
+		return nil, &utils.ErrMalformedXML, http.StatusBadRequest
+	}
+	rules := make([]storage.LifecycleRule, 0, len(c.Rules))
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if r.unsupported() {
+			return nil, &utils.ErrUnsupportedLifecycle, http.StatusNotImplemented
+		}
+		if r.Status != lifecycleEnabled && r.Status != lifecycleDisabled {
+			return nil, &utils.ErrMalformedXML, http.StatusBadRequest
+		}
+		// Prefix 和 Filter 只能二选一
+		if r.Prefix != nil && r.Filter != nil {
+			return nil, &utils.ErrMalformedXML, http.StatusBadRequest
+		}
+		if r.Expiration == nil || r.Expiration.Days == nil {
+			return nil, &utils.ErrUnsupportedLifecycle, http.StatusNotImplemented
+		}
+		rule := storage.LifecycleRule{
+			ID:      r.ID,
+			Days:    *r.Expiration.Days,
+			Enabled: r.Status == lifecycleEnabled,
+		}
+		if r.Prefix != nil {
+			rule.Prefix = *r.Prefix
+		} else if r.Filter != nil {
+			rule.Prefix = r.Filter.Prefix
+		}
+		rules = append(rules, rule)
+	}
+	if err := storage.ValidateLifecycleRules(rules); err != nil {
+		e := utils.ErrMalformedXML
+		e.Message = err.Error()
+		return nil, &e, http.StatusBadRequest
+	}
+	return rules, nil, 0
+}
+
+// lifecycleConfigurationFromRules 将存储格式的规则转换为 S3 响应
+func lifecycleConfigurationFromRules(rules []storage.LifecycleRule) LifecycleConfiguration {
+	config := LifecycleConfiguration{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/"}
+	for _, rule := range rules {
+		days := rule.Days
+		status := lifecycleDisabled
+		if rule.Enabled {
+			status = lifecycleEnabled
+		}
+		config.Rules = append(config.Rules, LifecycleRule{
+			ID:         rule.ID,
+			Filter:     &LifecycleFilter{Prefix: rule.Prefix},
+			Status:     status,
+			Expiration: &LifecycleExpiration{Days: &days},
+		})
+	}
+	return config
+}
+
+// handleBucketLifecycle 处理 ?lifecycle 请求
+// 规则会自动删除对象，读取和修改都只有桶所有者（管理员 Key）可以调用
+func (s *Server) handleBucketLifecycle(w http.ResponseWriter, r *http.Request, bucket string) {
+	resource := "/" + bucket
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if b == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, resource)
+		return
+	}
+	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	if accessKeyID == "" || accessKeyID != bucketOwnerID() {
+		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, resource)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if len(b.LifecycleRules) == 0 {
+			utils.WriteError(w, utils.ErrNoSuchLifecycle, http.StatusNotFound, resource)
+			return
+		}
+		utils.WriteXML(w, http.StatusOK, lifecycleConfigurationFromRules(b.LifecycleRules))
+	case http.MethodPut:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxLifecycleBodySize+1))
+		if err != nil || len(body) > maxLifecycleBodySize {
+			utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, resource)
+			return
+		}
+		var config LifecycleConfiguration
+		if err := xml.Unmarshal(body, &config); err != nil {
+			utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, resource)
+			return
+		}
+		rules, s3err, status := config.toStorageRules()
+		if s3err != nil {
+			utils.WriteError(w, *s3err, status, resource)
+			return
+		}
+		if err := s.metadata.UpdateBucketLifecycle(bucket, rules); err != nil {
+			utils.Error("update bucket lifecycle failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+			return
+		}
+		s.adminHandler.Audit(r, storage.AuditActionBucketSetLifecycle, accessKeyID, bucket, true, map[string]interface{}{
+			"rules": len(rules),
+		})
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		if err := s.metadata.UpdateBucketLifecycle(bucket, nil); err != nil {
+			utils.Error("delete bucket lifecycle failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+			return
+		}
+		if len(b.LifecycleRules) > 0 {
+			s.adminHandler.Audit(r, storage.AuditActionBucketSetLifecycle, accessKeyID, bucket, true, map[string]interface{}{
+				"rules": 0,
+			})
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, resource)
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/xml"
+	"net/http"
+	"time"
+
+	"sss/internal/storage"
+)
+
+// xmlField ListBucketResult 中的简单字段
+type xmlField struct {
+	name  string
+	value interface{}
+}
+
+// listResultStream 流式输出 ListBucketResult，逐个编码 Contents 和 CommonPrefixes，内存占用与列表长度无关
+// 依赖遍历结果的字段（KeyCount、IsTruncated、下一页标记）在列表之后输出，S3 客户端按元素名解析，不依赖字段顺序
+type listResultStream struct {
+	w       http.ResponseWriter
+	enc     *xml.Encoder
+	head    []xmlField
+	started bool
+}
+
+func newListResultStream(w http.ResponseWriter, head []xmlField) *listResultStream {
+	return &listResultStream{w: w, enc: xml.NewEncoder(w), head: head}
+}
+
+// begin 首次输出时写响应头和固定字段，遍历开始前出错时仍可返回错误响应
+func (l *listResultStream) begin() error {
+	if l.started {
+		return nil
+	}
+	l.started = true
+	l.w.Header().Set("Content-Type", "application/xml")
+	l.w.WriteHeader(http.StatusOK)
+	if _, err := l.w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	start := xml.StartElement{
+		Name: xml.Name{Local: "ListBucketResult"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "http://s3.amazonaws.com/doc/2006-03-01/"}},
+	}
+	if err := l.enc.EncodeToken(start); err != nil {
+		return err
+	}
+	return l.fields(l.head)
+}
+
+func (l *listResultStream) fields(fields []xmlField) error {
+	for _, f := range fields {
+		if err := l.enc.EncodeElement(f.value, xml.StartElement{Name: xml.Name{Local: f.name}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visitor 返回把对象和公共前缀写入响应的遍历回调
+func (l *listResultStream) visitor() storage.ObjectListVisitor {
+	return storage.ObjectListVisitor{
+		Object: func(obj *storage.Object) error {
+			if err := l.begin(); err != nil {
+				return err
+			}
+			return l.enc.EncodeElement(ObjectInfo{
+				Key:          obj.Key,
+				LastModified: obj.LastModified.UTC().Format(time.RFC3339),
+				ETag:         `"` + obj.ETag + `"`,
+				Size:         obj.Size,
+				StorageClass: "STANDARD",
+			}, xml.StartElement{Name: xml.Name{Local: "Contents"}})
+		},
+		CommonPrefix: func(prefix string) error {
+			if err := l.begin(); err != nil {
+				return err
+			}
+			return l.enc.EncodeElement(CommonPrefix{Prefix: prefix}, xml.StartElement{Name: xml.Name{Local: "CommonPrefixes"}})
+		},
+	}
+}
+
+// finish 写入尾部字段并结束文档
+func (l *listResultStream) finish(tail []xmlField) error {
+	if err := l.begin(); err != nil {
+		return err
+	}
+	if err := l.fields(tail); err != nil {
+		return err
+	}
+	if err := l.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "ListBucketResult"}}); err != nil {
+		return err
+	}
+	return l.enc.Flush()
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"sss/internal/config"
+	"sss/internal/storage"
+)
+
+// metricsResponseWriter 记录响应状态码和响应体字节数
+type metricsResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	bytesOut int64
+}
+
+func (m *metricsResponseWriter) WriteHeader(status int) {
+	if m.status == 0 {
+		m.status = status
+	}
+	m.ResponseWriter.WriteHeader(status)
+}
+
+func (m *metricsResponseWriter) Write(data []byte) (int, error) {
+	if m.status == 0 {
+		m.status = http.StatusOK
+	}
+	n, err := m.ResponseWriter.Write(data)
+	m.bytesOut += int64(n)
+	return n, err
+}
+
+// ReadFrom 保留底层连接的 sendfile 优化
+func (m *metricsResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if m.status == 0 {
+		m.status = http.StatusOK
+	}
+	n, err := io.Copy(m.ResponseWriter, src)
+	m.bytesOut += n
+	return n, err
+}
+
+// Flush 透传流式响应的刷新（如 S3 Select）
+func (m *metricsResponseWriter) Flush() {
+	if f, ok := m.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (m *metricsResponseWriter) Unwrap() http.ResponseWriter {
+	return m.ResponseWriter
+}
+
+// countingReadCloser 统计请求体读取的字节数
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// withBucketMetrics 包装请求和响应以统计桶级别的请求数、流量和错误，返回请求结束时调用的记录函数
+func withBucketMetrics(w http.ResponseWriter, r *http.Request, bucket string) (http.ResponseWriter, func()) {
+	return withLateBucketMetrics(w, r, func() string { return bucket })
+}
+
+// withLateBucketMetrics 同 withBucketMetrics，桶名在记录时才确定（如表单上传需读取表单后才知道命名空间），为空时不记录
+func withLateBucketMetrics(w http.ResponseWriter, r *http.Request, bucket func() string) (http.ResponseWriter, func()) {
+	mw := &metricsResponseWriter{ResponseWriter: w}
+	var body *countingReadCloser
+	if r.Body != nil && r.Body != http.NoBody {
+		body = &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+	}
+
+	return mw, func() {
+		maxBuckets := storage.DefaultMetricsMaxBuckets
+		if config.Global != nil {
+			maxBuckets = config.Global.Server.MetricsMaxBuckets
+		}
+		status := mw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		var bytesIn int64
+		if body != nil {
+			bytesIn = body.n
+		}
+		if name := bucket(); name != "" {
+			storage.GetBucketMetrics().Record(name, status, bytesIn, mw.bytesOut, maxBuckets)
+		}
+	}
+}
@@ -2,12 +2,14 @@ package api
 
 import (
 	"encoding/xml"
+	"io"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"sss/internal/config"
 	"sss/internal/storage"
 	"sss/internal/utils"
 )
@@ -63,6 +65,40 @@ type PartInfo struct {
 	Size         int64  `xml:"Size"`
 }
 
+// CopyPartResult UploadPartCopy 响应
+type CopyPartResult struct {
+	XMLName      xml.Name `xml:"CopyPartResult"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	LastModified string   `xml:"LastModified"`
+	ETag         string   `xml:"ETag"`
+}
+
+// ListMultipartUploadsResult 列出未完成多段上传响应
+type ListMultipartUploadsResult struct {
+	XMLName            xml.Name       `xml:"ListMultipartUploadsResult"`
+	Xmlns              string         `xml:"xmlns,attr"`
+	Bucket             string         `xml:"Bucket"`
+	KeyMarker          string         `xml:"KeyMarker"`
+	UploadIdMarker     string         `xml:"UploadIdMarker"`
+	NextKeyMarker      string         `xml:"NextKeyMarker,omitempty"`
+	NextUploadIdMarker string         `xml:"NextUploadIdMarker,omitempty"`
+	Prefix             string         `xml:"Prefix"`
+	Delimiter          string         `xml:"Delimiter,omitempty"`
+	MaxUploads         int            `xml:"MaxUploads"`
+	IsTruncated        bool           `xml:"IsTruncated"`
+	Uploads            []UploadInfo   `xml:"Upload"`
+	CommonPrefixes     []CommonPrefix `xml:"CommonPrefixes,omitempty"`
+}
+
+type UploadInfo struct {
+	Key          string `xml:"Key"`
+	UploadId     string `xml:"UploadId"`
+	Initiator    Owner  `xml:"Initiator"`
+	Owner        Owner  `xml:"Owner"`
+	StorageClass string `xml:"StorageClass"`
+	Initiated    string `xml:"Initiated"`
+}
+
 // handleInitiateMultipartUpload 初始化多段上传
 func (s *Server) handleInitiateMultipartUpload(w http.ResponseWriter, r *http.Request, bucket, key string) {
 	// 检查存储桶
@@ -76,6 +112,31 @@ func (s *Server) handleInitiateMultipartUpload(w http.ResponseWriter, r *http.Re
 		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+bucket)
 		return
 	}
+	if !checkReadOnly(w, b, "/"+bucket+"/"+key) {
+		return
+	}
+	if b.DeniesKey(key) {
+		utils.WriteError(w, utils.ErrKeyNotAllowed, http.StatusBadRequest, "/"+bucket+"/"+key)
+		return
+	}
+
+	// 检查桶内未完成上传数上限
+	if maxUploads := config.Global.Storage.MaxIncompleteUploads; maxUploads > 0 {
+		count, err := s.metadata.CountMultipartUploads(bucket)
+		if err != nil {
+			utils.Error("count multipart uploads failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+			return
+		}
+		if count >= maxUploads {
+			utils.WriteError(w, utils.ErrTooManyUploads, http.StatusTooManyRequests, "/"+bucket+"/"+key)
+			return
+		}
+	}
+
+	if !checkMetadataSize(w, r, "/"+bucket+"/"+key) {
+		return
+	}
 
 	// 生成 UploadID
 	uploadID := utils.GenerateID(32)
@@ -85,6 +146,11 @@ func (s *Server) handleInitiateMultipartUpload(w http.ResponseWriter, r *http.Re
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
+	// 分段上传在初始化时按声明的类型检查桶的内容类型限制
+	if !b.AllowsContentType(contentType) {
+		utils.WriteError(w, utils.ErrContentTypeNotAllowed, http.StatusBadRequest, "/"+bucket+"/"+key)
+		return
+	}
 
 	// 创建多段上传记录
 	upload := &storage.MultipartUpload{
@@ -93,9 +159,14 @@ func (s *Server) handleInitiateMultipartUpload(w http.ResponseWriter, r *http.Re
 		Key:         key,
 		Initiated:   time.Now().UTC(),
 		ContentType: contentType,
+		Metadata:    userMetadataFromRequest(r.Header),
 	}
 
 	if err := s.metadata.CreateMultipartUpload(upload); err != nil {
+		if err == storage.ErrBucketDeleted {
+			utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+bucket)
+			return
+		}
 		utils.Error("create multipart upload failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
 		return
@@ -103,7 +174,7 @@ func (s *Server) handleInitiateMultipartUpload(w http.ResponseWriter, r *http.Re
 
 	result := InitiateMultipartUploadResult{
 		Xmlns:    "http://s3.amazonaws.com/doc/2006-03-01/",
-		Bucket:   bucket,
+		Bucket:   displayBucket(r, bucket),
 		Key:      key,
 		UploadId: uploadID,
 	}
@@ -133,8 +204,53 @@ func (s *Server) handleUploadPart(w http.ResponseWriter, r *http.Request, bucket
 		return
 	}
 
+	// 上传开始后桶被设为只读，不再接收分片
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+		return
+	}
+	if !checkReadOnly(w, b, "/"+bucket+"/"+key) {
+		return
+	}
+
+	// 检查上传保留的分片数上限，重传已有分片不受限制
+	if maxParts := config.Global.Storage.MaxUploadParts; maxParts > 0 {
+		count, err := s.metadata.CountOtherParts(uploadID, partNumber)
+		if err != nil {
+			utils.Error("count parts failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
+			return
+		}
+		if count >= maxParts {
+			utils.WriteError(w, utils.ErrTooManyUploadParts, http.StatusForbidden, "/"+bucket+"/"+key)
+			return
+		}
+	}
+
+	// 带 x-amz-copy-source 时为 UploadPartCopy，分片数据来自已有对象
+	if r.Header.Get("x-amz-copy-source") != "" {
+		s.handleUploadPartCopy(w, r, bucket, key, uploadID, partNumber)
+		return
+	}
+
+	// aws-chunked 流式上传只保存解码后的真实数据
+	if !decodeAWSChunked(w, r, "/"+bucket+"/"+key) {
+		return
+	}
+	if !s.checkFreeSpace(w, r, "/"+bucket+"/"+key) {
+		return
+	}
+
 	// 存储分片
 	etag, size, err := s.filestore.PutPart(uploadID, partNumber, r.Body)
+	if writeDigestError(w, err, "/"+bucket+"/"+key) {
+		return
+	}
+	if writeNoSpaceError(w, err, "/"+bucket+"/"+key) {
+		return
+	}
 	if err != nil {
 		utils.Error("store part failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
@@ -160,6 +276,68 @@ func (s *Server) handleUploadPart(w http.ResponseWriter, r *http.Request, bucket
 	w.WriteHeader(http.StatusOK)
 }
 
+// handleUploadPartCopy 从已有对象复制分片数据，x-amz-copy-source-range 指定时只复制该字节范围
+// 大对象的服务端复制可拆成多个分片并行执行
+func (s *Server) handleUploadPartCopy(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string, partNumber int) {
+	src, ok := s.resolveCopySource(w, r, "/"+bucket+"/"+key)
+	if !ok {
+		return
+	}
+	if !s.checkFreeSpace(w, r, "/"+bucket+"/"+key) {
+		return
+	}
+
+	// 加密的源对象读取时解密，分片在完成上传前不加密
+	srcFile, err := s.filestore.OpenObject(src.obj)
+	if err != nil {
+		utils.Error("open copy source failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+src.bucket+"/"+src.key)
+		return
+	}
+	defer srcFile.Close()
+	var body io.Reader = srcFile
+	if rangeHeader := r.Header.Get("x-amz-copy-source-range"); rangeHeader != "" {
+		start, end, ok := parseCopySourceRange(rangeHeader, src.obj.Size)
+		if !ok {
+			utils.WriteError(w, utils.ErrInvalidRange, http.StatusRequestedRangeNotSatisfiable, "/"+src.bucket+"/"+src.key)
+			return
+		}
+		body = io.NewSectionReader(srcFile, start, end-start+1)
+	}
+
+	etag, size, err := s.filestore.PutPart(uploadID, partNumber, body)
+	if writeNoSpaceError(w, err, "/"+bucket+"/"+key) {
+		return
+	}
+	if err != nil {
+		utils.Error("copy part failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
+		return
+	}
+
+	part := &storage.Part{
+		UploadID:   uploadID,
+		PartNumber: partNumber,
+		Size:       size,
+		ETag:       etag,
+		ModifiedAt: time.Now().UTC(),
+	}
+	if err := s.metadata.PutPart(part); err != nil {
+		utils.Error("save part metadata failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
+		return
+	}
+
+	if src.hasVersion {
+		w.Header().Set("x-amz-copy-source-version-id", src.versionID)
+	}
+	utils.WriteXML(w, http.StatusOK, CopyPartResult{
+		Xmlns:        "http://s3.amazonaws.com/doc/2006-03-01/",
+		LastModified: part.ModifiedAt.Format(time.RFC3339),
+		ETag:         `"` + etag + `"`,
+	})
+}
+
 // handleCompleteMultipartUpload 完成多段上传
 func (s *Server) handleCompleteMultipartUpload(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string) {
 	// 检查多段上传是否存在
@@ -174,6 +352,29 @@ func (s *Server) handleCompleteMultipartUpload(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// 完成上传会覆盖同名对象，需检查不可变窗口
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+		return
+	}
+	if !checkReadOnly(w, b, "/"+bucket+"/"+key) {
+		return
+	}
+	if !s.checkImmutable(w, b, bucket, key, nil) {
+		return
+	}
+
+	// 条件完成：If-None-Match: * / If-Match，先检查一次，避免条件已不满足时仍合并分片
+	cond, ok := parseWriteCondition(w, r, "/"+bucket+"/"+key)
+	if !ok {
+		return
+	}
+	if !s.checkWriteCondition(w, cond, bucket, key) {
+		return
+	}
+
 	// 限制请求体大小（防止大请求攻击）
 	r.Body = http.MaxBytesReader(w, r.Body, 10*1024*1024) // 最大10MB
 
@@ -185,6 +386,10 @@ func (s *Server) handleCompleteMultipartUpload(w http.ResponseWriter, r *http.Re
 		utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, "/"+bucket+"/"+key)
 		return
 	}
+	if len(completeReq.Parts) == 0 {
+		utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, "/"+bucket+"/"+key)
+		return
+	}
 
 	// 获取已上传的分片
 	dbParts, err := s.metadata.ListParts(uploadID)
@@ -220,26 +425,64 @@ func (s *Server) handleCompleteMultipartUpload(w http.ResponseWriter, r *http.Re
 	// 按分片号排序
 	sort.Ints(partNumbers)
 
-	// 合并分片
-	etag, totalSize, err := s.filestore.MergeParts(bucket, key, uploadID, partNumbers)
+	// 除最后一个外每个分片不得小于最小分片大小，失败时保留分片以便重新上传
+	if minSize := config.Global.Storage.MinPartSize; minSize > 0 {
+		for _, n := range partNumbers[:len(partNumbers)-1] {
+			if partMap[n].Size < minSize {
+				utils.WriteError(w, utils.ErrEntityTooSmall, http.StatusBadRequest, "/"+bucket+"/"+key)
+				return
+			}
+		}
+	}
+
+	// 合并分片，失败时保留分片以便重试
+	if !s.checkFreeSpace(w, r, "/"+bucket+"/"+key) {
+		return
+	}
+	versionID := newVersionID(b)
+	staged, err := s.filestore.MergePartsStaged(bucket, key, versionID, uploadID, partNumbers)
+	if writeNoSpaceError(w, err, "/"+bucket+"/"+key) {
+		return
+	}
 	if err != nil {
 		utils.Error("merge parts failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
 		return
 	}
+	etag := staged.ETag
 
 	// 保存对象元数据
 	obj := &storage.Object{
 		Key:          key,
 		Bucket:       bucket,
-		Size:         totalSize,
+		Size:         staged.Size,
 		ETag:         etag,
 		ContentType:  upload.ContentType,
 		LastModified: time.Now().UTC(),
-		StoragePath:  s.filestore.GetStoragePath(bucket, key),
+		StoragePath:  staged.Path,
+		Metadata:     upload.Metadata,
+		VersionID:    versionID,
+
+		EncryptionNonce: staged.Nonce,
 	}
 
-	if err := s.metadata.PutObject(obj); err != nil {
+	// 条件检查、替换对象文件与写入元数据在同一写锁内完成，条件不满足时已有对象保持不变
+	if err := s.metadata.PutObjectIf(obj, cond, staged.Commit); err != nil {
+		if err == storage.ErrPreconditionFailed {
+			staged.Discard()
+			utils.WriteError(w, utils.ErrPreconditionFailed, http.StatusPreconditionFailed, "/"+bucket+"/"+key)
+			return
+		}
+		if err == storage.ErrBucketDeleted {
+			staged.Discard()
+			utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+bucket)
+			return
+		}
+		if err == storage.ErrQuotaExceeded {
+			staged.Discard()
+			writeQuotaError(w, err, "/"+bucket+"/"+key)
+			return
+		}
 		utils.Error("save object metadata failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
 		return
@@ -249,10 +492,14 @@ func (s *Server) handleCompleteMultipartUpload(w http.ResponseWriter, r *http.Re
 	s.metadata.DeleteParts(uploadID)
 	s.metadata.DeleteMultipartUpload(uploadID)
 
+	s.adminHandler.Replicate(bucket, key, storage.ReplicationOpPut)
+	s.adminHandler.NotifyObjectCreated(storage.EventObjectCreatedCompleteMultipart, obj)
+
+	setVersionIDHeader(w, obj)
 	result := CompleteMultipartUploadResult{
 		Xmlns:    "http://s3.amazonaws.com/doc/2006-03-01/",
-		Location: "/" + bucket + "/" + key,
-		Bucket:   bucket,
+		Location: "/" + displayBucket(r, bucket) + "/" + key,
+		Bucket:   displayBucket(r, bucket),
 		Key:      key,
 		ETag:     `"` + etag + `"`,
 	}
@@ -287,6 +534,83 @@ func (s *Server) handleAbortMultipartUpload(w http.ResponseWriter, r *http.Reque
 }
 
 // handleListParts 列出已上传的分片
+// handleListMultipartUploads 列出桶内未完成的多段上传，按键和上传 ID 排序分页
+func (s *Server) handleListMultipartUploads(w http.ResponseWriter, r *http.Request, bucket string) {
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+		return
+	}
+	if b == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+bucket)
+		return
+	}
+
+	query := r.URL.Query()
+	q := &storage.MultipartUploadListQuery{
+		Bucket:         bucket,
+		Prefix:         query.Get("prefix"),
+		Delimiter:      query.Get("delimiter"),
+		KeyMarker:      query.Get("key-marker"),
+		UploadIDMarker: query.Get("upload-id-marker"),
+		MaxUploads:     1000,
+	}
+	if v := query.Get("max-uploads"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, "/"+bucket)
+			return
+		}
+		// 与 S3 一致，单页最多 1000 个
+		if n < q.MaxUploads {
+			q.MaxUploads = n
+		}
+	}
+
+	// max-uploads=0 时返回空列表
+	list := &storage.MultipartUploadList{}
+	if q.MaxUploads > 0 {
+		list, err = s.metadata.ListMultipartUploads(q)
+		if err != nil {
+			utils.Error("list multipart uploads failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+			return
+		}
+	}
+
+	result := ListMultipartUploadsResult{
+		Xmlns:          "http://s3.amazonaws.com/doc/2006-03-01/",
+		Bucket:         displayBucket(r, bucket),
+		KeyMarker:      q.KeyMarker,
+		UploadIdMarker: q.UploadIDMarker,
+		Prefix:         q.Prefix,
+		Delimiter:      q.Delimiter,
+		MaxUploads:     q.MaxUploads,
+		IsTruncated:    list.IsTruncated,
+	}
+	if list.IsTruncated {
+		result.NextKeyMarker = list.NextKeyMarker
+		result.NextUploadIdMarker = list.NextUploadIDMarker
+	}
+	owner := Owner{ID: bucketOwnerID(), DisplayName: "sss-user"}
+	for _, u := range list.Uploads {
+		result.Uploads = append(result.Uploads, UploadInfo{
+			Key:          u.Key,
+			UploadId:     u.UploadID,
+			Initiator:    owner,
+			Owner:        owner,
+			StorageClass: "STANDARD",
+			Initiated:    u.Initiated.UTC().Format(time.RFC3339),
+		})
+	}
+	for _, p := range list.CommonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, CommonPrefix{Prefix: p})
+	}
+
+	utils.WriteXML(w, http.StatusOK, result)
+}
+
 func (s *Server) handleListParts(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string) {
 	// 检查多段上传是否存在
 	upload, err := s.metadata.GetMultipartUpload(uploadID)
@@ -310,7 +634,7 @@ func (s *Server) handleListParts(w http.ResponseWriter, r *http.Request, bucket,
 
 	result := ListPartsResult{
 		Xmlns:    "http://s3.amazonaws.com/doc/2006-03-01/",
-		Bucket:   bucket,
+		Bucket:   displayBucket(r, bucket),
 		Key:      key,
 		UploadId: uploadID,
 		MaxParts: 1000,
@@ -2,13 +2,16 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
 
+	"sss/internal/auth"
 	"sss/internal/config"
 	"sss/internal/storage"
 	"sss/internal/utils"
@@ -54,6 +57,13 @@ func TestHandleInitiateMultipartUpload(t *testing.T) {
 	if err := server.metadata.CreateBucket("multipart-bucket"); err != nil {
 		t.Fatalf("创建桶失败: %v", err)
 	}
+	// 只允许图片的桶
+	if err := server.metadata.CreateBucket("multipart-image-bucket"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+	if err := server.metadata.UpdateBucketContentTypes("multipart-image-bucket", storage.ContentTypeModeAllow, "image/*", false); err != nil {
+		t.Fatalf("设置内容类型限制失败: %v", err)
+	}
 
 	tests := []struct {
 		name           string
@@ -62,6 +72,26 @@ func TestHandleInitiateMultipartUpload(t *testing.T) {
 		contentType    string
 		expectedStatus int
 	}{
+		{
+			name:           "允许的内容类型",
+			bucket:         "multipart-image-bucket",
+			key:            "photo.png",
+			contentType:    "image/png",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "不允许的内容类型",
+			bucket:         "multipart-image-bucket",
+			key:            "setup.exe",
+			contentType:    "application/x-msdownload",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "未声明类型按 application/octet-stream 检查",
+			bucket:         "multipart-image-bucket",
+			key:            "unknown.bin",
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:           "初始化普通上传",
 			bucket:         "multipart-bucket",
@@ -240,6 +270,10 @@ func TestHandleCompleteMultipartUpload(t *testing.T) {
 	server, cleanup := setupMultipartTestServer(t)
 	defer cleanup()
 
+	saved := *config.Global
+	defer func() { *config.Global = saved }()
+	config.Global.Storage.MinPartSize = config.DefaultMinPartSize
+
 	// 创建测试桶
 	if err := server.metadata.CreateBucket("complete-bucket"); err != nil {
 		t.Fatalf("创建桶失败: %v", err)
@@ -259,8 +293,8 @@ func TestHandleCompleteMultipartUpload(t *testing.T) {
 		xml.Unmarshal(initRec.Body.Bytes(), &initResult)
 		uploadID := initResult.UploadId
 
-		// 通过API上传分片
-		part1Content := bytes.Repeat([]byte("A"), 1024)
+		// 通过API上传分片，最后一个分片可以小于最小分片大小
+		part1Content := bytes.Repeat([]byte("A"), config.DefaultMinPartSize)
 		part2Content := bytes.Repeat([]byte("B"), 1024)
 
 		part1Req := httptest.NewRequest(http.MethodPut, "/complete-bucket/completed-file.bin?uploadId="+uploadID+"&partNumber=1", bytes.NewReader(part1Content))
@@ -405,6 +439,197 @@ func TestHandleCompleteMultipartUpload(t *testing.T) {
 	})
 }
 
+// TestCompleteMultipartUploadPartSize 测试完成上传时的分片最小大小检查和空分片列表
+func TestCompleteMultipartUploadPartSize(t *testing.T) {
+	server, cleanup := setupMultipartTestServer(t)
+	defer cleanup()
+
+	saved := *config.Global
+	defer func() { *config.Global = saved }()
+	config.Global.Storage.MinPartSize = 10
+
+	server.metadata.CreateBucket("size-bucket")
+	initiate := func(key string) string {
+		rec := httptest.NewRecorder()
+		server.handleInitiateMultipartUpload(rec, httptest.NewRequest(http.MethodPost, "/size-bucket/"+key+"?uploads", nil), "size-bucket", key)
+		var result InitiateMultipartUploadResult
+		xml.Unmarshal(rec.Body.Bytes(), &result)
+		return result.UploadId
+	}
+	uploadPart := func(key, uploadID string, n int, content string) string {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/size-bucket/"+key+"?uploadId="+uploadID+"&partNumber="+strconv.Itoa(n), strings.NewReader(content))
+		server.handleUploadPart(rec, req, "size-bucket", key, uploadID)
+		return strings.Trim(rec.Header().Get("ETag"), `"`)
+	}
+	complete := func(key, uploadID string, etags map[int]string, order ...int) *httptest.ResponseRecorder {
+		body := "<CompleteMultipartUpload>"
+		for _, n := range order {
+			body += "<Part><PartNumber>" + strconv.Itoa(n) + "</PartNumber><ETag>\"" + etags[n] + "\"</ETag></Part>"
+		}
+		body += "</CompleteMultipartUpload>"
+		rec := httptest.NewRecorder()
+		server.handleCompleteMultipartUpload(rec, httptest.NewRequest(http.MethodPost, "/size-bucket/"+key+"?uploadId="+uploadID, strings.NewReader(body)), "size-bucket", key, uploadID)
+		return rec
+	}
+
+	t.Run("非最后分片过小", func(t *testing.T) {
+		uploadID := initiate("small.bin")
+		etags := map[int]string{
+			1: uploadPart("small.bin", uploadID, 1, "0123456789"),
+			2: uploadPart("small.bin", uploadID, 2, "short"),
+			3: uploadPart("small.bin", uploadID, 3, "x"),
+		}
+		rec := complete("small.bin", uploadID, etags, 1, 2, 3)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "EntityTooSmall") {
+			t.Fatalf("应返回 EntityTooSmall: %d %s", rec.Code, rec.Body.String())
+		}
+		if obj, _ := server.metadata.GetObject("size-bucket", "small.bin"); obj != nil {
+			t.Error("失败时不应创建对象")
+		}
+
+		// 分片保留，重新上传过小的分片后可以完成
+		etags[2] = uploadPart("small.bin", uploadID, 2, "abcdefghij")
+		if rec := complete("small.bin", uploadID, etags, 1, 2, 3); rec.Code != http.StatusOK {
+			t.Fatalf("重新上传后应完成: %d %s", rec.Code, rec.Body.String())
+		}
+		obj, _ := server.metadata.GetObject("size-bucket", "small.bin")
+		if obj == nil || obj.Size != 21 {
+			t.Errorf("对象错误: %+v", obj)
+		}
+	})
+
+	t.Run("最后分片按分片号而非请求顺序确定", func(t *testing.T) {
+		uploadID := initiate("order.bin")
+		etags := map[int]string{
+			1: uploadPart("order.bin", uploadID, 1, "0123456789"),
+			2: uploadPart("order.bin", uploadID, 2, "tail"),
+		}
+		if rec := complete("order.bin", uploadID, etags, 2, 1); rec.Code != http.StatusOK {
+			t.Errorf("最后一个分片可以过小: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("只有一个分片", func(t *testing.T) {
+		uploadID := initiate("single.bin")
+		etags := map[int]string{1: uploadPart("single.bin", uploadID, 1, "tiny")}
+		if rec := complete("single.bin", uploadID, etags, 1); rec.Code != http.StatusOK {
+			t.Errorf("单个分片不受最小大小限制: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("关闭检查", func(t *testing.T) {
+		config.Global.Storage.MinPartSize = 0
+		defer func() { config.Global.Storage.MinPartSize = 10 }()
+		uploadID := initiate("off.bin")
+		etags := map[int]string{
+			1: uploadPart("off.bin", uploadID, 1, "a"),
+			2: uploadPart("off.bin", uploadID, 2, "b"),
+		}
+		if rec := complete("off.bin", uploadID, etags, 1, 2); rec.Code != http.StatusOK {
+			t.Errorf("关闭检查后应完成: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("没有分片", func(t *testing.T) {
+		uploadID := initiate("empty.bin")
+		uploadPart("empty.bin", uploadID, 1, "0123456789")
+		rec := complete("empty.bin", uploadID, nil)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "MalformedXML") {
+			t.Errorf("应返回 MalformedXML: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+// TestCompleteMultipartUploadConditional 测试条件完成多段上传
+func TestCompleteMultipartUploadConditional(t *testing.T) {
+	server, cleanup := setupMultipartTestServer(t)
+	defer cleanup()
+
+	bucket, key := "cond-bucket", "target.bin"
+	if err := server.metadata.CreateBucket(bucket); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+
+	// startUpload 初始化上传并上传一个分片，返回上传 ID 和完成请求体
+	startUpload := func(content string) (string, string) {
+		initRec := httptest.NewRecorder()
+		server.handleInitiateMultipartUpload(initRec, httptest.NewRequest(http.MethodPost, "/"+bucket+"/"+key+"?uploads", nil), bucket, key)
+		var initResult InitiateMultipartUploadResult
+		xml.Unmarshal(initRec.Body.Bytes(), &initResult)
+		uploadID := initResult.UploadId
+
+		partRec := httptest.NewRecorder()
+		partReq := httptest.NewRequest(http.MethodPut, "/"+bucket+"/"+key+"?uploadId="+uploadID+"&partNumber=1", strings.NewReader(content))
+		server.handleUploadPart(partRec, partReq, bucket, key, uploadID)
+		if partRec.Code != http.StatusOK {
+			t.Fatalf("上传分片失败: %d", partRec.Code)
+		}
+		body := `<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>` + partRec.Header().Get("ETag") + `</ETag></Part></CompleteMultipartUpload>`
+		return uploadID, body
+	}
+	complete := func(uploadID, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/"+bucket+"/"+key+"?uploadId="+uploadID, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		server.handleCompleteMultipartUpload(rec, req, bucket, key, uploadID)
+		return rec
+	}
+	readObject := func() string {
+		obj, _ := server.metadata.GetObject(bucket, key)
+		if obj == nil {
+			return ""
+		}
+		data, _ := os.ReadFile(obj.StoragePath)
+		return string(data)
+	}
+
+	uploadID, body := startUpload("first")
+	if rec := complete(uploadID, body, map[string]string{"If-None-Match": "*"}); rec.Code != http.StatusOK {
+		t.Fatalf("目标不存在时应成功: %d %s", rec.Code, rec.Body.String())
+	}
+	original, _ := server.metadata.GetObject(bucket, key)
+
+	t.Run("If-None-Match目标已存在", func(t *testing.T) {
+		uploadID, body := startUpload("second")
+		rec := complete(uploadID, body, map[string]string{"If-None-Match": "*"})
+		if rec.Code != http.StatusPreconditionFailed {
+			t.Fatalf("应返回 412: %d", rec.Code)
+		}
+		if got := readObject(); got != "first" {
+			t.Errorf("已有对象不应被覆盖: %q", got)
+		}
+		if upload, _ := server.metadata.GetMultipartUpload(uploadID); upload == nil {
+			t.Error("条件不满足时应保留上传以便重试")
+		}
+	})
+
+	t.Run("If-Match", func(t *testing.T) {
+		uploadID, body := startUpload("third")
+		if rec := complete(uploadID, body, map[string]string{"If-Match": `"mismatch"`}); rec.Code != http.StatusPreconditionFailed {
+			t.Fatalf("ETag 不一致应返回 412: %d", rec.Code)
+		}
+		if got := readObject(); got != "first" {
+			t.Errorf("已有对象不应被覆盖: %q", got)
+		}
+		if rec := complete(uploadID, body, map[string]string{"If-Match": `"` + original.ETag + `"`}); rec.Code != http.StatusOK {
+			t.Fatalf("ETag 一致应成功: %d %s", rec.Code, rec.Body.String())
+		}
+		if got := readObject(); got != "third" {
+			t.Errorf("对象应已更新: %q", got)
+		}
+	})
+
+	t.Run("不支持的If-None-Match", func(t *testing.T) {
+		uploadID, body := startUpload("fourth")
+		if rec := complete(uploadID, body, map[string]string{"If-None-Match": `"abc"`}); rec.Code != http.StatusNotImplemented {
+			t.Errorf("应返回 501: %d", rec.Code)
+		}
+	})
+}
+
 // TestHandleAbortMultipartUpload 测试中止多部分上传
 func TestHandleAbortMultipartUpload(t *testing.T) {
 	server, cleanup := setupMultipartTestServer(t)
@@ -552,6 +777,252 @@ func TestHandleListParts(t *testing.T) {
 	})
 }
 
+// TestMultipartUploadLimits 测试未完成上传的分片数和桶内上传数上限
+func TestMultipartUploadLimits(t *testing.T) {
+	server, cleanup := setupMultipartTestServer(t)
+	defer cleanup()
+
+	saved := *config.Global
+	defer func() { *config.Global = saved }()
+	config.Global.Storage.MaxUploadParts = 2
+	config.Global.Storage.MaxIncompleteUploads = 2
+
+	server.metadata.CreateBucket("limit-bucket")
+	initiate := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/limit-bucket/"+key+"?uploads", nil)
+		rec := httptest.NewRecorder()
+		server.handleInitiateMultipartUpload(rec, req, "limit-bucket", key)
+		return rec
+	}
+	var result InitiateMultipartUploadResult
+	xml.Unmarshal(initiate("a.bin").Body.Bytes(), &result)
+	uploadID := result.UploadId
+
+	t.Run("超出桶内未完成上传数返回429", func(t *testing.T) {
+		if rec := initiate("b.bin"); rec.Code != http.StatusOK {
+			t.Fatalf("第二个上传应成功: %d", rec.Code)
+		}
+		rec := initiate("c.bin")
+		if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "SlowDown") {
+			t.Errorf("期望 429 SlowDown: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	uploadPart := func(partNumber int) int {
+		req := httptest.NewRequest(http.MethodPut, "/limit-bucket/a.bin?partNumber="+strconv.Itoa(partNumber)+"&uploadId="+uploadID, strings.NewReader("data"))
+		rec := httptest.NewRecorder()
+		server.handleUploadPart(rec, req, "limit-bucket", "a.bin", uploadID)
+		return rec.Code
+	}
+
+	t.Run("超出分片数返回403", func(t *testing.T) {
+		if uploadPart(1) != http.StatusOK || uploadPart(2) != http.StatusOK {
+			t.Fatal("上限内的分片应上传成功")
+		}
+		if code := uploadPart(3); code != http.StatusForbidden {
+			t.Errorf("期望 403，实际 %d", code)
+		}
+		if code := uploadPart(2); code != http.StatusOK {
+			t.Errorf("重传已有分片应成功，实际 %d", code)
+		}
+	})
+
+	t.Run("统计未完成上传", func(t *testing.T) {
+		stats, err := server.metadata.GetStorageStats()
+		if err != nil {
+			t.Fatalf("获取统计失败: %v", err)
+		}
+		if stats.IncompleteUploads != 2 || stats.IncompleteParts != 2 || stats.IncompleteSize != 8 {
+			t.Errorf("未完成上传统计错误: %d %d %d", stats.IncompleteUploads, stats.IncompleteParts, stats.IncompleteSize)
+		}
+		if len(stats.BucketStats) != 1 || stats.BucketStats[0].IncompleteUploads != 2 {
+			t.Errorf("桶未完成上传数错误: %+v", stats.BucketStats)
+		}
+	})
+}
+
+// TestHandleUploadPartCopy 测试从已有对象按范围复制分片并完成上传
+func TestHandleUploadPartCopy(t *testing.T) {
+	server, cleanup := setupMultipartTestServer(t)
+	defer cleanup()
+
+	saved := *config.Global
+	defer func() { *config.Global = saved }()
+	config.Global.Storage.MinPartSize = 0
+
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	server.metadata.CreateBucket("part-copy")
+	path, etag, err := server.filestore.PutObject("part-copy", "source.txt", bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("上传源对象失败: %v", err)
+	}
+	server.metadata.PutObject(&storage.Object{Bucket: "part-copy", Key: "source.txt", Size: int64(len(content)), ETag: etag, StoragePath: path})
+
+	req := httptest.NewRequest(http.MethodPost, "/part-copy/dest.txt?uploads", nil)
+	rec := httptest.NewRecorder()
+	server.handleInitiateMultipartUpload(rec, req, "part-copy", "dest.txt")
+	var initResult InitiateMultipartUploadResult
+	xml.Unmarshal(rec.Body.Bytes(), &initResult)
+	uploadID := initResult.UploadId
+
+	copyPart := func(partNumber int, source, copyRange string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/part-copy/dest.txt?partNumber="+strconv.Itoa(partNumber)+"&uploadId="+uploadID, nil)
+		req = asAdmin(t, req)
+		req.Header.Set("x-amz-copy-source", source)
+		if copyRange != "" {
+			req.Header.Set("x-amz-copy-source-range", copyRange)
+		}
+		rec := httptest.NewRecorder()
+		server.handleUploadPart(rec, req, "part-copy", "dest.txt", uploadID)
+		return rec
+	}
+
+	var etags []string
+	for i, copyRange := range []string{"bytes=0-9", "bytes=10-35"} {
+		rec := copyPart(i+1, "/part-copy/source.txt", copyRange)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("复制分片 %d 失败: %d %s", i+1, rec.Code, rec.Body.String())
+		}
+		var result CopyPartResult
+		if err := xml.Unmarshal(rec.Body.Bytes(), &result); err != nil || result.ETag == "" || result.LastModified == "" {
+			t.Fatalf("CopyPartResult 错误: %v %s", err, rec.Body.String())
+		}
+		etags = append(etags, result.ETag)
+	}
+	parts, _ := server.metadata.ListParts(uploadID)
+	if len(parts) != 2 || parts[0].Size != 10 || parts[1].Size != 26 {
+		t.Fatalf("分片大小错误: %+v", parts)
+	}
+
+	t.Run("无效范围和源不存在", func(t *testing.T) {
+		if rec := copyPart(3, "/part-copy/source.txt", "bytes=30-40"); rec.Code != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("越界范围期望 416，实际 %d", rec.Code)
+		}
+		if rec := copyPart(3, "/part-copy/missing.txt", ""); rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "NoSuchKey") {
+			t.Errorf("源不存在期望 404 NoSuchKey: %d %s", rec.Code, rec.Body.String())
+		}
+		if parts, _ := server.metadata.ListParts(uploadID); len(parts) != 2 {
+			t.Errorf("失败的复制不应保存分片: %d", len(parts))
+		}
+	})
+
+	t.Run("须有源桶的读权限", func(t *testing.T) {
+		server.metadata.CreateBucket("part-copy-private")
+		path, etag, _ := server.filestore.PutObject("part-copy-private", "secret.txt", strings.NewReader("secret"), 6)
+		server.metadata.PutObject(&storage.Object{Bucket: "part-copy-private", Key: "secret.txt", Size: 6, ETag: etag, StoragePath: path})
+
+		key, _ := server.metadata.CreateAPIKey("part copy")
+		server.metadata.SetAPIKeyPermission(&storage.APIKeyPermission{AccessKeyID: key.AccessKeyID, BucketName: "part-copy", CanRead: true, CanWrite: true})
+		auth.InitAPIKeyCache(server.metadata)
+		copyAs := func(source string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPut, "/part-copy/dest.txt?partNumber=3&uploadId="+uploadID, nil)
+			req = req.WithContext(context.WithValue(req.Context(), ContextKeyAccessKeyID, key.AccessKeyID))
+			req.Header.Set("x-amz-copy-source", source)
+			rec := httptest.NewRecorder()
+			server.handleUploadPart(rec, req, "part-copy", "dest.txt", uploadID)
+			return rec
+		}
+
+		if rec := copyAs("/part-copy-private/secret.txt"); rec.Code != http.StatusForbidden {
+			t.Errorf("无源桶读权限期望 403，实际 %d %s", rec.Code, rec.Body.String())
+		}
+		if parts, _ := server.metadata.ListParts(uploadID); len(parts) != 2 {
+			t.Errorf("被拒绝的复制不应保存分片: %d", len(parts))
+		}
+
+		server.metadata.SetAPIKeyPermission(&storage.APIKeyPermission{AccessKeyID: key.AccessKeyID, BucketName: "part-copy-private", CanRead: true})
+		auth.ReloadAPIKeyCache()
+		if rec := copyAs("/part-copy-private/secret.txt"); rec.Code != http.StatusOK {
+			t.Errorf("授予读权限后应可复制: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("完成后内容与源一致", func(t *testing.T) {
+		body := `<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>` + etags[0] + `</ETag></Part><Part><PartNumber>2</PartNumber><ETag>` + etags[1] + `</ETag></Part></CompleteMultipartUpload>`
+		req := httptest.NewRequest(http.MethodPost, "/part-copy/dest.txt?uploadId="+uploadID, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		server.handleCompleteMultipartUpload(rec, req, "part-copy", "dest.txt", uploadID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("完成上传失败: %d %s", rec.Code, rec.Body.String())
+		}
+		obj, _ := server.metadata.GetObject("part-copy", "dest.txt")
+		data, _ := os.ReadFile(obj.StoragePath)
+		if !bytes.Equal(data, content) {
+			t.Errorf("合并内容错误: %q", data)
+		}
+	})
+}
+
+// TestHandleListMultipartUploads 测试列出未完成的多段上传及分页
+func TestHandleListMultipartUploads(t *testing.T) {
+	server, cleanup := setupMultipartTestServer(t)
+	defer cleanup()
+
+	server.metadata.CreateBucket("list-uploads")
+	for _, key := range []string{"b.bin", "a.bin", "b.bin"} {
+		req := httptest.NewRequest(http.MethodPost, "/list-uploads/"+key+"?uploads", nil)
+		server.handleInitiateMultipartUpload(httptest.NewRecorder(), req, "list-uploads", key)
+	}
+	list := func(query string) (*httptest.ResponseRecorder, ListMultipartUploadsResult) {
+		req := httptest.NewRequest(http.MethodGet, "/list-uploads?uploads"+query, nil)
+		rec := httptest.NewRecorder()
+		server.handleListMultipartUploads(rec, req, "list-uploads")
+		var result ListMultipartUploadsResult
+		xml.Unmarshal(rec.Body.Bytes(), &result)
+		return rec, result
+	}
+
+	t.Run("列出全部上传", func(t *testing.T) {
+		rec, result := list("")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("期望 200，实际 %d: %s", rec.Code, rec.Body.String())
+		}
+		if len(result.Uploads) != 3 || result.IsTruncated || result.MaxUploads != 1000 {
+			t.Fatalf("列表错误: %+v", result)
+		}
+		u := result.Uploads[0]
+		if u.Key != "a.bin" || u.UploadId == "" || u.Initiated == "" || u.StorageClass != "STANDARD" {
+			t.Errorf("上传信息错误: %+v", u)
+		}
+		if result.Uploads[1].Key != "b.bin" || result.Uploads[2].Key != "b.bin" || result.Uploads[1].UploadId >= result.Uploads[2].UploadId {
+			t.Errorf("同一键的上传应按上传 ID 排序: %+v", result.Uploads)
+		}
+	})
+
+	t.Run("按标记分页", func(t *testing.T) {
+		var seen []string
+		query := "&max-uploads=2"
+		for page := 0; page < 3; page++ {
+			_, result := list(query)
+			for _, u := range result.Uploads {
+				seen = append(seen, u.UploadId)
+			}
+			if !result.IsTruncated {
+				break
+			}
+			query = "&max-uploads=2&key-marker=" + result.NextKeyMarker + "&upload-id-marker=" + result.NextUploadIdMarker
+		}
+		if len(seen) != 3 || seen[0] == seen[1] || seen[1] == seen[2] {
+			t.Errorf("分页结果错误: %v", seen)
+		}
+	})
+
+	t.Run("参数错误和桶不存在", func(t *testing.T) {
+		if rec, _ := list("&max-uploads=abc"); rec.Code != http.StatusBadRequest {
+			t.Errorf("非法 max-uploads 期望 400，实际 %d", rec.Code)
+		}
+		if _, result := list("&max-uploads=0"); len(result.Uploads) != 0 || result.IsTruncated {
+			t.Errorf("max-uploads=0 应返回空列表: %+v", result)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/missing?uploads", nil)
+		rec := httptest.NewRecorder()
+		server.handleListMultipartUploads(rec, req, "missing")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("桶不存在期望 404，实际 %d", rec.Code)
+		}
+	})
+}
+
 // TestMultipartUploadCompleteFlow 测试多部分上传完整流程
 func TestMultipartUploadCompleteFlow(t *testing.T) {
 	server, cleanup := setupMultipartTestServer(t)
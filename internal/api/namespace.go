@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"sss/internal/auth"
+)
+
+// ContextKeyNamespace 存储请求所用 API Key 的桶命名空间
+const ContextKeyNamespace contextKey = "namespace"
+
+// withKeyNamespace 解析请求声明的 API Key 的命名空间并存入上下文
+// 命名空间在签名校验前确定，路径中的桶名随后映射为实际桶名；签名仍按客户端看到的原始路径校验
+func withKeyNamespace(r *http.Request) (*http.Request, string) {
+	ns := auth.KeyNamespace(auth.CredentialAccessKey(r))
+	if ns == "" {
+		return r, ""
+	}
+	return r.WithContext(context.WithValue(r.Context(), ContextKeyNamespace, ns)), ns
+}
+
+// requestNamespace 返回请求的桶命名空间，未限制时为空
+func requestNamespace(r *http.Request) string {
+	ns, _ := r.Context().Value(ContextKeyNamespace).(string)
+	return ns
+}
+
+// displayBucket 返回客户端看到的桶名，去掉命名空间前缀
+func displayBucket(r *http.Request, bucket string) string {
+	return strings.TrimPrefix(bucket, requestNamespace(r))
+}
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -27,20 +30,58 @@ func (s *Server) handleGetObject(w http.ResponseWriter, r *http.Request, bucket,
 		return
 	}
 
-	// 获取对象元数据
-	obj, err := s.metadata.GetObject(bucket, key)
+	// 获取对象元数据，本地不存在时按桶配置回源
+	obj, err := s.getObjectForRequest(r, b, bucket, key)
+	if errors.Is(err, errDeleteMarker) {
+		w.Header().Set("x-amz-delete-marker", "true")
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "/"+bucket+"/"+key)
+		return
+	}
 	if err != nil {
+		if s3err, status, ok := originErrorStatus(err); ok {
+			utils.Warn("fetch object from origin failed", "bucket", bucket, "key", key, "error", err)
+			utils.WriteError(w, s3err, status, "/"+bucket+"/"+key)
+			return
+		}
 		utils.Error("get object metadata failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
 		return
 	}
 	if obj == nil {
+		if r.URL.Query().Get("versionId") != "" {
+			utils.WriteError(w, utils.ErrNoSuchVersion, http.StatusNotFound, "/"+bucket+"/"+key)
+			return
+		}
 		utils.WriteError(w, utils.ErrNoSuchKey, http.StatusNotFound, "/"+bucket+"/"+key)
 		return
 	}
+	if b.ReadAgeExceeded(obj, time.Now()) {
+		utils.WriteError(w, utils.ErrObjectReadAgeExceeded, http.StatusGone, "/"+bucket+"/"+key)
+		return
+	}
+	switch readConditionStatus(r, obj) {
+	case http.StatusNotModified:
+		writeNotModified(w, obj)
+		return
+	case http.StatusPreconditionFailed:
+		utils.WriteError(w, utils.ErrPreconditionFailed, http.StatusPreconditionFailed, "/"+bucket+"/"+key)
+		return
+	}
+
+	// 图片按需缩放，未开启时忽略查询参数
+	if b.ImageTransform {
+		t, params, err := matchTransform(obj.ContentType, r.URL.Query())
+		if err != nil {
+			utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, "/"+bucket+"/"+key)
+			return
+		}
+		if t != nil && s.serveTransformed(w, b, obj, t, params) {
+			return
+		}
+	}
 
 	// 打开文件
-	file, err := s.filestore.GetObject(obj.StoragePath)
+	file, err := s.filestore.OpenObject(obj)
 	if err != nil {
 		utils.Error("get object file failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
@@ -48,38 +89,15 @@ func (s *Server) handleGetObject(w http.ResponseWriter, r *http.Request, bucket,
 	}
 	defer file.Close()
 
-	// 处理 Range 请求
-	var start, end int64 = 0, obj.Size - 1
+	// 处理 Range 请求，If-Range 与当前对象不符时忽略 Range 返回完整对象
+	ranges := []byteRange{{start: 0, end: obj.Size - 1}}
 	rangeHeader := r.Header.Get("Range")
+	if rangeHeader != "" && !ifRangeMatches(r, obj) {
+		rangeHeader = ""
+	}
 	if rangeHeader != "" && obj.Size > 0 {
-		if strings.HasPrefix(rangeHeader, "bytes=") {
-			rangeSpec := strings.TrimPrefix(rangeHeader, "bytes=")
-			parts := strings.Split(rangeSpec, "-")
-			if len(parts) == 2 {
-				if parts[0] != "" {
-					parsedStart, err := strconv.ParseInt(parts[0], 10, 64)
-					if err == nil && parsedStart >= 0 {
-						start = parsedStart
-					}
-				}
-				if parts[1] != "" {
-					parsedEnd, err := strconv.ParseInt(parts[1], 10, 64)
-					if err == nil && parsedEnd >= 0 {
-						end = parsedEnd
-					}
-				} else if parts[0] != "" {
-					end = obj.Size - 1
-				}
-			}
-		}
-		// 验证范围有效性
-		if start < 0 {
-			start = 0
-		}
-		if end >= obj.Size {
-			end = obj.Size - 1
-		}
-		if start > end {
+		var ok bool
+		if ranges, ok = parseRangeHeader(rangeHeader, obj.Size); !ok {
 			// 无效范围，返回416
 			w.Header().Set("Content-Range", "bytes */"+strconv.FormatInt(obj.Size, 10))
 			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
@@ -88,34 +106,39 @@ func (s *Server) handleGetObject(w http.ResponseWriter, r *http.Request, bucket,
 	}
 
 	// 设置响应头
-	w.Header().Set("Content-Type", obj.ContentType)
-	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	setObjectHeaders(w, b, obj)
+	// response-content-disposition 覆盖下载文件名（如预签名下载链接），非 ASCII 文件名按 RFC 5987 编码
+	if v := r.URL.Query().Get("response-content-disposition"); v != "" {
+		w.Header().Set("Content-Disposition", utils.NormalizeContentDisposition(v))
+	}
 	w.Header().Set("ETag", `"`+obj.ETag+`"`)
 	w.Header().Set("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
 	w.Header().Set("Accept-Ranges", "bytes")
 
+	// 多个范围：返回 multipart/byteranges
+	if len(ranges) > 1 {
+		writeByteRanges(w, r, file, obj, ranges)
+		return
+	}
+	br := ranges[0]
+	w.Header().Set("Content-Type", obj.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(br.length(), 10))
+
 	if rangeHeader != "" {
 		// Range 请求：返回 206 Partial Content
-		w.Header().Set("Content-Range", "bytes "+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(end, 10)+"/"+strconv.FormatInt(obj.Size, 10))
+		w.Header().Set("Content-Range", br.contentRange(obj.Size))
 		w.WriteHeader(http.StatusPartialContent)
-		if start > 0 {
-			if _, err := file.Seek(start, 0); err != nil {
+		if br.start > 0 {
+			if _, err := file.Seek(br.start, 0); err != nil {
 				utils.Error("seek file failed", "error", err)
 				return
 			}
 		}
-		if _, err := io.CopyN(w, file, end-start+1); err != nil {
-			// 客户端可能已断开连接，只记录日志
-			utils.Debug("copy to response failed", "error", err)
-		}
 	} else {
 		// 普通请求：返回 200 OK
 		w.WriteHeader(http.StatusOK)
-		if _, err := io.Copy(w, file); err != nil {
-			// 客户端可能已断开连接，只记录日志
-			utils.Debug("copy to response failed", "error", err)
-		}
 	}
+	copyObjectBody(w, r, file, obj, br.length())
 }
 
 // handlePutObject 上传对象
@@ -128,13 +151,73 @@ func (s *Server) handlePutObject(w http.ResponseWriter, r *http.Request, bucket,
 		return
 	}
 	if b == nil {
-		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+bucket)
+		// 默认严格模式返回 404，开启自动建桶时隐式创建
+		if !config.Global.Storage.AutoCreate {
+			utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+bucket)
+			return
+		}
+		created, ok := s.autoCreateBucket(w, r, bucket)
+		if !ok {
+			return
+		}
+		b, bucket = created, created.Name
+	}
+
+	if !checkReadOnly(w, b, "/"+bucket+"/"+key) {
+		return
+	}
+
+	// 检查桶保留键
+	if b.DeniesKey(key) {
+		utils.WriteError(w, utils.ErrKeyNotAllowed, http.StatusBadRequest, "/"+bucket+"/"+key)
+		return
+	}
+	// aws-chunked 流式上传只保存解码后的真实数据
+	if !decodeAWSChunked(w, r, "/"+bucket+"/"+key) {
+		return
+	}
+	// 幂等重试先于不可变窗口检查，避免首次写入后重试被拒绝
+	idemKey := idempotencyKey(r)
+	if idemKey != "" && s.replayIdempotentPut(w, r, bucket, key, idemKey) {
+		return
+	}
+	if !s.checkImmutable(w, b, bucket, key, nil) {
+		return
+	}
+	// 条件写入：If-None-Match: * 只在对象不存在时创建，If-Match 要求 ETag 一致，先检查一次避免无谓写入
+	cond, ok := parseWriteCondition(w, r, "/"+bucket+"/"+key)
+	if !ok {
+		return
+	}
+	if !s.checkWriteCondition(w, cond, bucket, key) {
+		return
+	}
+	expiresAt, err := parseExpiresAt(r, time.Now())
+	if err != nil {
+		utils.WriteError(w, utils.ErrInvalidExpiresAt, http.StatusBadRequest, "/"+bucket+"/"+key)
+		return
+	}
+	if !checkMetadataSize(w, r, "/"+bucket+"/"+key) {
+		return
+	}
+	// 占位模式下以 / 结尾的键只能是零字节的文件夹占位对象
+	folderMarker := config.Global.Storage.FolderPlaceholders() && strings.HasSuffix(key, "/")
+	if folderMarker && r.ContentLength > 0 {
+		utils.WriteError(w, utils.ErrFolderMarkerNotEmpty, http.StatusBadRequest, "/"+bucket+"/"+key)
 		return
 	}
 
 	// 验证文件大小限制
 	query := r.URL.Query()
 
+	// 未知长度（分块传输）时以最小的正数限制作为流式写入硬上限
+	var maxSize int64
+	limit := func(n int64) {
+		if n > 0 && (maxSize == 0 || n < maxSize) {
+			maxSize = n
+		}
+	}
+
 	// 1. 检查预签名URL的大小限制（如果有）
 	if maxContentLengthStr := query.Get("X-Amz-Max-Content-Length"); maxContentLengthStr != "" {
 		maxContentLength, err := strconv.ParseInt(maxContentLengthStr, 10, 64)
@@ -143,6 +226,7 @@ func (s *Server) handlePutObject(w http.ResponseWriter, r *http.Request, bucket,
 				utils.WriteError(w, utils.ErrEntityTooLarge, http.StatusBadRequest, "/"+bucket+"/"+key)
 				return
 			}
+			limit(maxContentLength)
 		}
 	}
 
@@ -153,6 +237,7 @@ func (s *Server) handlePutObject(w http.ResponseWriter, r *http.Request, bucket,
 			return
 		}
 	}
+	limit(config.Global.Storage.MaxUploadSize)
 
 	// 3. 检查全局最大对象大小限制
 	if config.Global.Storage.MaxObjectSize > 0 && r.ContentLength > 0 {
@@ -161,6 +246,7 @@ func (s *Server) handlePutObject(w http.ResponseWriter, r *http.Request, bucket,
 			return
 		}
 	}
+	limit(config.Global.Storage.MaxObjectSize)
 
 	// 获取 Content-Type
 	contentType := r.Header.Get("Content-Type")
@@ -176,38 +262,158 @@ func (s *Server) handlePutObject(w http.ResponseWriter, r *http.Request, bucket,
 		}
 	}
 
-	// 存储文件
-	storagePath, etag, err := s.filestore.PutObject(bucket, key, r.Body, r.ContentLength)
+	// 5. 验证桶的内容类型限制
+	body, err := b.CheckContentType(contentType, r.Body)
+	if err != nil {
+		if err == storage.ErrContentTypeNotAllowed {
+			utils.WriteError(w, utils.ErrContentTypeNotAllowed, http.StatusBadRequest, "/"+bucket+"/"+key)
+		} else {
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
+		}
+		return
+	}
+
+	// 6. 写入时校验 Content-MD5 和 x-amz-content-sha256，不一致时不保存对象
+	body, ok = verifyPayloadDigest(w, r, body, "/"+bucket+"/"+key)
+	if !ok {
+		return
+	}
+
+	// 7. 检查数据盘可用空间和桶配额
+	if !s.checkFreeSpace(w, r, "/"+bucket+"/"+key) {
+		return
+	}
+	if !s.checkBucketQuota(w, r, b, key, "/"+bucket+"/"+key) {
+		return
+	}
+
+	// 开启覆盖审计时记录旧版本信息
+	var previous *storage.Object
+	if config.Global.Security.AuditOverwrite {
+		previous, _ = s.metadata.GetObject(bucket, key)
+	}
+
+	// 写入临时文件，启用版本控制时每个版本写入独立的文件
+	versionID := newVersionID(b)
+	staged, err := s.filestore.PutObjectStaged(bucket, key, versionID, body, maxSize)
+	if err == storage.ErrObjectTooLarge {
+		utils.WriteError(w, utils.ErrEntityTooLarge, http.StatusBadRequest, "/"+bucket+"/"+key)
+		return
+	}
+	if writeDigestError(w, err, "/"+bucket+"/"+key) {
+		return
+	}
+	if writeNoSpaceError(w, err, "/"+bucket+"/"+key) {
+		return
+	}
 	if err != nil {
 		utils.Error("store object failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
 		return
 	}
+	etag := staged.ETag
+	if folderMarker {
+		// 未知长度上传只能在写入后检查
+		if staged.Size > 0 {
+			staged.Discard()
+			utils.WriteError(w, utils.ErrFolderMarkerNotEmpty, http.StatusBadRequest, "/"+bucket+"/"+key)
+			return
+		}
+		contentType = storage.FolderMarkerContentType
+	}
 
 	// 保存元数据
 	obj := &storage.Object{
 		Key:          key,
 		Bucket:       bucket,
-		Size:         r.ContentLength,
+		Size:         staged.Size,
 		ETag:         etag,
 		ContentType:  contentType,
 		LastModified: time.Now().UTC(),
-		StoragePath:  storagePath,
+		StoragePath:  staged.Path,
+		Headers:      b.MergeDefaultHeaders(objectHeadersFromRequest(r)),
+		Metadata:     userMetadataFromRequest(r.Header),
+		ExpiresAt:    expiresAt,
+		VersionID:    versionID,
+
+		EncryptionNonce: staged.Nonce,
 	}
 
-	if err := s.metadata.PutObject(obj); err != nil {
+	// 条件检查、替换对象文件与写入元数据在同一写锁内完成，条件不满足时已有对象保持不变
+	if err := s.metadata.PutObjectIf(obj, cond, staged.Commit); err != nil {
+		staged.Discard()
+		if err == storage.ErrPreconditionFailed {
+			utils.WriteError(w, utils.ErrPreconditionFailed, http.StatusPreconditionFailed, "/"+bucket+"/"+key)
+			return
+		}
+		if writeQuotaError(w, err, "/"+bucket+"/"+key) {
+			return
+		}
+		if err == storage.ErrBucketDeleted {
+			utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+bucket)
+			return
+		}
 		utils.Error("save object metadata failed", "error", err)
-		s.filestore.DeleteObject(storagePath) // 回滚
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
 		return
 	}
 
+	s.adminHandler.Replicate(bucket, key, storage.ReplicationOpPut)
+	s.adminHandler.NotifyObjectCreated(storage.EventObjectCreatedPut, obj)
+	if idemKey != "" {
+		s.recordIdempotentPut(r, bucket, key, idemKey, obj)
+	}
+
+	if previous != nil {
+		accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+		s.adminHandler.Audit(r, storage.AuditActionObjectOverwrite, accessKeyID, bucket+"/"+key, true, map[string]interface{}{
+			"old_etag": previous.ETag,
+			"old_size": previous.Size,
+			"new_etag": etag,
+			"new_size": obj.Size,
+		})
+	}
+
+	setVersionIDHeader(w, obj)
 	w.Header().Set("ETag", `"`+etag+`"`)
 	w.WriteHeader(http.StatusOK)
 }
 
 // handleDeleteObject 删除对象
 func (s *Server) handleDeleteObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket)
+		return
+	}
+	if !checkReadOnly(w, b, "/"+bucket+"/"+key) {
+		return
+	}
+
+	// 指定版本时永久删除该版本
+	if versionID := r.URL.Query().Get("versionId"); versionID != "" {
+		v, err := s.deleteObjectVersion(b, key, versionID)
+		if errors.Is(err, errVersionImmutable) {
+			utils.WriteError(w, utils.ErrObjectImmutable, http.StatusForbidden, "/"+bucket+"/"+key)
+			return
+		}
+		if err != nil {
+			utils.Error("delete object version failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
+			return
+		}
+		if v == nil && versionID != storage.NullVersionID {
+			utils.WriteError(w, utils.ErrNoSuchVersion, http.StatusNotFound, "/"+bucket+"/"+key)
+			return
+		}
+		if v != nil {
+			setDeleteVersionHeaders(w, versionID, v.DeleteMarker)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	// 获取对象元数据
 	obj, err := s.metadata.GetObject(bucket, key)
 	if err != nil {
@@ -216,7 +422,69 @@ func (s *Server) handleDeleteObject(w http.ResponseWriter, r *http.Request, buck
 		return
 	}
 
-	if obj != nil {
+	if obj != nil && !s.checkImmutable(w, b, bucket, key, obj) {
+		return
+	}
+
+	// 条件删除：If-Match / x-amz-if-match-size
+	ifMatch := unquoteETag(r.Header.Get("If-Match"))
+	matchSize := int64(-1)
+	if v := r.Header.Get("x-amz-if-match-size"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, "/"+bucket+"/"+key)
+			return
+		}
+		matchSize = n
+	}
+	if ifMatch == "*" {
+		ifMatch = ""
+	}
+
+	// 启用版本控制的桶写入删除标记，当前版本转为历史版本，文件保留
+	if b != nil && b.VersioningEnabled {
+		markerID := utils.GenerateID(16)
+		if obj == nil {
+			// 与未启用版本控制时一致，对象不存在时不检查条件
+			ifMatch, matchSize = "", -1
+		}
+		created, err := s.metadata.PutDeleteMarker(bucket, key, markerID, ifMatch, matchSize)
+		if err != nil {
+			utils.Error("put delete marker failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
+			return
+		}
+		if !created {
+			utils.WriteError(w, utils.ErrPreconditionFailed, http.StatusPreconditionFailed, "/"+bucket+"/"+key)
+			return
+		}
+		if obj != nil {
+			s.adminHandler.Replicate(bucket, key, storage.ReplicationOpDelete)
+		}
+		s.adminHandler.NotifyObjectRemoved(storage.EventObjectRemovedDeleteMarkerCreated, bucket, key, markerID)
+		setDeleteVersionHeaders(w, markerID, true)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if obj != nil && (ifMatch != "" || matchSize >= 0) {
+		// 检查与删除在同一条 SQL 中完成，避免读取后对象被覆盖
+		deleted, err := s.metadata.DeleteObjectIfMatch(bucket, key, ifMatch, matchSize)
+		if err != nil {
+			utils.Error("delete object metadata failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
+			return
+		}
+		if !deleted {
+			utils.WriteError(w, utils.ErrPreconditionFailed, http.StatusPreconditionFailed, "/"+bucket+"/"+key)
+			return
+		}
+		if err := s.filestore.DeleteObject(obj.StoragePath); err != nil {
+			utils.Warn("delete object file failed", "error", err)
+		}
+		s.adminHandler.Replicate(bucket, key, storage.ReplicationOpDelete)
+		s.adminHandler.NotifyObjectRemoved(storage.EventObjectRemovedDelete, bucket, key, "")
+	} else if obj != nil {
 		// 删除文件
 		if err := s.filestore.DeleteObject(obj.StoragePath); err != nil {
 			utils.Warn("delete object file failed", "error", err)
@@ -228,26 +496,316 @@ func (s *Server) handleDeleteObject(w http.ResponseWriter, r *http.Request, buck
 			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
 			return
 		}
+		s.adminHandler.Replicate(bucket, key, storage.ReplicationOpDelete)
+		s.adminHandler.NotifyObjectRemoved(storage.EventObjectRemovedDelete, bucket, key, "")
 	}
 
 	// S3 删除不存在的对象也返回 204
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// handleCopyObject 复制对象
-func (s *Server) handleCopyObject(w http.ResponseWriter, r *http.Request, destBucket, destKey string) {
+// checkImmutable 检查已有对象是否处于桶的不可变窗口内，是则返回 403 及剩余时间
+// obj 为 nil 时按需查询；管理后台操作不经过此检查
+func (s *Server) checkImmutable(w http.ResponseWriter, b *storage.Bucket, bucket, key string, obj *storage.Object) bool {
+	if b == nil || b.ImmutableMinutes <= 0 {
+		return true
+	}
+	if obj == nil {
+		var err error
+		if obj, err = s.metadata.GetObject(bucket, key); err != nil {
+			utils.Error("get object metadata failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
+			return false
+		}
+	}
+	remaining := b.ImmutableRemaining(obj, time.Now())
+	if remaining <= 0 {
+		return true
+	}
+	seconds := int64(math.Ceil(remaining.Seconds()))
+	e := utils.ErrObjectImmutable
+	e.Message = fmt.Sprintf("%s, %d seconds remaining", e.Message, seconds)
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	utils.WriteError(w, e, http.StatusForbidden, "/"+bucket+"/"+key)
+	return false
+}
+
+// checkReadOnly 检查桶是否只读，是则返回 403；管理后台操作不经过此检查
+func checkReadOnly(w http.ResponseWriter, b *storage.Bucket, resource string) bool {
+	if b == nil || !b.ReadOnly {
+		return true
+	}
+	utils.WriteError(w, utils.ErrBucketReadOnly, http.StatusForbidden, resource)
+	return false
+}
+
+// getObjectForRequest 获取 GET/HEAD 请求的对象元数据
+// 指定 versionId 时返回该版本，版本是删除标记时返回 errDeleteMarker；
+// 匿名访问开启静态网站的桶时，目录请求返回索引文档，单页应用模式下不存在的键回退到索引文档
+func (s *Server) getObjectForRequest(r *http.Request, b *storage.Bucket, bucket, key string) (*storage.Object, error) {
+	if versionID := r.URL.Query().Get("versionId"); versionID != "" {
+		return s.getObjectVersion(bucket, key, versionID)
+	}
+	if _, signed := r.Context().Value(ContextKeyAccessKeyID).(string); signed {
+		return s.getLiveOrOriginObject(bucket, key)
+	}
+	obj, err := s.getLiveOrOriginObject(bucket, b.WebsiteIndexKey(key))
+	if err != nil || obj != nil {
+		return obj, err
+	}
+	if fallback := b.WebsiteFallbackKey(); fallback != "" {
+		return s.getLiveOrOriginObject(bucket, fallback)
+	}
+	return nil, nil
+}
+
+// getLiveObject 获取对象元数据，已过自定义过期时间但尚未清理的对象视为不存在
+func (s *Server) getLiveObject(bucket, key string) (*storage.Object, error) {
+	obj, err := s.metadata.GetObject(bucket, key)
+	if err != nil || obj.Expired(time.Now()) {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// isWebsiteBucket 桶是否配置了静态网站索引文档
+func (s *Server) isWebsiteBucket(bucket string) bool {
+	b, err := s.metadata.GetBucket(bucket)
+	return err == nil && b != nil && b.WebsiteIndex != ""
+}
+
+// objectHeadersFromRequest 提取上传请求中需要随对象保存的响应头
+func objectHeadersFromRequest(r *http.Request) map[string]string {
+	var headers map[string]string
+	for _, name := range storage.ObjectHeaderNames() {
+		if v := r.Header.Get(name); v != "" {
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers[name] = v
+		}
+	}
+	return headers
+}
+
+// setObjectHeaders 输出对象保存的响应头，缺失的项使用桶默认值
+func setObjectHeaders(w http.ResponseWriter, b *storage.Bucket, obj *storage.Object) {
+	for name, v := range b.MergeDefaultHeaders(obj.Headers) {
+		w.Header().Set(name, v)
+	}
+	for name, v := range obj.Metadata {
+		w.Header().Set(userMetadataPrefix+name, v)
+	}
+	if obj.ExpiresAt != nil {
+		w.Header().Set("x-amz-expires-at", obj.ExpiresAt.Format(time.RFC3339))
+	}
+	if !obj.CreatedAt.IsZero() {
+		w.Header().Set("x-amz-created-at", obj.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	if len(obj.Tags) > 0 {
+		w.Header().Set("x-amz-tagging-count", strconv.Itoa(len(obj.Tags)))
+	}
+	setVersionIDHeader(w, obj)
+}
+
+// unquoteETag 去除 ETag 的引号与弱校验前缀
+func unquoteETag(etag string) string {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	return strings.Trim(etag, `"`)
+}
+
+// etagListMatches 判断 If-Match / If-None-Match 的 ETag 列表是否包含 etag，* 匹配任何对象
+func etagListMatches(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = unquoteETag(v)
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// readConditionStatus 按 If-Match / If-Unmodified-Since / If-None-Match / If-Modified-Since 判断 GET/HEAD 的结果
+// 返回 412 或 304，条件都满足时返回 0；与 S3 一致，有 If-Match 时忽略 If-Unmodified-Since，有 If-None-Match 时忽略 If-Modified-Since
+// 时间按秒比较，与 Last-Modified 响应头的精度一致
+func readConditionStatus(r *http.Request, obj *storage.Object) int {
+	modified := obj.LastModified.UTC().Truncate(time.Second)
+	if v := r.Header.Get("If-Match"); v != "" {
+		if !etagListMatches(v, obj.ETag) {
+			return http.StatusPreconditionFailed
+		}
+	} else if t, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); err == nil && modified.After(t) {
+		return http.StatusPreconditionFailed
+	}
+	if v := r.Header.Get("If-None-Match"); v != "" {
+		if etagListMatches(v, obj.ETag) {
+			return http.StatusNotModified
+		}
+	} else if t, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.After(t) {
+		return http.StatusNotModified
+	}
+	return 0
+}
+
+// ifRangeMatches 判断 If-Range 是否与当前对象一致，未提供时视为一致
+// 值为 ETag 时按强比较，弱 ETag 不匹配；值为日期时须与 Last-Modified 相同（按秒），无法解析的值视为不一致
+func ifRangeMatches(r *http.Request, obj *storage.Object) bool {
+	v := strings.TrimSpace(r.Header.Get("If-Range"))
+	if v == "" {
+		return true
+	}
+	if strings.HasPrefix(v, "W/") {
+		return false
+	}
+	if strings.HasPrefix(v, `"`) {
+		return strings.Trim(v, `"`) == obj.ETag
+	}
+	t, err := http.ParseTime(v)
+	return err == nil && obj.LastModified.UTC().Truncate(time.Second).Equal(t)
+}
+
+// writeNotModified 返回 304，只带 ETag 和 Last-Modified
+func writeNotModified(w http.ResponseWriter, obj *storage.Object) {
+	w.Header().Set("ETag", `"`+obj.ETag+`"`)
+	w.Header().Set("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// parseWriteCondition 解析条件写入请求头 If-None-Match / If-Match，If-None-Match 只支持 *
+func parseWriteCondition(w http.ResponseWriter, r *http.Request, resource string) (storage.WriteCondition, bool) {
+	var cond storage.WriteCondition
+	if v := strings.TrimSpace(r.Header.Get("If-None-Match")); v != "" {
+		if v != "*" {
+			utils.WriteError(w, utils.ErrUnsupportedIfNoneMatch, http.StatusNotImplemented, resource)
+			return cond, false
+		}
+		cond.IfNoneMatch = true
+	}
+	cond.IfMatch = unquoteETag(r.Header.Get("If-Match"))
+	return cond, true
+}
+
+// checkWriteCondition 写入前按当前对象检查条件，不满足时返回 412
+// 只是提前拒绝，写入元数据时还会在写锁内再检查一次
+func (s *Server) checkWriteCondition(w http.ResponseWriter, cond storage.WriteCondition, bucket, key string) bool {
+	if cond == (storage.WriteCondition{}) {
+		return true
+	}
+	existing, err := s.metadata.GetObject(bucket, key)
+	if err != nil {
+		utils.Error("get object metadata failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+bucket+"/"+key)
+		return false
+	}
+	var existingETag string
+	if existing != nil {
+		existingETag = existing.ETag
+	}
+	if !cond.Satisfied(existing != nil, existingETag) {
+		utils.WriteError(w, utils.ErrPreconditionFailed, http.StatusPreconditionFailed, "/"+bucket+"/"+key)
+		return false
+	}
+	return true
+}
+
+// userMetadataPrefix 用户元数据请求头前缀
+const userMetadataPrefix = "x-amz-meta-"
+
+// userMetadataSize 计算 x-amz-meta-* 用户元数据大小（去掉前缀的名称与所有值的字节数之和）
+func userMetadataSize(h http.Header) int {
+	size := 0
+	for name, values := range h {
+		if len(name) <= len(userMetadataPrefix) || !strings.EqualFold(name[:len(userMetadataPrefix)], userMetadataPrefix) {
+			continue
+		}
+		size += len(name) - len(userMetadataPrefix)
+		for _, v := range values {
+			size += len(v)
+		}
+	}
+	return size
+}
+
+// userMetadataFromRequest 提取 x-amz-meta-* 用户元数据，键为去掉前缀的小写名称，同名多个值以逗号连接
+func userMetadataFromRequest(h http.Header) map[string]string {
+	var metadata map[string]string
+	for name, values := range h {
+		if len(name) <= len(userMetadataPrefix) || !strings.EqualFold(name[:len(userMetadataPrefix)], userMetadataPrefix) {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[strings.ToLower(name[len(userMetadataPrefix):])] = strings.Join(values, ",")
+	}
+	return metadata
+}
+
+// checkMetadataSize 校验用户元数据总大小不超过 MaxMetadataSize，超出时写入 MetadataTooLarge
+func checkMetadataSize(w http.ResponseWriter, r *http.Request, resource string) bool {
+	limit := config.Global.Storage.MaxMetadataSize
+	if limit <= 0 {
+		return true
+	}
+	size := userMetadataSize(r.Header)
+	if size <= limit {
+		return true
+	}
+	e := utils.ErrMetadataTooLarge
+	e.Message = fmt.Sprintf("%s: %d bytes, limit is %d bytes", e.Message, size, limit)
+	utils.WriteError(w, e, http.StatusBadRequest, resource)
+	return false
+}
+
+// normalizeObjectKey 按 LeadingSlash 策略处理对象键的前导斜杠
+// normalize 模式去除所有前导斜杠，reject 模式拒绝；去除后为空的键同样拒绝
+func normalizeObjectKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, "/") {
+		return key, true
+	}
+	if config.Global.Storage.LeadingSlash == config.LeadingSlashReject {
+		return "", false
+	}
+	key = strings.TrimLeft(key, "/")
+	return key, key != ""
+}
+
+// copySource 解析后的复制源
+type copySource struct {
+	bucket     string
+	key        string
+	versionID  string // ?versionId= 指定的版本，未指定时为空
+	hasVersion bool
+	obj        *storage.Object
+}
+
+// resolveCopySource 解析 x-amz-copy-source 并获取源对象（CopyObject 与 UploadPartCopy 共用）
+// 失败时已写入错误响应，resource 为目标对象资源路径
+func (s *Server) resolveCopySource(w http.ResponseWriter, r *http.Request, resource string) (*copySource, bool) {
 	// 解析源对象路径
-	copySource := r.Header.Get("x-amz-copy-source")
-	if copySource == "" {
-		utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, "/"+destBucket+"/"+destKey)
-		return
+	source := r.Header.Get("x-amz-copy-source")
+	if source == "" {
+		utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, resource)
+		return nil, false
+	}
+
+	// 可选的 ?versionId= 后缀，"null" 表示未启用版本控制时写入的版本
+	src := &copySource{}
+	source, versionQuery, hasVersion := strings.Cut(source, "?")
+	if hasVersion {
+		q, err := url.ParseQuery(versionQuery)
+		if src.versionID = q.Get("versionId"); err != nil || src.versionID == "" {
+			utils.WriteErrorResponse(w, "InvalidCopySource", "Invalid x-amz-copy-source version", http.StatusBadRequest)
+			return nil, false
+		}
+		src.hasVersion = true
 	}
 
 	// URL解码源路径（处理中文文件名等）
-	decodedSource, err := url.PathUnescape(copySource)
+	decodedSource, err := url.PathUnescape(source)
 	if err != nil {
 		utils.WriteErrorResponse(w, "InvalidCopySource", "Invalid x-amz-copy-source encoding", http.StatusBadRequest)
-		return
+		return nil, false
 	}
 
 	// 解析源路径，格式: /bucket/key 或 bucket/key
@@ -255,32 +813,78 @@ func (s *Server) handleCopyObject(w http.ResponseWriter, r *http.Request, destBu
 	parts := strings.SplitN(decodedSource, "/", 2)
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
 		utils.WriteErrorResponse(w, "InvalidCopySource", "Invalid x-amz-copy-source format", http.StatusBadRequest)
-		return
+		return nil, false
+	}
+	// 源桶同样位于请求 Key 的命名空间下
+	src.bucket = requestNamespace(r) + parts[0]
+	srcKey, ok := normalizeObjectKey(parts[1])
+	if !ok {
+		utils.WriteErrorResponse(w, "InvalidCopySource", "Invalid source key", http.StatusBadRequest)
+		return nil, false
 	}
-	srcBucket := parts[0]
-	srcKey := parts[1]
 
 	// 验证路径安全性（防止路径遍历）
-	if strings.Contains(srcBucket, "..") || strings.ContainsAny(srcBucket, "/\\") {
+	if strings.Contains(src.bucket, "..") || strings.ContainsAny(src.bucket, "/\\") {
 		utils.WriteErrorResponse(w, "InvalidCopySource", "Invalid source bucket name", http.StatusBadRequest)
-		return
+		return nil, false
 	}
 	if strings.Contains(srcKey, "..") {
 		utils.WriteErrorResponse(w, "InvalidCopySource", "Invalid source key", http.StatusBadRequest)
-		return
+		return nil, false
 	}
 
 	// 检查源存储桶
-	srcB, err := s.metadata.GetBucket(srcBucket)
+	srcB, err := s.metadata.GetBucket(src.bucket)
 	if err != nil {
 		utils.Error("check source bucket failed", "error", err)
-		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+srcBucket)
-		return
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+src.bucket)
+		return nil, false
 	}
 	if srcB == nil {
-		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+srcBucket)
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+src.bucket)
+		return nil, false
+	}
+	// 复制会读出源对象，须有源桶的读权限（公开可读的源对象除外），否则可借复制越权读取
+	if !srcB.AllowsAnonymousRead(storage.PolicyActionGetObject, srcKey) &&
+		!s.checkBucketAction(r, w, srcB, src.bucket, srcKey, storage.PolicyActionGetObject, false) {
+		return nil, false
+	}
+	src.key = srcB.RewriteKey(srcKey)
+
+	// 获取源对象元数据
+	if src.hasVersion {
+		src.obj, err = s.getObjectVersion(src.bucket, src.key, src.versionID)
+	} else {
+		src.obj, err = s.getLiveObject(src.bucket, src.key)
+	}
+	if errors.Is(err, errDeleteMarker) {
+		utils.WriteError(w, utils.ErrCopySourceDeleteMarker, http.StatusBadRequest, "/"+src.bucket+"/"+src.key)
+		return nil, false
+	}
+	if err != nil {
+		utils.Error("get source object metadata failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+src.bucket+"/"+src.key)
+		return nil, false
+	}
+	if src.obj == nil {
+		if src.hasVersion {
+			utils.WriteError(w, utils.ErrNoSuchVersion, http.StatusNotFound, "/"+src.bucket+"/"+src.key)
+			return nil, false
+		}
+		utils.WriteError(w, utils.ErrNoSuchKey, http.StatusNotFound, "/"+src.bucket+"/"+src.key)
+		return nil, false
+	}
+	return src, true
+}
+
+// handleCopyObject 复制对象
+// x-amz-metadata-directive 为 COPY（默认）时沿用源对象的 Content-Type、响应头和用户元数据，REPLACE 时改用请求中的值
+func (s *Server) handleCopyObject(w http.ResponseWriter, r *http.Request, destBucket, destKey string) {
+	src, ok := s.resolveCopySource(w, r, "/"+destBucket+"/"+destKey)
+	if !ok {
 		return
 	}
+	srcObj := src.obj
 
 	// 检查目标存储桶
 	destB, err := s.metadata.GetBucket(destBucket)
@@ -293,21 +897,58 @@ func (s *Server) handleCopyObject(w http.ResponseWriter, r *http.Request, destBu
 		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+destBucket)
 		return
 	}
+	if !checkReadOnly(w, destB, "/"+destBucket+"/"+destKey) {
+		return
+	}
+	if destB.DeniesKey(destKey) {
+		utils.WriteError(w, utils.ErrKeyNotAllowed, http.StatusBadRequest, "/"+destBucket+"/"+destKey)
+		return
+	}
+	if !s.checkImmutable(w, destB, destBucket, destKey, nil) {
+		return
+	}
 
-	// 获取源对象元数据
-	srcObj, err := s.metadata.GetObject(srcBucket, srcKey)
-	if err != nil {
-		utils.Error("get source object metadata failed", "error", err)
-		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+srcBucket+"/"+srcKey)
+	contentType, headers, metadata := srcObj.ContentType, srcObj.Headers, srcObj.Metadata
+	switch r.Header.Get("x-amz-metadata-directive") {
+	case "", "COPY":
+	case "REPLACE":
+		if !checkMetadataSize(w, r, "/"+destBucket+"/"+destKey) {
+			return
+		}
+		if contentType = r.Header.Get("Content-Type"); contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		if !destB.AllowsContentType(contentType) {
+			utils.WriteError(w, utils.ErrContentTypeNotAllowed, http.StatusBadRequest, "/"+destBucket+"/"+destKey)
+			return
+		}
+		headers = destB.MergeDefaultHeaders(objectHeadersFromRequest(r))
+		metadata = userMetadataFromRequest(r.Header)
+	default:
+		utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, "/"+destBucket+"/"+destKey)
 		return
 	}
-	if srcObj == nil {
-		utils.WriteError(w, utils.ErrNoSuchKey, http.StatusNotFound, "/"+srcBucket+"/"+srcKey)
+
+	if !s.checkFreeSpace(w, r, "/"+destBucket+"/"+destKey) {
 		return
 	}
 
-	// 复制文件
-	newStoragePath, etag, err := s.filestore.CopyObject(srcObj.StoragePath, destBucket, destKey)
+	// 复制到临时文件，指定 x-amz-copy-source-range 时只复制该字节范围；加密对象解密后按目标重新加密
+	var staged *storage.StagedFile
+	destVersionID := newVersionID(destB)
+	if rangeHeader := r.Header.Get("x-amz-copy-source-range"); rangeHeader != "" {
+		start, end, ok := parseCopySourceRange(rangeHeader, srcObj.Size)
+		if !ok {
+			utils.WriteError(w, utils.ErrInvalidRange, http.StatusRequestedRangeNotSatisfiable, "/"+src.bucket+"/"+src.key)
+			return
+		}
+		staged, err = s.copyObjectRange(srcObj, destBucket, destKey, destVersionID, start, end)
+	} else {
+		staged, err = s.filestore.CopyObjectStaged(srcObj, destBucket, destKey, destVersionID)
+	}
+	if writeNoSpaceError(w, err, "/"+destBucket+"/"+destKey) {
+		return
+	}
 	if err != nil {
 		utils.Error("copy object file failed", "error", err)
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+destBucket+"/"+destKey)
@@ -318,31 +959,84 @@ func (s *Server) handleCopyObject(w http.ResponseWriter, r *http.Request, destBu
 	newObj := &storage.Object{
 		Key:          destKey,
 		Bucket:       destBucket,
-		Size:         srcObj.Size,
-		ETag:         etag,
-		ContentType:  srcObj.ContentType,
+		Size:         staged.Size,
+		ETag:         staged.ETag,
+		ContentType:  contentType,
 		LastModified: time.Now().UTC(),
-		StoragePath:  newStoragePath,
+		StoragePath:  staged.Path,
+		Headers:      headers,
+		Metadata:     metadata,
+		Tags:         srcObj.Tags, // 与 S3 默认的 x-amz-tagging-directive: COPY 一致
+		VersionID:    destVersionID,
+
+		EncryptionNonce: staged.Nonce,
 	}
 
-	if err := s.metadata.PutObject(newObj); err != nil {
+	// 替换目标文件与写入元数据在同一写锁内完成，失败时已有的目标对象保持不变
+	if err := s.metadata.PutObjectIf(newObj, storage.WriteCondition{}, staged.Commit); err != nil {
+		staged.Discard()
+		if writeQuotaError(w, err, "/"+destBucket+"/"+destKey) {
+			return
+		}
+		if err == storage.ErrBucketDeleted {
+			utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+destBucket)
+			return
+		}
 		utils.Error("save copied object metadata failed", "error", err)
-		s.filestore.DeleteObject(newStoragePath) // 回滚
 		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "/"+destBucket+"/"+destKey)
 		return
 	}
+	s.adminHandler.Replicate(destBucket, destKey, storage.ReplicationOpPut)
+	s.adminHandler.NotifyObjectCreated(storage.EventObjectCreatedCopy, newObj)
 
 	// 返回 S3 CopyObject 响应格式
+	if src.hasVersion {
+		w.Header().Set("x-amz-copy-source-version-id", src.versionID)
+	}
+	setVersionIDHeader(w, newObj)
 	w.Header().Set("Content-Type", "application/xml")
 	w.WriteHeader(http.StatusOK)
 	response := `<?xml version="1.0" encoding="UTF-8"?>
 <CopyObjectResult>
   <LastModified>` + newObj.LastModified.Format(time.RFC3339) + `</LastModified>
-  <ETag>"` + etag + `"</ETag>
+  <ETag>"` + newObj.ETag + `"</ETag>
 </CopyObjectResult>`
 	w.Write([]byte(response))
 }
 
+// parseCopySourceRange 解析 x-amz-copy-source-range，格式必须为 bytes=first-last
+// 范围需落在源对象内，返回闭区间的起止偏移
+func parseCopySourceRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, 0, false
+	}
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start || end >= size {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// copyObjectRange 复制源对象的 [start, end] 字节范围到目标对象的临时文件
+func (s *Server) copyObjectRange(src *storage.Object, destBucket, destKey, versionID string, start, end int64) (*storage.StagedFile, error) {
+	srcFile, err := s.filestore.OpenObject(src)
+	if err != nil {
+		return nil, err
+	}
+	defer srcFile.Close()
+
+	return s.filestore.PutObjectStaged(destBucket, destKey, versionID, io.NewSectionReader(srcFile, start, end-start+1), 0)
+}
+
 // handleHeadObject 获取对象元数据
 func (s *Server) handleHeadObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
 	// 检查存储桶
@@ -357,9 +1051,19 @@ func (s *Server) handleHeadObject(w http.ResponseWriter, r *http.Request, bucket
 		return
 	}
 
-	// 获取对象元数据
-	obj, err := s.metadata.GetObject(bucket, key)
+	// 获取对象元数据，本地不存在时按桶配置回源
+	obj, err := s.getObjectForRequest(r, b, bucket, key)
+	if errors.Is(err, errDeleteMarker) {
+		w.Header().Set("x-amz-delete-marker", "true")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
 	if err != nil {
+		if _, status, ok := originErrorStatus(err); ok {
+			utils.Warn("fetch object from origin failed", "bucket", bucket, "key", key, "error", err)
+			w.WriteHeader(status)
+			return
+		}
 		utils.Error("get object metadata failed", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -368,7 +1072,20 @@ func (s *Server) handleHeadObject(w http.ResponseWriter, r *http.Request, bucket
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if b.ReadAgeExceeded(obj, time.Now()) {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+	switch readConditionStatus(r, obj) {
+	case http.StatusNotModified:
+		writeNotModified(w, obj)
+		return
+	case http.StatusPreconditionFailed:
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
 
+	setObjectHeaders(w, b, obj)
 	w.Header().Set("Content-Type", obj.ContentType)
 	w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
 	w.Header().Set("ETag", `"`+obj.ETag+`"`)
@@ -2,14 +2,30 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/png"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"sss/internal/config"
 	"sss/internal/storage"
@@ -48,32 +64,1049 @@ func setupObjectTestServer(t *testing.T) (*Server, func()) {
 	return server, cleanup
 }
 
+// asAdmin 模拟已通过认证的管理员 Key 发起的请求，供直接调用处理函数的测试使用
+func asAdmin(t *testing.T, req *http.Request) *http.Request {
+	t.Helper()
+	if config.Global.Auth.AccessKeyID == "" {
+		saved := config.Global.Auth
+		config.Global.Auth.AccessKeyID = "TEST-ADMIN-KEY"
+		config.Global.Auth.SecretAccessKey = "TEST-ADMIN-SECRET"
+		t.Cleanup(func() { config.Global.Auth = saved })
+	}
+	return req.WithContext(context.WithValue(req.Context(), ContextKeyAccessKeyID, config.Global.Auth.AccessKeyID))
+}
+
 // createTestBucketAndObject 创建测试桶和对象
 func createTestBucketAndObject(t *testing.T, s *Server, bucket, key string, content []byte) {
 	t.Helper()
 
-	// 创建桶
-	if err := s.metadata.CreateBucket(bucket); err != nil {
-		t.Fatalf("创建桶失败: %v", err)
+	// 创建桶
+	if err := s.metadata.CreateBucket(bucket); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+
+	// 上传对象
+	storagePath, etag, err := s.filestore.PutObject(bucket, key, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("上传对象失败: %v", err)
+	}
+
+	obj := &storage.Object{
+		Key:         key,
+		Bucket:      bucket,
+		Size:        int64(len(content)),
+		ETag:        etag,
+		ContentType: "text/plain",
+		StoragePath: storagePath,
+	}
+	if err := s.metadata.PutObject(obj); err != nil {
+		t.Fatalf("保存对象元数据失败: %v", err)
+	}
+}
+
+// TestHandlePutObjectContentTypePolicy 测试桶内容类型限制
+func TestHandlePutObjectContentTypePolicy(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	server.metadata.CreateBucket("ct-bucket")
+	if err := server.metadata.UpdateBucketContentTypes("ct-bucket", storage.ContentTypeModeBlock, "text/html,application/x-msdownload", true); err != nil {
+		t.Fatalf("设置内容类型限制失败: %v", err)
+	}
+
+	put := func(key, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/ct-bucket/"+key, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "ct-bucket", key)
+		return rec
+	}
+
+	t.Run("普通类型上传成功", func(t *testing.T) {
+		rec := put("a.txt", "text/plain", "hello")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+		obj, _ := server.metadata.GetObject("ct-bucket", "a.txt")
+		if obj == nil || obj.Size != 5 {
+			t.Errorf("对象未正确保存: %+v", obj)
+		}
+		data, _ := os.ReadFile(obj.StoragePath)
+		if string(data) != "hello" {
+			t.Errorf("嗅探后内容不完整: %q", data)
+		}
+	})
+
+	t.Run("禁止类型返回400", func(t *testing.T) {
+		rec := put("b.html", "text/html; charset=utf-8", "<p>x</p>")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("状态码错误: 期望 400, 实际 %d", rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "InvalidArgument") {
+			t.Errorf("错误码错误: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("嗅探识别伪装类型", func(t *testing.T) {
+		rec := put("c.jpg", "image/jpeg", "<!DOCTYPE html><html><script>alert(1)</script></html>")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("伪装为图片的HTML应被拒绝: %d", rec.Code)
+		}
+	})
+}
+
+// TestHandlePutObjectKeyDenylist 测试桶保留键
+func TestHandlePutObjectKeyDenylist(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "site-bucket", "src.html", []byte("<p>x</p>"))
+	if err := server.metadata.UpdateBucketKeyDenylist("site-bucket", "index.html\nadmin/*"); err != nil {
+		t.Fatalf("设置保留键失败: %v", err)
+	}
+
+	put := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/site-bucket/"+key, strings.NewReader("data"))
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "site-bucket", key)
+		return rec
+	}
+
+	t.Run("保留键返回400", func(t *testing.T) {
+		for _, key := range []string{"index.html", "admin/config.js"} {
+			rec := put(key)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "InvalidArgument") {
+				t.Errorf("%s 应被拒绝: %d %s", key, rec.Code, rec.Body.String())
+			}
+			if obj, _ := server.metadata.GetObject("site-bucket", key); obj != nil {
+				t.Errorf("%s 不应被写入", key)
+			}
+		}
+	})
+
+	t.Run("普通键不受影响", func(t *testing.T) {
+		if rec := put("docs/index.html"); rec.Code != http.StatusOK {
+			t.Errorf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("复制与分段上传同样受限", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/site-bucket/index.html", nil)
+		req = asAdmin(t, req)
+		req.Header.Set("x-amz-copy-source", "/site-bucket/src.html")
+		rec := httptest.NewRecorder()
+		server.handleCopyObject(rec, req, "site-bucket", "index.html")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("复制到保留键应返回400: %d", rec.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/site-bucket/index.html?uploads", nil)
+		rec = httptest.NewRecorder()
+		server.handleInitiateMultipartUpload(rec, req, "site-bucket", "index.html")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("分段上传保留键应返回400: %d", rec.Code)
+		}
+	})
+}
+
+// TestBucketDefaultHeaders 测试桶默认响应头
+func TestBucketDefaultHeaders(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	// 设置默认头之前已存在的对象
+	createTestBucketAndObject(t, server, "site-bucket", "old.html", []byte("old"))
+	if err := server.metadata.UpdateBucketDefaultHeaders("site-bucket", map[string]string{
+		"Cache-Control":    "public, max-age=3600",
+		"Content-Language": "zh-CN",
+	}); err != nil {
+		t.Fatalf("设置默认响应头失败: %v", err)
+	}
+
+	put := func(key string, headers map[string]string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/site-bucket/"+key, strings.NewReader("data"))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "site-bucket", key)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("上传失败: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	get := func(method, key string) http.Header {
+		t.Helper()
+		req := httptest.NewRequest(method, "/site-bucket/"+key, nil)
+		rec := httptest.NewRecorder()
+		if method == http.MethodHead {
+			server.handleHeadObject(rec, req, "site-bucket", key)
+		} else {
+			server.handleGetObject(rec, req, "site-bucket", key)
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s 失败: %d", method, rec.Code)
+		}
+		return rec.Header()
+	}
+
+	put("new.html", nil)
+	put("custom.html", map[string]string{"Cache-Control": "no-store", "Content-Disposition": "inline"})
+
+	tests := []struct {
+		key         string
+		cache       string
+		disposition string
+	}{
+		{"old.html", "public, max-age=3600", ""},
+		{"new.html", "public, max-age=3600", ""},
+		{"custom.html", "no-store", "inline"},
+	}
+	for _, tc := range tests {
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			h := get(method, tc.key)
+			if got := h.Get("Cache-Control"); got != tc.cache {
+				t.Errorf("%s %s Cache-Control 错误: %q", method, tc.key, got)
+			}
+			if got := h.Get("Content-Disposition"); got != tc.disposition {
+				t.Errorf("%s %s Content-Disposition 错误: %q", method, tc.key, got)
+			}
+			if got := h.Get("Content-Language"); got != "zh-CN" {
+				t.Errorf("%s %s Content-Language 错误: %q", method, tc.key, got)
+			}
+		}
+	}
+
+	obj, _ := server.metadata.GetObject("site-bucket", "custom.html")
+	if obj.Headers["Cache-Control"] != "no-store" || obj.Headers["Content-Language"] != "zh-CN" {
+		t.Errorf("上传时应合并默认头并保存: %v", obj.Headers)
+	}
+}
+
+// TestWebsiteSPAFallback 测试静态网站索引文档与单页应用回退
+func TestWebsiteSPAFallback(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "spa-bucket", "index.html", []byte("<app>"))
+	putObject := func(key, content string) {
+		t.Helper()
+		path, etag, _ := server.filestore.PutObject("spa-bucket", key, strings.NewReader(content), int64(len(content)))
+		server.metadata.PutObject(&storage.Object{Bucket: "spa-bucket", Key: key, Size: int64(len(content)), ETag: etag, ContentType: "text/html", StoragePath: path})
+	}
+	putObject("docs/index.html", "<docs>")
+	putObject("app.js", "js")
+	server.metadata.UpdateBucketPublic("spa-bucket", true)
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	t.Run("未开启时保持 S3 行为", func(t *testing.T) {
+		if code, _ := get("/spa-bucket/dashboard/settings"); code != http.StatusNotFound {
+			t.Errorf("未开启网站时应返回404: %d", code)
+		}
+	})
+
+	server.metadata.UpdateBucketWebsite("spa-bucket", "index.html", true)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/spa-bucket/app.js", "js"},
+		{"/spa-bucket/", "<app>"},
+		{"/spa-bucket/docs/", "<docs>"},
+		{"/spa-bucket/dashboard/settings", "<app>"},
+		{"/spa-bucket/missing/", "<app>"},
+	}
+	for _, tc := range tests {
+		code, body := get(tc.path)
+		if code != http.StatusOK || body != tc.body {
+			t.Errorf("%s: 期望 200 %q, 实际 %d %q", tc.path, tc.body, code, body)
+		}
+	}
+
+	t.Run("关闭SPA时目录索引仍生效", func(t *testing.T) {
+		server.metadata.UpdateBucketWebsite("spa-bucket", "index.html", false)
+		defer server.metadata.UpdateBucketWebsite("spa-bucket", "index.html", true)
+		if code, body := get("/spa-bucket/docs/"); code != http.StatusOK || body != "<docs>" {
+			t.Errorf("目录索引错误: %d %q", code, body)
+		}
+		if code, _ := get("/spa-bucket/dashboard/settings"); code != http.StatusNotFound {
+			t.Errorf("关闭 SPA 后应返回404: %d", code)
+		}
+	})
+
+	t.Run("签名请求不回退", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/spa-bucket/dashboard/settings", nil)
+		req = req.WithContext(context.WithValue(req.Context(), ContextKeyAccessKeyID, "AKID"))
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "spa-bucket", "dashboard/settings")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("签名请求应返回404: %d", rec.Code)
+		}
+	})
+}
+
+// TestObjectExpiresAt 测试对象自定义过期时间
+func TestObjectExpiresAt(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	if err := server.metadata.CreateBucket("ttl-bucket"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+
+	put := func(key, expiresAt string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/ttl-bucket/"+key, strings.NewReader("share"))
+		req.Header.Set("x-amz-expires-at", expiresAt)
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "ttl-bucket", key)
+		return rec
+	}
+
+	t.Run("无效过期时间", func(t *testing.T) {
+		for _, v := range []string{"tomorrow", time.Now().Add(-time.Hour).Format(time.RFC3339)} {
+			if rec := put("bad.txt", v); rec.Code != http.StatusBadRequest {
+				t.Errorf("%q 应返回400: %d", v, rec.Code)
+			}
+		}
+	})
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	if rec := put("share.txt", expiresAt.Format(http.TimeFormat)); rec.Code != http.StatusOK {
+		t.Fatalf("上传失败: %d %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodHead, "/ttl-bucket/share.txt", nil)
+	rec := httptest.NewRecorder()
+	server.handleHeadObject(rec, req, "ttl-bucket", "share.txt")
+	if rec.Code != http.StatusOK || rec.Header().Get("x-amz-expires-at") != expiresAt.Format(time.RFC3339) {
+		t.Fatalf("未到期时应可访问并返回过期时间: %d %q", rec.Code, rec.Header().Get("x-amz-expires-at"))
+	}
+
+	// 模拟已到期但清理尚未运行
+	obj, _ := server.metadata.GetObject("ttl-bucket", "share.txt")
+	past := time.Now().Add(-time.Second)
+	obj.ExpiresAt = &past
+	server.metadata.PutObject(obj)
+
+	req = httptest.NewRequest(http.MethodGet, "/ttl-bucket/share.txt", nil)
+	rec = httptest.NewRecorder()
+	server.handleGetObject(rec, req, "ttl-bucket", "share.txt")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("到期对象 GET 应返回404: %d", rec.Code)
+	}
+	req = httptest.NewRequest(http.MethodHead, "/ttl-bucket/share.txt", nil)
+	rec = httptest.NewRecorder()
+	server.handleHeadObject(rec, req, "ttl-bucket", "share.txt")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("到期对象 HEAD 应返回404: %d", rec.Code)
+	}
+
+	server.sweepExpiredObjects(time.Now())
+	if obj, _ := server.metadata.GetObject("ttl-bucket", "share.txt"); obj != nil {
+		t.Error("清理后对象元数据应已删除")
+	}
+	if _, err := os.Stat(obj.StoragePath); !os.IsNotExist(err) {
+		t.Error("清理后对象文件应已删除")
+	}
+}
+
+// TestObjectImmutabilityWindow 测试桶对象不可变窗口
+func TestObjectImmutabilityWindow(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "worm-bucket", "fresh.txt", []byte("fresh"))
+	if err := server.metadata.UpdateBucketImmutability("worm-bucket", 10); err != nil {
+		t.Fatalf("设置不可变窗口失败: %v", err)
+	}
+	fresh, _ := server.metadata.GetObject("worm-bucket", "fresh.txt")
+	fresh.LastModified = time.Now().UTC()
+	server.metadata.PutObject(fresh)
+
+	t.Run("窗口内覆盖被拒绝", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/worm-bucket/fresh.txt", strings.NewReader("clobber"))
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "worm-bucket", "fresh.txt")
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "seconds remaining") {
+			t.Fatalf("期望 403 及剩余时间: %d %s", rec.Code, rec.Body.String())
+		}
+		if ra, _ := strconv.Atoi(rec.Header().Get("Retry-After")); ra <= 0 || ra > 600 {
+			t.Errorf("Retry-After 错误: %q", rec.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("窗口内删除被拒绝", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/worm-bucket/fresh.txt", nil)
+		rec := httptest.NewRecorder()
+		server.handleDeleteObject(rec, req, "worm-bucket", "fresh.txt")
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("期望 403, 实际 %d", rec.Code)
+		}
+		if obj, _ := server.metadata.GetObject("worm-bucket", "fresh.txt"); obj == nil {
+			t.Error("对象不应被删除")
+		}
+	})
+
+	t.Run("新对象与过期对象不受限", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/worm-bucket/new.txt", strings.NewReader("new"))
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "worm-bucket", "new.txt")
+		if rec.Code != http.StatusOK {
+			t.Errorf("新对象上传应成功: %d", rec.Code)
+		}
+
+		old, _ := server.metadata.GetObject("worm-bucket", "fresh.txt")
+		old.LastModified = time.Now().Add(-11 * time.Minute)
+		server.metadata.PutObject(old)
+		req = httptest.NewRequest(http.MethodDelete, "/worm-bucket/fresh.txt", nil)
+		rec = httptest.NewRecorder()
+		server.handleDeleteObject(rec, req, "worm-bucket", "fresh.txt")
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("窗口结束后删除应成功: %d", rec.Code)
+		}
+	})
+}
+
+// TestBucketReadOnly 测试只读桶拒绝写入和删除，读取正常
+func TestBucketReadOnly(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "frozen", "a.txt", []byte("frozen content"))
+	if err := server.metadata.UpdateBucketReadOnly("frozen", true); err != nil {
+		t.Fatalf("设置只读失败: %v", err)
+	}
+
+	expectReadOnly := func(name string, rec *httptest.ResponseRecorder) {
+		t.Helper()
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "read-only") {
+			t.Errorf("%s 应返回 403 只读: %d %s", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.handlePutObject(rec, httptest.NewRequest(http.MethodPut, "/frozen/b.txt", strings.NewReader("new")), "frozen", "b.txt")
+	expectReadOnly("PutObject", rec)
+
+	rec = httptest.NewRecorder()
+	server.handleDeleteObject(rec, httptest.NewRequest(http.MethodDelete, "/frozen/a.txt", nil), "frozen", "a.txt")
+	expectReadOnly("DeleteObject", rec)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/frozen/c.txt", nil)
+	req = asAdmin(t, req)
+	req.Header.Set("x-amz-copy-source", "/frozen/a.txt")
+	server.handleCopyObject(rec, req, "frozen", "c.txt")
+	expectReadOnly("CopyObject", rec)
+
+	rec = httptest.NewRecorder()
+	server.handleInitiateMultipartUpload(rec, httptest.NewRequest(http.MethodPost, "/frozen/big.bin?uploads", nil), "frozen", "big.bin")
+	expectReadOnly("InitiateMultipartUpload", rec)
+
+	if obj, _ := server.metadata.GetObject("frozen", "a.txt"); obj == nil {
+		t.Error("对象不应被删除")
+	}
+	if obj, _ := server.metadata.GetObject("frozen", "b.txt"); obj != nil {
+		t.Error("不应写入新对象")
+	}
+
+	rec = httptest.NewRecorder()
+	server.handleGetObject(rec, httptest.NewRequest(http.MethodGet, "/frozen/a.txt", nil), "frozen", "a.txt")
+	if rec.Code != http.StatusOK || rec.Body.String() != "frozen content" {
+		t.Errorf("只读桶读取应正常: %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	server.handleListObjects(rec, httptest.NewRequest(http.MethodGet, "/frozen", nil), "frozen")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "a.txt") {
+		t.Errorf("只读桶列举应正常: %d", rec.Code)
+	}
+
+	// 解除只读后恢复写入
+	server.metadata.UpdateBucketReadOnly("frozen", false)
+	rec = httptest.NewRecorder()
+	server.handlePutObject(rec, httptest.NewRequest(http.MethodPut, "/frozen/b.txt", strings.NewReader("new")), "frozen", "b.txt")
+	if rec.Code != http.StatusOK {
+		t.Errorf("解除只读后写入应成功: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestBucketQuota 测试桶存储配额：写满后拒绝写入（507），删除对象释放空间后恢复
+func TestBucketQuota(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	server.metadata.CreateBucket("quota")
+	if err := server.metadata.UpdateBucketQuota("quota", 10); err != nil {
+		t.Fatalf("设置配额失败: %v", err)
+	}
+
+	put := func(key, body string, knownLength bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/quota/"+key, strings.NewReader(body))
+		if !knownLength {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "quota", key)
+		return rec
+	}
+	expectQuota := func(name string, rec *httptest.ResponseRecorder) {
+		t.Helper()
+		if rec.Code != http.StatusInsufficientStorage || !strings.Contains(rec.Body.String(), "InsufficientStorage") {
+			t.Errorf("%s 应返回 507: %d %s", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	// 写满配额
+	for _, key := range []string{"a.txt", "b.txt"} {
+		if rec := put(key, "12345", true); rec.Code != http.StatusOK {
+			t.Fatalf("配额内写入应成功: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	if used, _ := server.metadata.BucketUsage("quota"); used != 10 {
+		t.Fatalf("用量应为 10，实际 %d", used)
+	}
+
+	expectQuota("已知长度写入", put("c.txt", "x", true))
+	expectQuota("未知长度写入", put("c.txt", "x", false))
+	expectQuota("覆盖为更大的对象", put("a.txt", "123456", false))
+	if obj, _ := server.metadata.GetObject("quota", "c.txt"); obj != nil {
+		t.Error("超出配额时不应写入对象")
+	}
+
+	// 覆盖为相同大小的对象不增加用量
+	if rec := put("a.txt", "abcde", true); rec.Code != http.StatusOK {
+		t.Errorf("同等大小的覆盖应成功: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// 删除后释放空间
+	rec := httptest.NewRecorder()
+	server.handleDeleteObject(rec, httptest.NewRequest(http.MethodDelete, "/quota/b.txt", nil), "quota", "b.txt")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("删除失败: %d", rec.Code)
+	}
+	if rec := put("c.txt", "xyz", true); rec.Code != http.StatusOK {
+		t.Errorf("删除后写入应成功: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// 关闭配额后不限制
+	server.metadata.UpdateBucketQuota("quota", 0)
+	if rec := put("d.txt", "0123456789", true); rec.Code != http.StatusOK {
+		t.Errorf("关闭配额后写入应成功: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestObjectEncryptionAtRest 测试启用静态加密后上传、读取、Range 读取和复制
+func TestObjectEncryptionAtRest(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	// 启用加密前写入的明文对象
+	createTestBucketAndObject(t, server, "enc", "plain.txt", []byte("plain content"))
+	if err := server.filestore.SetEncryptionPassphrase("test passphrase"); err != nil {
+		t.Fatalf("设置口令失败: %v", err)
+	}
+	defer server.filestore.SetEncryptionPassphrase("")
+
+	content := "0123456789abcdefghijklmnopqrstuvwxyz"
+	rec := httptest.NewRecorder()
+	server.handlePutObject(rec, httptest.NewRequest(http.MethodPut, "/enc/secret.txt", strings.NewReader(content)), "enc", "secret.txt")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("上传失败: %d %s", rec.Code, rec.Body.String())
+	}
+	obj, _ := server.metadata.GetObject("enc", "secret.txt")
+	if obj == nil || !obj.Encrypted() {
+		t.Fatalf("新上传的对象应加密: %+v", obj)
+	}
+	if data, _ := os.ReadFile(obj.StoragePath); string(data) == content {
+		t.Error("磁盘上的文件不应为明文")
+	}
+
+	get := func(key, rangeHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/enc/"+key, nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "enc", key)
+		return rec
+	}
+	if rec := get("secret.txt", ""); rec.Code != http.StatusOK || rec.Body.String() != content {
+		t.Errorf("读取应透明解密: %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := get("secret.txt", "bytes=10-20"); rec.Code != http.StatusPartialContent || rec.Body.String() != content[10:21] {
+		t.Errorf("Range 读取内容错误: %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := get("secret.txt", "bytes=0-1,30-"); rec.Code != http.StatusPartialContent ||
+		!strings.Contains(rec.Body.String(), "01") || !strings.Contains(rec.Body.String(), content[30:]) {
+		t.Errorf("多范围读取内容错误: %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := get("plain.txt", ""); rec.Body.String() != "plain content" {
+		t.Errorf("明文对象应仍可读取: %q", rec.Body.String())
+	}
+
+	// 复制和按范围复制
+	for _, tc := range []struct{ key, copyRange, want string }{
+		{"copy.txt", "", content},
+		{"part.txt", "bytes=5-9", content[5:10]},
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/enc/"+tc.key, nil)
+		req = asAdmin(t, req)
+		req.Header.Set("x-amz-copy-source", "/enc/secret.txt")
+		if tc.copyRange != "" {
+			req.Header.Set("x-amz-copy-source-range", tc.copyRange)
+		}
+		rec := httptest.NewRecorder()
+		server.handleCopyObject(rec, req, "enc", tc.key)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("复制失败: %d %s", rec.Code, rec.Body.String())
+		}
+		copied, _ := server.metadata.GetObject("enc", tc.key)
+		if copied == nil || !copied.Encrypted() || copied.EncryptionNonce == obj.EncryptionNonce {
+			t.Errorf("复制的对象应使用新的 IV 加密: %+v", copied)
+		}
+		if rec := get(tc.key, ""); rec.Body.String() != tc.want {
+			t.Errorf("复制的对象内容错误: %q", rec.Body.String())
+		}
+	}
+}
+
+// TestObjectEventNotifications 测试写入和删除对象后异步投递 Webhook 事件
+func TestObjectEventNotifications(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	received := make(chan storage.ObjectEvent, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event storage.ObjectEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer hook.Close()
+
+	server.metadata.CreateBucket("events")
+	server.metadata.SaveNotificationConfig(&storage.NotificationConfig{Bucket: "events", Targets: []storage.NotificationTarget{
+		{URL: hook.URL, Events: []string{"s3:ObjectCreated:*", "s3:ObjectRemoved:*"}},
+	}})
+
+	rec := httptest.NewRecorder()
+	server.handlePutObject(rec, httptest.NewRequest(http.MethodPut, "/events/a.txt", strings.NewReader("hello")), "events", "a.txt")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("上传失败: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	server.handleDeleteObject(rec, httptest.NewRequest(http.MethodDelete, "/events/a.txt", nil), "events", "a.txt")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("删除失败: %d", rec.Code)
+	}
+	// 删除不存在的对象不产生事件
+	rec = httptest.NewRecorder()
+	server.handleDeleteObject(rec, httptest.NewRequest(http.MethodDelete, "/events/missing.txt", nil), "events", "missing.txt")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.adminHandler.ShutdownNotifications(ctx); err != nil {
+		t.Fatalf("等待投递超时: %v", err)
+	}
+	close(received)
+	var events []storage.ObjectEvent
+	for e := range received {
+		events = append(events, e)
+	}
+	if len(events) != 2 {
+		t.Fatalf("应收到 2 个事件，实际 %+v", events)
+	}
+	if e := events[0]; e.EventName != storage.EventObjectCreatedPut || e.Key != "a.txt" || e.Size != 5 ||
+		e.ETag != "5d41402abc4b2a76b9719d911017c592" || e.EventTime.IsZero() {
+		t.Errorf("创建事件内容错误: %+v", e)
+	}
+	if e := events[1]; e.EventName != storage.EventObjectRemovedDelete || e.Bucket != "events" || e.Key != "a.txt" {
+		t.Errorf("删除事件内容错误: %+v", e)
+	}
+}
+
+// TestPutObjectIdempotency 测试 PUT 幂等键
+func TestPutObjectIdempotency(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	window := config.Global.Storage.IdempotencyWindow
+	config.Global.Storage.IdempotencyWindow = 60
+	defer func() { config.Global.Storage.IdempotencyWindow = window }()
+	server.metadata.CreateBucket("idem-bucket")
+
+	put := func(key, idemKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/idem-bucket/"+key, strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, idemKey)
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "idem-bucket", key)
+		return rec
+	}
+
+	first := put("a.txt", "job-1", "payload")
+	if first.Code != http.StatusOK {
+		t.Fatalf("首次上传失败: %d", first.Code)
+	}
+	obj, _ := server.metadata.GetObject("idem-bucket", "a.txt")
+
+	t.Run("相同内容重试返回首次结果", func(t *testing.T) {
+		rec := put("a.txt", "job-1", "payload")
+		if rec.Code != http.StatusOK || rec.Header().Get("ETag") != first.Header().Get("ETag") {
+			t.Fatalf("重试应返回首次 ETag: %d %s", rec.Code, rec.Header().Get("ETag"))
+		}
+		if rec.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("应标记为重放结果")
+		}
+		if again, _ := server.metadata.GetObject("idem-bucket", "a.txt"); !again.LastModified.Equal(obj.LastModified) {
+			t.Error("重试不应重新写入")
+		}
+	})
+
+	t.Run("内容不一致返回409", func(t *testing.T) {
+		for _, body := range []string{"PAYLOAD", "payload-longer"} {
+			if rec := put("a.txt", "job-1", body); rec.Code != http.StatusConflict {
+				t.Errorf("%q 期望 409, 实际 %d", body, rec.Code)
+			}
+		}
+	})
+
+	t.Run("用于其他对象返回409", func(t *testing.T) {
+		if rec := put("b.txt", "job-1", "payload"); rec.Code != http.StatusConflict {
+			t.Errorf("期望 409, 实际 %d", rec.Code)
+		}
+	})
+
+	t.Run("过期后按新请求处理", func(t *testing.T) {
+		server.metadata.CleanExpiredPutIdempotency(time.Now().Add(2 * time.Hour))
+		if rec := put("a.txt", "job-1", "new payload"); rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("过期后应正常写入: %d", rec.Code)
+		}
+	})
+
+	t.Run("无效幂等键", func(t *testing.T) {
+		if rec := put("c.txt", "has space", "x"); rec.Code != http.StatusBadRequest {
+			t.Errorf("期望 400, 实际 %d", rec.Code)
+		}
+	})
+}
+
+// TestObjectCreatedAtHeader 测试 GET/HEAD 返回首次写入时间，覆盖后不变
+func TestObjectCreatedAtHeader(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "created-bucket", "a.txt", []byte("v1"))
+	obj, _ := server.metadata.GetObject("created-bucket", "a.txt")
+	server.metadata.DeleteObject("created-bucket", "a.txt")
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	obj.CreatedAt = created
+	obj.LastModified = created
+	server.metadata.PutObject(obj)
+
+	req := httptest.NewRequest(http.MethodPut, "/created-bucket/a.txt", strings.NewReader("v2"))
+	rec := httptest.NewRecorder()
+	server.handlePutObject(rec, req, "created-bucket", "a.txt")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("覆盖失败: %d", rec.Code)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		req := httptest.NewRequest(method, "/created-bucket/a.txt", nil)
+		rec := httptest.NewRecorder()
+		if method == http.MethodGet {
+			server.handleGetObject(rec, req, "created-bucket", "a.txt")
+		} else {
+			server.handleHeadObject(rec, req, "created-bucket", "a.txt")
+		}
+		if got := rec.Header().Get("x-amz-created-at"); got != "2024-01-02T03:04:05Z" {
+			t.Errorf("%s x-amz-created-at 错误: %q", method, got)
+		}
+		if lm, _ := http.ParseTime(rec.Header().Get("Last-Modified")); !lm.After(created) {
+			t.Errorf("%s Last-Modified 应为覆盖时间: %q", method, rec.Header().Get("Last-Modified"))
+		}
+	}
+}
+
+// TestObjectMaxReadAge 测试桶最大可读天数
+func TestObjectMaxReadAge(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "log-bucket", "old.log", []byte("old"))
+	if err := server.metadata.UpdateBucketMaxReadAge("log-bucket", 7, ""); err != nil {
+		t.Fatalf("设置最大可读天数失败: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPut, "/log-bucket/new.log", strings.NewReader("new"))
+	rec := httptest.NewRecorder()
+	server.handlePutObject(rec, req, "log-bucket", "new.log")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("上传失败: %d", rec.Code)
+	}
+	old, _ := server.metadata.GetObject("log-bucket", "old.log")
+	old.LastModified = time.Now().Add(-8 * 24 * time.Hour)
+	server.metadata.PutObject(old)
+
+	t.Run("过期对象返回410", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/log-bucket/old.log", nil)
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "log-bucket", "old.log")
+		if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "ObjectReadAgeExceeded") {
+			t.Errorf("GET 期望 410: %d %s", rec.Code, rec.Body.String())
+		}
+
+		req = httptest.NewRequest(http.MethodHead, "/log-bucket/old.log", nil)
+		rec = httptest.NewRecorder()
+		server.handleHeadObject(rec, req, "log-bucket", "old.log")
+		if rec.Code != http.StatusGone {
+			t.Errorf("HEAD 期望 410: %d", rec.Code)
+		}
+
+		if obj, _ := server.metadata.GetObject("log-bucket", "old.log"); obj == nil {
+			t.Error("对象不应被删除")
+		}
+	})
+
+	t.Run("窗口内对象可读", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/log-bucket/new.log", nil)
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "log-bucket", "new.log")
+		if rec.Code != http.StatusOK || rec.Body.String() != "new" {
+			t.Errorf("期望 200: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("按首次写入时间计算", func(t *testing.T) {
+		server.metadata.UpdateBucketMaxReadAge("log-bucket", 7, storage.ReadAgeBasisCreated)
+		// old.log 修改时间已超期，但创建时间在窗口内
+		old, _ := server.metadata.GetObject("log-bucket", "old.log")
+		server.metadata.DeleteObject("log-bucket", "old.log")
+		old.CreatedAt = time.Now()
+		server.metadata.PutObject(old)
+		req := httptest.NewRequest(http.MethodGet, "/log-bucket/old.log", nil)
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "log-bucket", "old.log")
+		if rec.Code != http.StatusOK {
+			t.Errorf("创建时间未超期时期望 200: %d", rec.Code)
+		}
+
+		obj, _ := server.metadata.GetObject("log-bucket", "new.log")
+		server.metadata.DeleteObject("log-bucket", "new.log")
+		obj.CreatedAt = time.Now().Add(-8 * 24 * time.Hour)
+		server.metadata.PutObject(obj)
+		req = httptest.NewRequest(http.MethodHead, "/log-bucket/new.log", nil)
+		rec = httptest.NewRecorder()
+		server.handleHeadObject(rec, req, "log-bucket", "new.log")
+		if rec.Code != http.StatusGone {
+			t.Errorf("创建时间超期时期望 410: %d", rec.Code)
+		}
+	})
+
+	t.Run("关闭后恢复读取", func(t *testing.T) {
+		server.metadata.UpdateBucketMaxReadAge("log-bucket", 0, "")
+		req := httptest.NewRequest(http.MethodGet, "/log-bucket/old.log", nil)
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "log-bucket", "old.log")
+		if rec.Code != http.StatusOK {
+			t.Errorf("期望 200: %d", rec.Code)
+		}
+	})
+}
+
+// TestImageTransform 测试图片按需缩放与变体缓存
+func TestImageTransform(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	server.metadata.CreateBucket("img-bucket")
+	putPNG := func(width, height int) {
+		t.Helper()
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for i := range img.Pix {
+			img.Pix[i] = 0xff
+		}
+		img.Set(0, 0, color.RGBA{A: 0xff})
+		var buf bytes.Buffer
+		png.Encode(&buf, img)
+		req := httptest.NewRequest(http.MethodPut, "/img-bucket/a.png", &buf)
+		req.Header.Set("Content-Type", "image/png")
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "img-bucket", "a.png")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("上传失败: %d %s", rec.Code, rec.Body.String())
+		}
 	}
-
-	// 上传对象
-	storagePath, etag, err := s.filestore.PutObject(bucket, key, bytes.NewReader(content), int64(len(content)))
-	if err != nil {
-		t.Fatalf("上传对象失败: %v", err)
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/img-bucket/a.png"+query, nil)
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "img-bucket", "a.png")
+		return rec
+	}
+	size := func(rec *httptest.ResponseRecorder) image.Point {
+		t.Helper()
+		cfg, err := png.DecodeConfig(rec.Body)
+		if err != nil {
+			t.Fatalf("解析 PNG 失败: %v", err)
+		}
+		return image.Pt(cfg.Width, cfg.Height)
 	}
+	putPNG(400, 200)
+	obj, _ := server.metadata.GetObject("img-bucket", "a.png")
 
-	obj := &storage.Object{
-		Key:         key,
-		Bucket:      bucket,
-		Size:        int64(len(content)),
-		ETag:        etag,
-		ContentType: "text/plain",
-		StoragePath: storagePath,
+	t.Run("未开启时忽略参数", func(t *testing.T) {
+		rec := get("?w=100")
+		if rec.Code != http.StatusOK || size(rec) != image.Pt(400, 200) {
+			t.Errorf("期望原图: %d", rec.Code)
+		}
+	})
+
+	server.metadata.UpdateBucketImageTransform("img-bucket", true)
+
+	t.Run("按比例缩放并缓存", func(t *testing.T) {
+		rec := get("?w=100&h=100")
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("缩放失败: %d %s", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("ETag") == `"`+obj.ETag+`"` {
+			t.Error("变体 ETag 不应与原对象相同")
+		}
+		if got := size(rec); got != image.Pt(100, 50) {
+			t.Errorf("期望 100x50，实际 %v", got)
+		}
+		if v, _ := server.metadata.GetObjectVariant("img-bucket", "a.png", obj.ETag, "w=100,h=100"); v == nil {
+			t.Error("变体应被缓存")
+		}
+		if got := size(get("?w=100&h=100")); got != image.Pt(100, 50) {
+			t.Errorf("缓存命中尺寸错误: %v", got)
+		}
+	})
+
+	t.Run("不放大", func(t *testing.T) {
+		rec := get("?w=1000")
+		if rec.Header().Get("ETag") != `"`+obj.ETag+`"` || size(rec) != image.Pt(400, 200) {
+			t.Error("目标尺寸大于原图时应返回原对象")
+		}
+	})
+
+	t.Run("无效参数", func(t *testing.T) {
+		for _, q := range []string{"?w=0", "?h=abc", "?w=5000"} {
+			if rec := get(q); rec.Code != http.StatusBadRequest {
+				t.Errorf("%s 期望 400: %d", q, rec.Code)
+			}
+		}
+	})
+
+	t.Run("源对象覆盖后生成新变体", func(t *testing.T) {
+		putPNG(200, 200)
+		if got := size(get("?w=100&h=100")); got != image.Pt(100, 100) {
+			t.Errorf("期望 100x100，实际 %v", got)
+		}
+		stale, err := server.metadata.ListStaleObjectVariants()
+		if err != nil || len(stale) != 1 || stale[0].SourceETag != obj.ETag {
+			t.Errorf("旧变体应标记为过期: %v %v", stale, err)
+		}
+	})
+
+	t.Run("非图片对象不变换", func(t *testing.T) {
+		createTestBucketAndObject(t, server, "txt-bucket", "a.txt", []byte("text"))
+		server.metadata.UpdateBucketImageTransform("txt-bucket", true)
+		req := httptest.NewRequest(http.MethodGet, "/txt-bucket/a.txt?w=10", nil)
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "txt-bucket", "a.txt")
+		if rec.Code != http.StatusOK || rec.Body.String() != "text" {
+			t.Errorf("期望原内容: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+// flushCountingRecorder 统计 Flush 调用次数
+type flushCountingRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushCountingRecorder) Flush() {
+	f.flushes++
+	f.ResponseRecorder.Flush()
+}
+
+// TestImageTransformStreaming 测试未命中缓存的变换结果以 chunked 方式分段发送
+func TestImageTransformStreaming(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	// 随机像素难以压缩，保证编码结果远大于刷新阈值
+	img := image.NewRGBA(image.Rect(0, 0, 600, 600))
+	rand.Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
 	}
-	if err := s.metadata.PutObject(obj); err != nil {
-		t.Fatalf("保存对象元数据失败: %v", err)
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	server.metadata.CreateBucket("img-bucket")
+	server.metadata.UpdateBucketImageTransform("img-bucket", true)
+	req := httptest.NewRequest(http.MethodPut, "/img-bucket/big.png", &buf)
+	req.Header.Set("Content-Type", "image/png")
+	rec := httptest.NewRecorder()
+	server.handlePutObject(rec, req, "img-bucket", "big.png")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("上传失败: %d %s", rec.Code, rec.Body.String())
 	}
+
+	t.Run("分段刷新", func(t *testing.T) {
+		rec := &flushCountingRecorder{ResponseRecorder: httptest.NewRecorder()}
+		req := httptest.NewRequest(http.MethodGet, "/img-bucket/big.png?w=500", nil)
+		server.handleGetObject(rec, req, "img-bucket", "big.png")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("期望 200: %d", rec.Code)
+		}
+		if rec.Header().Get("Content-Length") != "" {
+			t.Error("流式响应不应设置 Content-Length")
+		}
+		if want := rec.Body.Len() / streamFlushSize; rec.flushes < want || rec.flushes < 2 {
+			t.Errorf("期望至少 %d 次刷新，实际 %d（%d 字节）", want, rec.flushes, rec.Body.Len())
+		}
+		if cfg, err := png.DecodeConfig(rec.Body); err != nil || cfg.Width != 500 {
+			t.Errorf("流式结果无效: %v %v", cfg, err)
+		}
+	})
+
+	t.Run("经过 gzip 中间件仍为 chunked", func(t *testing.T) {
+		handler := utils.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			server.handleGetObject(w, r, "img-bucket", "big.png")
+		}))
+		ts := httptest.NewServer(handler)
+		defer ts.Close()
+
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/img-bucket/big.png?w=400", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.ContentLength != -1 || len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+			t.Errorf("期望 chunked 响应: length=%d encoding=%v", resp.ContentLength, resp.TransferEncoding)
+		}
+		if cfg, err := png.DecodeConfig(resp.Body); err != nil || cfg.Width != 400 {
+			t.Errorf("流式结果无效: %v %v", cfg, err)
+		}
+	})
+
+	t.Run("缓存命中带长度", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/img-bucket/big.png?w=500", nil)
+		server.handleGetObject(rec, req, "img-bucket", "big.png")
+		if rec.Header().Get("Content-Length") != strconv.Itoa(rec.Body.Len()) || rec.Header().Get("ETag") == "" {
+			t.Errorf("缓存命中应返回长度和 ETag: %v", rec.Header())
+		}
+	})
 }
 
 // TestHandleGetObject 测试获取对象
@@ -174,6 +1207,23 @@ func TestHandleGetObject(t *testing.T) {
 	}
 }
 
+// TestGetObjectResponseContentDisposition 测试 response-content-disposition 覆盖下载文件名
+func TestGetObjectResponseContentDisposition(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+	createTestBucketAndObject(t, server, "test-bucket", "file.txt", []byte("content"))
+
+	override := url.QueryEscape(`attachment; filename="年度报告.txt"`)
+	req := httptest.NewRequest(http.MethodGet, "/test-bucket/file.txt?response-content-disposition="+override, nil)
+	rec := httptest.NewRecorder()
+	server.handleGetObject(rec, req, "test-bucket", "file.txt")
+
+	want := `attachment; filename="____.txt"; filename*=UTF-8''%E5%B9%B4%E5%BA%A6%E6%8A%A5%E5%91%8A.txt`
+	if got := rec.Header().Get("Content-Disposition"); got != want {
+		t.Errorf("Content-Disposition 错误: got %q, want %q", got, want)
+	}
+}
+
 // TestHandleGetObjectRangeEdgeCases 测试Range请求边界情况
 func TestHandleGetObjectRangeEdgeCases(t *testing.T) {
 	server, cleanup := setupObjectTestServer(t)
@@ -187,6 +1237,7 @@ func TestHandleGetObjectRangeEdgeCases(t *testing.T) {
 		rangeHeader    string
 		expectedStatus int
 		expectedBody   string
+		contentRange   string
 	}{
 		{
 			name:           "超出范围的end",
@@ -205,6 +1256,46 @@ func TestHandleGetObjectRangeEdgeCases(t *testing.T) {
 			expectedStatus: http.StatusPartialContent,
 			expectedBody:   "56789",
 		},
+		{
+			name:           "后缀范围-最后5字节",
+			rangeHeader:    "bytes=-5",
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "56789",
+			contentRange:   "bytes 5-9/10",
+		},
+		{
+			name:           "后缀范围-超过对象大小",
+			rangeHeader:    "bytes=-100",
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "0123456789",
+			contentRange:   "bytes 0-9/10",
+		},
+		{
+			name:           "后缀范围-零字节",
+			rangeHeader:    "bytes=-0",
+			expectedStatus: http.StatusRequestedRangeNotSatisfiable,
+			contentRange:   "bytes */10",
+		},
+		{
+			name:           "后缀范围-最后3字节",
+			rangeHeader:    "bytes=-3",
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "789",
+			contentRange:   "bytes 7-9/10",
+		},
+		{
+			name:           "多范围-丢弃不可满足的范围",
+			rangeHeader:    "bytes=2-4,20-30",
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "234",
+			contentRange:   "bytes 2-4/10",
+		},
+		{
+			name:           "多范围-全部不可满足",
+			rangeHeader:    "bytes=20-30,-0",
+			expectedStatus: http.StatusRequestedRangeNotSatisfiable,
+			contentRange:   "bytes */10",
+		},
 	}
 
 	for _, tc := range tests {
@@ -222,8 +1313,105 @@ func TestHandleGetObjectRangeEdgeCases(t *testing.T) {
 			if tc.expectedBody != "" && rec.Body.String() != tc.expectedBody {
 				t.Errorf("响应体错误: 期望 %q, 实际 %q", tc.expectedBody, rec.Body.String())
 			}
+			if tc.contentRange != "" && rec.Header().Get("Content-Range") != tc.contentRange {
+				t.Errorf("Content-Range 错误: 期望 %q, 实际 %q", tc.contentRange, rec.Header().Get("Content-Range"))
+			}
+		})
+	}
+
+	t.Run("多范围返回multipart/byteranges", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/range-test/data.bin", nil)
+		req.Header.Set("Range", "bytes=0-1, -3")
+		rec := httptest.NewRecorder()
+
+		server.handleGetObject(rec, req, "range-test", "data.bin")
+
+		if rec.Code != http.StatusPartialContent {
+			t.Fatalf("状态码错误: 期望 %d, 实际 %d", http.StatusPartialContent, rec.Code)
+		}
+		mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+		if err != nil || mediaType != "multipart/byteranges" {
+			t.Fatalf("Content-Type 错误: %q", rec.Header().Get("Content-Type"))
+		}
+		if rec.Header().Get("Content-Length") != strconv.Itoa(rec.Body.Len()) {
+			t.Errorf("Content-Length 错误: 头 %s, 实际 %d", rec.Header().Get("Content-Length"), rec.Body.Len())
+		}
+
+		want := []struct{ body, contentRange string }{
+			{"01", "bytes 0-1/10"},
+			{"789", "bytes 7-9/10"},
+		}
+		mr := multipart.NewReader(rec.Body, params["boundary"])
+		for i, w := range want {
+			part, err := mr.NextPart()
+			if err != nil {
+				t.Fatalf("读取第 %d 段失败: %v", i, err)
+			}
+			body, _ := io.ReadAll(part)
+			if string(body) != w.body || part.Header.Get("Content-Range") != w.contentRange {
+				t.Errorf("第 %d 段错误: %q %q", i, body, part.Header.Get("Content-Range"))
+			}
+		}
+		if _, err := mr.NextPart(); err != io.EOF {
+			t.Errorf("应只有 %d 段: %v", len(want), err)
+		}
+	})
+}
+
+// TestHandleGetObjectIfRange 测试 If-Range：与当前对象一致时返回范围，否则返回完整对象
+func TestHandleGetObjectIfRange(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	content := []byte("0123456789")
+	createTestBucketAndObject(t, server, "if-range", "data.bin", content)
+	obj, _ := server.metadata.GetObject("if-range", "data.bin")
+	lastModified := obj.LastModified.UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name    string
+		ifRange string
+		partial bool
+	}{
+		{"ETag 一致", `"` + obj.ETag + `"`, true},
+		{"ETag 不一致", `"0123456789abcdef0123456789abcdef"`, false},
+		{"弱 ETag 不匹配", `W/"` + obj.ETag + `"`, false},
+		{"日期一致", lastModified, true},
+		{"日期较早", obj.LastModified.Add(-time.Hour).UTC().Format(http.TimeFormat), false},
+		{"日期较晚", obj.LastModified.Add(time.Hour).UTC().Format(http.TimeFormat), false},
+		{"无法解析", "not-a-date", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/if-range/data.bin", nil)
+			req.Header.Set("Range", "bytes=2-4")
+			req.Header.Set("If-Range", tc.ifRange)
+			rec := httptest.NewRecorder()
+
+			server.handleGetObject(rec, req, "if-range", "data.bin")
+
+			if tc.partial {
+				if rec.Code != http.StatusPartialContent || rec.Body.String() != "234" || rec.Header().Get("Content-Range") != "bytes 2-4/10" {
+					t.Errorf("应返回范围: %d %q %q", rec.Code, rec.Body.String(), rec.Header().Get("Content-Range"))
+				}
+				return
+			}
+			if rec.Code != http.StatusOK || rec.Body.String() != string(content) || rec.Header().Get("Content-Range") != "" {
+				t.Errorf("应返回完整对象: %d %q", rec.Code, rec.Body.String())
+			}
 		})
 	}
+
+	t.Run("不一致时忽略无效范围", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/if-range/data.bin", nil)
+		req.Header.Set("Range", "bytes=20-30")
+		req.Header.Set("If-Range", `"changed"`)
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "if-range", "data.bin")
+		if rec.Code != http.StatusOK || rec.Body.String() != string(content) {
+			t.Errorf("应返回完整对象: %d %q", rec.Code, rec.Body.String())
+		}
+	})
 }
 
 // TestHandlePutObject 测试上传对象
@@ -322,6 +1510,342 @@ func TestHandlePutObject(t *testing.T) {
 	}
 }
 
+// TestHandlePutObjectDigest 测试 Content-MD5 和 x-amz-content-sha256 校验
+func TestHandlePutObjectDigest(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	if err := server.metadata.CreateBucket("digest-bucket"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+	content := []byte("digest content")
+	md5Sum := md5.Sum(content)
+	shaSum := sha256.Sum256(content)
+	otherMD5 := md5.Sum([]byte("other"))
+	otherSHA := sha256.Sum256([]byte("other"))
+
+	tests := []struct {
+		name           string
+		key            string
+		contentMD5     string
+		contentSHA256  string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"正确的 Content-MD5", "md5-ok.txt", base64.StdEncoding.EncodeToString(md5Sum[:]), "", http.StatusOK, ""},
+		{"错误的 Content-MD5", "md5-bad.txt", base64.StdEncoding.EncodeToString(otherMD5[:]), "", http.StatusBadRequest, "BadDigest"},
+		{"格式错误的 Content-MD5", "md5-invalid.txt", "not-base64!", "", http.StatusBadRequest, "InvalidDigest"},
+		{"长度错误的 Content-MD5", "md5-short.txt", base64.StdEncoding.EncodeToString([]byte("short")), "", http.StatusBadRequest, "InvalidDigest"},
+		{"正确的 SHA256", "sha-ok.txt", "", hex.EncodeToString(shaSum[:]), http.StatusOK, ""},
+		{"错误的 SHA256", "sha-bad.txt", "", hex.EncodeToString(otherSHA[:]), http.StatusBadRequest, "XAmzContentSHA256Mismatch"},
+		{"UNSIGNED-PAYLOAD 不校验", "unsigned.txt", "", "UNSIGNED-PAYLOAD", http.StatusOK, ""},
+		{"两者都正确", "both-ok.txt", base64.StdEncoding.EncodeToString(md5Sum[:]), hex.EncodeToString(shaSum[:]), http.StatusOK, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/digest-bucket/"+tc.key, bytes.NewReader(content))
+			req.ContentLength = int64(len(content))
+			if tc.contentMD5 != "" {
+				req.Header.Set("Content-MD5", tc.contentMD5)
+			}
+			if tc.contentSHA256 != "" {
+				req.Header.Set("X-Amz-Content-Sha256", tc.contentSHA256)
+			}
+			rec := httptest.NewRecorder()
+			server.handlePutObject(rec, req, "digest-bucket", tc.key)
+
+			if rec.Code != tc.expectedStatus {
+				t.Fatalf("状态码错误: 期望 %d, 实际 %d, 响应: %s", tc.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if tc.expectedCode != "" && !strings.Contains(rec.Body.String(), "<Code>"+tc.expectedCode+"</Code>") {
+				t.Errorf("错误码应为 %s: %s", tc.expectedCode, rec.Body.String())
+			}
+			obj, _ := server.metadata.GetObject("digest-bucket", tc.key)
+			if (obj != nil) != (tc.expectedStatus == http.StatusOK) {
+				t.Errorf("对象是否保存错误: %v", obj)
+			}
+		})
+	}
+
+	// 摘要不一致的覆盖不破坏已有对象
+	req := httptest.NewRequest(http.MethodPut, "/digest-bucket/md5-ok.txt", strings.NewReader("corrupted"))
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5Sum[:]))
+	rec := httptest.NewRecorder()
+	server.handlePutObject(rec, req, "digest-bucket", "md5-ok.txt")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("覆盖时摘要不一致应返回 400: %d", rec.Code)
+	}
+	obj, _ := server.metadata.GetObject("digest-bucket", "md5-ok.txt")
+	if obj == nil || obj.ETag != hex.EncodeToString(md5Sum[:]) {
+		t.Fatalf("原对象元数据应保留: %+v", obj)
+	}
+	data, err := os.ReadFile(obj.StoragePath)
+	if err != nil || !bytes.Equal(data, content) {
+		t.Errorf("原对象文件应保留: %q, %v", data, err)
+	}
+}
+
+// TestHandlePutObjectAWSChunked 测试 aws-chunked 流式分块上传只保存解码后的数据
+func TestHandlePutObjectAWSChunked(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	oldAuth := config.Global.Auth
+	config.Global.Auth.AccessKeyID = "CHUNKED_TEST_KEY"
+	config.Global.Auth.SecretAccessKey = "CHUNKED_TEST_SECRET"
+	defer func() { config.Global.Auth = oldAuth }()
+
+	if err := server.metadata.CreateBucket("chunked-bucket"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+
+	const (
+		amzDate  = "20240101T000000Z"
+		scope    = "20240101/us-east-1/s3/aws4_request"
+		seedSig  = "4f232c4386841ef735655705268965c44a0e4690baa4adea153f7db9fa80a0a9"
+		emptySHA = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	)
+	hmacSum := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	signingKey := hmacSum(hmacSum(hmacSum(hmacSum([]byte("AWS4CHUNKED_TEST_SECRET"), "20240101"), "us-east-1"), "s3"), "aws4_request")
+
+	// buildBody 按分块格式编码，signed 时计算签名链，最后一块为零长度块
+	buildBody := func(chunks []string, signed bool) string {
+		var body strings.Builder
+		prev := seedSig
+		for _, chunk := range append(chunks, "") {
+			body.WriteString(strconv.FormatInt(int64(len(chunk)), 16))
+			if signed {
+				sum := sha256.Sum256([]byte(chunk))
+				stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256-PAYLOAD", amzDate, scope, prev, emptySHA, hex.EncodeToString(sum[:])}, "\n")
+				prev = hex.EncodeToString(hmacSum(signingKey, stringToSign))
+				body.WriteString(";chunk-signature=" + prev)
+			}
+			body.WriteString("\r\n" + chunk + "\r\n")
+		}
+		if !signed {
+			body.WriteString("x-amz-checksum-crc32:AAAAAA==\r\n\r\n")
+		}
+		return body.String()
+	}
+	chunks := []string{strings.Repeat("a", 70000), "hello chunked"}
+	content := strings.Join(chunks, "")
+
+	put := func(key, body, payload string, decodedLength int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/chunked-bucket/"+key, strings.NewReader(body))
+		req.Header.Set("Content-Encoding", "aws-chunked")
+		req.Header.Set("X-Amz-Content-Sha256", payload)
+		req.Header.Set("X-Amz-Decoded-Content-Length", strconv.Itoa(decodedLength))
+		req.Header.Set("X-Amz-Date", amzDate)
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=CHUNKED_TEST_KEY/"+scope+", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="+seedSig)
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "chunked-bucket", key)
+		return rec
+	}
+	checkStored := func(t *testing.T, key string) {
+		t.Helper()
+		obj, _ := server.metadata.GetObject("chunked-bucket", key)
+		if obj == nil {
+			t.Fatal("对象应已保存")
+		}
+		data, err := os.ReadFile(obj.StoragePath)
+		if err != nil || string(data) != content || obj.Size != int64(len(content)) {
+			t.Errorf("保存的内容应为解码后的数据: size=%d, err=%v", obj.Size, err)
+		}
+		if obj.Headers["Content-Encoding"] != "" {
+			t.Errorf("不应保存 aws-chunked 编码: %v", obj.Headers)
+		}
+	}
+
+	t.Run("签名分块", func(t *testing.T) {
+		rec := put("signed.txt", buildBody(chunks, true), "STREAMING-AWS4-HMAC-SHA256-PAYLOAD", len(content))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, %s", rec.Code, rec.Body.String())
+		}
+		checkStored(t, "signed.txt")
+	})
+
+	t.Run("未签名分块带结尾校验和", func(t *testing.T) {
+		rec := put("unsigned.txt", buildBody(chunks, false), "STREAMING-UNSIGNED-PAYLOAD-TRAILER", len(content))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, %s", rec.Code, rec.Body.String())
+		}
+		checkStored(t, "unsigned.txt")
+	})
+
+	t.Run("分块签名错误", func(t *testing.T) {
+		body := strings.Replace(buildBody(chunks, true), "hello", "HELLO", 1)
+		rec := put("tampered.txt", body, "STREAMING-AWS4-HMAC-SHA256-PAYLOAD", len(content))
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "SignatureDoesNotMatch") {
+			t.Errorf("应返回 403 SignatureDoesNotMatch: %d, %s", rec.Code, rec.Body.String())
+		}
+		if obj, _ := server.metadata.GetObject("chunked-bucket", "tampered.txt"); obj != nil {
+			t.Error("签名错误时不应保存对象")
+		}
+	})
+
+	t.Run("解码长度不一致", func(t *testing.T) {
+		rec := put("short.txt", buildBody(chunks, true), "STREAMING-AWS4-HMAC-SHA256-PAYLOAD", len(content)+1)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "IncompleteBody") {
+			t.Errorf("应返回 400 IncompleteBody: %d, %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("签名结尾格式不支持", func(t *testing.T) {
+		rec := put("trailer.txt", buildBody(chunks, true), "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER", len(content))
+		if rec.Code != http.StatusNotImplemented || !strings.Contains(rec.Body.String(), "NotImplemented") {
+			t.Errorf("应返回 501 NotImplemented: %d, %s", rec.Code, rec.Body.String())
+		}
+		if obj, _ := server.metadata.GetObject("chunked-bucket", "trailer.txt"); obj != nil {
+			t.Error("不支持的格式不应保存对象")
+		}
+	})
+
+	t.Run("缺少解码长度", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/chunked-bucket/nolen.txt", strings.NewReader(buildBody(chunks, false)))
+		req.Header.Set("X-Amz-Content-Sha256", "STREAMING-UNSIGNED-PAYLOAD-TRAILER")
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "chunked-bucket", "nolen.txt")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("应返回 400: %d", rec.Code)
+		}
+	})
+}
+
+// TestConditionalReadObject 测试 GET/HEAD 的 If-Match / If-None-Match / If-Modified-Since / If-Unmodified-Since
+func TestConditionalReadObject(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "cond-bucket", "doc.txt", []byte("conditional"))
+	obj, _ := server.metadata.GetObject("cond-bucket", "doc.txt")
+	etag := `"` + obj.ETag + `"`
+	modified := obj.LastModified.UTC()
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+	exact := modified.Format(http.TimeFormat)
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{"无条件", nil, http.StatusOK},
+		{"If-Match 匹配", map[string]string{"If-Match": etag}, http.StatusOK},
+		{"If-Match 列表匹配", map[string]string{"If-Match": `"other", ` + etag}, http.StatusOK},
+		{"If-Match 星号", map[string]string{"If-Match": "*"}, http.StatusOK},
+		{"If-Match 不匹配", map[string]string{"If-Match": `"other"`}, http.StatusPreconditionFailed},
+		{"If-None-Match 匹配", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"If-None-Match 弱校验匹配", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"If-None-Match 不匹配", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"If-Modified-Since 之后未修改", map[string]string{"If-Modified-Since": after}, http.StatusNotModified},
+		{"If-Modified-Since 等于修改时间", map[string]string{"If-Modified-Since": exact}, http.StatusNotModified},
+		{"If-Modified-Since 之后已修改", map[string]string{"If-Modified-Since": before}, http.StatusOK},
+		{"If-Modified-Since 格式错误被忽略", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"If-Unmodified-Since 之后未修改", map[string]string{"If-Unmodified-Since": after}, http.StatusOK},
+		{"If-Unmodified-Since 之后已修改", map[string]string{"If-Unmodified-Since": before}, http.StatusPreconditionFailed},
+		{"If-Match 优先于 If-Unmodified-Since", map[string]string{"If-Match": etag, "If-Unmodified-Since": before}, http.StatusOK},
+		{"If-None-Match 优先于 If-Modified-Since", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": after}, http.StatusOK},
+		{"If-None-Match 匹配且 If-Modified-Since 已修改", map[string]string{"If-None-Match": etag, "If-Modified-Since": before}, http.StatusNotModified},
+		{"If-Match 不匹配优先于 304", map[string]string{"If-Match": `"other"`, "If-None-Match": etag}, http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range tests {
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			t.Run(tc.name+"/"+method, func(t *testing.T) {
+				req := httptest.NewRequest(method, "/cond-bucket/doc.txt", nil)
+				for k, v := range tc.headers {
+					req.Header.Set(k, v)
+				}
+				rec := httptest.NewRecorder()
+				if method == http.MethodGet {
+					server.handleGetObject(rec, req, "cond-bucket", "doc.txt")
+				} else {
+					server.handleHeadObject(rec, req, "cond-bucket", "doc.txt")
+				}
+
+				if rec.Code != tc.expectedStatus {
+					t.Fatalf("状态码错误: 期望 %d, 实际 %d", tc.expectedStatus, rec.Code)
+				}
+				switch rec.Code {
+				case http.StatusNotModified:
+					if rec.Body.Len() != 0 || rec.Header().Get("ETag") != etag || rec.Header().Get("Last-Modified") == "" {
+						t.Errorf("304 应只返回 ETag 和 Last-Modified: %v %q", rec.Header(), rec.Body.String())
+					}
+				case http.StatusOK:
+					if method == http.MethodGet && rec.Body.String() != "conditional" {
+						t.Errorf("响应内容错误: %q", rec.Body.String())
+					}
+				case http.StatusPreconditionFailed:
+					if method == http.MethodGet && !strings.Contains(rec.Body.String(), "PreconditionFailed") {
+						t.Errorf("应返回 PreconditionFailed: %s", rec.Body.String())
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestHandlePutObjectConditional 测试 PUT 的 If-None-Match: * 和 If-Match 条件写入
+func TestHandlePutObjectConditional(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	if err := server.metadata.CreateBucket("cond-put"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+	put := func(content string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/cond-put/doc.txt", strings.NewReader(content))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "cond-put", "doc.txt")
+		return rec
+	}
+
+	if rec := put("v1", map[string]string{"If-Match": "*"}); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("对象不存在时 If-Match 应返回 412: %d", rec.Code)
+	}
+	if rec := put("v1", map[string]string{"If-None-Match": "*"}); rec.Code != http.StatusOK {
+		t.Fatalf("对象不存在时 If-None-Match: * 应创建: %d %s", rec.Code, rec.Body.String())
+	}
+	original, _ := server.metadata.GetObject("cond-put", "doc.txt")
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{"If-None-Match 目标已存在", map[string]string{"If-None-Match": "*"}, http.StatusPreconditionFailed},
+		{"If-Match 不匹配", map[string]string{"If-Match": `"other"`}, http.StatusPreconditionFailed},
+		{"不支持的 If-None-Match", map[string]string{"If-None-Match": `"` + original.ETag + `"`}, http.StatusNotImplemented},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := put("v2", tc.headers); rec.Code != tc.expectedStatus {
+				t.Fatalf("状态码错误: 期望 %d, 实际 %d", tc.expectedStatus, rec.Code)
+			}
+			obj, _ := server.metadata.GetObject("cond-put", "doc.txt")
+			data, err := os.ReadFile(obj.StoragePath)
+			if obj.ETag != original.ETag || err != nil || string(data) != "v1" {
+				t.Errorf("条件不满足时原对象应保持不变: %s %q %v", obj.ETag, data, err)
+			}
+		})
+	}
+
+	if rec := put("v2", map[string]string{"If-Match": `"` + original.ETag + `"`}); rec.Code != http.StatusOK {
+		t.Fatalf("If-Match 匹配时应覆盖: %d", rec.Code)
+	}
+	if obj, _ := server.metadata.GetObject("cond-put", "doc.txt"); obj.ETag == original.ETag {
+		t.Error("对象应已被覆盖")
+	}
+}
+
 // TestHandlePutObjectWithSizeLimit 测试上传对象大小限制
 func TestHandlePutObjectWithSizeLimit(t *testing.T) {
 	server, cleanup := setupObjectTestServer(t)
@@ -372,6 +1896,34 @@ func TestHandlePutObjectWithSizeLimit(t *testing.T) {
 		}
 	})
 
+	t.Run("未知长度上传按上限流式写入", func(t *testing.T) {
+		config.Global.Storage.MaxUploadSize = 100
+		config.Global.Storage.MaxObjectSize = 0
+
+		put := func(key string, n int) int {
+			req := httptest.NewRequest(http.MethodPut, "/limit-bucket/"+key, io.NopCloser(bytes.NewReader(make([]byte, n))))
+			req.ContentLength = -1
+			rec := httptest.NewRecorder()
+			server.handlePutObject(rec, req, "limit-bucket", key)
+			return rec.Code
+		}
+
+		if code := put("chunked.bin", 60); code != http.StatusOK {
+			t.Fatalf("期望状态码 %d, 实际 %d", http.StatusOK, code)
+		}
+		obj, _ := server.metadata.GetObject("limit-bucket", "chunked.bin")
+		if obj == nil || obj.Size != 60 {
+			t.Errorf("元数据应记录实际大小: %+v", obj)
+		}
+
+		if code := put("chunked-big.bin", 200); code != http.StatusBadRequest {
+			t.Errorf("期望状态码 %d, 实际 %d", http.StatusBadRequest, code)
+		}
+		if obj, _ := server.metadata.GetObject("limit-bucket", "chunked-big.bin"); obj != nil {
+			t.Error("超限对象不应保存元数据")
+		}
+	})
+
 	t.Run("预签名URL内容类型限制", func(t *testing.T) {
 		content := []byte("test")
 		req := httptest.NewRequest(http.MethodPut, "/limit-bucket/typed.bin?X-Amz-Content-Type=application/json", bytes.NewReader(content))
@@ -387,6 +1939,89 @@ func TestHandlePutObjectWithSizeLimit(t *testing.T) {
 	})
 }
 
+// TestHandlePutObjectAutoCreateBucket 测试上传时自动创建桶
+func TestHandlePutObjectAutoCreateBucket(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	origStorage := config.Global.Storage
+	defer func() { config.Global.Storage = origStorage }()
+
+	put := func(bucket string) int {
+		content := []byte("auto")
+		req := httptest.NewRequest(http.MethodPut, "/"+bucket+"/file.txt", bytes.NewReader(content))
+		req.ContentLength = int64(len(content))
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, bucket, "file.txt")
+		return rec.Code
+	}
+
+	t.Run("默认不自动建桶", func(t *testing.T) {
+		config.Global.Storage.AutoCreate = false
+		if code := put("strict-bucket"); code != http.StatusNotFound {
+			t.Errorf("期望状态码 %d, 实际 %d", http.StatusNotFound, code)
+		}
+	})
+
+	t.Run("开启后自动建桶并记录审计", func(t *testing.T) {
+		config.Global.Storage.AutoCreate = true
+		if code := put("auto-bucket"); code != http.StatusOK {
+			t.Fatalf("期望状态码 %d, 实际 %d", http.StatusOK, code)
+		}
+		if b, _ := server.metadata.GetBucket("auto-bucket"); b == nil {
+			t.Fatal("桶应该已被创建")
+		}
+		if obj, _ := server.metadata.GetObject("auto-bucket", "file.txt"); obj == nil {
+			t.Error("对象应该已被保存")
+		}
+
+		logs, _, err := server.metadata.QueryAuditLogs(&storage.AuditLogQuery{Action: storage.AuditActionBucketCreate, Limit: 10})
+		if err != nil {
+			t.Fatalf("查询审计日志失败: %v", err)
+		}
+		if len(logs) != 1 || logs[0].Resource != "auto-bucket" || !strings.Contains(logs[0].Detail, "implicit") {
+			t.Errorf("审计日志错误: %+v", logs)
+		}
+	})
+
+	t.Run("带命名空间时在命名空间下建桶", func(t *testing.T) {
+		config.Global.Storage.AutoCreate = true
+		content := []byte("tenant")
+		req := httptest.NewRequest(http.MethodPut, "/docs/file.txt", bytes.NewReader(content))
+		req.ContentLength = int64(len(content))
+		req = req.WithContext(context.WithValue(req.Context(), ContextKeyNamespace, "acme-"))
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "docs", "file.txt")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d, 实际 %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if b, _ := server.metadata.GetBucket("docs"); b != nil {
+			t.Error("不应创建命名空间外的桶")
+		}
+		if obj, _ := server.metadata.GetObject("acme-docs", "file.txt"); obj == nil {
+			t.Error("对象应保存在命名空间下的桶中")
+		}
+	})
+
+	t.Run("无效桶名被拒绝", func(t *testing.T) {
+		config.Global.Storage.AutoCreate = true
+		if code := put("Invalid_Bucket"); code != http.StatusBadRequest {
+			t.Errorf("期望状态码 %d, 实际 %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("超过桶数量上限被拒绝", func(t *testing.T) {
+		config.Global.Storage.AutoCreate = true
+		config.Global.Storage.MaxBuckets = 1
+		if code := put("another-bucket"); code != http.StatusBadRequest {
+			t.Errorf("期望状态码 %d, 实际 %d", http.StatusBadRequest, code)
+		}
+		if b, _ := server.metadata.GetBucket("another-bucket"); b != nil {
+			t.Error("超过上限不应创建桶")
+		}
+	})
+}
+
 // TestHandleDeleteObject 测试删除对象
 func TestHandleDeleteObject(t *testing.T) {
 	server, cleanup := setupObjectTestServer(t)
@@ -440,6 +2075,67 @@ func TestHandleDeleteObject(t *testing.T) {
 	})
 }
 
+// TestHandleDeleteObjectConditional 测试 If-Match / x-amz-if-match-size 条件删除
+func TestHandleDeleteObjectConditional(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "cond-bucket", "doc.txt", []byte("version one"))
+
+	del := func(headers map[string]string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/cond-bucket/doc.txt", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		server.handleDeleteObject(rec, req, "cond-bucket", "doc.txt")
+		return rec.Code
+	}
+
+	t.Run("读取后ETag变化拒绝删除", func(t *testing.T) {
+		read, _ := server.metadata.GetObject("cond-bucket", "doc.txt")
+
+		// 读取与删除之间对象被覆盖
+		put := httptest.NewRequest(http.MethodPut, "/cond-bucket/doc.txt", strings.NewReader("version two"))
+		server.handlePutObject(httptest.NewRecorder(), put, "cond-bucket", "doc.txt")
+
+		if code := del(map[string]string{"If-Match": `"` + read.ETag + `"`}); code != http.StatusPreconditionFailed {
+			t.Fatalf("期望状态码 %d, 实际 %d", http.StatusPreconditionFailed, code)
+		}
+		obj, _ := server.metadata.GetObject("cond-bucket", "doc.txt")
+		if obj == nil {
+			t.Fatal("对象不应被删除")
+		}
+		data, _ := os.ReadFile(obj.StoragePath)
+		if string(data) != "version two" {
+			t.Errorf("新版本内容应保留: %q", data)
+		}
+	})
+
+	t.Run("大小不匹配拒绝删除", func(t *testing.T) {
+		if code := del(map[string]string{"x-amz-if-match-size": "3"}); code != http.StatusPreconditionFailed {
+			t.Errorf("期望状态码 %d, 实际 %d", http.StatusPreconditionFailed, code)
+		}
+		if code := del(map[string]string{"x-amz-if-match-size": "abc"}); code != http.StatusBadRequest {
+			t.Errorf("无效大小应返回400: %d", code)
+		}
+	})
+
+	t.Run("条件满足时删除", func(t *testing.T) {
+		obj, _ := server.metadata.GetObject("cond-bucket", "doc.txt")
+		code := del(map[string]string{
+			"If-Match":            `"` + obj.ETag + `"`,
+			"x-amz-if-match-size": strconv.FormatInt(obj.Size, 10),
+		})
+		if code != http.StatusNoContent {
+			t.Fatalf("期望状态码 %d, 实际 %d", http.StatusNoContent, code)
+		}
+		if obj, _ := server.metadata.GetObject("cond-bucket", "doc.txt"); obj != nil {
+			t.Error("对象应已被删除")
+		}
+	})
+}
+
 // TestHandleCopyObject 测试复制对象
 func TestHandleCopyObject(t *testing.T) {
 	server, cleanup := setupObjectTestServer(t)
@@ -495,6 +2191,27 @@ func TestHandleCopyObject(t *testing.T) {
 			copySource:     "/src-bucket/nonexistent.txt",
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name:           "指定当前版本null",
+			destBucket:     "dest-bucket",
+			destKey:        "copied-null.txt",
+			copySource:     "/src-bucket/original.txt?versionId=null",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "指定不存在的版本",
+			destBucket:     "dest-bucket",
+			destKey:        "copied.txt",
+			copySource:     "/src-bucket/original.txt?versionId=3HL4kqtJlcpXroDTDmJ",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "缺少版本号",
+			destBucket:     "dest-bucket",
+			destKey:        "copied.txt",
+			copySource:     "/src-bucket/original.txt?versionId=",
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:           "缺少copy-source头",
 			destBucket:     "dest-bucket",
@@ -514,6 +2231,7 @@ func TestHandleCopyObject(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPut, "/"+tc.destBucket+"/"+tc.destKey, nil)
+			req = asAdmin(t, req)
 			if tc.copySource != "" {
 				req.Header.Set("x-amz-copy-source", tc.copySource)
 			}
@@ -534,6 +2252,9 @@ func TestHandleCopyObject(t *testing.T) {
 				if !strings.Contains(body, "<ETag>") {
 					t.Error("响应应包含ETag")
 				}
+				if strings.Contains(tc.copySource, "?versionId=") && rec.Header().Get("x-amz-copy-source-version-id") != "null" {
+					t.Error("响应应包含源版本号")
+				}
 			}
 		})
 	}
@@ -570,6 +2291,7 @@ func TestHandleCopyObjectPathTraversal(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPut, "/secure-bucket/target.txt", nil)
+			req = asAdmin(t, req)
 			req.Header.Set("x-amz-copy-source", tc.copySource)
 			rec := httptest.NewRecorder()
 
@@ -592,6 +2314,7 @@ func TestHandleCopyObjectURLEncoding(t *testing.T) {
 
 	t.Run("URL编码的中文路径", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPut, "/encoding-bucket/copied-file.txt", nil)
+		req = asAdmin(t, req)
 		// URL编码的中文
 		req.Header.Set("x-amz-copy-source", "/encoding-bucket/%E4%B8%AD%E6%96%87%E6%96%87%E4%BB%B6.txt")
 		rec := httptest.NewRecorder()
@@ -604,6 +2327,154 @@ func TestHandleCopyObjectURLEncoding(t *testing.T) {
 	})
 }
 
+// TestHandleCopyObjectRange 测试按字节范围复制对象
+func TestHandleCopyObjectRange(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	createTestBucketAndObject(t, server, "range-bucket", "source.txt", content)
+
+	t.Run("复制10-20字节", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/range-bucket/part.txt", nil)
+		req = asAdmin(t, req)
+		req.Header.Set("x-amz-copy-source", "/range-bucket/source.txt")
+		req.Header.Set("x-amz-copy-source-range", "bytes=10-20")
+		rec := httptest.NewRecorder()
+
+		server.handleCopyObject(rec, req, "range-bucket", "part.txt")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, 响应: %s", rec.Code, rec.Body.String())
+		}
+		obj, err := server.metadata.GetObject("range-bucket", "part.txt")
+		if err != nil || obj == nil {
+			t.Fatalf("获取目标对象失败: %v", err)
+		}
+		if obj.Size != 11 {
+			t.Errorf("目标对象大小错误: 期望 11, 实际 %d", obj.Size)
+		}
+		data, err := os.ReadFile(obj.StoragePath)
+		if err != nil {
+			t.Fatalf("读取目标文件失败: %v", err)
+		}
+		if string(data) != "abcdefghijk" {
+			t.Errorf("目标对象内容错误: %q", data)
+		}
+		if !strings.Contains(rec.Body.String(), obj.ETag) {
+			t.Errorf("响应 ETag 应与目标对象一致: %s", rec.Body.String())
+		}
+	})
+
+	invalid := []string{
+		"bytes=10-36",
+		"bytes=20-10",
+		"bytes=10-",
+		"bytes=-5",
+		"10-20",
+		"bytes=a-b",
+	}
+	for _, rangeHeader := range invalid {
+		t.Run("无效范围 "+rangeHeader, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/range-bucket/bad.txt", nil)
+			req = asAdmin(t, req)
+			req.Header.Set("x-amz-copy-source", "/range-bucket/source.txt")
+			req.Header.Set("x-amz-copy-source-range", rangeHeader)
+			rec := httptest.NewRecorder()
+
+			server.handleCopyObject(rec, req, "range-bucket", "bad.txt")
+
+			if rec.Code != http.StatusRequestedRangeNotSatisfiable {
+				t.Errorf("状态码错误: 期望 416, 实际 %d", rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), "InvalidRange") {
+				t.Errorf("应返回 InvalidRange: %s", rec.Body.String())
+			}
+			if obj, _ := server.metadata.GetObject("range-bucket", "bad.txt"); obj != nil {
+				t.Error("无效范围不应创建目标对象")
+			}
+		})
+	}
+}
+
+// TestHandleCopyObjectMetadataDirective 测试 x-amz-metadata-directive 的 COPY 与 REPLACE
+func TestHandleCopyObjectMetadataDirective(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	createTestBucketAndObject(t, server, "directive-bucket", "src.txt", []byte("hello"))
+	src, _ := server.metadata.GetObject("directive-bucket", "src.txt")
+	src.Headers = map[string]string{"Cache-Control": "max-age=60", "Content-Disposition": "inline"}
+	server.metadata.PutObject(src)
+
+	copyObject := func(destKey, directive string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/directive-bucket/"+destKey, nil)
+		req = asAdmin(t, req)
+		req.Header.Set("x-amz-copy-source", "/directive-bucket/src.txt")
+		if directive != "" {
+			req.Header.Set("x-amz-metadata-directive", directive)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		server.handleCopyObject(rec, req, "directive-bucket", destKey)
+		return rec
+	}
+	newHeaders := map[string]string{"Content-Type": "application/json", "Cache-Control": "no-cache"}
+
+	for _, directive := range []string{"", "COPY"} {
+		t.Run("保留源元数据 "+directive, func(t *testing.T) {
+			if rec := copyObject("copy.txt", directive, newHeaders); rec.Code != http.StatusOK {
+				t.Fatalf("复制失败: %d %s", rec.Code, rec.Body.String())
+			}
+			obj, _ := server.metadata.GetObject("directive-bucket", "copy.txt")
+			if obj.ContentType != "text/plain" || obj.Headers["Cache-Control"] != "max-age=60" || obj.Headers["Content-Disposition"] != "inline" {
+				t.Errorf("应保留源对象元数据: %s %v", obj.ContentType, obj.Headers)
+			}
+		})
+	}
+
+	t.Run("REPLACE 使用请求中的元数据", func(t *testing.T) {
+		if rec := copyObject("replaced.txt", "REPLACE", newHeaders); rec.Code != http.StatusOK {
+			t.Fatalf("复制失败: %d %s", rec.Code, rec.Body.String())
+		}
+		obj, _ := server.metadata.GetObject("directive-bucket", "replaced.txt")
+		if obj.ContentType != "application/json" || obj.Headers["Cache-Control"] != "no-cache" || obj.Headers["Content-Disposition"] != "" {
+			t.Errorf("应使用请求中的元数据: %s %v", obj.ContentType, obj.Headers)
+		}
+		if obj.ETag != src.ETag {
+			t.Errorf("内容应与源对象一致: %s", obj.ETag)
+		}
+	})
+
+	t.Run("REPLACE 原地修改元数据", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/directive-bucket/src.txt", nil)
+		req = asAdmin(t, req)
+		req.Header.Set("x-amz-copy-source", "/directive-bucket/src.txt")
+		req.Header.Set("x-amz-metadata-directive", "REPLACE")
+		rec := httptest.NewRecorder()
+		server.handleCopyObject(rec, req, "directive-bucket", "src.txt")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("复制失败: %d %s", rec.Code, rec.Body.String())
+		}
+		obj, _ := server.metadata.GetObject("directive-bucket", "src.txt")
+		if obj.ContentType != "application/octet-stream" || len(obj.Headers) != 0 {
+			t.Errorf("未指定时应使用默认值: %s %v", obj.ContentType, obj.Headers)
+		}
+	})
+
+	t.Run("无效指令返回400", func(t *testing.T) {
+		rec := copyObject("bad.txt", "MERGE", nil)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "InvalidArgument") {
+			t.Errorf("期望 400 InvalidArgument: %d %s", rec.Code, rec.Body.String())
+		}
+		if obj, _ := server.metadata.GetObject("directive-bucket", "bad.txt"); obj != nil {
+			t.Error("无效指令不应创建目标对象")
+		}
+	})
+}
+
 // TestHandleHeadObject 测试获取对象元数据
 func TestHandleHeadObject(t *testing.T) {
 	server, cleanup := setupObjectTestServer(t)
@@ -675,6 +2546,113 @@ func TestHandleHeadObject(t *testing.T) {
 	}
 }
 
+// TestObjectUserMetadata 测试 x-amz-meta-* 用户元数据在上传、HEAD/GET、复制和多段上传中保留
+func TestObjectUserMetadata(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	saved := *config.Global
+	defer func() { *config.Global = saved }()
+	config.Global.Storage.MaxMetadataSize = 2048
+
+	server.metadata.CreateBucket("meta-bucket")
+	req := httptest.NewRequest(http.MethodPut, "/meta-bucket/photo.jpg", strings.NewReader("data"))
+	req.Header.Set("X-Amz-Meta-Origin", "camera-01")
+	req.Header.Set("x-amz-meta-Project", "Alpha")
+	rec := httptest.NewRecorder()
+	server.handlePutObject(rec, req, "meta-bucket", "photo.jpg")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("上传失败: %d %s", rec.Code, rec.Body.String())
+	}
+
+	checkMetadata := func(t *testing.T, key string, want map[string]string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodHead, "/meta-bucket/"+key, nil)
+		rec := httptest.NewRecorder()
+		server.handleHeadObject(rec, req, "meta-bucket", key)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("HEAD 失败: %d", rec.Code)
+		}
+		for name, v := range want {
+			if got := rec.Header().Get("x-amz-meta-" + name); got != v {
+				t.Errorf("x-amz-meta-%s 期望 %q，实际 %q", name, v, got)
+			}
+		}
+		count := 0
+		for name := range rec.Header() {
+			if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+				count++
+			}
+		}
+		if count != len(want) {
+			t.Errorf("用户元数据数量期望 %d，实际 %d: %v", len(want), count, rec.Header())
+		}
+	}
+
+	t.Run("HEAD 和 GET 返回用户元数据", func(t *testing.T) {
+		checkMetadata(t, "photo.jpg", map[string]string{"origin": "camera-01", "project": "Alpha"})
+		req := httptest.NewRequest(http.MethodGet, "/meta-bucket/photo.jpg", nil)
+		rec := httptest.NewRecorder()
+		server.handleGetObject(rec, req, "meta-bucket", "photo.jpg")
+		if rec.Header().Get("x-amz-meta-origin") != "camera-01" {
+			t.Errorf("GET 应返回用户元数据: %v", rec.Header())
+		}
+	})
+
+	t.Run("复制时按指令保留或替换", func(t *testing.T) {
+		copyObject := func(destKey, directive string) {
+			req := httptest.NewRequest(http.MethodPut, "/meta-bucket/"+destKey, nil)
+			req = asAdmin(t, req)
+			req.Header.Set("x-amz-copy-source", "/meta-bucket/photo.jpg")
+			req.Header.Set("x-amz-metadata-directive", directive)
+			req.Header.Set("x-amz-meta-origin", "copied")
+			rec := httptest.NewRecorder()
+			server.handleCopyObject(rec, req, "meta-bucket", destKey)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("复制失败: %d %s", rec.Code, rec.Body.String())
+			}
+		}
+		copyObject("copy.jpg", "COPY")
+		checkMetadata(t, "copy.jpg", map[string]string{"origin": "camera-01", "project": "Alpha"})
+		copyObject("replaced.jpg", "REPLACE")
+		checkMetadata(t, "replaced.jpg", map[string]string{"origin": "copied"})
+	})
+
+	t.Run("多段上传完成后带初始化时的元数据", func(t *testing.T) {
+		config.Global.Storage.MinPartSize = 0
+		req := httptest.NewRequest(http.MethodPost, "/meta-bucket/big.bin?uploads", nil)
+		req.Header.Set("x-amz-meta-origin", "multipart")
+		rec := httptest.NewRecorder()
+		server.handleInitiateMultipartUpload(rec, req, "meta-bucket", "big.bin")
+		var initResult InitiateMultipartUploadResult
+		xml.Unmarshal(rec.Body.Bytes(), &initResult)
+		uploadID := initResult.UploadId
+
+		req = httptest.NewRequest(http.MethodPut, "/meta-bucket/big.bin?partNumber=1&uploadId="+uploadID, strings.NewReader("part"))
+		rec = httptest.NewRecorder()
+		server.handleUploadPart(rec, req, "meta-bucket", "big.bin", uploadID)
+		body := `<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>` + rec.Header().Get("ETag") + `</ETag></Part></CompleteMultipartUpload>`
+		req = httptest.NewRequest(http.MethodPost, "/meta-bucket/big.bin?uploadId="+uploadID, strings.NewReader(body))
+		rec = httptest.NewRecorder()
+		server.handleCompleteMultipartUpload(rec, req, "meta-bucket", "big.bin", uploadID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("完成上传失败: %d %s", rec.Code, rec.Body.String())
+		}
+		checkMetadata(t, "big.bin", map[string]string{"origin": "multipart"})
+	})
+
+	t.Run("超出大小上限返回400", func(t *testing.T) {
+		config.Global.Storage.MaxMetadataSize = 10
+		req := httptest.NewRequest(http.MethodPost, "/meta-bucket/large.bin?uploads", nil)
+		req.Header.Set("x-amz-meta-origin", strings.Repeat("x", 20))
+		rec := httptest.NewRecorder()
+		server.handleInitiateMultipartUpload(rec, req, "meta-bucket", "large.bin")
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "MetadataTooLarge") {
+			t.Errorf("期望 400 MetadataTooLarge: %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
 // TestObjectOverwrite 测试覆盖已存在的对象
 func TestObjectOverwrite(t *testing.T) {
 	server, cleanup := setupObjectTestServer(t)
@@ -723,6 +2701,70 @@ func TestObjectOverwrite(t *testing.T) {
 	}
 }
 
+// TestObjectOverwriteAudit 测试覆盖对象的审计日志
+func TestObjectOverwriteAudit(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	orig := config.Global.Security.AuditOverwrite
+	defer func() { config.Global.Security.AuditOverwrite = orig }()
+
+	if err := server.metadata.CreateBucket("audit-bucket"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+
+	put := func(content string) {
+		req := httptest.NewRequest(http.MethodPut, "/audit-bucket/file.txt", strings.NewReader(content))
+		req.ContentLength = int64(len(content))
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "audit-bucket", "file.txt")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("上传失败: %d", rec.Code)
+		}
+	}
+	overwriteLogs := func() []storage.AuditLog {
+		logs, _, err := server.metadata.QueryAuditLogs(&storage.AuditLogQuery{Action: storage.AuditActionObjectOverwrite, Limit: 10})
+		if err != nil {
+			t.Fatalf("查询审计日志失败: %v", err)
+		}
+		return logs
+	}
+
+	config.Global.Security.AuditOverwrite = true
+	put("v1")
+	if logs := overwriteLogs(); len(logs) != 0 {
+		t.Fatalf("首次创建不应记录覆盖: %d", len(logs))
+	}
+	first, _ := server.metadata.GetObject("audit-bucket", "file.txt")
+
+	put("version2")
+	logs := overwriteLogs()
+	if len(logs) != 1 {
+		t.Fatalf("期望1条覆盖日志, 实际 %d", len(logs))
+	}
+	var detail map[string]interface{}
+	if err := json.Unmarshal([]byte(logs[0].Detail), &detail); err != nil {
+		t.Fatalf("解析详情失败: %v", err)
+	}
+	second, _ := server.metadata.GetObject("audit-bucket", "file.txt")
+	if detail["old_etag"] != first.ETag || detail["new_etag"] != second.ETag {
+		t.Errorf("ETag记录错误: %v", detail)
+	}
+	if detail["old_size"] != float64(2) || detail["new_size"] != float64(8) {
+		t.Errorf("大小记录错误: %v", detail)
+	}
+	if logs[0].Resource != "audit-bucket/file.txt" {
+		t.Errorf("资源错误: %s", logs[0].Resource)
+	}
+
+	// 关闭后不再记录
+	config.Global.Security.AuditOverwrite = false
+	put("v3")
+	if logs := overwriteLogs(); len(logs) != 1 {
+		t.Errorf("关闭后不应记录覆盖: %d", len(logs))
+	}
+}
+
 // TestLargeObjectOperations 测试大对象操作
 func TestLargeObjectOperations(t *testing.T) {
 	if testing.Short() {
@@ -996,3 +3038,111 @@ func BenchmarkHandleHeadObject(b *testing.B) {
 		server.handleHeadObject(rec, req, "bench-bucket", "bench.txt")
 	}
 }
+
+// TestPutObjectMetadataSizeLimit 测试用户元数据大小上限的边界
+func TestPutObjectMetadataSizeLimit(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	if err := server.metadata.CreateBucket("meta-bucket"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+	original := config.Global.Storage.MaxMetadataSize
+	config.Global.Storage.MaxMetadataSize = 2048
+	defer func() { config.Global.Storage.MaxMetadataSize = original }()
+
+	// 名称 "note"(4) + "k"(1) + 值长度，合计 size 字节
+	put := func(size int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/meta-bucket/a.txt", strings.NewReader("data"))
+		req.Header.Set("x-amz-meta-k", "v")
+		req.Header.Set("X-Amz-Meta-Note", strings.Repeat("x", size-4-1-1))
+		rec := httptest.NewRecorder()
+		server.handlePutObject(rec, req, "meta-bucket", "a.txt")
+		return rec
+	}
+
+	if rec := put(2048); rec.Code != http.StatusOK {
+		t.Errorf("恰好等于上限应成功: %d %s", rec.Code, rec.Body.String())
+	}
+	rec := put(2049)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("超出上限应返回400: %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "<Code>MetadataTooLarge</Code>") || !strings.Contains(body, "2048") {
+		t.Errorf("错误响应应包含错误码和上限: %s", body)
+	}
+
+	config.Global.Storage.MaxMetadataSize = 0
+	if rec := put(4096); rec.Code != http.StatusOK {
+		t.Errorf("上限为0时不应限制: %d", rec.Code)
+	}
+}
+
+// TestObjectOrigin 测试本地不存在的对象按桶回源配置拉取
+func TestObjectOrigin(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	var gets atomic.Int32
+	var originDown atomic.Bool
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets.Add(1)
+		if originDown.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Path != "/legacy/a.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "origin content")
+	}))
+	defer origin.Close()
+
+	server.metadata.CreateBucket("cutover")
+	server.metadata.UpdateBucketPublic("cutover", true)
+	if err := server.metadata.SaveOriginConfig(&storage.OriginConfig{
+		Bucket: "cutover", Endpoint: origin.URL, AccessKey: "ak", SecretKey: "sk",
+		Region: "us-east-1", SourceBucket: "legacy", Enabled: true,
+	}); err != nil {
+		t.Fatalf("保存回源配置失败: %v", err)
+	}
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/cutover/a.txt"); rec.Code != http.StatusOK || rec.Body.String() != "origin content" {
+		t.Fatalf("首次读取应回源: %d %s", rec.Code, rec.Body.String())
+	}
+	if obj, _ := server.metadata.GetObject("cutover", "a.txt"); obj == nil {
+		t.Fatal("回源对象应保存到本地")
+	}
+
+	// 之后的读取命中本地，源端不可用也不受影响
+	originDown.Store(true)
+	before := gets.Load()
+	if rec := do(http.MethodGet, "/cutover/a.txt"); rec.Code != http.StatusOK || rec.Body.String() != "origin content" {
+		t.Errorf("本地读取失败: %d", rec.Code)
+	}
+	if rec := do(http.MethodHead, "/cutover/a.txt"); rec.Code != http.StatusOK {
+		t.Errorf("HEAD 应命中本地: %d", rec.Code)
+	}
+	if n := gets.Load() - before; n != 0 {
+		t.Errorf("命中本地时不应访问源端: %d", n)
+	}
+
+	if rec := do(http.MethodGet, "/cutover/b.txt"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("源端错误应返回503: %d", rec.Code)
+	}
+	originDown.Store(false)
+	if rec := do(http.MethodGet, "/cutover/b.txt"); rec.Code != http.StatusNotFound {
+		t.Errorf("源端也不存在应返回404: %d", rec.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"sss/internal/config"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// getLiveOrOriginObject 获取对象元数据，本地不存在时从桶的回源配置拉取并保存到本地
+// 本地已过期但尚未清理的对象不回源，避免把过期对象重新拉回
+func (s *Server) getLiveOrOriginObject(bucket, key string) (*storage.Object, error) {
+	obj, err := s.metadata.GetObject(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if obj != nil {
+		if obj.Expired(time.Now()) {
+			return nil, nil
+		}
+		return obj, nil
+	}
+
+	obj, err = s.adminHandler.FetchFromOrigin(bucket, key, config.Global.Storage.MaxObjectSize)
+	if errors.Is(err, storage.ErrObjectTooLarge) {
+		// 超出对象大小上限的源对象视为不存在
+		utils.Warn("origin object exceeds max object size", "bucket", bucket, "key", key)
+		return nil, nil
+	}
+	if obj != nil {
+		utils.Info("object pulled from origin", "bucket", bucket, "key", key, "size", obj.Size)
+	}
+	return obj, err
+}
+
+// originErrorStatus 回源失败对应的 S3 错误和状态码，其他错误返回 false
+func originErrorStatus(err error) (utils.S3Error, int, bool) {
+	switch {
+	case errors.Is(err, storage.ErrOriginFetch):
+		return utils.ErrOriginUnavailable, http.StatusServiceUnavailable, true
+	case errors.Is(err, storage.ErrInsufficientStorage):
+		return utils.ErrInsufficientStorage, http.StatusInsufficientStorage, true
+	}
+	return utils.S3Error{}, 0, false
+}
@@ -0,0 +1,386 @@
+package api
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"sss/internal/auth"
+	"sss/internal/config"
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// maxPostFormFieldsSize 表单中文件之前所有字段的总大小上限，与 S3 一致
+const maxPostFormFieldsSize = 20 * 1024
+
+// PresignPostRequest 预签名 POST 表单请求
+type PresignPostRequest struct {
+	Bucket         string `json:"bucket"`
+	Key            string `json:"key"` // 可包含 ${filename}，上传时替换为所选文件名
+	ExpiresMinutes int    `json:"expiresMinutes"`
+	MinSize        int64  `json:"minSize"`   // 最小文件大小（字节）
+	MaxSizeMB      int64  `json:"maxSizeMB"` // 最大文件大小（MB），0 表示只受全局限制
+	ContentType    string `json:"contentType"`
+}
+
+// PresignPostResponse 预签名 POST 表单响应：表单提交到 url，fields 原样作为隐藏字段，文件字段名为 file 且放在最后
+type PresignPostResponse struct {
+	URL     string            `json:"url"`
+	Fields  map[string]string `json:"fields"`
+	Expires int               `json:"expires"`
+}
+
+// PostResponse success_action_status 为 201 时的响应
+type PostResponse struct {
+	XMLName  xml.Name `xml:"PostResponse"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+// handlePresignPost 生成浏览器表单直传使用的 POST 策略和签名
+// POST /api/presign/post
+func (s *Server) handlePresignPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1024*1024) // 最大1MB
+
+	var req PresignPostRequest
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.WriteError(w, utils.ErrMalformedJSON, http.StatusBadRequest, "")
+		return
+	}
+	if req.Bucket == "" || req.Key == "" {
+		utils.WriteErrorResponse(w, "MissingRequiredParameter", "bucket and key are required", http.StatusBadRequest)
+		return
+	}
+	if strings.Contains(req.Bucket, "..") || strings.ContainsAny(req.Bucket, "/\\") {
+		utils.WriteErrorResponse(w, "InvalidBucketName", "Invalid bucket name", http.StatusBadRequest)
+		return
+	}
+	if strings.Contains(req.Key, "..") || strings.HasPrefix(req.Key, "/") {
+		utils.WriteErrorResponse(w, "InvalidKey", "Invalid object key", http.StatusBadRequest)
+		return
+	}
+	if req.MinSize < 0 || req.MaxSizeMB < 0 || req.MaxSizeMB > 0 && req.MinSize > req.MaxSizeMB*1024*1024 {
+		utils.WriteErrorResponse(w, "InvalidParameter", "minSize and maxSizeMB must form a valid range", http.StatusBadRequest)
+		return
+	}
+
+	ns := requestNamespace(r)
+	bucket, err := s.metadata.GetBucket(ns + req.Bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	if bucket == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "")
+		return
+	}
+	// 表单上传写入对象，需要桶的写权限，且只能用自身密钥签名
+	accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+	if !auth.CheckBucketPermission(accessKeyID, ns+req.Bucket, true) {
+		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, "/"+req.Bucket)
+		return
+	}
+	if !auth.CanPresign(accessKeyID) {
+		utils.WriteError(w, utils.S3Error{Code: utils.ErrAccessDenied.Code, Message: "Presigned POST policies cannot be issued with temporary credentials"}, http.StatusForbidden, "/"+req.Bucket)
+		return
+	}
+	if !s.checkPresignLimit(w, r, 1) {
+		return
+	}
+
+	if req.ExpiresMinutes == 0 {
+		req.ExpiresMinutes = 60 // 默认1小时
+	}
+	if req.ExpiresMinutes > 7*24*60 { // 最大7天
+		req.ExpiresMinutes = 7 * 24 * 60
+	}
+	opts := &auth.PostPolicyOptions{
+		Expires:          time.Duration(req.ExpiresMinutes) * time.Minute,
+		MinContentLength: req.MinSize,
+		MaxContentLength: req.MaxSizeMB * 1024 * 1024,
+		ContentType:      req.ContentType,
+	}
+	// 带命名空间时表单中保留不带前缀的桶名，上传时再映射到实际桶
+	opts.AccessKeyID = accessKeyID
+
+	post, err := auth.GeneratePresignedPost(req.Bucket, req.Key, opts)
+	if err != nil {
+		utils.Error("generate presigned post failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, "")
+		return
+	}
+	utils.WriteJSONResponse(w, PresignPostResponse{
+		URL:     post.URL,
+		Fields:  post.Fields,
+		Expires: req.ExpiresMinutes * 60,
+	})
+}
+
+// isPostObjectRequest 是否为浏览器表单上传：multipart/form-data 请求体 POST 到 /{bucket}
+func isPostObjectRequest(r *http.Request, parts []string) bool {
+	if r.Method != http.MethodPost || parts[0] == "" || len(parts) == 2 && parts[1] != "" {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "multipart/form-data"
+}
+
+// readPostForm 读取文件之前的表单字段（字段名转为小写），返回文件部分；文件之后的字段被忽略
+func readPostForm(r *http.Request) (map[string]string, *multipart.Part, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := make(map[string]string)
+	remaining := int64(maxPostFormFieldsSize)
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, nil, err
+		}
+		name := strings.ToLower(part.FormName())
+		if name == "" {
+			continue
+		}
+		if name == "file" {
+			return fields, part, nil
+		}
+		value, err := io.ReadAll(io.LimitReader(part, remaining+1))
+		if err != nil {
+			return nil, nil, err
+		}
+		if remaining -= int64(len(value)); remaining < 0 {
+			return nil, nil, errors.New("form fields too large")
+		}
+		fields[name] = string(value)
+	}
+}
+
+// handlePostObject 浏览器表单上传（S3 POST Object）
+// 认证信息在表单字段中：校验策略签名、过期时间和条件后写入对象
+func (s *Server) handlePostObject(w http.ResponseWriter, r *http.Request, name string) {
+	resource := "/" + name
+	var bucket string
+	w, record := withLateBucketMetrics(w, r, func() string { return bucket })
+	defer record()
+
+	fields, file, err := readPostForm(r)
+	if err != nil {
+		utils.WriteError(w, utils.ErrMalformedPOSTRequest, http.StatusBadRequest, resource)
+		return
+	}
+
+	accessKeyID, policy, err := auth.VerifyPostPolicy(fields, time.Now())
+	switch {
+	case errors.Is(err, auth.ErrPostPolicyMissing):
+		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, resource)
+		return
+	case errors.Is(err, auth.ErrPostPolicyExpired):
+		e := utils.ErrAccessDenied
+		e.Message = "Invalid according to Policy: Policy expired"
+		utils.WriteError(w, e, http.StatusForbidden, resource)
+		return
+	case errors.Is(err, auth.ErrPostPolicySignature):
+		utils.WriteError(w, utils.ErrSignatureDoesNotMatch, http.StatusForbidden, resource)
+		return
+	case err != nil:
+		utils.WriteError(w, utils.ErrInvalidPolicyDocument, http.StatusBadRequest, resource)
+		return
+	}
+
+	// 与签名认证的请求一致，带命名空间的 Key 访问命名空间下的桶
+	ns := auth.KeyNamespace(accessKeyID)
+	ctx := context.WithValue(r.Context(), ContextKeyAccessKeyID, accessKeyID)
+	if ns != "" {
+		ctx = context.WithValue(ctx, ContextKeyNamespace, ns)
+	}
+	r = r.WithContext(ctx)
+
+	b, err := s.metadata.GetBucket(ns + name)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if b == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, resource)
+		return
+	}
+	bucket = b.Name
+	if !b.AllowsMethod(r.Method) {
+		w.Header().Set("Allow", b.AllowedMethods)
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, resource)
+		return
+	}
+	if !checkReadOnly(w, b, resource) {
+		return
+	}
+
+	// 策略条件按表单中的原始值检查，桶名为客户端看到的名称
+	fields["bucket"] = name
+	if fields["key"] == "" {
+		e := utils.ErrInvalidArgument
+		e.Message = "Bucket POST must contain a field named 'key'"
+		utils.WriteError(w, e, http.StatusBadRequest, resource)
+		return
+	}
+	if err := policy.CheckFields(fields); err != nil {
+		e := utils.ErrAccessDenied
+		e.Message = "Invalid according to Policy: " + strings.TrimPrefix(err.Error(), auth.ErrPostPolicyCondition.Error()+": ")
+		utils.WriteError(w, e, http.StatusForbidden, resource)
+		return
+	}
+
+	key := strings.ReplaceAll(fields["key"], auth.PostKeyFilename, file.FileName())
+	if key == "" || strings.Contains(key, "..") {
+		utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, resource)
+		return
+	}
+	key, ok := normalizeObjectKey(key)
+	if !ok {
+		utils.WriteError(w, utils.ErrLeadingSlashKey, http.StatusBadRequest, resource)
+		return
+	}
+	key = b.RewriteKey(key)
+	// 桶策略按最终写入的键判定
+	if !s.checkBucketPermission(r, w, b, bucket, key, true) {
+		return
+	}
+	resource += "/" + key
+	if b.DeniesKey(key) {
+		utils.WriteError(w, utils.ErrKeyNotAllowed, http.StatusBadRequest, resource)
+		return
+	}
+	if !s.checkImmutable(w, b, bucket, key, nil) {
+		return
+	}
+
+	// 大小上限取策略范围和全局限制中最小的正数
+	var maxSize int64
+	limit := func(n int64) {
+		if n > 0 && (maxSize == 0 || n < maxSize) {
+			maxSize = n
+		}
+	}
+	minSize, policyMax, hasRange := policy.ContentLengthRange()
+	if hasRange {
+		limit(policyMax)
+	}
+	limit(config.Global.Storage.MaxUploadSize)
+	limit(config.Global.Storage.MaxObjectSize)
+
+	contentType := fields["content-type"]
+	if contentType == "" {
+		contentType = file.Header.Get("Content-Type")
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	body, err := b.CheckContentType(contentType, file)
+	if err != nil {
+		if err == storage.ErrContentTypeNotAllowed {
+			utils.WriteError(w, utils.ErrContentTypeNotAllowed, http.StatusBadRequest, resource)
+		} else {
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		}
+		return
+	}
+	if !s.checkFreeSpace(w, r, resource) {
+		return
+	}
+
+	versionID := newVersionID(b)
+	staged, err := s.filestore.PutObjectStaged(bucket, key, versionID, body, maxSize)
+	if err == storage.ErrObjectTooLarge {
+		utils.WriteError(w, utils.ErrEntityTooLarge, http.StatusBadRequest, resource)
+		return
+	}
+	if writeNoSpaceError(w, err, resource) {
+		return
+	}
+	if err != nil {
+		utils.Error("store object failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if hasRange && staged.Size < minSize {
+		staged.Discard()
+		utils.WriteError(w, utils.ErrEntityTooSmall, http.StatusBadRequest, resource)
+		return
+	}
+
+	// 表单中的标准对象头字段随对象保存
+	var headers map[string]string
+	for _, h := range storage.ObjectHeaderNames() {
+		if v := fields[strings.ToLower(h)]; v != "" {
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers[h] = v
+		}
+	}
+	obj := &storage.Object{
+		Key:          key,
+		Bucket:       bucket,
+		Size:         staged.Size,
+		ETag:         staged.ETag,
+		ContentType:  contentType,
+		LastModified: time.Now().UTC(),
+		StoragePath:  staged.Path,
+		Headers:      b.MergeDefaultHeaders(headers),
+		VersionID:    versionID,
+
+		EncryptionNonce: staged.Nonce,
+	}
+	if err := s.metadata.PutObjectIf(obj, storage.WriteCondition{}, staged.Commit); err != nil {
+		staged.Discard()
+		if writeQuotaError(w, err, resource) {
+			return
+		}
+		if err == storage.ErrBucketDeleted {
+			utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+name)
+			return
+		}
+		utils.Error("save object metadata failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	s.adminHandler.Replicate(bucket, key, storage.ReplicationOpPut)
+	s.adminHandler.NotifyObjectCreated(storage.EventObjectCreatedPost, obj)
+
+	// 与 S3 一致，默认返回 204，success_action_status 可改为 200 或 201
+	location := "/" + name + "/" + (&url.URL{Path: key}).EscapedPath()
+	setVersionIDHeader(w, obj)
+	w.Header().Set("ETag", `"`+obj.ETag+`"`)
+	w.Header().Set("Location", location)
+	switch status, _ := strconv.Atoi(fields["success_action_status"]); status {
+	case http.StatusCreated:
+		utils.WriteXML(w, http.StatusCreated, PostResponse{
+			Location: location,
+			Bucket:   name,
+			Key:      key,
+			ETag:     `"` + obj.ETag + `"`,
+		})
+	case http.StatusOK:
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"sss/internal/utils"
+)
+
+// promMethods 单独统计的 HTTP 方法，其他方法归入 OTHER
+var promMethods = [...]string{"GET", "HEAD", "PUT", "POST", "DELETE", "OPTIONS", "OTHER"}
+
+// promDurationBuckets 请求耗时直方图的桶上界（秒），与 Prometheus 客户端默认值一致
+var promDurationBuckets = [...]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// promMaxStatus 状态码上限，超出范围的状态码不计入按状态码的请求数
+const promMaxStatus = 600
+
+// httpMetrics 进程内的 HTTP 请求统计，全部为原子计数，记录时不加锁也不分配内存
+type httpMetrics struct {
+	requests      [len(promMethods)][promMaxStatus]atomic.Int64
+	durations     [len(promDurationBuckets) + 1]atomic.Int64 // 落在各区间的请求数（非累计），最后一项为 +Inf
+	durationNanos atomic.Int64
+	uploadBytes   atomic.Int64
+	downloadBytes atomic.Int64
+}
+
+// promMethodIndex 返回方法在 promMethods 中的下标
+func promMethodIndex(method string) int {
+	for i, m := range promMethods[:len(promMethods)-1] {
+		if m == method {
+			return i
+		}
+	}
+	return len(promMethods) - 1
+}
+
+// observe 记录一个请求
+func (m *httpMetrics) observe(method string, status int, elapsed time.Duration, bytesIn, bytesOut int64) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status > 0 && status < promMaxStatus {
+		m.requests[promMethodIndex(method)][status].Add(1)
+	}
+	seconds := elapsed.Seconds()
+	i := 0
+	for i < len(promDurationBuckets) && seconds > promDurationBuckets[i] {
+		i++
+	}
+	m.durations[i].Add(1)
+	m.durationNanos.Add(int64(elapsed))
+	m.uploadBytes.Add(bytesIn)
+	m.downloadBytes.Add(bytesOut)
+}
+
+// requestRecorder 统计单个请求的状态码和上下行字节数，响应和请求体的计数合并为一次分配
+type requestRecorder struct {
+	metricsResponseWriter
+	body countingReadCloser
+}
+
+// newRequestRecorder 包装请求和响应，统计状态码和上下行字节数
+func newRequestRecorder(w http.ResponseWriter, r *http.Request) *requestRecorder {
+	rec := &requestRecorder{metricsResponseWriter: metricsResponseWriter{ResponseWriter: w}}
+	if r.Body != nil && r.Body != http.NoBody {
+		rec.body.ReadCloser = r.Body
+		r.Body = &rec.body
+	}
+	return rec
+}
+
+// finish 请求结束时记录统计
+func (rec *requestRecorder) finish(m *httpMetrics, method string, start time.Time) {
+	m.observe(method, rec.status, time.Since(start), rec.body.n, rec.bytesOut)
+}
+
+// isPrometheusRequest 判断是否为 Prometheus 抓取请求
+// 只接管未签名、不带查询参数的 GET /metrics，开启后同名桶的匿名列举请求会被覆盖
+func isPrometheusRequest(r *http.Request, enabled bool) bool {
+	return enabled && r.Method == http.MethodGet && r.URL.Path == "/metrics" && r.URL.RawQuery == "" &&
+		r.Header.Get("Authorization") == ""
+}
+
+// promLabelEscaper 转义 Prometheus 标签值
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// handlePrometheusMetrics 以 Prometheus 文本格式输出请求统计和各桶的对象数、存储量
+func (s *Server) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	buckets, err := s.metadata.ListBucketCounters()
+	if err != nil {
+		utils.Error("list bucket counters failed", "error", err)
+		http.Error(w, "list bucket counters failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	m := s.httpMetrics
+
+	bw.WriteString("# HELP sss_http_requests_total Total HTTP requests by method and status code.\n")
+	bw.WriteString("# TYPE sss_http_requests_total counter\n")
+	for i, method := range promMethods {
+		for code := range m.requests[i] {
+			if n := m.requests[i][code].Load(); n > 0 {
+				bw.WriteString(`sss_http_requests_total{method="` + method + `",code="` + strconv.Itoa(code) + `"} ` + strconv.FormatInt(n, 10) + "\n")
+			}
+		}
+	}
+
+	bw.WriteString("# HELP sss_request_duration_seconds HTTP request latency in seconds.\n")
+	bw.WriteString("# TYPE sss_request_duration_seconds histogram\n")
+	var count int64
+	for i := range m.durations {
+		count += m.durations[i].Load()
+		le := "+Inf"
+		if i < len(promDurationBuckets) {
+			le = strconv.FormatFloat(promDurationBuckets[i], 'g', -1, 64)
+		}
+		bw.WriteString(`sss_request_duration_seconds_bucket{le="` + le + `"} ` + strconv.FormatInt(count, 10) + "\n")
+	}
+	bw.WriteString("sss_request_duration_seconds_sum " + strconv.FormatFloat(time.Duration(m.durationNanos.Load()).Seconds(), 'g', -1, 64) + "\n")
+	bw.WriteString("sss_request_duration_seconds_count " + strconv.FormatInt(count, 10) + "\n")
+
+	bw.WriteString("# HELP sss_upload_bytes_total Request body bytes received.\n")
+	bw.WriteString("# TYPE sss_upload_bytes_total counter\n")
+	bw.WriteString("sss_upload_bytes_total " + strconv.FormatInt(m.uploadBytes.Load(), 10) + "\n")
+	bw.WriteString("# HELP sss_download_bytes_total Response body bytes sent.\n")
+	bw.WriteString("# TYPE sss_download_bytes_total counter\n")
+	bw.WriteString("sss_download_bytes_total " + strconv.FormatInt(m.downloadBytes.Load(), 10) + "\n")
+
+	bw.WriteString("# HELP sss_objects Objects stored per bucket.\n")
+	bw.WriteString("# TYPE sss_objects gauge\n")
+	for _, b := range buckets {
+		bw.WriteString(`sss_objects{bucket="` + promLabelEscaper.Replace(b.Name) + `"} ` + strconv.Itoa(b.ObjectCount) + "\n")
+	}
+	bw.WriteString("# HELP sss_storage_bytes Bytes stored per bucket.\n")
+	bw.WriteString("# TYPE sss_storage_bytes gauge\n")
+	for _, b := range buckets {
+		bw.WriteString(`sss_storage_bytes{bucket="` + promLabelEscaper.Replace(b.Name) + `"} ` + strconv.FormatInt(b.TotalSize, 10) + "\n")
+	}
+}
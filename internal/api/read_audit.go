@@ -0,0 +1,58 @@
+package api
+
+import (
+	"math/rand/v2"
+	"net/http"
+
+	"sss/internal/config"
+	"sss/internal/storage"
+)
+
+// readAuditAction 返回请求对应的读操作审计类型，非读操作返回空
+func readAuditAction(r *http.Request, bucket, key string) storage.AuditAction {
+	if bucket == "" {
+		return ""
+	}
+	query := r.URL.Query()
+	if query.Has("uploads") || query.Get("uploadId") != "" {
+		return ""
+	}
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		return storage.AuditActionBucketList
+	case r.Method == http.MethodGet:
+		return storage.AuditActionObjectRead
+	case r.Method == http.MethodHead && key != "":
+		return storage.AuditActionObjectHead
+	}
+	return ""
+}
+
+// sampleReadAudit 按配置的百分比决定是否审计本次读操作
+func sampleReadAudit() bool {
+	percent := config.Global.Security.AuditReadPercent
+	return percent >= 100 || (percent > 0 && rand.IntN(100) < percent)
+}
+
+// withReadAudit 包装响应，请求结束时写入读操作审计日志（操作者、资源、状态码、响应字节数）
+func (s *Server) withReadAudit(w http.ResponseWriter, r *http.Request, action storage.AuditAction, bucket, key string) (http.ResponseWriter, func()) {
+	mw := &metricsResponseWriter{ResponseWriter: w}
+	return mw, func() {
+		status := mw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		actor, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+		if actor == "" {
+			actor = "anonymous"
+		}
+		resource := bucket
+		if key != "" {
+			resource += "/" + key
+		}
+		s.adminHandler.Audit(r, action, actor, resource, status < http.StatusBadRequest, map[string]interface{}{
+			"status": status,
+			"bytes":  mw.bytesOut,
+		})
+	}
+}
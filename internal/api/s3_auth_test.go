@@ -5,6 +5,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
@@ -167,22 +168,28 @@ func getCanonicalQueryStringForTest(query url.Values) string {
 		return ""
 	}
 
-	var keys []string
-	for k := range query {
-		keys = append(keys, k)
+	// AWS 编码：空格为 %20，先按编码后的键、再按编码后的值排序
+	encode := func(s string) string {
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
 	}
-	sort.Strings(keys)
-
-	var pairs []string
-	for _, k := range keys {
-		values := query[k]
-		sort.Strings(values)
+	var pairs [][2]string
+	for k, values := range query {
 		for _, v := range values {
-			pairs = append(pairs, fmt.Sprintf("%s=%s", url.QueryEscape(k), url.QueryEscape(v)))
+			pairs = append(pairs, [2]string{encode(k), encode(v)})
 		}
 	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
 
-	return strings.Join(pairs, "&")
+	parts := make([]string, len(pairs))
+	for i, p := range pairs {
+		parts[i] = p[0] + "=" + p[1]
+	}
+	return strings.Join(parts, "&")
 }
 
 // createStringToSignForTest 创建待签名字符串
@@ -305,6 +312,64 @@ func TestDirectGetObject(t *testing.T) {
 	}
 }
 
+// TestVirtualHostStyle 测试虚拟主机风格（bucket.domain/key）访问与路径风格共存
+func TestVirtualHostStyle(t *testing.T) {
+	server, cleanup := setupS3AuthTest(t)
+	defer cleanup()
+	config.Global.Server.VirtualHostDomains = "s3.example.com"
+
+	do := func(method, host, path string, body []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Host = host
+		signRequest(req, testAccessKey, testSecretKey, testRegion, body)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	vhost := testBucket + ".s3.example.com:8080"
+	if w := do("PUT", "s3.example.com:8080", "/"+testBucket, nil); w.Code != http.StatusOK {
+		t.Fatalf("路径风格创建桶失败: %d %s", w.Code, w.Body.String())
+	}
+
+	// 键以 assets/、api/ 开头也按对象处理，不进入静态文件和管理路由
+	content := []byte("virtual host content")
+	for _, key := range []string{"assets/app.js", "api/admin/data.json"} {
+		if w := do("PUT", vhost, "/"+key, content); w.Code != http.StatusOK {
+			t.Fatalf("虚拟主机风格上传 %s 失败: %d %s", key, w.Code, w.Body.String())
+		}
+		if obj, _ := server.metadata.GetObject(testBucket, key); obj == nil {
+			t.Errorf("对象应写入桶 %s: %s", testBucket, key)
+		}
+	}
+
+	if w := do("GET", vhost, "/assets/app.js", nil); w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), content) {
+		t.Errorf("虚拟主机风格读取失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("GET", vhost, "/?list-type=2", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<Key>assets/app.js</Key>") {
+		t.Errorf("虚拟主机风格列举失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("GET", "s3.example.com:8080", "/"+testBucket+"/assets/app.js", nil); w.Code != http.StatusOK {
+		t.Errorf("路径风格读取应不受影响: %d", w.Code)
+	}
+
+	// 签名必须按客户端发出的原始路径计算
+	req := httptest.NewRequest("GET", "/"+testBucket+"/assets/app.js", nil)
+	req.Host = vhost
+	signRequest(req, testAccessKey, testSecretKey, testRegion, nil)
+	req.URL.Path = "/assets/app.js"
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("按改写后路径签名应被拒绝: %d", w.Code)
+	}
+}
+
 // TestDirectDeleteObject 测试直接DELETE操作
 func TestDirectDeleteObject(t *testing.T) {
 	server, cleanup := setupS3AuthTest(t)
@@ -357,6 +422,58 @@ func TestDirectDeleteObject(t *testing.T) {
 	}
 }
 
+// TestExpectedBucketOwner 测试 x-amz-expected-bucket-owner 校验
+func TestExpectedBucketOwner(t *testing.T) {
+	server, cleanup := setupS3AuthTest(t)
+	defer cleanup()
+
+	do := func(method, path, header, owner string, body []byte) int {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Host = "localhost:8080"
+		if owner != "" {
+			req.Header.Set(header, owner)
+		}
+		signRequest(req, testAccessKey, testSecretKey, testRegion, body)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do("PUT", "/"+testBucket, "x-amz-expected-bucket-owner", testAccessKey, nil); code != http.StatusOK {
+		t.Fatalf("所有者匹配时创建Bucket应成功: %d", code)
+	}
+
+	content := []byte("owner check")
+	if code := do("PUT", "/"+testBucket+"/a.txt", "", "", content); code != http.StatusOK {
+		t.Fatalf("未携带请求头时应忽略校验: %d", code)
+	}
+	if code := do("PUT", "/"+testBucket+"/b.txt", "x-amz-expected-bucket-owner", "other-account", content); code != http.StatusForbidden {
+		t.Errorf("所有者不匹配时上传应返回403: %d", code)
+	}
+	if code := do("DELETE", "/"+testBucket+"/a.txt", "x-amz-expected-bucket-owner", "other-account", nil); code != http.StatusForbidden {
+		t.Errorf("所有者不匹配时删除应返回403: %d", code)
+	}
+	if code := do("GET", "/"+testBucket+"/a.txt", "x-amz-expected-bucket-owner", "other-account", nil); code != http.StatusOK {
+		t.Errorf("读操作不校验所有者: %d", code)
+	}
+	if code := do("DELETE", "/"+testBucket+"/a.txt", "x-amz-expected-bucket-owner", testAccessKey, nil); code != http.StatusNoContent {
+		t.Errorf("所有者匹配时删除应成功: %d", code)
+	}
+
+	// CopyObject 同时校验源桶所有者
+	do("PUT", "/"+testBucket+"/src.txt", "", "", content)
+	req := httptest.NewRequest("PUT", "/"+testBucket+"/dst.txt", nil)
+	req.Host = "localhost:8080"
+	req.Header.Set("x-amz-copy-source", "/"+testBucket+"/src.txt")
+	req.Header.Set("x-amz-source-expected-bucket-owner", "other-account")
+	signRequest(req, testAccessKey, testSecretKey, testRegion, nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("源桶所有者不匹配时复制应返回403: %d", w.Code)
+	}
+}
+
 // TestListBuckets 测试ListBuckets操作
 func TestListBuckets(t *testing.T) {
 	server, cleanup := setupS3AuthTest(t)
@@ -630,6 +747,197 @@ func TestAPIKeyPermission(t *testing.T) {
 	}
 }
 
+// TestTemporaryCredential 测试临时凭证：需携带会话令牌，权限限定在签发的桶和读写范围内，过期后返回 ExpiredToken
+func TestTemporaryCredential(t *testing.T) {
+	server, cleanup := setupS3AuthTest(t)
+	defer cleanup()
+
+	for _, bucket := range []string{testBucket, "other-bucket"} {
+		req := httptest.NewRequest("PUT", "/"+bucket, nil)
+		req.Host = "localhost:8080"
+		signRequest(req, testAccessKey, testSecretKey, testRegion, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("创建Bucket失败: %d", w.Code)
+		}
+	}
+
+	cred := auth.IssueTemporaryCredential(testBucket, true, false, time.Hour)
+	send := func(method, path, token string, payload []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Host = "localhost:8080"
+		if token != "" {
+			req.Header.Set("X-Amz-Security-Token", token)
+		}
+		signRequest(req, cred.AccessKeyID, cred.SecretAccessKey, testRegion, payload)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("GET", "/"+testBucket, cred.SessionToken, nil); w.Code != http.StatusOK {
+		t.Errorf("携带会话令牌的读请求应成功: %d %s", w.Code, w.Body.String())
+	}
+	if w := send("GET", "/"+testBucket, "", nil); w.Code != http.StatusForbidden {
+		t.Errorf("缺少会话令牌应被拒绝: %d", w.Code)
+	}
+	if w := send("GET", "/"+testBucket, "wrong-token", nil); w.Code != http.StatusForbidden {
+		t.Errorf("错误的会话令牌应被拒绝: %d", w.Code)
+	}
+	if w := send("PUT", "/"+testBucket+"/a.txt", cred.SessionToken, []byte("data")); w.Code != http.StatusForbidden {
+		t.Errorf("只读凭证不应有写权限: %d", w.Code)
+	}
+	if w := send("GET", "/other-bucket", cred.SessionToken, nil); w.Code != http.StatusForbidden {
+		t.Errorf("凭证不应访问其他桶: %d", w.Code)
+	}
+
+	// 已过期的凭证返回 ExpiredToken，清理后视为无效 Key
+	cred = auth.IssueTemporaryCredential(testBucket, true, true, -time.Second)
+	w := send("GET", "/"+testBucket, cred.SessionToken, nil)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "ExpiredToken") {
+		t.Errorf("过期凭证应返回 ExpiredToken: %d %s", w.Code, w.Body.String())
+	}
+	if n := auth.SweepTemporaryCredentials(time.Now()); n != 1 {
+		t.Errorf("应清理 1 个过期凭证，实际 %d", n)
+	}
+	w = send("GET", "/"+testBucket, cred.SessionToken, nil)
+	if w.Code != http.StatusForbidden || strings.Contains(w.Body.String(), "ExpiredToken") {
+		t.Errorf("清理后应按签名错误拒绝: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestTemporaryCredentialCopyScope 测试临时凭证不能借 CopyObject 或 UploadPartCopy 读取范围外的桶
+func TestTemporaryCredentialCopyScope(t *testing.T) {
+	server, cleanup := setupS3AuthTest(t)
+	defer cleanup()
+
+	adminDo := func(method, path string, payload []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Host = "localhost:8080"
+		signRequest(req, testAccessKey, testSecretKey, testRegion, payload)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	for _, bucket := range []string{testBucket, "secret-bucket"} {
+		if w := adminDo("PUT", "/"+bucket, nil); w.Code != http.StatusOK {
+			t.Fatalf("创建Bucket失败: %d", w.Code)
+		}
+		if w := adminDo("PUT", "/"+bucket+"/src.txt", []byte("content of "+bucket)); w.Code != http.StatusOK {
+			t.Fatalf("上传源对象失败: %d", w.Code)
+		}
+	}
+
+	cred := auth.IssueTemporaryCredential(testBucket, true, true, time.Hour)
+	stsDo := func(method, path, copySource string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Host = "localhost:8080"
+		req.Header.Set("X-Amz-Security-Token", cred.SessionToken)
+		if copySource != "" {
+			req.Header.Set("x-amz-copy-source", copySource)
+		}
+		signRequest(req, cred.AccessKeyID, cred.SecretAccessKey, testRegion, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("CopyObject", func(t *testing.T) {
+		if w := stsDo("PUT", "/"+testBucket+"/stolen.txt", "/secret-bucket/src.txt"); w.Code != http.StatusForbidden {
+			t.Errorf("从范围外的桶复制应返回 403: %d %s", w.Code, w.Body.String())
+		}
+		if obj, _ := server.metadata.GetObject(testBucket, "stolen.txt"); obj != nil {
+			t.Error("被拒绝的复制不应写入对象")
+		}
+		if w := stsDo("PUT", "/"+testBucket+"/copy.txt", "/"+testBucket+"/src.txt"); w.Code != http.StatusOK {
+			t.Errorf("范围内复制应成功: %d %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("UploadPartCopy", func(t *testing.T) {
+		w := stsDo("POST", "/"+testBucket+"/parts.txt?uploads", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("初始化分段上传失败: %d %s", w.Code, w.Body.String())
+		}
+		var init InitiateMultipartUploadResult
+		xml.Unmarshal(w.Body.Bytes(), &init)
+
+		partPath := "/" + testBucket + "/parts.txt?partNumber=1&uploadId=" + init.UploadId
+		if w := stsDo("PUT", partPath, "/secret-bucket/src.txt"); w.Code != http.StatusForbidden {
+			t.Errorf("从范围外的桶复制分段应返回 403: %d %s", w.Code, w.Body.String())
+		}
+		if parts, _ := server.metadata.ListParts(init.UploadId); len(parts) != 0 {
+			t.Errorf("被拒绝的分段复制不应写入分段: %d", len(parts))
+		}
+		if w := stsDo("PUT", partPath, "/"+testBucket+"/src.txt"); w.Code != http.StatusOK {
+			t.Errorf("范围内分段复制应成功: %d %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+// TestTemporaryCredentialAPIScope 测试临时凭证不能借预签名或桶管理 API 越过签发范围
+func TestTemporaryCredentialAPIScope(t *testing.T) {
+	server, cleanup := setupS3AuthTest(t)
+	defer cleanup()
+
+	for _, bucket := range []string{testBucket, "other-bucket"} {
+		req := httptest.NewRequest("PUT", "/"+bucket, nil)
+		req.Host = "localhost:8080"
+		signRequest(req, testAccessKey, testSecretKey, testRegion, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("创建Bucket失败: %d", w.Code)
+		}
+	}
+
+	cred := auth.IssueTemporaryCredential(testBucket, true, true, time.Hour)
+	do := func(accessKey, secretKey, token, method, path, body string) *httptest.ResponseRecorder {
+		payload := []byte(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Host = "localhost:8080"
+		if token != "" {
+			req.Header.Set("X-Amz-Security-Token", token)
+		}
+		signRequest(req, accessKey, secretKey, testRegion, payload)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	stsDo := func(method, path, body string) *httptest.ResponseRecorder {
+		return do(cred.AccessKeyID, cred.SecretAccessKey, cred.SessionToken, method, path, body)
+	}
+
+	if w := stsDo("POST", "/api/presign", `{"method":"PUT","bucket":"other-bucket","key":"a.txt"}`); w.Code != http.StatusForbidden {
+		t.Errorf("为范围外的桶预签名应返回 403: %d %s", w.Code, w.Body.String())
+	}
+	if w := stsDo("POST", "/api/presign", `{"method":"GET","bucket":"`+testBucket+`","key":"a.txt"}`); w.Code != http.StatusForbidden {
+		t.Errorf("临时凭证不能签发预签名 URL: %d %s", w.Code, w.Body.String())
+	}
+	if w := stsDo("POST", "/api/presign/post", `{"bucket":"`+testBucket+`","key":"a.txt"}`); w.Code != http.StatusForbidden {
+		t.Errorf("临时凭证不能签发表单上传策略: %d %s", w.Code, w.Body.String())
+	}
+	if w := stsDo("PUT", "/api/bucket/"+testBucket+"/public", `{"is_public":true}`); w.Code != http.StatusForbidden {
+		t.Errorf("临时凭证不能修改桶的公有状态: %d %s", w.Code, w.Body.String())
+	}
+	if w := stsDo("GET", "/api/bucket/other-bucket/public", ""); w.Code != http.StatusForbidden {
+		t.Errorf("临时凭证不能查询范围外的桶: %d %s", w.Code, w.Body.String())
+	}
+	if b, _ := server.metadata.GetBucket(testBucket); b == nil || b.IsPublic {
+		t.Error("被拒绝的请求不应修改桶的公有状态")
+	}
+
+	// 桶所有者仍可签发和修改公有状态
+	w := do(testAccessKey, testSecretKey, "", "POST", "/api/presign", `{"method":"GET","bucket":"`+testBucket+`","key":"a.txt"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), testAccessKey) {
+		t.Errorf("桶所有者预签名应成功并使用自身 Key: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(testAccessKey, testSecretKey, "", "PUT", "/api/bucket/"+testBucket+"/public", `{"is_public":true}`); w.Code != http.StatusOK {
+		t.Errorf("桶所有者修改公有状态应成功: %d %s", w.Code, w.Body.String())
+	}
+}
+
 // TestMultipartUpload 测试多段上传
 func TestMultipartUpload(t *testing.T) {
 	server, cleanup := setupS3AuthTest(t)
@@ -887,3 +1195,117 @@ func TestStreamingUpload(t *testing.T) {
 		t.Errorf("下载内容与上传内容不匹配")
 	}
 }
+
+// TestAnonymousDownloadLimit 测试公有桶匿名下载计数与每日流量上限
+func TestAnonymousDownloadLimit(t *testing.T) {
+	server, cleanup := setupS3AuthTest(t)
+	defer cleanup()
+
+	content := []byte("release binary")
+	server.metadata.CreateBucket(testBucket)
+	putReq := httptest.NewRequest("PUT", "/"+testBucket+"/app.bin", bytes.NewReader(content))
+	putReq.Host = "localhost:8080"
+	putReq.ContentLength = int64(len(content))
+	signRequest(putReq, testAccessKey, testSecretKey, testRegion, content)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, putReq)
+	if w.Code != http.StatusOK {
+		t.Fatalf("上传对象失败: %d, %s", w.Code, w.Body.String())
+	}
+	server.metadata.UpdateBucketPublic(testBucket, true)
+	config.Global.Storage.AnonymousDailyBytes = int64(len(content)) * 2
+
+	anonymousGet := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/"+testBucket+"/app.bin", nil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := anonymousGet(); rec.Code != http.StatusOK {
+			t.Fatalf("第 %d 次匿名下载失败: %d", i+1, rec.Code)
+		}
+	}
+	stats, _ := server.metadata.ListObjectDownloads(testBucket, 10)
+	if len(stats) != 1 || stats[0].Downloads != 2 {
+		t.Errorf("下载次数错误: %+v", stats)
+	}
+
+	rec := anonymousGet()
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("超出上限应返回 429: %d %v", rec.Code, rec.Header())
+	}
+
+	signedReq := httptest.NewRequest("GET", "/"+testBucket+"/app.bin", nil)
+	signedReq.Host = "localhost:8080"
+	signRequest(signedReq, testAccessKey, testSecretKey, testRegion, nil)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, signedReq)
+	if w.Code != http.StatusOK {
+		t.Errorf("签名访问不应受限: %d", w.Code)
+	}
+	if stats, _ := server.metadata.ListObjectDownloads(testBucket, 10); stats[0].Downloads != 2 {
+		t.Errorf("签名访问不应计入匿名下载: %+v", stats)
+	}
+}
+
+// TestReadAudit 测试读操作审计开关与采样
+func TestReadAudit(t *testing.T) {
+	server, cleanup := setupS3AuthTest(t)
+	defer cleanup()
+
+	orig := config.Global.Security.AuditReadPercent
+	defer func() { config.Global.Security.AuditReadPercent = orig }()
+
+	content := []byte("audited")
+	server.metadata.CreateBucket(testBucket)
+	send := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Host = "localhost:8080"
+		req.ContentLength = int64(len(body))
+		signRequest(req, testAccessKey, testSecretKey, testRegion, body)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := send("PUT", "/"+testBucket+"/a.txt", content); rec.Code != http.StatusOK {
+		t.Fatalf("上传对象失败: %d", rec.Code)
+	}
+	readLogs := func() []storage.AuditLog {
+		var logs []storage.AuditLog
+		for _, action := range []storage.AuditAction{storage.AuditActionObjectRead, storage.AuditActionObjectHead, storage.AuditActionBucketList} {
+			found, _, _ := server.metadata.QueryAuditLogs(&storage.AuditLogQuery{Action: action, Limit: 100})
+			logs = append(logs, found...)
+		}
+		return logs
+	}
+
+	config.Global.Security.AuditReadPercent = 0
+	send("GET", "/"+testBucket+"/a.txt", nil)
+	if logs := readLogs(); len(logs) != 0 {
+		t.Fatalf("关闭时不应记录读操作: %+v", logs)
+	}
+
+	config.Global.Security.AuditReadPercent = 100
+	send("GET", "/"+testBucket+"/a.txt", nil)
+	send("HEAD", "/"+testBucket+"/a.txt", nil)
+	send("GET", "/"+testBucket+"/missing.txt", nil)
+	send("GET", "/"+testBucket+"?list-type=2", nil)
+	logs := readLogs()
+	if len(logs) != 4 {
+		t.Fatalf("期望 4 条读操作审计: %+v", logs)
+	}
+	var get *storage.AuditLog
+	for i := range logs {
+		if logs[i].Action == storage.AuditActionObjectRead && logs[i].Resource == testBucket+"/a.txt" {
+			get = &logs[i]
+		}
+		if logs[i].Resource == testBucket+"/missing.txt" && logs[i].Success {
+			t.Error("404 应记录为失败")
+		}
+	}
+	if get == nil || get.Actor != testAccessKey || !get.Success || !strings.Contains(get.Detail, `"bytes":7`) {
+		t.Errorf("GET 审计内容错误: %+v", get)
+	}
+}
@@ -0,0 +1,538 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sss/internal/utils"
+)
+
+// selectFlushSize Records 事件的最大负载，超过后立即发送
+const selectFlushSize = 64 * 1024
+
+// SelectObjectContentRequest S3 Select 请求体
+type SelectObjectContentRequest struct {
+	XMLName            xml.Name `xml:"SelectObjectContentRequest"`
+	Expression         string   `xml:"Expression"`
+	ExpressionType     string   `xml:"ExpressionType"`
+	InputSerialization struct {
+		CompressionType string `xml:"CompressionType"`
+		CSV             *struct {
+			FileHeaderInfo  string `xml:"FileHeaderInfo"`
+			FieldDelimiter  string `xml:"FieldDelimiter"`
+			QuoteCharacter  string `xml:"QuoteCharacter"`
+			RecordDelimiter string `xml:"RecordDelimiter"`
+			Comments        string `xml:"Comments"`
+		} `xml:"CSV"`
+		JSON *struct {
+			Type string `xml:"Type"`
+		} `xml:"JSON"`
+	} `xml:"InputSerialization"`
+	OutputSerialization struct {
+		CSV *struct {
+			FieldDelimiter  string `xml:"FieldDelimiter"`
+			RecordDelimiter string `xml:"RecordDelimiter"`
+			QuoteFields     string `xml:"QuoteFields"`
+		} `xml:"CSV"`
+		JSON *struct {
+			RecordDelimiter string `xml:"RecordDelimiter"`
+		} `xml:"JSON"`
+	} `xml:"OutputSerialization"`
+}
+
+// isSelectRequest 判断是否为 SelectObjectContent 请求（属于读操作）
+func isSelectRequest(r *http.Request) bool {
+	return r.Method == http.MethodPost && r.URL.Query().Has("select")
+}
+
+// handleSelectObjectContent 处理 SelectObjectContent
+// POST /{bucket}/{key}?select&select-type=2
+func (s *Server) handleSelectObjectContent(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	resource := "/" + bucket + "/" + key
+	invalid := func(msg string) {
+		e := utils.ErrInvalidArgument
+		e.Message = msg
+		utils.WriteError(w, e, http.StatusBadRequest, resource)
+	}
+
+	if r.URL.Query().Get("select-type") != "2" {
+		invalid("select-type must be 2")
+		return
+	}
+
+	var req SelectObjectContentRequest
+	if err := xml.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+		utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, resource)
+		return
+	}
+	if req.ExpressionType != "" && !strings.EqualFold(req.ExpressionType, "SQL") {
+		invalid("ExpressionType must be SQL")
+		return
+	}
+	query, err := parseSelectQuery(req.Expression)
+	if err != nil {
+		invalid("Invalid expression: " + err.Error())
+		return
+	}
+
+	in := req.InputSerialization
+	if (in.CSV == nil) == (in.JSON == nil) {
+		invalid("InputSerialization must specify exactly one of CSV or JSON")
+		return
+	}
+	compression := strings.ToUpper(in.CompressionType)
+	if compression != "" && compression != "NONE" && compression != "GZIP" {
+		invalid("Unsupported CompressionType: " + in.CompressionType)
+		return
+	}
+	out := req.OutputSerialization
+	if (out.CSV == nil) == (out.JSON == nil) {
+		invalid("OutputSerialization must specify exactly one of CSV or JSON")
+		return
+	}
+
+	obj, err := s.metadata.GetObject(bucket, key)
+	if err != nil {
+		utils.Error("get object metadata failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if obj == nil {
+		utils.WriteError(w, utils.ErrNoSuchKey, http.StatusNotFound, resource)
+		return
+	}
+	file, err := s.filestore.OpenObject(obj)
+	if err != nil {
+		utils.Error("open object failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	defer file.Close()
+
+	scanned := &countingReader{r: file}
+	var src io.Reader = scanned
+	if compression == "GZIP" {
+		gz, err := gzip.NewReader(scanned)
+		if err != nil {
+			invalid("Object is not valid GZIP data")
+			return
+		}
+		defer gz.Close()
+		src = gz
+	}
+	processed := &countingReader{r: src}
+
+	next, err := newSelectRecordReader(&req, processed)
+	if err != nil {
+		invalid(err.Error())
+		return
+	}
+	writeRow := newSelectRowWriter(&req)
+
+	// 开始输出事件流，此后错误通过 error 事件返回
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	ew := &eventStreamWriter{w: w}
+
+	var buf bytes.Buffer
+	var returned int64
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		returned += int64(buf.Len())
+		err := ew.records(buf.Bytes())
+		buf.Reset()
+		return err
+	}
+
+	matched := 0
+	for query.limit < 0 || matched < query.limit {
+		rec, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			code := "CSVParsingError"
+			if req.InputSerialization.JSON != nil {
+				code = "JSONParsingError"
+			}
+			ew.error(code, err.Error())
+			return
+		}
+		if query.where != nil && !query.where.eval(rec) {
+			continue
+		}
+		matched++
+		if query.count {
+			continue
+		}
+		names, values := projectRecord(query, rec)
+		writeRow(&buf, names, values)
+		if buf.Len() >= selectFlushSize {
+			if err := flush(); err != nil {
+				return
+			}
+		}
+	}
+	if query.count {
+		writeRow(&buf, []string{"_1"}, []sqlValue{{s: strconv.Itoa(matched), isNum: true}})
+	}
+	if err := flush(); err != nil {
+		return
+	}
+
+	ew.stats(scanned.n, processed.n, returned)
+	ew.end()
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// csvRecord CSV 输入记录
+type csvRecord struct {
+	fields []string
+	header map[string]int // 列名到位置的映射（FileHeaderInfo=USE 时）
+	names  []string       // 表头名称，用于 SELECT * 输出列名
+}
+
+func (c csvRecord) lookup(ref colRef) sqlValue {
+	idx := ref.pos - 1
+	if ref.pos == 0 {
+		if len(ref.path) != 1 || c.header == nil {
+			return sqlValue{null: true}
+		}
+		i, ok := c.header[ref.path[0]]
+		if !ok {
+			i, ok = c.header[strings.ToLower(ref.path[0])]
+		}
+		if !ok {
+			return sqlValue{null: true}
+		}
+		idx = i
+	}
+	if idx < 0 || idx >= len(c.fields) {
+		return sqlValue{null: true}
+	}
+	return sqlValue{s: c.fields[idx], raw: c.fields[idx]}
+}
+
+// jsonRecord JSON 输入记录
+type jsonRecord struct {
+	doc interface{}
+}
+
+func (j jsonRecord) lookup(ref colRef) sqlValue {
+	if ref.pos > 0 {
+		return sqlValue{null: true}
+	}
+	cur := j.doc
+	for _, p := range ref.path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return sqlValue{null: true}
+		}
+		v, ok := m[p]
+		if !ok {
+			// 不区分大小写的回退匹配
+			for k, mv := range m {
+				if strings.EqualFold(k, p) {
+					v, ok = mv, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return sqlValue{null: true}
+		}
+		cur = v
+	}
+	return jsonValue(cur)
+}
+
+// jsonValue 将 JSON 值转换为 sqlValue
+func jsonValue(v interface{}) sqlValue {
+	switch t := v.(type) {
+	case nil:
+		return sqlValue{null: true}
+	case string:
+		return sqlValue{s: t, raw: t}
+	case json.Number:
+		return sqlValue{s: t.String(), isNum: true, raw: t}
+	case bool:
+		return sqlValue{s: strconv.FormatBool(t), raw: t}
+	default:
+		data, _ := json.Marshal(t)
+		return sqlValue{s: string(data), raw: t}
+	}
+}
+
+// newSelectRecordReader 根据输入格式创建记录读取函数
+func newSelectRecordReader(req *SelectObjectContentRequest, src io.Reader) (func() (selectRecord, error), error) {
+	if in := req.InputSerialization.JSON; in != nil {
+		typ := strings.ToUpper(in.Type)
+		if typ != "" && typ != "DOCUMENT" && typ != "LINES" {
+			return nil, fmt.Errorf("unsupported JSON Type: %s", in.Type)
+		}
+		dec := json.NewDecoder(src)
+		dec.UseNumber()
+		return func() (selectRecord, error) {
+			var doc interface{}
+			if err := dec.Decode(&doc); err != nil {
+				return nil, err
+			}
+			return jsonRecord{doc: doc}, nil
+		}, nil
+	}
+
+	in := req.InputSerialization.CSV
+	cr := csv.NewReader(bufio.NewReader(src))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	if in.FieldDelimiter != "" {
+		d := []rune(in.FieldDelimiter)
+		if len(d) != 1 {
+			return nil, fmt.Errorf("FieldDelimiter must be a single character")
+		}
+		cr.Comma = d[0]
+	}
+	if in.QuoteCharacter != "" && in.QuoteCharacter != `"` {
+		return nil, fmt.Errorf("only \" is supported as QuoteCharacter")
+	}
+	if in.RecordDelimiter != "" && in.RecordDelimiter != "\n" && in.RecordDelimiter != "\r\n" {
+		return nil, fmt.Errorf("only \\n and \\r\\n are supported as RecordDelimiter")
+	}
+	if in.Comments != "" {
+		c := []rune(in.Comments)
+		if len(c) != 1 {
+			return nil, fmt.Errorf("Comments must be a single character")
+		}
+		cr.Comment = c[0]
+	}
+
+	headerInfo := strings.ToUpper(in.FileHeaderInfo)
+	var header map[string]int
+	var headerNames []string
+	first := true
+	return func() (selectRecord, error) {
+		fields, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			first = false
+			switch headerInfo {
+			case "USE":
+				header = make(map[string]int, len(fields))
+				headerNames = append([]string(nil), fields...)
+				for i, name := range fields {
+					header[name] = i
+					if _, ok := header[strings.ToLower(name)]; !ok {
+						header[strings.ToLower(name)] = i
+					}
+				}
+				fields, err = cr.Read()
+			case "IGNORE":
+				fields, err = cr.Read()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return csvRecord{fields: fields, header: header, names: headerNames}, nil
+	}, nil
+}
+
+// projectRecord 计算投影后的列名和值
+func projectRecord(q *selectQuery, rec selectRecord) ([]string, []sqlValue) {
+	if !q.star {
+		names := make([]string, len(q.columns))
+		values := make([]sqlValue, len(q.columns))
+		for i, c := range q.columns {
+			names[i] = c.name()
+			values[i] = rec.lookup(c)
+		}
+		return names, values
+	}
+
+	switch r := rec.(type) {
+	case csvRecord:
+		names := make([]string, len(r.fields))
+		values := make([]sqlValue, len(r.fields))
+		for i, f := range r.fields {
+			if i < len(r.names) {
+				names[i] = r.names[i]
+			} else {
+				names[i] = "_" + strconv.Itoa(i+1)
+			}
+			values[i] = sqlValue{s: f, raw: f}
+		}
+		return names, values
+	case jsonRecord:
+		m, ok := r.doc.(map[string]interface{})
+		if !ok {
+			return []string{"_1"}, []sqlValue{jsonValue(r.doc)}
+		}
+		names := make([]string, 0, len(m))
+		for k := range m {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		values := make([]sqlValue, len(names))
+		for i, k := range names {
+			values[i] = jsonValue(m[k])
+		}
+		return names, values
+	}
+	return nil, nil
+}
+
+// newSelectRowWriter 根据输出格式创建行写入函数
+func newSelectRowWriter(req *SelectObjectContentRequest) func(buf *bytes.Buffer, names []string, values []sqlValue) {
+	if out := req.OutputSerialization.JSON; out != nil {
+		delim := out.RecordDelimiter
+		if delim == "" {
+			delim = "\n"
+		}
+		return func(buf *bytes.Buffer, names []string, values []sqlValue) {
+			buf.WriteByte('{')
+			for i, name := range names {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				k, _ := json.Marshal(name)
+				buf.Write(k)
+				buf.WriteByte(':')
+				v := values[i]
+				switch {
+				case v.null:
+					buf.WriteString("null")
+				case v.raw != nil:
+					data, _ := json.Marshal(v.raw)
+					buf.Write(data)
+				case v.isNum:
+					buf.WriteString(v.s)
+				default:
+					data, _ := json.Marshal(v.s)
+					buf.Write(data)
+				}
+			}
+			buf.WriteByte('}')
+			buf.WriteString(delim)
+		}
+	}
+
+	out := req.OutputSerialization.CSV
+	fieldDelim, recordDelim := ",", "\n"
+	if out.FieldDelimiter != "" {
+		fieldDelim = out.FieldDelimiter
+	}
+	if out.RecordDelimiter != "" {
+		recordDelim = out.RecordDelimiter
+	}
+	always := strings.EqualFold(out.QuoteFields, "ALWAYS")
+	return func(buf *bytes.Buffer, names []string, values []sqlValue) {
+		for i, v := range values {
+			if i > 0 {
+				buf.WriteString(fieldDelim)
+			}
+			if v.null {
+				continue
+			}
+			s := v.s
+			if always || strings.Contains(s, fieldDelim) || strings.ContainsAny(s, "\"\r\n") || strings.Contains(s, recordDelim) {
+				s = `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+			}
+			buf.WriteString(s)
+		}
+		buf.WriteString(recordDelim)
+	}
+}
+
+// eventStreamWriter 按 AWS event stream 格式输出消息
+type eventStreamWriter struct {
+	w io.Writer
+}
+
+// writeMessage 写入一条消息：prelude(总长度、头长度、CRC) + 头 + 负载 + CRC
+func (e *eventStreamWriter) writeMessage(headers [][2]string, payload []byte) error {
+	var hb bytes.Buffer
+	for _, h := range headers {
+		hb.WriteByte(byte(len(h[0])))
+		hb.WriteString(h[0])
+		hb.WriteByte(7) // 字符串类型
+		binary.Write(&hb, binary.BigEndian, uint16(len(h[1])))
+		hb.WriteString(h[1])
+	}
+
+	total := 12 + hb.Len() + len(payload) + 4
+	msg := make([]byte, 0, total)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(total))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(hb.Len()))
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+	msg = append(msg, hb.Bytes()...)
+	msg = append(msg, payload...)
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+
+	if _, err := e.w.Write(msg); err != nil {
+		return err
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+func (e *eventStreamWriter) records(payload []byte) error {
+	return e.writeMessage([][2]string{
+		{":message-type", "event"},
+		{":event-type", "Records"},
+		{":content-type", "application/octet-stream"},
+	}, payload)
+}
+
+func (e *eventStreamWriter) stats(scanned, processed, returned int64) error {
+	payload := fmt.Sprintf("<Stats><BytesScanned>%d</BytesScanned><BytesProcessed>%d</BytesProcessed><BytesReturned>%d</BytesReturned></Stats>",
+		scanned, processed, returned)
+	return e.writeMessage([][2]string{
+		{":message-type", "event"},
+		{":event-type", "Stats"},
+		{":content-type", "text/xml"},
+	}, []byte(payload))
+}
+
+func (e *eventStreamWriter) end() error {
+	return e.writeMessage([][2]string{
+		{":message-type", "event"},
+		{":event-type", "End"},
+	}, nil)
+}
+
+func (e *eventStreamWriter) error(code, message string) error {
+	return e.writeMessage([][2]string{
+		{":message-type", "error"},
+		{":error-code", code},
+		{":error-message", message},
+	}, nil)
+}
@@ -0,0 +1,598 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// S3 Select 的 SQL 子集：
+//   SELECT * | COUNT(*) | col[, col...] FROM S3Object [alias] [WHERE cond] [LIMIT n]
+// cond 支持 = != <> < <= > >= LIKE IS [NOT] NULL，以及 AND / OR / NOT 和括号
+// 列引用支持 alias.name、"quoted name"、_N（CSV 按位置）以及 JSON 的嵌套路径 s.a.b
+
+// sqlTokenKind 词法单元类型
+type sqlTokenKind int
+
+const (
+	sqlTokEOF sqlTokenKind = iota
+	sqlTokIdent
+	sqlTokQuotedIdent
+	sqlTokString
+	sqlTokNumber
+	sqlTokSymbol
+)
+
+// sqlToken 词法单元
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// tokenizeSQL 将表达式切分为词法单元
+func tokenizeSQL(input string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			// 字符串或带引号的标识符，连续两个引号表示转义
+			var sb strings.Builder
+			j := i + 1
+			closed := false
+			for j < len(runes) {
+				if runes[j] == c {
+					if j+1 < len(runes) && runes[j+1] == c {
+						sb.WriteRune(c)
+						j += 2
+						continue
+					}
+					closed = true
+					break
+				}
+				sb.WriteRune(runes[j])
+				j++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quoted text at position %d", i)
+			}
+			kind := sqlTokString
+			if c == '"' {
+				kind = sqlTokQuotedIdent
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: sb.String()})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]) && !lastIsOperand(tokens)):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokNumber, text: string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokIdent, text: string(runes[i:j])})
+			i = j
+		default:
+			// 两字符运算符优先
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				if two == "<=" || two == ">=" || two == "<>" || two == "!=" {
+					tokens = append(tokens, sqlToken{kind: sqlTokSymbol, text: two})
+					i += 2
+					continue
+				}
+			}
+			if strings.ContainsRune("*,().=<>[]", c) {
+				tokens = append(tokens, sqlToken{kind: sqlTokSymbol, text: string(c)})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	return append(tokens, sqlToken{kind: sqlTokEOF}), nil
+}
+
+// lastIsOperand 判断前一个词法单元是否为操作数（用于区分负号与减号）
+func lastIsOperand(tokens []sqlToken) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	switch last.kind {
+	case sqlTokNumber, sqlTokString, sqlTokQuotedIdent:
+		return true
+	case sqlTokIdent:
+		return !isSQLKeyword(last.text)
+	}
+	return last.text == ")"
+}
+
+// sqlKeywords 保留关键字
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LIMIT": true,
+	"AND": true, "OR": true, "NOT": true, "LIKE": true,
+	"IS": true, "NULL": true, "COUNT": true, "AS": true,
+}
+
+// isSQLKeyword 判断是否为关键字
+func isSQLKeyword(s string) bool {
+	return sqlKeywords[strings.ToUpper(s)]
+}
+
+// colRef 列引用
+type colRef struct {
+	path []string // 字段路径
+	pos  int      // _N 形式的位置索引（从 1 开始），0 表示按名称
+}
+
+// name 输出时使用的列名
+func (c colRef) name() string {
+	if c.pos > 0 {
+		return "_" + strconv.Itoa(c.pos)
+	}
+	return c.path[len(c.path)-1]
+}
+
+// selectQuery 解析后的查询
+type selectQuery struct {
+	star    bool
+	count   bool
+	columns []colRef
+	alias   string
+	where   sqlCond
+	limit   int // -1 表示不限制
+}
+
+// sqlValue 求值结果
+type sqlValue struct {
+	s     string
+	null  bool
+	isNum bool
+	raw   interface{} // JSON 输入的原始值，用于 JSON 输出
+}
+
+// number 尝试按数值解析
+func (v sqlValue) number() (float64, bool) {
+	if v.null {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(v.s), 64)
+	return f, err == nil
+}
+
+// selectRecord 单条记录，按列引用取值
+type selectRecord interface {
+	lookup(ref colRef) sqlValue
+}
+
+// sqlOperand 操作数
+type sqlOperand interface {
+	value(rec selectRecord) sqlValue
+}
+
+// literalOperand 字面量
+type literalOperand struct{ v sqlValue }
+
+func (l literalOperand) value(selectRecord) sqlValue { return l.v }
+
+// columnOperand 列引用
+type columnOperand struct{ ref colRef }
+
+func (c columnOperand) value(rec selectRecord) sqlValue { return rec.lookup(c.ref) }
+
+// sqlCond 条件表达式
+type sqlCond interface {
+	eval(rec selectRecord) bool
+}
+
+type andCond struct{ l, r sqlCond }
+type orCond struct{ l, r sqlCond }
+type notCond struct{ c sqlCond }
+
+func (c andCond) eval(rec selectRecord) bool { return c.l.eval(rec) && c.r.eval(rec) }
+func (c orCond) eval(rec selectRecord) bool  { return c.l.eval(rec) || c.r.eval(rec) }
+func (c notCond) eval(rec selectRecord) bool { return !c.c.eval(rec) }
+
+// compareCond 比较表达式
+type compareCond struct {
+	op          string
+	left, right sqlOperand
+}
+
+func (c compareCond) eval(rec selectRecord) bool {
+	l, r := c.left.value(rec), c.right.value(rec)
+	if l.null || r.null {
+		return false
+	}
+
+	var cmp int
+	lf, lok := l.number()
+	rf, rok := r.number()
+	if (l.isNum || r.isNum) && lok && rok {
+		switch {
+		case lf < rf:
+			cmp = -1
+		case lf > rf:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(l.s, r.s)
+	}
+
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=", "<>":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// likeCond LIKE 匹配
+type likeCond struct {
+	operand sqlOperand
+	pattern string
+	not     bool
+}
+
+func (c likeCond) eval(rec selectRecord) bool {
+	v := c.operand.value(rec)
+	if v.null {
+		return false
+	}
+	return likeMatch([]rune(c.pattern), []rune(v.s)) != c.not
+}
+
+// likeMatch 实现 % 与 _ 通配符匹配
+func likeMatch(pattern, s []rune) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '%':
+			for len(pattern) > 0 && pattern[0] == '%' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if likeMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '_':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+// isNullCond IS [NOT] NULL
+type isNullCond struct {
+	operand sqlOperand
+	not     bool
+}
+
+func (c isNullCond) eval(rec selectRecord) bool {
+	return c.operand.value(rec).null != c.not
+}
+
+// sqlParser 递归下降解析器
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+}
+
+// parseSelectQuery 解析 SELECT 表达式
+func parseSelectQuery(expr string) (*selectQuery, error) {
+	tokens, err := tokenizeSQL(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{tokens: tokens}
+	q := &selectQuery{limit: -1}
+
+	if !p.acceptKeyword("SELECT") {
+		return nil, fmt.Errorf("expression must start with SELECT")
+	}
+	if err := p.parseProjection(q); err != nil {
+		return nil, err
+	}
+	if !p.acceptKeyword("FROM") {
+		return nil, fmt.Errorf("expected FROM")
+	}
+	if tok := p.next(); tok.kind != sqlTokIdent || !strings.EqualFold(tok.text, "S3Object") {
+		return nil, fmt.Errorf("only FROM S3Object is supported")
+	}
+	// 兼容 S3Object[*]
+	if p.acceptSymbol("[") {
+		if !p.acceptSymbol("*") || !p.acceptSymbol("]") {
+			return nil, fmt.Errorf("unsupported S3Object path")
+		}
+	}
+	p.acceptKeyword("AS")
+	if tok := p.peek(); tok.kind == sqlTokIdent && !isSQLKeyword(tok.text) {
+		q.alias = p.next().text
+	}
+
+	if p.acceptKeyword("WHERE") {
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		q.where = cond
+	}
+	if p.acceptKeyword("LIMIT") {
+		tok := p.next()
+		n, err := strconv.Atoi(tok.text)
+		if tok.kind != sqlTokNumber || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid LIMIT value %q", tok.text)
+		}
+		q.limit = n
+	}
+	if tok := p.peek(); tok.kind != sqlTokEOF {
+		return nil, fmt.Errorf("unexpected token %q", tok.text)
+	}
+
+	q.resolveAlias()
+	return q, nil
+}
+
+// parseProjection 解析 SELECT 列表
+func (p *sqlParser) parseProjection(q *selectQuery) error {
+	if p.acceptSymbol("*") {
+		q.star = true
+		return nil
+	}
+	if p.peekKeyword("COUNT") {
+		p.next()
+		if !p.acceptSymbol("(") || !p.acceptSymbol("*") || !p.acceptSymbol(")") {
+			return fmt.Errorf("only COUNT(*) is supported")
+		}
+		q.count = true
+		return nil
+	}
+	for {
+		ref, err := p.parseColRef()
+		if err != nil {
+			return err
+		}
+		q.columns = append(q.columns, ref)
+		if !p.acceptSymbol(",") {
+			return nil
+		}
+	}
+}
+
+// parseColRef 解析列引用
+func (p *sqlParser) parseColRef() (colRef, error) {
+	var ref colRef
+	for {
+		tok := p.next()
+		if tok.kind == sqlTokSymbol && tok.text == "*" && len(ref.path) > 0 {
+			return ref, fmt.Errorf("alias.* is not supported, use *")
+		}
+		if (tok.kind != sqlTokIdent || isSQLKeyword(tok.text)) && tok.kind != sqlTokQuotedIdent {
+			return ref, fmt.Errorf("expected column name, got %q", tok.text)
+		}
+		ref.path = append(ref.path, tok.text)
+		if !p.acceptSymbol(".") {
+			return ref, nil
+		}
+	}
+}
+
+// parseOr 解析 OR 表达式
+func (p *sqlParser) parseOr() (sqlCond, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCond{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd 解析 AND 表达式
+func (p *sqlParser) parseAnd() (sqlCond, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andCond{left, right}
+	}
+	return left, nil
+}
+
+// parseNot 解析 NOT 与括号
+func (p *sqlParser) parseNot() (sqlCond, error) {
+	if p.acceptKeyword("NOT") {
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCond{c}, nil
+	}
+	if p.acceptSymbol("(") {
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptSymbol(")") {
+			return nil, fmt.Errorf("expected )")
+		}
+		return c, nil
+	}
+	return p.parsePredicate()
+}
+
+// parsePredicate 解析比较、LIKE、IS NULL
+func (p *sqlParser) parsePredicate() (sqlCond, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.acceptKeyword("IS") {
+		not := p.acceptKeyword("NOT")
+		if !p.acceptKeyword("NULL") {
+			return nil, fmt.Errorf("expected NULL after IS")
+		}
+		return isNullCond{operand: left, not: not}, nil
+	}
+
+	not := p.acceptKeyword("NOT")
+	if p.acceptKeyword("LIKE") {
+		tok := p.next()
+		if tok.kind != sqlTokString {
+			return nil, fmt.Errorf("LIKE pattern must be a string literal")
+		}
+		return likeCond{operand: left, pattern: tok.text, not: not}, nil
+	}
+	if not {
+		return nil, fmt.Errorf("expected LIKE after NOT")
+	}
+
+	tok := p.next()
+	switch tok.text {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("expected comparison operator, got %q", tok.text)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return compareCond{op: tok.text, left: left, right: right}, nil
+}
+
+// parseOperand 解析操作数
+func (p *sqlParser) parseOperand() (sqlOperand, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == sqlTokString:
+		p.next()
+		return literalOperand{sqlValue{s: tok.text}}, nil
+	case tok.kind == sqlTokNumber:
+		p.next()
+		return literalOperand{sqlValue{s: tok.text, isNum: true}}, nil
+	case tok.kind == sqlTokIdent && strings.EqualFold(tok.text, "NULL"):
+		p.next()
+		return literalOperand{sqlValue{null: true}}, nil
+	}
+	ref, err := p.parseColRef()
+	if err != nil {
+		return nil, err
+	}
+	return columnOperand{ref}, nil
+}
+
+func (p *sqlParser) peek() sqlToken { return p.tokens[p.pos] }
+
+func (p *sqlParser) next() sqlToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != sqlTokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *sqlParser) peekKeyword(kw string) bool {
+	tok := p.peek()
+	return tok.kind == sqlTokIdent && strings.EqualFold(tok.text, kw)
+}
+
+func (p *sqlParser) acceptKeyword(kw string) bool {
+	if p.peekKeyword(kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) acceptSymbol(sym string) bool {
+	tok := p.peek()
+	if tok.kind == sqlTokSymbol && tok.text == sym {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// resolveAlias 去掉列引用中的表别名前缀，并识别 _N 位置引用
+func (q *selectQuery) resolveAlias() {
+	fix := func(ref colRef) colRef {
+		if len(ref.path) > 1 && (strings.EqualFold(ref.path[0], q.alias) || strings.EqualFold(ref.path[0], "S3Object")) {
+			ref.path = ref.path[1:]
+		}
+		if len(ref.path) == 1 && strings.HasPrefix(ref.path[0], "_") {
+			if n, err := strconv.Atoi(ref.path[0][1:]); err == nil && n > 0 {
+				ref.pos = n
+			}
+		}
+		return ref
+	}
+	for i := range q.columns {
+		q.columns[i] = fix(q.columns[i])
+	}
+	q.where = fixCond(q.where, fix)
+}
+
+// fixCond 递归处理条件中的列引用
+func fixCond(c sqlCond, fix func(colRef) colRef) sqlCond {
+	fixOperand := func(o sqlOperand) sqlOperand {
+		if col, ok := o.(columnOperand); ok {
+			return columnOperand{fix(col.ref)}
+		}
+		return o
+	}
+	switch v := c.(type) {
+	case andCond:
+		return andCond{fixCond(v.l, fix), fixCond(v.r, fix)}
+	case orCond:
+		return orCond{fixCond(v.l, fix), fixCond(v.r, fix)}
+	case notCond:
+		return notCond{fixCond(v.c, fix)}
+	case compareCond:
+		return compareCond{op: v.op, left: fixOperand(v.left), right: fixOperand(v.right)}
+	case likeCond:
+		return likeCond{operand: fixOperand(v.operand), pattern: v.pattern, not: v.not}
+	case isNullCond:
+		return isNullCond{operand: fixOperand(v.operand), not: v.not}
+	}
+	return c
+}
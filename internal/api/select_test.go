@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"sss/internal/storage"
+)
+
+// selectEvent 解析后的事件消息
+type selectEvent struct {
+	headers map[string]string
+	payload []byte
+}
+
+// decodeSelectEvents 解析 event stream，同时校验 CRC
+func decodeSelectEvents(t *testing.T, data []byte) []selectEvent {
+	t.Helper()
+	var events []selectEvent
+	for len(data) > 0 {
+		if len(data) < 16 {
+			t.Fatalf("消息长度不足: %d", len(data))
+		}
+		total := int(binary.BigEndian.Uint32(data[0:4]))
+		hlen := int(binary.BigEndian.Uint32(data[4:8]))
+		if crc32.ChecksumIEEE(data[0:8]) != binary.BigEndian.Uint32(data[8:12]) {
+			t.Fatal("prelude CRC 错误")
+		}
+		if crc32.ChecksumIEEE(data[:total-4]) != binary.BigEndian.Uint32(data[total-4:total]) {
+			t.Fatal("message CRC 错误")
+		}
+
+		headers := make(map[string]string)
+		h := data[12 : 12+hlen]
+		for len(h) > 0 {
+			nl := int(h[0])
+			name := string(h[1 : 1+nl])
+			vl := int(binary.BigEndian.Uint16(h[2+nl : 4+nl]))
+			headers[name] = string(h[4+nl : 4+nl+vl])
+			h = h[4+nl+vl:]
+		}
+		events = append(events, selectEvent{headers: headers, payload: data[12+hlen : total-4]})
+		data = data[total:]
+	}
+	return events
+}
+
+// selectRecords 汇总所有 Records 事件的负载
+func selectRecords(events []selectEvent) string {
+	var sb strings.Builder
+	for _, e := range events {
+		if e.headers[":event-type"] == "Records" {
+			sb.Write(e.payload)
+		}
+	}
+	return sb.String()
+}
+
+// doSelect 执行 Select 请求
+func doSelect(t *testing.T, server *Server, key, expression, input, output string) *httptest.ResponseRecorder {
+	t.Helper()
+	body := `<SelectObjectContentRequest><Expression>` + expression + `</Expression><ExpressionType>SQL</ExpressionType>` +
+		`<InputSerialization>` + input + `</InputSerialization><OutputSerialization>` + output + `</OutputSerialization></SelectObjectContentRequest>`
+	req := httptest.NewRequest(http.MethodPost, "/select-bucket/"+key+"?select&select-type=2", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.handleSelectObjectContent(rec, req, "select-bucket", key)
+	return rec
+}
+
+// TestSelectObjectContent 测试 S3 Select
+func TestSelectObjectContent(t *testing.T) {
+	server, cleanup := setupObjectTestServer(t)
+	defer cleanup()
+
+	csvData := "name,age,city\nalice,30,beijing\nbob,25,shanghai\ncarol,41,\"new york, ny\"\n"
+	createTestBucketAndObject(t, server, "select-bucket", "people.csv", []byte(csvData))
+
+	const csvIn = `<CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV>`
+
+	t.Run("CSV输入WHERE过滤输出CSV", func(t *testing.T) {
+		rec := doSelect(t, server, "people.csv", "SELECT s.name, s.city FROM S3Object s WHERE s.age &gt; 26", csvIn, `<CSV/>`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("状态码错误: %d, body: %s", rec.Code, rec.Body.String())
+		}
+		events := decodeSelectEvents(t, rec.Body.Bytes())
+		got := selectRecords(events)
+		want := "alice,beijing\ncarol,\"new york, ny\"\n"
+		if got != want {
+			t.Errorf("结果错误:\n got %q\nwant %q", got, want)
+		}
+		last := events[len(events)-1]
+		if last.headers[":event-type"] != "End" {
+			t.Errorf("最后一条应为 End 事件: %v", last.headers)
+		}
+		var hasStats bool
+		for _, e := range events {
+			if e.headers[":event-type"] == "Stats" {
+				hasStats = strings.Contains(string(e.payload), "<BytesScanned>"+strconv.Itoa(len(csvData))+"</BytesScanned>")
+			}
+		}
+		if !hasStats {
+			t.Error("缺少正确的 Stats 事件")
+		}
+	})
+
+	t.Run("CSV输入输出JSON", func(t *testing.T) {
+		rec := doSelect(t, server, "people.csv", "SELECT * FROM S3Object WHERE name LIKE 'b%' OR city = 'beijing' LIMIT 5", csvIn, `<JSON/>`)
+		got := selectRecords(decodeSelectEvents(t, rec.Body.Bytes()))
+		want := `{"name":"alice","age":"30","city":"beijing"}` + "\n" + `{"name":"bob","age":"25","city":"shanghai"}` + "\n"
+		if got != want {
+			t.Errorf("结果错误:\n got %q\nwant %q", got, want)
+		}
+	})
+
+	t.Run("位置引用与COUNT", func(t *testing.T) {
+		rec := doSelect(t, server, "people.csv", "SELECT _1 FROM S3Object WHERE _2 &lt; 40 LIMIT 1", `<CSV><FileHeaderInfo>IGNORE</FileHeaderInfo></CSV>`, `<CSV/>`)
+		if got := selectRecords(decodeSelectEvents(t, rec.Body.Bytes())); got != "alice\n" {
+			t.Errorf("位置引用结果错误: %q", got)
+		}
+
+		rec = doSelect(t, server, "people.csv", "SELECT COUNT(*) FROM S3Object s WHERE NOT (s.city IS NULL) AND s.age &gt;= 25", csvIn, `<JSON/>`)
+		if got := selectRecords(decodeSelectEvents(t, rec.Body.Bytes())); got != `{"_1":3}`+"\n" {
+			t.Errorf("COUNT 结果错误: %q", got)
+		}
+	})
+
+	t.Run("JSON Lines 输入", func(t *testing.T) {
+		jsonData := `{"id":1,"user":{"name":"alice"},"tags":["a"]}` + "\n" + `{"id":2,"user":{"name":"bob"}}` + "\n"
+		storagePath, etag, _ := server.filestore.PutObject("select-bucket", "events.json", strings.NewReader(jsonData), int64(len(jsonData)))
+		putTestObjectMeta(t, server, "events.json", storagePath, etag, int64(len(jsonData)))
+
+		rec := doSelect(t, server, "events.json", "SELECT s.id, s.user.name FROM S3Object s WHERE s.id = 2", `<JSON><Type>LINES</Type></JSON>`, `<JSON/>`)
+		if got := selectRecords(decodeSelectEvents(t, rec.Body.Bytes())); got != `{"id":2,"name":"bob"}`+"\n" {
+			t.Errorf("JSON 结果错误: %q", got)
+		}
+	})
+
+	t.Run("GZIP压缩输入", func(t *testing.T) {
+		var gz bytes.Buffer
+		zw := gzip.NewWriter(&gz)
+		zw.Write([]byte(csvData))
+		zw.Close()
+		storagePath, etag, _ := server.filestore.PutObject("select-bucket", "people.csv.gz", bytes.NewReader(gz.Bytes()), int64(gz.Len()))
+		putTestObjectMeta(t, server, "people.csv.gz", storagePath, etag, int64(gz.Len()))
+
+		rec := doSelect(t, server, "people.csv.gz", "SELECT name FROM S3Object WHERE age = 25",
+			`<CompressionType>GZIP</CompressionType>`+csvIn, `<CSV/>`)
+		if got := selectRecords(decodeSelectEvents(t, rec.Body.Bytes())); got != "bob\n" {
+			t.Errorf("GZIP 结果错误: %q", got)
+		}
+	})
+
+	t.Run("参数错误", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/select-bucket/people.csv?select", strings.NewReader("<x/>"))
+		rec := httptest.NewRecorder()
+		server.handleSelectObjectContent(rec, req, "select-bucket", "people.csv")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("缺少 select-type 应返回400: %d", rec.Code)
+		}
+
+		if rec := doSelect(t, server, "people.csv", "DELETE FROM S3Object", csvIn, `<CSV/>`); rec.Code != http.StatusBadRequest {
+			t.Errorf("非 SELECT 表达式应返回400: %d", rec.Code)
+		}
+		if rec := doSelect(t, server, "missing.csv", "SELECT * FROM S3Object", csvIn, `<CSV/>`); rec.Code != http.StatusNotFound {
+			t.Errorf("对象不存在应返回404: %d", rec.Code)
+		}
+	})
+}
+
+// TestParseSelectQuery 测试 SQL 解析
+func TestParseSelectQuery(t *testing.T) {
+	valid := []string{
+		"SELECT * FROM S3Object",
+		"select s.a, s.\"b c\" from s3object s where s.a = 'x''y' and (s.b > -1 or not s.c like '%z')",
+		"SELECT * FROM S3Object[*] s WHERE s.a IS NOT NULL LIMIT 10",
+	}
+	for _, expr := range valid {
+		if _, err := parseSelectQuery(expr); err != nil {
+			t.Errorf("解析失败 %q: %v", expr, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"SELECT FROM S3Object",
+		"SELECT * FROM other",
+		"SELECT * FROM S3Object WHERE a ==",
+		"SELECT * FROM S3Object WHERE a = 'unterminated",
+		"SELECT * FROM S3Object LIMIT -1",
+	}
+	for _, expr := range invalid {
+		if _, err := parseSelectQuery(expr); err == nil {
+			t.Errorf("应解析失败: %q", expr)
+		}
+	}
+}
+
+// putTestObjectMeta 保存测试对象元数据
+func putTestObjectMeta(t *testing.T, server *Server, key, storagePath, etag string, size int64) {
+	t.Helper()
+	if err := server.metadata.PutObject(&storage.Object{
+		Key: key, Bucket: "select-bucket", Size: size, ETag: etag, StoragePath: storagePath,
+	}); err != nil {
+		t.Fatalf("保存元数据失败: %v", err)
+	}
+}
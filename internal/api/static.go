@@ -14,6 +14,8 @@ var useEmbed bool
 
 // serveStatic 处理静态文件请求
 func (s *Server) serveStatic(w http.ResponseWriter, r *http.Request) {
+	setSecurityHeaders(w)
+
 	// 获取请求路径
 	path := r.URL.Path
 
@@ -30,22 +32,37 @@ func (s *Server) serveStatic(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 处理静态资源（去掉开头的斜杠）
+	// 资源缺失时返回 404，避免把 index.html 当作 JS/CSS 返回
 	if strings.HasPrefix(path, "/assets/") {
 		s.serveStaticFile(w, r, path[1:]) // 去掉开头的 /
 		return
 	}
 
-	// 处理根目录静态文件（favicon.svg, robots.txt 等）
-	if strings.HasSuffix(path, ".svg") || strings.HasSuffix(path, ".ico") ||
-		strings.HasSuffix(path, ".png") || strings.HasSuffix(path, ".txt") {
+	// 处理根目录静态文件（favicon.svg, robots.txt 等），文件存在时直接返回
+	if isRootStaticFile(path) && staticFileExists(path[1:]) {
 		s.serveStaticFile(w, r, path[1:]) // 去掉开头的 /
 		return
 	}
 
-	// 其他路径也返回 index.html (SPA 路由)
+	// 其他路径（包括 /admin/ 下的深链接）返回 index.html，交给前端路由
 	s.serveStaticFile(w, r, "index.html")
 }
 
+// staticFileExists 检查静态文件是否存在（不含目录）
+func staticFileExists(name string) bool {
+	if staticFS == nil {
+		return false
+	}
+	stat, err := fs.Stat(staticFS, name)
+	return err == nil && !stat.IsDir()
+}
+
+// isAdminSPAPath 检查是否是管理界面 SPA 路径（/admin 或 /admin/...）
+// 不匹配 /admin-data 这类以 admin 开头的桶名
+func isAdminSPAPath(path string) bool {
+	return path == "/admin" || strings.HasPrefix(path, "/admin/")
+}
+
 // serveStaticFile 从文件系统或嵌入文件发送文件
 func (s *Server) serveStaticFile(w http.ResponseWriter, r *http.Request, name string) {
 	// 打开文件
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"io"
+	"net/http"
+)
+
+// streamFlushSize 流式响应累计写出该字节数后刷新一次
+// 长度未知的响应不设置 Content-Length，由 net/http 以 chunked 方式发送
+const streamFlushSize = 32 * 1024
+
+// flushWriter 每写出 size 字节刷新一次底层连接，避免结果在服务端缓冲区中积压
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+	size    int
+	pending int
+}
+
+// newFlushWriter 包装 ResponseWriter，不支持刷新时按普通写入处理
+func newFlushWriter(w http.ResponseWriter, size int) *flushWriter {
+	f, _ := w.(http.Flusher)
+	return &flushWriter{w: w, flusher: f, size: size}
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.pending += n
+	if f.flusher != nil && f.pending >= f.size {
+		f.flusher.Flush()
+		f.pending = 0
+	}
+	return n, err
+}
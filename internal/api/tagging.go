@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sort"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// maxTaggingBodySize PutObjectTagging 请求体大小上限
+const maxTaggingBodySize = 64 * 1024
+
+// Tagging GetObjectTagging 响应 / PutObjectTagging 请求
+type Tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	TagSet  []Tag    `xml:"TagSet>Tag"`
+}
+
+// Tag 对象标签
+type Tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// handleObjectTagging 处理 ?tagging 请求，只支持对象标签
+func (s *Server) handleObjectTagging(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	resource := "/" + bucket + "/" + key
+	if key == "" {
+		utils.WriteError(w, utils.ErrUnsupportedTagging, http.StatusNotImplemented, "/"+bucket)
+		return
+	}
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if b == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, "/"+bucket)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetObjectTagging(w, r, bucket, key)
+	case http.MethodPut:
+		if !checkReadOnly(w, b, resource) {
+			return
+		}
+		s.handlePutObjectTagging(w, r, bucket, key)
+	case http.MethodDelete:
+		if !checkReadOnly(w, b, resource) {
+			return
+		}
+		s.handleDeleteObjectTagging(w, r, bucket, key)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, resource)
+	}
+}
+
+// handleGetObjectTagging 返回对象标签，按键排序
+func (s *Server) handleGetObjectTagging(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	resource := "/" + bucket + "/" + key
+	// 公有桶的匿名读权限不包括标签
+	if isAnonymousRequest(r) {
+		utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, resource)
+		return
+	}
+	obj, err := s.getLiveObject(bucket, key)
+	if err != nil {
+		utils.Error("get object metadata failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if obj == nil {
+		utils.WriteError(w, utils.ErrNoSuchKey, http.StatusNotFound, resource)
+		return
+	}
+
+	tagging := Tagging{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/", TagSet: make([]Tag, 0, len(obj.Tags))}
+	for k, v := range obj.Tags {
+		tagging.TagSet = append(tagging.TagSet, Tag{Key: k, Value: v})
+	}
+	sort.Slice(tagging.TagSet, func(i, j int) bool { return tagging.TagSet[i].Key < tagging.TagSet[j].Key })
+	utils.WriteXML(w, http.StatusOK, tagging)
+}
+
+// handlePutObjectTagging 替换对象的全部标签，不改变 ETag 和最后修改时间
+func (s *Server) handlePutObjectTagging(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	resource := "/" + bucket + "/" + key
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxTaggingBodySize+1))
+	if err != nil || len(body) > maxTaggingBodySize {
+		utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, resource)
+		return
+	}
+	var tagging Tagging
+	if err := xml.Unmarshal(body, &tagging); err != nil {
+		utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, resource)
+		return
+	}
+	tags := make([]storage.ObjectTag, 0, len(tagging.TagSet))
+	for _, t := range tagging.TagSet {
+		tags = append(tags, storage.ObjectTag{Key: t.Key, Value: t.Value})
+	}
+	tagMap, err := storage.ValidateObjectTags(tags)
+	if err != nil {
+		invalid := utils.ErrInvalidTag
+		invalid.Message = err.Error()
+		utils.WriteError(w, invalid, http.StatusBadRequest, resource)
+		return
+	}
+
+	if !s.updateObjectTags(w, bucket, key, tagMap) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleDeleteObjectTagging 清除对象的全部标签
+func (s *Server) handleDeleteObjectTagging(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	if !s.updateObjectTags(w, bucket, key, nil) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updateObjectTags 保存对象标签，对象不存在或已过期时返回 404
+func (s *Server) updateObjectTags(w http.ResponseWriter, bucket, key string, tags map[string]string) bool {
+	resource := "/" + bucket + "/" + key
+	obj, err := s.getLiveObject(bucket, key)
+	if err == nil && obj != nil {
+		var updated bool
+		updated, err = s.metadata.PutObjectTags(bucket, key, tags)
+		if !updated {
+			obj = nil
+		}
+	}
+	if err != nil {
+		utils.Error("update object tags failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return false
+	}
+	if obj == nil {
+		utils.WriteError(w, utils.ErrNoSuchKey, http.StatusNotFound, resource)
+		return false
+	}
+	return true
+}
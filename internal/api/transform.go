@@ -0,0 +1,320 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// 图片缩放限制
+const (
+	MaxTransformDimension = 4096             // w/h 参数上限
+	MaxTransformPixels    = 25 * 1000 * 1000 // 源图片像素上限，防止解码占用过多内存
+	transformJPEGQuality  = 85
+)
+
+var (
+	// errTransformNoop 无需变换（如目标尺寸不小于原图），直接返回原对象
+	errTransformNoop = errors.New("transform not needed")
+	// errTransformTooLarge 源对象超出变换限制
+	errTransformTooLarge = errors.New("source too large to transform")
+)
+
+// objectTransform 按需对象变换，源对象内容类型匹配且请求带有对应查询参数时生效
+// 结果按源 ETag 和规范化参数缓存为派生对象
+type objectTransform struct {
+	contentTypes []string
+	// params 从查询参数解析规范化的变换参数，未请求该变换时返回空串
+	params func(q url.Values) (string, error)
+	// prepare 读取并校验源对象，返回结果的内容类型和写出结果的编码函数
+	// 编码前即可判断是否需要变换，编码输出长度事先未知
+	prepare func(src io.ReadSeeker, params string) (string, func(dst io.Writer) error, error)
+}
+
+// objectTransforms 已注册的变换，按顺序匹配第一个
+var objectTransforms = []objectTransform{
+	{
+		contentTypes: []string{"image/jpeg", "image/png"},
+		params:       imageResizeParams,
+		prepare:      prepareImageResize,
+	},
+}
+
+// matchTransform 查找适用于对象和查询参数的变换，未请求变换时返回 nil
+func matchTransform(contentType string, q url.Values) (*objectTransform, string, error) {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	for i := range objectTransforms {
+		t := &objectTransforms[i]
+		for _, ct := range t.contentTypes {
+			if ct != mediaType {
+				continue
+			}
+			params, err := t.params(q)
+			if err != nil || params == "" {
+				return nil, "", err
+			}
+			return t, params, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// serveTransformed 返回对象的变换结果，优先使用缓存的变体
+// 未命中缓存时边编码边以 chunked 方式输出，同时写入变体缓存
+// 返回 false 表示无需变换，调用方继续返回原对象
+func (s *Server) serveTransformed(w http.ResponseWriter, b *storage.Bucket, obj *storage.Object, t *objectTransform, params string) bool {
+	resource := "/" + obj.Bucket + "/" + obj.Key
+	variant, err := s.metadata.GetObjectVariant(obj.Bucket, obj.Key, obj.ETag, params)
+	if err != nil {
+		utils.Error("get object variant failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return true
+	}
+	if variant == nil {
+		return s.streamTransformed(w, b, obj, t, params)
+	}
+
+	file, err := s.filestore.GetObject(variant.StoragePath)
+	if err != nil {
+		utils.Error("get object variant file failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return true
+	}
+	defer file.Close()
+
+	setObjectHeaders(w, b, obj)
+	w.Header().Set("Content-Type", variant.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(variant.Size, 10))
+	w.Header().Set("ETag", `"`+variant.ETag+`"`)
+	w.Header().Set("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		utils.Debug("copy to response failed", "error", err)
+	}
+	return true
+}
+
+// streamTransformed 生成变体并流式返回，不设置 Content-Length 和 ETag
+// 编码结果同时写入变体缓存，缓存写入失败不影响响应，编码完整结束才保存变体记录
+func (s *Server) streamTransformed(w http.ResponseWriter, b *storage.Bucket, obj *storage.Object, t *objectTransform, params string) bool {
+	resource := "/" + obj.Bucket + "/" + obj.Key
+	src, err := s.filestore.OpenObject(obj)
+	if err != nil {
+		utils.Error("get object file failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return true
+	}
+	defer src.Close()
+
+	contentType, encode, err := t.prepare(src, params)
+	if err == errTransformNoop {
+		return false
+	}
+	if err == errTransformTooLarge {
+		utils.WriteError(w, utils.ErrInvalidArgument, http.StatusBadRequest, resource)
+		return true
+	}
+	if err != nil {
+		// 源内容无法解码时退回原对象
+		utils.Warn("transform object failed", "bucket", obj.Bucket, "key", obj.Key, "error", err)
+		return false
+	}
+
+	if obj.Encrypted() {
+		// 变体缓存以明文保存，加密对象每次重新生成，不在磁盘上留下明文
+		setObjectHeaders(w, b, obj)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		if err := encode(newFlushWriter(w, streamFlushSize)); err != nil {
+			utils.Warn("stream transformed object failed", "bucket", obj.Bucket, "key", obj.Key, "encode_error", err)
+		}
+		return true
+	}
+
+	pr, pw := io.Pipe()
+	type putResult struct {
+		path, etag string
+		size       int64
+		err        error
+	}
+	stored := make(chan putResult, 1)
+	go func() {
+		path, etag, size, err := s.filestore.PutVariant(obj.Bucket, obj.Key, obj.ETag, params, pr)
+		// 提前失败时关闭读端，避免编码端阻塞在管道写入上
+		pr.CloseWithError(err)
+		stored <- putResult{path, etag, size, err}
+	}()
+
+	setObjectHeaders(w, b, obj)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+
+	cache := &bestEffortWriter{w: pw}
+	encodeErr := encode(io.MultiWriter(newFlushWriter(w, streamFlushSize), cache))
+	if encodeErr == nil {
+		encodeErr = cache.err
+	}
+	pw.CloseWithError(encodeErr)
+	res := <-stored
+	if encodeErr != nil || res.err != nil {
+		utils.Warn("stream transformed object failed", "bucket", obj.Bucket, "key", obj.Key,
+			"encode_error", encodeErr, "store_error", res.err)
+		return true
+	}
+
+	variant := &storage.ObjectVariant{
+		Bucket:      obj.Bucket,
+		Key:         obj.Key,
+		SourceETag:  obj.ETag,
+		Params:      params,
+		StoragePath: res.path,
+		Size:        res.size,
+		ETag:        res.etag,
+		ContentType: contentType,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.metadata.SaveObjectVariant(variant); err != nil {
+		utils.Error("save object variant failed", "error", err)
+	}
+	return true
+}
+
+// bestEffortWriter 写入失败后丢弃后续数据并记录错误，不中断 MultiWriter 中的其他写入方
+type bestEffortWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (b *bestEffortWriter) Write(p []byte) (int, error) {
+	if b.err == nil {
+		_, b.err = b.w.Write(p)
+	}
+	return len(p), nil
+}
+
+// imageResizeParams 解析 w/h 参数，至少提供一个，规范化为 w=W,h=H（未提供为 0）
+func imageResizeParams(q url.Values) (string, error) {
+	ws, hs := q.Get("w"), q.Get("h")
+	if ws == "" && hs == "" {
+		return "", nil
+	}
+	parse := func(v string) (int, error) {
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxTransformDimension {
+			return 0, fmt.Errorf("dimension must be between 1 and %d", MaxTransformDimension)
+		}
+		return n, nil
+	}
+	width, err := parse(ws)
+	if err != nil {
+		return "", err
+	}
+	height, err := parse(hs)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("w=%d,h=%d", width, height), nil
+}
+
+// prepareImageResize 按比例缩小图片到 w×h 范围内，不放大，输出与源相同的格式
+func prepareImageResize(src io.ReadSeeker, params string) (string, func(dst io.Writer) error, error) {
+	var width, height int
+	if _, err := fmt.Sscanf(params, "w=%d,h=%d", &width, &height); err != nil {
+		return "", nil, err
+	}
+	cfg, format, err := image.DecodeConfig(src)
+	if err != nil {
+		return "", nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxTransformPixels {
+		return "", nil, errTransformTooLarge
+	}
+	dw, dh := fitSize(cfg.Width, cfg.Height, width, height)
+	if dw == cfg.Width && dh == cfg.Height {
+		return "", nil, errTransformNoop
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return "", nil, err
+	}
+	resized := resizeImage(img, dw, dh)
+	switch format {
+	case "jpeg":
+		return "image/jpeg", func(dst io.Writer) error {
+			return jpeg.Encode(dst, resized, &jpeg.Options{Quality: transformJPEGQuality})
+		}, nil
+	case "png":
+		return "image/png", func(dst io.Writer) error {
+			return png.Encode(dst, resized)
+		}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported image format %q", format)
+}
+
+// fitSize 计算保持宽高比、不超过 w×h 的尺寸（0 表示该方向不限制），不放大
+func fitSize(sw, sh, w, h int) (int, int) {
+	scale := 1.0
+	if w > 0 {
+		scale = math.Min(scale, float64(w)/float64(sw))
+	}
+	if h > 0 {
+		scale = math.Min(scale, float64(h)/float64(sh))
+	}
+	dw := int(math.Round(float64(sw) * scale))
+	dh := int(math.Round(float64(sh) * scale))
+	return max(dw, 1), max(dh, 1)
+}
+
+// resizeImage 区域平均缩小图片，dw/dh 不大于原尺寸
+func resizeImage(src image.Image, dw, dh int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba, ok := src.(*image.RGBA)
+	if !ok || bounds.Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	}
+	sw, sh := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, (y+1)*sh/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, (x+1)*sw/dw
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += uint64(row[sx*4+c])
+					}
+				}
+			}
+			n := uint64((y1 - y0) * (x1 - x0))
+			i := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[i+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"sss/internal/storage"
+	"sss/internal/utils"
+)
+
+// maxVersioningBodySize PutBucketVersioning 请求体大小上限
+const maxVersioningBodySize = 64 * 1024
+
+// S3 版本控制状态
+const (
+	versioningEnabled   = "Enabled"
+	versioningSuspended = "Suspended"
+)
+
+var (
+	// errDeleteMarker 请求的版本是删除标记
+	errDeleteMarker = errors.New("version is a delete marker")
+	// errVersionImmutable 要删除的版本处于桶的不可变窗口内
+	errVersionImmutable = errors.New("version is within the immutability window")
+)
+
+// VersioningConfiguration GetBucketVersioning 响应 / PutBucketVersioning 请求
+type VersioningConfiguration struct {
+	XMLName xml.Name `xml:"VersioningConfiguration"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Status  string   `xml:"Status,omitempty"`
+}
+
+// newVersionID 启用版本控制的桶为新写入生成版本ID，未启用时返回空（null 版本）
+func newVersionID(b *storage.Bucket) string {
+	if b == nil || !b.VersioningEnabled {
+		return ""
+	}
+	return utils.GenerateID(16)
+}
+
+// setVersionIDHeader 对象带版本ID时返回 x-amz-version-id
+func setVersionIDHeader(w http.ResponseWriter, obj *storage.Object) {
+	if obj.VersionID != "" {
+		w.Header().Set("x-amz-version-id", obj.VersionID)
+	}
+}
+
+// handleBucketVersioning 处理 ?versioning 请求
+// GET 返回 Enabled，未启用时返回空配置；PUT 只有桶所有者（管理员 Key）可以调用，Suspended 关闭版本控制并保留已有版本
+func (s *Server) handleBucketVersioning(w http.ResponseWriter, r *http.Request, bucket string) {
+	resource := "/" + bucket
+	b, err := s.metadata.GetBucket(bucket)
+	if err != nil {
+		utils.Error("check bucket failed", "error", err)
+		utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+		return
+	}
+	if b == nil {
+		utils.WriteError(w, utils.ErrNoSuchBucket, http.StatusNotFound, resource)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		config := VersioningConfiguration{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/"}
+		if b.VersioningEnabled {
+			config.Status = versioningEnabled
+		}
+		utils.WriteXML(w, http.StatusOK, config)
+	case http.MethodPut:
+		accessKeyID, _ := r.Context().Value(ContextKeyAccessKeyID).(string)
+		if accessKeyID == "" || accessKeyID != bucketOwnerID() {
+			utils.WriteError(w, utils.ErrAccessDenied, http.StatusForbidden, resource)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxVersioningBodySize+1))
+		if err != nil || len(body) > maxVersioningBodySize {
+			utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, resource)
+			return
+		}
+		var config VersioningConfiguration
+		if err := xml.Unmarshal(body, &config); err != nil ||
+			config.Status != versioningEnabled && config.Status != versioningSuspended {
+			utils.WriteError(w, utils.ErrMalformedXML, http.StatusBadRequest, resource)
+			return
+		}
+		enabled := config.Status == versioningEnabled
+		if err := s.metadata.UpdateBucketVersioning(bucket, enabled); err != nil {
+			utils.Error("update bucket versioning failed", "error", err)
+			utils.WriteError(w, utils.ErrInternalError, http.StatusInternalServerError, resource)
+			return
+		}
+		s.adminHandler.Audit(r, storage.AuditActionBucketSetVersioning, accessKeyID, bucket, true, map[string]interface{}{
+			"enabled": enabled,
+		})
+		w.WriteHeader(http.StatusOK)
+	default:
+		utils.WriteError(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed, resource)
+	}
+}
+
+// getObjectVersion 获取 GET/HEAD ?versionId= 请求的对象版本，不存在时返回 nil，删除标记返回 errDeleteMarker
+func (s *Server) getObjectVersion(bucket, key, versionID string) (*storage.Object, error) {
+	v, err := s.metadata.GetObjectVersion(bucket, key, versionID)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if v.DeleteMarker {
+		return nil, errDeleteMarker
+	}
+	return &v.Object, nil
+}
+
+// deleteObjectVersion 永久删除对象的指定版本及其文件，返回被删除的版本，不存在时为 nil
+// 处于桶不可变窗口内的版本不删除，返回 errVersionImmutable
+func (s *Server) deleteObjectVersion(b *storage.Bucket, key, versionID string) (*storage.ObjectVersion, error) {
+	v, err := s.metadata.GetObjectVersion(b.Name, key, versionID)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if !v.DeleteMarker && b.ImmutableRemaining(&v.Object, time.Now()) > 0 {
+		return nil, errVersionImmutable
+	}
+	if v, err = s.metadata.DeleteObjectVersion(b.Name, key, versionID); err != nil || v == nil {
+		return nil, err
+	}
+	if !v.DeleteMarker {
+		if err := s.filestore.DeleteObject(v.StoragePath); err != nil {
+			utils.Warn("delete object version file failed", "key", key, "version", versionID, "error", err)
+		}
+	}
+	s.adminHandler.NotifyObjectRemoved(storage.EventObjectRemovedDelete, b.Name, key, versionID)
+	// 最新版本变化后按当前状态同步到复制目标
+	if v.IsLatest {
+		cur, err := s.metadata.GetObject(b.Name, key)
+		if err == nil && cur != nil {
+			s.adminHandler.Replicate(b.Name, key, storage.ReplicationOpPut)
+		} else if err == nil {
+			s.adminHandler.Replicate(b.Name, key, storage.ReplicationOpDelete)
+		}
+	}
+	return v, nil
+}
+
+// setDeleteVersionHeaders 返回删除操作涉及的版本ID，以及该版本是否为删除标记
+func setDeleteVersionHeaders(w http.ResponseWriter, versionID string, deleteMarker bool) {
+	w.Header().Set("x-amz-version-id", versionID)
+	if deleteMarker {
+		w.Header().Set("x-amz-delete-marker", "true")
+	}
+}
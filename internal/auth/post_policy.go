@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"sss/internal/config"
+)
+
+// PostKeyFilename 表单 key 字段中的占位符，上传时替换为浏览器提交的文件名
+const PostKeyFilename = "${filename}"
+
+// POST 策略校验错误
+var (
+	ErrPostPolicyMissing   = errors.New("post policy fields are missing")
+	ErrPostPolicyMalformed = errors.New("post policy document is malformed")
+	ErrPostPolicyExpired   = errors.New("post policy expired")
+	ErrPostPolicySignature = errors.New("post policy signature does not match")
+	ErrPostPolicyCondition = errors.New("post policy condition failed")
+)
+
+// PostPolicyOptions 预签名 POST 表单选项
+type PostPolicyOptions struct {
+	Expires          time.Duration // 过期时间
+	MinContentLength int64         // 最小文件大小（字节）
+	MaxContentLength int64         // 最大文件大小（字节），0 表示只受全局限制
+	ContentType      string        // 限制 Content-Type 字段，空表示不限制
+	AccessKeyID      string        // 签名使用的 API Key，空表示旧配置的管理员 Key
+}
+
+// PresignedPost 浏览器表单直传的提交地址和需要原样放入表单的字段
+type PresignedPost struct {
+	URL    string            `json:"url"`
+	Fields map[string]string `json:"fields"`
+}
+
+// GeneratePresignedPost 生成 POST 策略并签名
+// key 含 ${filename} 时策略只限制占位符之前的前缀，否则要求 key 完全一致
+func GeneratePresignedPost(bucket, key string, opts *PostPolicyOptions) (*PresignedPost, error) {
+	cfg := config.Global
+	host, scheme := presignEndpoint()
+
+	now := time.Now().UTC()
+	dateStr := now.Format("20060102")
+	amzDate := now.Format("20060102T150405Z")
+
+	accessKeyID, secretKey := cfg.Auth.AccessKeyID, cfg.Auth.SecretAccessKey
+	if opts.AccessKeyID != "" {
+		accessKeyID, secretKey = opts.AccessKeyID, getSecretKey(opts.AccessKeyID)
+	}
+	credential := fmt.Sprintf("%s/%s/%s/s3/aws4_request", accessKeyID, dateStr, cfg.Server.Region)
+
+	conditions := []interface{}{
+		map[string]string{"bucket": bucket},
+	}
+	if i := strings.Index(key, PostKeyFilename); i >= 0 {
+		conditions = append(conditions, []string{"starts-with", "$key", key[:i]})
+	} else {
+		conditions = append(conditions, map[string]string{"key": key})
+	}
+
+	// 文件大小限制不超过全局上传限制
+	maxLength := opts.MaxContentLength
+	if cfg.Storage.MaxUploadSize > 0 && (maxLength <= 0 || maxLength > cfg.Storage.MaxUploadSize) {
+		maxLength = cfg.Storage.MaxUploadSize
+	}
+	if opts.MinContentLength > 0 || maxLength > 0 {
+		if maxLength <= 0 {
+			maxLength = math.MaxInt64
+		}
+		conditions = append(conditions, []interface{}{"content-length-range", opts.MinContentLength, maxLength})
+	}
+
+	fields := map[string]string{
+		"key":              key,
+		"x-amz-algorithm":  algorithm,
+		"x-amz-credential": credential,
+		"x-amz-date":       amzDate,
+	}
+	if opts.ContentType != "" {
+		fields["Content-Type"] = opts.ContentType
+		conditions = append(conditions, map[string]string{"Content-Type": opts.ContentType})
+	}
+	for _, name := range []string{"x-amz-algorithm", "x-amz-credential", "x-amz-date"} {
+		conditions = append(conditions, map[string]string{name: fields[name]})
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": now.Add(opts.Expires).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(policy)
+	signingKey := deriveSigningKey(secretKey, dateStr, cfg.Server.Region)
+	fields["policy"] = encoded
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(signingKey, []byte(encoded)))
+
+	return &PresignedPost{
+		URL:    fmt.Sprintf("%s://%s/%s", scheme, host, bucket),
+		Fields: fields,
+	}, nil
+}
+
+// postCondition 策略中的一条字段条件，field 为小写字段名
+type postCondition struct {
+	op    string // eq 或 starts-with
+	field string
+	value string
+}
+
+// PostPolicy 签名校验通过的 POST 策略
+type PostPolicy struct {
+	Expiration time.Time
+
+	conditions     []postCondition
+	hasLengthRange bool
+	minLength      int64
+	maxLength      int64
+}
+
+// ContentLengthRange 返回策略限制的文件大小范围，未限制时 ok 为 false
+func (p *PostPolicy) ContentLengthRange() (min, max int64, ok bool) {
+	return p.minLength, p.maxLength, p.hasLengthRange
+}
+
+// VerifyPostPolicy 校验表单中的策略签名和过期时间，返回签名使用的 Access Key ID
+// fields 的键为小写表单字段名
+func VerifyPostPolicy(fields map[string]string, now time.Time) (string, *PostPolicy, error) {
+	encoded, signature, credential := fields["policy"], fields["x-amz-signature"], fields["x-amz-credential"]
+	if encoded == "" || signature == "" || credential == "" || fields["x-amz-date"] == "" {
+		return "", nil, ErrPostPolicyMissing
+	}
+	if fields["x-amz-algorithm"] != algorithm {
+		return "", nil, ErrPostPolicyMalformed
+	}
+
+	// Credential 格式: accessKey/date/region/s3/aws4_request
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[3] != serviceName || parts[4] != terminationStr {
+		return "", nil, ErrPostPolicyMalformed
+	}
+	secretKey := getSecretKey(parts[0])
+	if secretKey == "" {
+		return "", nil, ErrPostPolicySignature
+	}
+	signingKey := deriveSigningKey(secretKey, parts[1], parts[2])
+	expected := hex.EncodeToString(hmacSHA256(signingKey, []byte(encoded)))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return "", nil, ErrPostPolicySignature
+	}
+
+	policy, err := parsePostPolicy(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+	if now.After(policy.Expiration) {
+		return "", nil, ErrPostPolicyExpired
+	}
+	return parts[0], policy, nil
+}
+
+// parsePostPolicy 解析 base64 编码的策略文档
+func parsePostPolicy(encoded string) (*PostPolicy, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrPostPolicyMalformed
+	}
+	var doc struct {
+		Expiration string            `json:"expiration"`
+		Conditions []json.RawMessage `json:"conditions"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, ErrPostPolicyMalformed
+	}
+	expiration, err := time.Parse(time.RFC3339, doc.Expiration)
+	if err != nil {
+		return nil, ErrPostPolicyMalformed
+	}
+
+	policy := &PostPolicy{Expiration: expiration}
+	for _, raw := range doc.Conditions {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '{' {
+			// {"field": "value"} 等同于 ["eq", "$field", "value"]
+			var m map[string]string
+			if err := json.Unmarshal(raw, &m); err != nil || len(m) != 1 {
+				return nil, ErrPostPolicyMalformed
+			}
+			for field, value := range m {
+				policy.conditions = append(policy.conditions, postCondition{"eq", strings.ToLower(field), value})
+			}
+			continue
+		}
+
+		var list []interface{}
+		if err := json.Unmarshal(raw, &list); err != nil || len(list) != 3 {
+			return nil, ErrPostPolicyMalformed
+		}
+		op, _ := list[0].(string)
+		op = strings.ToLower(op)
+		if op == "content-length-range" {
+			min, ok1 := list[1].(float64)
+			max, ok2 := list[2].(float64)
+			if !ok1 || !ok2 || min < 0 || max < min {
+				return nil, ErrPostPolicyMalformed
+			}
+			policy.hasLengthRange = true
+			policy.minLength = int64(min)
+			policy.maxLength = math.MaxInt64
+			if max < math.MaxInt64 {
+				policy.maxLength = int64(max)
+			}
+			continue
+		}
+		field, ok1 := list[1].(string)
+		value, ok2 := list[2].(string)
+		if (op != "eq" && op != "starts-with") || !ok1 || !ok2 || !strings.HasPrefix(field, "$") {
+			return nil, ErrPostPolicyMalformed
+		}
+		policy.conditions = append(policy.conditions, postCondition{op, strings.ToLower(field[1:]), value})
+	}
+	return policy, nil
+}
+
+// CheckFields 检查表单字段是否满足策略条件
+// 与 S3 一致，除签名相关字段、file 和 x-ignore- 前缀的字段外，每个字段都必须有对应的条件
+func (p *PostPolicy) CheckFields(fields map[string]string) error {
+	covered := make(map[string]bool, len(p.conditions))
+	for _, c := range p.conditions {
+		covered[c.field] = true
+		value := fields[c.field]
+		if c.op == "eq" && value != c.value || c.op == "starts-with" && !strings.HasPrefix(value, c.value) {
+			return fmt.Errorf("%w: [%q, \"$%s\", %q]", ErrPostPolicyCondition, c.op, c.field, c.value)
+		}
+	}
+	for name := range fields {
+		switch {
+		case covered[name], name == "policy", name == "x-amz-signature", name == "file", name == "bucket",
+			strings.HasPrefix(name, "x-ignore-"):
+		default:
+			return fmt.Errorf("%w: extra input field %s", ErrPostPolicyCondition, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestGeneratePresignedPost 测试生成的 POST 策略可以通过校验
+func TestGeneratePresignedPost(t *testing.T) {
+	setupPresignTestConfig()
+
+	post, err := GeneratePresignedPost("bucket", "uploads/${filename}", &PostPolicyOptions{
+		Expires:          time.Hour,
+		MinContentLength: 1,
+		MaxContentLength: 1024,
+		ContentType:      "image/png",
+	})
+	if err != nil {
+		t.Fatalf("生成失败: %v", err)
+	}
+	if post.URL != "http://localhost:8080/bucket" {
+		t.Errorf("表单地址错误: %s", post.URL)
+	}
+
+	fields := map[string]string{"bucket": "bucket", "content-type": "image/png"}
+	for k, v := range post.Fields {
+		if k != "Content-Type" {
+			fields[k] = v
+		}
+	}
+	accessKeyID, policy, err := VerifyPostPolicy(fields, time.Now())
+	if err != nil || accessKeyID != "test-access-key" {
+		t.Fatalf("校验失败: %q, %v", accessKeyID, err)
+	}
+	if min, max, ok := policy.ContentLengthRange(); !ok || min != 1 || max != 1024 {
+		t.Errorf("大小范围错误: %d %d %v", min, max, ok)
+	}
+	if err := policy.CheckFields(fields); err != nil {
+		t.Errorf("原样提交的字段应满足策略: %v", err)
+	}
+
+	fields["key"] = "other/a.png"
+	if err := policy.CheckFields(fields); !errors.Is(err, ErrPostPolicyCondition) {
+		t.Errorf("键前缀不符应失败: %v", err)
+	}
+
+	if _, _, err := VerifyPostPolicy(fields, time.Now().Add(2*time.Hour)); !errors.Is(err, ErrPostPolicyExpired) {
+		t.Errorf("过期策略应失败: %v", err)
+	}
+}
+
+// TestParsePostPolicy 测试策略文档解析
+func TestParsePostPolicy(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	cases := []struct {
+		name   string
+		policy string
+		ok     bool
+	}{
+		{"对象与数组条件", `{"expiration":"2030-01-01T00:00:00.000Z","conditions":[{"bucket":"b"},["eq","$key","k"],["starts-with","$Content-Type","image/"],["content-length-range",0,10]]}`, true},
+		{"过期时间格式错误", `{"expiration":"tomorrow","conditions":[]}`, false},
+		{"未知操作", `{"expiration":"2030-01-01T00:00:00Z","conditions":[["matches","$key","k"]]}`, false},
+		{"字段缺少$", `{"expiration":"2030-01-01T00:00:00Z","conditions":[["eq","key","k"]]}`, false},
+		{"大小范围颠倒", `{"expiration":"2030-01-01T00:00:00Z","conditions":[["content-length-range",10,1]]}`, false},
+		{"对象条件多个键", `{"expiration":"2030-01-01T00:00:00Z","conditions":[{"a":"1","b":"2"}]}`, false},
+	}
+	for _, tc := range cases {
+		_, err := parsePostPolicy(encode(tc.policy))
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+	}
+
+	policy, _ := parsePostPolicy(encode(cases[0].policy))
+	fields := map[string]string{"bucket": "b", "key": "k", "content-type": "image/png", "x-ignore-note": "1", "policy": "p", "x-amz-signature": "s"}
+	if err := policy.CheckFields(fields); err != nil {
+		t.Errorf("字段名不区分大小写、忽略 x-ignore- 字段: %v", err)
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net/url"
-	"sort"
-	"strings"
 	"time"
 
 	"sss/internal/config"
@@ -14,9 +12,16 @@ import (
 
 // PresignOptions 预签名URL选项
 type PresignOptions struct {
-	MaxContentLength int64     // 最大内容长度（字节），0表示不限制
-	ContentType      string    // 限制内容类型
+	MaxContentLength int64         // 最大内容长度（字节），0表示不限制
+	ContentType      string        // 限制内容类型
 	Expires          time.Duration // 过期时间
+	AccessKeyID      string        // 签名使用的 API Key，空表示旧配置的管理员 Key
+}
+
+// CanPresign 判断能否以该 Access Key 签发预签名 URL 或 POST 策略
+// 只有管理员 Key 和长期 API Key 有可签名的 Secret Key；临时凭证须携带会话令牌，不能把令牌放进 URL 转交他人
+func CanPresign(accessKeyID string) bool {
+	return accessKeyID != "" && getSecretKey(accessKeyID) != ""
 }
 
 // GeneratePresignedURL 生成预签名 URL（向后兼容）
@@ -31,19 +36,19 @@ func GeneratePresignedURLWithOptions(method, bucket, key string, opts *PresignOp
 	cfg := config.Global
 
 	// 构建 URL
-	host := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	if cfg.Server.Host == "0.0.0.0" {
-		host = fmt.Sprintf("localhost:%d", cfg.Server.Port)
-	}
-
+	host, scheme := presignEndpoint()
 	path := fmt.Sprintf("/%s/%s", bucket, key)
 
 	now := time.Now().UTC()
 	dateStr := now.Format("20060102")
 	amzDate := now.Format("20060102T150405Z")
 
+	accessKeyID, secretKey := cfg.Auth.AccessKeyID, cfg.Auth.SecretAccessKey
+	if opts.AccessKeyID != "" {
+		accessKeyID, secretKey = opts.AccessKeyID, getSecretKey(opts.AccessKeyID)
+	}
 	credential := fmt.Sprintf("%s/%s/%s/s3/aws4_request",
-		cfg.Auth.AccessKeyID, dateStr, cfg.Server.Region)
+		accessKeyID, dateStr, cfg.Server.Region)
 
 	// 构建查询参数
 	params := url.Values{
@@ -100,30 +105,28 @@ func GeneratePresignedURLWithOptions(method, bucket, key string, opts *PresignOp
 	)
 
 	// 计算签名
-	signingKey := deriveSigningKey(cfg.Auth.SecretAccessKey, dateStr, cfg.Server.Region)
+	signingKey := deriveSigningKey(secretKey, dateStr, cfg.Server.Region)
 	signature := hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign)))
 
-	// 构建最终 URL（使用可配置的协议）
-	scheme := "http"
-	if cfg.Security.PresignScheme != "" {
-		scheme = cfg.Security.PresignScheme
-	}
+	// 构建最终 URL
 	return fmt.Sprintf("%s://%s%s?%s&X-Amz-Signature=%s",
 		scheme, host, path, canonicalQuery, signature)
 }
 
-func getCanonicalQueryStringForPresign(params url.Values) string {
-	var keys []string
-	for k := range params {
-		keys = append(keys, k)
+// presignEndpoint 返回预签名 URL 使用的主机和协议（协议可配置）
+func presignEndpoint() (host, scheme string) {
+	cfg := config.Global
+	host = fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	if cfg.Server.Host == "0.0.0.0" {
+		host = fmt.Sprintf("localhost:%d", cfg.Server.Port)
 	}
-	sort.Strings(keys)
-
-	var pairs []string
-	for _, k := range keys {
-		for _, v := range params[k] {
-			pairs = append(pairs, fmt.Sprintf("%s=%s", url.QueryEscape(k), url.QueryEscape(v)))
-		}
+	scheme = "http"
+	if cfg.Security.PresignScheme != "" {
+		scheme = cfg.Security.PresignScheme
 	}
-	return strings.Join(pairs, "&")
+	return host, scheme
+}
+
+func getCanonicalQueryStringForPresign(params url.Values) string {
+	return canonicalQuery(params)
 }
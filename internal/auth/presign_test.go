@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
@@ -444,6 +445,43 @@ func TestPresignedURLExpiration(t *testing.T) {
 	}
 }
 
+// TestPresignedURLExpired 测试预签名URL过期检测
+func TestPresignedURLExpired(t *testing.T) {
+	setupPresignTestConfig()
+
+	past := time.Now().Add(-2 * time.Hour).UTC().Format("20060102T150405Z")
+
+	t.Run("已过期", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/bucket/key?X-Amz-Signature=abc&X-Amz-Date="+past+"&X-Amz-Expires=60", nil)
+		expiresAt, expired := PresignedURLExpired(req)
+		if !expired {
+			t.Fatal("应识别为已过期")
+		}
+		if time.Since(expiresAt) < time.Hour {
+			t.Errorf("过期时间计算错误: %v", expiresAt)
+		}
+		if VerifyRequest(req) {
+			t.Error("过期URL不应通过验证")
+		}
+	})
+
+	t.Run("未过期", func(t *testing.T) {
+		u, _ := url.Parse(GeneratePresignedURL("GET", "bucket", "key", time.Hour))
+		req := httptest.NewRequest("GET", u.RequestURI(), nil)
+		if _, expired := PresignedURLExpired(req); expired {
+			t.Error("有效URL不应识别为过期")
+		}
+	})
+
+	t.Run("非预签名请求", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/bucket/key?X-Amz-Date="+past+"&X-Amz-Expires=60", nil)
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=x")
+		if _, expired := PresignedURLExpired(req); expired {
+			t.Error("头部认证不应识别为预签名过期")
+		}
+	})
+}
+
 // TestPresignedURLSignature 测试预签名URL签名
 func TestPresignedURLSignature(t *testing.T) {
 	setupPresignTestConfig()
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -49,6 +50,27 @@ func CheckBucketPermission(accessKeyID, bucket string, needWrite bool) bool {
 	return false
 }
 
+// KeyNamespace 返回 API Key 的桶命名空间，旧配置的管理员 Key 不受限制
+func KeyNamespace(accessKeyID string) string {
+	if accessKeyID == "" || apiKeyCache == nil ||
+		(config.Global.Auth.AccessKeyID != "" && accessKeyID == config.Global.Auth.AccessKeyID) {
+		return ""
+	}
+	return apiKeyCache.Namespace(accessKeyID)
+}
+
+// CredentialAccessKey 从 Authorization 头或预签名参数中取出声明的 Access Key ID（不校验签名）
+func CredentialAccessKey(r *http.Request) string {
+	if credential := r.URL.Query().Get("X-Amz-Credential"); credential != "" {
+		accessKeyID, _, _ := strings.Cut(credential, "/")
+		return accessKeyID
+	}
+	if matches := authHeaderRegex.FindStringSubmatch(r.Header.Get("Authorization")); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
+
 const (
 	algorithm       = "AWS4-HMAC-SHA256"
 	serviceName     = "s3"
@@ -90,7 +112,7 @@ func VerifyRequestAndGetAccessKey(r *http.Request) (string, bool) {
 	signature := matches[5]
 
 	// 获取对应的 Secret Key
-	secretKey := getSecretKey(accessKey)
+	secretKey := getRequestSecretKey(r, accessKey)
 	if secretKey == "" {
 		utils.Debug("invalid access key", "got", accessKey)
 		return "", false
@@ -123,6 +145,52 @@ func getSecretKey(accessKeyID string) string {
 	return ""
 }
 
+// getRequestSecretKey 获取请求所用 Access Key 的 Secret Key
+// 长期密钥直接查找；临时凭证须同时携带有效的会话令牌（x-amz-security-token 头或 X-Amz-Security-Token 参数）
+func getRequestSecretKey(r *http.Request, accessKeyID string) string {
+	if secret := getSecretKey(accessKeyID); secret != "" {
+		return secret
+	}
+	token := sessionToken(r)
+	if token == "" || apiKeyCache == nil {
+		return ""
+	}
+	secret, _ := apiKeyCache.TemporarySecretKey(accessKeyID, token, time.Now())
+	return secret
+}
+
+// sessionToken 取出请求携带的会话令牌
+func sessionToken(r *http.Request) string {
+	if token := r.Header.Get("X-Amz-Security-Token"); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("X-Amz-Security-Token")
+}
+
+// IssueTemporaryCredential 签发限定桶和读写权限的临时凭证
+func IssueTemporaryCredential(bucket string, canRead, canWrite bool, duration time.Duration) *storage.TemporaryCredential {
+	if apiKeyCache == nil {
+		return nil
+	}
+	return apiKeyCache.IssueTemporaryCredential(bucket, canRead, canWrite, duration)
+}
+
+// SessionTokenExpired 判断请求是否使用了已过期的临时凭证，用于与签名错误区分
+func SessionTokenExpired(r *http.Request) bool {
+	if apiKeyCache == nil || sessionToken(r) == "" {
+		return false
+	}
+	return apiKeyCache.TemporaryCredentialExpired(CredentialAccessKey(r), time.Now())
+}
+
+// SweepTemporaryCredentials 清理缓存中已过期的临时凭证，返回清理数量
+func SweepTemporaryCredentials(now time.Time) int {
+	if apiKeyCache == nil {
+		return 0
+	}
+	return apiKeyCache.SweepTemporaryCredentials(now)
+}
+
 // calculateSignatureWithSecret 使用指定密钥计算请求签名
 func calculateSignatureWithSecret(r *http.Request, dateStr, region, signedHeaders, secretKey string) string {
 	// 获取请求时间
@@ -147,13 +215,30 @@ func calculateSignatureWithSecret(r *http.Request, dateStr, region, signedHeader
 	return signature
 }
 
+// signedPathKey 请求上下文中客户端签名时使用的路径
+type signedPathKey struct{}
+
+// WithSignedPath 记录客户端签名时使用的路径
+// 虚拟主机风格请求在路由前会把桶名加到 URL.Path 前，签名仍按客户端发出的原始路径校验
+func WithSignedPath(r *http.Request, path string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), signedPathKey{}, path))
+}
+
+// signedPath 返回用于计算签名的请求路径
+func signedPath(r *http.Request) string {
+	if path, ok := r.Context().Value(signedPathKey{}).(string); ok {
+		return path
+	}
+	return r.URL.Path
+}
+
 // createCanonicalRequest 创建规范请求
 func createCanonicalRequest(r *http.Request, signedHeaders string) string {
 	// HTTP 方法
 	method := r.Method
 
 	// 规范 URI
-	canonicalURI := getCanonicalURI(r.URL.Path)
+	canonicalURI := getCanonicalURI(signedPath(r))
 
 	// 规范查询字符串
 	canonicalQuery := getCanonicalQueryString(r.URL.Query())
@@ -237,22 +322,84 @@ func getCanonicalQueryString(query url.Values) string {
 		return ""
 	}
 
-	var keys []string
-	for k := range query {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
+	return canonicalQuery(query)
+}
 
-	var pairs []string
-	for _, k := range keys {
-		values := query[k]
-		sort.Strings(values)
+// canonicalQuery 按 SigV4 规则构造规范查询字符串
+// 键与值分别 URI 编码，先按编码后的键、再按编码后的值排序；无值参数输出为 key=
+func canonicalQuery(query url.Values) string {
+	type pair struct{ k, v string }
+	var pairs []pair
+	for k, values := range query {
+		ek := uriEncode(k)
 		for _, v := range values {
-			pairs = append(pairs, fmt.Sprintf("%s=%s", url.QueryEscape(k), url.QueryEscape(v)))
+			pairs = append(pairs, pair{ek, uriEncode(v)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].k != pairs[j].k {
+			return pairs[i].k < pairs[j].k
+		}
+		return pairs[i].v < pairs[j].v
+	})
+
+	parts := make([]string, len(pairs))
+	for i, p := range pairs {
+		parts[i] = p.k + "=" + p.v
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode 按 AWS 规则编码：除 A-Z a-z 0-9 - _ . ~ 外的字节均编码为 %XX（大写）
+// 与 url.QueryEscape 不同，空格编码为 %20 而非 +
+func uriEncode(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+			continue
 		}
+		sb.WriteByte('%')
+		sb.WriteByte(hexDigits[c>>4])
+		sb.WriteByte(hexDigits[c&0xF])
+	}
+	return sb.String()
+}
+
+// presignExpiration 根据 X-Amz-Date 与 X-Amz-Expires 计算预签名 URL 的过期时间
+func presignExpiration(query url.Values) (time.Time, bool) {
+	amzDate := query.Get("X-Amz-Date")
+	expires := query.Get("X-Amz-Expires")
+	if amzDate == "" || expires == "" {
+		return time.Time{}, false
 	}
 
-	return strings.Join(pairs, "&")
+	t, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var expireSec int
+	fmt.Sscanf(expires, "%d", &expireSec)
+	return t.Add(time.Duration(expireSec) * time.Second), true
+}
+
+// PresignedURLExpired 判断请求是否为已过期的预签名 URL，返回过期时间
+// 仅针对查询参数认证，使用 Authorization 头的请求始终返回 false
+func PresignedURLExpired(r *http.Request) (time.Time, bool) {
+	query := r.URL.Query()
+	if query.Get("X-Amz-Signature") == "" {
+		return time.Time{}, false
+	}
+	expiresAt, ok := presignExpiration(query)
+	if !ok || !time.Now().After(expiresAt) {
+		return time.Time{}, false
+	}
+	return expiresAt, true
 }
 
 // verifyPresignedURL 验证预签名 URL，返回 access key ID
@@ -275,7 +422,7 @@ func verifyPresignedURL(r *http.Request) (string, bool) {
 	region := parts[2]
 
 	// 获取对应的 Secret Key
-	secretKey := getSecretKey(accessKeyID)
+	secretKey := getRequestSecretKey(r, accessKeyID)
 	if secretKey == "" {
 		utils.Debug("invalid access key in presigned URL", "got", accessKeyID)
 		return "", false
@@ -283,19 +430,11 @@ func verifyPresignedURL(r *http.Request) (string, bool) {
 
 	// 检查过期时间
 	amzDate := query.Get("X-Amz-Date")
-	expires := query.Get("X-Amz-Expires")
-	if amzDate == "" || expires == "" {
-		return "", false
-	}
-
-	t, err := time.Parse("20060102T150405Z", amzDate)
-	if err != nil {
+	expiresAt, ok := presignExpiration(query)
+	if !ok {
 		return "", false
 	}
-
-	var expireSec int
-	fmt.Sscanf(expires, "%d", &expireSec)
-	if time.Now().After(t.Add(time.Duration(expireSec) * time.Second)) {
+	if time.Now().After(expiresAt) {
 		utils.Debug("presigned URL expired")
 		return "", false
 	}
@@ -317,10 +456,11 @@ func verifyPresignedURL(r *http.Request) (string, bool) {
 
 	// 对URL路径进行解码，因为浏览器会自动编码中文字符
 	// 但生成预签名URL时使用的是原始未编码的路径
-	decodedPath, err := url.PathUnescape(r.URL.Path)
+	path := signedPath(r)
+	decodedPath, err := url.PathUnescape(path)
 	if err != nil {
-		utils.Debug("failed to decode path", "path", r.URL.Path, "error", err)
-		decodedPath = r.URL.Path // 解码失败则使用原路径
+		utils.Debug("failed to decode path", "path", path, "error", err)
+		decodedPath = path // 解码失败则使用原路径
 	}
 	canonicalURI := decodedPath
 	canonicalQuery := getCanonicalQueryString(queryWithoutSig)
@@ -368,3 +508,30 @@ func GetPayloadHash(r *http.Request) string {
 	hash := sha256.Sum256(body)
 	return hex.EncodeToString(hash[:])
 }
+
+// VerifyKeyRoundTrip 用缓存中的密钥签名一个内部请求并走完整验证流程
+// 管理员看不到密钥，借此确认 Key 已加载、已启用且可以完成签名认证
+func VerifyKeyRoundTrip(accessKeyID string) bool {
+	secret := getSecretKey(accessKeyID)
+	if secret == "" {
+		return false
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://sss.internal/", nil)
+	if err != nil {
+		return false
+	}
+	now := time.Now().UTC()
+	dateStr := now.Format("20060102")
+	region := config.Global.Server.Region
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	signature := calculateSignatureWithSecret(req, dateStr, region, signedHeaders, secret)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s/%s/%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, accessKeyID, dateStr, region, serviceName, terminationStr, signedHeaders, signature))
+
+	verified, ok := VerifyRequestAndGetAccessKey(req)
+	return ok && verified == accessKeyID
+}
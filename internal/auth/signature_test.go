@@ -237,7 +237,46 @@ func TestGetCanonicalQueryString(t *testing.T) {
 			query: url.Values{
 				"key": []string{"hello world"},
 			},
-			expected: "key=hello+world",
+			expected: "key=hello%20world",
+		},
+		{
+			name: "加号与保留字符",
+			query: url.Values{
+				"key": []string{"a+b", "a*b~c/d"},
+			},
+			expected: "key=a%2Ab~c%2Fd&key=a%2Bb",
+		},
+		{
+			name: "重复键按编码后的值排序",
+			query: url.Values{
+				"tag": []string{"z", "é", "A", ""},
+			},
+			expected: "tag=&tag=%C3%A9&tag=A&tag=z",
+		},
+		{
+			name: "键按编码后排序",
+			query: url.Values{
+				"z":  []string{"1"},
+				"中": []string{"2"},
+				"a":  []string{"3"},
+			},
+			expected: "%E4%B8%AD=2&a=3&z=1",
+		},
+		{
+			name: "空值参数",
+			query: url.Values{
+				"uploads":  []string{""},
+				"uploadId": []string{"abc"},
+			},
+			expected: "uploadId=abc&uploads=",
+		},
+		{
+			name: "键含特殊字符",
+			query: url.Values{
+				"x-id":  []string{"PutObject"},
+				"my key": []string{"v"},
+			},
+			expected: "my%20key=v&x-id=PutObject",
 		},
 		{
 			name: "移除X-Amz-Signature",
@@ -764,8 +803,12 @@ func TestSignatureTampering(t *testing.T) {
 	signature := calculateSignatureWithSecret(req, dateStr, region, signedHeaders, config.Global.Auth.SecretAccessKey)
 
 	t.Run("篡改签名", func(t *testing.T) {
-		// 修改签名的一个字符
-		tamperedSig := "0" + signature[1:]
+		// 修改签名的一个字符（确保与原字符不同）
+		first := "0"
+		if signature[0] == '0' {
+			first = "1"
+		}
+		tamperedSig := first + signature[1:]
 		authHeader := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s/%s/s3/aws4_request, SignedHeaders=%s, Signature=%s",
 			config.Global.Auth.AccessKeyID, dateStr, region, signedHeaders, tamperedSig)
 
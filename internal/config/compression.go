@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 响应压缩算法（即 Content-Encoding 取值）
+const (
+	CompressionBrotli = "br"
+	CompressionGzip   = "gzip"
+	CompressionNone   = "none"
+)
+
+// 响应压缩默认配置：仅 gzip，最快级别
+const (
+	DefaultCompression = CompressionGzip
+	DefaultGzipLevel   = 1
+	DefaultBrotliLevel = 4
+)
+
+// CompressionAlgorithms 解析启用的响应压缩算法（按服务端优先级排列）并校验压缩级别
+// 空或 none 表示关闭压缩
+func (s ServerConfig) CompressionAlgorithms() ([]string, error) {
+	if s.GzipLevel < 1 || s.GzipLevel > 9 {
+		return nil, fmt.Errorf("gzip level must be between 1 and 9")
+	}
+	if s.BrotliLevel < 0 || s.BrotliLevel > 11 {
+		return nil, fmt.Errorf("brotli level must be between 0 and 11")
+	}
+	var algorithms []string
+	for _, a := range strings.Split(s.Compression, ",") {
+		a = strings.ToLower(strings.TrimSpace(a))
+		switch a {
+		case "", CompressionNone:
+			continue
+		case CompressionBrotli, CompressionGzip:
+		default:
+			return nil, fmt.Errorf("unsupported compression algorithm: %s", a)
+		}
+		duplicate := false
+		for _, existing := range algorithms {
+			duplicate = duplicate || existing == a
+		}
+		if !duplicate {
+			algorithms = append(algorithms, a)
+		}
+	}
+	return algorithms, nil
+}
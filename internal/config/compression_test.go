@@ -0,0 +1,41 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompressionAlgorithms(t *testing.T) {
+	tests := []struct {
+		compression string
+		want        []string
+	}{
+		{"gzip", []string{"gzip"}},
+		{"br, GZIP", []string{"br", "gzip"}},
+		{"gzip,br,gzip", []string{"gzip", "br"}},
+		{"none", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		cfg := ServerConfig{Compression: tt.compression, GzipLevel: DefaultGzipLevel, BrotliLevel: DefaultBrotliLevel}
+		got, err := cfg.CompressionAlgorithms()
+		if err != nil {
+			t.Errorf("%q: 不应返回错误: %v", tt.compression, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.compression, got, tt.want)
+		}
+	}
+
+	for name, cfg := range map[string]ServerConfig{
+		"不支持的算法":     {Compression: "zstd", GzipLevel: 1},
+		"gzip级别过高":   {Compression: "gzip", GzipLevel: 10},
+		"gzip级别为0":   {Compression: "gzip"},
+		"brotli级别过高": {Compression: "br", GzipLevel: 1, BrotliLevel: 12},
+	} {
+		if _, err := cfg.CompressionAlgorithms(); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config 运行时配置（不再从 YAML 加载，全部从命令行参数和数据库获取）
@@ -25,24 +27,174 @@ type GeoStatsConfig struct {
 
 // SecurityConfig 安全配置
 type SecurityConfig struct {
-	CORSOrigin     string // CORS 允许的来源，默认 "*"
-	PresignScheme  string // 预签名URL协议，"http" 或 "https"，默认 "http"
-	TrustedProxies string // 信任的代理 IP/CIDR，逗号分隔（如 Cloudflare IP 范围）
+	CORSOrigin           string // CORS 允许的来源，默认 "*"，多个来源用逗号分隔（白名单）
+	CORSAllowCredentials bool   // 是否允许携带凭证（与 "*" 互斥）
+	PresignScheme        string // 预签名URL协议，"http" 或 "https"，默认 "http"
+	TrustedProxies       string // 信任的代理 IP/CIDR，逗号分隔（如 Cloudflare IP 范围）
+	AuditOverwrite       bool   // 是否记录对象覆盖审计日志，默认关闭
+	AuditReadPercent     int    // 读操作（GET/HEAD/列举对象）审计采样百分比 0-100，0 表示关闭，默认关闭
+	PresignLimit         int    // 每个 Access Key 在一个时间窗口内可生成的预签名 URL 数上限，0 表示不限制
+	PresignWindowMinutes int    // 预签名计数时间窗口（分钟），默认 60
+
+	// 浏览器安全响应头（作用于管理界面/静态资源，可选作用于公有桶对象）
+	HeaderNoSniff         bool   // X-Content-Type-Options: nosniff，默认开启
+	ReferrerPolicy        string // Referrer-Policy，默认 "strict-origin-when-cross-origin"，空表示不发送
+	FrameOptions          string // X-Frame-Options，默认 "DENY"，空表示不发送
+	ContentSecurityPolicy string // Content-Security-Policy，默认不发送
+	HeadersOnPublic       bool   // 公有桶对象 GET/HEAD 是否也附加安全头，默认关闭
+
+	TraceHeader string // 客户端追踪 ID 请求头，默认 "X-Request-Id"，空表示不启用
+}
+
+// HeaderDisabled 安全头设置为该值时表示不发送
+const HeaderDisabled = "off"
+
+// headerSetting 将数据库中的安全头设置转换为运行时值
+func headerSetting(v string) string {
+	if v == HeaderDisabled {
+		return ""
+	}
+	return v
+}
+
+// CORSOrigins 解析 CORS 来源白名单
+func (s SecurityConfig) CORSOrigins() []string {
+	var origins []string
+	for _, o := range strings.Split(s.CORSOrigin, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
+
+// IsWildcardCORSOrigin 判断 CORS 来源是否为通配符
+func IsWildcardCORSOrigin(origin string) bool {
+	for _, o := range strings.Split(origin, ",") {
+		if strings.TrimSpace(o) == "*" {
+			return true
+		}
+	}
+	return strings.TrimSpace(origin) == ""
 }
 
 // ServerConfig 服务器配置（启动时通过命令行参数设置，运行时不可改）
 type ServerConfig struct {
-	Host   string // 监听地址，命令行参数
-	Port   int    // 监听端口，命令行参数
-	Region string // S3 区域，可在线修改
+	Host    string // 监听地址，命令行参数，IPv6 地址可带或不带方括号
+	Port    int    // 监听端口，命令行参数
+	Network string // 监听地址族 tcp（双栈）/tcp4/tcp6，命令行参数
+	Region  string // S3 区域，可在线修改
+
+	Maintenance bool // 维护模式，就绪探针返回 503 以便编排系统摘除流量，可在线修改
+
+	MetricsMaxBuckets int  // 按桶请求统计最多单独记录的桶数，超出归入 "_other"，0 表示不限制，可在线修改
+	PrometheusEnabled bool // 是否开放无需认证的 /metrics（Prometheus 文本格式），默认关闭，可在线修改
+
+	// 内置 TLS（同时指定证书和私钥时启用），命令行参数
+	TLSCert         string // 证书文件路径
+	TLSKey          string // 私钥文件路径
+	TLSMinVersion   string // 最低 TLS 版本 1.2/1.3，默认 1.2
+	TLSCipherSuites string // TLS 1.2 加密套件白名单，逗号分隔，空表示使用 Go 默认安全套件
+
+	// HTTP/2，命令行参数
+	HTTP2           bool // 是否启用 HTTP/2（TLS 连接通过 ALPN 协商），默认开启
+	H2C             bool // 是否在明文连接上接受 HTTP/2（h2c），默认关闭
+	HTTP2MaxStreams int  // 每个连接的最大并发流数量，0 表示使用默认值 250
+
+	// 响应压缩，命令行参数
+	Compression string // 启用的压缩算法 br/gzip，按优先级逗号分隔，none 表示关闭，默认 gzip
+	GzipLevel   int    // gzip 压缩级别 1-9，默认 1
+	BrotliLevel int    // brotli 压缩级别 0-11，默认 4
+
+	// 虚拟主机风格访问的域名，逗号分隔，如 s3.example.com；Host 为 <bucket>.<domain> 时按该桶处理，空表示关闭，命令行参数
+	VirtualHostDomains string
+
+	DrainTimeout time.Duration // 关闭时等待后台任务（复制队列、迁移等）退出的最长时间，命令行参数
+
+	// 连接保护，命令行参数
+	MaxConns          int           // 最大并发连接数，超出的连接上的请求返回 503，0 表示不限制
+	MaxConnsPerIP     int           // 单个客户端 IP（TCP 直连地址）的最大并发连接数，0 表示不限制
+	MinDownloadRate   int64         // 下载最低速度（字节/秒），客户端接收速度低于该值时中止下载，0 表示不检查
+	MinDownloadWindow time.Duration // 计算下载速度的时间窗口，默认 30 秒
 }
 
 // StorageConfig 存储配置
 type StorageConfig struct {
-	DataPath      string // 数据目录，命令行参数（运行时不可改）
-	DBPath        string // 数据库路径，命令行参数（运行时不可改）
-	MaxObjectSize int64  // 最大对象大小，可在线修改
-	MaxUploadSize int64  // 最大上传大小，可在线修改
+	DataPath          string // 数据目录，命令行参数（运行时不可改）
+	DBPath            string // 数据库路径，命令行参数（运行时不可改）
+	PathLayout        string // 对象文件路径布局 prefix/hashed，命令行参数（运行时不可改）
+	EncryptionKeyFile string // 对象静态加密口令文件，空表示不加密，命令行参数（运行时不可改）
+	MaxObjectSize     int64  // 最大对象大小，可在线修改
+	MaxUploadSize     int64  // 最大上传大小，可在线修改
+	MaxBuckets        int    // 最大桶数量，0 表示不限制，可在线修改
+	AutoCreate        bool   // PUT 对象时自动创建不存在的桶，默认关闭，可在线修改
+	LeadingSlash      string // 对象键前导斜杠处理 normalize/reject，默认 normalize，可在线修改
+	FolderMarkers     string // 以 / 结尾的零字节对象处理 object/placeholder，默认 object，可在线修改
+
+	MaxMetadataSize int // x-amz-meta-* 用户元数据总大小上限（字节），默认 2KB，0 表示不限制，可在线修改
+
+	AnonymousDailyBytes int64 // 公有桶匿名下载每日（UTC）流量上限（字节），超出后匿名请求返回 429，0 表示不限制，可在线修改
+	IdempotencyWindow   int   // PUT 幂等键保留时间（分钟），默认 1440，0 表示忽略幂等键，可在线修改
+
+	// 未完成多段上传的资源保护，与 S3 分片编号上限（10000）无关
+	MaxUploadParts       int // 单个未完成上传在磁盘上保留的分片数上限，超出返回 403，0 表示不限制，可在线修改
+	MaxIncompleteUploads int // 每个桶同时存在的未完成上传数上限，超出返回 429，0 表示不限制，可在线修改
+
+	MinPartSize int64 // 完成多段上传时除最后一个外每个分片的最小大小（字节），默认 5MiB，0 表示不检查，可在线修改
+
+	MinFreeSpace int64 // 数据盘最低可用空间（字节），写入后将低于该值的上传返回 507，就绪探针报告异常，0 表示不检查，可在线修改
+
+	ListTimeBudget int // 单次列举的扫描时间预算（毫秒），超出后返回部分结果和续传标记，0 表示不限制，可在线修改
+
+	DeleteGraceSeconds int // 管理界面删除对象的撤销窗口（秒），窗口内可凭令牌撤销，0 表示立即删除，可在线修改
+
+	LifecycleInterval int // 生命周期过期扫描间隔（分钟），默认 60，0 表示暂停扫描，可在线修改
+}
+
+// 多段上传分片最小大小，默认与 S3 一致
+const (
+	DefaultMinPartSize = 5 * 1024 * 1024
+	MaxMinPartSize     = 5 * 1024 * 1024 * 1024 // 与 S3 单个分片大小上限一致
+)
+
+// MaxDeleteGraceSeconds 删除撤销窗口上限（秒）
+const MaxDeleteGraceSeconds = 300
+
+// 生命周期过期扫描间隔（分钟）
+const (
+	DefaultLifecycleInterval = 60      // 默认 1 小时
+	MaxLifecycleInterval     = 24 * 60 // 上限 1 天
+)
+
+// PUT 幂等键保留时间（分钟）
+const (
+	DefaultIdempotencyWindow = 24 * 60      // 默认 1 天
+	MaxIdempotencyWindow     = 30 * 24 * 60 // 上限 30 天
+)
+
+// 对象键前导斜杠处理策略
+const (
+	LeadingSlashNormalize = "normalize" // 去除前导斜杠后存取
+	LeadingSlashReject    = "reject"    // 拒绝带前导斜杠的键
+)
+
+// 以 / 结尾的零字节对象（如 "folder/"）处理策略
+const (
+	FolderMarkersObject      = "object"      // 与普通对象相同，出现在列表 Contents 中
+	FolderMarkersPlaceholder = "placeholder" // 视为文件夹占位，列表中只以 CommonPrefixes 出现
+)
+
+// FolderPlaceholders 是否将以 / 结尾的零字节对象视为文件夹占位
+func (s StorageConfig) FolderPlaceholders() bool {
+	return s.FolderMarkers == FolderMarkersPlaceholder
+}
+
+// ListDeadline 返回从 start 开始的列举截止时间，未设置时间预算返回零值
+func (s StorageConfig) ListDeadline(start time.Time) time.Time {
+	if s.ListTimeBudget <= 0 {
+		return time.Time{}
+	}
+	return start.Add(time.Duration(s.ListTimeBudget) * time.Millisecond)
 }
 
 // AuthConfig 认证配置
@@ -61,19 +213,43 @@ type LogConfig struct {
 // Global 全局配置实例
 var Global *Config
 
-// NewDefault 创建默认配置
+// NewDefault 创建默认配置并设为全局配置
 func NewDefault() *Config {
-	cfg := &Config{
+	cfg := defaults()
+	Global = cfg
+	return cfg
+}
+
+// defaults 返回默认配置，不修改全局配置
+func defaults() *Config {
+	return &Config{
 		Server: ServerConfig{
-			Host:   "0.0.0.0",
-			Port:   8080,
-			Region: "us-east-1",
+			Host:          "0.0.0.0",
+			Port:          8080,
+			Network:       NetworkDualStack,
+			Region:        "us-east-1",
+			TLSMinVersion: "1.2",
+			HTTP2:         true,
+			Compression:   DefaultCompression,
+			GzipLevel:     DefaultGzipLevel,
+			BrotliLevel:   DefaultBrotliLevel,
+
+			MetricsMaxBuckets: 1000,
+			MinDownloadWindow: DefaultMinDownloadWindow,
 		},
 		Storage: StorageConfig{
 			DataPath:      "./data/buckets",
 			DBPath:        "./data/metadata.db",
+			PathLayout:    "prefix",
 			MaxObjectSize: 5 * 1024 * 1024 * 1024, // 5GB
 			MaxUploadSize: 1024 * 1024 * 1024,     // 1GB
+			LeadingSlash:  LeadingSlashNormalize,
+			FolderMarkers: FolderMarkersObject,
+
+			MaxMetadataSize:   2 * 1024,
+			IdempotencyWindow: DefaultIdempotencyWindow,
+			LifecycleInterval: DefaultLifecycleInterval,
+			MinPartSize:       DefaultMinPartSize,
 		},
 		Auth: AuthConfig{
 			AdminUsername: "admin",
@@ -82,20 +258,24 @@ func NewDefault() *Config {
 			CORSOrigin:     "*",    // 默认允许所有来源
 			PresignScheme:  "http", // 默认 HTTP
 			TrustedProxies: "",     // 默认不信任任何代理
+			HeaderNoSniff:  true,
+			ReferrerPolicy: "strict-origin-when-cross-origin",
+			FrameOptions:   "DENY",
+			TraceHeader:    "X-Request-Id",
+
+			PresignWindowMinutes: 60,
 		},
 		GeoStats: GeoStatsConfig{
-			Enabled:       false,     // 默认关闭
+			Enabled:       false,      // 默认关闭
 			Mode:          "realtime", // 默认实时模式
-			BatchSize:     100,       // 默认缓存大小
-			FlushInterval: 60,        // 默认刷新间隔 60 秒
-			RetentionDays: 90,        // 默认保留 90 天
+			BatchSize:     100,        // 默认缓存大小
+			FlushInterval: 60,         // 默认刷新间隔 60 秒
+			RetentionDays: 90,         // 默认保留 90 天
 		},
 		Log: LogConfig{
 			Level: "info",
 		},
 	}
-	Global = cfg
-	return cfg
 }
 
 // SettingsLoader 数据库配置加载接口
@@ -121,6 +301,17 @@ func LoadFromDB(loader SettingsLoader) {
 		if region, err := loader.GetSetting("server.region"); err == nil && region != "" {
 			Global.Server.Region = region
 		}
+		if maintenance, err := loader.GetSetting("server.maintenance"); err == nil {
+			Global.Server.Maintenance = maintenance == "true"
+		}
+		if maxBuckets, err := loader.GetSetting("server.metrics_max_buckets"); err == nil && maxBuckets != "" {
+			if n, err := strconv.Atoi(maxBuckets); err == nil && n >= 0 {
+				Global.Server.MetricsMaxBuckets = n
+			}
+		}
+		if prometheus, err := loader.GetSetting("server.prometheus_enabled"); err == nil {
+			Global.Server.PrometheusEnabled = prometheus == "true"
+		}
 
 		// 存储配置（只加载大小限制，DataPath 由命令行参数决定）
 		_, maxObjSize, maxUploadSize := loader.GetStorageConfig()
@@ -131,16 +322,123 @@ func LoadFromDB(loader SettingsLoader) {
 			Global.Storage.MaxUploadSize = maxUploadSize
 		}
 
+		if maxBuckets, err := loader.GetSetting("storage.max_buckets"); err == nil && maxBuckets != "" {
+			if n, err := strconv.Atoi(maxBuckets); err == nil && n >= 0 {
+				Global.Storage.MaxBuckets = n
+			}
+		}
+		if autoCreate, err := loader.GetSetting("storage.auto_create_bucket"); err == nil {
+			Global.Storage.AutoCreate = autoCreate == "true"
+		}
+		if leadingSlash, err := loader.GetSetting("storage.key_leading_slash"); err == nil && (leadingSlash == LeadingSlashNormalize || leadingSlash == LeadingSlashReject) {
+			Global.Storage.LeadingSlash = leadingSlash
+		}
+		if folderMarkers, err := loader.GetSetting("storage.folder_markers"); err == nil && (folderMarkers == FolderMarkersObject || folderMarkers == FolderMarkersPlaceholder) {
+			Global.Storage.FolderMarkers = folderMarkers
+		}
+		if maxMetadata, err := loader.GetSetting("storage.max_metadata_size"); err == nil && maxMetadata != "" {
+			if n, err := strconv.Atoi(maxMetadata); err == nil && n >= 0 {
+				Global.Storage.MaxMetadataSize = n
+			}
+		}
+		if window, err := loader.GetSetting("storage.idempotency_window_minutes"); err == nil && window != "" {
+			if n, err := strconv.Atoi(window); err == nil && n >= 0 {
+				Global.Storage.IdempotencyWindow = n
+			}
+		}
+		if anonymousDaily, err := loader.GetSetting("storage.anonymous_daily_bytes"); err == nil && anonymousDaily != "" {
+			if n, err := strconv.ParseInt(anonymousDaily, 10, 64); err == nil && n >= 0 {
+				Global.Storage.AnonymousDailyBytes = n
+			}
+		}
+		if maxParts, err := loader.GetSetting("storage.max_upload_parts"); err == nil && maxParts != "" {
+			if n, err := strconv.Atoi(maxParts); err == nil && n >= 0 {
+				Global.Storage.MaxUploadParts = n
+			}
+		}
+		if maxUploads, err := loader.GetSetting("storage.max_incomplete_uploads"); err == nil && maxUploads != "" {
+			if n, err := strconv.Atoi(maxUploads); err == nil && n >= 0 {
+				Global.Storage.MaxIncompleteUploads = n
+			}
+		}
+		if minPart, err := loader.GetSetting("storage.min_part_size"); err == nil && minPart != "" {
+			if n, err := strconv.ParseInt(minPart, 10, 64); err == nil && n >= 0 && n <= MaxMinPartSize {
+				Global.Storage.MinPartSize = n
+			}
+		}
+		if minFree, err := loader.GetSetting("storage.min_free_bytes"); err == nil && minFree != "" {
+			if n, err := strconv.ParseInt(minFree, 10, 64); err == nil && n >= 0 {
+				Global.Storage.MinFreeSpace = n
+			}
+		}
+		if budget, err := loader.GetSetting("storage.list_time_budget_ms"); err == nil && budget != "" {
+			if n, err := strconv.Atoi(budget); err == nil && n >= 0 {
+				Global.Storage.ListTimeBudget = n
+			}
+		}
+		if grace, err := loader.GetSetting("storage.delete_grace_seconds"); err == nil && grace != "" {
+			if n, err := strconv.Atoi(grace); err == nil && n >= 0 && n <= MaxDeleteGraceSeconds {
+				Global.Storage.DeleteGraceSeconds = n
+			}
+		}
+		if interval, err := loader.GetSetting("storage.lifecycle_interval_minutes"); err == nil && interval != "" {
+			if n, err := strconv.Atoi(interval); err == nil && n >= 0 && n <= MaxLifecycleInterval {
+				Global.Storage.LifecycleInterval = n
+			}
+		}
+
 		// 安全配置
 		if corsOrigin, err := loader.GetSetting("security.cors_origin"); err == nil && corsOrigin != "" {
 			Global.Security.CORSOrigin = corsOrigin
 		}
+		// 通配符来源不允许携带凭证
+		if credentials, err := loader.GetSetting("security.cors_allow_credentials"); err == nil && credentials == "true" {
+			Global.Security.CORSAllowCredentials = !IsWildcardCORSOrigin(Global.Security.CORSOrigin)
+		}
 		if presignScheme, err := loader.GetSetting("security.presign_scheme"); err == nil && presignScheme != "" {
 			Global.Security.PresignScheme = presignScheme
 		}
 		if trustedProxies, err := loader.GetSetting("security.trusted_proxies"); err == nil {
 			Global.Security.TrustedProxies = trustedProxies
 		}
+		if auditOverwrite, err := loader.GetSetting("security.audit_overwrite"); err == nil {
+			Global.Security.AuditOverwrite = auditOverwrite == "true"
+		}
+		if auditReads, err := loader.GetSetting("security.audit_read_percent"); err == nil && auditReads != "" {
+			if n, err := strconv.Atoi(auditReads); err == nil && n >= 0 && n <= 100 {
+				Global.Security.AuditReadPercent = n
+			}
+		}
+		if presignLimit, err := loader.GetSetting("security.presign_limit"); err == nil && presignLimit != "" {
+			if n, err := strconv.Atoi(presignLimit); err == nil && n >= 0 {
+				Global.Security.PresignLimit = n
+			}
+		}
+		if presignWindow, err := loader.GetSetting("security.presign_window_minutes"); err == nil && presignWindow != "" {
+			if n, err := strconv.Atoi(presignWindow); err == nil && n > 0 {
+				Global.Security.PresignWindowMinutes = n
+			}
+		}
+
+		// 安全响应头（未设置时保持默认值）
+		if noSniff, err := loader.GetSetting("security.header_nosniff"); err == nil && noSniff != "" {
+			Global.Security.HeaderNoSniff = noSniff == "true"
+		}
+		if referrer, err := loader.GetSetting("security.referrer_policy"); err == nil && referrer != "" {
+			Global.Security.ReferrerPolicy = headerSetting(referrer)
+		}
+		if frame, err := loader.GetSetting("security.frame_options"); err == nil && frame != "" {
+			Global.Security.FrameOptions = headerSetting(frame)
+		}
+		if csp, err := loader.GetSetting("security.csp"); err == nil && csp != "" {
+			Global.Security.ContentSecurityPolicy = headerSetting(csp)
+		}
+		if onPublic, err := loader.GetSetting("security.headers_on_public"); err == nil {
+			Global.Security.HeadersOnPublic = onPublic == "true"
+		}
+		if traceHeader, err := loader.GetSetting("security.trace_header"); err == nil && traceHeader != "" {
+			Global.Security.TraceHeader = headerSetting(traceHeader)
+		}
 
 		// 认证配置
 		Global.Auth.AdminUsername = loader.GetAdminUsername()
@@ -258,6 +258,89 @@ func TestLoadFromDB_PresignScheme(t *testing.T) {
 	}
 }
 
+// TestLoadFromDB_CORSCredentials 测试加载 CORS 凭证配置
+func TestLoadFromDB_CORSCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"白名单来源允许凭证", "https://a.example.com,https://b.example.com", true},
+		{"通配符来源禁止凭证", "*", false},
+		{"白名单包含通配符禁止凭证", "https://a.example.com,*", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Global = nil
+			loader := &mockSettingsLoader{
+				installed:     true,
+				adminUsername: "admin",
+				settings: map[string]string{
+					"security.cors_origin":            tt.origin,
+					"security.cors_allow_credentials": "true",
+				},
+			}
+
+			LoadFromDB(loader)
+
+			if Global.Security.CORSAllowCredentials != tt.want {
+				t.Errorf("Security.CORSAllowCredentials = %v, want %v", Global.Security.CORSAllowCredentials, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadFromDB_SecurityHeaders 测试加载安全响应头配置
+func TestLoadFromDB_SecurityHeaders(t *testing.T) {
+	Global = nil
+	LoadFromDB(&mockSettingsLoader{installed: true, adminUsername: "admin", settings: map[string]string{}})
+	if !Global.Security.HeaderNoSniff || Global.Security.FrameOptions != "DENY" || Global.Security.ReferrerPolicy == "" {
+		t.Errorf("未设置时应保持默认值: %+v", Global.Security)
+	}
+
+	Global = nil
+	LoadFromDB(&mockSettingsLoader{
+		installed:     true,
+		adminUsername: "admin",
+		settings: map[string]string{
+			"security.header_nosniff":    "false",
+			"security.frame_options":     "off",
+			"security.referrer_policy":   "no-referrer",
+			"security.csp":               "default-src 'self'",
+			"security.headers_on_public": "true",
+			"security.trace_header":      "X-Correlation-Id",
+		},
+	})
+	if Global.Security.HeaderNoSniff {
+		t.Error("HeaderNoSniff 应为 false")
+	}
+	if Global.Security.FrameOptions != "" {
+		t.Errorf("FrameOptions = %q, want empty", Global.Security.FrameOptions)
+	}
+	if Global.Security.ReferrerPolicy != "no-referrer" {
+		t.Errorf("ReferrerPolicy = %q", Global.Security.ReferrerPolicy)
+	}
+	if Global.Security.ContentSecurityPolicy != "default-src 'self'" {
+		t.Errorf("ContentSecurityPolicy = %q", Global.Security.ContentSecurityPolicy)
+	}
+	if !Global.Security.HeadersOnPublic {
+		t.Error("HeadersOnPublic 应为 true")
+	}
+	if Global.Security.TraceHeader != "X-Correlation-Id" {
+		t.Errorf("TraceHeader = %q", Global.Security.TraceHeader)
+	}
+}
+
+// TestCORSOrigins 测试 CORS 白名单解析
+func TestCORSOrigins(t *testing.T) {
+	s := SecurityConfig{CORSOrigin: " https://a.example.com , ,https://b.example.com"}
+	got := s.CORSOrigins()
+	if len(got) != 2 || got[0] != "https://a.example.com" || got[1] != "https://b.example.com" {
+		t.Errorf("CORSOrigins() = %v", got)
+	}
+}
+
 // TestUpdateFromSettings 测试从设置更新配置
 func TestUpdateFromSettings(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 配置值来源
+const (
+	SourceDefault = "default" // 默认值
+	SourceFlag    = "flag"    // 命令行参数
+	SourceDB      = "db"      // 数据库（安装时写入或管理界面修改）
+)
+
+// RedactedValue 敏感配置在输出中的替代值
+const RedactedValue = "******"
+
+// flagKeys 由命令行参数设置的配置项，其余非默认值均来自数据库
+var flagKeys = map[string]bool{
+	"server.host":                 true,
+	"server.port":                 true,
+	"server.network":              true,
+	"server.tls_cert":             true,
+	"server.tls_key":              true,
+	"server.tls_min_version":      true,
+	"server.tls_cipher_suites":    true,
+	"server.http2":                true,
+	"server.h2c":                  true,
+	"server.http2_max_streams":    true,
+	"server.compression":          true,
+	"server.gzip_level":           true,
+	"server.brotli_level":         true,
+	"server.virtual_host_domains": true,
+	"server.drain_timeout":        true,
+	"server.max_conns":            true,
+	"server.max_conns_per_ip":     true,
+	"server.min_download_rate":    true,
+	"server.min_download_window":  true,
+	"storage.data_path":           true,
+	"storage.db_path":             true,
+	"storage.path_layout":         true,
+	"storage.encryption_key_file": true,
+	"log.level":                   true,
+}
+
+// EffectiveSetting 一项生效中的配置
+type EffectiveSetting struct {
+	Key     string      `json:"key"` // 如 server.port、storage.max_buckets
+	Value   interface{} `json:"value"`
+	Default interface{} `json:"default"`
+	Source  string      `json:"source"` // default/flag/db
+}
+
+// Effective 按字段顺序列出全部生效配置及其来源，密钥和密码以 RedactedValue 代替
+// 来源按与默认值的差异推断：与默认值相同的项记为 default，即使它被显式设置过
+func (c *Config) Effective() []EffectiveSetting {
+	def := reflect.ValueOf(defaults()).Elem()
+	cur := reflect.ValueOf(c).Elem()
+
+	var settings []EffectiveSetting
+	for i := 0; i < cur.NumField(); i++ {
+		section := snakeCase(cur.Type().Field(i).Name)
+		curSection, defSection := cur.Field(i), def.Field(i)
+		for j := 0; j < curSection.NumField(); j++ {
+			key := section + "." + snakeCase(curSection.Type().Field(j).Name)
+			value, defValue := curSection.Field(j).Interface(), defSection.Field(j).Interface()
+
+			source := SourceDefault
+			if !reflect.DeepEqual(value, defValue) {
+				source = SourceDB
+				if flagKeys[key] {
+					source = SourceFlag
+				}
+			}
+			settings = append(settings, EffectiveSetting{
+				Key:     key,
+				Value:   displayValue(key, value),
+				Default: displayValue(key, defValue),
+				Source:  source,
+			})
+		}
+	}
+	return settings
+}
+
+// isSecretKey 密钥和密码类配置不对外输出
+func isSecretKey(key string) bool {
+	return strings.Contains(key, "secret") || strings.Contains(key, "password")
+}
+
+// displayValue 转换为便于阅读的输出值：时长以字符串表示，非空敏感值脱敏
+func displayValue(key string, v interface{}) interface{} {
+	switch val := v.(type) {
+	case time.Duration:
+		return val.String()
+	case string:
+		if val != "" && isSecretKey(key) {
+			return RedactedValue
+		}
+	}
+	return v
+}
+
+// snakeCase 把字段名转换为配置键，连续大写视为缩写，如 HTTP2MaxStreams -> http2_max_streams
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || ((unicode.IsUpper(prev) || unicode.IsDigit(prev)) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Port":            "port",
+		"HTTP2":           "http2",
+		"H2C":             "h2c",
+		"HTTP2MaxStreams": "http2_max_streams",
+		"TLSCert":         "tls_cert",
+		"DBPath":          "db_path",
+		"MaxConnsPerIP":   "max_conns_per_ip",
+		"CORSOrigin":      "cors_origin",
+		"GeoStats":        "geo_stats",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestEffective(t *testing.T) {
+	cfg := defaults()
+	cfg.Server.Port = 9000
+	cfg.Server.DrainTimeout = 5 * time.Second
+	cfg.Storage.MaxBuckets = 10
+	cfg.Storage.EncryptionKeyFile = "/etc/sss/encryption.key"
+	cfg.Auth.AccessKeyID = "AKID"
+	cfg.Auth.SecretAccessKey = "super-secret"
+
+	settings := make(map[string]EffectiveSetting)
+	for _, s := range cfg.Effective() {
+		settings[s.Key] = s
+	}
+
+	tests := []struct {
+		key    string
+		value  interface{}
+		source string
+	}{
+		{"server.port", 9000, SourceFlag},
+		{"server.drain_timeout", "5s", SourceFlag},
+		{"server.region", "us-east-1", SourceDefault},
+		{"storage.max_buckets", 10, SourceDB},
+		{"storage.encryption_key_file", "/etc/sss/encryption.key", SourceFlag},
+		{"auth.access_key_id", "AKID", SourceDB},
+		{"auth.secret_access_key", RedactedValue, SourceDB},
+	}
+	for _, tt := range tests {
+		s, ok := settings[tt.key]
+		if !ok {
+			t.Errorf("缺少配置项 %s", tt.key)
+			continue
+		}
+		if s.Value != tt.value || s.Source != tt.source {
+			t.Errorf("%s = %v (%s), want %v (%s)", tt.key, s.Value, s.Source, tt.value, tt.source)
+		}
+	}
+
+	// 未设置的密钥保持为空，便于判断是否已配置
+	if s := defaults().Effective(); len(s) != len(settings) {
+		t.Errorf("配置项数量不一致: %d != %d", len(s), len(settings))
+	}
+	for _, s := range defaults().Effective() {
+		if s.Key == "auth.secret_access_key" && s.Value != "" {
+			t.Errorf("未设置的密钥应为空: %v", s.Value)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DefaultHTTP2MaxStreams 每个 HTTP/2 连接默认允许的并发流数量
+const DefaultHTTP2MaxStreams = 250
+
+// ApplyHTTPProtocols 按配置设置 http.Server 支持的协议：HTTP/1.1 始终启用，
+// HTTP/2 用于 TLS 连接，h2c 用于明文连接（如反向代理之后）
+func (s ServerConfig) ApplyHTTPProtocols(srv *http.Server) error {
+	if s.H2C && !s.HTTP2 {
+		return fmt.Errorf("h2c requires HTTP/2 to be enabled")
+	}
+	if s.HTTP2MaxStreams < 0 {
+		return fmt.Errorf("HTTP/2 max concurrent streams must not be negative")
+	}
+
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(s.HTTP2)
+	protocols.SetUnencryptedHTTP2(s.H2C)
+	srv.Protocols = &protocols
+
+	if s.HTTP2 {
+		maxStreams := s.HTTP2MaxStreams
+		if maxStreams == 0 {
+			maxStreams = DefaultHTTP2MaxStreams
+		}
+		srv.HTTP2 = &http.HTTP2Config{MaxConcurrentStreams: maxStreams}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestApplyHTTPProtocols(t *testing.T) {
+	t.Run("默认启用HTTP/2不启用h2c", func(t *testing.T) {
+		srv := &http.Server{}
+		if err := (ServerConfig{HTTP2: true}).ApplyHTTPProtocols(srv); err != nil {
+			t.Fatalf("配置失败: %v", err)
+		}
+		if !srv.Protocols.HTTP1() || !srv.Protocols.HTTP2() || srv.Protocols.UnencryptedHTTP2() {
+			t.Errorf("协议错误: %v", srv.Protocols)
+		}
+		if srv.HTTP2 == nil || srv.HTTP2.MaxConcurrentStreams != DefaultHTTP2MaxStreams {
+			t.Errorf("并发流数量应为默认值: %+v", srv.HTTP2)
+		}
+	})
+
+	t.Run("禁用HTTP/2", func(t *testing.T) {
+		srv := &http.Server{}
+		if err := (ServerConfig{}).ApplyHTTPProtocols(srv); err != nil {
+			t.Fatalf("配置失败: %v", err)
+		}
+		if !srv.Protocols.HTTP1() || srv.Protocols.HTTP2() || srv.HTTP2 != nil {
+			t.Errorf("不应启用 HTTP/2: %v", srv.Protocols)
+		}
+	})
+
+	t.Run("无效配置", func(t *testing.T) {
+		for name, cfg := range map[string]ServerConfig{
+			"h2c需要HTTP/2": {H2C: true},
+			"负数并发流":       {HTTP2: true, HTTP2MaxStreams: -1},
+		} {
+			if err := cfg.ApplyHTTPProtocols(&http.Server{}); err == nil {
+				t.Errorf("%s: 应返回错误", name)
+			}
+		}
+	})
+
+	t.Run("h2c明文连接使用HTTP/2", func(t *testing.T) {
+		srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Proto))
+		})}
+		if err := (ServerConfig{HTTP2: true, H2C: true, HTTP2MaxStreams: 10}).ApplyHTTPProtocols(srv); err != nil {
+			t.Fatalf("配置失败: %v", err)
+		}
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("监听失败: %v", err)
+		}
+		go srv.Serve(ln)
+		defer srv.Close()
+
+		var protocols http.Protocols
+		protocols.SetUnencryptedHTTP2(true)
+		client := &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+		resp, err := client.Get("http://" + ln.Addr().String())
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != 2 {
+			t.Errorf("应使用 HTTP/2: %s", resp.Proto)
+		}
+	})
+}
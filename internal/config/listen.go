@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 监听地址族
+const (
+	NetworkDualStack = "tcp"  // 通配地址同时接受 IPv4 和 IPv6（IPv4 以 ::ffff:a.b.c.d 形式到达）
+	NetworkIPv4      = "tcp4" // 仅 IPv4
+	NetworkIPv6      = "tcp6" // 仅 IPv6
+)
+
+// DefaultMinDownloadWindow 计算下载最低速度的默认时间窗口
+const DefaultMinDownloadWindow = 30 * time.Second
+
+// ListenAddr 返回监听地址，IPv6 地址自动加方括号，如 [::]:8080
+func (s ServerConfig) ListenAddr() string {
+	host := strings.TrimSuffix(strings.TrimPrefix(s.Host, "["), "]")
+	return net.JoinHostPort(host, strconv.Itoa(s.Port))
+}
+
+// Listen 按配置的地址族创建监听器，Network 为空时使用双栈
+func (s ServerConfig) Listen() (net.Listener, error) {
+	network := s.Network
+	if network == "" {
+		network = NetworkDualStack
+	}
+	switch network {
+	case NetworkDualStack, NetworkIPv4, NetworkIPv6:
+	default:
+		return nil, fmt.Errorf("unsupported listen network %q, use tcp, tcp4 or tcp6", s.Network)
+	}
+	return net.Listen(network, s.ListenAddr())
+}
+
+// ValidateConnLimits 检查连接数上限和下载最低速度配置
+func (s ServerConfig) ValidateConnLimits() error {
+	if s.MaxConns < 0 || s.MaxConnsPerIP < 0 {
+		return fmt.Errorf("connection limits must not be negative")
+	}
+	if s.MinDownloadRate < 0 {
+		return fmt.Errorf("minimum download rate must not be negative")
+	}
+	if s.MinDownloadRate > 0 && s.MinDownloadWindow <= 0 {
+		return fmt.Errorf("minimum download window must be positive when a minimum download rate is set")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"0.0.0.0", "0.0.0.0:8080"},
+		{"", ":8080"},
+		{"::", "[::]:8080"},
+		{"[::1]", "[::1]:8080"},
+		{"2001:db8::1", "[2001:db8::1]:8080"},
+	}
+	for _, tt := range tests {
+		if got := (ServerConfig{Host: tt.host, Port: 8080}).ListenAddr(); got != tt.want {
+			t.Errorf("ListenAddr(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestListen(t *testing.T) {
+	t.Run("双栈通配地址", func(t *testing.T) {
+		ln, err := (ServerConfig{Host: "0.0.0.0", Network: NetworkDualStack}).Listen()
+		if err != nil {
+			t.Fatalf("监听失败: %v", err)
+		}
+		defer ln.Close()
+		port := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+
+		conn, err := net.Dial("tcp4", net.JoinHostPort("127.0.0.1", port))
+		if err != nil {
+			t.Fatalf("IPv4 连接失败: %v", err)
+		}
+		conn.Close()
+
+		v6, err := net.Listen("tcp6", "[::1]:0")
+		if err != nil {
+			t.Skip("环境不支持 IPv6")
+		}
+		v6.Close()
+		conn, err = net.Dial("tcp6", net.JoinHostPort("::1", port))
+		if err != nil {
+			t.Fatalf("IPv6 连接失败: %v", err)
+		}
+		conn.Close()
+	})
+
+	t.Run("仅IPv4", func(t *testing.T) {
+		ln, err := (ServerConfig{Host: "127.0.0.1", Network: NetworkIPv4}).Listen()
+		if err != nil {
+			t.Fatalf("监听失败: %v", err)
+		}
+		ln.Close()
+	})
+
+	t.Run("无效地址族", func(t *testing.T) {
+		if _, err := (ServerConfig{Host: "127.0.0.1", Network: "udp"}).Listen(); err == nil {
+			t.Error("应返回错误")
+		}
+	})
+}
+
+func TestValidateConnLimits(t *testing.T) {
+	valid := []ServerConfig{
+		{},
+		{MaxConns: 1000, MaxConnsPerIP: 20},
+		{MinDownloadRate: 1024, MinDownloadWindow: DefaultMinDownloadWindow},
+	}
+	for _, cfg := range valid {
+		if err := cfg.ValidateConnLimits(); err != nil {
+			t.Errorf("%+v: 不应返回错误: %v", cfg, err)
+		}
+	}
+
+	for name, cfg := range map[string]ServerConfig{
+		"负数连接上限":  {MaxConns: -1},
+		"负数单IP上限": {MaxConnsPerIP: -1},
+		"负数下载速度":  {MinDownloadRate: -1},
+		"缺少时间窗口":  {MinDownloadRate: 1024},
+	} {
+		if err := cfg.ValidateConnLimits(); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
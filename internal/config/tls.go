@@ -0,0 +1,91 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// tlsVersions 允许配置的最低 TLS 版本（1.0/1.1 视为弱配置）
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSEnabled 是否启用内置 TLS
+func (s ServerConfig) TLSEnabled() bool {
+	return s.TLSCert != "" || s.TLSKey != ""
+}
+
+// BuildTLSConfig 根据配置生成 tls.Config，弱配置直接返回错误
+func (s ServerConfig) BuildTLSConfig() (*tls.Config, error) {
+	if s.TLSCert == "" || s.TLSKey == "" {
+		return nil, fmt.Errorf("both TLS certificate and key must be specified")
+	}
+
+	minVersion := s.TLSMinVersion
+	if minVersion == "" {
+		minVersion = "1.2"
+	}
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TLS minimum version %q: must be 1.2 or 1.3", minVersion)
+	}
+
+	suites, err := parseCipherSuites(s.TLSCipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	if len(suites) > 0 && version == tls.VersionTLS13 {
+		return nil, fmt.Errorf("TLS cipher suites cannot be configured when minimum version is 1.3")
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.TLSCert, s.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS certificate failed: %w", err)
+	}
+
+	return &tls.Config{
+		MinVersion:   version,
+		CipherSuites: suites,
+		Certificates: []tls.Certificate{cert},
+	}, nil
+}
+
+// parseCipherSuites 解析加密套件白名单，拒绝不安全或不适用于 TLS 1.2 的套件
+func parseCipherSuites(list string) ([]uint16, error) {
+	var ids []uint16
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, err := lookupCipherSuite(name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// lookupCipherSuite 按名称查找加密套件
+func lookupCipherSuite(name string) (uint16, error) {
+	for _, cs := range tls.InsecureCipherSuites() {
+		if cs.Name == name {
+			return 0, fmt.Errorf("TLS cipher suite %s is insecure", name)
+		}
+	}
+	for _, cs := range tls.CipherSuites() {
+		if cs.Name != name {
+			continue
+		}
+		for _, v := range cs.SupportedVersions {
+			if v == tls.VersionTLS12 {
+				return cs.ID, nil
+			}
+		}
+		return 0, fmt.Errorf("TLS cipher suite %s is TLS 1.3 only and cannot be configured", name)
+	}
+	return 0, fmt.Errorf("unknown TLS cipher suite %q", name)
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCert 生成自签名证书和私钥文件
+func writeTestCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("生成证书失败: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+// TestBuildTLSConfig 测试 TLS 配置生成
+func TestBuildTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	t.Run("默认最低TLS1.2", func(t *testing.T) {
+		s := NewDefault().Server
+		if s.TLSEnabled() {
+			t.Error("未配置证书时不应启用 TLS")
+		}
+		s.TLSCert, s.TLSKey = certFile, keyFile
+		cfg, err := s.BuildTLSConfig()
+		if err != nil {
+			t.Fatalf("生成配置失败: %v", err)
+		}
+		if cfg.MinVersion != tls.VersionTLS12 || cfg.CipherSuites != nil || len(cfg.Certificates) != 1 {
+			t.Errorf("配置错误: min=%x suites=%v", cfg.MinVersion, cfg.CipherSuites)
+		}
+	})
+
+	t.Run("加密套件白名单", func(t *testing.T) {
+		s := ServerConfig{
+			TLSCert: certFile, TLSKey: keyFile, TLSMinVersion: "1.2",
+			TLSCipherSuites: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256",
+		}
+		cfg, err := s.BuildTLSConfig()
+		if err != nil {
+			t.Fatalf("生成配置失败: %v", err)
+		}
+		want := []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256}
+		if len(cfg.CipherSuites) != 2 || cfg.CipherSuites[0] != want[0] || cfg.CipherSuites[1] != want[1] {
+			t.Errorf("套件错误: %v", cfg.CipherSuites)
+		}
+	})
+
+	t.Run("TLS1.3", func(t *testing.T) {
+		s := ServerConfig{TLSCert: certFile, TLSKey: keyFile, TLSMinVersion: "1.3"}
+		cfg, err := s.BuildTLSConfig()
+		if err != nil || cfg.MinVersion != tls.VersionTLS13 {
+			t.Errorf("TLS1.3 配置错误: %v", err)
+		}
+	})
+
+	t.Run("拒绝弱配置", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			config ServerConfig
+			errMsg string
+		}{
+			{"TLS1.0", ServerConfig{TLSCert: certFile, TLSKey: keyFile, TLSMinVersion: "1.0"}, "unsupported TLS minimum version"},
+			{"TLS1.1", ServerConfig{TLSCert: certFile, TLSKey: keyFile, TLSMinVersion: "1.1"}, "unsupported TLS minimum version"},
+			{"不安全套件", ServerConfig{TLSCert: certFile, TLSKey: keyFile, TLSCipherSuites: "TLS_RSA_WITH_RC4_128_SHA"}, "insecure"},
+			{"未知套件", ServerConfig{TLSCert: certFile, TLSKey: keyFile, TLSCipherSuites: "TLS_FAKE"}, "unknown"},
+			{"TLS1.3专用套件", ServerConfig{TLSCert: certFile, TLSKey: keyFile, TLSCipherSuites: "TLS_AES_128_GCM_SHA256"}, "TLS 1.3 only"},
+			{"TLS1.3配置套件", ServerConfig{TLSCert: certFile, TLSKey: keyFile, TLSMinVersion: "1.3", TLSCipherSuites: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}, "cannot be configured"},
+			{"缺少私钥", ServerConfig{TLSCert: certFile}, "must be specified"},
+			{"证书不存在", ServerConfig{TLSCert: certFile + ".missing", TLSKey: keyFile}, "load TLS certificate"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := tc.config.BuildTLSConfig()
+				if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+					t.Errorf("期望错误包含 %q，实际: %v", tc.errMsg, err)
+				}
+			})
+		}
+	})
+}
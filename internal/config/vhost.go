@@ -0,0 +1,28 @@
+package config
+
+import (
+	"net"
+	"strings"
+)
+
+// VirtualHostBucket 按虚拟主机风格（<bucket>.<domain>）从 Host 头解析桶名
+// 未配置 VirtualHostDomains、Host 不匹配或恰好等于域名本身时返回 false，按路径风格处理
+func (s ServerConfig) VirtualHostBucket(host string) (string, bool) {
+	if s.VirtualHostDomains == "" || host == "" {
+		return "", false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range strings.Split(s.VirtualHostDomains, ",") {
+		domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+		if domain == "" {
+			continue
+		}
+		if bucket, ok := strings.CutSuffix(host, "."+domain); ok && bucket != "" {
+			return bucket, true
+		}
+	}
+	return "", false
+}
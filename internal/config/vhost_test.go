@@ -0,0 +1,35 @@
+package config
+
+import "testing"
+
+// TestVirtualHostBucket 测试按 Host 头解析虚拟主机风格的桶名
+func TestVirtualHostBucket(t *testing.T) {
+	s := ServerConfig{VirtualHostDomains: "s3.example.com, .cdn.example.org"}
+	tests := []struct {
+		host   string
+		bucket string
+		ok     bool
+	}{
+		{"photos.s3.example.com", "photos", true},
+		{"photos.s3.example.com:8080", "photos", true},
+		{"Photos.S3.Example.com.", "photos", true},
+		{"my.logs.s3.example.com", "my.logs", true}, // 桶名可以包含点
+		{"media.cdn.example.org", "media", true},
+		{"s3.example.com", "", false},
+		{"s3.example.com:8080", "", false},
+		{"photos.other.com", "", false},
+		{"evil-s3.example.com", "", false},
+		{"127.0.0.1:8080", "", false},
+		{"[::1]:8080", "", false},
+	}
+	for _, tt := range tests {
+		bucket, ok := s.VirtualHostBucket(tt.host)
+		if bucket != tt.bucket || ok != tt.ok {
+			t.Errorf("VirtualHostBucket(%q) = %q, %v, want %q, %v", tt.host, bucket, ok, tt.bucket, tt.ok)
+		}
+	}
+
+	if _, ok := (ServerConfig{}).VirtualHostBucket("photos.s3.example.com"); ok {
+		t.Error("未配置域名时不应启用虚拟主机风格")
+	}
+}
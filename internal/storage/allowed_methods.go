@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BucketMethods 桶可限制的 S3 API 方法，按此顺序存储和展示
+var BucketMethods = []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPost, http.MethodDelete}
+
+// ParseAllowedMethods 解析逗号分隔的方法列表，转为大写并去重，按 BucketMethods 顺序返回
+// 允许 GET 时自动包含 HEAD，空列表表示不限制
+func ParseAllowedMethods(s string) ([]string, error) {
+	set := make(map[string]bool)
+	for _, m := range strings.Split(s, ",") {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m == "" {
+			continue
+		}
+		if !isBucketMethod(m) {
+			return nil, fmt.Errorf("unsupported method %q", m)
+		}
+		set[m] = true
+	}
+	if set[http.MethodGet] {
+		set[http.MethodHead] = true
+	}
+	var methods []string
+	for _, m := range BucketMethods {
+		if set[m] {
+			methods = append(methods, m)
+		}
+	}
+	return methods, nil
+}
+
+func isBucketMethod(method string) bool {
+	for _, m := range BucketMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsMethod 判断桶是否允许该 HTTP 方法，未配置时全部允许，OPTIONS 预检始终允许
+func (b *Bucket) AllowsMethod(method string) bool {
+	if b == nil || b.AllowedMethods == "" || method == http.MethodOptions {
+		return true
+	}
+	for _, m := range strings.Split(b.AllowedMethods, ",") {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
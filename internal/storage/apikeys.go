@@ -5,6 +5,8 @@ import (
 	"crypto/subtle"
 	"database/sql"
 	"encoding/hex"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -16,6 +18,46 @@ type APIKey struct {
 	Description     string    `json:"description"`
 	CreatedAt       time.Time `json:"created_at"`
 	Enabled         bool      `json:"enabled"`
+	Namespace       string    `json:"namespace"` // 桶命名空间，非空时该 Key 只能访问以 "命名空间-" 开头的桶
+}
+
+// MaxNamespaceLength API密钥命名空间最大长度，为桶名本身保留足够空间
+const MaxNamespaceLength = 32
+
+// IsValidNamespace 检查命名空间是否可作为桶名前缀：小写字母、数字和连字符，以字母或数字开头
+func IsValidNamespace(ns string) bool {
+	if ns == "" || len(ns) > MaxNamespaceLength {
+		return false
+	}
+	for i := 0; i < len(ns); i++ {
+		c := ns[i]
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+		if !isAlnum && (c != '-' || i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// NamespaceSeparator 命名空间与桶名之间的固定分隔符
+const NamespaceSeparator = "-"
+
+// NamespacePrefix 返回命名空间实际使用的桶名前缀，总以分隔符结尾
+// 已以分隔符结尾的命名空间（如 tenant-a-）保持不变，避免 acme 匹配到 acme2 的桶
+func NamespacePrefix(ns string) string {
+	if ns == "" {
+		return ""
+	}
+	return strings.TrimSuffix(ns, NamespaceSeparator) + NamespaceSeparator
+}
+
+// NamespacesOverlap 判断两个不同的命名空间是否一个是另一个的前缀，此时前者的 Key 能访问后者的桶
+func NamespacesOverlap(a, b string) bool {
+	pa, pb := NamespacePrefix(a), NamespacePrefix(b)
+	if pa == "" || pb == "" || pa == pb {
+		return false
+	}
+	return strings.HasPrefix(pa, pb) || strings.HasPrefix(pb, pa)
 }
 
 // APIKeyPermission API密钥权限
@@ -36,21 +78,24 @@ type APIKeyWithPermissions struct {
 type CachedAPIKey struct {
 	SecretAccessKey string
 	Enabled         bool
+	Namespace       string
 	Permissions     map[string]*APIKeyPermission // bucket_name -> permission
 }
 
 // APIKeyCache API密钥缓存
 type APIKeyCache struct {
-	mu    sync.RWMutex
-	keys  map[string]*CachedAPIKey // access_key_id -> cached key
-	store *MetadataStore
+	mu       sync.RWMutex
+	keys     map[string]*CachedAPIKey        // access_key_id -> cached key
+	sessions map[string]*TemporaryCredential // 临时凭证，不随 Reload 清空
+	store    *MetadataStore
 }
 
 // NewAPIKeyCache 创建API密钥缓存
 func NewAPIKeyCache(store *MetadataStore) *APIKeyCache {
 	cache := &APIKeyCache{
-		keys:  make(map[string]*CachedAPIKey),
-		store: store,
+		keys:     make(map[string]*CachedAPIKey),
+		sessions: make(map[string]*TemporaryCredential),
+		store:    store,
 	}
 	// 初始化时加载所有API密钥
 	cache.Reload()
@@ -73,6 +118,7 @@ func (c *APIKeyCache) Reload() error {
 		cached := &CachedAPIKey{
 			SecretAccessKey: key.SecretAccessKey,
 			Enabled:         key.Enabled,
+			Namespace:       key.Namespace,
 			Permissions:     make(map[string]*APIKeyPermission),
 		}
 		for i := range key.Permissions {
@@ -110,13 +156,17 @@ func (c *APIKeyCache) GetSecretKey(accessKeyID string) (string, bool) {
 	return cached.SecretAccessKey, true
 }
 
-// CheckPermission 检查API密钥的桶权限
+// CheckPermission 检查API密钥的桶权限，临时凭证按签发时的范围检查
 func (c *APIKeyCache) CheckPermission(accessKeyID, bucketName string, needWrite bool) bool {
 	c.mu.RLock()
 	cached, exists := c.keys[accessKeyID]
 	c.mu.RUnlock()
 
-	if !exists || !cached.Enabled {
+	if !exists {
+		cred := c.activeSession(accessKeyID, time.Now())
+		return cred != nil && cred.allows(bucketName, needWrite)
+	}
+	if !cached.Enabled {
 		return false
 	}
 
@@ -140,6 +190,18 @@ func (c *APIKeyCache) CheckPermission(accessKeyID, bucketName string, needWrite
 	return perm.CanRead
 }
 
+// Namespace 获取API密钥的桶名前缀（命名空间加分隔符），密钥不存在或已禁用时返回空
+func (c *APIKeyCache) Namespace(accessKeyID string) string {
+	c.mu.RLock()
+	cached, exists := c.keys[accessKeyID]
+	c.mu.RUnlock()
+
+	if !exists || !cached.Enabled {
+		return ""
+	}
+	return NamespacePrefix(cached.Namespace)
+}
+
 // === MetadataStore API Key 操作 ===
 
 // CreateAPIKey 创建API密钥（SecretKey 加密存储）
@@ -179,9 +241,9 @@ func (m *MetadataStore) CreateAPIKey(description string) (*APIKey, error) {
 func (m *MetadataStore) GetAPIKey(accessKeyID string) (*APIKey, error) {
 	var key APIKey
 	err := m.db.QueryRow(`
-		SELECT access_key_id, description, created_at, enabled
+		SELECT access_key_id, description, created_at, enabled, namespace
 		FROM api_keys WHERE access_key_id = ?`, accessKeyID,
-	).Scan(&key.AccessKeyID, &key.Description, &key.CreatedAt, &key.Enabled)
+	).Scan(&key.AccessKeyID, &key.Description, &key.CreatedAt, &key.Enabled, &key.Namespace)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -191,7 +253,7 @@ func (m *MetadataStore) GetAPIKey(accessKeyID string) (*APIKey, error) {
 // ListAPIKeys 列出所有API密钥（不返回SecretKey）
 func (m *MetadataStore) ListAPIKeys() ([]APIKey, error) {
 	rows, err := m.db.Query(`
-		SELECT access_key_id, description, created_at, enabled
+		SELECT access_key_id, description, created_at, enabled, namespace
 		FROM api_keys ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
@@ -201,7 +263,7 @@ func (m *MetadataStore) ListAPIKeys() ([]APIKey, error) {
 	var keys []APIKey
 	for rows.Next() {
 		var key APIKey
-		if err := rows.Scan(&key.AccessKeyID, &key.Description, &key.CreatedAt, &key.Enabled); err != nil {
+		if err := rows.Scan(&key.AccessKeyID, &key.Description, &key.CreatedAt, &key.Enabled, &key.Namespace); err != nil {
 			return nil, err
 		}
 		keys = append(keys, key)
@@ -209,6 +271,78 @@ func (m *MetadataStore) ListAPIKeys() ([]APIKey, error) {
 	return keys, nil
 }
 
+// APIKeyQuery API密钥查询参数
+type APIKeyQuery struct {
+	Description string // 描述包含（可选）
+	Enabled     *bool  // 是否启用（可选）
+	Sort        string // 排序字段：created（默认）
+	Ascending   bool   // 是否升序，默认降序
+	Limit       int    // 返回数量限制
+	Offset      int    // 偏移量
+}
+
+// apiKeySortColumns 允许排序的字段
+var apiKeySortColumns = map[string]string{
+	"":        "created_at",
+	"created": "created_at",
+}
+
+// QueryAPIKeys 分页查询API密钥（不包含SecretKey），返回当前页和总数
+func (m *MetadataStore) QueryAPIKeys(query *APIKeyQuery) ([]APIKey, int, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if query.Description != "" {
+		conditions = append(conditions, "description LIKE ?")
+		args = append(args, "%"+query.Description+"%")
+	}
+	if query.Enabled != nil {
+		conditions = append(conditions, "enabled = ?")
+		args = append(args, *query.Enabled)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM api_keys "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := apiKeySortColumns[query.Sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid sort field: %s", query.Sort)
+	}
+	order := "DESC"
+	if query.Ascending {
+		order = "ASC"
+	}
+	if query.Limit <= 0 {
+		query.Limit = 50
+	}
+
+	rows, err := m.db.Query(`
+		SELECT access_key_id, description, created_at, enabled, namespace
+		FROM api_keys `+whereClause+` ORDER BY `+column+` `+order+`, access_key_id LIMIT ? OFFSET ?`,
+		append(args, query.Limit, query.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var key APIKey
+		if err := rows.Scan(&key.AccessKeyID, &key.Description, &key.CreatedAt, &key.Enabled, &key.Namespace); err != nil {
+			return nil, 0, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, total, rows.Err()
+}
+
 // ListAPIKeysWithPermissions 列出所有API密钥及其权限（内部使用，包含SecretKey，自动解密）
 func (m *MetadataStore) ListAPIKeysWithPermissions() ([]APIKeyWithPermissions, error) {
 	// 使用事务确保读取一致性
@@ -219,7 +353,7 @@ func (m *MetadataStore) ListAPIKeysWithPermissions() ([]APIKeyWithPermissions, e
 	defer tx.Rollback()
 
 	rows, err := tx.Query(`
-		SELECT access_key_id, secret_access_key, description, created_at, enabled
+		SELECT access_key_id, secret_access_key, description, created_at, enabled, namespace
 		FROM api_keys ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
@@ -229,7 +363,7 @@ func (m *MetadataStore) ListAPIKeysWithPermissions() ([]APIKeyWithPermissions, e
 	for rows.Next() {
 		var key APIKeyWithPermissions
 		var encryptedSecret string
-		if err := rows.Scan(&key.AccessKeyID, &encryptedSecret, &key.Description, &key.CreatedAt, &key.Enabled); err != nil {
+		if err := rows.Scan(&key.AccessKeyID, &encryptedSecret, &key.Description, &key.CreatedAt, &key.Enabled, &key.Namespace); err != nil {
 			rows.Close()
 			return nil, err
 		}
@@ -290,6 +424,35 @@ func (m *MetadataStore) UpdateAPIKeyDescription(accessKeyID, description string)
 	})
 }
 
+// UpdateAPIKeyNamespace 更新API密钥的桶命名空间，空字符串表示不限制
+func (m *MetadataStore) UpdateAPIKeyNamespace(accessKeyID, namespace string) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE api_keys SET namespace = ? WHERE access_key_id = ?", namespace, accessKeyID)
+		return err
+	})
+}
+
+// FindOverlappingNamespace 查找与 namespace 互为前缀的其他 API 密钥命名空间，排除 accessKeyID 自身
+// 返回冲突的命名空间，没有冲突时返回空
+func (m *MetadataStore) FindOverlappingNamespace(namespace, accessKeyID string) (string, error) {
+	rows, err := m.db.Query("SELECT DISTINCT namespace FROM api_keys WHERE namespace != '' AND access_key_id != ?", accessKeyID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var existing string
+		if err := rows.Scan(&existing); err != nil {
+			return "", err
+		}
+		if NamespacesOverlap(namespace, existing) {
+			return existing, nil
+		}
+	}
+	return "", rows.Err()
+}
+
 // ResetAPIKeySecret 重置API密钥的SecretKey（加密存储）
 func (m *MetadataStore) ResetAPIKeySecret(accessKeyID string) (string, error) {
 	newSecret := generateRandomKey(40)
@@ -365,6 +528,57 @@ func (m *MetadataStore) GetAPIKeyPermissions(accessKeyID string) ([]APIKeyPermis
 	return perms, nil
 }
 
+// ListBucketPermissions 列出授予指定桶（不含通配符 *）的 API 密钥权限
+func (m *MetadataStore) ListBucketPermissions(bucketName string) ([]APIKeyPermission, error) {
+	rows, err := m.db.Query(`
+		SELECT access_key_id, bucket_name, can_read, can_write
+		FROM api_key_permissions WHERE bucket_name = ? ORDER BY access_key_id`, bucketName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []APIKeyPermission
+	for rows.Next() {
+		var perm APIKeyPermission
+		if err := rows.Scan(&perm.AccessKeyID, &perm.BucketName, &perm.CanRead, &perm.CanWrite); err != nil {
+			return nil, err
+		}
+		perms = append(perms, perm)
+	}
+	return perms, rows.Err()
+}
+
+// ReplaceBucketACL 在同一事务中设置桶的公开状态并替换授予该桶的 API 密钥权限
+// 通配符（*）权限不受影响
+func (m *MetadataStore) ReplaceBucketACL(bucketName string, isPublic bool, perms []APIKeyPermission) error {
+	return m.withWriteLock(func() error {
+		tx, err := m.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec("UPDATE buckets SET is_public = ? WHERE name = ?", isPublic, bucketName); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM api_key_permissions WHERE bucket_name = ?", bucketName); err != nil {
+			return err
+		}
+		for _, perm := range perms {
+			if _, err := tx.Exec(`
+				INSERT INTO api_key_permissions (access_key_id, bucket_name, can_read, can_write)
+				VALUES (?, ?, ?, ?)`,
+				perm.AccessKeyID, bucketName, perm.CanRead, perm.CanWrite,
+			); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+}
+
 // generateRandomKey 生成随机密钥
 func generateRandomKey(length int) string {
 	bytes := make([]byte, length/2)
@@ -150,6 +150,59 @@ func TestListAPIKeys(t *testing.T) {
 	}
 }
 
+// TestQueryAPIKeys 测试分页查询API密钥
+func TestQueryAPIKeys(t *testing.T) {
+	ms, cleanup := setupAPIKeysTest(t)
+	defer cleanup()
+
+	var ids []string
+	for _, desc := range []string{"ci deploy", "backup job", "ci test", "legacy"} {
+		key, err := ms.CreateAPIKey(desc)
+		if err != nil {
+			t.Fatalf("创建密钥失败: %v", err)
+		}
+		ids = append(ids, key.AccessKeyID)
+		time.Sleep(time.Millisecond)
+	}
+	ms.UpdateAPIKeyEnabled(ids[2], false)
+
+	// 分页
+	keys, total, err := ms.QueryAPIKeys(&APIKeyQuery{Limit: 3})
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if total != 4 || len(keys) != 3 || keys[0].AccessKeyID != ids[3] {
+		t.Errorf("第一页错误: total=%d, keys=%+v", total, keys)
+	}
+	keys, _, _ = ms.QueryAPIKeys(&APIKeyQuery{Limit: 3, Offset: 3})
+	if len(keys) != 1 || keys[0].AccessKeyID != ids[0] {
+		t.Errorf("第二页错误: %+v", keys)
+	}
+
+	// 升序
+	keys, _, _ = ms.QueryAPIKeys(&APIKeyQuery{Ascending: true, Limit: 1})
+	if len(keys) != 1 || keys[0].AccessKeyID != ids[0] {
+		t.Errorf("升序排序错误: %+v", keys)
+	}
+
+	// 描述与启用状态筛选
+	enabled := true
+	keys, total, _ = ms.QueryAPIKeys(&APIKeyQuery{Description: "ci", Enabled: &enabled})
+	if total != 1 || len(keys) != 1 || keys[0].Description != "ci deploy" {
+		t.Errorf("筛选结果错误: total=%d, keys=%+v", total, keys)
+	}
+
+	// 无结果时返回空列表
+	keys, total, _ = ms.QueryAPIKeys(&APIKeyQuery{Description: "nothing"})
+	if total != 0 || keys == nil || len(keys) != 0 {
+		t.Errorf("无结果时应返回空列表: %v", keys)
+	}
+
+	if _, _, err := ms.QueryAPIKeys(&APIKeyQuery{Sort: "secret"}); err == nil {
+		t.Error("无效排序字段应返回错误")
+	}
+}
+
 // TestDeleteAPIKey 测试删除API密钥
 func TestDeleteAPIKey(t *testing.T) {
 	ms, cleanup := setupAPIKeysTest(t)
@@ -251,6 +304,125 @@ func TestUpdateAPIKeyDescription(t *testing.T) {
 	}
 }
 
+// TestUpdateAPIKeyNamespace 测试设置API密钥的桶命名空间
+func TestUpdateAPIKeyNamespace(t *testing.T) {
+	ms, cleanup := setupAPIKeysTest(t)
+	defer cleanup()
+
+	key, err := ms.CreateAPIKey("tenant")
+	if err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+	if err := ms.UpdateAPIKeyNamespace(key.AccessKeyID, "tenant-a-"); err != nil {
+		t.Fatalf("设置命名空间失败: %v", err)
+	}
+
+	retrieved, err := ms.GetAPIKey(key.AccessKeyID)
+	if err != nil {
+		t.Fatalf("获取密钥失败: %v", err)
+	}
+	if retrieved.Namespace != "tenant-a-" {
+		t.Errorf("命名空间未更新: got %q", retrieved.Namespace)
+	}
+
+	cache := NewAPIKeyCache(ms)
+	if ns := cache.Namespace(key.AccessKeyID); ns != "tenant-a-" {
+		t.Errorf("缓存中的命名空间错误: got %q", ns)
+	}
+
+	// 清空命名空间
+	if err := ms.UpdateAPIKeyNamespace(key.AccessKeyID, ""); err != nil {
+		t.Fatalf("清空命名空间失败: %v", err)
+	}
+	cache.Reload()
+	if ns := cache.Namespace(key.AccessKeyID); ns != "" {
+		t.Errorf("命名空间应已清空: got %q", ns)
+	}
+}
+
+// TestIsValidNamespace 测试命名空间格式校验
+func TestIsValidNamespace(t *testing.T) {
+	tests := []struct {
+		ns    string
+		valid bool
+	}{
+		{"tenant-a-", true},
+		{"t1", true},
+		{"", false},
+		{"-tenant", false},
+		{"Tenant-", false},
+		{"tenant.a", false},
+		{"tenant/a", false},
+		{strings.Repeat("a", MaxNamespaceLength+1), false},
+	}
+	for _, tt := range tests {
+		if got := IsValidNamespace(tt.ns); got != tt.valid {
+			t.Errorf("IsValidNamespace(%q) = %v, want %v", tt.ns, got, tt.valid)
+		}
+	}
+}
+
+// TestNamespacePrefix 测试命名空间前缀总以分隔符结尾，以及命名空间互为前缀的判断
+func TestNamespacePrefix(t *testing.T) {
+	prefixes := map[string]string{
+		"":          "",
+		"acme":      "acme-",
+		"tenant-a-": "tenant-a-",
+	}
+	for ns, want := range prefixes {
+		if got := NamespacePrefix(ns); got != want {
+			t.Errorf("NamespacePrefix(%q) = %q, want %q", ns, got, want)
+		}
+	}
+
+	overlaps := []struct {
+		a, b string
+		want bool
+	}{
+		{"acme", "acme2", false},
+		{"acme", "acme-", false},
+		{"acme", "acme-eu", true},
+		{"tenant-a-", "tenant-a-b", true},
+		{"tenant-a", "tenant-b", false},
+		{"", "acme", false},
+	}
+	for _, tt := range overlaps {
+		if got := NamespacesOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("NamespacesOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestFindOverlappingNamespace 测试查找与其他密钥互为前缀的命名空间
+func TestFindOverlappingNamespace(t *testing.T) {
+	ms, cleanup := setupAPIKeysTest(t)
+	defer cleanup()
+
+	key, _ := ms.CreateAPIKey("acme")
+	ms.UpdateAPIKeyNamespace(key.AccessKeyID, "acme")
+
+	cases := map[string]string{
+		"acme-eu": "acme",
+		"ac":      "",
+		"acme2":   "",
+		"acme":    "",
+	}
+	for ns, want := range cases {
+		got, err := ms.FindOverlappingNamespace(ns, "")
+		if err != nil {
+			t.Fatalf("查找失败: %v", err)
+		}
+		if got != want {
+			t.Errorf("FindOverlappingNamespace(%q) = %q, want %q", ns, got, want)
+		}
+	}
+
+	// 排除密钥自身
+	if got, _ := ms.FindOverlappingNamespace("acme-eu", key.AccessKeyID); got != "" {
+		t.Errorf("应排除自身命名空间, got %q", got)
+	}
+}
+
 // TestResetAPIKeySecret 测试重置API密钥的SecretKey
 func TestResetAPIKeySecret(t *testing.T) {
 	ms, cleanup := setupAPIKeysTest(t)
@@ -936,6 +1108,54 @@ func TestAPIKeyDescriptionSpecialCharacters(t *testing.T) {
 	}
 }
 
+// TestPresignQuota 测试预签名额度按窗口计数与重置
+func TestPresignQuota(t *testing.T) {
+	ms, cleanup := setupAPIKeysTest(t)
+	defer cleanup()
+
+	window := time.Hour
+	now := time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC)
+
+	usage, ok, err := ms.ConsumePresignQuota("AK1", 2, 3, window, now)
+	if err != nil || !ok {
+		t.Fatalf("首次占用应成功: ok=%v err=%v", ok, err)
+	}
+	if usage.Count != 2 || !usage.ResetAt.Equal(time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("用量错误: %+v", usage)
+	}
+
+	// 超出上限不计数
+	usage, ok, err = ms.ConsumePresignQuota("AK1", 2, 3, window, now.Add(time.Minute))
+	if err != nil || ok {
+		t.Fatalf("超出上限应被拒绝: ok=%v err=%v", ok, err)
+	}
+	if usage.Count != 2 {
+		t.Errorf("被拒绝时用量不应变化: %d", usage.Count)
+	}
+	if _, ok, _ = ms.ConsumePresignQuota("AK1", 1, 3, window, now.Add(time.Minute)); !ok {
+		t.Error("剩余额度内应成功")
+	}
+
+	got, err := ms.GetPresignUsage("AK1", 3, window, now.Add(2*time.Minute))
+	if err != nil || got.Count != 3 {
+		t.Fatalf("当前窗口用量应为 3: %+v err=%v", got, err)
+	}
+
+	// 进入下一个窗口后重新计数
+	next := now.Add(time.Hour)
+	if got, _ := ms.GetPresignUsage("AK1", 3, window, next); got.Count != 0 {
+		t.Errorf("新窗口用量应为 0: %d", got.Count)
+	}
+	if usage, ok, _ = ms.ConsumePresignQuota("AK1", 1, 3, window, next); !ok || usage.Count != 1 {
+		t.Errorf("新窗口应重新计数: ok=%v %+v", ok, usage)
+	}
+
+	// 未使用过的 Key 用量为 0
+	if got, _ := ms.GetPresignUsage("AK2", 3, window, now); got.Count != 0 {
+		t.Errorf("未使用的 Key 用量应为 0: %d", got.Count)
+	}
+}
+
 // BenchmarkAPIKeyCacheValidate API密钥验证性能基准
 func BenchmarkAPIKeyCacheValidate(b *testing.B) {
 	ms, cleanup := setupAPIKeysTest(&testing.T{})
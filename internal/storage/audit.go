@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 )
 
@@ -21,16 +22,40 @@ const (
 	AuditActionPasswordChange AuditAction = "password_change" // 修改密码
 
 	// Bucket 相关
-	AuditActionBucketCreate     AuditAction = "bucket_create"      // 创建桶
-	AuditActionBucketDelete     AuditAction = "bucket_delete"      // 删除桶
-	AuditActionBucketSetPublic  AuditAction = "bucket_set_public"  // 设置桶公开
-	AuditActionBucketSetPrivate AuditAction = "bucket_set_private" // 设置桶私有
+	AuditActionBucketCreate          AuditAction = "bucket_create"            // 创建桶
+	AuditActionBucketDelete          AuditAction = "bucket_delete"            // 删除桶
+	AuditActionBucketSetPublic       AuditAction = "bucket_set_public"        // 设置桶公开
+	AuditActionBucketSetPrivate      AuditAction = "bucket_set_private"       // 设置桶私有
+	AuditActionBucketSetContentTypes AuditAction = "bucket_set_content_types" // 设置桶内容类型限制
+	AuditActionBucketSetKeyDenylist  AuditAction = "bucket_set_key_denylist"  // 设置桶保留键
+	AuditActionBucketSetImmutability AuditAction = "bucket_set_immutability"  // 设置桶对象不可变窗口
+	AuditActionBucketSetReadAge      AuditAction = "bucket_set_read_age"      // 设置桶最大可读天数
+	AuditActionBucketSetTransform    AuditAction = "bucket_set_transform"     // 设置桶图片按需缩放
+	AuditActionBucketSetHeaders      AuditAction = "bucket_set_headers"       // 设置桶默认响应头
+	AuditActionBucketSetWebsite      AuditAction = "bucket_set_website"       // 设置桶静态网站
+	AuditActionBucketSetMethods      AuditAction = "bucket_set_methods"       // 设置桶允许的 HTTP 方法
+	AuditActionBucketSetRewrites     AuditAction = "bucket_set_rewrites"      // 设置桶对象键前缀改写
+	AuditActionBucketSetReadOnly     AuditAction = "bucket_set_read_only"     // 设置桶只读
+	AuditActionBucketSetACL          AuditAction = "bucket_set_acl"           // 通过 S3 API 设置桶 ACL
+	AuditActionBucketSetVersioning   AuditAction = "bucket_set_versioning"    // 设置桶版本控制
+	AuditActionBucketSetPolicy       AuditAction = "bucket_set_policy"        // 设置或删除桶策略
+	AuditActionBucketSetLifecycle    AuditAction = "bucket_set_lifecycle"     // 设置桶生命周期规则
+	AuditActionBucketSetQuota        AuditAction = "bucket_set_quota"         // 设置桶存储配额
 
 	// 对象相关
-	AuditActionObjectUpload AuditAction = "object_upload" // 上传对象
-	AuditActionObjectDelete AuditAction = "object_delete" // 删除对象
-	AuditActionObjectCopy   AuditAction = "object_copy"   // 复制对象
-	AuditActionBatchDelete  AuditAction = "batch_delete"  // 批量删除
+	AuditActionObjectUpload     AuditAction = "object_upload"      // 上传对象
+	AuditActionObjectOverwrite  AuditAction = "object_overwrite"   // 覆盖已存在的对象
+	AuditActionObjectDelete     AuditAction = "object_delete"      // 删除对象
+	AuditActionObjectUndoDelete AuditAction = "object_undo_delete" // 在撤销窗口内恢复被删除的对象
+	AuditActionObjectCopy       AuditAction = "object_copy"        // 复制对象
+	AuditActionBatchDelete      AuditAction = "batch_delete"       // 批量删除
+	AuditActionBatchTag         AuditAction = "batch_tag"          // 批量设置对象标签
+	AuditActionBatchUntag       AuditAction = "batch_untag"        // 批量移除对象标签
+
+	// 读操作（按配置的百分比采样记录）
+	AuditActionObjectRead AuditAction = "object_read" // 下载对象
+	AuditActionObjectHead AuditAction = "object_head" // 获取对象元数据
+	AuditActionBucketList AuditAction = "bucket_list" // 列举对象
 
 	// API Key 相关
 	AuditActionAPIKeyCreate      AuditAction = "apikey_create"       // 创建 API Key
@@ -39,12 +64,63 @@ const (
 	AuditActionAPIKeyUpdate      AuditAction = "apikey_update"       // 更新 API Key
 	AuditActionAPIKeySetPerm     AuditAction = "apikey_set_perm"     // 设置权限
 	AuditActionAPIKeyDelPerm     AuditAction = "apikey_del_perm"     // 删除权限
+	AuditActionSTSIssue          AuditAction = "sts_issue"           // 签发临时凭证
 
 	// 迁移相关
 	AuditActionMigrateCreate AuditAction = "migrate_create" // 创建迁移任务
 	AuditActionMigrateCancel AuditAction = "migrate_cancel" // 取消迁移任务
+	AuditActionMigrateDelete AuditAction = "migrate_delete" // 删除迁移任务记录
+
+	// 复制相关
+	AuditActionReplicationSet    AuditAction = "replication_set"    // 设置桶复制
+	AuditActionReplicationDelete AuditAction = "replication_delete" // 删除桶复制
+
+	// 事件通知相关
+	AuditActionNotificationSet    AuditAction = "notification_set"    // 设置桶事件通知
+	AuditActionNotificationDelete AuditAction = "notification_delete" // 删除桶事件通知
+
+	// 回源相关
+	AuditActionOriginSet    AuditAction = "origin_set"    // 设置桶回源
+	AuditActionOriginDelete AuditAction = "origin_delete" // 删除桶回源
+
+	// 维护相关
+	AuditActionGCExecute       AuditAction = "gc_execute"       // 执行垃圾回收
+	AuditActionIntegrityRepair AuditAction = "integrity_repair" // 修复完整性问题
+	AuditActionOrphanSweep     AuditAction = "orphan_sweep"     // 清理孤立文件
+	AuditActionStatsRecompute  AuditAction = "stats_recompute"  // 重建存储统计计数器
+	AuditActionEtagRederive    AuditAction = "etag_rederive"    // 启动 ETag 重算任务
 )
 
+// auditActionAliases 审计操作的别名，便于按合规报表中的通用名称筛选
+// 存储的仍是原始操作名，保持与历史日志兼容
+var auditActionAliases = map[string]AuditAction{
+	"login_success":   AuditActionLogin,
+	"login_failure":   AuditActionLoginFailed,
+	"permission_set":  AuditActionAPIKeySetPerm,
+	"settings_change": AuditActionSettingsUpdate,
+}
+
+// ParseAuditActions 解析逗号分隔的审计操作筛选条件，支持别名，忽略空项和重复项
+func ParseAuditActions(s string) []AuditAction {
+	var actions []AuditAction
+	seen := make(map[AuditAction]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		action := AuditAction(part)
+		if alias, ok := auditActionAliases[part]; ok {
+			action = alias
+		}
+		if !seen[action] {
+			seen[action] = true
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
 // AuditLog 审计日志
 type AuditLog struct {
 	ID          int64       `json:"id"`
@@ -58,6 +134,8 @@ type AuditLog struct {
 	Detail      string      `json:"detail"`       // 详细信息（JSON 格式）
 	Success     bool        `json:"success"`      // 是否成功
 	UserAgent   string      `json:"user_agent"`   // 客户端 User-Agent
+	RequestID   string      `json:"request_id"`   // 服务端请求 ID（x-amz-request-id）
+	TraceID     string      `json:"trace_id"`     // 客户端传入的追踪 ID
 }
 
 // initAuditTable 初始化审计日志表
@@ -87,6 +165,7 @@ func (m *MetadataStore) initAuditTable() error {
 		// 复合索引：优化带筛选的分页查询
 		`CREATE INDEX IF NOT EXISTS idx_audit_timestamp_action ON audit_logs(timestamp DESC, action)`,
 		`CREATE INDEX IF NOT EXISTS idx_audit_success ON audit_logs(success)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_resource ON audit_logs(resource)`,
 	}
 	for _, idx := range indexes {
 		if _, err := m.db.Exec(idx); err != nil {
@@ -98,6 +177,10 @@ func (m *MetadataStore) initAuditTable() error {
 	m.db.Exec(`ALTER TABLE audit_logs ADD COLUMN forwarded_ip TEXT NOT NULL DEFAULT ''`)
 	// 迁移：为旧表添加 location 列（如果不存在）
 	m.db.Exec(`ALTER TABLE audit_logs ADD COLUMN location TEXT NOT NULL DEFAULT ''`)
+	// 迁移：为旧表添加 request_id/trace_id 列（如果不存在）
+	m.db.Exec(`ALTER TABLE audit_logs ADD COLUMN request_id TEXT NOT NULL DEFAULT ''`)
+	m.db.Exec(`ALTER TABLE audit_logs ADD COLUMN trace_id TEXT NOT NULL DEFAULT ''`)
+	m.db.Exec(`CREATE INDEX IF NOT EXISTS idx_audit_trace_id ON audit_logs(trace_id)`)
 
 	return nil
 }
@@ -115,9 +198,10 @@ func (m *MetadataStore) WriteAuditLog(log *AuditLog) error {
 
 	return m.withWriteLock(func() error {
 		_, err := m.db.Exec(`
-			INSERT INTO audit_logs (timestamp, action, actor, ip, forwarded_ip, location, resource, detail, success, user_agent)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			INSERT INTO audit_logs (timestamp, action, actor, ip, forwarded_ip, location, resource, detail, success, user_agent, request_id, trace_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			log.Timestamp, log.Action, log.Actor, log.IP, log.ForwardedIP, log.Location, log.Resource, log.Detail, successInt, log.UserAgent,
+			log.RequestID, log.TraceID,
 		)
 		return err
 	})
@@ -125,15 +209,19 @@ func (m *MetadataStore) WriteAuditLog(log *AuditLog) error {
 
 // AuditLogQuery 审计日志查询参数
 type AuditLogQuery struct {
-	Action    AuditAction // 操作类型（可选）
-	Actor     string      // 操作者（可选）
-	IP        string      // IP 地址（可选）
-	Resource  string      // 资源（可选）
-	StartTime *time.Time  // 开始时间（可选）
-	EndTime   *time.Time  // 结束时间（可选）
-	Success   *bool       // 是否成功（可选）
-	Limit     int         // 返回数量限制
-	Offset    int         // 偏移量
+	Action        AuditAction   // 操作类型（可选）
+	Actions       []AuditAction // 多个操作类型，任一匹配即可（可选）
+	Actor         string        // 操作者（可选）
+	IP            string        // IP 地址（可选）
+	Resource      string        // 资源（可选，默认模糊匹配）
+	ExactResource bool          // 资源精确匹配，如对象历史只匹配 bucket/key 本身
+	TraceID       string        // 追踪 ID 或请求 ID（可选，精确匹配）
+	StartTime     *time.Time    // 开始时间（可选）
+	EndTime       *time.Time    // 结束时间（可选）
+	Success       *bool         // 是否成功（可选）
+	Limit         int           // 返回数量限制
+	Offset        int           // 偏移量
+	Ascending     bool          // 按时间正序返回（默认倒序）
 }
 
 // QueryAuditLogs 查询审计日志
@@ -146,6 +234,13 @@ func (m *MetadataStore) QueryAuditLogs(query *AuditLogQuery) ([]AuditLog, int, e
 		conditions = append(conditions, "action = ?")
 		args = append(args, query.Action)
 	}
+	if len(query.Actions) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(query.Actions)), ",")
+		conditions = append(conditions, "action IN ("+placeholders+")")
+		for _, action := range query.Actions {
+			args = append(args, action)
+		}
+	}
 	if query.Actor != "" {
 		conditions = append(conditions, "actor LIKE ?")
 		args = append(args, "%"+query.Actor+"%")
@@ -154,10 +249,17 @@ func (m *MetadataStore) QueryAuditLogs(query *AuditLogQuery) ([]AuditLog, int, e
 		conditions = append(conditions, "ip LIKE ?")
 		args = append(args, "%"+query.IP+"%")
 	}
-	if query.Resource != "" {
+	if query.Resource != "" && query.ExactResource {
+		conditions = append(conditions, "resource = ?")
+		args = append(args, query.Resource)
+	} else if query.Resource != "" {
 		conditions = append(conditions, "resource LIKE ?")
 		args = append(args, "%"+query.Resource+"%")
 	}
+	if query.TraceID != "" {
+		conditions = append(conditions, "(trace_id = ? OR request_id = ?)")
+		args = append(args, query.TraceID, query.TraceID)
+	}
 	if query.StartTime != nil {
 		conditions = append(conditions, "timestamp >= ?")
 		args = append(args, *query.StartTime)
@@ -201,8 +303,12 @@ func (m *MetadataStore) QueryAuditLogs(query *AuditLogQuery) ([]AuditLog, int, e
 		query.Limit = 1000
 	}
 
-	dataSQL := "SELECT id, timestamp, action, actor, ip, forwarded_ip, location, resource, detail, success, user_agent FROM audit_logs " +
-		whereClause + " ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+	order := "timestamp DESC, id DESC"
+	if query.Ascending {
+		order = "timestamp ASC, id ASC"
+	}
+	dataSQL := "SELECT id, timestamp, action, actor, ip, forwarded_ip, location, resource, detail, success, user_agent, request_id, trace_id FROM audit_logs " +
+		whereClause + " ORDER BY " + order + " LIMIT ? OFFSET ?"
 	args = append(args, query.Limit, query.Offset)
 
 	rows, err := m.db.Query(dataSQL, args...)
@@ -216,7 +322,8 @@ func (m *MetadataStore) QueryAuditLogs(query *AuditLogQuery) ([]AuditLog, int, e
 		var log AuditLog
 		var successInt int
 		if err := rows.Scan(&log.ID, &log.Timestamp, &log.Action, &log.Actor, &log.IP,
-			&log.ForwardedIP, &log.Location, &log.Resource, &log.Detail, &successInt, &log.UserAgent); err != nil {
+			&log.ForwardedIP, &log.Location, &log.Resource, &log.Detail, &successInt, &log.UserAgent,
+			&log.RequestID, &log.TraceID); err != nil {
 			return nil, 0, err
 		}
 		log.Success = successInt == 1
@@ -233,7 +340,7 @@ func (m *MetadataStore) GetRecentAuditLogs(limit int) ([]AuditLog, error) {
 	}
 
 	rows, err := m.db.Query(`
-		SELECT id, timestamp, action, actor, ip, forwarded_ip, location, resource, detail, success, user_agent
+		SELECT id, timestamp, action, actor, ip, forwarded_ip, location, resource, detail, success, user_agent, request_id, trace_id
 		FROM audit_logs ORDER BY timestamp DESC LIMIT ?`, limit)
 	if err != nil {
 		return nil, err
@@ -245,7 +352,8 @@ func (m *MetadataStore) GetRecentAuditLogs(limit int) ([]AuditLog, error) {
 		var log AuditLog
 		var successInt int
 		if err := rows.Scan(&log.ID, &log.Timestamp, &log.Action, &log.Actor, &log.IP,
-			&log.ForwardedIP, &log.Location, &log.Resource, &log.Detail, &successInt, &log.UserAgent); err != nil {
+			&log.ForwardedIP, &log.Location, &log.Resource, &log.Detail, &successInt, &log.UserAgent,
+			&log.RequestID, &log.TraceID); err != nil {
 			return nil, err
 		}
 		log.Success = successInt == 1
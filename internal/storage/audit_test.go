@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -576,6 +577,10 @@ func TestAuditLogActions(t *testing.T) {
 		// 迁移相关
 		AuditActionMigrateCreate,
 		AuditActionMigrateCancel,
+		AuditActionMigrateDelete,
+		// 维护相关
+		AuditActionGCExecute,
+		AuditActionIntegrityRepair,
 	}
 
 	for _, action := range actions {
@@ -605,6 +610,96 @@ func TestAuditLogActions(t *testing.T) {
 	}
 }
 
+// TestParseAuditActions 测试审计操作筛选条件解析
+func TestParseAuditActions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []AuditAction
+	}{
+		{"", nil},
+		{"bucket_create", []AuditAction{AuditActionBucketCreate}},
+		{"login_success", []AuditAction{AuditActionLogin}},
+		{"login_failure", []AuditAction{AuditActionLoginFailed}},
+		{"permission_set", []AuditAction{AuditActionAPIKeySetPerm}},
+		{"settings_change", []AuditAction{AuditActionSettingsUpdate}},
+		{"apikey_create, apikey_reset_secret,,", []AuditAction{AuditActionAPIKeyCreate, AuditActionAPIKeyResetSecret}},
+		{"login,login_success", []AuditAction{AuditActionLogin}},
+	}
+	for _, tt := range tests {
+		if got := ParseAuditActions(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAuditActions(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestQueryAuditLogsMultipleActions 测试按多个操作类型查询
+func TestQueryAuditLogsMultipleActions(t *testing.T) {
+	ms, cleanup := setupAuditTest(t)
+	defer cleanup()
+
+	for _, action := range []AuditAction{AuditActionLogin, AuditActionLoginFailed, AuditActionPasswordChange, AuditActionBucketCreate} {
+		if err := ms.WriteAuditLog(&AuditLog{Action: action, Success: true}); err != nil {
+			t.Fatalf("写入日志失败: %v", err)
+		}
+	}
+
+	logs, total, err := ms.QueryAuditLogs(&AuditLogQuery{
+		Actions: ParseAuditActions("login_success,login_failure,password_change"),
+	})
+	if err != nil {
+		t.Fatalf("查询日志失败: %v", err)
+	}
+	if total != 3 || len(logs) != 3 {
+		t.Errorf("期望 3 条日志, 实际 total=%d len=%d", total, len(logs))
+	}
+	for _, log := range logs {
+		if log.Action == AuditActionBucketCreate {
+			t.Error("不应返回未筛选的操作")
+		}
+	}
+}
+
+// TestQueryAuditLogsExactResource 测试按资源精确匹配并按时间正序查询
+func TestQueryAuditLogsExactResource(t *testing.T) {
+	ms, cleanup := setupAuditTest(t)
+	defer cleanup()
+
+	base := time.Now().Add(-time.Hour)
+	entries := []struct {
+		action   AuditAction
+		resource string
+	}{
+		{AuditActionObjectUpload, "docs/a.txt"},
+		{AuditActionObjectUpload, "docs/a.txt.bak"},
+		{AuditActionObjectOverwrite, "docs/a.txt"},
+		{AuditActionObjectDelete, "docs/a.txt"},
+	}
+	for i, e := range entries {
+		if err := ms.WriteAuditLog(&AuditLog{Timestamp: base.Add(time.Duration(i) * time.Minute), Action: e.action, Resource: e.resource, Success: true}); err != nil {
+			t.Fatalf("写入日志失败: %v", err)
+		}
+	}
+
+	logs, total, err := ms.QueryAuditLogs(&AuditLogQuery{Resource: "docs/a.txt", ExactResource: true, Ascending: true})
+	if err != nil {
+		t.Fatalf("查询日志失败: %v", err)
+	}
+	if total != 3 || len(logs) != 3 {
+		t.Fatalf("期望 3 条日志, 实际 total=%d len=%d", total, len(logs))
+	}
+	want := []AuditAction{AuditActionObjectUpload, AuditActionObjectOverwrite, AuditActionObjectDelete}
+	for i, log := range logs {
+		if log.Action != want[i] {
+			t.Errorf("第 %d 条顺序错误: got %s, want %s", i, log.Action, want[i])
+		}
+	}
+
+	// 默认模糊匹配仍包含相似资源
+	if _, total, _ := ms.QueryAuditLogs(&AuditLogQuery{Resource: "docs/a.txt"}); total != 4 {
+		t.Errorf("模糊匹配期望 4 条, 实际 %d", total)
+	}
+}
+
 // TestAuditLogConcurrentWrites 测试并发写入审计日志
 func TestAuditLogConcurrentWrites(t *testing.T) {
 	ms, cleanup := setupAuditTest(t)
@@ -782,6 +877,34 @@ func timePtr(t time.Time) *time.Time {
 	return &t
 }
 
+// TestAuditLogTraceID 测试请求 ID 与追踪 ID 的记录和查询
+func TestAuditLogTraceID(t *testing.T) {
+	ms, cleanup := setupAuditTest(t)
+	defer cleanup()
+
+	ms.WriteAuditLog(&AuditLog{Action: AuditActionObjectDelete, Actor: "svc", RequestID: "req-1", TraceID: "trace-abc", Success: true})
+	ms.WriteAuditLog(&AuditLog{Action: AuditActionObjectDelete, Actor: "svc", RequestID: "req-2", Success: true})
+
+	logs, total, err := ms.QueryAuditLogs(&AuditLogQuery{TraceID: "trace-abc"})
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if total != 1 || logs[0].RequestID != "req-1" || logs[0].TraceID != "trace-abc" {
+		t.Errorf("按追踪 ID 查询结果错误: total=%d, logs=%+v", total, logs)
+	}
+
+	// 也可按服务端请求 ID 查询
+	logs, total, _ = ms.QueryAuditLogs(&AuditLogQuery{TraceID: "req-2"})
+	if total != 1 || logs[0].TraceID != "" {
+		t.Errorf("按请求 ID 查询结果错误: total=%d, logs=%+v", total, logs)
+	}
+
+	recent, _ := ms.GetRecentAuditLogs(10)
+	if len(recent) != 2 || recent[0].RequestID == "" {
+		t.Errorf("最近日志应包含请求 ID: %+v", recent)
+	}
+}
+
 // BenchmarkWriteAuditLog 审计日志写入性能基准测试
 func BenchmarkWriteAuditLog(b *testing.B) {
 	ms, cleanup := setupAuditTest(&testing.T{})
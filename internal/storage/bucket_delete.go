@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrBucketDeleted 桶已被强制删除，迟到的写入被拒绝
+	ErrBucketDeleted = errors.New("bucket has been deleted")
+	// ErrBucketImmutable 桶中仍有处于不可变窗口内的对象
+	ErrBucketImmutable = errors.New("bucket contains objects within the immutability window")
+)
+
+// BucketPurge 强制删除桶时清除的内容，调用方据此清理磁盘文件
+type BucketPurge struct {
+	StoragePaths []string // 对象文件及历史版本文件路径
+	UploadIDs    []string // 未完成的分片上传
+	Objects      int      // 删除的对象数
+	Bytes        int64    // 删除的对象总大小
+}
+
+// ForceDeleteBucket 在同一事务中删除桶及其全部对象、分片上传和复制配置
+// 删除期间持有写锁，完成后该桶名被标记为已删除，在同名桶重新创建前拒绝迟到的对象写入
+// immutableSince 非零时，若存在该时间之后写入的对象则整体放弃并返回 ErrBucketImmutable
+func (m *MetadataStore) ForceDeleteBucket(name string, immutableSince time.Time) (*BucketPurge, error) {
+	m.wmu.Lock()
+	defer m.wmu.Unlock()
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	purge := &BucketPurge{}
+	rows, err := tx.Query("SELECT storage_path, size, last_modified FROM objects WHERE bucket = ?", name)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var path string
+		var size int64
+		var modified time.Time
+		if err := rows.Scan(&path, &size, &modified); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !immutableSince.IsZero() && modified.After(immutableSince) {
+			rows.Close()
+			return nil, ErrBucketImmutable
+		}
+		purge.StoragePaths = append(purge.StoragePaths, path)
+		purge.Objects++
+		purge.Bytes += size
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 历史版本的文件一并清理，删除标记没有文件
+	rows, err = tx.Query("SELECT storage_path FROM object_versions WHERE bucket = ? AND delete_marker = 0", name)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		purge.StoragePaths = append(purge.StoragePaths, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query("SELECT upload_id FROM multipart_uploads WHERE bucket = ?", name)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var uploadID string
+		if err := rows.Scan(&uploadID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		purge.UploadIDs = append(purge.UploadIDs, uploadID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, stmt := range []string{
+		"DELETE FROM parts WHERE upload_id IN (SELECT upload_id FROM multipart_uploads WHERE bucket = ?)",
+		"DELETE FROM multipart_uploads WHERE bucket = ?",
+		"DELETE FROM objects WHERE bucket = ?",
+		"DELETE FROM object_versions WHERE bucket = ?",
+		"DELETE FROM bucket_replication WHERE bucket = ?",
+		"DELETE FROM bucket_notification WHERE bucket = ?",
+		"DELETE FROM bucket_origin WHERE bucket = ?",
+		"DELETE FROM object_downloads WHERE bucket = ?",
+		"DELETE FROM buckets WHERE name = ?",
+	} {
+		if _, err := tx.Exec(stmt, name); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if m.deletedBuckets == nil {
+		m.deletedBuckets = make(map[string]bool)
+	}
+	m.deletedBuckets[name] = true
+	return purge, nil
+}
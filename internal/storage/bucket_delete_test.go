@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// TestForceDeleteBucket 测试强制删除桶及其内容
+func TestForceDeleteBucket(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	bucket := "force-bucket"
+	store.CreateBucket(bucket)
+	for _, key := range []string{"a.txt", "dir/b.txt"} {
+		store.PutObject(&Object{Bucket: bucket, Key: key, Size: 10, ETag: "e", StoragePath: "/path/" + key, LastModified: time.Now().Add(-time.Hour)})
+	}
+	store.CreateMultipartUpload(&MultipartUpload{UploadID: "abc123", Bucket: bucket, Key: "big.bin", Initiated: time.Now()})
+	store.PutPart(&Part{UploadID: "abc123", PartNumber: 1, Size: 5, ETag: "p", ModifiedAt: time.Now()})
+
+	t.Run("不可变窗口内的对象阻止删除", func(t *testing.T) {
+		_, err := store.ForceDeleteBucket(bucket, time.Now().Add(-2*time.Hour))
+		if err != ErrBucketImmutable {
+			t.Fatalf("期望 ErrBucketImmutable, 实际 %v", err)
+		}
+		if b, _ := store.GetBucket(bucket); b == nil {
+			t.Fatal("放弃删除后桶应仍存在")
+		}
+	})
+
+	t.Run("删除对象、分片上传和桶", func(t *testing.T) {
+		purge, err := store.ForceDeleteBucket(bucket, time.Time{})
+		if err != nil {
+			t.Fatalf("强制删除失败: %v", err)
+		}
+		if purge.Objects != 2 || purge.Bytes != 20 || len(purge.StoragePaths) != 2 {
+			t.Errorf("删除统计错误: %+v", purge)
+		}
+		if len(purge.UploadIDs) != 1 || purge.UploadIDs[0] != "abc123" {
+			t.Errorf("分片上传错误: %v", purge.UploadIDs)
+		}
+		if b, _ := store.GetBucket(bucket); b != nil {
+			t.Error("桶应已删除")
+		}
+		if objs, _ := store.ListAllObjects(bucket); len(objs) != 0 {
+			t.Errorf("对象应已删除: %d", len(objs))
+		}
+		if parts, _ := store.ListParts("abc123"); len(parts) != 0 {
+			t.Errorf("分片应已删除: %d", len(parts))
+		}
+	})
+
+	t.Run("迟到的写入被拒绝直到桶重建", func(t *testing.T) {
+		obj := &Object{Bucket: bucket, Key: "late.txt", Size: 1, ETag: "e", StoragePath: "/path/late.txt"}
+		if err := store.PutObject(obj); err != ErrBucketDeleted {
+			t.Errorf("期望 ErrBucketDeleted, 实际 %v", err)
+		}
+		if err := store.CreateMultipartUpload(&MultipartUpload{UploadID: "def456", Bucket: bucket, Key: "k", Initiated: time.Now()}); err != ErrBucketDeleted {
+			t.Errorf("期望 ErrBucketDeleted, 实际 %v", err)
+		}
+
+		store.CreateBucket(bucket)
+		if err := store.PutObject(obj); err != nil {
+			t.Errorf("重建桶后写入失败: %v", err)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// OtherBucketsLabel 超出基数上限的桶统一归入该标签
+const OtherBucketsLabel = "_other"
+
+// DefaultMetricsMaxBuckets 默认最多单独统计的桶数量
+const DefaultMetricsMaxBuckets = 1000
+
+// BucketMetrics 单个桶的请求统计
+type BucketMetrics struct {
+	Bucket       string  `json:"bucket"`
+	Requests     int64   `json:"requests"`      // 请求数
+	Errors       int64   `json:"errors"`        // 4xx/5xx 响应数
+	ServerErrors int64   `json:"server_errors"` // 5xx 响应数
+	BytesIn      int64   `json:"bytes_in"`      // 请求体字节数
+	BytesOut     int64   `json:"bytes_out"`     // 响应体字节数
+	ErrorRate    float64 `json:"error_rate"`    // 错误率 (0-1)
+}
+
+// BucketMetricsCollector 内存中的按桶请求统计，进程重启后清零
+type BucketMetricsCollector struct {
+	mu        sync.Mutex
+	buckets   map[string]*BucketMetrics
+	startedAt time.Time
+}
+
+var (
+	bucketMetrics     *BucketMetricsCollector
+	bucketMetricsOnce sync.Once
+)
+
+// GetBucketMetrics 获取按桶统计单例
+func GetBucketMetrics() *BucketMetricsCollector {
+	bucketMetricsOnce.Do(func() {
+		bucketMetrics = NewBucketMetricsCollector()
+	})
+	return bucketMetrics
+}
+
+// NewBucketMetricsCollector 创建按桶统计
+func NewBucketMetricsCollector() *BucketMetricsCollector {
+	return &BucketMetricsCollector{
+		buckets:   make(map[string]*BucketMetrics),
+		startedAt: time.Now(),
+	}
+}
+
+// Record 记录一次请求，新桶超过 maxBuckets 时归入 OtherBucketsLabel（maxBuckets<=0 表示不限）
+func (c *BucketMetricsCollector) Record(bucket string, status int, bytesIn, bytesOut int64, maxBuckets int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m, ok := c.buckets[bucket]
+	if !ok {
+		tracked := len(c.buckets)
+		if _, hasOther := c.buckets[OtherBucketsLabel]; hasOther {
+			tracked--
+		}
+		if maxBuckets > 0 && tracked >= maxBuckets {
+			bucket = OtherBucketsLabel
+			m = c.buckets[bucket]
+		}
+		if m == nil {
+			m = &BucketMetrics{Bucket: bucket}
+			c.buckets[bucket] = m
+		}
+	}
+
+	m.Requests++
+	if status >= 400 {
+		m.Errors++
+	}
+	if status >= 500 {
+		m.ServerErrors++
+	}
+	m.BytesIn += bytesIn
+	m.BytesOut += bytesOut
+}
+
+// Snapshot 返回所有桶的统计，按请求数降序
+func (c *BucketMetricsCollector) Snapshot() []BucketMetrics {
+	c.mu.Lock()
+	result := make([]BucketMetrics, 0, len(c.buckets))
+	for _, m := range c.buckets {
+		item := *m
+		if item.Requests > 0 {
+			item.ErrorRate = float64(item.Errors) / float64(item.Requests)
+		}
+		result = append(result, item)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Bucket < result[j].Bucket
+	})
+	return result
+}
+
+// StartedAt 返回统计开始时间
+func (c *BucketMetricsCollector) StartedAt() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.startedAt
+}
+
+// Reset 清空统计
+func (c *BucketMetricsCollector) Reset() {
+	c.mu.Lock()
+	c.buckets = make(map[string]*BucketMetrics)
+	c.startedAt = time.Now()
+	c.mu.Unlock()
+}
@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxBucketPolicySize 桶策略文档大小上限，与 S3 一致
+const MaxBucketPolicySize = 20 * 1024
+
+// 桶策略支持的操作
+const (
+	PolicyActionGetObject    = "s3:GetObject"
+	PolicyActionPutObject    = "s3:PutObject"
+	PolicyActionDeleteObject = "s3:DeleteObject"
+	PolicyActionListBucket   = "s3:ListBucket"
+)
+
+// 桶策略语句的效果
+const (
+	PolicyEffectAllow = "Allow"
+	PolicyEffectDeny  = "Deny"
+)
+
+// policyARNPrefix 桶策略资源 ARN 前缀
+const policyARNPrefix = "arn:aws:s3:::"
+
+// PolicyDecision 桶策略对一次请求的判定结果
+type PolicyDecision int
+
+const (
+	PolicyNone  PolicyDecision = iota // 没有语句命中，按 API Key 权限或公有桶处理
+	PolicyAllow                       // 命中 Allow 且没有命中 Deny
+	PolicyDeny                        // 命中 Deny，优先于任何 Allow
+)
+
+// PolicyStrings 策略中既可以写成单个字符串也可以写成数组的字段，统一按数组保存
+type PolicyStrings []string
+
+// UnmarshalJSON 接受字符串或字符串数组
+func (s *PolicyStrings) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*s = PolicyStrings{one}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or an array of strings")
+	}
+	*s = list
+	return nil
+}
+
+// PolicyPrincipal 策略语句的主体："*"（任何人，包括匿名请求）或 API Key 的 Access Key ID
+// 接受 "*"、{"AWS": "*"}、{"AWS": ["key"]}，以及直接写 Access Key ID 的字符串或数组
+type PolicyPrincipal PolicyStrings
+
+// UnmarshalJSON 解析 S3 格式或简写的主体
+func (p *PolicyPrincipal) UnmarshalJSON(data []byte) error {
+	var aws struct {
+		AWS PolicyStrings `json:"AWS"`
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		if err := json.Unmarshal(data, &aws); err != nil {
+			return err
+		}
+		*p = PolicyPrincipal(aws.AWS)
+		return nil
+	}
+	var list PolicyStrings
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*p = PolicyPrincipal(list)
+	return nil
+}
+
+// MarshalJSON 任何人输出为 "*"，其余输出为 {"AWS": [...]}
+func (p PolicyPrincipal) MarshalJSON() ([]byte, error) {
+	if len(p) == 1 && p[0] == "*" {
+		return json.Marshal("*")
+	}
+	return json.Marshal(map[string][]string{"AWS": p})
+}
+
+// PolicyStatement 桶策略中的一条语句
+type PolicyStatement struct {
+	Sid       string          `json:"Sid,omitempty"`
+	Effect    string          `json:"Effect"`
+	Principal PolicyPrincipal `json:"Principal"`
+	Action    PolicyStrings   `json:"Action"`
+	Resource  PolicyStrings   `json:"Resource"`
+}
+
+// BucketPolicy 桶策略文档（S3 桶策略 JSON 的子集）
+type BucketPolicy struct {
+	Version   string            `json:"Version,omitempty"`
+	Statement []PolicyStatement `json:"Statement"`
+}
+
+// ParseBucketPolicy 解析并校验桶策略文档
+func ParseBucketPolicy(bucket string, data []byte) (*BucketPolicy, error) {
+	if len(data) > MaxBucketPolicySize {
+		return nil, fmt.Errorf("policy exceeds %d bytes", MaxBucketPolicySize)
+	}
+	var policy BucketPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("policy is not valid JSON: %v", err)
+	}
+	if err := ValidateBucketPolicy(bucket, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ValidateBucketPolicy 校验桶策略：只支持 Allow/Deny、s3:GetObject/PutObject/DeleteObject/ListBucket 操作，
+// 资源必须是本桶的 ARN，通配符 * 只能出现在末尾（前缀匹配）
+func ValidateBucketPolicy(bucket string, policy *BucketPolicy) error {
+	if policy.Version != "" && policy.Version != "2012-10-17" && policy.Version != "2008-10-17" {
+		return fmt.Errorf("unsupported policy version %q", policy.Version)
+	}
+	if len(policy.Statement) == 0 {
+		return fmt.Errorf("policy must contain at least one statement")
+	}
+	bucketARN := policyARNPrefix + bucket
+	for i, st := range policy.Statement {
+		if st.Effect != PolicyEffectAllow && st.Effect != PolicyEffectDeny {
+			return fmt.Errorf("statement %d: effect must be Allow or Deny", i)
+		}
+		if len(st.Principal) == 0 {
+			return fmt.Errorf("statement %d: principal is required", i)
+		}
+		for _, principal := range st.Principal {
+			if principal == "" {
+				return fmt.Errorf("statement %d: principal must not be empty", i)
+			}
+		}
+		if len(st.Action) == 0 {
+			return fmt.Errorf("statement %d: action is required", i)
+		}
+		for _, action := range st.Action {
+			if !isPolicyAction(action) {
+				return fmt.Errorf("statement %d: unsupported action %q", i, action)
+			}
+		}
+		if len(st.Resource) == 0 {
+			return fmt.Errorf("statement %d: resource is required", i)
+		}
+		for _, resource := range st.Resource {
+			if resource != bucketARN && !strings.HasPrefix(resource, bucketARN+"/") {
+				return fmt.Errorf("statement %d: resource %q does not belong to bucket %s", i, resource, bucket)
+			}
+			if strings.Contains(strings.TrimSuffix(resource, "*"), "*") {
+				return fmt.Errorf("statement %d: resource %q may only end with a wildcard", i, resource)
+			}
+		}
+	}
+	return nil
+}
+
+func isPolicyAction(action string) bool {
+	switch strings.ToLower(action) {
+	case "*", "s3:*", strings.ToLower(PolicyActionGetObject), strings.ToLower(PolicyActionPutObject),
+		strings.ToLower(PolicyActionDeleteObject), strings.ToLower(PolicyActionListBucket):
+		return true
+	}
+	return false
+}
+
+// PolicyResource 返回对象（key 为空时为桶）的资源 ARN
+func PolicyResource(bucket, key string) string {
+	if key == "" {
+		return policyARNPrefix + bucket
+	}
+	return policyARNPrefix + bucket + "/" + key
+}
+
+// Evaluate 判定主体对资源执行操作的结果，principal 为空表示匿名请求，只匹配 "*"
+// 任何命中的 Deny 优先于 Allow
+func (p *BucketPolicy) Evaluate(principal, action, resource string) PolicyDecision {
+	if p == nil || action == "" {
+		return PolicyNone
+	}
+	decision := PolicyNone
+	for i := range p.Statement {
+		st := &p.Statement[i]
+		if !st.matchesPrincipal(principal) || !st.matchesAction(action) || !st.matchesResource(resource) {
+			continue
+		}
+		if st.Effect == PolicyEffectDeny {
+			return PolicyDeny
+		}
+		decision = PolicyAllow
+	}
+	return decision
+}
+
+func (st *PolicyStatement) matchesPrincipal(principal string) bool {
+	for _, p := range st.Principal {
+		if p == "*" || principal != "" && p == principal {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *PolicyStatement) matchesAction(action string) bool {
+	for _, a := range st.Action {
+		if a == "*" || strings.EqualFold(a, "s3:*") || strings.EqualFold(a, action) {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *PolicyStatement) matchesResource(resource string) bool {
+	for _, r := range st.Resource {
+		if prefix, ok := strings.CutSuffix(r, "*"); ok {
+			if strings.HasPrefix(resource, prefix) {
+				return true
+			}
+		} else if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyDecision 按桶策略判定主体对桶中对象（key 为空时为桶本身）执行操作的结果
+func (b *Bucket) PolicyDecision(principal, action, key string) PolicyDecision {
+	if b == nil {
+		return PolicyNone
+	}
+	return b.Policy.Evaluate(principal, action, PolicyResource(b.Name, key))
+}
+
+// AllowsAnonymousRead 判断匿名 GET/HEAD 请求能否跳过认证
+// 桶策略对 "*" 的 Deny 优先，其次是 Allow，都未命中时公有桶（旧的公开开关）允许读取
+func (b *Bucket) AllowsAnonymousRead(action, key string) bool {
+	if b == nil {
+		return false
+	}
+	switch b.PolicyDecision("", action, key) {
+	case PolicyDeny:
+		return false
+	case PolicyAllow:
+		return true
+	}
+	return b.IsPublic
+}
+
+// encodeBucketPolicy 序列化桶策略，无策略时为空串
+func encodeBucketPolicy(policy *BucketPolicy) string {
+	if policy == nil {
+		return ""
+	}
+	data, _ := json.Marshal(policy)
+	return string(data)
+}
+
+// decodeBucketPolicy 反序列化桶策略，格式错误时视为没有策略
+func decodeBucketPolicy(s string) *BucketPolicy {
+	if s == "" {
+		return nil
+	}
+	var policy BucketPolicy
+	if err := json.Unmarshal([]byte(s), &policy); err != nil {
+		return nil
+	}
+	return &policy
+}
+
+// SetBucketPolicy 设置桶策略，policy 为 nil 时删除
+func (m *MetadataStore) SetBucketPolicy(name string, policy *BucketPolicy) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET policy = ? WHERE name = ?", encodeBucketPolicy(policy), name)
+		return err
+	})
+}
+
+// GetBucketPolicy 获取桶策略，桶不存在或未设置时返回 nil
+func (m *MetadataStore) GetBucketPolicy(name string) (*BucketPolicy, error) {
+	b, err := m.GetBucket(name)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	return b.Policy, nil
+}
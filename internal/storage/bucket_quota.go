@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrQuotaExceeded 写入后桶的存储量将超过配额
+var ErrQuotaExceeded = errors.New("bucket quota exceeded")
+
+// UpdateBucketQuota 设置桶的存储配额（字节），0 表示不限制
+func (m *MetadataStore) UpdateBucketQuota(name string, quotaBytes int64) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET quota_bytes = ? WHERE name = ?", quotaBytes, name)
+		return err
+	})
+}
+
+// BucketUsage 返回桶内当前对象的总大小，读取触发器维护的计数器，不扫描 objects 表
+func (m *MetadataStore) BucketUsage(bucket string) (int64, error) {
+	var total int64
+	err := m.db.QueryRow("SELECT total_size FROM bucket_counters WHERE bucket = ?", bucket).Scan(&total)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return total, err
+}
+
+// CheckBucketQuota 检查把 key 写为 size 字节的对象后桶是否仍在配额内，超出时返回 ErrQuotaExceeded
+// 覆盖写入按新旧大小的差值计算；不增加存储量的写入总是允许，配额调低后仍可覆盖为更小的对象
+func (m *MetadataStore) CheckBucketQuota(bucket, key string, size int64) error {
+	var quota int64
+	err := m.db.QueryRow("SELECT COALESCE(quota_bytes, 0) FROM buckets WHERE name = ?", bucket).Scan(&quota)
+	if err == sql.ErrNoRows || (err == nil && quota <= 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var existing int64
+	err = m.db.QueryRow("SELECT size FROM objects WHERE bucket = ? AND key = ?", bucket, key).Scan(&existing)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if size <= existing {
+		return nil
+	}
+	usage, err := m.BucketUsage(bucket)
+	if err != nil {
+		return err
+	}
+	if usage-existing+size > quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"strings"
+	"time"
+)
+
+// 按需计算的对象校验和算法
+const (
+	ChecksumMD5    = "md5"
+	ChecksumSHA256 = "sha256"
+	ChecksumCRC32C = "crc32c"
+)
+
+// checksumBufferSize 计算校验和时的读缓冲大小
+const checksumBufferSize = 256 * 1024
+
+// ErrUnsupportedChecksum 不支持的校验和算法
+var ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+
+// ObjectChecksum 对象内容的校验和，按对象 ETag 缓存，对象被覆盖后失效
+type ObjectChecksum struct {
+	Bucket     string    `json:"bucket"`
+	Key        string    `json:"key"`
+	ETag       string    `json:"etag"`      // 计算时对象的 ETag
+	Algorithm  string    `json:"algorithm"` // md5/sha256/crc32c
+	Value      string    `json:"value"`     // 十六进制摘要
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// newChecksumHash 创建算法对应的哈希
+func newChecksumHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	}
+	return nil, ErrUnsupportedChecksum
+}
+
+// NormalizeChecksumAlgorithm 规范化算法名（如 SHA-256 → sha256），不支持时返回错误
+func NormalizeChecksumAlgorithm(algorithm string) (string, error) {
+	algorithm = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(algorithm), "-", ""))
+	if _, err := newChecksumHash(algorithm); err != nil {
+		return "", err
+	}
+	return algorithm, nil
+}
+
+// initObjectChecksumTable 初始化对象校验和缓存表
+func (m *MetadataStore) initObjectChecksumTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS object_checksums (
+		bucket TEXT NOT NULL,
+		key TEXT NOT NULL,
+		algorithm TEXT NOT NULL,
+		etag TEXT NOT NULL,
+		value TEXT NOT NULL,
+		computed_at DATETIME NOT NULL,
+		PRIMARY KEY (bucket, key, algorithm)
+	)`)
+	return err
+}
+
+// GetObjectChecksum 获取与当前 ETag 一致的缓存校验和，不存在或已失效返回 nil
+func (m *MetadataStore) GetObjectChecksum(bucket, key, etag, algorithm string) (*ObjectChecksum, error) {
+	c := ObjectChecksum{Bucket: bucket, Key: key, ETag: etag, Algorithm: algorithm}
+	err := m.db.QueryRow(`
+		SELECT value, computed_at FROM object_checksums
+		WHERE bucket = ? AND key = ? AND algorithm = ? AND etag = ?
+	`, bucket, key, algorithm, etag).Scan(&c.Value, &c.ComputedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// SaveObjectChecksum 保存对象校验和，覆盖同算法的旧记录
+func (m *MetadataStore) SaveObjectChecksum(c *ObjectChecksum) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec(`
+			INSERT OR REPLACE INTO object_checksums (bucket, key, algorithm, etag, value, computed_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, c.Bucket, c.Key, c.Algorithm, c.ETag, c.Value, c.ComputedAt)
+		return err
+	})
+}
+
+// staleChecksumsWhere 源对象已删除或已被覆盖的校验和记录
+const staleChecksumsWhere = `NOT EXISTS (
+	SELECT 1 FROM objects o
+	WHERE o.bucket = object_checksums.bucket AND o.key = object_checksums.key AND o.etag = object_checksums.etag
+)`
+
+// CountStaleObjectChecksums 统计已失效的校验和记录
+func (m *MetadataStore) CountStaleObjectChecksums() (int, error) {
+	var count int
+	err := m.db.QueryRow("SELECT COUNT(*) FROM object_checksums WHERE " + staleChecksumsWhere).Scan(&count)
+	return count, err
+}
+
+// DeleteStaleObjectChecksums 删除已失效的校验和记录
+func (m *MetadataStore) DeleteStaleObjectChecksums() error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("DELETE FROM object_checksums WHERE " + staleChecksumsWhere)
+		return err
+	})
+}
+
+// ComputeObjectChecksum 流式读取对象内容计算校验和并缓存，加密对象按明文计算
+// 已有与当前 ETag 一致的缓存且未要求刷新时直接返回，第二个返回值表示是否命中缓存
+func (m *MetadataStore) ComputeObjectChecksum(filestore *FileStore, obj *Object, algorithm string, refresh bool) (*ObjectChecksum, bool, error) {
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return nil, false, err
+	}
+	if !refresh {
+		cached, err := m.GetObjectChecksum(obj.Bucket, obj.Key, obj.ETag, algorithm)
+		if err != nil || cached != nil {
+			return cached, cached != nil, err
+		}
+	}
+
+	sum, err := hashObjectWith(filestore, obj, h, make([]byte, checksumBufferSize))
+	if err != nil {
+		return nil, false, err
+	}
+	c := &ObjectChecksum{
+		Bucket:     obj.Bucket,
+		Key:        obj.Key,
+		ETag:       obj.ETag,
+		Algorithm:  algorithm,
+		Value:      hex.EncodeToString(sum),
+		ComputedAt: time.Now().UTC(),
+	}
+	if err := m.SaveObjectChecksum(c); err != nil {
+		return nil, false, err
+	}
+	return c, false, nil
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// 内容类型限制模式
+const (
+	ContentTypeModeNone  = ""      // 不限制
+	ContentTypeModeAllow = "allow" // 仅允许列表中的类型
+	ContentTypeModeBlock = "block" // 禁止列表中的类型
+)
+
+// ErrContentTypeNotAllowed 内容类型不被桶策略允许
+var ErrContentTypeNotAllowed = errors.New("content type not allowed")
+
+// sniffLen 内容嗅探读取的字节数（与 http.DetectContentType 一致）
+const sniffLen = 512
+
+// IsValidContentTypeMode 检查内容类型限制模式是否有效
+func IsValidContentTypeMode(mode string) bool {
+	switch mode {
+	case ContentTypeModeNone, ContentTypeModeAllow, ContentTypeModeBlock:
+		return true
+	}
+	return false
+}
+
+// ParseContentTypePatterns 解析逗号分隔的类型模式，统一小写并去重
+func ParseContentTypePatterns(s string) []string {
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, p := range strings.Split(s, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// matchContentType 判断类型是否匹配模式，支持 type/* 通配
+func matchContentType(pattern, contentType string) bool {
+	if pattern == "*" || pattern == "*/*" {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(contentType, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == contentType
+}
+
+// normalizeContentType 去除参数部分（如 charset）并转为小写
+func normalizeContentType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// AllowsContentType 判断桶策略是否允许该内容类型
+func (b *Bucket) AllowsContentType(contentType string) bool {
+	if b == nil || b.ContentTypeMode == ContentTypeModeNone {
+		return true
+	}
+	contentType = normalizeContentType(contentType)
+	matched := false
+	for _, p := range ParseContentTypePatterns(b.ContentTypes) {
+		if matchContentType(p, contentType) {
+			matched = true
+			break
+		}
+	}
+	if b.ContentTypeMode == ContentTypeModeAllow {
+		return matched
+	}
+	return !matched
+}
+
+// CheckContentType 按桶策略校验声明的内容类型，开启嗅探时同时校验实际内容
+// 返回的 Reader 包含已读取的嗅探数据，调用方应使用它替代原 body
+// 嗅探结果为 application/octet-stream（无法识别）时仅以声明类型为准
+func (b *Bucket) CheckContentType(declared string, body io.Reader) (io.Reader, error) {
+	if b == nil || b.ContentTypeMode == ContentTypeModeNone {
+		return body, nil
+	}
+	if !b.AllowsContentType(declared) {
+		return body, ErrContentTypeNotAllowed
+	}
+	if !b.ContentTypeSniff {
+		return body, nil
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return body, err
+	}
+	if n == 0 {
+		return body, nil
+	}
+	head = head[:n]
+	reader := io.MultiReader(bytes.NewReader(head), body)
+
+	sniffed := http.DetectContentType(head)
+	if normalizeContentType(sniffed) != "application/octet-stream" && !b.AllowsContentType(sniffed) {
+		return reader, ErrContentTypeNotAllowed
+	}
+	return reader, nil
+}
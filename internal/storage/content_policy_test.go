@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestDeniesKey 测试桶保留键匹配
+func TestDeniesKey(t *testing.T) {
+	b := &Bucket{KeyDenylist: "index.html\n private/* \n*.bak\nindex.html"}
+	if got := ParseKeyPatterns(b.KeyDenylist); len(got) != 3 {
+		t.Fatalf("解析去重错误: %v", got)
+	}
+
+	tests := []struct {
+		key  string
+		deny bool
+	}{
+		{"index.html", true},
+		{"docs/index.html", false},
+		{"private/a.txt", true},
+		{"private/sub/a.txt", false},
+		{"old.bak", true},
+		{"readme.md", false},
+	}
+	for _, tc := range tests {
+		if got := b.DeniesKey(tc.key); got != tc.deny {
+			t.Errorf("DeniesKey(%q) = %v, want %v", tc.key, got, tc.deny)
+		}
+	}
+
+	var nilBucket *Bucket
+	if nilBucket.DeniesKey("index.html") || (&Bucket{}).DeniesKey("index.html") {
+		t.Error("未配置时不应拒绝")
+	}
+	if IsValidKeyPattern("[abc") {
+		t.Error("非法 glob 应被识别")
+	}
+}
+
+// TestRewriteKey 测试对象键前缀改写
+func TestRewriteKey(t *testing.T) {
+	b := &Bucket{PrefixRewrites: []PrefixRewrite{
+		{From: "v1/", To: "legacy/"},
+		{From: "v1/img/", To: "images/"},
+		{From: "old-", To: ""},
+	}}
+	if err := ValidatePrefixRewrites(b.PrefixRewrites); err != nil {
+		t.Fatalf("合法规则校验失败: %v", err)
+	}
+	tests := map[string]string{
+		"v1/a.txt":     "legacy/a.txt",
+		"v1/img/x.png": "images/x.png", // 最长前缀优先
+		"old-readme":   "readme",
+		"legacy/a.txt": "legacy/a.txt",
+		"v2/a.txt":     "v2/a.txt",
+	}
+	for key, want := range tests {
+		if got := b.RewriteKey(key); got != want {
+			t.Errorf("RewriteKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+	var nilBucket *Bucket
+	if nilBucket.RewriteKey("v1/a") != "v1/a" {
+		t.Error("未配置时不应改写")
+	}
+
+	for _, rules := range [][]PrefixRewrite{
+		{{From: "", To: "a/"}},
+		{{From: "/v1/", To: "a/"}},
+		{{From: "v1/", To: "a/"}, {From: "v1/", To: "b/"}},
+		{{From: "v1/", To: "v1/sub/"}},
+		{{From: "v1/", To: "v2/"}, {From: "v2/", To: "v3/"}},
+	} {
+		if err := ValidatePrefixRewrites(rules); err == nil {
+			t.Errorf("非法规则应被拒绝: %+v", rules)
+		}
+	}
+}
+
+// TestAllowsMethod 测试桶允许的 HTTP 方法
+func TestAllowsMethod(t *testing.T) {
+	methods, err := ParseAllowedMethods(" delete, get ,GET")
+	if err != nil || strings.Join(methods, ",") != "GET,HEAD,DELETE" {
+		t.Fatalf("解析错误（GET 应包含 HEAD）: %v, %v", methods, err)
+	}
+	if _, err := ParseAllowedMethods("GET,PATCH"); err == nil {
+		t.Error("不支持的方法应返回错误")
+	}
+
+	b := &Bucket{AllowedMethods: "GET,HEAD"}
+	for method, allow := range map[string]bool{
+		http.MethodGet:     true,
+		http.MethodHead:    true,
+		http.MethodOptions: true,
+		http.MethodPut:     false,
+		http.MethodDelete:  false,
+		http.MethodPost:    false,
+	} {
+		if got := b.AllowsMethod(method); got != allow {
+			t.Errorf("AllowsMethod(%s) = %v, want %v", method, got, allow)
+		}
+	}
+
+	var nilBucket *Bucket
+	if !nilBucket.AllowsMethod(http.MethodDelete) || !(&Bucket{}).AllowsMethod(http.MethodDelete) {
+		t.Error("未配置时应允许所有方法")
+	}
+}
+
+// TestAllowsContentType 测试内容类型匹配规则
+func TestAllowsContentType(t *testing.T) {
+	allow := &Bucket{ContentTypeMode: ContentTypeModeAllow, ContentTypes: "image/*, application/pdf"}
+	block := &Bucket{ContentTypeMode: ContentTypeModeBlock, ContentTypes: "text/html"}
+	none := &Bucket{}
+
+	testCases := []struct {
+		name        string
+		bucket      *Bucket
+		contentType string
+		expected    bool
+	}{
+		{"不限制", none, "text/html", true},
+		{"nil桶不限制", nil, "text/html", true},
+		{"通配匹配", allow, "image/png", true},
+		{"精确匹配", allow, "application/pdf", true},
+		{"大小写与参数", allow, "Image/JPEG; q=1", true},
+		{"不在允许列表", allow, "text/plain", false},
+		{"通配不误匹配", allow, "imagex/png", false},
+		{"禁止列表命中", block, "text/html; charset=utf-8", false},
+		{"禁止列表未命中", block, "text/plain", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.bucket.AllowsContentType(tc.contentType); got != tc.expected {
+				t.Errorf("AllowsContentType(%q) = %v, want %v", tc.contentType, got, tc.expected)
+			}
+		})
+	}
+}
+
+// TestCheckContentTypeSniff 测试内容嗅探
+func TestCheckContentTypeSniff(t *testing.T) {
+	b := &Bucket{ContentTypeMode: ContentTypeModeBlock, ContentTypes: "text/html", ContentTypeSniff: true}
+
+	t.Run("嗅探出禁止类型", func(t *testing.T) {
+		_, err := b.CheckContentType("image/png", strings.NewReader("<html><body></body></html>"))
+		if err != ErrContentTypeNotAllowed {
+			t.Errorf("应拒绝伪装的HTML: %v", err)
+		}
+	})
+
+	t.Run("嗅探后保留完整内容", func(t *testing.T) {
+		content := strings.Repeat("a", 2000)
+		r, err := b.CheckContentType("text/plain", strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("不应拒绝: %v", err)
+		}
+		data, _ := io.ReadAll(r)
+		if string(data) != content {
+			t.Errorf("内容长度不一致: %d", len(data))
+		}
+	})
+
+	t.Run("无法识别的内容以声明类型为准", func(t *testing.T) {
+		allow := &Bucket{ContentTypeMode: ContentTypeModeAllow, ContentTypes: "application/zip", ContentTypeSniff: true}
+		if _, err := allow.CheckContentType("application/zip", strings.NewReader("\x00\x01\x02binary")); err != nil {
+			t.Errorf("不应拒绝: %v", err)
+		}
+	})
+}
+
+// TestUpdateBucketContentTypes 测试内容类型限制持久化
+func TestUpdateBucketContentTypes(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	store.CreateBucket("ct-bucket")
+	if err := store.UpdateBucketContentTypes("ct-bucket", ContentTypeModeAllow, "image/*", true); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+
+	b, err := store.GetBucket("ct-bucket")
+	if err != nil || b == nil {
+		t.Fatalf("获取桶失败: %v", err)
+	}
+	if b.ContentTypeMode != ContentTypeModeAllow || b.ContentTypes != "image/*" || !b.ContentTypeSniff {
+		t.Errorf("配置不一致: %+v", b)
+	}
+
+	buckets, _ := store.ListBuckets()
+	if len(buckets) != 1 || buckets[0].ContentTypes != "image/*" {
+		t.Errorf("列表中配置不一致: %+v", buckets)
+	}
+}
+
+// TestBucketPolicyEvaluate 测试桶策略解析与判定
+func TestBucketPolicyEvaluate(t *testing.T) {
+	policy, err := ParseBucketPolicy("photos", []byte(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::photos/public/*"},
+		{"Effect":"Allow","Principal":{"AWS":"KEY1"},"Action":["s3:PutObject","s3:ListBucket"],"Resource":["arn:aws:s3:::photos","arn:aws:s3:::photos/*"]},
+		{"Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::photos/public/secret*"}
+	]}`))
+	if err != nil {
+		t.Fatalf("解析策略失败: %v", err)
+	}
+	b := &Bucket{Name: "photos", Policy: policy}
+
+	tests := []struct {
+		principal, action, key string
+		want                   PolicyDecision
+	}{
+		{"", PolicyActionGetObject, "public/a.jpg", PolicyAllow},
+		{"", PolicyActionGetObject, "private/a.jpg", PolicyNone},
+		{"", PolicyActionPutObject, "public/a.jpg", PolicyNone},
+		{"KEY1", PolicyActionGetObject, "public/a.jpg", PolicyAllow},
+		{"KEY1", PolicyActionPutObject, "any/a.jpg", PolicyAllow},
+		{"KEY1", PolicyActionListBucket, "", PolicyAllow},
+		{"KEY1", PolicyActionGetObject, "private/a.jpg", PolicyNone},
+		{"KEY2", PolicyActionPutObject, "any/a.jpg", PolicyNone},
+		{"KEY1", PolicyActionPutObject, "public/secret.jpg", PolicyDeny}, // Deny 优先
+		{"", PolicyActionGetObject, "public/secret/x", PolicyDeny},
+		{"KEY1", "", "any/a.jpg", PolicyNone},
+	}
+	for _, tc := range tests {
+		if got := b.PolicyDecision(tc.principal, tc.action, tc.key); got != tc.want {
+			t.Errorf("PolicyDecision(%q, %q, %q) = %v, want %v", tc.principal, tc.action, tc.key, got, tc.want)
+		}
+	}
+
+	// 公开开关作为匿名读取的简写，Deny 仍然优先
+	if !b.AllowsAnonymousRead(PolicyActionGetObject, "public/a.jpg") || b.AllowsAnonymousRead(PolicyActionGetObject, "private/a.jpg") {
+		t.Error("私有桶只允许策略放行的前缀")
+	}
+	b.IsPublic = true
+	if !b.AllowsAnonymousRead(PolicyActionGetObject, "private/a.jpg") || b.AllowsAnonymousRead(PolicyActionGetObject, "public/secret.jpg") {
+		t.Error("公有桶未命中策略时允许读取，命中 Deny 时拒绝")
+	}
+	var nilBucket *Bucket
+	if nilBucket.PolicyDecision("", PolicyActionGetObject, "a") != PolicyNone || nilBucket.AllowsAnonymousRead(PolicyActionGetObject, "a") {
+		t.Error("不存在的桶不应放行")
+	}
+
+	invalid := map[string]string{
+		"空语句":    `{"Statement":[]}`,
+		"未知效果":   `{"Statement":[{"Effect":"Maybe","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::photos/*"}]}`,
+		"缺少主体":   `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::photos/*"}]}`,
+		"不支持的操作": `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObjectAcl","Resource":"arn:aws:s3:::photos/*"}]}`,
+		"其他桶":    `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::photos2/*"}]}`,
+		"中间通配符":  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::photos/*.jpg"}]}`,
+		"错误版本":   `{"Version":"2020-01-01","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::photos/*"}]}`,
+		"超出大小":   `{"Statement":[{"Sid":"` + strings.Repeat("x", MaxBucketPolicySize) + `"}]}`,
+	}
+	for name, doc := range invalid {
+		if _, err := ParseBucketPolicy("photos", []byte(doc)); err == nil {
+			t.Errorf("%s 应校验失败", name)
+		}
+	}
+}
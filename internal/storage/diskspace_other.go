@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package storage
+
+import (
+	"errors"
+	"syscall"
+)
+
+// freeSpace 当前平台不支持查询可用空间
+func freeSpace(path string) (int64, error) {
+	return 0, ErrFreeSpaceUnsupported
+}
+
+// isNoSpace 判断错误是否为磁盘空间不足
+func isNoSpace(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
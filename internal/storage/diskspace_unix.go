@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd
+
+package storage
+
+import (
+	"errors"
+	"syscall"
+)
+
+// freeSpace 返回 path 所在文件系统对非特权用户可用的字节数
+func freeSpace(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return int64(uint64(st.Bavail) * uint64(st.Bsize)), nil
+}
+
+// isNoSpace 判断错误是否为磁盘空间不足
+func isNoSpace(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT)
+}
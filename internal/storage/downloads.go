@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"time"
+)
+
+// ObjectDownloadStat 对象匿名下载统计
+type ObjectDownloadStat struct {
+	Bucket         string    `json:"bucket"`
+	Key            string    `json:"key"`
+	Downloads      int64     `json:"downloads"`        // 完整下载次数（200 响应）
+	Bytes          int64     `json:"bytes"`            // 累计传出字节数（含 Range 请求）
+	LastDownloadAt time.Time `json:"last_download_at"` // 最近一次下载时间
+}
+
+// initDownloadTables 初始化匿名下载统计表
+func (m *MetadataStore) initDownloadTables() error {
+	if _, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS object_downloads (
+		bucket TEXT NOT NULL,
+		key TEXT NOT NULL,
+		downloads INTEGER NOT NULL DEFAULT 0,
+		bytes INTEGER NOT NULL DEFAULT 0,
+		last_download_at DATETIME NOT NULL,
+		PRIMARY KEY (bucket, key)
+	)`); err != nil {
+		return err
+	}
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS anonymous_bandwidth (
+		day TEXT PRIMARY KEY,
+		bytes INTEGER NOT NULL DEFAULT 0
+	)`)
+	return err
+}
+
+// bandwidthDay 匿名流量按 UTC 自然日统计
+func bandwidthDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// RecordAnonymousDownload 记录一次匿名请求的传出流量，counted 为 true 时同时累加对象下载次数
+func (m *MetadataStore) RecordAnonymousDownload(bucket, key string, bytes int64, counted bool, now time.Time) error {
+	return m.withWriteLock(func() error {
+		tx, err := m.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if bytes > 0 {
+			if _, err := tx.Exec(`INSERT INTO anonymous_bandwidth (day, bytes) VALUES (?, ?)
+				ON CONFLICT(day) DO UPDATE SET bytes = bytes + excluded.bytes`,
+				bandwidthDay(now), bytes); err != nil {
+				return err
+			}
+		}
+		if key != "" && (counted || bytes > 0) {
+			downloads := 0
+			if counted {
+				downloads = 1
+			}
+			if _, err := tx.Exec(`INSERT INTO object_downloads (bucket, key, downloads, bytes, last_download_at)
+				VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT(bucket, key) DO UPDATE SET
+					downloads = downloads + excluded.downloads,
+					bytes = bytes + excluded.bytes,
+					last_download_at = excluded.last_download_at`,
+				bucket, key, downloads, bytes, now.UTC()); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+}
+
+// AnonymousBytesOn 返回指定时间所在 UTC 自然日的匿名传出字节数
+func (m *MetadataStore) AnonymousBytesOn(now time.Time) (int64, error) {
+	var bytes int64
+	err := m.db.QueryRow("SELECT COALESCE(SUM(bytes), 0) FROM anonymous_bandwidth WHERE day = ?", bandwidthDay(now)).Scan(&bytes)
+	return bytes, err
+}
+
+// ListObjectDownloads 按下载次数降序列出对象下载统计，bucket 为空表示所有桶
+func (m *MetadataStore) ListObjectDownloads(bucket string, limit int) ([]ObjectDownloadStat, error) {
+	query := "SELECT bucket, key, downloads, bytes, last_download_at FROM object_downloads"
+	var args []interface{}
+	if bucket != "" {
+		query += " WHERE bucket = ?"
+		args = append(args, bucket)
+	}
+	query += " ORDER BY downloads DESC, bytes DESC, bucket, key LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]ObjectDownloadStat, 0)
+	for rows.Next() {
+		var s ObjectDownloadStat
+		if err := rows.Scan(&s.Bucket, &s.Key, &s.Downloads, &s.Bytes, &s.LastDownloadAt); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// etagRederiveCursorKey 续扫游标在系统配置表中的键，格式为 bucket/key
+const etagRederiveCursorKey = "storage.etag_rederive_cursor"
+
+// ETag 重算任务参数
+const (
+	etagRederivePageSize       = 500  // 每页读取的对象数，每处理完一页保存一次游标
+	maxEtagRederiveCorrections = 1000 // 结果中最多列出的修正记录数
+)
+
+// ErrEtagRederiveRunning 已有 ETag 重算任务在运行
+var ErrEtagRederiveRunning = errors.New("an etag rederive job is already running")
+
+// EtagRederiveOptions ETag 重算选项
+type EtagRederiveOptions struct {
+	Fix        bool    // 是否把元数据中的 ETag 改为重算值，false 时仅报告
+	RateLimit  float64 // 每秒最多校验的对象数，0 表示不限
+	BufferSize int     // 读缓冲大小（字节）
+	Restart    bool    // 忽略已保存的游标，从头开始
+}
+
+// EtagCorrection 一条 ETag 不一致记录
+type EtagCorrection struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Stored string `json:"stored"` // 元数据中的 ETag
+	Actual string `json:"actual"` // 按磁盘文件重算的 ETag
+	Fixed  bool   `json:"fixed"`  // 是否已更新元数据
+}
+
+// EtagRederiveStatus ETag 重算任务状态快照
+type EtagRederiveStatus struct {
+	Running     bool             `json:"running"`
+	Fix         bool             `json:"fix"`
+	Cursor      string           `json:"cursor"`      // 已处理到的位置（bucket/key），中断后从此处继续
+	Done        bool             `json:"done"`        // 是否已遍历全部对象
+	Cancelled   bool             `json:"cancelled"`   // 是否被取消或因服务关闭中断
+	Scanned     int              `json:"scanned"`     // 已校验对象数
+	Mismatched  int              `json:"mismatched"`  // ETag 不一致的对象数
+	Fixed       int              `json:"fixed"`       // 已修正的对象数
+	Missing     int              `json:"missing"`     // 磁盘文件缺失的对象数
+	Failed      int              `json:"failed"`      // 读取或更新失败的对象数
+	Corrections []EtagCorrection `json:"corrections"` // 不一致记录（最多 1000 条）
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// EtagRederiver 后台按磁盘文件重算所有对象 ETag，同一时间只运行一个任务
+type EtagRederiver struct {
+	metadata  *MetadataStore
+	filestore *FileStore // 读取加密对象
+
+	mu     sync.Mutex
+	status EtagRederiveStatus
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewEtagRederiver 创建 ETag 重算任务管理器
+func NewEtagRederiver(metadata *MetadataStore, filestore *FileStore) *EtagRederiver {
+	return &EtagRederiver{metadata: metadata, filestore: filestore, status: EtagRederiveStatus{Corrections: make([]EtagCorrection, 0)}}
+}
+
+// Start 在后台启动重算任务，未指定 Restart 时从已保存的游标继续
+func (e *EtagRederiver) Start(opts EtagRederiveOptions) error {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultIntegrityBufferSize
+	}
+	if opts.BufferSize < MinIntegrityBufferSize {
+		opts.BufferSize = MinIntegrityBufferSize
+	}
+	if opts.BufferSize > MaxIntegrityBufferSize {
+		opts.BufferSize = MaxIntegrityBufferSize
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.status.Running {
+		return ErrEtagRederiveRunning
+	}
+
+	cursor := ""
+	if !opts.Restart {
+		saved, err := e.metadata.GetSetting(etagRederiveCursorKey)
+		if err != nil {
+			return err
+		}
+		cursor = saved
+	}
+
+	now := time.Now()
+	e.status = EtagRederiveStatus{
+		Running:     true,
+		Fix:         opts.Fix,
+		Cursor:      cursor,
+		Corrections: make([]EtagCorrection, 0),
+		StartedAt:   &now,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+	go e.run(ctx, opts, e.done)
+	return nil
+}
+
+// Cancel 取消正在运行的任务，已处理的位置保留在游标中；没有任务运行时返回 false
+func (e *EtagRederiver) Cancel() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.status.Running {
+		return false
+	}
+	e.cancel()
+	return true
+}
+
+// Wait 等待当前任务退出
+func (e *EtagRederiver) Wait(ctx context.Context) error {
+	e.mu.Lock()
+	done := e.done
+	e.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("etag rederive job still running: %w", ctx.Err())
+	}
+}
+
+// Shutdown 服务关闭时取消任务并等待退出，下次启动后可从游标继续
+func (e *EtagRederiver) Shutdown(ctx context.Context) error {
+	e.Cancel()
+	return e.Wait(ctx)
+}
+
+// Status 返回当前任务状态
+func (e *EtagRederiver) Status() EtagRederiveStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	status := e.status
+	status.Corrections = append([]EtagCorrection(nil), e.status.Corrections...)
+	return status
+}
+
+// run 按 (bucket, key) 顺序分页遍历对象，逐个重算 ETag
+func (e *EtagRederiver) run(ctx context.Context, opts EtagRederiveOptions, done chan struct{}) {
+	defer close(done)
+
+	e.mu.Lock()
+	cursor := e.status.Cursor
+	e.mu.Unlock()
+
+	// 限速：按固定间隔校验
+	var tick <-chan time.Time
+	if opts.RateLimit > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.RateLimit))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	buf := make([]byte, opts.BufferSize)
+	var runErr error
+	completed := false
+	first := true
+	for ctx.Err() == nil && runErr == nil && !completed {
+		bucket, key, _ := strings.Cut(cursor, "/")
+		objects, err := e.metadata.listObjectsAfter(bucket, key, etagRederivePageSize)
+		if err != nil {
+			runErr = err
+			break
+		}
+		if len(objects) == 0 {
+			completed = true
+			break
+		}
+		for i := range objects {
+			if tick != nil && !first {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+				}
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			first = false
+			e.rederiveObject(&objects[i], opts.Fix, buf)
+			cursor = objects[i].Bucket + "/" + objects[i].Key
+			e.mu.Lock()
+			e.status.Cursor = cursor
+			e.mu.Unlock()
+		}
+		if err := e.metadata.SetSetting(etagRederiveCursorKey, cursor); err != nil {
+			runErr = err
+		}
+	}
+
+	// 遍历完成后清空游标，下次从头开始
+	if completed {
+		if err := e.metadata.SetSetting(etagRederiveCursorKey, ""); err != nil {
+			runErr = err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	e.status.Running = false
+	e.status.Done = completed
+	e.status.Cancelled = !completed && runErr == nil
+	e.status.FinishedAt = &now
+	if completed {
+		e.status.Cursor = ""
+	}
+	if runErr != nil {
+		e.status.Error = runErr.Error()
+	}
+}
+
+// rederiveObject 重算单个对象的 ETag，不一致时记录并按需更新元数据
+func (e *EtagRederiver) rederiveObject(obj *Object, fix bool, buf []byte) {
+	sum, err := hashObjectWith(e.filestore, obj, md5.New(), buf)
+	actual := hex.EncodeToString(sum)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.status.Scanned++
+	if err != nil {
+		if os.IsNotExist(err) {
+			e.status.Missing++
+		} else {
+			e.status.Failed++
+		}
+		return
+	}
+	if actual == obj.ETag {
+		return
+	}
+
+	e.status.Mismatched++
+	correction := EtagCorrection{Bucket: obj.Bucket, Key: obj.Key, Stored: obj.ETag, Actual: actual}
+	if fix {
+		// 只在对象未被并发覆盖时更新
+		if updated, err := e.metadata.replaceObjectEtag(obj, actual); err != nil || !updated {
+			e.status.Failed++
+		} else {
+			correction.Fixed = true
+			e.status.Fixed++
+		}
+	}
+	if len(e.status.Corrections) < maxEtagRederiveCorrections {
+		e.status.Corrections = append(e.status.Corrections, correction)
+	}
+}
+
+// listObjectsAfter 按 (bucket, key) 顺序返回位于给定位置之后的对象
+func (m *MetadataStore) listObjectsAfter(bucket, key string, limit int) ([]Object, error) {
+	rows, err := m.db.Query(`
+		SELECT bucket, key, size, etag, content_type, last_modified, storage_path, COALESCE(encryption_nonce, '')
+		FROM objects
+		WHERE bucket > ? OR (bucket = ? AND key > ?)
+		ORDER BY bucket, key
+		LIMIT ?
+	`, bucket, bucket, key, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []Object
+	for rows.Next() {
+		var obj Object
+		if err := rows.Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag,
+			&obj.ContentType, &obj.LastModified, &obj.StoragePath, &obj.EncryptionNonce); err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, rows.Err()
+}
+
+// replaceObjectEtag 在对象的 ETag 和存储路径仍与 obj 一致时更新 ETag，返回是否更新
+func (m *MetadataStore) replaceObjectEtag(obj *Object, etag string) (bool, error) {
+	var n int64
+	err := m.withWriteLock(func() error {
+		res, err := m.db.Exec(`
+			UPDATE objects
+			SET etag = ?
+			WHERE bucket = ? AND key = ? AND etag = ? AND storage_path = ?
+		`, etag, obj.Bucket, obj.Key, obj.ETag, obj.StoragePath)
+		if err != nil {
+			return err
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	return n > 0, err
+}
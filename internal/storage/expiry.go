@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"os"
+	"time"
+)
+
+// DefaultExpirySweepInterval 过期对象清理间隔
+const DefaultExpirySweepInterval = time.Minute
+
+// expirySweepBatch 每轮清理的最大对象数
+const expirySweepBatch = 500
+
+// Expired 对象是否已过自定义过期时间
+func (o *Object) Expired(now time.Time) bool {
+	return o != nil && o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// expiresAtUnix 过期时间转为 Unix 秒，未设置为 0
+func expiresAtUnix(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// expiresAtTime Unix 秒转为过期时间，0 表示未设置
+func expiresAtTime(sec int64) *time.Time {
+	if sec <= 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0).UTC()
+	return &t
+}
+
+// ListExpiredObjects 列出已过期但尚未清理的对象
+func (m *MetadataStore) ListExpiredObjects(now time.Time, limit int) ([]Object, error) {
+	rows, err := m.db.Query(`
+		SELECT bucket, key, size, etag, storage_path, expires_at
+		FROM objects WHERE expires_at > 0 AND expires_at <= ?
+		ORDER BY expires_at LIMIT ?`,
+		now.Unix(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []Object
+	for rows.Next() {
+		var obj Object
+		var expiresAt int64
+		if err := rows.Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag, &obj.StoragePath, &expiresAt); err != nil {
+			return nil, err
+		}
+		obj.ExpiresAt = expiresAtTime(expiresAt)
+		objects = append(objects, obj)
+	}
+	return objects, rows.Err()
+}
+
+// DeleteExpiredObject 删除仍处于过期状态的对象元数据，期间被覆盖（过期时间已变）时不删除
+func (m *MetadataStore) DeleteExpiredObject(obj *Object, now time.Time) (bool, error) {
+	var deleted bool
+	err := m.withWriteLock(func() error {
+		res, err := m.db.Exec(
+			"DELETE FROM objects WHERE bucket = ? AND key = ? AND etag = ? AND expires_at > 0 AND expires_at <= ?",
+			obj.Bucket, obj.Key, obj.ETag, now.Unix(),
+		)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		deleted = n > 0
+		return err
+	})
+	return deleted, err
+}
+
+// SweepExpiredObjects 删除已过期的对象（元数据与文件），返回实际删除的对象
+func SweepExpiredObjects(m *MetadataStore, f *FileStore, now time.Time) ([]Object, error) {
+	expired, err := m.ListExpiredObjects(now, expirySweepBatch)
+	if err != nil {
+		return nil, err
+	}
+	var deleted []Object
+	for i := range expired {
+		obj := &expired[i]
+		ok, err := m.DeleteExpiredObject(obj, now)
+		if err != nil {
+			return deleted, err
+		}
+		if !ok {
+			continue
+		}
+		if err := f.DeleteObject(obj.StoragePath); err != nil && !os.IsNotExist(err) {
+			return deleted, err
+		}
+		deleted = append(deleted, *obj)
+	}
+	return deleted, nil
+}
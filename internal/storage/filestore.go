@@ -9,17 +9,40 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // 安全错误定义
 var (
 	ErrInvalidPath = errors.New("invalid path: path traversal detected")
 	ErrInvalidKey  = errors.New("invalid key: contains forbidden characters")
+	// ErrObjectTooLarge 写入内容超过大小上限
+	ErrObjectTooLarge = errors.New("object exceeds maximum allowed size")
+	// ErrInsufficientStorage 写入时数据盘空间不足，临时文件已清理
+	ErrInsufficientStorage = errors.New("insufficient storage space")
+	// ErrFreeSpaceUnsupported 当前平台不支持查询可用空间
+	ErrFreeSpaceUnsupported = errors.New("free space query not supported on this platform")
 )
 
+// NoSpaceWindow 最近一次写入因空间不足失败后，健康检查报告异常的时长
+const NoSpaceWindow = time.Minute
+
+// 对象文件路径布局
+const (
+	PathLayoutPrefix = "prefix" // bucket/<hash前2位>/key（默认）
+	PathLayoutHashed = "hashed" // bucket/ab/cd/<完整hash>，适合单桶海量对象
+)
+
+// ErrInvalidPathLayout 未知的路径布局
+var ErrInvalidPathLayout = errors.New("invalid path layout")
+
 // FileStore 文件系统存储
 type FileStore struct {
-	basePath string
+	basePath  string
+	layout    string        // 新对象的路径布局，已有对象沿用其 StoragePath
+	noSpaceAt atomic.Int64  // 最近一次空间不足写入失败的时间（UnixNano），写入成功后清零
+	cipher    *objectCipher // 对象静态加密的主密钥，为空表示不加密，见 SetEncryptionPassphrase
 }
 
 // NewFileStore 创建文件存储
@@ -32,7 +55,143 @@ func NewFileStore(basePath string) (*FileStore, error) {
 	if err := os.MkdirAll(absPath, 0755); err != nil {
 		return nil, err
 	}
-	return &FileStore{basePath: absPath}, nil
+	return &FileStore{basePath: absPath, layout: PathLayoutPrefix}, nil
+}
+
+// FreeSpace 返回数据目录所在文件系统的可用字节数
+func (f *FileStore) FreeSpace() (int64, error) {
+	return freeSpace(f.basePath)
+}
+
+// RecentNoSpace 返回 window 内最近一次因空间不足写入失败的时间，没有则返回 false
+func (f *FileStore) RecentNoSpace(window time.Duration) (time.Time, bool) {
+	at := f.noSpaceAt.Load()
+	if at == 0 {
+		return time.Time{}, false
+	}
+	t := time.Unix(0, at)
+	return t, time.Since(t) < window
+}
+
+// writeResult 记录写入结果，空间不足时转换为 ErrInsufficientStorage
+func (f *FileStore) writeResult(err error) error {
+	if err == nil {
+		f.noSpaceAt.Store(0)
+		return nil
+	}
+	if isNoSpace(err) {
+		f.noSpaceAt.Store(time.Now().UnixNano())
+		return fmt.Errorf("%w: %v", ErrInsufficientStorage, err)
+	}
+	return err
+}
+
+// writeFileAtomic 写入同目录临时文件并同步后重命名为 path，同时计算 MD5
+// 任何失败都会删除临时文件，不会破坏 path 处已有的文件
+func (f *FileStore) writeFileAtomic(path string, write func(w io.Writer) (int64, error)) (string, int64, error) {
+	staged, err := f.writeFileStaged(path, false, write)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := staged.Commit(); err != nil {
+		return "", 0, err
+	}
+	return staged.ETag, staged.Size, nil
+}
+
+// StagedFile 已写入同目录临时文件、尚未替换目标路径的文件
+// 调用方确认可以写入后 Commit，否则 Discard，二者都不会破坏目标路径上已有的文件
+type StagedFile struct {
+	Path    string // 目标路径
+	ETag    string // 明文的 MD5
+	Size    int64
+	Nonce   string // 加密保存时的 IV，需写入 Object.EncryptionNonce，明文为空
+	tmpPath string
+	cleanup func() // Commit 成功后执行
+}
+
+// Commit 将临时文件重命名为目标路径
+func (s *StagedFile) Commit() error {
+	if err := os.Rename(s.tmpPath, s.Path); err != nil {
+		os.Remove(s.tmpPath)
+		return err
+	}
+	if s.cleanup != nil {
+		s.cleanup()
+	}
+	return nil
+}
+
+// Discard 删除临时文件
+func (s *StagedFile) Discard() {
+	os.Remove(s.tmpPath)
+}
+
+// writeFileStaged 写入 path 同目录的临时文件并同步，同时计算明文的 MD5
+// encrypt 为 true 且启用了静态加密时加密保存，IV 记录在 StagedFile.Nonce
+func (f *FileStore) writeFileStaged(path string, encrypt bool, write func(w io.Writer) (int64, error)) (*StagedFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, f.writeResult(err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return nil, f.writeResult(err)
+	}
+	tmpPath := file.Name()
+
+	var out io.Writer = file
+	var nonce string
+	if encrypt && f.cipher != nil {
+		if out, nonce, err = f.cipher.encryptWriter(file); err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+			return nil, err
+		}
+	}
+	hash := md5.New()
+	written, err := write(io.MultiWriter(out, hash))
+	if err == nil {
+		// 确保数据写入磁盘
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, f.writeResult(err)
+	}
+	f.writeResult(nil)
+	return &StagedFile{Path: path, ETag: hex.EncodeToString(hash.Sum(nil)), Size: written, Nonce: nonce, tmpPath: tmpPath}, nil
+}
+
+// SetPathLayout 设置新对象的路径布局
+func (f *FileStore) SetPathLayout(layout string) error {
+	switch layout {
+	case "":
+		f.layout = PathLayoutPrefix
+	case PathLayoutPrefix, PathLayoutHashed:
+		f.layout = layout
+	default:
+		return ErrInvalidPathLayout
+	}
+	return nil
+}
+
+// PathLayout 返回当前路径布局
+func (f *FileStore) PathLayout() string {
+	return f.layout
+}
+
+// CheckWritable 检查数据目录是否可写（创建并删除临时文件）
+func (f *FileStore) CheckWritable() error {
+	tmp, err := os.CreateTemp(f.basePath, ".health-*")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+	tmp.Close()
+	return os.Remove(name)
 }
 
 // validateKey 验证key是否安全（防止路径遍历攻击）
@@ -86,10 +245,7 @@ func (f *FileStore) getPath(bucket, key string) (string, error) {
 		return "", err
 	}
 
-	// 使用 key 的 hash 前两位作为子目录，避免单目录文件过多
-	h := md5.Sum([]byte(key))
-	subdir := hex.EncodeToString(h[:1])
-	fullPath := filepath.Join(f.basePath, bucket, subdir, key)
+	fullPath := f.layoutPath(bucket, key)
 
 	// 确保最终路径在basePath内（双重验证）
 	cleanPath := filepath.Clean(fullPath)
@@ -100,6 +256,50 @@ func (f *FileStore) getPath(bucket, key string) (string, error) {
 	return cleanPath, nil
 }
 
+// versionsDir 桶目录下保存版本文件的子目录，对象文件位于 bucket/<hash前2位>/ 下，二者不会冲突
+const versionsDir = ".versions"
+
+// getVersionPath 获取版本文件路径：bucket/.versions/<版本ID前2位>/<版本ID>
+// 启用版本控制后每次写入使用独立的文件，覆盖写入不会破坏旧版本
+func (f *FileStore) getVersionPath(bucket, versionID string) (string, error) {
+	if err := validateBucket(bucket); err != nil {
+		return "", err
+	}
+	// 版本ID由服务端生成，只允许十六进制字符
+	if len(versionID) < 2 {
+		return "", ErrInvalidPath
+	}
+	for _, c := range versionID {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
+			return "", ErrInvalidPath
+		}
+	}
+	return filepath.Join(f.basePath, bucket, versionsDir, versionID[0:2], versionID), nil
+}
+
+// getObjectPath versionID 为空时返回按布局计算的对象路径，否则返回版本文件路径
+func (f *FileStore) getObjectPath(bucket, key, versionID string) (string, error) {
+	if versionID == "" {
+		return f.getPath(bucket, key)
+	}
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return f.getVersionPath(bucket, versionID)
+}
+
+// layoutPath 按路径布局计算对象文件路径（不做安全校验）
+func (f *FileStore) layoutPath(bucket, key string) string {
+	h := md5.Sum([]byte(key))
+	sum := hex.EncodeToString(h[:])
+	if f.layout == PathLayoutHashed {
+		// 两级 hash 目录，文件名为完整 hash，与 key 长度和字符无关
+		return filepath.Join(f.basePath, bucket, sum[0:2], sum[2:4], sum)
+	}
+	// 使用 key 的 hash 前两位作为子目录，避免单目录文件过多
+	return filepath.Join(f.basePath, bucket, sum[0:2], key)
+}
+
 // getPartPath 获取分片存储路径
 func (f *FileStore) getPartPath(uploadID string, partNumber int) (string, error) {
 	// 验证uploadID安全性（只允许十六进制字符）
@@ -138,39 +338,53 @@ func (f *FileStore) DeleteBucket(name string) error {
 
 // PutObject 存储对象并返回 ETag
 func (f *FileStore) PutObject(bucket, key string, reader io.Reader, size int64) (string, string, error) {
-	path, err := f.getPath(bucket, key)
-	if err != nil {
-		return "", "", err
-	}
+	path, etag, _, err := f.PutObjectStream(bucket, key, reader, 0)
+	return path, etag, err
+}
 
-	// 确保目录存在
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return "", "", err
-	}
+// PutObjectStream 流式存储对象，返回实际写入大小
+// maxSize > 0 时超过上限立即中止并清理临时文件，返回 ErrObjectTooLarge
+// 先写入同目录临时文件再重命名，失败时不会破坏已有对象；磁盘空间不足返回 ErrInsufficientStorage
+func (f *FileStore) PutObjectStream(bucket, key string, reader io.Reader, maxSize int64) (string, string, int64, error) {
+	return f.PutObjectVersionStream(bucket, key, "", reader, maxSize)
+}
 
-	file, err := os.Create(path)
+// PutObjectVersionStream 与 PutObjectStream 相同，versionID 非空时写入该版本独立的文件
+// 以上直接提交的写入总是保存明文，需要静态加密的写入使用 PutObjectStaged 并保存 StagedFile.Nonce
+func (f *FileStore) PutObjectVersionStream(bucket, key, versionID string, reader io.Reader, maxSize int64) (string, string, int64, error) {
+	staged, err := f.putObjectStaged(bucket, key, versionID, reader, maxSize, false)
 	if err != nil {
-		return "", "", err
+		return "", "", 0, err
+	}
+	if err := staged.Commit(); err != nil {
+		return "", "", 0, err
 	}
-	defer file.Close()
+	return staged.Path, staged.ETag, staged.Size, nil
+}
 
-	// 同时计算 MD5
-	hash := md5.New()
-	writer := io.MultiWriter(file, hash)
+// PutObjectStaged 流式写入对象到临时文件，由调用方 Commit 替换目标路径或 Discard
+// 用于条件写入：条件检查通过前不破坏已有对象；启用静态加密时加密保存，调用方需保存 StagedFile.Nonce
+func (f *FileStore) PutObjectStaged(bucket, key, versionID string, reader io.Reader, maxSize int64) (*StagedFile, error) {
+	return f.putObjectStaged(bucket, key, versionID, reader, maxSize, true)
+}
 
-	if _, err := io.Copy(writer, reader); err != nil {
-		os.Remove(path)
-		return "", "", err
+func (f *FileStore) putObjectStaged(bucket, key, versionID string, reader io.Reader, maxSize int64, encrypt bool) (*StagedFile, error) {
+	path, err := f.getObjectPath(bucket, key, versionID)
+	if err != nil {
+		return nil, err
 	}
 
-	// 确保数据写入磁盘
-	if err := file.Sync(); err != nil {
-		os.Remove(path)
-		return "", "", err
+	if maxSize > 0 {
+		// 多读一个字节用于判断是否超限
+		reader = io.LimitReader(reader, maxSize+1)
 	}
-
-	etag := hex.EncodeToString(hash.Sum(nil))
-	return path, etag, nil
+	return f.writeFileStaged(path, encrypt, func(w io.Writer) (int64, error) {
+		written, err := io.Copy(w, reader)
+		if err == nil && maxSize > 0 && written > maxSize {
+			err = ErrObjectTooLarge
+		}
+		return written, err
+	})
 }
 
 // GetObject 获取对象
@@ -218,6 +432,12 @@ func (f *FileStore) DeleteObject(storagePath string) error {
 
 // CopyObject 复制对象到新位置
 func (f *FileStore) CopyObject(srcStoragePath, destBucket, destKey string) (string, string, error) {
+	return f.CopyObjectVersion(srcStoragePath, destBucket, destKey, "")
+}
+
+// CopyObjectVersion 与 CopyObject 相同，versionID 非空时写入该版本独立的文件
+// 按原样复制文件内容，加密对象使用 CopyObjectStaged
+func (f *FileStore) CopyObjectVersion(srcStoragePath, destBucket, destKey, versionID string) (string, string, error) {
 	// 处理相对路径：如果不是以 basePath 开头，尝试将其转换为绝对路径
 	cleanSrcPath := filepath.Clean(srcStoragePath)
 
@@ -243,39 +463,18 @@ func (f *FileStore) CopyObject(srcStoragePath, destBucket, destKey string) (stri
 	defer srcFile.Close()
 
 	// 获取目标路径
-	destPath, err := f.getPath(destBucket, destKey)
+	destPath, err := f.getObjectPath(destBucket, destKey, versionID)
 	if err != nil {
 		return "", "", err
 	}
 
-	// 确保目标目录存在
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return "", "", err
-	}
-
-	// 创建目标文件
-	destFile, err := os.Create(destPath)
+	// 经临时文件写入，失败时保留已有的目标对象
+	etag, _, err := f.writeFileAtomic(destPath, func(w io.Writer) (int64, error) {
+		return io.Copy(w, srcFile)
+	})
 	if err != nil {
 		return "", "", err
 	}
-	defer destFile.Close()
-
-	// 同时计算 MD5
-	hash := md5.New()
-	writer := io.MultiWriter(destFile, hash)
-
-	if _, err := io.Copy(writer, srcFile); err != nil {
-		os.Remove(destPath)
-		return "", "", err
-	}
-
-	// 确保数据写入磁盘
-	if err := destFile.Sync(); err != nil {
-		os.Remove(destPath)
-		return "", "", err
-	}
-
-	etag := hex.EncodeToString(hash.Sum(nil))
 	return destPath, etag, nil
 }
 
@@ -286,84 +485,66 @@ func (f *FileStore) PutPart(uploadID string, partNumber int, reader io.Reader) (
 		return "", 0, err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return "", 0, err
-	}
-
-	file, err := os.Create(path)
-	if err != nil {
-		return "", 0, err
-	}
-	defer file.Close()
-
-	hash := md5.New()
-	writer := io.MultiWriter(file, hash)
-
-	size, err := io.Copy(writer, reader)
-	if err != nil {
-		os.Remove(path)
-		return "", 0, err
-	}
-
-	// 确保数据写入磁盘
-	if err := file.Sync(); err != nil {
-		os.Remove(path)
-		return "", 0, err
-	}
-
-	etag := hex.EncodeToString(hash.Sum(nil))
-	return etag, size, nil
+	return f.writeFileAtomic(path, func(w io.Writer) (int64, error) {
+		return io.Copy(w, reader)
+	})
 }
 
 // MergeParts 合并分片
+// 保存明文，需要静态加密时使用 MergePartsStaged
 func (f *FileStore) MergeParts(bucket, key, uploadID string, partNumbers []int) (string, int64, error) {
-	path, err := f.getPath(bucket, key)
+	staged, err := f.mergePartsStaged(bucket, key, "", uploadID, partNumbers, false)
 	if err != nil {
 		return "", 0, err
 	}
-
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+	if err := staged.Commit(); err != nil {
 		return "", 0, err
 	}
+	return staged.ETag, staged.Size, nil
+}
 
-	outFile, err := os.Create(path)
+// MergePartsStaged 将分片合并到临时文件，Commit 后替换对象文件并清理分片目录
+// versionID 非空时合并到该版本独立的文件；合并失败或 Discard 时保留已有对象和全部分片，客户端可以重试
+// 分片以明文暂存，启用静态加密时合并结果加密保存
+func (f *FileStore) MergePartsStaged(bucket, key, versionID, uploadID string, partNumbers []int) (*StagedFile, error) {
+	return f.mergePartsStaged(bucket, key, versionID, uploadID, partNumbers, true)
+}
+
+func (f *FileStore) mergePartsStaged(bucket, key, versionID, uploadID string, partNumbers []int, encrypt bool) (*StagedFile, error) {
+	path, err := f.getObjectPath(bucket, key, versionID)
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
-	defer outFile.Close()
-
-	hash := md5.New()
-	writer := io.MultiWriter(outFile, hash)
-	var totalSize int64
 
-	for _, partNum := range partNumbers {
-		partPath, err := f.getPartPath(uploadID, partNum)
-		if err != nil {
-			return "", 0, err
+	staged, err := f.writeFileStaged(path, encrypt, func(w io.Writer) (int64, error) {
+		var total int64
+		for _, partNum := range partNumbers {
+			partPath, err := f.getPartPath(uploadID, partNum)
+			if err != nil {
+				return total, err
+			}
+			partFile, err := os.Open(partPath)
+			if err != nil {
+				return total, err
+			}
+			n, err := io.Copy(w, partFile)
+			partFile.Close()
+			total += n
+			if err != nil {
+				return total, err
+			}
 		}
-		partFile, err := os.Open(partPath)
-		if err != nil {
-			return "", 0, err
-		}
-
-		n, err := io.Copy(writer, partFile)
-		partFile.Close()
-		if err != nil {
-			return "", 0, err
-		}
-		totalSize += n
+		return total, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 确保数据写入磁盘
-	if err := outFile.Sync(); err != nil {
-		return "", 0, err
+	// 提交后清理分片目录
+	staged.cleanup = func() {
+		os.RemoveAll(filepath.Join(f.basePath, ".multipart", uploadID))
 	}
-
-	// 清理分片目录
-	os.RemoveAll(filepath.Join(f.basePath, ".multipart", uploadID))
-
-	etag := hex.EncodeToString(hash.Sum(nil))
-	return etag, totalSize, nil
+	return staged, nil
 }
 
 // AbortMultipartUpload 清理分片
@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 )
 
@@ -418,6 +421,89 @@ func TestPutObject(t *testing.T) {
 	})
 }
 
+// TestPutObjectStream 测试未知长度流式写入与大小上限
+func TestPutObjectStream(t *testing.T) {
+	fs, cleanup := setupFileStore(t)
+	defer cleanup()
+
+	bucket := "stream-bucket"
+	fs.CreateBucket(bucket)
+
+	path, _, size, err := fs.PutObjectStream(bucket, "obj.bin", strings.NewReader("original"), 10)
+	if err != nil {
+		t.Fatalf("上传失败: %v", err)
+	}
+	if size != int64(len("original")) {
+		t.Errorf("实际大小错误: got %d", size)
+	}
+
+	t.Run("恰好等于上限", func(t *testing.T) {
+		_, _, size, err := fs.PutObjectStream(bucket, "exact.bin", strings.NewReader("0123456789"), 10)
+		if err != nil || size != 10 {
+			t.Errorf("等于上限应成功: size=%d, err=%v", size, err)
+		}
+	})
+
+	t.Run("超过上限中止并保留旧对象", func(t *testing.T) {
+		_, _, _, err := fs.PutObjectStream(bucket, "obj.bin", strings.NewReader("this is far too long"), 10)
+		if err != ErrObjectTooLarge {
+			t.Fatalf("期望 ErrObjectTooLarge, 实际 %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "original" {
+			t.Errorf("旧对象应保持不变: %q, %v", data, err)
+		}
+		entries, _ := os.ReadDir(filepath.Dir(path))
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".upload-") {
+				t.Errorf("临时文件未清理: %s", e.Name())
+			}
+		}
+	})
+
+	t.Run("上限为0不限制", func(t *testing.T) {
+		content := strings.Repeat("x", 4096)
+		_, _, size, err := fs.PutObjectStream(bucket, "unlimited.bin", strings.NewReader(content), 0)
+		if err != nil || size != int64(len(content)) {
+			t.Errorf("不限制时应成功: size=%d, err=%v", size, err)
+		}
+	})
+
+	t.Run("磁盘空间不足", func(t *testing.T) {
+		full := io.MultiReader(strings.NewReader("partial"), &failingReader{err: &os.PathError{Op: "write", Path: "x", Err: syscall.ENOSPC}})
+		_, _, _, err := fs.PutObjectStream(bucket, "obj.bin", full, 0)
+		if !errors.Is(err, ErrInsufficientStorage) {
+			t.Fatalf("期望 ErrInsufficientStorage, 实际 %v", err)
+		}
+		if _, recent := fs.RecentNoSpace(NoSpaceWindow); !recent {
+			t.Error("应记录最近一次空间不足")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "original" {
+			t.Errorf("旧对象应保持不变: %q, %v", data, err)
+		}
+		entries, _ := os.ReadDir(filepath.Dir(path))
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".upload-") {
+				t.Errorf("临时文件未清理: %s", e.Name())
+			}
+		}
+
+		// 写入成功后清除空间不足标记
+		if _, _, _, err := fs.PutObjectStream(bucket, "after.bin", strings.NewReader("ok"), 0); err != nil {
+			t.Fatalf("上传失败: %v", err)
+		}
+		if _, recent := fs.RecentNoSpace(NoSpaceWindow); recent {
+			t.Error("写入成功后应清除空间不足标记")
+		}
+	})
+}
+
+// failingReader 读取时返回指定错误
+type failingReader struct{ err error }
+
+func (r *failingReader) Read(p []byte) (int, error) { return 0, r.err }
+
 // TestGetObject 测试获取对象
 func TestGetObject(t *testing.T) {
 	fs, cleanup := setupFileStore(t)
@@ -749,6 +835,65 @@ func TestGetStoragePath(t *testing.T) {
 	})
 }
 
+// TestPathLayout 测试路径布局
+func TestPathLayout(t *testing.T) {
+	fs, cleanup := setupFileStore(t)
+	defer cleanup()
+
+	t.Run("默认prefix布局", func(t *testing.T) {
+		if fs.PathLayout() != PathLayoutPrefix {
+			t.Errorf("默认布局错误: %s", fs.PathLayout())
+		}
+		path := fs.GetStoragePath("bucket", "dir/key.txt")
+		if !strings.HasSuffix(path, filepath.Join("dir", "key.txt")) {
+			t.Errorf("prefix布局应以key结尾: %s", path)
+		}
+	})
+
+	t.Run("无效布局被拒绝", func(t *testing.T) {
+		if err := fs.SetPathLayout("flat"); err != ErrInvalidPathLayout {
+			t.Errorf("期望 ErrInvalidPathLayout, 实际 %v", err)
+		}
+		if fs.PathLayout() != PathLayoutPrefix {
+			t.Error("无效布局不应修改当前布局")
+		}
+	})
+
+	t.Run("hashed布局", func(t *testing.T) {
+		oldPath, _, err := fs.PutObject("bucket", "old.txt", strings.NewReader("old"), 3)
+		if err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+
+		if err := fs.SetPathLayout(PathLayoutHashed); err != nil {
+			t.Fatalf("设置布局失败: %v", err)
+		}
+		path, _, err := fs.PutObject("bucket", "dir/new.txt", strings.NewReader("new"), 3)
+		if err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+
+		rel, _ := filepath.Rel(filepath.Join(fs.basePath, "bucket"), path)
+		parts := strings.Split(rel, string(filepath.Separator))
+		if len(parts) != 3 || len(parts[0]) != 2 || len(parts[1]) != 2 || len(parts[2]) != 32 {
+			t.Errorf("hashed布局路径格式错误: %s", rel)
+		}
+		if !strings.HasPrefix(parts[2], parts[0]+parts[1]) {
+			t.Errorf("目录应为hash前缀: %s", rel)
+		}
+		if path != fs.GetStoragePathFromKey("bucket", "dir/new.txt") {
+			t.Error("GetStoragePathFromKey 应与写入路径一致")
+		}
+
+		// 已有对象沿用原路径
+		f, err := fs.GetObject(oldPath)
+		if err != nil {
+			t.Fatalf("旧对象应可读取: %v", err)
+		}
+		f.Close()
+	})
+}
+
 // setupFileStore 辅助函数：创建测试用的FileStore
 func setupFileStore(t *testing.T) (*FileStore, func()) {
 	t.Helper()
@@ -0,0 +1,11 @@
+package storage
+
+import "strings"
+
+// FolderMarkerContentType 文件夹占位对象的 Content-Type
+const FolderMarkerContentType = "application/x-directory"
+
+// IsFolderMarker 判断是否为文件夹占位对象：以 / 结尾的零字节键
+func IsFolderMarker(key string, size int64) bool {
+	return size == 0 && strings.HasSuffix(key, "/")
+}
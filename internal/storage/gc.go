@@ -1,8 +1,6 @@
 package storage
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,6 +15,8 @@ type GCResult struct {
 	ExpiredUploads  []string     `json:"expired_uploads"`   // 过期的分片上传ID
 	ExpiredCount    int          `json:"expired_count"`     // 过期上传数量
 	ExpiredPartSize int64        `json:"expired_part_size"` // 过期分片总大小
+	StaleVariants   int          `json:"stale_variants"`    // 源对象已删除或已变更的变体数量
+	StaleChecksums  int          `json:"stale_checksums"`   // 源对象已删除或已变更的校验和记录数量
 	Cleaned         bool         `json:"cleaned"`           // 是否已清理
 	CleanedAt       *time.Time   `json:"cleaned_at"`        // 清理时间
 }
@@ -63,6 +63,22 @@ func (f *FileStore) ScanOrphanFiles(metadata *MetadataStore) (*GCResult, error)
 			knownPaths[obj.StoragePath] = true
 		}
 	}
+	// 仍有效的对象变体；过期变体的文件按孤立文件处理
+	variantPaths, err := metadata.listCurrentVariantPaths()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range variantPaths {
+		knownPaths[path] = true
+	}
+	// 对象的历史版本
+	versionPaths, err := metadata.listVersionStoragePaths()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range versionPaths {
+		knownPaths[path] = true
+	}
 
 	// 遍历磁盘文件
 	err = filepath.Walk(f.basePath, func(path string, info os.FileInfo, err error) error {
@@ -206,7 +222,8 @@ func (m *MetadataStore) CleanExpiredUploads(uploadIDs []string, filestore *FileS
 // ListAllObjects 列出桶中所有对象（无分页限制，内部使用）
 func (m *MetadataStore) ListAllObjects(bucket string) ([]Object, error) {
 	rows, err := m.db.Query(`
-		SELECT bucket, key, size, etag, content_type, last_modified, storage_path
+		SELECT bucket, key, size, etag, content_type, last_modified, storage_path, COALESCE(version_id, ''),
+			COALESCE(encryption_nonce, '')
 		FROM objects
 		WHERE bucket = ?
 		ORDER BY key
@@ -220,7 +237,7 @@ func (m *MetadataStore) ListAllObjects(bucket string) ([]Object, error) {
 	for rows.Next() {
 		var obj Object
 		if err := rows.Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag,
-			&obj.ContentType, &obj.LastModified, &obj.StoragePath); err != nil {
+			&obj.ContentType, &obj.LastModified, &obj.StoragePath, &obj.VersionID, &obj.EncryptionNonce); err != nil {
 			return nil, err
 		}
 		objects = append(objects, obj)
@@ -331,6 +348,18 @@ func RunGC(filestore *FileStore, metadata *MetadataStore, maxUploadAge time.Dura
 	}
 	result.ExpiredCount = len(expiredUploads)
 
+	// 4. 扫描过期的对象变体（文件已计入孤立文件）
+	staleVariants, err := metadata.ListStaleObjectVariants()
+	if err != nil {
+		return nil, err
+	}
+	result.StaleVariants = len(staleVariants)
+
+	// 5. 统计过期的校验和记录
+	if result.StaleChecksums, err = metadata.CountStaleObjectChecksums(); err != nil {
+		return nil, err
+	}
+
 	// 如果不是干运行模式，执行清理
 	if !dryRun {
 		// 清理孤立文件
@@ -347,6 +376,20 @@ func RunGC(filestore *FileStore, metadata *MetadataStore, maxUploadAge time.Dura
 			}
 		}
 
+		// 清理过期变体记录
+		if len(staleVariants) > 0 {
+			if err := metadata.DeleteObjectVariants(staleVariants); err != nil {
+				return result, err
+			}
+		}
+
+		// 清理过期校验和记录
+		if result.StaleChecksums > 0 {
+			if err := metadata.DeleteStaleObjectChecksums(); err != nil {
+				return result, err
+			}
+		}
+
 		result.Cleaned = true
 		now := time.Now()
 		result.CleanedAt = &now
@@ -357,7 +400,5 @@ func RunGC(filestore *FileStore, metadata *MetadataStore, maxUploadAge time.Dura
 
 // GetStoragePathFromKey 根据 bucket 和 key 计算预期的存储路径
 func (f *FileStore) GetStoragePathFromKey(bucket, key string) string {
-	h := md5.Sum([]byte(key))
-	subdir := hex.EncodeToString(h[:1])
-	return filepath.Join(f.basePath, bucket, subdir, key)
+	return f.layoutPath(bucket, key)
 }
@@ -553,6 +553,61 @@ func TestRunGCCleanup(t *testing.T) {
 	}
 }
 
+// TestRunGCStaleVariants 测试源对象变更或删除后清理对象变体
+func TestRunGCStaleVariants(t *testing.T) {
+	fs, ms, cleanup := setupGCTest(t)
+	defer cleanup()
+
+	bucket := "img-bucket"
+	ms.CreateBucket(bucket)
+	putVariant := func(key, sourceETag string) *ObjectVariant {
+		path, etag, size, err := fs.PutVariant(bucket, key, sourceETag, "w=10,h=0", strings.NewReader("variant-"+key))
+		if err != nil {
+			t.Fatalf("写入变体失败: %v", err)
+		}
+		v := &ObjectVariant{Bucket: bucket, Key: key, SourceETag: sourceETag, Params: "w=10,h=0",
+			StoragePath: path, Size: size, ETag: etag, ContentType: "image/png", CreatedAt: time.Now()}
+		if err := ms.SaveObjectVariant(v); err != nil {
+			t.Fatalf("保存变体失败: %v", err)
+		}
+		return v
+	}
+	for _, key := range []string{"a.png", "b.png"} {
+		path, _, _ := fs.PutObject(bucket, key, strings.NewReader(key), int64(len(key)))
+		ms.PutObject(&Object{Bucket: bucket, Key: key, Size: int64(len(key)), ETag: "etag-" + key,
+			ContentType: "image/png", LastModified: time.Now(), StoragePath: path})
+	}
+	current := putVariant("a.png", "etag-a.png")
+	overwritten := putVariant("b.png", "etag-old")
+	deleted := putVariant("gone.png", "etag-gone")
+
+	result, err := RunGC(fs, ms, time.Hour, true)
+	if err != nil {
+		t.Fatalf("GC失败: %v", err)
+	}
+	if result.StaleVariants != 2 || result.OrphanCount != 2 {
+		t.Fatalf("期望 2 个过期变体且文件计入孤立文件: %+v", result)
+	}
+
+	if _, err := RunGC(fs, ms, time.Hour, false); err != nil {
+		t.Fatalf("GC失败: %v", err)
+	}
+	for _, v := range []*ObjectVariant{overwritten, deleted} {
+		if _, err := os.Stat(v.StoragePath); !os.IsNotExist(err) {
+			t.Errorf("过期变体文件应已删除: %s", v.Key)
+		}
+		if got, _ := ms.GetObjectVariant(v.Bucket, v.Key, v.SourceETag, v.Params); got != nil {
+			t.Errorf("过期变体记录应已删除: %s", v.Key)
+		}
+	}
+	if _, err := os.Stat(current.StoragePath); err != nil {
+		t.Errorf("有效变体文件不应删除: %v", err)
+	}
+	if got, _ := ms.GetObjectVariant(bucket, "a.png", "etag-a.png", "w=10,h=0"); got == nil {
+		t.Error("有效变体记录不应删除")
+	}
+}
+
 // TestGetStoragePathFromKey 测试存储路径计算
 func TestGetStoragePathFromKey(t *testing.T) {
 	fs, _, cleanup := setupGCTest(t)
@@ -589,6 +644,40 @@ func TestGetStoragePathFromKey(t *testing.T) {
 	}
 }
 
+// TestScanOrphanFilesHashedLayout 测试hashed布局下的孤立文件扫描
+func TestScanOrphanFilesHashedLayout(t *testing.T) {
+	fs, ms, cleanup := setupGCTest(t)
+	defer cleanup()
+
+	if err := fs.SetPathLayout(PathLayoutHashed); err != nil {
+		t.Fatalf("设置布局失败: %v", err)
+	}
+
+	bucket := "test-bucket"
+	ms.CreateBucket(bucket)
+	path, etag, err := fs.PutObject(bucket, "known.txt", strings.NewReader("known"), 5)
+	if err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	ms.PutObject(&Object{Bucket: bucket, Key: "known.txt", Size: 5, ETag: etag, StoragePath: path, LastModified: time.Now()})
+
+	// 写入文件但不记录元数据
+	if _, _, err := fs.PutObject(bucket, "orphan.txt", strings.NewReader("orphan"), 6); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	result, err := fs.ScanOrphanFiles(ms)
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if result.OrphanCount != 1 {
+		t.Fatalf("期望1个孤立文件, 实际 %d", result.OrphanCount)
+	}
+	if filepath.Join(fs.basePath, result.OrphanFiles[0].Path) != fs.GetStoragePathFromKey(bucket, "orphan.txt") {
+		t.Errorf("孤立文件路径错误: %s", result.OrphanFiles[0].Path)
+	}
+}
+
 // TestGetStoragePathFromKeyConsistency 测试路径计算的一致性
 func TestGetStoragePathFromKeyConsistency(t *testing.T) {
 	fs, _, cleanup := setupGCTest(t)
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MaxIdempotencyKeyLength 幂等键最大长度
+const MaxIdempotencyKeyLength = 255
+
+// PutIdempotencyRecord PUT 幂等键记录，窗口内同一调用方重复使用同一幂等键时返回首次结果
+type PutIdempotencyRecord struct {
+	Owner          string    // 调用方 Access Key ID
+	IdempotencyKey string    // 客户端提供的幂等键
+	Bucket         string    // 首次请求的桶
+	Key            string    // 首次请求的对象键
+	ETag           string    // 首次写入的 ETag（内容 MD5）
+	Size           int64     // 首次写入的字节数
+	ExpiresAt      time.Time // 记录过期时间
+}
+
+// initIdempotencyTable 初始化 PUT 幂等键表
+func (m *MetadataStore) initIdempotencyTable() error {
+	if _, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS put_idempotency (
+		owner TEXT NOT NULL,
+		idempotency_key TEXT NOT NULL,
+		bucket TEXT NOT NULL,
+		key TEXT NOT NULL,
+		etag TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		PRIMARY KEY (owner, idempotency_key)
+	)`); err != nil {
+		return err
+	}
+	_, err := m.db.Exec("CREATE INDEX IF NOT EXISTS idx_put_idempotency_expires ON put_idempotency(expires_at)")
+	return err
+}
+
+// GetPutIdempotency 获取未过期的幂等键记录，不存在或已过期返回 nil
+func (m *MetadataStore) GetPutIdempotency(owner, idempotencyKey string, now time.Time) (*PutIdempotencyRecord, error) {
+	rec := PutIdempotencyRecord{Owner: owner, IdempotencyKey: idempotencyKey}
+	var expiresAt int64
+	err := m.db.QueryRow(`
+		SELECT bucket, key, etag, size, expires_at FROM put_idempotency
+		WHERE owner = ? AND idempotency_key = ? AND expires_at > ?
+	`, owner, idempotencyKey, now.Unix()).Scan(&rec.Bucket, &rec.Key, &rec.ETag, &rec.Size, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec.ExpiresAt = time.Unix(expiresAt, 0).UTC()
+	return &rec, nil
+}
+
+// SavePutIdempotency 保存幂等键记录，覆盖同键的过期记录
+func (m *MetadataStore) SavePutIdempotency(rec *PutIdempotencyRecord) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec(`
+			INSERT OR REPLACE INTO put_idempotency (owner, idempotency_key, bucket, key, etag, size, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, rec.Owner, rec.IdempotencyKey, rec.Bucket, rec.Key, rec.ETag, rec.Size, rec.ExpiresAt.Unix())
+		return err
+	})
+}
+
+// CleanExpiredPutIdempotency 删除已过期的幂等键记录，返回删除数量
+func (m *MetadataStore) CleanExpiredPutIdempotency(now time.Time) (int64, error) {
+	var deleted int64
+	err := m.withWriteLock(func() error {
+		result, err := m.db.Exec("DELETE FROM put_idempotency WHERE expires_at <= ?", now.Unix())
+		if err != nil {
+			return err
+		}
+		deleted, err = result.RowsAffected()
+		return err
+	})
+	return deleted, err
+}
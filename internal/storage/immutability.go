@@ -0,0 +1,19 @@
+package storage
+
+import "time"
+
+// MaxImmutableMinutes 不可变窗口上限（30 天）
+const MaxImmutableMinutes = 30 * 24 * 60
+
+// ImmutableRemaining 返回对象剩余的不可变时间，不在窗口内返回 0
+// 以对象最后一次写入时间为起点，覆盖写入会重新开始计时
+func (b *Bucket) ImmutableRemaining(obj *Object, now time.Time) time.Duration {
+	if b == nil || obj == nil || b.ImmutableMinutes <= 0 {
+		return 0
+	}
+	until := obj.LastModified.Add(time.Duration(b.ImmutableMinutes) * time.Minute)
+	if !now.Before(until) {
+		return 0
+	}
+	return until.Sub(now)
+}
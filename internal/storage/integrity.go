@@ -4,8 +4,11 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,8 +37,132 @@ type IntegrityResult struct {
 	RepairedCount  int              `json:"repaired_count"`   // 修复数量
 }
 
+// 完整性检查的并发、缓冲与速率默认值和上限
+const (
+	DefaultIntegrityWorkers    = 1
+	MaxIntegrityWorkers        = 32
+	DefaultIntegrityBufferSize = 64 * 1024
+	MinIntegrityBufferSize     = 4 * 1024
+	MaxIntegrityBufferSize     = 4 * 1024 * 1024
+)
+
+// IntegrityOptions 完整性检查选项
+type IntegrityOptions struct {
+	VerifyEtag bool               // 是否校验 ETag
+	Limit      int                // 检查数量上限，0 表示不限
+	Workers    int                // 并行 worker 数
+	BufferSize int                // 每个 worker 的读缓冲大小（字节）
+	RateLimit  float64            // 每秒最多检查的文件数，0 表示不限
+	Progress   *IntegrityProgress // 进度记录，可为空
+}
+
+// normalize 补齐默认值并限制在合法范围内
+func (o *IntegrityOptions) normalize() {
+	if o.Workers <= 0 {
+		o.Workers = DefaultIntegrityWorkers
+	}
+	if o.Workers > MaxIntegrityWorkers {
+		o.Workers = MaxIntegrityWorkers
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = DefaultIntegrityBufferSize
+	}
+	if o.BufferSize < MinIntegrityBufferSize {
+		o.BufferSize = MinIntegrityBufferSize
+	}
+	if o.BufferSize > MaxIntegrityBufferSize {
+		o.BufferSize = MaxIntegrityBufferSize
+	}
+	if o.RateLimit < 0 {
+		o.RateLimit = 0
+	}
+}
+
+// IntegrityProgress 记录正在进行的完整性检查进度
+type IntegrityProgress struct {
+	mu        sync.Mutex
+	running   bool
+	blocked   bool // 生命周期过期等会删除对象的后台任务正在运行
+	total     int
+	startedAt time.Time
+	scanned   atomic.Int64
+}
+
+// IntegrityProgressInfo 完整性检查进度快照
+type IntegrityProgressInfo struct {
+	Running   bool      `json:"running"`    // 是否正在检查
+	Total     int       `json:"total"`      // 待检查对象数
+	Scanned   int       `json:"scanned"`    // 已检查对象数
+	StartedAt time.Time `json:"started_at"` // 开始时间
+}
+
+// TryStart 标记检查开始，已有检查或会删除对象的后台任务在运行时返回 false
+func (p *IntegrityProgress) TryStart() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running || p.blocked {
+		return false
+	}
+	p.running = true
+	p.total = 0
+	p.startedAt = time.Now()
+	p.scanned.Store(0)
+	return true
+}
+
+// Finish 标记检查结束
+func (p *IntegrityProgress) Finish() {
+	p.mu.Lock()
+	p.running = false
+	p.mu.Unlock()
+}
+
+// TryBlock 标记会删除对象的后台任务开始，期间不能开始检查，避免把刚删除的对象报告为缺失文件
+// 检查正在运行或已被占用时返回 false，结束后调用 Unblock
+func (p *IntegrityProgress) TryBlock() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running || p.blocked {
+		return false
+	}
+	p.blocked = true
+	return true
+}
+
+// Unblock 标记会删除对象的后台任务结束
+func (p *IntegrityProgress) Unblock() {
+	p.mu.Lock()
+	p.blocked = false
+	p.mu.Unlock()
+}
+
+// setTotal 设置待检查对象数
+func (p *IntegrityProgress) setTotal(total int) {
+	p.mu.Lock()
+	p.total = total
+	p.mu.Unlock()
+}
+
+// Snapshot 返回当前进度
+func (p *IntegrityProgress) Snapshot() IntegrityProgressInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return IntegrityProgressInfo{
+		Running:   p.running,
+		Total:     p.total,
+		Scanned:   int(p.scanned.Load()),
+		StartedAt: p.startedAt,
+	}
+}
+
 // CheckIntegrity 检查数据完整性
 func CheckIntegrity(filestore *FileStore, metadata *MetadataStore, verifyEtag bool, limit int) (*IntegrityResult, error) {
+	return CheckIntegrityWithOptions(filestore, metadata, IntegrityOptions{VerifyEtag: verifyEtag, Limit: limit})
+}
+
+// CheckIntegrityWithOptions 按选项并行检查数据完整性
+func CheckIntegrityWithOptions(filestore *FileStore, metadata *MetadataStore, opts IntegrityOptions) (*IntegrityResult, error) {
+	opts.normalize()
 	startTime := time.Now()
 	result := &IntegrityResult{
 		Issues:    make([]IntegrityIssue, 0),
@@ -48,63 +175,71 @@ func CheckIntegrity(filestore *FileStore, metadata *MetadataStore, verifyEtag bo
 		return nil, err
 	}
 
-	checked := 0
+	// 先收集待检查对象，便于报告总数
+	var objects []Object
 	for _, bucket := range buckets {
-		// 获取桶中所有对象
-		objects, err := metadata.ListAllObjects(bucket.Name)
+		bucketObjects, err := metadata.ListAllObjects(bucket.Name)
 		if err != nil {
 			continue
 		}
+		if opts.Limit > 0 && len(objects)+len(bucketObjects) > opts.Limit {
+			bucketObjects = bucketObjects[:opts.Limit-len(objects)]
+		}
+		objects = append(objects, bucketObjects...)
+		if opts.Limit > 0 && len(objects) >= opts.Limit {
+			break
+		}
+	}
+	if opts.Progress != nil {
+		opts.Progress.setTotal(len(objects))
+	}
 
-		for _, obj := range objects {
-			// 检查文件是否存在
-			if _, err := os.Stat(obj.StoragePath); os.IsNotExist(err) {
-				issue := IntegrityIssue{
-					Bucket:     obj.Bucket,
-					Key:        obj.Key,
-					IssueType:  "missing_file",
-					Expected:   obj.StoragePath,
-					Actual:     "not found",
-					Size:       obj.Size,
-					Repairable: true, // 可以删除元数据记录
-				}
-				result.Issues = append(result.Issues, issue)
-				result.MissingFiles++
-				result.IssuesFound++
-			} else if verifyEtag {
-				// 验证 ETag
-				actualEtag, err := calculateFileEtag(obj.StoragePath)
-				if err == nil && actualEtag != obj.ETag {
-					// 去掉引号比较
-					expectedEtag := trimQuotes(obj.ETag)
-					if actualEtag != expectedEtag {
-						issue := IntegrityIssue{
-							Bucket:     obj.Bucket,
-							Key:        obj.Key,
-							IssueType:  "etag_mismatch",
-							Expected:   obj.ETag,
-							Actual:     actualEtag,
-							Size:       obj.Size,
-							Repairable: true, // 可以更新 ETag
-						}
-						result.Issues = append(result.Issues, issue)
-						result.EtagMismatches++
-						result.IssuesFound++
-					}
+	// 每个对象的结果按下标保存，保证问题列表顺序稳定
+	issues := make([]*IntegrityIssue, len(objects))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, opts.BufferSize)
+			for idx := range jobs {
+				issues[idx] = checkObjectIntegrity(filestore, &objects[idx], opts.VerifyEtag, buf)
+				if opts.Progress != nil {
+					opts.Progress.scanned.Add(1)
 				}
 			}
+		}()
+	}
 
-			checked++
-			result.TotalChecked = checked
-
-			// 限制检查数量
-			if limit > 0 && checked >= limit {
-				break
-			}
+	// 限速：按固定间隔派发任务
+	var tick <-chan time.Time
+	if opts.RateLimit > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.RateLimit))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for i := range objects {
+		if tick != nil && i > 0 {
+			<-tick
 		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 
-		if limit > 0 && checked >= limit {
-			break
+	result.TotalChecked = len(objects)
+	for _, issue := range issues {
+		if issue == nil {
+			continue
+		}
+		result.Issues = append(result.Issues, *issue)
+		result.IssuesFound++
+		switch issue.IssueType {
+		case "missing_file":
+			result.MissingFiles++
+		case "etag_mismatch":
+			result.EtagMismatches++
 		}
 	}
 
@@ -112,6 +247,44 @@ func CheckIntegrity(filestore *FileStore, metadata *MetadataStore, verifyEtag bo
 	return result, nil
 }
 
+// checkObjectIntegrity 检查单个对象，无问题时返回 nil
+func checkObjectIntegrity(filestore *FileStore, obj *Object, verifyEtag bool, buf []byte) *IntegrityIssue {
+	// 检查文件是否存在
+	if _, err := os.Stat(obj.StoragePath); os.IsNotExist(err) {
+		return &IntegrityIssue{
+			Bucket:     obj.Bucket,
+			Key:        obj.Key,
+			IssueType:  "missing_file",
+			Expected:   obj.StoragePath,
+			Actual:     "not found",
+			Size:       obj.Size,
+			Repairable: true, // 可以删除元数据记录
+		}
+	}
+	if !verifyEtag {
+		return nil
+	}
+
+	// 验证 ETag（去掉引号比较）
+	sum, err := hashObjectWith(filestore, obj, md5.New(), buf)
+	if err != nil {
+		return nil
+	}
+	actualEtag := hex.EncodeToString(sum)
+	if actualEtag == trimQuotes(obj.ETag) {
+		return nil
+	}
+	return &IntegrityIssue{
+		Bucket:     obj.Bucket,
+		Key:        obj.Key,
+		IssueType:  "etag_mismatch",
+		Expected:   obj.ETag,
+		Actual:     actualEtag,
+		Size:       obj.Size,
+		Repairable: true, // 可以更新 ETag
+	}
+}
+
 // RepairIntegrity 修复完整性问题
 func RepairIntegrity(filestore *FileStore, metadata *MetadataStore, issues []IntegrityIssue) (*IntegrityResult, error) {
 	result := &IntegrityResult{
@@ -136,10 +309,11 @@ func RepairIntegrity(filestore *FileStore, metadata *MetadataStore, issues []Int
 			if err != nil {
 				continue
 			}
-			newEtag, err := calculateFileEtag(obj.StoragePath)
+			sum, err := hashObjectWith(filestore, obj, md5.New(), make([]byte, 32*1024))
 			if err != nil {
 				continue
 			}
+			newEtag := hex.EncodeToString(sum)
 			// 更新 ETag
 			if err := metadata.UpdateObjectEtag(issue.Bucket, issue.Key, fmt.Sprintf("\"%s\"", newEtag)); err == nil {
 				result.RepairedCount++
@@ -167,6 +341,37 @@ func calculateFileEtag(path string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
+// hashFileWith 使用给定哈希和缓冲流式计算文件摘要
+func hashFileWith(path string, h hash.Hash, buf []byte) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// 包装一层，避免 io.CopyBuffer 走 WriterTo 而绕过给定缓冲
+	if _, err := io.CopyBuffer(h, struct{ io.Reader }{file}, buf); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// hashObjectWith 使用给定哈希和缓冲流式计算对象内容的摘要，加密对象先解密
+func hashObjectWith(filestore *FileStore, obj *Object, h hash.Hash, buf []byte) ([]byte, error) {
+	if !obj.Encrypted() {
+		return hashFileWith(obj.StoragePath, h, buf)
+	}
+	reader, err := filestore.OpenObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	if _, err := io.CopyBuffer(h, struct{ io.Reader }{reader}, buf); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
 // trimQuotes 去掉字符串两端的引号
 func trimQuotes(s string) string {
 	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
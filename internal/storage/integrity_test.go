@@ -1,10 +1,13 @@
 package storage
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // setupIntegrityTest 为完整性测试创建测试环境
@@ -404,6 +407,62 @@ func TestCalculateFileEtagErrors(t *testing.T) {
 	}
 }
 
+// TestComputeObjectChecksum 测试按需计算并缓存对象校验和
+func TestComputeObjectChecksum(t *testing.T) {
+	fs, ms, cleanup := setupIntegrityTest(t)
+	defer cleanup()
+
+	bucket := "checksum-bucket"
+	ms.CreateBucket(bucket)
+	put := func(data string) *Object {
+		storagePath, etag, _ := fs.PutObject(bucket, "file.txt", strings.NewReader(data), int64(len(data)))
+		obj := &Object{Bucket: bucket, Key: "file.txt", Size: int64(len(data)), ETag: etag, StoragePath: storagePath}
+		ms.PutObject(obj)
+		return obj
+	}
+	obj := put("hello")
+
+	expected := map[string]string{
+		ChecksumMD5:    "5d41402abc4b2a76b9719d911017c592",
+		ChecksumSHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		ChecksumCRC32C: "9a71bb4c",
+	}
+	for algorithm, want := range expected {
+		c, cached, err := ms.ComputeObjectChecksum(nil, obj, algorithm, false)
+		if err != nil || cached || c.Value != want {
+			t.Errorf("%s 计算错误: %+v %v %v", algorithm, c, cached, err)
+		}
+	}
+
+	if c, cached, _ := ms.ComputeObjectChecksum(nil, obj, ChecksumSHA256, false); !cached || c.Value != expected[ChecksumSHA256] {
+		t.Error("第二次应命中缓存")
+	}
+	if _, cached, _ := ms.ComputeObjectChecksum(nil, obj, ChecksumSHA256, true); cached {
+		t.Error("refresh 应重新计算")
+	}
+	if _, _, err := ms.ComputeObjectChecksum(nil, obj, "sha1", false); err != ErrUnsupportedChecksum {
+		t.Errorf("期望不支持的算法错误: %v", err)
+	}
+	if algorithm, err := NormalizeChecksumAlgorithm(" SHA-256 "); err != nil || algorithm != ChecksumSHA256 {
+		t.Errorf("算法名规范化错误: %q %v", algorithm, err)
+	}
+
+	// 覆盖后缓存失效，旧记录计为过期
+	obj = put("world")
+	if c, cached, _ := ms.ComputeObjectChecksum(nil, obj, ChecksumMD5, false); cached || c.Value != "7d793037a0760186574b0282f2f435e7" {
+		t.Errorf("覆盖后应重新计算: %+v", c)
+	}
+	if n, err := ms.CountStaleObjectChecksums(); err != nil || n != 2 {
+		t.Errorf("期望 2 条过期记录: %d %v", n, err)
+	}
+	if err := ms.DeleteStaleObjectChecksums(); err != nil {
+		t.Fatalf("删除过期记录失败: %v", err)
+	}
+	if n, _ := ms.CountStaleObjectChecksums(); n != 0 {
+		t.Errorf("过期记录未删除: %d", n)
+	}
+}
+
 // TestTrimQuotes 测试去除引号功能
 func TestTrimQuotes(t *testing.T) {
 	testCases := []struct {
@@ -531,6 +590,83 @@ func TestCheckIntegrityWithoutEtagVerification(t *testing.T) {
 	}
 }
 
+// TestCheckIntegrityWithOptions 测试并行、缓冲、限速与进度
+func TestCheckIntegrityWithOptions(t *testing.T) {
+	fs, ms, cleanup := setupIntegrityTest(t)
+	defer cleanup()
+
+	bucket := "test-bucket"
+	ms.CreateBucket(bucket)
+
+	// 10 个对象，其中 file-3 的 ETag 错误、file-7 的文件缺失
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("file-%d.txt", i)
+		data := []byte(strings.Repeat("x", 10000+i))
+		storagePath, etag, _ := fs.PutObject(bucket, key, strings.NewReader(string(data)), int64(len(data)))
+		if i == 3 {
+			etag = "\"wrong-etag\""
+		}
+		if i == 7 {
+			os.Remove(storagePath)
+		}
+		ms.PutObject(&Object{Bucket: bucket, Key: key, Size: int64(len(data)), ETag: etag, StoragePath: storagePath})
+	}
+
+	t.Run("并行检查结果稳定", func(t *testing.T) {
+		progress := &IntegrityProgress{}
+		progress.TryStart()
+		result, err := CheckIntegrityWithOptions(fs, ms, IntegrityOptions{
+			VerifyEtag: true,
+			Workers:    4,
+			BufferSize: 1, // 低于下限时使用最小缓冲
+			Progress:   progress,
+		})
+		progress.Finish()
+		if err != nil {
+			t.Fatalf("完整性检查失败: %v", err)
+		}
+		if result.TotalChecked != 10 || result.EtagMismatches != 1 || result.MissingFiles != 1 {
+			t.Errorf("结果错误: %+v", result)
+		}
+		if len(result.Issues) != 2 || result.Issues[0].Key != "file-3.txt" || result.Issues[1].Key != "file-7.txt" {
+			t.Errorf("问题列表顺序错误: %+v", result.Issues)
+		}
+		info := progress.Snapshot()
+		if info.Running || info.Total != 10 || info.Scanned != 10 {
+			t.Errorf("进度错误: %+v", info)
+		}
+	})
+
+	t.Run("限速", func(t *testing.T) {
+		start := time.Now()
+		result, err := CheckIntegrityWithOptions(fs, ms, IntegrityOptions{Limit: 5, RateLimit: 50})
+		if err != nil {
+			t.Fatalf("完整性检查失败: %v", err)
+		}
+		if result.TotalChecked != 5 {
+			t.Errorf("TotalChecked 错误: %d", result.TotalChecked)
+		}
+		// 5 个文件以 50/s 派发至少需要 4 个间隔
+		if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+			t.Errorf("限速未生效: %v", elapsed)
+		}
+	})
+
+	t.Run("并发检查互斥", func(t *testing.T) {
+		progress := &IntegrityProgress{}
+		if !progress.TryStart() {
+			t.Fatal("首次开始应成功")
+		}
+		if progress.TryStart() {
+			t.Error("运行中再次开始应失败")
+		}
+		progress.Finish()
+		if !progress.TryStart() {
+			t.Error("结束后应可再次开始")
+		}
+	})
+}
+
 // BenchmarkCheckIntegrity 完整性检查性能基准
 func BenchmarkCheckIntegrity(b *testing.B) {
 	fs, ms, cleanup := setupIntegrityTest(&testing.T{})
@@ -559,3 +695,96 @@ func BenchmarkCheckIntegrity(b *testing.B) {
 		CheckIntegrity(fs, ms, false, 0)
 	}
 }
+
+// TestEtagRederive 测试后台重算 ETag、修正不一致以及从游标续扫
+func TestEtagRederive(t *testing.T) {
+	fs, ms, cleanup := setupIntegrityTest(t)
+	defer cleanup()
+
+	ms.CreateBucket("bucket-a")
+	ms.CreateBucket("bucket-b")
+	put := func(bucket, key, content, etag string) {
+		path, actual, err := fs.PutObject(bucket, key, strings.NewReader(content), int64(len(content)))
+		if err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+		if etag == "" {
+			etag = actual
+		}
+		ms.PutObject(&Object{Bucket: bucket, Key: key, Size: int64(len(content)), ETag: etag, StoragePath: path})
+	}
+	put("bucket-a", "ok.txt", "ok", "")
+	put("bucket-a", "placeholder.txt", "imported", "placeholder")
+	put("bucket-b", "zero.txt", "zero", "00000000000000000000000000000000")
+	ms.PutObject(&Object{Bucket: "bucket-b", Key: "missing.txt", ETag: "x", StoragePath: filepath.Join(t.TempDir(), "missing")})
+
+	wait := func(e *EtagRederiver) EtagRederiveStatus {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := e.Wait(ctx); err != nil {
+			t.Fatalf("等待任务失败: %v", err)
+		}
+		return e.Status()
+	}
+
+	// 仅报告，不修改元数据
+	e := NewEtagRederiver(ms, nil)
+	if err := e.Start(EtagRederiveOptions{}); err != nil {
+		t.Fatalf("启动失败: %v", err)
+	}
+	status := wait(e)
+	if !status.Done || status.Scanned != 4 || status.Mismatched != 2 || status.Missing != 1 || status.Fixed != 0 {
+		t.Fatalf("报告结果错误: %+v", status)
+	}
+	if obj, _ := ms.GetObject("bucket-a", "placeholder.txt"); obj.ETag != "placeholder" {
+		t.Errorf("仅报告时不应修改 ETag: %s", obj.ETag)
+	}
+
+	// 从保存的游标续扫：只处理游标之后的对象
+	if err := ms.SetSetting(etagRederiveCursorKey, "bucket-a/placeholder.txt"); err != nil {
+		t.Fatalf("保存游标失败: %v", err)
+	}
+	if err := e.Start(EtagRederiveOptions{Fix: true}); err != nil {
+		t.Fatalf("启动失败: %v", err)
+	}
+	status = wait(e)
+	if status.Scanned != 2 || status.Fixed != 1 {
+		t.Fatalf("续扫结果错误: %+v", status)
+	}
+	if cursor, _ := ms.GetSetting(etagRederiveCursorKey); cursor != "" {
+		t.Errorf("完成后游标应清空: %q", cursor)
+	}
+
+	// 从头修正
+	if err := e.Start(EtagRederiveOptions{Fix: true, Restart: true}); err != nil {
+		t.Fatalf("启动失败: %v", err)
+	}
+	status = wait(e)
+	if status.Fixed != 1 || len(status.Corrections) != 1 || status.Corrections[0].Key != "placeholder.txt" {
+		t.Fatalf("修正结果错误: %+v", status)
+	}
+	obj, _ := ms.GetObject("bucket-a", "placeholder.txt")
+	if actual, _ := calculateFileEtag(obj.StoragePath); obj.ETag != actual {
+		t.Errorf("ETag 应修正为 %s: %s", actual, obj.ETag)
+	}
+
+	// 限速下取消任务，游标保留已处理的位置
+	if err := e.Start(EtagRederiveOptions{RateLimit: 1, Restart: true}); err != nil {
+		t.Fatalf("启动失败: %v", err)
+	}
+	if err := e.Start(EtagRederiveOptions{}); err != ErrEtagRederiveRunning {
+		t.Errorf("重复启动应返回 ErrEtagRederiveRunning: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !e.Cancel() {
+		t.Fatal("取消运行中的任务应返回 true")
+	}
+	status = wait(e)
+	if !status.Cancelled || status.Done || status.Scanned != 1 {
+		t.Fatalf("取消结果错误: %+v", status)
+	}
+	if cursor, _ := ms.GetSetting(etagRederiveCursorKey); cursor != "bucket-a/ok.txt" {
+		t.Errorf("取消后游标应保留: %q", cursor)
+	}
+}
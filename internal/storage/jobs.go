@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout 关闭时等待后台任务退出的默认时间
+const DefaultDrainTimeout = 15 * time.Second
+
+// BackgroundJobs 后台任务管理器
+// 所有后台工作者共享同一个上下文，Shutdown 取消上下文后在限定时间内等待其退出，
+// 再依次执行收尾函数（如刷新缓冲区、排空复制队列）
+type BackgroundJobs struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]int // 按名称统计仍在运行的任务
+	hooks   []shutdownHook
+}
+
+// shutdownHook 关闭时执行的收尾函数
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewBackgroundJobs 创建后台任务管理器
+func NewBackgroundJobs() *BackgroundJobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BackgroundJobs{ctx: ctx, cancel: cancel, running: make(map[string]int)}
+}
+
+// Context 返回后台任务共享的上下文，Shutdown 时取消
+func (j *BackgroundJobs) Context() context.Context {
+	return j.ctx
+}
+
+// Go 启动后台任务，fn 应在 ctx 取消后尽快返回；关闭后调用不再启动
+func (j *BackgroundJobs) Go(name string, fn func(ctx context.Context)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ctx.Err() != nil {
+		return
+	}
+	j.running[name]++
+	j.wg.Add(1)
+	go func() {
+		defer func() {
+			j.mu.Lock()
+			if j.running[name]--; j.running[name] == 0 {
+				delete(j.running, name)
+			}
+			j.mu.Unlock()
+			j.wg.Done()
+		}()
+		fn(j.ctx)
+	}()
+}
+
+// Every 按固定间隔执行 fn，直到关闭；正在执行的一轮完成后才退出
+func (j *BackgroundJobs) Every(name string, interval time.Duration, fn func(ctx context.Context)) {
+	j.Go(name, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// OnShutdown 注册收尾函数，在后台任务退出后按注册顺序执行，ctx 携带剩余的等待时间
+func (j *BackgroundJobs) OnShutdown(name string, fn func(ctx context.Context) error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.hooks = append(j.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Shutdown 取消共享上下文并等待后台任务退出，再执行收尾函数
+// ctx 到期时不再等待，返回未能退出的任务和失败的收尾函数
+func (j *BackgroundJobs) Shutdown(ctx context.Context) error {
+	j.mu.Lock()
+	j.cancel()
+	j.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(done)
+	}()
+
+	var errs []error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("background jobs still running: %s", strings.Join(j.runningNames(), ", ")))
+	}
+
+	j.mu.Lock()
+	hooks := j.hooks
+	j.hooks = nil
+	j.mu.Unlock()
+	for _, h := range hooks {
+		if err := h.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runningNames 返回仍在运行的任务名称
+func (j *BackgroundJobs) runningNames() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	names := make([]string, 0, len(j.running))
+	for name, n := range j.running {
+		if n > 1 {
+			name = fmt.Sprintf("%s(%d)", name, n)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
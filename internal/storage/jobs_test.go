@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestBackgroundJobsShutdown 测试关闭时取消任务、等待退出并执行收尾函数
+func TestBackgroundJobsShutdown(t *testing.T) {
+	jobs := NewBackgroundJobs()
+
+	var ticks, exited atomic.Int32
+	jobs.Every("ticker", time.Millisecond, func(ctx context.Context) {
+		ticks.Add(1)
+	})
+	jobs.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		exited.Add(1)
+	})
+
+	var order []string
+	jobs.OnShutdown("first", func(ctx context.Context) error {
+		// 收尾函数在任务退出后执行
+		if exited.Load() != 1 {
+			t.Error("收尾函数应在任务退出后执行")
+		}
+		order = append(order, "first")
+		return nil
+	})
+	jobs.OnShutdown("second", func(ctx context.Context) error {
+		order = append(order, "second")
+		return nil
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for ticks.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := jobs.Shutdown(ctx); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("收尾顺序错误: %v", order)
+	}
+
+	// 关闭后不再启动新任务
+	started := false
+	jobs.Go("late", func(ctx context.Context) { started = true })
+	time.Sleep(10 * time.Millisecond)
+	if started {
+		t.Error("关闭后不应启动新任务")
+	}
+}
+
+// TestBackgroundJobsShutdownTimeout 测试等待超时后报告未退出的任务
+func TestBackgroundJobsShutdownTimeout(t *testing.T) {
+	jobs := NewBackgroundJobs()
+	release := make(chan struct{})
+	defer close(release)
+	jobs.Go("stuck", func(ctx context.Context) { <-release })
+
+	hookRan := false
+	jobs.OnShutdown("flush", func(ctx context.Context) error {
+		hookRan = true
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := jobs.Shutdown(ctx)
+	if err == nil || !strings.Contains(err.Error(), "stuck") {
+		t.Errorf("应报告未退出的任务: %v", err)
+	}
+	if !hookRan {
+		t.Error("超时后仍应执行收尾函数")
+	}
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"path"
+	"strings"
+)
+
+// ParseKeyPatterns 解析换行分隔的键模式，去除空白并去重
+// 对象键可能包含逗号，因此与内容类型不同采用换行分隔
+func ParseKeyPatterns(s string) []string {
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, p := range strings.Split(s, "\n") {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// IsValidKeyPattern 检查键模式是否为合法的 glob
+func IsValidKeyPattern(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
+
+// DeniesKey 判断键是否命中桶的保留键列表
+// 模式遵循 path.Match 语义，* 不跨越 /
+func (b *Bucket) DeniesKey(key string) bool {
+	if b == nil || b.KeyDenylist == "" {
+		return false
+	}
+	for _, p := range ParseKeyPatterns(b.KeyDenylist) {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
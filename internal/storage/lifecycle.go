@@ -0,0 +1,355 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// MaxLifecycleRules 每个桶的生命周期规则数上限
+const MaxLifecycleRules = 100
+
+// lifecycleBatch 每条规则每次查询的对象数
+const lifecycleBatch = 500
+
+// LifecycleRule 生命周期过期规则：键以 Prefix 开头、最后修改时间超过 Days 天的对象被自动删除
+// 启用版本控制的桶只为当前版本写入删除标记，历史版本保留
+type LifecycleRule struct {
+	ID      string `json:"id"`
+	Prefix  string `json:"prefix"` // 空表示整个桶
+	Days    int    `json:"days"`
+	Enabled bool   `json:"enabled"`
+}
+
+// ValidateLifecycleRules 校验生命周期规则：Days 至少为 1，ID 不能重复
+func ValidateLifecycleRules(rules []LifecycleRule) error {
+	if len(rules) > MaxLifecycleRules {
+		return fmt.Errorf("at most %d rules are allowed", MaxLifecycleRules)
+	}
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if len(rule.ID) > 255 {
+			return fmt.Errorf("rule id must be at most 255 characters")
+		}
+		if rule.ID != "" {
+			if seen[rule.ID] {
+				return fmt.Errorf("duplicate rule id: %s", rule.ID)
+			}
+			seen[rule.ID] = true
+		}
+		if rule.Days < 1 {
+			return fmt.Errorf("expiration days must be a positive integer")
+		}
+	}
+	return nil
+}
+
+// encodeLifecycleRules 序列化生命周期规则，无规则时为空串
+func encodeLifecycleRules(rules []LifecycleRule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(rules)
+	return string(data)
+}
+
+// decodeLifecycleRules 反序列化生命周期规则，格式错误时视为空
+func decodeLifecycleRules(s string) []LifecycleRule {
+	if s == "" {
+		return nil
+	}
+	var rules []LifecycleRule
+	if err := json.Unmarshal([]byte(s), &rules); err != nil {
+		return nil
+	}
+	return rules
+}
+
+// UpdateBucketLifecycle 设置桶的生命周期规则，空列表表示删除配置
+func (m *MetadataStore) UpdateBucketLifecycle(name string, rules []LifecycleRule) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET lifecycle_rules = ? WHERE name = ?", encodeLifecycleRules(rules), name)
+		return err
+	})
+}
+
+// listLifecycleCandidates 按键顺序列出 after 之后、键以 prefix 开头且最后修改时间早于 before 的当前版本对象
+func (m *MetadataStore) listLifecycleCandidates(bucket, prefix, after string, before time.Time, limit int) ([]Object, error) {
+	rows, err := m.db.Query(`
+		SELECT bucket, key, size, etag, last_modified, storage_path, created_at, COALESCE(version_id, '')
+		FROM objects WHERE bucket = ? AND key LIKE ? ESCAPE '\' AND key > ? AND last_modified < ?
+		ORDER BY key LIMIT ?`,
+		bucket, escapeLikePattern(prefix)+"%", after, before, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []Object
+	for rows.Next() {
+		var obj Object
+		var created sql.NullTime
+		if err := rows.Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag, &obj.LastModified, &obj.StoragePath, &created, &obj.VersionID); err != nil {
+			return nil, err
+		}
+		obj.CreatedAt = created.Time
+		objects = append(objects, obj)
+	}
+	return objects, rows.Err()
+}
+
+// LifecycleRunStats 一轮生命周期过期扫描的统计
+type LifecycleRunStats struct {
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Buckets      int       `json:"buckets"`       // 配置了启用规则的桶数
+	Expired      int       `json:"expired"`       // 匹配规则且已过期的对象数
+	Deleted      int       `json:"deleted"`       // 实际删除（或写入删除标记）的对象数
+	DeletedBytes int64     `json:"deleted_bytes"` // 删除的字节数（删除标记不计）
+	Skipped      int       `json:"skipped"`       // 处于不可变窗口或扫描期间被覆盖而跳过的对象数
+	Deferred     bool      `json:"deferred"`      // 完整性检查正在运行，本轮推迟到下一次
+	Error        string    `json:"error,omitempty"`
+}
+
+// LifecycleStatus 生命周期过期服务状态
+type LifecycleStatus struct {
+	IntervalMinutes int                `json:"interval_minutes"` // 扫描间隔，0 表示已暂停
+	Running         bool               `json:"running"`          // 是否正在扫描
+	LastRun         *LifecycleRunStats `json:"last_run"`         // 最近一轮统计，尚未运行时为 null
+}
+
+// LifecycleService 生命周期过期服务：按间隔扫描配置了规则的桶，删除过期对象
+type LifecycleService struct {
+	mu        sync.Mutex
+	store     *MetadataStore
+	filestore *FileStore
+	interval  time.Duration
+	integrity *IntegrityProgress // 与完整性检查互斥
+	onDelete  func(obj *Object)  // 对象删除后回调（用于同步到复制目标）
+	stopChan  chan struct{}
+	ticker    *time.Ticker
+	started   bool
+	running   bool
+	lastRun   *LifecycleRunStats
+}
+
+var (
+	lifecycleService     *LifecycleService
+	lifecycleServiceOnce sync.Once
+)
+
+// GetLifecycleService 获取生命周期过期服务单例
+func GetLifecycleService() *LifecycleService {
+	lifecycleServiceOnce.Do(func() {
+		lifecycleService = &LifecycleService{}
+	})
+	return lifecycleService
+}
+
+// InitLifecycleService 初始化生命周期过期服务，interval 大于 0 时启动定时扫描
+func InitLifecycleService(store *MetadataStore, filestore *FileStore, interval time.Duration) {
+	service := GetLifecycleService()
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.store = store
+	service.filestore = filestore
+	service.interval = interval
+	service.startTicker()
+}
+
+// SetIntegrityProgress 设置需要互斥的完整性检查进度，扫描期间不能开始检查，检查期间扫描推迟
+func (s *LifecycleService) SetIntegrityProgress(p *IntegrityProgress) {
+	s.mu.Lock()
+	s.integrity = p
+	s.mu.Unlock()
+}
+
+// OnDelete 设置对象被删除后的回调
+func (s *LifecycleService) OnDelete(fn func(obj *Object)) {
+	s.mu.Lock()
+	s.onDelete = fn
+	s.mu.Unlock()
+}
+
+// SetInterval 修改扫描间隔，0 表示暂停
+func (s *LifecycleService) SetInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = interval
+	s.stopTicker()
+	s.startTicker()
+}
+
+// startTicker 启动定时扫描（需持有锁）
+func (s *LifecycleService) startTicker() {
+	if s.started || s.interval <= 0 || s.store == nil {
+		return
+	}
+	stop := make(chan struct{})
+	ticker := time.NewTicker(s.interval)
+	s.stopChan, s.ticker, s.started = stop, ticker, true
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.Run(time.Now())
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopTicker 停止定时扫描（需持有锁），正在进行的一轮会执行完
+func (s *LifecycleService) stopTicker() {
+	if !s.started {
+		return
+	}
+	s.ticker.Stop()
+	close(s.stopChan)
+	s.started = false
+}
+
+// Stop 停止服务（程序退出时调用）
+func (s *LifecycleService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopTicker()
+}
+
+// Status 返回服务状态和最近一轮统计
+func (s *LifecycleService) Status() LifecycleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := LifecycleStatus{Running: s.running}
+	if s.started {
+		status.IntervalMinutes = int(s.interval / time.Minute)
+	}
+	if s.lastRun != nil {
+		last := *s.lastRun
+		status.LastRun = &last
+	}
+	return status
+}
+
+// Run 执行一轮扫描，已有一轮在运行时返回 nil
+// 完整性检查正在运行时不扫描，返回 Deferred 的统计
+func (s *LifecycleService) Run(now time.Time) *LifecycleRunStats {
+	s.mu.Lock()
+	if s.running || s.store == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = true
+	store, filestore, integrity, onDelete := s.store, s.filestore, s.integrity, s.onDelete
+	s.mu.Unlock()
+
+	stats := &LifecycleRunStats{StartedAt: now.UTC()}
+	if integrity != nil && !integrity.TryBlock() {
+		stats.Deferred = true
+	} else {
+		if err := expireLifecycleObjects(store, filestore, now, stats, onDelete); err != nil {
+			stats.Error = err.Error()
+		}
+		if integrity != nil {
+			integrity.Unblock()
+		}
+	}
+	stats.FinishedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	s.running = false
+	s.lastRun = stats
+	s.mu.Unlock()
+	return stats
+}
+
+// expireLifecycleObjects 按各桶启用的规则删除过期对象
+func expireLifecycleObjects(m *MetadataStore, f *FileStore, now time.Time, stats *LifecycleRunStats, onDelete func(obj *Object)) error {
+	buckets, err := m.ListBuckets()
+	if err != nil {
+		return err
+	}
+	for i := range buckets {
+		b := &buckets[i]
+		// 只读（冻结）的桶不做任何修改
+		if b.ReadOnly {
+			continue
+		}
+		counted := false
+		for _, rule := range b.LifecycleRules {
+			if !rule.Enabled {
+				continue
+			}
+			if !counted {
+				stats.Buckets++
+				counted = true
+			}
+			if err := expireLifecycleRule(m, f, b, rule, now, stats, onDelete); err != nil {
+				return fmt.Errorf("bucket %s: %w", b.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expireLifecycleRule 删除命中一条规则的过期对象
+func expireLifecycleRule(m *MetadataStore, f *FileStore, b *Bucket, rule LifecycleRule, now time.Time, stats *LifecycleRunStats, onDelete func(obj *Object)) error {
+	before := now.UTC().AddDate(0, 0, -rule.Days)
+	after := ""
+	for {
+		objects, err := m.listLifecycleCandidates(b.Name, rule.Prefix, after, before, lifecycleBatch)
+		if err != nil {
+			return err
+		}
+		for i := range objects {
+			obj := &objects[i]
+			stats.Expired++
+			if b.ImmutableRemaining(obj, now) > 0 {
+				stats.Skipped++
+				continue
+			}
+			deleted, err := expireObject(m, f, b, obj)
+			if err != nil {
+				return err
+			}
+			if !deleted {
+				stats.Skipped++
+				continue
+			}
+			stats.Deleted++
+			if !b.VersioningEnabled {
+				stats.DeletedBytes += obj.Size
+			}
+			if onDelete != nil {
+				onDelete(obj)
+			}
+		}
+		if len(objects) < lifecycleBatch {
+			return nil
+		}
+		after = objects[len(objects)-1].Key
+	}
+}
+
+// expireObject 删除过期对象，扫描后被覆盖（ETag 或大小已变）时不删除
+// 启用版本控制的桶写入删除标记并保留文件
+func expireObject(m *MetadataStore, f *FileStore, b *Bucket, obj *Object) (bool, error) {
+	if b.VersioningEnabled {
+		return m.PutDeleteMarker(b.Name, obj.Key, generateRandomKey(32), obj.ETag, obj.Size)
+	}
+	deleted, err := m.DeleteObjectIfMatch(b.Name, obj.Key, obj.ETag, obj.Size)
+	if err != nil || !deleted {
+		return false, err
+	}
+	if err := f.DeleteObject(obj.StoragePath); err != nil && !os.IsNotExist(err) {
+		return true, err
+	}
+	return true, nil
+}
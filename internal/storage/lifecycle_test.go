@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestValidateLifecycleRules 测试生命周期规则校验
+func TestValidateLifecycleRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []LifecycleRule
+		wantErr bool
+	}{
+		{"空规则", nil, false},
+		{"合法规则", []LifecycleRule{{ID: "logs", Prefix: "logs/", Days: 30, Enabled: true}, {Days: 1}}, false},
+		{"天数为零", []LifecycleRule{{ID: "a", Days: 0}}, true},
+		{"天数为负", []LifecycleRule{{ID: "a", Days: -1}}, true},
+		{"ID 重复", []LifecycleRule{{ID: "a", Days: 1}, {ID: "a", Days: 2}}, true},
+		{"ID 过长", []LifecycleRule{{ID: strings.Repeat("x", 256), Days: 1}}, true},
+		{"规则过多", make([]LifecycleRule, MaxLifecycleRules+1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateLifecycleRules(tt.rules); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLifecycleRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestBucketLifecycleStorage 测试生命周期规则的保存和删除
+func TestBucketLifecycleStorage(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	store.CreateBucket("lc-bucket")
+	rules := []LifecycleRule{{ID: "logs", Prefix: "logs/", Days: 7, Enabled: true}}
+	if err := store.UpdateBucketLifecycle("lc-bucket", rules); err != nil {
+		t.Fatalf("保存生命周期规则失败: %v", err)
+	}
+	b, _ := store.GetBucket("lc-bucket")
+	if len(b.LifecycleRules) != 1 || b.LifecycleRules[0] != rules[0] {
+		t.Errorf("读取的规则不一致: %+v", b.LifecycleRules)
+	}
+	buckets, _ := store.ListBuckets()
+	if len(buckets) != 1 || len(buckets[0].LifecycleRules) != 1 {
+		t.Errorf("列举桶应包含生命周期规则: %+v", buckets)
+	}
+
+	if err := store.UpdateBucketLifecycle("lc-bucket", nil); err != nil {
+		t.Fatalf("删除生命周期规则失败: %v", err)
+	}
+	if b, _ := store.GetBucket("lc-bucket"); b.LifecycleRules != nil {
+		t.Errorf("规则应已删除: %+v", b.LifecycleRules)
+	}
+}
+
+// TestLifecycleServiceRun 测试生命周期过期扫描
+func TestLifecycleServiceRun(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	fs, _ := setupFileStore(t)
+
+	put := func(bucket, key, versionID string) string {
+		path, etag, err := fs.PutObject(bucket, key, strings.NewReader(key), int64(len(key)))
+		if err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+		store.PutObject(&Object{Bucket: bucket, Key: key, Size: int64(len(key)), ETag: etag, StoragePath: path, VersionID: versionID, LastModified: time.Now().UTC()})
+		return path
+	}
+
+	store.CreateBucket("lc-bucket")
+	store.UpdateBucketLifecycle("lc-bucket", []LifecycleRule{
+		{ID: "logs", Prefix: "logs/", Days: 30, Enabled: true},
+		{ID: "tmp", Prefix: "tmp/", Days: 1, Enabled: false},
+	})
+	expiredPath := put("lc-bucket", "logs/a.log", "")
+	put("lc-bucket", "logs_b.log", "")
+	put("lc-bucket", "tmp/c.txt", "")
+	put("lc-bucket", "keep.txt", "")
+
+	store.CreateBucket("lc-versioned")
+	store.UpdateBucketVersioning("lc-versioned", true)
+	store.UpdateBucketLifecycle("lc-versioned", []LifecycleRule{{Days: 30, Enabled: true}})
+	put("lc-versioned", "doc.txt", "v1")
+
+	store.CreateBucket("lc-frozen")
+	store.UpdateBucketReadOnly("lc-frozen", true)
+	store.UpdateBucketLifecycle("lc-frozen", []LifecycleRule{{Days: 1, Enabled: true}})
+	put("lc-frozen", "frozen.txt", "")
+
+	integrity := &IntegrityProgress{}
+	var deleted []string
+	service := &LifecycleService{store: store, filestore: fs, integrity: integrity}
+	service.OnDelete(func(obj *Object) { deleted = append(deleted, obj.Bucket+"/"+obj.Key) })
+
+	// 未到过期天数时不删除
+	if stats := service.Run(time.Now()); stats == nil || stats.Deleted != 0 || stats.Buckets != 2 {
+		t.Fatalf("未过期时不应删除: %+v", stats)
+	}
+
+	// 完整性检查运行期间推迟
+	if !integrity.TryStart() {
+		t.Fatal("开始完整性检查失败")
+	}
+	later := time.Now().AddDate(0, 0, 31)
+	if stats := service.Run(later); stats == nil || !stats.Deferred || stats.Deleted != 0 {
+		t.Fatalf("完整性检查期间应推迟: %+v", stats)
+	}
+	integrity.Finish()
+
+	stats := service.Run(later)
+	if stats == nil || stats.Error != "" {
+		t.Fatalf("扫描失败: %+v", stats)
+	}
+	if stats.Deleted != 2 || stats.DeletedBytes != int64(len("logs/a.log")) {
+		t.Errorf("统计错误: %+v", stats)
+	}
+	if len(deleted) != 2 || deleted[0] != "lc-bucket/logs/a.log" || deleted[1] != "lc-versioned/doc.txt" {
+		t.Errorf("删除回调错误: %v", deleted)
+	}
+	if obj, _ := store.GetObject("lc-bucket", "logs/a.log"); obj != nil {
+		t.Error("过期对象元数据应已删除")
+	}
+	if _, err := os.Stat(expiredPath); !os.IsNotExist(err) {
+		t.Error("过期对象文件应已删除")
+	}
+	for _, key := range []string{"logs_b.log", "tmp/c.txt", "keep.txt"} {
+		if obj, _ := store.GetObject("lc-bucket", key); obj == nil {
+			t.Errorf("%s 不应被删除", key)
+		}
+	}
+	if obj, _ := store.GetObject("lc-frozen", "frozen.txt"); obj == nil {
+		t.Error("只读桶中的对象不应被删除")
+	}
+
+	// 启用版本控制的桶写入删除标记，历史版本保留
+	versions, _ := store.ListObjectVersions("lc-versioned", "doc.txt")
+	if len(versions) != 2 || !versions[0].DeleteMarker || versions[1].VersionID != "v1" {
+		t.Errorf("应写入删除标记并保留历史版本: %+v", versions)
+	}
+
+	status := service.Status()
+	if status.LastRun == nil || status.LastRun.Deleted != 2 || status.Running {
+		t.Errorf("状态错误: %+v", status)
+	}
+
+	// 扫描期间不能开始完整性检查
+	if !integrity.TryBlock() || integrity.TryStart() {
+		t.Error("生命周期扫描期间不应开始完整性检查")
+	}
+	integrity.Unblock()
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -14,6 +15,8 @@ import (
 type MetadataStore struct {
 	db    *sql.DB
 	wmu   sync.Mutex // 写操作互斥锁，确保写入串行化
+
+	deletedBuckets map[string]bool // 已强制删除的桶，受 wmu 保护
 }
 
 // NewMetadataStore 创建元数据存储
@@ -86,8 +89,13 @@ func (m *MetadataStore) initTables() error {
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_objects_bucket ON objects(bucket)`,
 		`CREATE INDEX IF NOT EXISTS idx_objects_prefix ON objects(bucket, key)`,
+		// 对象列表按大小/修改时间排序
+		`CREATE INDEX IF NOT EXISTS idx_objects_size ON objects(bucket, size, key)`,
+		`CREATE INDEX IF NOT EXISTS idx_objects_modified ON objects(bucket, last_modified, key)`,
 		// 优化 last_modified 排序查询（Dashboard 最近文件）
 		`CREATE INDEX IF NOT EXISTS idx_objects_last_modified ON objects(last_modified DESC)`,
+		// 孤立文件清理按存储路径批量核对
+		`CREATE INDEX IF NOT EXISTS idx_objects_storage_path ON objects(storage_path)`,
 		// 优化 multipart_uploads 查询
 		`CREATE INDEX IF NOT EXISTS idx_multipart_bucket ON multipart_uploads(bucket)`,
 		`CREATE INDEX IF NOT EXISTS idx_multipart_initiated ON multipart_uploads(initiated)`,
@@ -141,16 +149,125 @@ func (m *MetadataStore) initTables() error {
 		}
 	}
 
+	// 桶内容类型限制列
+	contentTypeColumns := []struct{ table, name, ddl string }{
+		{"buckets", "content_type_mode", "ALTER TABLE buckets ADD COLUMN content_type_mode TEXT DEFAULT ''"},
+		{"buckets", "content_types", "ALTER TABLE buckets ADD COLUMN content_types TEXT DEFAULT ''"},
+		{"buckets", "content_type_sniff", "ALTER TABLE buckets ADD COLUMN content_type_sniff INTEGER DEFAULT 0"},
+		{"buckets", "key_denylist", "ALTER TABLE buckets ADD COLUMN key_denylist TEXT DEFAULT ''"},
+		{"buckets", "immutable_minutes", "ALTER TABLE buckets ADD COLUMN immutable_minutes INTEGER DEFAULT 0"},
+		{"buckets", "default_headers", "ALTER TABLE buckets ADD COLUMN default_headers TEXT DEFAULT ''"},
+		{"buckets", "website_index", "ALTER TABLE buckets ADD COLUMN website_index TEXT DEFAULT ''"},
+		{"buckets", "website_spa", "ALTER TABLE buckets ADD COLUMN website_spa INTEGER DEFAULT 0"},
+		{"buckets", "max_read_age_days", "ALTER TABLE buckets ADD COLUMN max_read_age_days INTEGER DEFAULT 0"},
+		{"buckets", "image_transform", "ALTER TABLE buckets ADD COLUMN image_transform INTEGER DEFAULT 0"},
+		{"buckets", "allowed_methods", "ALTER TABLE buckets ADD COLUMN allowed_methods TEXT DEFAULT ''"},
+		{"buckets", "prefix_rewrites", "ALTER TABLE buckets ADD COLUMN prefix_rewrites TEXT DEFAULT ''"},
+		{"buckets", "read_age_basis", "ALTER TABLE buckets ADD COLUMN read_age_basis TEXT DEFAULT ''"},
+		{"buckets", "read_only", "ALTER TABLE buckets ADD COLUMN read_only INTEGER DEFAULT 0"},
+		{"buckets", "versioning_enabled", "ALTER TABLE buckets ADD COLUMN versioning_enabled INTEGER DEFAULT 0"},
+		{"buckets", "policy", "ALTER TABLE buckets ADD COLUMN policy TEXT DEFAULT ''"},
+		{"buckets", "lifecycle_rules", "ALTER TABLE buckets ADD COLUMN lifecycle_rules TEXT DEFAULT ''"},
+		{"buckets", "quota_bytes", "ALTER TABLE buckets ADD COLUMN quota_bytes INTEGER DEFAULT 0"},
+		{"api_keys", "namespace", "ALTER TABLE api_keys ADD COLUMN namespace TEXT DEFAULT ''"},
+		{"objects", "headers", "ALTER TABLE objects ADD COLUMN headers TEXT DEFAULT ''"},
+		{"objects", "expires_at", "ALTER TABLE objects ADD COLUMN expires_at INTEGER DEFAULT 0"},
+		{"objects", "created_at", "ALTER TABLE objects ADD COLUMN created_at DATETIME"},
+		{"objects", "tags", "ALTER TABLE objects ADD COLUMN tags TEXT DEFAULT ''"},
+		{"objects", "version_id", "ALTER TABLE objects ADD COLUMN version_id TEXT DEFAULT ''"},
+		{"objects", "encryption_nonce", "ALTER TABLE objects ADD COLUMN encryption_nonce TEXT DEFAULT ''"},
+		{"objects", "metadata", "ALTER TABLE objects ADD COLUMN metadata TEXT DEFAULT ''"},
+		{"multipart_uploads", "metadata", "ALTER TABLE multipart_uploads ADD COLUMN metadata TEXT DEFAULT ''"},
+	}
+	var hasCreatedAt bool
+	if err := m.db.QueryRow("SELECT COUNT(*) > 0 FROM pragma_table_info('objects') WHERE name = 'created_at'").Scan(&hasCreatedAt); err != nil {
+		return fmt.Errorf("check column failed: %v", err)
+	}
+	for _, col := range contentTypeColumns {
+		if err := m.db.QueryRow(`
+			SELECT COUNT(*) > 0
+			FROM pragma_table_info(?)
+			WHERE name = ?
+		`, col.table, col.name).Scan(&columnExists); err != nil {
+			return fmt.Errorf("check column failed: %v", err)
+		}
+		if !columnExists {
+			if _, err := m.db.Exec(col.ddl); err != nil {
+				return fmt.Errorf("add %s column failed: %v", col.name, err)
+			}
+		}
+	}
+	if _, err := m.db.Exec("CREATE INDEX IF NOT EXISTS idx_objects_expires_at ON objects(expires_at) WHERE expires_at > 0"); err != nil {
+		return fmt.Errorf("create expires_at index failed: %v", err)
+	}
+	// 已有对象无法得知首次写入时间，以最后修改时间回填
+	if !hasCreatedAt {
+		if _, err := m.db.Exec("UPDATE objects SET created_at = last_modified WHERE created_at IS NULL"); err != nil {
+			return fmt.Errorf("backfill created_at failed: %v", err)
+		}
+	}
+	if _, err := m.db.Exec("CREATE INDEX IF NOT EXISTS idx_objects_created ON objects(bucket, created_at, key)"); err != nil {
+		return fmt.Errorf("create created_at index failed: %v", err)
+	}
+
 	// 初始化审计日志表
 	if err := m.initAuditTable(); err != nil {
 		return fmt.Errorf("init audit table failed: %v", err)
 	}
 
+	// 初始化复制配置表
+	if err := m.initReplicationTable(); err != nil {
+		return fmt.Errorf("init replication table failed: %v", err)
+	}
+
+	// 初始化事件通知配置表
+	if err := m.initNotificationTable(); err != nil {
+		return fmt.Errorf("init notification table failed: %v", err)
+	}
+
+	// 初始化回源配置表
+	if err := m.initOriginTable(); err != nil {
+		return fmt.Errorf("init origin table failed: %v", err)
+	}
+
 	// 初始化 GeoStats 表
 	if err := m.initGeoStatsTable(); err != nil {
 		return fmt.Errorf("init geo_stats table failed: %v", err)
 	}
 
+	// 初始化匿名下载统计表
+	if err := m.initDownloadTables(); err != nil {
+		return fmt.Errorf("init download tables failed: %v", err)
+	}
+
+	// 初始化 PUT 幂等键表
+	if err := m.initIdempotencyTable(); err != nil {
+		return fmt.Errorf("init idempotency table failed: %v", err)
+	}
+
+	// 初始化对象变体缓存表
+	if err := m.initObjectVariantTable(); err != nil {
+		return fmt.Errorf("init object variant table failed: %v", err)
+	}
+	if err := m.initObjectChecksumTable(); err != nil {
+		return fmt.Errorf("init object variant table failed: %v", err)
+	}
+
+	// 初始化对象计数器表
+	if err := m.initObjectCounterTable(); err != nil {
+		return fmt.Errorf("init object counter table failed: %v", err)
+	}
+
+	// 初始化预签名用量表
+	if err := m.initPresignUsageTable(); err != nil {
+		return fmt.Errorf("init presign usage table failed: %v", err)
+	}
+
+	// 初始化对象版本表
+	if err := m.initObjectVersionTable(); err != nil {
+		return fmt.Errorf("init object version table failed: %v", err)
+	}
+
 	return nil
 }
 
@@ -159,6 +276,12 @@ func (m *MetadataStore) Close() error {
 	return m.db.Close()
 }
 
+// Ping 检查数据库是否可用
+func (m *MetadataStore) Ping() error {
+	var one int
+	return m.db.QueryRow("SELECT 1").Scan(&one)
+}
+
 // withWriteLock 执行写操作（带互斥锁）
 func (m *MetadataStore) withWriteLock(fn func() error) error {
 	m.wmu.Lock()
@@ -174,6 +297,9 @@ func (m *MetadataStore) CreateBucket(name string) error {
 			"INSERT INTO buckets (name, creation_date, is_public) VALUES (?, ?, ?)",
 			name, time.Now().UTC(), 0,
 		)
+		if err == nil {
+			delete(m.deletedBuckets, name)
+		}
 		return err
 	})
 }
@@ -197,28 +323,58 @@ func (m *MetadataStore) DeleteBucket(name string) error {
 	if count > 0 {
 		return fmt.Errorf("bucket not empty")
 	}
+	// 历史版本和删除标记同样占用桶
+	if err := tx.QueryRow("SELECT COUNT(*) FROM object_versions WHERE bucket = ?", name).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("bucket not empty")
+	}
 
 	// 删除桶
 	if _, err := tx.Exec("DELETE FROM buckets WHERE name = ?", name); err != nil {
 		return err
 	}
+	if _, err := tx.Exec("DELETE FROM bucket_replication WHERE bucket = ?", name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM bucket_notification WHERE bucket = ?", name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM bucket_origin WHERE bucket = ?", name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM object_downloads WHERE bucket = ?", name); err != nil {
+		return err
+	}
 
 	return tx.Commit()
 }
 
+// bucketColumns 桶查询字段
+const bucketColumns = "name, creation_date, is_public, COALESCE(content_type_mode, ''), COALESCE(content_types, ''), COALESCE(content_type_sniff, 0), COALESCE(key_denylist, ''), COALESCE(immutable_minutes, 0), COALESCE(default_headers, ''), COALESCE(website_index, ''), COALESCE(website_spa, 0), COALESCE(max_read_age_days, 0), COALESCE(image_transform, 0), COALESCE(allowed_methods, ''), COALESCE(prefix_rewrites, ''), COALESCE(read_age_basis, ''), COALESCE(read_only, 0), COALESCE(versioning_enabled, 0), COALESCE(policy, ''), COALESCE(lifecycle_rules, ''), COALESCE(quota_bytes, 0)"
+
 func (m *MetadataStore) GetBucket(name string) (*Bucket, error) {
 	var bucket Bucket
+	var defaultHeaders, prefixRewrites, policy, lifecycleRules string
 	err := m.db.QueryRow(
-		"SELECT name, creation_date, is_public FROM buckets WHERE name = ?", name,
-	).Scan(&bucket.Name, &bucket.CreationDate, &bucket.IsPublic)
+		"SELECT "+bucketColumns+" FROM buckets WHERE name = ?", name,
+	).Scan(&bucket.Name, &bucket.CreationDate, &bucket.IsPublic,
+		&bucket.ContentTypeMode, &bucket.ContentTypes, &bucket.ContentTypeSniff, &bucket.KeyDenylist, &bucket.ImmutableMinutes, &defaultHeaders,
+		&bucket.WebsiteIndex, &bucket.WebsiteSPA, &bucket.MaxReadAgeDays, &bucket.ImageTransform, &bucket.AllowedMethods, &prefixRewrites,
+		&bucket.ReadAgeBasis, &bucket.ReadOnly, &bucket.VersioningEnabled, &policy, &lifecycleRules, &bucket.QuotaBytes)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	bucket.DefaultHeaders = decodeHeaders(defaultHeaders)
+	bucket.PrefixRewrites = decodePrefixRewrites(prefixRewrites)
+	bucket.Policy = decodeBucketPolicy(policy)
+	bucket.LifecycleRules = decodeLifecycleRules(lifecycleRules)
 	return &bucket, err
 }
 
 func (m *MetadataStore) ListBuckets() ([]Bucket, error) {
-	rows, err := m.db.Query("SELECT name, creation_date, is_public FROM buckets ORDER BY name")
+	rows, err := m.db.Query("SELECT " + bucketColumns + " FROM buckets ORDER BY name")
 	if err != nil {
 		return nil, err
 	}
@@ -227,14 +383,29 @@ func (m *MetadataStore) ListBuckets() ([]Bucket, error) {
 	var buckets []Bucket
 	for rows.Next() {
 		var b Bucket
-		if err := rows.Scan(&b.Name, &b.CreationDate, &b.IsPublic); err != nil {
+		var defaultHeaders, prefixRewrites, policy, lifecycleRules string
+		if err := rows.Scan(&b.Name, &b.CreationDate, &b.IsPublic,
+			&b.ContentTypeMode, &b.ContentTypes, &b.ContentTypeSniff, &b.KeyDenylist, &b.ImmutableMinutes, &defaultHeaders,
+			&b.WebsiteIndex, &b.WebsiteSPA, &b.MaxReadAgeDays, &b.ImageTransform, &b.AllowedMethods, &prefixRewrites,
+			&b.ReadAgeBasis, &b.ReadOnly, &b.VersioningEnabled, &policy, &lifecycleRules, &b.QuotaBytes); err != nil {
 			return nil, err
 		}
+		b.DefaultHeaders = decodeHeaders(defaultHeaders)
+		b.PrefixRewrites = decodePrefixRewrites(prefixRewrites)
+		b.Policy = decodeBucketPolicy(policy)
+		b.LifecycleRules = decodeLifecycleRules(lifecycleRules)
 		buckets = append(buckets, b)
 	}
 	return buckets, nil
 }
 
+// CountBuckets 统计桶数量
+func (m *MetadataStore) CountBuckets() (int, error) {
+	var count int
+	err := m.db.QueryRow("SELECT COUNT(*) FROM buckets").Scan(&count)
+	return count, err
+}
+
 // UpdateBucketPublic 设置桶的公有/私有状态
 func (m *MetadataStore) UpdateBucketPublic(name string, isPublic bool) error {
 	return m.withWriteLock(func() error {
@@ -246,29 +417,206 @@ func (m *MetadataStore) UpdateBucketPublic(name string, isPublic bool) error {
 	})
 }
 
-// === Object 操作 ===
+// UpdateBucketKeyDenylist 设置桶的保留键列表
+func (m *MetadataStore) UpdateBucketKeyDenylist(name, denylist string) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET key_denylist = ? WHERE name = ?", denylist, name)
+		return err
+	})
+}
 
-func (m *MetadataStore) PutObject(obj *Object) error {
+// UpdateBucketImmutability 设置桶的对象不可变窗口（分钟）
+func (m *MetadataStore) UpdateBucketImmutability(name string, minutes int) error {
 	return m.withWriteLock(func() error {
-		_, err := m.db.Exec(`
-			INSERT OR REPLACE INTO objects (bucket, key, size, etag, content_type, last_modified, storage_path)
-			VALUES (?, ?, ?, ?, ?, ?, ?)`,
-			obj.Bucket, obj.Key, obj.Size, obj.ETag, obj.ContentType, obj.LastModified, obj.StoragePath,
+		_, err := m.db.Exec("UPDATE buckets SET immutable_minutes = ? WHERE name = ?", minutes, name)
+		return err
+	})
+}
+
+// UpdateBucketMaxReadAge 设置桶的最大可读天数及其计算基准（modified/created，空为 modified）
+func (m *MetadataStore) UpdateBucketMaxReadAge(name string, days int, basis string) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET max_read_age_days = ?, read_age_basis = ? WHERE name = ?", days, basis, name)
+		return err
+	})
+}
+
+// UpdateBucketImageTransform 设置桶是否启用图片按需缩放
+func (m *MetadataStore) UpdateBucketImageTransform(name string, enabled bool) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET image_transform = ? WHERE name = ?", enabled, name)
+		return err
+	})
+}
+
+// UpdateBucketAllowedMethods 设置桶允许的 HTTP 方法（逗号分隔，空表示不限制）
+func (m *MetadataStore) UpdateBucketAllowedMethods(name, methods string) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET allowed_methods = ? WHERE name = ?", methods, name)
+		return err
+	})
+}
+
+// UpdateBucketReadOnly 设置桶是否只读（冻结 S3 API 写入）
+func (m *MetadataStore) UpdateBucketReadOnly(name string, readOnly bool) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET read_only = ? WHERE name = ?", readOnly, name)
+		return err
+	})
+}
+
+// UpdateBucketVersioning 设置桶是否启用版本控制，关闭后已有版本保留
+func (m *MetadataStore) UpdateBucketVersioning(name string, enabled bool) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET versioning_enabled = ? WHERE name = ?", enabled, name)
+		return err
+	})
+}
+
+// UpdateBucketPrefixRewrites 设置桶的对象键前缀改写规则
+func (m *MetadataStore) UpdateBucketPrefixRewrites(name string, rules []PrefixRewrite) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET prefix_rewrites = ? WHERE name = ?", encodePrefixRewrites(rules), name)
+		return err
+	})
+}
+
+// UpdateBucketDefaultHeaders 设置桶的默认响应头
+func (m *MetadataStore) UpdateBucketDefaultHeaders(name string, headers map[string]string) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET default_headers = ? WHERE name = ?", encodeHeaders(headers), name)
+		return err
+	})
+}
+
+// UpdateBucketWebsite 设置桶的静态网站索引文档与单页应用回退
+func (m *MetadataStore) UpdateBucketWebsite(name, indexDocument string, spa bool) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("UPDATE buckets SET website_index = ?, website_spa = ? WHERE name = ?", indexDocument, spa, name)
+		return err
+	})
+}
+
+// UpdateBucketContentTypes 设置桶的内容类型限制
+func (m *MetadataStore) UpdateBucketContentTypes(name, mode, contentTypes string, sniff bool) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec(
+			"UPDATE buckets SET content_type_mode = ?, content_types = ?, content_type_sniff = ? WHERE name = ?",
+			mode, contentTypes, sniff, name,
 		)
 		return err
 	})
 }
 
+// === Object 操作 ===
+
+func (m *MetadataStore) PutObject(obj *Object) error {
+	return m.withWriteLock(func() error {
+		if m.deletedBuckets[obj.Bucket] {
+			return ErrBucketDeleted
+		}
+		return m.putObjectLocked(obj)
+	})
+}
+
+// ErrPreconditionFailed 条件写入的条件不满足
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// WriteCondition 条件写入：If-None-Match: * 要求对象不存在，If-Match 要求已有对象的 ETag 一致（* 表示存在即可）
+type WriteCondition struct {
+	IfNoneMatch bool
+	IfMatch     string
+}
+
+// Satisfied 判断已有对象（不存在时为空）是否满足条件
+func (c WriteCondition) Satisfied(exists bool, etag string) bool {
+	if c.IfNoneMatch && exists {
+		return false
+	}
+	if c.IfMatch != "" && (!exists || c.IfMatch != "*" && c.IfMatch != etag) {
+		return false
+	}
+	return true
+}
+
+// PutObjectIf 条件写入对象元数据，检查条件、配额、commit（如替换对象文件）与写入元数据在写锁内完成
+// 条件不满足时不调用 commit 并返回 ErrPreconditionFailed，超出桶配额时返回 ErrQuotaExceeded
+func (m *MetadataStore) PutObjectIf(obj *Object, cond WriteCondition, commit func() error) error {
+	return m.withWriteLock(func() error {
+		if m.deletedBuckets[obj.Bucket] {
+			return ErrBucketDeleted
+		}
+		var etag string
+		err := m.db.QueryRow("SELECT etag FROM objects WHERE bucket = ? AND key = ?", obj.Bucket, obj.Key).Scan(&etag)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if !cond.Satisfied(err == nil, etag) {
+			return ErrPreconditionFailed
+		}
+		if err := m.CheckBucketQuota(obj.Bucket, obj.Key, obj.Size); err != nil {
+			return err
+		}
+		if commit != nil {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
+		return m.putObjectLocked(obj)
+	})
+}
+
+// putObjectLocked 写入对象元数据（需持有写锁）
+// 被覆盖的对象有独立的版本文件时先转为历史版本，见 archiveCurrentLocked
+func (m *MetadataStore) putObjectLocked(obj *Object) error {
+	if err := m.archiveCurrentLocked(obj); err != nil {
+		return err
+	}
+	// 覆盖已存在的对象时保留首次写入时间
+	var created sql.NullTime
+	err := m.db.QueryRow("SELECT created_at FROM objects WHERE bucket = ? AND key = ?", obj.Bucket, obj.Key).Scan(&created)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if created.Valid {
+		obj.CreatedAt = created.Time
+	} else if obj.CreatedAt.IsZero() {
+		obj.CreatedAt = obj.LastModified
+	}
+	_, err = m.db.Exec(`
+		INSERT INTO objects (bucket, key, size, etag, content_type, last_modified, storage_path, headers, expires_at, created_at, tags, version_id,
+			encryption_nonce, metadata)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(bucket, key) DO UPDATE SET
+			size = excluded.size, etag = excluded.etag, content_type = excluded.content_type,
+			last_modified = excluded.last_modified, storage_path = excluded.storage_path,
+			headers = excluded.headers, expires_at = excluded.expires_at, created_at = excluded.created_at,
+			tags = excluded.tags, version_id = excluded.version_id, encryption_nonce = excluded.encryption_nonce,
+			metadata = excluded.metadata`,
+		obj.Bucket, obj.Key, obj.Size, obj.ETag, obj.ContentType, obj.LastModified, obj.StoragePath, encodeHeaders(obj.Headers),
+		expiresAtUnix(obj.ExpiresAt), obj.CreatedAt, encodeHeaders(obj.Tags), obj.VersionID, obj.EncryptionNonce, encodeHeaders(obj.Metadata),
+	)
+	return err
+}
+
 func (m *MetadataStore) GetObject(bucket, key string) (*Object, error) {
 	var obj Object
+	var headers, tags, metadata string
+	var expiresAt int64
 	err := m.db.QueryRow(`
-		SELECT bucket, key, size, etag, content_type, last_modified, storage_path
+		SELECT bucket, key, size, etag, content_type, last_modified, storage_path, COALESCE(headers, ''), COALESCE(expires_at, 0),
+			created_at, COALESCE(tags, ''), COALESCE(version_id, ''), COALESCE(encryption_nonce, ''), COALESCE(metadata, '')
 		FROM objects WHERE bucket = ? AND key = ?`,
 		bucket, key,
-	).Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag, &obj.ContentType, &obj.LastModified, &obj.StoragePath)
+	).Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag, &obj.ContentType, &obj.LastModified, &obj.StoragePath, &headers, &expiresAt,
+		&obj.CreatedAt, &tags, &obj.VersionID, &obj.EncryptionNonce, &metadata)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	obj.Headers = decodeHeaders(headers)
+	obj.Tags = decodeHeaders(tags)
+	obj.Metadata = decodeHeaders(metadata)
+	obj.ExpiresAt = expiresAtTime(expiresAt)
 	return &obj, err
 }
 
@@ -279,7 +627,115 @@ func (m *MetadataStore) DeleteObject(bucket, key string) error {
 	})
 }
 
+// DeleteObjectIfMatch 条件删除对象元数据，检查与删除在同一条语句中完成
+// etag 为空或 size < 0 时不检查对应条件，返回是否实际删除
+func (m *MetadataStore) DeleteObjectIfMatch(bucket, key, etag string, size int64) (bool, error) {
+	var deleted bool
+	err := m.withWriteLock(func() error {
+		query := "DELETE FROM objects WHERE bucket = ? AND key = ?"
+		args := []interface{}{bucket, key}
+		if etag != "" {
+			query += " AND etag = ?"
+			args = append(args, etag)
+		}
+		if size >= 0 {
+			query += " AND size = ?"
+			args = append(args, size)
+		}
+		res, err := m.db.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		deleted = n > 0
+		return err
+	})
+	return deleted, err
+}
+
+// RestoreObject 按原有元数据重新插入对象，键已被重新写入时不覆盖，返回是否插入
+func (m *MetadataStore) RestoreObject(obj *Object) (bool, error) {
+	var restored bool
+	err := m.withWriteLock(func() error {
+		res, err := m.db.Exec(`
+			INSERT INTO objects (bucket, key, size, etag, content_type, last_modified, storage_path, headers, expires_at, created_at, tags, version_id,
+				encryption_nonce, metadata)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(bucket, key) DO NOTHING`,
+			obj.Bucket, obj.Key, obj.Size, obj.ETag, obj.ContentType, obj.LastModified, obj.StoragePath, encodeHeaders(obj.Headers),
+			expiresAtUnix(obj.ExpiresAt), obj.CreatedAt, encodeHeaders(obj.Tags), obj.VersionID, obj.EncryptionNonce, encodeHeaders(obj.Metadata),
+		)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		restored = n > 0
+		return err
+	})
+	return restored, err
+}
+
 func (m *MetadataStore) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (*ListObjectsResult, error) {
+	return m.QueryObjects(&ObjectListQuery{Bucket: bucket, Prefix: prefix, Marker: marker, Delimiter: delimiter, MaxKeys: maxKeys})
+}
+
+// ListObjectsWithoutFolderMarkers 列出对象，文件夹占位对象不出现在 Contents 中
+// 指定分隔符时占位对象所在的目录仍以 CommonPrefixes 形式出现
+func (m *MetadataStore) ListObjectsWithoutFolderMarkers(bucket, prefix, marker, delimiter string, maxKeys int) (*ListObjectsResult, error) {
+	return m.QueryObjects(&ObjectListQuery{Bucket: bucket, Prefix: prefix, Marker: marker, Delimiter: delimiter, MaxKeys: maxKeys, HideFolderMarkers: true})
+}
+
+// ObjectListQuery 对象列表查询条件
+type ObjectListQuery struct {
+	Bucket            string
+	Prefix            string
+	Marker            string // 上一页最后一个对象的键
+	Delimiter         string
+	MaxKeys           int
+	Sort              ObjectSort // 零值按键升序
+	HideFolderMarkers bool       // 文件夹占位对象不出现在 Contents 中
+	// Deadline 扫描的软时间预算，超过后在行边界截断，返回已列出的部分和可续传的 NextMarker，零值表示不限制
+	Deadline time.Time
+}
+
+// QueryObjects 按条件列出对象，排序在 SQL 中完成
+// 非按键排序时 Marker 对应的对象须仍然存在，否则返回 ErrMarkerNotFound
+func (m *MetadataStore) QueryObjects(q *ObjectListQuery) (*ListObjectsResult, error) {
+	var contents []Object
+	var prefixes []string
+	result, err := m.WalkObjects(q, ObjectListVisitor{
+		Object: func(obj *Object) error {
+			contents = append(contents, *obj)
+			return nil
+		},
+		CommonPrefix: func(prefix string) error {
+			prefixes = append(prefixes, prefix)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Contents = contents
+	result.CommonPrefixes = prefixes
+	return result, nil
+}
+
+// ObjectListVisitor 流式列举回调，按查询顺序逐个接收对象和公共前缀，返回错误时中止遍历
+type ObjectListVisitor struct {
+	Object       func(obj *Object) error
+	CommonPrefix func(prefix string) error
+}
+
+// walkPageSize WalkObjects 每次查询读取的行数，一页读完即关闭游标再回调
+var walkPageSize = 1000
+
+// WalkObjects 按条件流式列出对象并回调，按键集分页查询，内存中只保留一页
+// 返回的结果只包含分页信息（IsTruncated、NextMarker、KeyCount），Contents 和 CommonPrefixes 为空
+// 每页读完后先关闭游标再回调，回调阻塞（如客户端读取缓慢）时不占用数据库连接
+func (m *MetadataStore) WalkObjects(q *ObjectListQuery, v ObjectListVisitor) (*ListObjectsResult, error) {
+	bucket, prefix, marker, delimiter, maxKeys := q.Bucket, q.Prefix, q.Marker, q.Delimiter, q.MaxKeys
+	hideFolderMarkers := q.HideFolderMarkers
 	result := &ListObjectsResult{
 		Name:      bucket,
 		Prefix:    prefix,
@@ -287,71 +743,195 @@ func (m *MetadataStore) ListObjects(bucket, prefix, marker, delimiter string, ma
 		MaxKeys:   maxKeys,
 	}
 
-	query := "SELECT bucket, key, size, etag, content_type, last_modified, storage_path FROM objects WHERE bucket = ?"
+	col := q.Sort.column()
+	byKey := col == "key"
+	cmp := " > "
+	if q.Sort.Desc {
+		cmp = " < "
+	}
+
+	query := "SELECT bucket, key, size, etag, content_type, last_modified, storage_path, created_at"
+	if !byKey {
+		// 同时取出排序列在库中存储的原始值作为下一页的游标，避免时间等类型往返转换后不一致
+		query += ", CAST(" + col + " AS TEXT)"
+	}
+	query += " FROM objects WHERE bucket = ?"
 	args := []interface{}{bucket}
 
 	if prefix != "" {
-		query += " AND key LIKE ?"
-		args = append(args, prefix+"%")
+		query += " AND key LIKE ? ESCAPE '\\'"
+		args = append(args, escapeLikePattern(prefix)+"%")
 	}
-	if marker != "" {
-		query += " AND key > ?"
-		args = append(args, marker)
+	// 在 SQL 中排除会落入 Contents 的占位对象，避免其占用 LIMIT 导致分页截断判断错误：
+	// 无分隔符时排除全部占位对象，有分隔符时只有与前缀相同的占位对象不会归入 CommonPrefixes
+	if hideFolderMarkers {
+		if delimiter == "" {
+			query += " AND NOT (size = 0 AND key LIKE '%/')"
+		} else {
+			query += " AND NOT (size = 0 AND key = ? AND key LIKE '%/')"
+			args = append(args, prefix)
+		}
 	}
 
-	query += " ORDER BY key LIMIT ?"
-	args = append(args, maxKeys+1)
+	// 第一页从标记之后开始
+	cursor, cursorArgs := "", []interface{}(nil)
+	if marker != "" {
+		if byKey {
+			cursor, cursorArgs = " AND key"+cmp+"?", []interface{}{marker}
+		} else {
+			// 直接比较库中存储的值，避免时间等类型往返转换后不一致
+			exists, err := m.GetObject(bucket, marker)
+			if err != nil {
+				return nil, err
+			}
+			if exists == nil {
+				return nil, ErrMarkerNotFound
+			}
+			cursor = " AND (" + col + ", key)" + cmp + "((SELECT " + col + " FROM objects WHERE bucket = ? AND key = ?), ?)"
+			cursorArgs = []interface{}{bucket, marker, marker}
+		}
+	}
 
-	rows, err := m.db.Query(query, args...)
-	if err != nil {
-		return nil, err
+	// 有分隔符时多行可能合并为一个公共前缀，行数无法预估，按页读取直到凑满 maxKeys；无分隔符时最多读 maxKeys+1 行
+	pageSize := walkPageSize
+	if delimiter == "" && maxKeys+1 < pageSize {
+		pageSize = maxKeys + 1
 	}
-	defer rows.Close()
 
+	// 按键排序时同一公共前缀的对象相邻，只需记住上一个前缀；其他排序需要记录全部已出现的前缀
+	lastPrefix := ""
 	prefixSet := make(map[string]bool)
-	for rows.Next() {
-		var obj Object
-		if err := rows.Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag, &obj.ContentType, &obj.LastModified, &obj.StoragePath); err != nil {
-			return nil, err
+	// 标记落在某个公共前缀内（如超时截断于前缀中途）时，该前缀已在上一页返回，不再重复
+	if byKey && delimiter != "" && strings.HasPrefix(marker, prefix) {
+		if idx := strings.Index(marker[len(prefix):], delimiter); idx >= 0 {
+			lastPrefix = marker[:len(prefix)+idx+len(delimiter)]
+		}
+	}
+	lastScanned := ""
+
+	// visit 处理一行，返回 true 表示列表已凑满或超时，停止遍历
+	visit := func(obj *Object) (bool, error) {
+		// 超出时间预算时从上一行之后续传，上一行可能已归入公共前缀或被隐藏，至少处理一行保证进展
+		if lastScanned != "" && !q.Deadline.IsZero() && time.Now().After(q.Deadline) {
+			result.IsTruncated = true
+			result.BudgetExceeded = true
+			result.NextMarker = lastScanned
+			return true, nil
 		}
+		lastScanned = obj.Key
 
 		// 处理分隔符
-		if delimiter != "" && prefix != "" {
+		if delimiter != "" {
 			rest := strings.TrimPrefix(obj.Key, prefix)
 			if idx := strings.Index(rest, delimiter); idx >= 0 {
-				commonPrefix := prefix + rest[:idx+1]
-				if !prefixSet[commonPrefix] {
+				commonPrefix := prefix + rest[:idx+len(delimiter)]
+				seen := commonPrefix == lastPrefix
+				if !byKey {
+					seen = prefixSet[commonPrefix]
 					prefixSet[commonPrefix] = true
-					result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix)
 				}
-				continue
+				if seen {
+					return false, nil
+				}
+				// 按键排序时公共前缀与对象一样计入 maxKeys，续传标记为前缀本身，下一页会跳过该前缀下的对象
+				if byKey && result.KeyCount >= maxKeys {
+					result.IsTruncated = true
+					return true, nil
+				}
+				lastPrefix = commonPrefix
+				if v.CommonPrefix != nil {
+					if err := v.CommonPrefix(commonPrefix); err != nil {
+						return true, err
+					}
+				}
+				if byKey {
+					result.KeyCount++
+					result.NextMarker = commonPrefix
+				}
+				return false, nil
 			}
 		}
+		if hideFolderMarkers && IsFolderMarker(obj.Key, obj.Size) {
+			return false, nil
+		}
 
-		if len(result.Contents) < maxKeys {
-			result.Contents = append(result.Contents, obj)
-		} else {
+		if result.KeyCount >= maxKeys {
 			result.IsTruncated = true
-			break
+			return true, nil
+		}
+		if v.Object != nil {
+			if err := v.Object(obj); err != nil {
+				return true, err
+			}
 		}
+		result.KeyCount++
+		result.NextMarker = obj.Key
+		return false, nil
 	}
 
-	if len(result.Contents) > 0 {
-		result.NextMarker = result.Contents[len(result.Contents)-1].Key
+	for {
+		page, sortValues, err := m.queryObjectPage(query+cursor+q.Sort.orderBy()+" LIMIT ?",
+			append(append(append([]interface{}{}, args...), cursorArgs...), pageSize), !byKey)
+		if err != nil {
+			return nil, err
+		}
+		for i := range page {
+			if stop, err := visit(&page[i]); stop || err != nil {
+				return result, err
+			}
+		}
+		if len(page) < pageSize {
+			return result, nil
+		}
+		// 下一页从本页最后一行之后开始
+		last := len(page) - 1
+		if byKey {
+			cursor, cursorArgs = " AND key"+cmp+"?", []interface{}{page[last].Key}
+		} else {
+			cursor = " AND (" + col + ", key)" + cmp + "(?, ?)"
+			cursorArgs = []interface{}{sortValues[last], page[last].Key}
+		}
 	}
-	result.KeyCount = len(result.Contents)
+}
 
-	return result, nil
+// queryObjectPage 读取一页对象后立即关闭游标，withSortValue 时每行最后一列为排序列的原始值
+func (m *MetadataStore) queryObjectPage(query string, args []interface{}, withSortValue bool) ([]Object, []interface{}, error) {
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var objects []Object
+	var sortValues []interface{}
+	for rows.Next() {
+		var obj Object
+		dest := []interface{}{&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag, &obj.ContentType, &obj.LastModified, &obj.StoragePath,
+			&obj.CreatedAt}
+		var sortValue interface{}
+		if withSortValue {
+			dest = append(dest, &sortValue)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, obj)
+		sortValues = append(sortValues, sortValue)
+	}
+	return objects, sortValues, rows.Err()
 }
 
 // === Multipart Upload 操作 ===
 
 func (m *MetadataStore) CreateMultipartUpload(upload *MultipartUpload) error {
 	return m.withWriteLock(func() error {
+		if m.deletedBuckets[upload.Bucket] {
+			return ErrBucketDeleted
+		}
 		_, err := m.db.Exec(`
-			INSERT INTO multipart_uploads (upload_id, bucket, key, initiated, content_type)
-			VALUES (?, ?, ?, ?, ?)`,
-			upload.UploadID, upload.Bucket, upload.Key, upload.Initiated, upload.ContentType,
+			INSERT INTO multipart_uploads (upload_id, bucket, key, initiated, content_type, metadata)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			upload.UploadID, upload.Bucket, upload.Key, upload.Initiated, upload.ContentType, encodeHeaders(upload.Metadata),
 		)
 		return err
 	})
@@ -359,16 +939,102 @@ func (m *MetadataStore) CreateMultipartUpload(upload *MultipartUpload) error {
 
 func (m *MetadataStore) GetMultipartUpload(uploadID string) (*MultipartUpload, error) {
 	var upload MultipartUpload
+	var metadata string
 	err := m.db.QueryRow(`
-		SELECT upload_id, bucket, key, initiated, content_type
+		SELECT upload_id, bucket, key, initiated, content_type, COALESCE(metadata, '')
 		FROM multipart_uploads WHERE upload_id = ?`, uploadID,
-	).Scan(&upload.UploadID, &upload.Bucket, &upload.Key, &upload.Initiated, &upload.ContentType)
+	).Scan(&upload.UploadID, &upload.Bucket, &upload.Key, &upload.Initiated, &upload.ContentType, &metadata)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	upload.Metadata = decodeHeaders(metadata)
 	return &upload, err
 }
 
+// CountMultipartUploads 统计桶内未完成的多段上传数量
+func (m *MetadataStore) CountMultipartUploads(bucket string) (int, error) {
+	var count int
+	err := m.db.QueryRow("SELECT COUNT(*) FROM multipart_uploads WHERE bucket = ?", bucket).Scan(&count)
+	return count, err
+}
+
+// ListMultipartUploads 分页列出桶内未完成的多段上传，附带已上传分片数和总大小
+func (m *MetadataStore) ListMultipartUploads(q *MultipartUploadListQuery) (*MultipartUploadList, error) {
+	query := `SELECT mu.upload_id, mu.bucket, mu.key, mu.initiated, mu.content_type,
+		COUNT(p.part_number), COALESCE(SUM(p.size), 0)
+		FROM multipart_uploads mu
+		LEFT JOIN parts p ON p.upload_id = mu.upload_id
+		WHERE mu.bucket = ?`
+	args := []interface{}{q.Bucket}
+	if q.Prefix != "" {
+		query += " AND mu.key LIKE ? ESCAPE '\\'"
+		args = append(args, escapeLikePattern(q.Prefix)+"%")
+	}
+	if q.KeyMarker != "" {
+		if q.UploadIDMarker != "" {
+			query += " AND (mu.key > ? OR (mu.key = ? AND mu.upload_id > ?))"
+			args = append(args, q.KeyMarker, q.KeyMarker, q.UploadIDMarker)
+		} else {
+			query += " AND mu.key > ?"
+			args = append(args, q.KeyMarker)
+		}
+	}
+	query += " GROUP BY mu.upload_id ORDER BY mu.key, mu.upload_id"
+	// 有分隔符时多行可能合并为一个公共前缀，不加 LIMIT，凑满后停止读取游标
+	if q.Delimiter == "" {
+		query += " LIMIT ?"
+		args = append(args, q.MaxUploads+1)
+	}
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &MultipartUploadList{}
+	count := 0
+	lastPrefix := ""
+	// 标记落在某个公共前缀内时，该前缀已在上一页返回
+	if q.Delimiter != "" && strings.HasPrefix(q.KeyMarker, q.Prefix) {
+		if idx := strings.Index(q.KeyMarker[len(q.Prefix):], q.Delimiter); idx >= 0 {
+			lastPrefix = q.KeyMarker[:len(q.Prefix)+idx+len(q.Delimiter)]
+		}
+	}
+	for rows.Next() {
+		var u MultipartUploadInfo
+		if err := rows.Scan(&u.UploadID, &u.Bucket, &u.Key, &u.Initiated, &u.ContentType, &u.PartCount, &u.TotalSize); err != nil {
+			return nil, err
+		}
+		if q.Delimiter != "" {
+			rest := strings.TrimPrefix(u.Key, q.Prefix)
+			if idx := strings.Index(rest, q.Delimiter); idx >= 0 {
+				commonPrefix := q.Prefix + rest[:idx+len(q.Delimiter)]
+				if commonPrefix == lastPrefix {
+					continue
+				}
+				if count >= q.MaxUploads {
+					result.IsTruncated = true
+					break
+				}
+				lastPrefix = commonPrefix
+				result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix)
+				result.NextKeyMarker, result.NextUploadIDMarker = commonPrefix, ""
+				count++
+				continue
+			}
+		}
+		if count >= q.MaxUploads {
+			result.IsTruncated = true
+			break
+		}
+		result.Uploads = append(result.Uploads, u)
+		result.NextKeyMarker, result.NextUploadIDMarker = u.Key, u.UploadID
+		count++
+	}
+	return result, rows.Err()
+}
+
 func (m *MetadataStore) DeleteMultipartUpload(uploadID string) error {
 	return m.withWriteLock(func() error {
 		_, err := m.db.Exec("DELETE FROM multipart_uploads WHERE upload_id = ?", uploadID)
@@ -387,6 +1053,13 @@ func (m *MetadataStore) PutPart(part *Part) error {
 	})
 }
 
+// CountOtherParts 统计上传中除 partNumber 外已保存的分片数（重传同一分片不增加占用）
+func (m *MetadataStore) CountOtherParts(uploadID string, partNumber int) (int, error) {
+	var count int
+	err := m.db.QueryRow("SELECT COUNT(*) FROM parts WHERE upload_id = ? AND part_number != ?", uploadID, partNumber).Scan(&count)
+	return count, err
+}
+
 func (m *MetadataStore) ListParts(uploadID string) ([]Part, error) {
 	rows, err := m.db.Query(`
 		SELECT upload_id, part_number, size, etag, modified_at
@@ -425,7 +1098,7 @@ func escapeLikePattern(pattern string) string {
 }
 
 // SearchObjects 模糊搜索对象（按文件名关键字）
-func (m *MetadataStore) SearchObjects(bucket, keyword string, maxResults int) ([]Object, error) {
+func (m *MetadataStore) SearchObjects(bucket, keyword string, maxResults int, sort ObjectSort) ([]Object, error) {
 	if maxResults <= 0 {
 		maxResults = 100
 	}
@@ -436,7 +1109,7 @@ func (m *MetadataStore) SearchObjects(bucket, keyword string, maxResults int) ([
 	// 转义关键字中的特殊字符，防止SQL注入
 	escapedKeyword := escapeLikePattern(keyword)
 
-	query := "SELECT bucket, key, size, etag, content_type, last_modified, storage_path FROM objects WHERE bucket = ? AND key LIKE ? ESCAPE '\\' ORDER BY key LIMIT ?"
+	query := "SELECT bucket, key, size, etag, content_type, last_modified, storage_path, created_at FROM objects WHERE bucket = ? AND key LIKE ? ESCAPE '\\'" + sort.orderBy() + " LIMIT ?"
 	// 使用 %keyword% 实现模糊匹配
 	args := []interface{}{bucket, "%" + escapedKeyword + "%", maxResults}
 
@@ -449,7 +1122,8 @@ func (m *MetadataStore) SearchObjects(bucket, keyword string, maxResults int) ([
 	var objects []Object
 	for rows.Next() {
 		var obj Object
-		if err := rows.Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag, &obj.ContentType, &obj.LastModified, &obj.StoragePath); err != nil {
+		if err := rows.Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag, &obj.ContentType, &obj.LastModified, &obj.StoragePath,
+			&obj.CreatedAt); err != nil {
 			return nil, err
 		}
 		objects = append(objects, obj)
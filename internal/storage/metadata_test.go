@@ -187,7 +187,7 @@ func TestSearchObjects(t *testing.T) {
 	}
 
 	// 测试正常搜索
-	results, err := store.SearchObjects("test-bucket", "file", 10)
+	results, err := store.SearchObjects("test-bucket", "file", 10, ObjectSort{})
 	if err != nil {
 		t.Fatalf("搜索失败: %v", err)
 	}
@@ -196,7 +196,7 @@ func TestSearchObjects(t *testing.T) {
 	}
 
 	// 测试特殊字符搜索（应该被正确转义）
-	results, err = store.SearchObjects("test-bucket", "100%", 10)
+	results, err = store.SearchObjects("test-bucket", "100%", 10, ObjectSort{})
 	if err != nil {
 		t.Fatalf("搜索特殊字符失败: %v", err)
 	}
@@ -205,7 +205,7 @@ func TestSearchObjects(t *testing.T) {
 	}
 
 	// 测试下划线搜索
-	results, err = store.SearchObjects("test-bucket", "_file", 10)
+	results, err = store.SearchObjects("test-bucket", "_file", 10, ObjectSort{})
 	if err != nil {
 		t.Fatalf("搜索下划线失败: %v", err)
 	}
@@ -214,7 +214,7 @@ func TestSearchObjects(t *testing.T) {
 	}
 
 	// 测试SQL注入尝试（应该安全处理）
-	results, err = store.SearchObjects("test-bucket", "'; DROP TABLE objects; --", 10)
+	results, err = store.SearchObjects("test-bucket", "'; DROP TABLE objects; --", 10, ObjectSort{})
 	if err != nil {
 		t.Fatalf("SQL注入尝试应该被安全处理: %v", err)
 	}
@@ -433,6 +433,514 @@ func TestListObjects(t *testing.T) {
 	})
 }
 
+// TestListObjectsDelimiter 测试分隔符把下一级目录合并为 CommonPrefixes
+func TestListObjectsDelimiter(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	bucket := "delimiter-bucket"
+	store.CreateBucket(bucket)
+	for _, key := range []string{
+		"a/b/c.txt",
+		"a/d.txt",
+		"docs/guide/intro.md",
+		"docs/readme.md",
+		"docs/x/1.txt",
+		"docs/x/2.txt",
+		"docs_old/z.txt",
+		"docsXold/y.txt",
+		"root.txt",
+	} {
+		store.PutObject(&Object{Bucket: bucket, Key: key, Size: 1, ETag: "test", StoragePath: "/path/" + key})
+	}
+
+	keys := func(objs []Object) string {
+		var out []string
+		for _, o := range objs {
+			out = append(out, o.Key)
+		}
+		return strings.Join(out, ",")
+	}
+
+	t.Run("多级路径只返回第一级前缀", func(t *testing.T) {
+		result, err := store.ListObjects(bucket, "", "", "/", 100)
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		if got := strings.Join(result.CommonPrefixes, ","); got != "a/,docs/,docsXold/,docs_old/" {
+			t.Errorf("CommonPrefixes 错误: %s", got)
+		}
+		if got := keys(result.Contents); got != "root.txt" {
+			t.Errorf("合并到前缀的对象不应出现在 Contents 中: %s", got)
+		}
+		if result.KeyCount != 5 || result.IsTruncated {
+			t.Errorf("KeyCount 应包含公共前缀: %+v", result)
+		}
+	})
+
+	t.Run("前缀加分隔符只返回直接子项", func(t *testing.T) {
+		result, err := store.ListObjects(bucket, "docs/", "", "/", 100)
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		if got := strings.Join(result.CommonPrefixes, ","); got != "docs/guide/,docs/x/" {
+			t.Errorf("CommonPrefixes 错误: %s", got)
+		}
+		if got := keys(result.Contents); got != "docs/readme.md" {
+			t.Errorf("Contents 错误: %s", got)
+		}
+	})
+
+	t.Run("前缀中的通配符按字面匹配", func(t *testing.T) {
+		result, err := store.ListObjects(bucket, "docs_", "", "/", 100)
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		if got := strings.Join(result.CommonPrefixes, ","); got != "docs_old/" || len(result.Contents) != 0 {
+			t.Errorf("下划线不应匹配任意字符: prefixes=%s contents=%s", got, keys(result.Contents))
+		}
+	})
+
+	t.Run("多字符分隔符", func(t *testing.T) {
+		result, err := store.ListObjects(bucket, "", "", "/x/", 100)
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		if got := strings.Join(result.CommonPrefixes, ","); got != "docs/x/" {
+			t.Errorf("CommonPrefixes 应包含完整分隔符: %s", got)
+		}
+	})
+
+	t.Run("公共前缀计入分页", func(t *testing.T) {
+		var prefixes, contents []string
+		marker := ""
+		for pages := 0; ; pages++ {
+			if pages > 10 {
+				t.Fatal("分页未结束")
+			}
+			result, err := store.ListObjects(bucket, "", marker, "/", 2)
+			if err != nil {
+				t.Fatalf("列出对象失败: %v", err)
+			}
+			if len(result.CommonPrefixes)+len(result.Contents) > 2 {
+				t.Errorf("单页返回超过 max-keys: %+v", result)
+			}
+			prefixes = append(prefixes, result.CommonPrefixes...)
+			contents = append(contents, keys(result.Contents))
+			if !result.IsTruncated {
+				break
+			}
+			marker = result.NextMarker
+		}
+		if got := strings.Join(prefixes, ","); got != "a/,docs/,docsXold/,docs_old/" {
+			t.Errorf("分页后前缀缺失或重复: %s", got)
+		}
+		if got := strings.Join(contents, ""); got != "root.txt" {
+			t.Errorf("分页后对象缺失: %s", got)
+		}
+	})
+}
+
+// TestQueryObjectsSort 测试对象列表按大小和修改时间排序及分页
+func TestQueryObjectsSort(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	bucket := "sort-bucket"
+	store.CreateBucket(bucket)
+	base := time.Now().UTC().Add(-time.Hour)
+	for i, o := range []struct {
+		key  string
+		size int64
+	}{{"a", 30}, {"b", 10}, {"c", 20}, {"d", 20}} {
+		store.PutObject(&Object{Bucket: bucket, Key: o.key, Size: o.size, ETag: "e", StoragePath: "/path/" + o.key,
+			LastModified: base.Add(time.Duration(3-i) * time.Minute)})
+	}
+
+	keys := func(objs []Object) string {
+		var out []string
+		for _, o := range objs {
+			out = append(out, o.Key)
+		}
+		return strings.Join(out, ",")
+	}
+	// listAll 逐页列出所有对象，验证分页标记
+	listAll := func(sort ObjectSort) string {
+		var all []Object
+		marker := ""
+		for {
+			result, err := store.QueryObjects(&ObjectListQuery{Bucket: bucket, Marker: marker, MaxKeys: 1, Sort: sort})
+			if err != nil {
+				t.Fatalf("列出对象失败: %v", err)
+			}
+			all = append(all, result.Contents...)
+			if !result.IsTruncated {
+				return keys(all)
+			}
+			marker = result.NextMarker
+		}
+	}
+
+	for _, tc := range []struct {
+		sort ObjectSort
+		want string
+	}{
+		{ObjectSort{}, "a,b,c,d"},
+		{ObjectSort{Field: ObjectSortKey, Desc: true}, "d,c,b,a"},
+		{ObjectSort{Field: ObjectSortSize}, "b,c,d,a"},
+		{ObjectSort{Field: ObjectSortSize, Desc: true}, "a,d,c,b"},
+		{ObjectSort{Field: ObjectSortModified, Desc: true}, "a,b,c,d"},
+		{ObjectSort{Field: ObjectSortModified}, "d,c,b,a"},
+		{ObjectSort{Field: ObjectSortCreated, Desc: true}, "a,b,c,d"},
+	} {
+		if got := listAll(tc.sort); got != tc.want {
+			t.Errorf("%s %s: 期望 %s, 实际 %s", tc.sort.Field, tc.sort.Order(), tc.want, got)
+		}
+	}
+
+	t.Run("分页标记对象已删除", func(t *testing.T) {
+		_, err := store.QueryObjects(&ObjectListQuery{Bucket: bucket, Marker: "missing", MaxKeys: 1, Sort: ObjectSort{Field: ObjectSortSize}})
+		if err != ErrMarkerNotFound {
+			t.Errorf("期望 ErrMarkerNotFound, 实际 %v", err)
+		}
+	})
+
+	t.Run("解析排序参数", func(t *testing.T) {
+		if s, err := ParseObjectSort("", ""); err != nil || s.Field != ObjectSortKey || s.Desc {
+			t.Errorf("默认排序错误: %+v %v", s, err)
+		}
+		if s, err := ParseObjectSort("modified", "desc"); err != nil || s.Field != ObjectSortModified || !s.Desc {
+			t.Errorf("解析错误: %+v %v", s, err)
+		}
+		for _, bad := range [][2]string{{"etag", ""}, {"size", "up"}} {
+			if _, err := ParseObjectSort(bad[0], bad[1]); err == nil {
+				t.Errorf("%v 应返回错误", bad)
+			}
+		}
+	})
+}
+
+// TestPutObjectIf 测试条件写入对象元数据
+func TestPutObjectIf(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	bucket := "cond-bucket"
+	store.CreateBucket(bucket)
+	commits := 0
+	commit := func() error { commits++; return nil }
+	put := func(etag string, cond WriteCondition) error {
+		return store.PutObjectIf(&Object{Bucket: bucket, Key: "k", ETag: etag, StoragePath: "/p/k", LastModified: time.Now()}, cond, commit)
+	}
+
+	if err := put("e1", WriteCondition{IfMatch: "*"}); err != ErrPreconditionFailed {
+		t.Errorf("对象不存在时 If-Match 应失败: %v", err)
+	}
+	if err := put("e1", WriteCondition{IfNoneMatch: true}); err != nil {
+		t.Fatalf("对象不存在时 If-None-Match 应成功: %v", err)
+	}
+	if err := put("e2", WriteCondition{IfNoneMatch: true}); err != ErrPreconditionFailed {
+		t.Errorf("对象已存在时 If-None-Match 应失败: %v", err)
+	}
+	if err := put("e2", WriteCondition{IfMatch: "other"}); err != ErrPreconditionFailed {
+		t.Errorf("ETag 不一致时应失败: %v", err)
+	}
+	if commits != 1 {
+		t.Errorf("条件不满足时不应调用 commit: %d", commits)
+	}
+	if obj, _ := store.GetObject(bucket, "k"); obj == nil || obj.ETag != "e1" {
+		t.Errorf("条件不满足时对象不应改变: %+v", obj)
+	}
+	if err := put("e2", WriteCondition{IfMatch: "e1"}); err != nil {
+		t.Fatalf("ETag 一致时应成功: %v", err)
+	}
+	if obj, _ := store.GetObject(bucket, "k"); obj == nil || obj.ETag != "e2" || commits != 2 {
+		t.Errorf("对象应已更新: %+v", obj)
+	}
+}
+
+// TestObjectCreatedAt 测试对象首次写入时间在覆盖时保持不变
+func TestObjectCreatedAt(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	bucket := "created-bucket"
+	store.CreateBucket(bucket)
+	first := time.Now().UTC().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := store.PutObject(&Object{Bucket: bucket, Key: "a", ETag: "e1", StoragePath: "/p/a1", LastModified: first}); err != nil {
+		t.Fatalf("写入对象失败: %v", err)
+	}
+	second := first.Add(24 * time.Hour)
+	overwrite := &Object{Bucket: bucket, Key: "a", ETag: "e2", StoragePath: "/p/a2", LastModified: second, CreatedAt: second}
+	if err := store.PutObject(overwrite); err != nil {
+		t.Fatalf("覆盖对象失败: %v", err)
+	}
+	if !overwrite.CreatedAt.Equal(first) {
+		t.Errorf("覆盖时应回填原创建时间: %v", overwrite.CreatedAt)
+	}
+
+	obj, _ := store.GetObject(bucket, "a")
+	if !obj.CreatedAt.Equal(first) || !obj.LastModified.Equal(second) {
+		t.Errorf("创建时间应保持不变、修改时间应更新: created=%v modified=%v", obj.CreatedAt, obj.LastModified)
+	}
+	result, _ := store.QueryObjects(&ObjectListQuery{Bucket: bucket, MaxKeys: 10})
+	if len(result.Contents) != 1 || !result.Contents[0].CreatedAt.Equal(first) {
+		t.Errorf("列表应返回创建时间: %+v", result.Contents)
+	}
+
+	store.DeleteObject(bucket, "a")
+	store.PutObject(&Object{Bucket: bucket, Key: "a", ETag: "e3", StoragePath: "/p/a3", LastModified: second})
+	if obj, _ := store.GetObject(bucket, "a"); !obj.CreatedAt.Equal(second) {
+		t.Errorf("删除后重新写入应使用新的创建时间: %v", obj.CreatedAt)
+	}
+}
+
+// TestObjectCreatedAtBackfill 测试旧库升级时以最后修改时间回填创建时间
+func TestObjectCreatedAtBackfill(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	store, err := NewMetadataStore(dbPath)
+	if err != nil {
+		t.Fatalf("创建MetadataStore失败: %v", err)
+	}
+	modified := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	store.CreateBucket("old-bucket")
+	store.PutObject(&Object{Bucket: "old-bucket", Key: "k", ETag: "e", StoragePath: "/p/k", LastModified: modified})
+	// 模拟升级前没有 created_at 列的数据库
+	for _, stmt := range []string{"DROP INDEX idx_objects_created", "ALTER TABLE objects DROP COLUMN created_at"} {
+		if _, err := store.db.Exec(stmt); err != nil {
+			t.Fatalf("%s 失败: %v", stmt, err)
+		}
+	}
+	store.Close()
+
+	store, err = NewMetadataStore(dbPath)
+	if err != nil {
+		t.Fatalf("重新打开失败: %v", err)
+	}
+	defer store.Close()
+	obj, err := store.GetObject("old-bucket", "k")
+	if err != nil || obj == nil {
+		t.Fatalf("读取对象失败: %v", err)
+	}
+	if !obj.CreatedAt.Equal(modified) {
+		t.Errorf("创建时间应回填为修改时间: 期望 %v, 实际 %v", modified, obj.CreatedAt)
+	}
+}
+
+// TestListObjectsWithoutFolderMarkers 测试占位模式下文件夹占位对象的列出
+func TestListObjectsWithoutFolderMarkers(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	bucket := "marker-bucket"
+	store.CreateBucket(bucket)
+	for key, size := range map[string]int64{
+		"docs/":         0,
+		"docs/a.txt":    10,
+		"docs/b.txt":    10,
+		"empty/":        0,
+		"nonempty-dir/": 5,
+		"readme.txt":    10,
+	} {
+		store.PutObject(&Object{Bucket: bucket, Key: key, Size: size, ETag: "test", StoragePath: "/path/" + key})
+	}
+
+	keys := func(objs []Object) string {
+		var out []string
+		for _, o := range objs {
+			out = append(out, o.Key)
+		}
+		return strings.Join(out, ",")
+	}
+
+	t.Run("平铺列出隐藏占位对象", func(t *testing.T) {
+		result, err := store.ListObjectsWithoutFolderMarkers(bucket, "", "", "", 100)
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		if got := keys(result.Contents); got != "docs/a.txt,docs/b.txt,nonempty-dir/,readme.txt" {
+			t.Errorf("Contents 错误: %s", got)
+		}
+	})
+
+	t.Run("分隔符列出时占位对象以CommonPrefixes出现", func(t *testing.T) {
+		result, err := store.ListObjectsWithoutFolderMarkers(bucket, "", "", "/", 100)
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		if got := strings.Join(result.CommonPrefixes, ","); got != "docs/,empty/,nonempty-dir/" {
+			t.Errorf("CommonPrefixes 错误: %s", got)
+		}
+		if got := keys(result.Contents); got != "readme.txt" {
+			t.Errorf("Contents 错误: %s", got)
+		}
+	})
+
+	t.Run("列出目录时不含自身占位对象", func(t *testing.T) {
+		result, err := store.ListObjectsWithoutFolderMarkers(bucket, "docs/", "", "/", 100)
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		if got := keys(result.Contents); got != "docs/a.txt,docs/b.txt" {
+			t.Errorf("Contents 错误: %s", got)
+		}
+	})
+
+	t.Run("占位对象不影响分页截断", func(t *testing.T) {
+		result, err := store.ListObjectsWithoutFolderMarkers(bucket, "docs/", "", "", 1)
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		if got := keys(result.Contents); got != "docs/a.txt" || !result.IsTruncated {
+			t.Errorf("分页错误: %s truncated=%v", got, result.IsTruncated)
+		}
+	})
+
+	t.Run("普通列出保留占位对象", func(t *testing.T) {
+		result, err := store.ListObjects(bucket, "docs/", "", "", 100)
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		if got := keys(result.Contents); got != "docs/,docs/a.txt,docs/b.txt" {
+			t.Errorf("Contents 错误: %s", got)
+		}
+	})
+}
+
+// TestWalkObjectsDeadline 测试超出时间预算时返回部分结果并可续传
+func TestWalkObjectsDeadline(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	bucket := "budget-bucket"
+	store.CreateBucket(bucket)
+	for _, key := range []string{"a.txt", "dir/1.txt", "dir/2.txt", "dir/3.txt", "z.txt"} {
+		store.PutObject(&Object{Bucket: bucket, Key: key, Size: 1, ETag: "test", StoragePath: "/path/" + key})
+	}
+
+	// 截止时间已过，每页只扫描一行，逐页续传直到结束
+	walkAll := func(delimiter string) (keys, prefixes []string, pages int) {
+		marker := ""
+		for pages = 1; pages <= 10; pages++ {
+			result, err := store.WalkObjects(&ObjectListQuery{
+				Bucket: bucket, Marker: marker, Delimiter: delimiter, MaxKeys: 100,
+				Deadline: time.Now().Add(-time.Second),
+			}, ObjectListVisitor{
+				Object:       func(obj *Object) error { keys = append(keys, obj.Key); return nil },
+				CommonPrefix: func(p string) error { prefixes = append(prefixes, p); return nil },
+			})
+			if err != nil {
+				t.Fatalf("列出对象失败: %v", err)
+			}
+			if !result.IsTruncated {
+				return
+			}
+			if !result.BudgetExceeded || result.NextMarker == "" {
+				t.Fatalf("超时截断应返回续传标记: %+v", result)
+			}
+			marker = result.NextMarker
+		}
+		t.Fatal("续传未结束")
+		return
+	}
+
+	keys, _, pages := walkAll("")
+	if strings.Join(keys, ",") != "a.txt,dir/1.txt,dir/2.txt,dir/3.txt,z.txt" || pages != 5 {
+		t.Errorf("平铺续传结果错误: %v, pages=%d", keys, pages)
+	}
+
+	keys, prefixes, _ := walkAll("/")
+	if strings.Join(keys, ",") != "a.txt,z.txt" || strings.Join(prefixes, ",") != "dir/" {
+		t.Errorf("截断于公共前缀中途后不应重复返回前缀: keys=%v prefixes=%v", keys, prefixes)
+	}
+
+	result, err := store.WalkObjects(&ObjectListQuery{Bucket: bucket, MaxKeys: 100, Deadline: time.Now().Add(time.Minute)}, ObjectListVisitor{})
+	if err != nil || result.IsTruncated || result.KeyCount != 5 {
+		t.Errorf("未超时应完整列出: %+v, %v", result, err)
+	}
+}
+
+// TestWalkObjectsPaging 测试按页读取时结果与一次读取一致，回调期间不占用数据库连接
+func TestWalkObjectsPaging(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	saved := walkPageSize
+	walkPageSize = 2
+	defer func() { walkPageSize = saved }()
+
+	bucket := "paging-bucket"
+	store.CreateBucket(bucket)
+	for i, key := range []string{"a.txt", "b.txt", "dir/1.txt", "dir/2.txt", "dir/3.txt", "e.txt", "f.txt"} {
+		store.PutObject(&Object{Bucket: bucket, Key: key, Size: int64(10 - i), ETag: "test", StoragePath: "/path/" + key})
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	walk := func(q ObjectListQuery) (string, *ListObjectsResult) {
+		var got []string
+		q.Bucket = bucket
+		result, err := store.WalkObjects(&q, ObjectListVisitor{
+			Object:       func(obj *Object) error { got = append(got, obj.Key); return nil },
+			CommonPrefix: func(p string) error { got = append(got, p); return nil },
+		})
+		if err != nil {
+			t.Fatalf("列出对象失败: %v", err)
+		}
+		return strings.Join(got, ","), result
+	}
+
+	tests := []struct {
+		name  string
+		query ObjectListQuery
+		want  string
+	}{
+		{"按键", ObjectListQuery{MaxKeys: 100}, "a.txt,b.txt,dir/1.txt,dir/2.txt,dir/3.txt,e.txt,f.txt"},
+		{"按键带标记", ObjectListQuery{MaxKeys: 100, Marker: "b.txt"}, "dir/1.txt,dir/2.txt,dir/3.txt,e.txt,f.txt"},
+		{"分隔符", ObjectListQuery{MaxKeys: 100, Delimiter: "/"}, "a.txt,b.txt,dir/,e.txt,f.txt"},
+		{"按大小", ObjectListQuery{MaxKeys: 100, Sort: ObjectSort{Field: ObjectSortSize}}, "f.txt,e.txt,dir/3.txt,dir/2.txt,dir/1.txt,b.txt,a.txt"},
+		{"按修改时间降序", ObjectListQuery{MaxKeys: 100, Sort: ObjectSort{Field: ObjectSortModified, Desc: true}}, "f.txt,e.txt,dir/3.txt,dir/2.txt,dir/1.txt,b.txt,a.txt"},
+		{"按修改时间带标记", ObjectListQuery{MaxKeys: 100, Marker: "b.txt", Sort: ObjectSort{Field: ObjectSortModified}}, "dir/1.txt,dir/2.txt,dir/3.txt,e.txt,f.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, result := walk(tt.query); got != tt.want || result.IsTruncated {
+				t.Errorf("结果错误: %s, truncated=%v", got, result.IsTruncated)
+			}
+		})
+	}
+
+	t.Run("凑满后截断", func(t *testing.T) {
+		got, result := walk(ObjectListQuery{MaxKeys: 3, Delimiter: "/"})
+		if got != "a.txt,b.txt,dir/" || !result.IsTruncated || result.NextMarker != "dir/" {
+			t.Errorf("截断结果错误: %s, %+v", got, result)
+		}
+	})
+
+	t.Run("回调期间不占用连接", func(t *testing.T) {
+		store.db.SetMaxOpenConns(1)
+		defer store.db.SetMaxOpenConns(10)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := store.WalkObjects(&ObjectListQuery{Bucket: bucket, MaxKeys: 100}, ObjectListVisitor{
+				Object: func(obj *Object) error {
+					_, err := store.GetObject(bucket, obj.Key)
+					return err
+				},
+			})
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("列出对象失败: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("回调中查询数据库被阻塞，游标未在回调前关闭")
+		}
+	})
+}
+
 // TestMultipartUploadOperations 测试多部分上传操作
 func TestMultipartUploadOperations(t *testing.T) {
 	store, cleanup := setupMetadataStore(t)
@@ -541,6 +1049,117 @@ func TestMultipartUploadOperations(t *testing.T) {
 	})
 }
 
+// TestObjectUserMetadataStorage 测试用户元数据随对象、历史版本和多段上传保存
+func TestObjectUserMetadataStorage(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	store.CreateBucket("meta")
+	store.UpdateBucketVersioning("meta", true)
+
+	store.PutObject(&Object{Bucket: "meta", Key: "a.txt", Size: 1, ETag: "e1", StoragePath: "/v1", VersionID: "v1",
+		LastModified: time.Now().UTC(), Metadata: map[string]string{"origin": "camera"}})
+	if obj, _ := store.GetObject("meta", "a.txt"); obj == nil || obj.Metadata["origin"] != "camera" {
+		t.Fatalf("应读取到用户元数据: %+v", obj)
+	}
+
+	// 覆盖后历史版本保留原元数据，新版本无元数据
+	store.PutObject(&Object{Bucket: "meta", Key: "a.txt", Size: 1, ETag: "e2", StoragePath: "/v2", VersionID: "v2",
+		LastModified: time.Now().UTC()})
+	if obj, _ := store.GetObject("meta", "a.txt"); obj == nil || len(obj.Metadata) != 0 {
+		t.Errorf("新版本不应带旧元数据: %+v", obj)
+	}
+	if v, _ := store.GetObjectVersion("meta", "a.txt", "v1"); v == nil || v.Metadata["origin"] != "camera" {
+		t.Fatalf("历史版本应保留元数据: %+v", v)
+	}
+	store.DeleteObjectVersion("meta", "a.txt", "v2")
+	if obj, _ := store.GetObject("meta", "a.txt"); obj == nil || obj.Metadata["origin"] != "camera" {
+		t.Errorf("恢复的当前版本应带元数据: %+v", obj)
+	}
+
+	store.CreateMultipartUpload(&MultipartUpload{UploadID: "u1", Bucket: "meta", Key: "big.bin", Initiated: time.Now(),
+		Metadata: map[string]string{"origin": "multipart", "project": "alpha"}})
+	if u, _ := store.GetMultipartUpload("u1"); u == nil || len(u.Metadata) != 2 || u.Metadata["project"] != "alpha" {
+		t.Errorf("多段上传应保存元数据: %+v", u)
+	}
+}
+
+// TestListMultipartUploads 测试未完成上传的排序、标记分页、前缀、分隔符和分片统计
+func TestListMultipartUploads(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	store.CreateBucket("uploads")
+	store.CreateBucket("other")
+
+	now := time.Now().UTC()
+	for _, u := range [][2]string{{"b.bin", "u2"}, {"a.bin", "u1"}, {"b.bin", "u3"}, {"docs/x.txt", "u4"}, {"docs/y.txt", "u5"}, {"z.bin", "u6"}} {
+		store.CreateMultipartUpload(&MultipartUpload{UploadID: u[1], Bucket: "uploads", Key: u[0], Initiated: now})
+	}
+	store.CreateMultipartUpload(&MultipartUpload{UploadID: "o1", Bucket: "other", Key: "a.bin", Initiated: now})
+	store.PutPart(&Part{UploadID: "u2", PartNumber: 1, Size: 100, ETag: "e1", ModifiedAt: now})
+	store.PutPart(&Part{UploadID: "u2", PartNumber: 2, Size: 50, ETag: "e2", ModifiedAt: now})
+
+	list := func(q MultipartUploadListQuery) *MultipartUploadList {
+		t.Helper()
+		q.Bucket = "uploads"
+		result, err := store.ListMultipartUploads(&q)
+		if err != nil {
+			t.Fatalf("列出未完成上传失败: %v", err)
+		}
+		return result
+	}
+	ids := func(l *MultipartUploadList) string {
+		var s []string
+		for _, u := range l.Uploads {
+			s = append(s, u.UploadID)
+		}
+		return strings.Join(s, ",")
+	}
+
+	t.Run("按键和上传ID排序并统计分片", func(t *testing.T) {
+		result := list(MultipartUploadListQuery{MaxUploads: 1000})
+		if ids(result) != "u1,u2,u3,u4,u5,u6" || result.IsTruncated {
+			t.Fatalf("列表错误: %s %v", ids(result), result.IsTruncated)
+		}
+		if u := result.Uploads[1]; u.PartCount != 2 || u.TotalSize != 150 || u.Key != "b.bin" {
+			t.Errorf("分片统计错误: %+v", u)
+		}
+	})
+
+	t.Run("按标记分页", func(t *testing.T) {
+		first := list(MultipartUploadListQuery{MaxUploads: 2})
+		if ids(first) != "u1,u2" || !first.IsTruncated || first.NextKeyMarker != "b.bin" || first.NextUploadIDMarker != "u2" {
+			t.Fatalf("第一页错误: %s %+v", ids(first), first)
+		}
+		second := list(MultipartUploadListQuery{MaxUploads: 2, KeyMarker: first.NextKeyMarker, UploadIDMarker: first.NextUploadIDMarker})
+		if ids(second) != "u3,u4" || !second.IsTruncated {
+			t.Errorf("第二页错误: %s", ids(second))
+		}
+		// 只有 key-marker 时跳过该键的全部上传
+		if got := list(MultipartUploadListQuery{MaxUploads: 10, KeyMarker: "b.bin"}); ids(got) != "u4,u5,u6" {
+			t.Errorf("只有键标记时结果错误: %s", ids(got))
+		}
+	})
+
+	t.Run("前缀和分隔符", func(t *testing.T) {
+		if got := list(MultipartUploadListQuery{MaxUploads: 10, Prefix: "docs/"}); ids(got) != "u4,u5" {
+			t.Errorf("前缀过滤错误: %s", ids(got))
+		}
+		got := list(MultipartUploadListQuery{MaxUploads: 10, Delimiter: "/"})
+		if ids(got) != "u1,u2,u3,u6" || len(got.CommonPrefixes) != 1 || got.CommonPrefixes[0] != "docs/" {
+			t.Errorf("分隔符结果错误: %s %v", ids(got), got.CommonPrefixes)
+		}
+		// 公共前缀计入数量，下一页不再返回已返回的前缀
+		page := list(MultipartUploadListQuery{MaxUploads: 4, Delimiter: "/"})
+		if ids(page) != "u1,u2,u3" || !page.IsTruncated || page.NextKeyMarker != "docs/" {
+			t.Fatalf("分页结果错误: %s %+v", ids(page), page)
+		}
+		next := list(MultipartUploadListQuery{MaxUploads: 4, Delimiter: "/", KeyMarker: page.NextKeyMarker})
+		if ids(next) != "u6" || len(next.CommonPrefixes) != 0 || next.IsTruncated {
+			t.Errorf("下一页结果错误: %s %v", ids(next), next.CommonPrefixes)
+		}
+	})
+}
+
 // TestConcurrentOperations 测试并发操作
 func TestConcurrentOperations(t *testing.T) {
 	store, cleanup := setupMetadataStore(t)
@@ -728,3 +1347,284 @@ func setupMetadataStore(t *testing.T) (*MetadataStore, func()) {
 		store.Close()
 	}
 }
+
+// TestDeleteObjectIfMatch 测试条件删除对象元数据
+func TestDeleteObjectIfMatch(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	store.CreateBucket("cond-bucket")
+	store.PutObject(&Object{Bucket: "cond-bucket", Key: "a.txt", Size: 10, ETag: "etag1", StoragePath: "/p/a.txt"})
+
+	if deleted, err := store.DeleteObjectIfMatch("cond-bucket", "a.txt", "other", -1); err != nil || deleted {
+		t.Errorf("ETag 不匹配不应删除: %v, %v", deleted, err)
+	}
+	if deleted, _ := store.DeleteObjectIfMatch("cond-bucket", "a.txt", "etag1", 11); deleted {
+		t.Error("大小不匹配不应删除")
+	}
+	if deleted, err := store.DeleteObjectIfMatch("cond-bucket", "a.txt", "etag1", 10); err != nil || !deleted {
+		t.Fatalf("条件满足应删除: %v, %v", deleted, err)
+	}
+	if obj, _ := store.GetObject("cond-bucket", "a.txt"); obj != nil {
+		t.Error("对象应已被删除")
+	}
+}
+
+// TestSweepExpiredObjects 测试清理过期对象
+func TestSweepExpiredObjects(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	fs, _ := setupFileStore(t)
+
+	store.CreateBucket("ttl-bucket")
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	put := func(key string, expiresAt *time.Time) string {
+		path, etag, err := fs.PutObject("ttl-bucket", key, strings.NewReader(key), int64(len(key)))
+		if err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+		store.PutObject(&Object{Bucket: "ttl-bucket", Key: key, Size: int64(len(key)), ETag: etag, StoragePath: path, ExpiresAt: expiresAt})
+		return path
+	}
+	expiredPath := put("expired.txt", &past)
+	put("future.txt", &future)
+	put("forever.txt", nil)
+
+	obj, _ := store.GetObject("ttl-bucket", "expired.txt")
+	if !obj.Expired(now) || obj.ExpiresAt.Unix() != past.Unix() {
+		t.Fatalf("过期时间未正确保存: %v", obj.ExpiresAt)
+	}
+
+	// 清理前被覆盖为永久对象时不应删除
+	stale, _ := store.ListExpiredObjects(now, 10)
+	if len(stale) != 1 {
+		t.Fatalf("应有 1 个过期对象: %d", len(stale))
+	}
+	store.PutObject(&Object{Bucket: "ttl-bucket", Key: "expired.txt", Size: 1, ETag: stale[0].ETag, StoragePath: expiredPath})
+	if deleted, err := store.DeleteExpiredObject(&stale[0], now); err != nil || deleted {
+		t.Errorf("已被覆盖的对象不应删除: %v %v", deleted, err)
+	}
+	store.PutObject(&Object{Bucket: "ttl-bucket", Key: "expired.txt", Size: 1, ETag: stale[0].ETag, StoragePath: expiredPath, ExpiresAt: &past})
+
+	deleted, err := SweepExpiredObjects(store, fs, now)
+	if err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Key != "expired.txt" {
+		t.Errorf("清理结果错误: %+v", deleted)
+	}
+	if obj, _ := store.GetObject("ttl-bucket", "expired.txt"); obj != nil {
+		t.Error("过期对象元数据应已删除")
+	}
+	if _, err := os.Stat(expiredPath); !os.IsNotExist(err) {
+		t.Error("过期对象文件应已删除")
+	}
+	for _, key := range []string{"future.txt", "forever.txt"} {
+		if obj, _ := store.GetObject("ttl-bucket", key); obj == nil {
+			t.Errorf("%s 不应被删除", key)
+		}
+	}
+}
+
+// TestObjectTags 测试对象标签的校验与持久化
+func TestObjectTags(t *testing.T) {
+	t.Run("校验", func(t *testing.T) {
+		tooMany := make([]ObjectTag, MaxObjectTags+1)
+		for i := range tooMany {
+			tooMany[i] = ObjectTag{Key: fmt.Sprintf("k%d", i)}
+		}
+		cases := map[string]struct {
+			tags []ObjectTag
+			err  error
+		}{
+			"合法":    {[]ObjectTag{{"env", "prod"}, {"team", "数据 平台"}, {"path", "a/b:c@d+e=f.g_h-i"}}, nil},
+			"空值":    {[]ObjectTag{{"flag", ""}}, nil},
+			"超过10个": {tooMany, ErrTooManyTags},
+			"空键":    {[]ObjectTag{{"", "v"}}, ErrTagKeyLength},
+			"键过长":   {[]ObjectTag{{strings.Repeat("k", MaxTagKeyLength+1), "v"}}, ErrTagKeyLength},
+			"值过长":   {[]ObjectTag{{"k", strings.Repeat("值", MaxTagValueLength+1)}}, ErrTagValueLength},
+			"保留前缀":  {[]ObjectTag{{"AWS:created", "v"}}, ErrTagReservedKey},
+			"非法字符":  {[]ObjectTag{{"k", "a&b"}}, ErrTagInvalidChars},
+			"重复键":   {[]ObjectTag{{"k", "1"}, {"k", "2"}}, ErrTagDuplicatedKey},
+		}
+		for name, tc := range cases {
+			if _, err := ValidateObjectTags(tc.tags); err != tc.err {
+				t.Errorf("%s: got %v, want %v", name, err, tc.err)
+			}
+		}
+		if tags, _ := ValidateObjectTags([]ObjectTag{{"k", strings.Repeat("值", MaxTagValueLength)}}); len(tags["k"]) == 0 {
+			t.Error("按字符而非字节计算长度")
+		}
+	})
+
+	t.Run("持久化", func(t *testing.T) {
+		store, cleanup := setupMetadataStore(t)
+		defer cleanup()
+
+		store.CreateBucket("tag-bucket")
+		store.PutObject(&Object{Bucket: "tag-bucket", Key: "a.txt", Size: 1, ETag: "etag1", StoragePath: "/p/a.txt"})
+		before, _ := store.GetObject("tag-bucket", "a.txt")
+
+		if ok, err := store.PutObjectTags("tag-bucket", "a.txt", map[string]string{"env": "prod"}); err != nil || !ok {
+			t.Fatalf("设置标签失败: %v, %v", ok, err)
+		}
+		obj, _ := store.GetObject("tag-bucket", "a.txt")
+		if obj.Tags["env"] != "prod" || obj.ETag != before.ETag || !obj.LastModified.Equal(before.LastModified) {
+			t.Errorf("标签更新不应改变对象: %+v", obj)
+		}
+
+		// 覆盖写入不带标签时清空
+		store.PutObject(&Object{Bucket: "tag-bucket", Key: "a.txt", Size: 2, ETag: "etag2", StoragePath: "/p/a.txt"})
+		if obj, _ := store.GetObject("tag-bucket", "a.txt"); len(obj.Tags) != 0 {
+			t.Errorf("覆盖写入后标签应清空: %v", obj.Tags)
+		}
+
+		if ok, _ := store.PutObjectTags("tag-bucket", "missing.txt", map[string]string{"k": "v"}); ok {
+			t.Error("不存在的对象不应更新")
+		}
+	})
+}
+
+func TestObjectVersions(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	bucket := "version-bucket"
+	store.CreateBucket(bucket)
+	versionIDs := func() string {
+		versions, err := store.ListObjectVersions(bucket, "a.txt")
+		if err != nil {
+			t.Fatalf("列出版本失败: %v", err)
+		}
+		var out []string
+		for _, v := range versions {
+			id := v.VersionIDOrNull()
+			if v.DeleteMarker {
+				id += "(marker)"
+			}
+			if v.IsLatest {
+				id += "*"
+			}
+			out = append(out, id)
+		}
+		return strings.Join(out, ",")
+	}
+
+	// 未启用版本控制时覆盖写入不保留旧版本
+	store.PutObject(&Object{Bucket: bucket, Key: "a.txt", Size: 1, ETag: "e0", StoragePath: "/p/a.txt"})
+	store.PutObject(&Object{Bucket: bucket, Key: "a.txt", Size: 1, ETag: "e0", StoragePath: "/p/a.txt"})
+	if got := versionIDs(); got != "null*" {
+		t.Fatalf("未启用版本控制: %s", got)
+	}
+
+	if err := store.UpdateBucketVersioning(bucket, true); err != nil {
+		t.Fatalf("启用版本控制失败: %v", err)
+	}
+	if b, _ := store.GetBucket(bucket); !b.VersioningEnabled {
+		t.Fatal("版本控制应已启用")
+	}
+	store.PutObject(&Object{Bucket: bucket, Key: "a.txt", Size: 2, ETag: "e1", StoragePath: "/v/v1", VersionID: "v1"})
+	store.PutObject(&Object{Bucket: bucket, Key: "a.txt", Size: 3, ETag: "e2", StoragePath: "/v/v2", VersionID: "v2"})
+	if got := versionIDs(); got != "v2*,v1,null" {
+		t.Fatalf("覆盖写入后: %s", got)
+	}
+	if v, _ := store.GetObjectVersion(bucket, "a.txt", "null"); v == nil || v.ETag != "e0" || v.IsLatest {
+		t.Errorf("null 版本: %+v", v)
+	}
+	if v, _ := store.GetObjectVersion(bucket, "a.txt", "missing"); v != nil {
+		t.Errorf("不存在的版本应返回 nil: %+v", v)
+	}
+
+	// 条件不满足时不写入删除标记
+	if ok, _ := store.PutDeleteMarker(bucket, "a.txt", "m1", "wrong", -1); ok {
+		t.Error("ETag 不匹配时不应写入删除标记")
+	}
+	if ok, err := store.PutDeleteMarker(bucket, "a.txt", "m1", "e2", 3); err != nil || !ok {
+		t.Fatalf("写入删除标记失败: %v, %v", ok, err)
+	}
+	if obj, _ := store.GetObject(bucket, "a.txt"); obj != nil {
+		t.Errorf("删除标记后当前版本应不存在: %+v", obj)
+	}
+	if got := versionIDs(); got != "m1(marker)*,v2,v1,null" {
+		t.Fatalf("删除标记后: %s", got)
+	}
+	if paths, _ := store.listVersionStoragePaths(); len(paths) != 3 {
+		t.Errorf("删除标记不应有存储路径: %v", paths)
+	}
+
+	// 删除最新的删除标记后上一个版本恢复为当前版本
+	if v, err := store.DeleteObjectVersion(bucket, "a.txt", "m1"); err != nil || v == nil || !v.DeleteMarker {
+		t.Fatalf("删除删除标记失败: %+v, %v", v, err)
+	}
+	if obj, _ := store.GetObject(bucket, "a.txt"); obj == nil || obj.VersionID != "v2" || obj.StoragePath != "/v/v2" {
+		t.Fatalf("v2 应恢复为当前版本: %+v", obj)
+	}
+	if got := versionIDs(); got != "v2*,v1,null" {
+		t.Fatalf("删除删除标记后: %s", got)
+	}
+
+	// 删除历史版本不影响当前版本
+	if v, _ := store.DeleteObjectVersion(bucket, "a.txt", "v1"); v == nil || v.StoragePath != "/v/v1" || v.IsLatest {
+		t.Errorf("删除历史版本: %+v", v)
+	}
+	if got := versionIDs(); got != "v2*,null" {
+		t.Fatalf("删除历史版本后: %s", got)
+	}
+
+	// 有历史版本时不能删除桶
+	if err := store.DeleteBucket(bucket); err == nil {
+		t.Error("存在对象版本时删除桶应失败")
+	}
+
+	// 暂停版本控制后写入 null 版本，替换已有的 null 版本
+	store.UpdateBucketVersioning(bucket, false)
+	store.PutObject(&Object{Bucket: bucket, Key: "a.txt", Size: 4, ETag: "e3", StoragePath: "/p/a.txt"})
+	if got := versionIDs(); got != "null*,v2" {
+		t.Fatalf("暂停版本控制后: %s", got)
+	}
+	if v, _ := store.GetObjectVersion(bucket, "a.txt", "null"); v == nil || v.ETag != "e3" {
+		t.Errorf("null 版本应被替换: %+v", v)
+	}
+}
+
+// TestBucketPolicyStorage 测试桶策略的保存、读取和删除
+func TestBucketPolicyStorage(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+
+	store.CreateBucket("policy-bucket")
+	if p, err := store.GetBucketPolicy("policy-bucket"); err != nil || p != nil {
+		t.Fatalf("新桶不应有策略: %v, %v", p, err)
+	}
+
+	policy, err := ParseBucketPolicy("policy-bucket", []byte(`{"Statement":[{"Effect":"Allow","Principal":{"AWS":["KEY1"]},"Action":"s3:PutObject","Resource":"arn:aws:s3:::policy-bucket/uploads/*"}]}`))
+	if err != nil {
+		t.Fatalf("解析策略失败: %v", err)
+	}
+	if err := store.SetBucketPolicy("policy-bucket", policy); err != nil {
+		t.Fatalf("保存策略失败: %v", err)
+	}
+	got, err := store.GetBucketPolicy("policy-bucket")
+	if err != nil || got == nil || len(got.Statement) != 1 {
+		t.Fatalf("读取策略失败: %+v, %v", got, err)
+	}
+	st := got.Statement[0]
+	if len(st.Principal) != 1 || st.Principal[0] != "KEY1" || st.Action[0] != PolicyActionPutObject {
+		t.Errorf("策略内容错误: %+v", st)
+	}
+	buckets, _ := store.ListBuckets()
+	if len(buckets) != 1 || buckets[0].Policy == nil {
+		t.Error("ListBuckets 应返回桶策略")
+	}
+
+	if err := store.SetBucketPolicy("policy-bucket", nil); err != nil {
+		t.Fatalf("删除策略失败: %v", err)
+	}
+	if p, _ := store.GetBucketPolicy("policy-bucket"); p != nil {
+		t.Error("策略应已删除")
+	}
+	if p, err := store.GetBucketPolicy("missing"); err != nil || p != nil {
+		t.Errorf("不存在的桶应返回 nil: %v, %v", p, err)
+	}
+}
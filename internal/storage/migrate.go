@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"sync"
 	"time"
@@ -26,8 +28,18 @@ type MigrateConfig struct {
 	TargetBucket    string `json:"targetBucket"`
 	TargetPrefix    string `json:"targetPrefix"`    // 可选：目标前缀
 	OverwriteExist  bool   `json:"overwriteExist"`  // 是否覆盖已存在的文件
+	MaxRetries      int    `json:"maxRetries"`      // 源读取失败的最大重试次数，0 表示不重试
+	RetryDelayMs    int    `json:"retryDelayMs"`    // 首次重试等待毫秒数，之后按指数退避
 }
 
+// 迁移重试默认值
+const (
+	DefaultMigrateMaxRetries   = 3
+	DefaultMigrateRetryDelayMs = 500
+	maxMigrateRetries          = 10
+	maxMigrateRetryDelay       = 30 * time.Second
+)
+
 // MigrateProgress 迁移进度
 type MigrateProgress struct {
 	JobID         string     `json:"jobId"`
@@ -42,7 +54,9 @@ type MigrateProgress struct {
 	StartTime     time.Time  `json:"startTime"`
 	EndTime       *time.Time `json:"endTime,omitempty"`
 	Error         string     `json:"error,omitempty"`
-	FailedObjects []string   `json:"failedObjects,omitempty"` // 失败的对象列表
+	Retries       int        `json:"retries"`                 // 累计重试次数
+	RetriedObjects int       `json:"retriedObjects"`          // 发生过重试的对象数
+	FailedObjects []string   `json:"failedObjects,omitempty"` // 重试耗尽后失败的对象，供后续重新迁移
 	Config        MigrateConfig `json:"config"`
 }
 
@@ -52,6 +66,8 @@ type MigrateManager struct {
 	jobs     map[string]*MigrateProgress
 	metadata *MetadataStore
 	fileStore *FileStore
+	workers  sync.WaitGroup
+	closed   bool // 服务关闭中，不再接受新任务
 }
 
 // 全局迁移管理器
@@ -109,10 +125,20 @@ func (m *MigrateManager) StartMigration(cfg MigrateConfig) (string, error) {
 		return "", fmt.Errorf("target bucket not found: %s", cfg.TargetBucket)
 	}
 
+	if cfg.MaxRetries < 0 || cfg.MaxRetries > maxMigrateRetries {
+		return "", fmt.Errorf("maxRetries must be between 0 and %d", maxMigrateRetries)
+	}
+	if cfg.RetryDelayMs < 0 {
+		return "", fmt.Errorf("retryDelayMs must not be negative")
+	}
+
 	// 设置默认区域
 	if cfg.SourceRegion == "" {
 		cfg.SourceRegion = "us-east-1"
 	}
+	if cfg.RetryDelayMs == 0 {
+		cfg.RetryDelayMs = DefaultMigrateRetryDelayMs
+	}
 
 	// 生成任务ID
 	jobID := generateJobID()
@@ -126,11 +152,19 @@ func (m *MigrateManager) StartMigration(cfg MigrateConfig) (string, error) {
 	}
 
 	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return "", errMigrationShuttingDown
+	}
 	m.jobs[jobID] = progress
+	m.workers.Add(1)
 	m.mu.Unlock()
 
 	// 启动后台任务
-	go m.runMigration(jobID, cfg)
+	go func() {
+		defer m.workers.Done()
+		m.runMigration(jobID, cfg)
+	}()
 
 	return jobID, nil
 }
@@ -174,6 +208,33 @@ func (m *MigrateManager) CancelMigration(jobID string) error {
 	return nil
 }
 
+// Shutdown 取消未完成的迁移任务并等待其退出，正在传输的对象完成后停止
+func (m *MigrateManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	now := time.Now()
+	for _, job := range m.jobs {
+		if job.Status == "pending" || job.Status == "running" {
+			job.Status = "cancelled"
+			job.Error = errMigrationShuttingDown.Error()
+			job.EndTime = &now
+		}
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("migration jobs still running: %w", ctx.Err())
+	}
+}
+
 // DeleteJob 删除任务记录
 func (m *MigrateManager) DeleteJob(jobID string) error {
 	m.mu.Lock()
@@ -196,6 +257,10 @@ func (m *MigrateManager) DeleteJob(jobID string) error {
 func (m *MigrateManager) runMigration(jobID string, cfg MigrateConfig) {
 	m.mu.Lock()
 	progress := m.jobs[jobID]
+	if progress.Status == "cancelled" {
+		m.mu.Unlock()
+		return
+	}
 	progress.Status = "running"
 	m.mu.Unlock()
 
@@ -274,8 +339,11 @@ func (m *MigrateManager) runMigration(jobID string, cfg MigrateConfig) {
 			}
 		}
 
-		// 下载并上传对象
-		err := m.transferObject(ctx, s3Client, cfg, obj.Key, targetKey, obj.Size)
+		// 下载并上传对象，源读取失败时按配置重试
+		err := m.transferWithRetry(ctx, s3Client, cfg, progress, obj.Key, targetKey, obj.Size)
+		if err == errMigrationCancelled {
+			return
+		}
 		if err != nil {
 			slog.Error("迁移对象失败",
 				"jobId", jobID,
@@ -310,16 +378,17 @@ type sourceObject struct {
 
 // createS3Client 创建源 S3 客户端
 func (m *MigrateManager) createS3Client(ctx context.Context, cfg MigrateConfig) (*s3.Client, error) {
+	return newS3Client(ctx, cfg.SourceEndpoint, cfg.SourceAccessKey, cfg.SourceSecretKey, cfg.SourceRegion)
+}
+
+// newS3Client 创建访问外部 S3 兼容服务的客户端（迁移源、复制目标共用）
+func newS3Client(ctx context.Context, endpoint, accessKey, secretKey, region string) (*s3.Client, error) {
 	// 创建自定义凭证提供程序
-	creds := credentials.NewStaticCredentialsProvider(
-		cfg.SourceAccessKey,
-		cfg.SourceSecretKey,
-		"",
-	)
+	creds := credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")
 
 	// 加载配置
 	awsCfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(cfg.SourceRegion),
+		config.WithRegion(region),
 		config.WithCredentialsProvider(creds),
 	)
 	if err != nil {
@@ -329,7 +398,7 @@ func (m *MigrateManager) createS3Client(ctx context.Context, cfg MigrateConfig)
 	// 创建 S3 客户端，使用 path-style（兼容大多数 S3 兼容服务）
 	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
 		o.UsePathStyle = true
-		o.BaseEndpoint = aws.String(cfg.SourceEndpoint)
+		o.BaseEndpoint = aws.String(endpoint)
 	})
 
 	return client, nil
@@ -373,6 +442,71 @@ func (m *MigrateManager) listSourceObjects(ctx context.Context, client *s3.Clien
 	return objects, nil
 }
 
+// errMigrationCancelled 重试等待期间任务被取消
+var errMigrationCancelled = errors.New("migration cancelled")
+
+// errMigrationShuttingDown 服务关闭时取消或拒绝迁移任务
+var errMigrationShuttingDown = errors.New("server is shutting down")
+
+// sourceReadError 源端读取错误（可重试），本地写入错误不重试
+type sourceReadError struct{ err error }
+
+func (e *sourceReadError) Error() string { return e.err.Error() }
+func (e *sourceReadError) Unwrap() error { return e.err }
+
+// sourceReader 包装源响应体，标记读取过程中的错误
+type sourceReader struct{ r io.Reader }
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = &sourceReadError{err}
+	}
+	return n, err
+}
+
+// retryDelay 第 attempt 次重试的等待时间（指数退避，有上限）
+func retryDelay(cfg MigrateConfig, attempt int) time.Duration {
+	d := time.Duration(cfg.RetryDelayMs) * time.Millisecond
+	for i := 1; i < attempt && d < maxMigrateRetryDelay; i++ {
+		d *= 2
+	}
+	if d > maxMigrateRetryDelay {
+		d = maxMigrateRetryDelay
+	}
+	return d
+}
+
+// transferWithRetry 传输对象，源端错误按指数退避重试
+func (m *MigrateManager) transferWithRetry(ctx context.Context, client *s3.Client, cfg MigrateConfig, progress *MigrateProgress, sourceKey, targetKey string, size int64) error {
+	for attempt := 0; ; attempt++ {
+		err := m.transferObject(ctx, client, cfg, sourceKey, targetKey, size)
+		var srcErr *sourceReadError
+		if err == nil || !errors.As(err, &srcErr) || attempt >= cfg.MaxRetries {
+			return err
+		}
+
+		m.mu.Lock()
+		if progress.Status == "cancelled" {
+			m.mu.Unlock()
+			return errMigrationCancelled
+		}
+		progress.Retries++
+		if attempt == 0 {
+			progress.RetriedObjects++
+		}
+		m.mu.Unlock()
+
+		delay := retryDelay(cfg, attempt+1)
+		slog.Warn("迁移源读取失败，稍后重试",
+			"key", sourceKey,
+			"attempt", attempt+1,
+			"delay", delay,
+			"error", err)
+		time.Sleep(delay)
+	}
+}
+
 // transferObject 传输单个对象
 func (m *MigrateManager) transferObject(ctx context.Context, client *s3.Client, cfg MigrateConfig, sourceKey, targetKey string, size int64) error {
 	// 从源下载
@@ -381,7 +515,7 @@ func (m *MigrateManager) transferObject(ctx context.Context, client *s3.Client,
 		Key:    aws.String(sourceKey),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to get object: %w", err)
+		return fmt.Errorf("failed to get object: %w", &sourceReadError{err})
 	}
 	defer getResp.Body.Close()
 
@@ -391,21 +525,27 @@ func (m *MigrateManager) transferObject(ctx context.Context, client *s3.Client,
 		contentType = *getResp.ContentType
 	}
 
-	// 存储到本地
-	storagePath, etag, err := m.fileStore.PutObject(cfg.TargetBucket, targetKey, getResp.Body, size)
+	// 存储到本地，启用静态加密时加密保存
+	staged, err := m.fileStore.PutObjectStaged(cfg.TargetBucket, targetKey, "", &sourceReader{getResp.Body}, 0)
+	if err == nil {
+		err = staged.Commit()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to store object: %w", err)
 	}
+	storagePath := staged.Path
 
 	// 保存元数据
 	obj := &Object{
 		Bucket:       cfg.TargetBucket,
 		Key:          targetKey,
 		Size:         size,
-		ETag:         etag,
+		ETag:         staged.ETag,
 		ContentType:  contentType,
 		StoragePath:  storagePath,
 		LastModified: time.Now(),
+
+		EncryptionNonce: staged.Nonce,
 	}
 	err = m.metadata.PutObject(obj)
 	if err != nil {
@@ -1,6 +1,11 @@
 package storage
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -842,3 +847,126 @@ func BenchmarkGetJobStats(b *testing.B) {
 		_ = manager.CancelMigration(jobID)
 	}
 }
+
+// newFlakySource 模拟不稳定的迁移源：flaky.txt 前两次返回截断的响应体，broken.txt 始终失败
+func newFlakySource(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	flakyGets := 0
+	objects := map[string]string{"ok.txt": "stable", "flaky.txt": "eventually", "broken.txt": "never"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("list-type") == "2" {
+			var sb strings.Builder
+			sb.WriteString(`<ListBucketResult><Name>source</Name><IsTruncated>false</IsTruncated>`)
+			for _, k := range []string{"broken.txt", "flaky.txt", "ok.txt"} {
+				fmt.Fprintf(&sb, `<Contents><Key>%s</Key><Size>%d</Size></Contents>`, k, len(objects[k]))
+			}
+			sb.WriteString(`</ListBucketResult>`)
+			w.Write([]byte(sb.String()))
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/source/")
+		switch key {
+		case "broken.txt":
+			w.WriteHeader(http.StatusForbidden)
+			return
+		case "flaky.txt":
+			mu.Lock()
+			flakyGets++
+			n := flakyGets
+			mu.Unlock()
+			if n <= 2 {
+				// 声明长度大于实际写入，客户端读取时遇到 unexpected EOF
+				w.Header().Set("Content-Length", "100")
+				w.Write([]byte("even"))
+				return
+			}
+		}
+		w.Write([]byte(objects[key]))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// waitMigration 等待迁移任务结束并返回进度快照
+func waitMigration(t *testing.T, manager *MigrateManager, jobID string) MigrateProgress {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		manager.mu.RLock()
+		p := *manager.jobs[jobID]
+		manager.mu.RUnlock()
+		if p.EndTime != nil {
+			return p
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("迁移任务超时")
+	return MigrateProgress{}
+}
+
+// TestMigrationRetry 测试源读取失败时的重试与失败记录
+func TestMigrationRetry(t *testing.T) {
+	manager, store, cleanup := setupMigrateManager(t)
+	defer cleanup()
+	store.CreateBucket("target")
+	source := newFlakySource(t)
+
+	cfg := MigrateConfig{
+		SourceEndpoint:  source.URL,
+		SourceAccessKey: "ak",
+		SourceSecretKey: "sk",
+		SourceBucket:    "source",
+		TargetBucket:    "target",
+		MaxRetries:      3,
+		RetryDelayMs:    1,
+	}
+
+	t.Run("参数校验", func(t *testing.T) {
+		bad := cfg
+		bad.MaxRetries = -1
+		if _, err := manager.StartMigration(bad); err == nil {
+			t.Error("负数重试次数应被拒绝")
+		}
+	})
+
+	jobID, err := manager.StartMigration(cfg)
+	if err != nil {
+		t.Fatalf("启动迁移失败: %v", err)
+	}
+	p := waitMigration(t, manager, jobID)
+
+	if p.Completed != 2 || p.Failed != 1 {
+		t.Errorf("结果错误: completed=%d failed=%d", p.Completed, p.Failed)
+	}
+	if len(p.FailedObjects) != 1 || p.FailedObjects[0] != "broken.txt" {
+		t.Errorf("失败列表错误: %v", p.FailedObjects)
+	}
+	// flaky 重试 2 次成功，broken 重试 3 次后放弃
+	if p.Retries != 5 || p.RetriedObjects != 2 {
+		t.Errorf("重试统计错误: retries=%d retriedObjects=%d", p.Retries, p.RetriedObjects)
+	}
+
+	obj, _ := store.GetObject("target", "flaky.txt")
+	if obj == nil {
+		t.Fatal("重试后对象应迁移成功")
+	}
+	data, _ := os.ReadFile(obj.StoragePath)
+	if string(data) != "eventually" {
+		t.Errorf("迁移内容错误: %q", data)
+	}
+}
+
+// TestRetryDelay 测试指数退避
+func TestRetryDelay(t *testing.T) {
+	cfg := MigrateConfig{RetryDelayMs: 100}
+	if d := retryDelay(cfg, 1); d != 100*time.Millisecond {
+		t.Errorf("首次重试等待错误: %v", d)
+	}
+	if d := retryDelay(cfg, 3); d != 400*time.Millisecond {
+		t.Errorf("第三次重试等待错误: %v", d)
+	}
+	if d := retryDelay(cfg, 20); d != maxMigrateRetryDelay {
+		t.Errorf("等待时间应有上限: %v", d)
+	}
+}
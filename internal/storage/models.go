@@ -7,26 +7,109 @@ type Bucket struct {
 	Name         string    `json:"name"`
 	CreationDate time.Time `json:"creation_date"`
 	IsPublic     bool      `json:"is_public"`     // 是否为公有桶
+
+	// 内容类型限制
+	ContentTypeMode  string `json:"content_type_mode"`  // allow/block，空表示不限制
+	ContentTypes     string `json:"content_types"`      // 逗号分隔的类型模式，如 image/*,application/pdf
+	ContentTypeSniff bool   `json:"content_type_sniff"` // 是否嗅探实际内容防止伪造
+
+	// 保留键，S3 API 禁止写入（管理后台不受限）
+	KeyDenylist string `json:"key_denylist"` // 换行分隔的 glob 模式，如 index.html
+
+	// 对象写入后 N 分钟内禁止通过 S3 API 删除或覆盖，0 表示不限制
+	ImmutableMinutes int `json:"immutable_minutes"`
+
+	// 对象写入超过 N 天后禁止通过 S3 API 读取（返回 410），仅限制读取不删除，0 表示不限制
+	MaxReadAgeDays int `json:"max_read_age_days"`
+
+	// 最大可读天数的计算基准：modified（最后修改时间，默认）或 created（首次写入时间）
+	ReadAgeBasis string `json:"read_age_basis"`
+
+	// 图片按需缩放：GET 图片时带 w/h 参数返回缩放后的变体，变体按源 ETag 缓存
+	ImageTransform bool `json:"image_transform"`
+
+	// S3 API 允许的 HTTP 方法，逗号分隔，如 GET,HEAD；先于认证检查，任何密钥都无法绕过，空表示不限制
+	AllowedMethods string `json:"allowed_methods"`
+
+	// 只读（冻结）：S3 API 拒绝写入、覆盖和删除对象（返回 403），读取和列举不受影响
+	ReadOnly bool `json:"read_only"`
+
+	// 版本控制：覆盖和删除保留历史版本（删除写入删除标记），关闭后已有版本保留
+	VersioningEnabled bool `json:"versioning_enabled"`
+
+	// 存储配额（字节），当前对象总大小超过配额的写入返回 507，历史版本不计入，0 表示不限制
+	QuotaBytes int64 `json:"quota_bytes"`
+
+	// 对象键前缀改写规则，S3 API 对象请求（读写均适用）在分发前按最长前缀改写，空表示关闭
+	PrefixRewrites []PrefixRewrite `json:"prefix_rewrites,omitempty" xml:"-"`
+
+	// 桶策略：按主体、操作和资源前缀允许或拒绝访问，Deny 优先；未命中时按 API Key 权限和公开开关处理
+	Policy *BucketPolicy `json:"policy,omitempty" xml:"-"`
+
+	// 生命周期过期规则，后台按间隔删除命中前缀且超过天数的对象，空表示关闭
+	LifecycleRules []LifecycleRule `json:"lifecycle_rules,omitempty" xml:"-"`
+
+	// 默认响应头，对象未设置时使用，如 Cache-Control
+	DefaultHeaders map[string]string `json:"default_headers,omitempty" xml:"-"`
+
+	// 静态网站（仅对匿名请求生效）
+	WebsiteIndex string `json:"website_index"` // 索引文档，如 index.html，空表示关闭
+	WebsiteSPA   bool   `json:"website_spa"`   // 对象不存在时返回索引文档（单页应用回退）
 }
 
 // Object 对象模型
 type Object struct {
-	Key          string    `json:"key"`
-	Bucket       string    `json:"bucket"`
-	Size         int64     `json:"size"`
-	ETag         string    `json:"etag"`
-	ContentType  string    `json:"content_type"`
-	LastModified time.Time `json:"last_modified"`
-	StoragePath  string    `json:"-"` // 实际存储路径
+	Key          string            `json:"key"`
+	Bucket       string            `json:"bucket"`
+	Size         int64             `json:"size"`
+	ETag         string            `json:"etag"`
+	ContentType  string            `json:"content_type"`
+	LastModified time.Time         `json:"last_modified"`
+	CreatedAt    time.Time         `json:"created_at" xml:"-"`           // 首次写入时间，覆盖时保持不变
+	StoragePath  string            `json:"-"`                            // 实际存储路径
+	Headers      map[string]string `json:"headers,omitempty" xml:"-"`    // 上传时指定的响应头，如 Cache-Control
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty" xml:"-"` // 自定义过期时间，到期后自动删除
+	Tags         map[string]string `json:"tags,omitempty" xml:"-"`       // 对象标签，覆盖写入时清空
+	VersionID    string            `json:"version_id,omitempty" xml:"-"` // 版本ID，未启用版本控制时写入的对象为空（即 null 版本）
+	Metadata     map[string]string `json:"metadata,omitempty" xml:"-"`   // 用户元数据（x-amz-meta-*），键为去掉前缀的小写名称
+
+	EncryptionNonce string `json:"-" xml:"-"` // 静态加密的 IV（十六进制），为空表示文件为明文（启用加密前写入的对象）
 }
 
 // MultipartUpload 多段上传模型
 type MultipartUpload struct {
-	UploadID    string    `json:"upload_id"`
-	Bucket      string    `json:"bucket"`
-	Key         string    `json:"key"`
-	Initiated   time.Time `json:"initiated"`
-	ContentType string    `json:"content_type"`
+	UploadID    string            `json:"upload_id"`
+	Bucket      string            `json:"bucket"`
+	Key         string            `json:"key"`
+	Initiated   time.Time         `json:"initiated"`
+	ContentType string            `json:"content_type"`
+	Metadata    map[string]string `json:"metadata,omitempty"` // 初始化时指定的用户元数据，完成上传后写入对象
+}
+
+// MultipartUploadInfo 未完成的多段上传及已上传分片的统计
+type MultipartUploadInfo struct {
+	MultipartUpload
+	PartCount int   `json:"part_count"`
+	TotalSize int64 `json:"total_size"`
+}
+
+// MultipartUploadListQuery 未完成多段上传列表查询条件，按键、上传 ID 升序
+type MultipartUploadListQuery struct {
+	Bucket         string
+	Prefix         string
+	Delimiter      string
+	KeyMarker      string // 从该键之后开始
+	UploadIDMarker string // 与 KeyMarker 同时指定时，KeyMarker 下上传 ID 大于该值的上传也会列出
+	MaxUploads     int
+}
+
+// MultipartUploadList 未完成多段上传列表，公共前缀与上传一样计入 MaxUploads
+type MultipartUploadList struct {
+	Uploads            []MultipartUploadInfo
+	CommonPrefixes     []string
+	IsTruncated        bool
+	NextKeyMarker      string
+	NextUploadIDMarker string
 }
 
 // Part 上传分片模型
@@ -54,4 +137,5 @@ type ListObjectsResult struct {
 	StartAfter         string    `xml:"StartAfter,omitempty"`
 	Marker             string    `xml:"Marker,omitempty"`
 	NextMarker         string    `xml:"NextMarker,omitempty"`
+	BudgetExceeded     bool      `xml:"-"` // 超出扫描时间预算提前截断
 }
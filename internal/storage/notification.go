@@ -0,0 +1,410 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 事件类型，与 S3 事件通知的名称一致
+const (
+	EventObjectCreatedPut                 = "s3:ObjectCreated:Put"
+	EventObjectCreatedPost                = "s3:ObjectCreated:Post"
+	EventObjectCreatedCopy                = "s3:ObjectCreated:Copy"
+	EventObjectCreatedCompleteMultipart   = "s3:ObjectCreated:CompleteMultipartUpload"
+	EventObjectRemovedDelete              = "s3:ObjectRemoved:Delete"
+	EventObjectRemovedDeleteMarkerCreated = "s3:ObjectRemoved:DeleteMarkerCreated"
+	EventTest                             = "s3:TestEvent" // 测试投递用的合成事件，不可订阅
+	eventObjectCreatedAll                 = "s3:ObjectCreated:*"
+	eventObjectRemovedAll                 = "s3:ObjectRemoved:*"
+)
+
+// 事件通知参数
+const (
+	notificationQueueSize  = 1000
+	notificationMaxRetries = 3
+	notificationTimeout    = 10 * time.Second
+	MaxNotificationTargets = 10
+)
+
+// notificationEvents 可订阅的事件（含通配）
+var notificationEvents = map[string]bool{
+	EventObjectCreatedPut:                 true,
+	EventObjectCreatedPost:                true,
+	EventObjectCreatedCopy:                true,
+	EventObjectCreatedCompleteMultipart:   true,
+	EventObjectRemovedDelete:              true,
+	EventObjectRemovedDeleteMarkerCreated: true,
+	eventObjectCreatedAll:                 true,
+	eventObjectRemovedAll:                 true,
+}
+
+// NotificationTarget Webhook 目标，事件以 JSON POST 到 URL
+type NotificationTarget struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"` // 如 s3:ObjectCreated:*、s3:ObjectRemoved:Delete
+}
+
+// NotificationConfig 桶事件通知配置
+type NotificationConfig struct {
+	Bucket    string               `json:"bucket"`
+	Targets   []NotificationTarget `json:"targets"`
+	UpdatedAt time.Time            `json:"updatedAt"`
+}
+
+// ObjectEvent 投递给 Webhook 的事件内容，删除事件不含 size 和 etag
+type ObjectEvent struct {
+	EventName string    `json:"eventName"`
+	EventTime time.Time `json:"eventTime"`
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	Size      int64     `json:"size,omitempty"`
+	ETag      string    `json:"etag,omitempty"`
+	VersionID string    `json:"versionId,omitempty"`
+}
+
+// NotificationStats 事件投递统计
+type NotificationStats struct {
+	Pending     int        `json:"pending"`   // 队列中等待投递的事件数
+	Delivered   int64      `json:"delivered"` // 成功投递的次数
+	Failed      int64      `json:"failed"`    // 重试后仍失败或队列已满丢弃的次数
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// NotificationTestResult 向单个目标发送测试事件的结果
+type NotificationTestResult struct {
+	URL        string `json:"url"`
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"statusCode,omitempty"` // 未收到响应时为 0
+	LatencyMs  int64  `json:"latencyMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Matches 目标是否订阅了该事件
+func (t *NotificationTarget) Matches(eventName string) bool {
+	for _, e := range t.Events {
+		if e == eventName || (strings.HasSuffix(e, ":*") && strings.HasPrefix(eventName, strings.TrimSuffix(e, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateNotificationConfig 校验目标 URL 和事件名称
+func ValidateNotificationConfig(cfg *NotificationConfig) error {
+	if len(cfg.Targets) > MaxNotificationTargets {
+		return fmt.Errorf("at most %d notification targets are allowed", MaxNotificationTargets)
+	}
+	for _, t := range cfg.Targets {
+		u, err := url.Parse(t.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook url %q", t.URL)
+		}
+		if len(t.Events) == 0 {
+			return fmt.Errorf("webhook %q has no events", t.URL)
+		}
+		for _, e := range t.Events {
+			if !notificationEvents[e] {
+				return fmt.Errorf("unsupported event %q", e)
+			}
+		}
+	}
+	return nil
+}
+
+// initNotificationTable 初始化事件通知配置表
+func (m *MetadataStore) initNotificationTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS bucket_notification (
+		bucket TEXT PRIMARY KEY,
+		targets TEXT NOT NULL,
+		updated_at DATETIME NOT NULL
+	)`)
+	return err
+}
+
+// GetNotificationConfig 获取桶事件通知配置，不存在返回 nil
+func (m *MetadataStore) GetNotificationConfig(bucket string) (*NotificationConfig, error) {
+	var cfg NotificationConfig
+	var targets string
+	err := m.db.QueryRow(`
+		SELECT bucket, targets, updated_at FROM bucket_notification WHERE bucket = ?
+	`, bucket).Scan(&cfg.Bucket, &targets, &cfg.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(targets), &cfg.Targets); err != nil {
+		return nil, fmt.Errorf("decode notification targets failed: %w", err)
+	}
+	return &cfg, nil
+}
+
+// SaveNotificationConfig 保存桶事件通知配置
+func (m *MetadataStore) SaveNotificationConfig(cfg *NotificationConfig) error {
+	targets, err := json.Marshal(cfg.Targets)
+	if err != nil {
+		return err
+	}
+	cfg.UpdatedAt = time.Now().UTC()
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec(`
+			INSERT OR REPLACE INTO bucket_notification (bucket, targets, updated_at) VALUES (?, ?, ?)
+		`, cfg.Bucket, string(targets), cfg.UpdatedAt)
+		return err
+	})
+}
+
+// DeleteNotificationConfig 删除桶事件通知配置
+func (m *MetadataStore) DeleteNotificationConfig(bucket string) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("DELETE FROM bucket_notification WHERE bucket = ?", bucket)
+		return err
+	})
+}
+
+// notificationTask 待投递的事件及其目标
+type notificationTask struct {
+	event ObjectEvent
+	url   string
+}
+
+// Notifier 异步事件通知器：写操作成功后事件进入缓冲队列，由后台工作者 POST 到 Webhook，不阻塞请求
+type Notifier struct {
+	mu        sync.Mutex
+	metadata  *MetadataStore
+	client    *http.Client
+	configs   map[string]*NotificationConfig // 已加载的配置，nil 表示桶无配置
+	queue     chan notificationTask
+	retryWait time.Duration
+	done      chan struct{}
+	closed    bool
+
+	pending     int
+	delivered   int64
+	failed      int64
+	lastError   string
+	lastErrorAt *time.Time
+}
+
+// NewNotifier 创建事件通知器并启动投递工作者
+func NewNotifier(metadata *MetadataStore) *Notifier {
+	n := &Notifier{
+		metadata:  metadata,
+		client:    &http.Client{Timeout: notificationTimeout},
+		configs:   make(map[string]*NotificationConfig),
+		queue:     make(chan notificationTask, notificationQueueSize),
+		retryWait: time.Second,
+		done:      make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// SetRetryWait 设置重试间隔（第 N 次重试等待 N 倍间隔）
+func (n *Notifier) SetRetryWait(d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.retryWait = d
+}
+
+// SetConfig 保存事件通知配置并立即生效
+func (n *Notifier) SetConfig(cfg *NotificationConfig) error {
+	if err := n.metadata.SaveNotificationConfig(cfg); err != nil {
+		return err
+	}
+	saved := *cfg
+	n.mu.Lock()
+	n.configs[cfg.Bucket] = &saved
+	n.mu.Unlock()
+	return nil
+}
+
+// DeleteConfig 删除事件通知配置，已入队的事件仍会投递
+func (n *Notifier) DeleteConfig(bucket string) error {
+	if err := n.metadata.DeleteNotificationConfig(bucket); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	n.configs[bucket] = nil
+	n.mu.Unlock()
+	return nil
+}
+
+// ForgetBucket 桶被删除时调用，清除缓存的配置
+func (n *Notifier) ForgetBucket(bucket string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.configs, bucket)
+}
+
+// configLocked 获取桶的配置，首次访问时从数据库加载（需持有锁）
+func (n *Notifier) configLocked(bucket string) *NotificationConfig {
+	if cfg, ok := n.configs[bucket]; ok {
+		return cfg
+	}
+	cfg, err := n.metadata.GetNotificationConfig(bucket)
+	if err != nil {
+		// 加载失败不缓存，下次重试
+		return nil
+	}
+	n.configs[bucket] = cfg
+	return cfg
+}
+
+// Enqueue 在写操作成功后调用，为订阅了该事件的每个目标入队一次；队列已满时丢弃并计为失败
+func (n *Notifier) Enqueue(event ObjectEvent) {
+	if event.EventTime.IsZero() {
+		event.EventTime = time.Now().UTC()
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	cfg := n.configLocked(event.Bucket)
+	if cfg == nil {
+		return
+	}
+	for _, t := range cfg.Targets {
+		if !t.Matches(event.EventName) {
+			continue
+		}
+		select {
+		case n.queue <- notificationTask{event: event, url: t.URL}:
+			n.pending++
+		default:
+			n.failed++
+			now := time.Now()
+			n.lastError = "notification queue full, dropped " + event.EventName + " " + event.Key
+			n.lastErrorAt = &now
+		}
+	}
+}
+
+// Stats 获取投递统计
+func (n *Notifier) Stats() NotificationStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return NotificationStats{
+		Pending:     n.pending,
+		Delivered:   n.delivered,
+		Failed:      n.failed,
+		LastError:   n.lastError,
+		LastErrorAt: n.lastErrorAt,
+	}
+}
+
+// Shutdown 停止接受新事件，等待队列中已有的事件投递完成或 ctx 到期
+func (n *Notifier) Shutdown(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("notification queue not drained: %w", ctx.Err())
+	}
+}
+
+// SendTestEvent 向桶配置的每个目标同步发送一次 s3:TestEvent，不经过队列、不重试，也不计入投递统计
+// 桶未配置事件通知时返回 nil
+func (n *Notifier) SendTestEvent(bucket string) []NotificationTestResult {
+	n.mu.Lock()
+	cfg := n.configLocked(bucket)
+	n.mu.Unlock()
+	if cfg == nil {
+		return nil
+	}
+
+	event := ObjectEvent{EventName: EventTest, EventTime: time.Now().UTC(), Bucket: bucket}
+	results := make([]NotificationTestResult, 0, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		start := time.Now()
+		status, err := n.post(t.URL, event)
+		result := NotificationTestResult{
+			URL:        t.URL,
+			Success:    err == nil,
+			StatusCode: status,
+			LatencyMs:  time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// run 工作者主循环，按入队顺序逐个投递
+func (n *Notifier) run() {
+	defer close(n.done)
+	for task := range n.queue {
+		var err error
+		for attempt := 0; attempt <= notificationMaxRetries; attempt++ {
+			if attempt > 0 {
+				n.mu.Lock()
+				wait := n.retryWait * time.Duration(attempt)
+				n.mu.Unlock()
+				time.Sleep(wait)
+			}
+			if err = n.deliver(task); err == nil {
+				break
+			}
+		}
+
+		n.mu.Lock()
+		n.pending--
+		if err != nil {
+			n.failed++
+			now := time.Now()
+			n.lastError = fmt.Sprintf("%s %s/%s to %s: %v", task.event.EventName, task.event.Bucket, task.event.Key, task.url, err)
+			n.lastErrorAt = &now
+		} else {
+			n.delivered++
+		}
+		n.mu.Unlock()
+	}
+}
+
+// deliver POST 单个事件，2xx 视为成功
+func (n *Notifier) deliver(task notificationTask) error {
+	_, err := n.post(task.url, task.event)
+	return err
+}
+
+// post 将事件以 JSON POST 到 target，返回响应状态码；非 2xx 返回错误
+func (n *Notifier) post(target string, event ObjectEvent) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWebhook 模拟的 Webhook 接收端，记录收到的事件
+type fakeWebhook struct {
+	mu       sync.Mutex
+	events   []ObjectEvent
+	failures int // 前几次请求返回 500
+	server   *httptest.Server
+}
+
+func newFakeWebhook(t *testing.T, failures int) *fakeWebhook {
+	t.Helper()
+	f := &fakeWebhook{failures: failures}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.failures > 0 {
+			f.failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var event ObjectEvent
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" ||
+			json.NewDecoder(r.Body).Decode(&event) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.events = append(f.events, event)
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeWebhook) snapshot() []ObjectEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ObjectEvent(nil), f.events...)
+}
+
+// drainNotifier 等待队列投递完成
+func drainNotifier(t *testing.T, n *Notifier) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.Shutdown(ctx); err != nil {
+		t.Fatalf("等待投递超时: %v", err)
+	}
+}
+
+// TestNotificationTargetMatches 测试事件名称和通配匹配
+func TestNotificationTargetMatches(t *testing.T) {
+	target := NotificationTarget{Events: []string{"s3:ObjectCreated:*", EventObjectRemovedDelete}}
+	cases := map[string]bool{
+		EventObjectCreatedPut:                 true,
+		EventObjectCreatedCompleteMultipart:   true,
+		EventObjectRemovedDelete:              true,
+		EventObjectRemovedDeleteMarkerCreated: false,
+	}
+	for event, want := range cases {
+		if got := target.Matches(event); got != want {
+			t.Errorf("%s: 期望 %v，实际 %v", event, want, got)
+		}
+	}
+}
+
+// TestValidateNotificationConfig 测试配置校验
+func TestValidateNotificationConfig(t *testing.T) {
+	valid := &NotificationConfig{Targets: []NotificationTarget{{URL: "https://hooks.example.com/s3", Events: []string{"s3:ObjectRemoved:*"}}}}
+	if err := ValidateNotificationConfig(valid); err != nil {
+		t.Errorf("合法配置校验失败: %v", err)
+	}
+	invalid := map[string]NotificationTarget{
+		"非 HTTP 地址": {URL: "ftp://example.com", Events: []string{EventObjectCreatedPut}},
+		"缺少主机":      {URL: "http:///path", Events: []string{EventObjectCreatedPut}},
+		"未知事件":      {URL: "http://example.com", Events: []string{"s3:ObjectRestore:*"}},
+		"没有事件":      {URL: "http://example.com"},
+	}
+	for name, target := range invalid {
+		if err := ValidateNotificationConfig(&NotificationConfig{Targets: []NotificationTarget{target}}); err == nil {
+			t.Errorf("%s 应校验失败", name)
+		}
+	}
+}
+
+// TestNotificationConfigStorage 测试配置的保存、读取、删除及删除桶时清理
+func TestNotificationConfigStorage(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	store.CreateBucket("events")
+
+	if cfg, err := store.GetNotificationConfig("events"); err != nil || cfg != nil {
+		t.Fatalf("未配置时应返回 nil: %v %v", cfg, err)
+	}
+	cfg := &NotificationConfig{Bucket: "events", Targets: []NotificationTarget{
+		{URL: "http://a.example.com", Events: []string{"s3:ObjectCreated:*"}},
+		{URL: "http://b.example.com", Events: []string{EventObjectRemovedDelete}},
+	}}
+	if err := store.SaveNotificationConfig(cfg); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	got, err := store.GetNotificationConfig("events")
+	if err != nil || got == nil || len(got.Targets) != 2 || got.Targets[1].Events[0] != EventObjectRemovedDelete {
+		t.Fatalf("读取配置错误: %+v %v", got, err)
+	}
+	if err := store.DeleteNotificationConfig("events"); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if got, _ := store.GetNotificationConfig("events"); got != nil {
+		t.Error("删除后应返回 nil")
+	}
+
+	store.SaveNotificationConfig(cfg)
+	if err := store.DeleteBucket("events"); err != nil {
+		t.Fatalf("删除桶失败: %v", err)
+	}
+	if got, _ := store.GetNotificationConfig("events"); got != nil {
+		t.Error("删除桶后配置应被清理")
+	}
+}
+
+// TestNotifierDelivery 测试事件按订阅投递、失败重试及失败计数
+func TestNotifierDelivery(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	store.CreateBucket("events")
+
+	created := newFakeWebhook(t, 2) // 前两次失败，重试后成功
+	removed := newFakeWebhook(t, 100)
+	n := NewNotifier(store)
+	n.SetRetryWait(time.Millisecond)
+	if err := n.SetConfig(&NotificationConfig{Bucket: "events", Targets: []NotificationTarget{
+		{URL: created.server.URL, Events: []string{"s3:ObjectCreated:*"}},
+		{URL: removed.server.URL, Events: []string{"s3:ObjectRemoved:*"}},
+	}}); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+
+	n.Enqueue(ObjectEvent{EventName: EventObjectCreatedPut, Bucket: "events", Key: "a.txt", Size: 3, ETag: "abc"})
+	n.Enqueue(ObjectEvent{EventName: EventObjectRemovedDelete, Bucket: "events", Key: "a.txt"})
+	n.Enqueue(ObjectEvent{EventName: EventObjectCreatedPut, Bucket: "other", Key: "b.txt"})
+	drainNotifier(t, n)
+
+	events := created.snapshot()
+	if len(events) != 1 {
+		t.Fatalf("应投递 1 个创建事件，实际 %d", len(events))
+	}
+	e := events[0]
+	if e.EventName != EventObjectCreatedPut || e.Bucket != "events" || e.Key != "a.txt" || e.Size != 3 || e.ETag != "abc" || e.EventTime.IsZero() {
+		t.Errorf("事件内容错误: %+v", e)
+	}
+	if len(removed.snapshot()) != 0 {
+		t.Error("持续失败的目标不应收到事件")
+	}
+
+	stats := n.Stats()
+	if stats.Delivered != 1 || stats.Failed != 1 || stats.Pending != 0 || stats.LastError == "" || stats.LastErrorAt == nil {
+		t.Errorf("投递统计错误: %+v", stats)
+	}
+
+	// 关闭后不再接受新事件
+	n.Enqueue(ObjectEvent{EventName: EventObjectCreatedPut, Bucket: "events", Key: "c.txt"})
+	if n.Stats().Pending != 0 {
+		t.Error("关闭后不应入队")
+	}
+}
+
+// TestNotifierConfigChanges 测试配置从数据库加载、删除配置后不再投递
+func TestNotifierConfigChanges(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	store.CreateBucket("events")
+	hook := newFakeWebhook(t, 0)
+	store.SaveNotificationConfig(&NotificationConfig{Bucket: "events", Targets: []NotificationTarget{
+		{URL: hook.server.URL, Events: []string{EventObjectCreatedCopy}},
+	}})
+
+	n := NewNotifier(store)
+	n.Enqueue(ObjectEvent{EventName: EventObjectCreatedCopy, Bucket: "events", Key: "a.txt"})
+	n.Enqueue(ObjectEvent{EventName: EventObjectCreatedPut, Bucket: "events", Key: "b.txt"})
+	if err := n.DeleteConfig("events"); err != nil {
+		t.Fatalf("删除配置失败: %v", err)
+	}
+	n.Enqueue(ObjectEvent{EventName: EventObjectCreatedCopy, Bucket: "events", Key: "c.txt"})
+	drainNotifier(t, n)
+
+	events := hook.snapshot()
+	if len(events) != 1 || events[0].Key != "a.txt" {
+		t.Errorf("只应投递删除配置前订阅的事件: %+v", events)
+	}
+}
+
+// TestNotifierSendTestEvent 测试同步发送测试事件：不论订阅了哪些事件都发送，返回各目标结果且不计入统计
+func TestNotifierSendTestEvent(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	store.CreateBucket("events")
+
+	n := NewNotifier(store)
+	defer drainNotifier(t, n)
+	if results := n.SendTestEvent("events"); results != nil {
+		t.Fatalf("未配置时应返回 nil: %+v", results)
+	}
+
+	ok := newFakeWebhook(t, 0)
+	failing := newFakeWebhook(t, 100)
+	n.SetConfig(&NotificationConfig{Bucket: "events", Targets: []NotificationTarget{
+		{URL: ok.server.URL, Events: []string{EventObjectRemovedDelete}},
+		{URL: failing.server.URL, Events: []string{"s3:ObjectCreated:*"}},
+		{URL: "http://127.0.0.1:1/unreachable", Events: []string{"s3:ObjectCreated:*"}},
+	}})
+
+	results := n.SendTestEvent("events")
+	if len(results) != 3 {
+		t.Fatalf("应返回 3 个结果: %+v", results)
+	}
+	if r := results[0]; !r.Success || r.StatusCode != http.StatusOK || r.Error != "" || r.URL != ok.server.URL {
+		t.Errorf("成功目标结果错误: %+v", r)
+	}
+	if r := results[1]; r.Success || r.StatusCode != http.StatusInternalServerError || r.Error == "" {
+		t.Errorf("返回 500 的目标结果错误: %+v", r)
+	}
+	if r := results[2]; r.Success || r.StatusCode != 0 || r.Error == "" {
+		t.Errorf("不可达目标结果错误: %+v", r)
+	}
+
+	events := ok.snapshot()
+	if len(events) != 1 || events[0].EventName != EventTest || events[0].Bucket != "events" || events[0].EventTime.IsZero() {
+		t.Errorf("测试事件内容错误: %+v", events)
+	}
+	failing.mu.Lock()
+	remaining := failing.failures
+	failing.mu.Unlock()
+	if remaining != 99 {
+		t.Errorf("测试事件不应重试: 剩余失败次数 %d", remaining)
+	}
+	if stats := n.Stats(); stats.Delivered != 0 || stats.Failed != 0 || stats.LastError != "" {
+		t.Errorf("测试事件不应计入统计: %+v", stats)
+	}
+}
@@ -0,0 +1,124 @@
+package storage
+
+// objectCounterTriggers 维护 bucket_counters 的触发器，与 objects 的写入在同一语句内生效，
+// 存储统计直接读取计数器，无需扫描 objects 表
+// 覆盖写入使用 UPSERT（见 putObjectLocked），走 UPDATE 触发器；INSERT OR REPLACE 默认不触发 DELETE 触发器
+var objectCounterTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS trg_objects_counter_insert AFTER INSERT ON objects
+	BEGIN
+		INSERT INTO bucket_counters (bucket, object_count, total_size) VALUES (NEW.bucket, 1, NEW.size)
+		ON CONFLICT(bucket) DO UPDATE SET object_count = object_count + 1, total_size = total_size + NEW.size;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS trg_objects_counter_delete AFTER DELETE ON objects
+	BEGIN
+		UPDATE bucket_counters SET object_count = object_count - 1, total_size = total_size - OLD.size
+		WHERE bucket = OLD.bucket;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS trg_objects_counter_update AFTER UPDATE OF bucket, size ON objects
+	BEGIN
+		UPDATE bucket_counters SET object_count = object_count - 1, total_size = total_size - OLD.size
+		WHERE bucket = OLD.bucket;
+		INSERT INTO bucket_counters (bucket, object_count, total_size) VALUES (NEW.bucket, 1, NEW.size)
+		ON CONFLICT(bucket) DO UPDATE SET object_count = object_count + 1, total_size = total_size + NEW.size;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS trg_buckets_counter_delete AFTER DELETE ON buckets
+	BEGIN
+		DELETE FROM bucket_counters WHERE bucket = OLD.name;
+	END`,
+}
+
+// initObjectCounterTable 初始化对象计数器表及触发器，首次创建时按现有对象回填
+func (m *MetadataStore) initObjectCounterTable() error {
+	var exists bool
+	if err := m.db.QueryRow("SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'bucket_counters'").Scan(&exists); err != nil {
+		return err
+	}
+	schema := `CREATE TABLE IF NOT EXISTS bucket_counters (
+		bucket TEXT PRIMARY KEY,
+		object_count INTEGER NOT NULL DEFAULT 0,
+		total_size INTEGER NOT NULL DEFAULT 0
+	)`
+	if _, err := m.db.Exec(schema); err != nil {
+		return err
+	}
+	for _, trigger := range objectCounterTriggers {
+		if _, err := m.db.Exec(trigger); err != nil {
+			return err
+		}
+	}
+	if !exists {
+		_, err := m.RecomputeObjectCounters()
+		return err
+	}
+	return nil
+}
+
+// ObjectCounterRecompute 重建计数器的结果，Drift 为重建后与重建前的差值
+type ObjectCounterRecompute struct {
+	TotalObjects int64 `json:"total_objects"`
+	TotalSize    int64 `json:"total_size"`
+	ObjectDrift  int64 `json:"object_drift"`
+	SizeDrift    int64 `json:"size_drift"`
+}
+
+// RecomputeObjectCounters 按 objects 表全量重建计数器，用于修复偏差（需要全表扫描）
+func (m *MetadataStore) RecomputeObjectCounters() (*ObjectCounterRecompute, error) {
+	result := &ObjectCounterRecompute{}
+	err := m.withWriteLock(func() error {
+		tx, err := m.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var beforeObjects, beforeSize int64
+		if err := tx.QueryRow("SELECT COALESCE(SUM(object_count), 0), COALESCE(SUM(total_size), 0) FROM bucket_counters").
+			Scan(&beforeObjects, &beforeSize); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM bucket_counters"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO bucket_counters (bucket, object_count, total_size)
+			SELECT bucket, COUNT(*), COALESCE(SUM(size), 0) FROM objects GROUP BY bucket
+		`); err != nil {
+			return err
+		}
+		if err := tx.QueryRow("SELECT COALESCE(SUM(object_count), 0), COALESCE(SUM(total_size), 0) FROM bucket_counters").
+			Scan(&result.TotalObjects, &result.TotalSize); err != nil {
+			return err
+		}
+		result.ObjectDrift = result.TotalObjects - beforeObjects
+		result.SizeDrift = result.TotalSize - beforeSize
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListBucketCounters 读取各桶的对象数和总大小（只读计数器，不扫描 objects 表），没有对象的桶计为 0，按桶名排序
+func (m *MetadataStore) ListBucketCounters() ([]BucketStat, error) {
+	rows, err := m.db.Query(`
+		SELECT b.name, COALESCE(c.object_count, 0), COALESCE(c.total_size, 0)
+		FROM buckets b
+		LEFT JOIN bucket_counters c ON b.name = c.bucket
+		ORDER BY b.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []BucketStat
+	for rows.Next() {
+		var bs BucketStat
+		if err := rows.Scan(&bs.Name, &bs.ObjectCount, &bs.TotalSize); err != nil {
+			return nil, err
+		}
+		stats = append(stats, bs)
+	}
+	return stats, rows.Err()
+}
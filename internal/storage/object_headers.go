@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// objectHeaderNames 随对象保存、下载时返回的标准响应头
+var objectHeaderNames = []string{
+	"Cache-Control",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Expires",
+}
+
+// MaxObjectHeaderValueLength 单个头部值长度上限
+const MaxObjectHeaderValueLength = 1024
+
+// ObjectHeaderNames 返回支持的对象响应头名称
+func ObjectHeaderNames() []string {
+	return append([]string(nil), objectHeaderNames...)
+}
+
+// CanonicalObjectHeader 返回受支持头部的规范名称，不支持时返回空
+func CanonicalObjectHeader(name string) string {
+	name = http.CanonicalHeaderKey(name)
+	for _, h := range objectHeaderNames {
+		if h == name {
+			return h
+		}
+	}
+	return ""
+}
+
+// encodeHeaders 序列化头部，空集合存为空串
+func encodeHeaders(headers map[string]string) string {
+	if len(headers) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(headers)
+	return string(data)
+}
+
+// decodeHeaders 反序列化头部，格式错误时视为空
+func decodeHeaders(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(s), &headers); err != nil {
+		return nil
+	}
+	return headers
+}
+
+// MergeDefaultHeaders 用桶默认头部补齐对象缺失的头部，对象已有的值优先
+func (b *Bucket) MergeDefaultHeaders(headers map[string]string) map[string]string {
+	if b == nil || len(b.DefaultHeaders) == 0 {
+		return headers
+	}
+	merged := make(map[string]string, len(headers)+len(b.DefaultHeaders))
+	for k, v := range b.DefaultHeaders {
+		merged[k] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 对象列表排序字段
+const (
+	ObjectSortKey      = "key"      // 按键字典序（与 S3 一致）
+	ObjectSortSize     = "size"     // 按大小
+	ObjectSortModified = "modified" // 按最后修改时间
+	ObjectSortCreated  = "created"  // 按首次写入时间
+)
+
+// ErrMarkerNotFound 非按键排序时分页标记指向的对象已不存在
+var ErrMarkerNotFound = errors.New("marker object no longer exists")
+
+// objectSortColumns 排序字段对应的列，均有 (bucket, 列, key) 索引
+var objectSortColumns = map[string]string{
+	ObjectSortKey:      "key",
+	ObjectSortSize:     "size",
+	ObjectSortModified: "last_modified",
+	ObjectSortCreated:  "created_at",
+}
+
+// ObjectSort 对象列表排序方式，零值为按键升序
+// 非按键排序时以键作为第二排序字段，方向相同，保证分页稳定
+type ObjectSort struct {
+	Field string // key/size/modified/created，空表示 key
+	Desc  bool   // 是否降序
+}
+
+// ParseObjectSort 解析排序字段和方向（asc/desc），均可为空
+func ParseObjectSort(field, order string) (ObjectSort, error) {
+	if field == "" {
+		field = ObjectSortKey
+	}
+	if _, ok := objectSortColumns[field]; !ok {
+		return ObjectSort{}, fmt.Errorf("sort must be one of key, size, modified, created")
+	}
+	if order != "" && order != "asc" && order != "desc" {
+		return ObjectSort{}, fmt.Errorf("order must be asc or desc")
+	}
+	return ObjectSort{Field: field, Desc: order == "desc"}, nil
+}
+
+// Order 返回排序方向 asc/desc
+func (s ObjectSort) Order() string {
+	if s.Desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// column 返回排序列
+func (s ObjectSort) column() string {
+	if col, ok := objectSortColumns[s.Field]; ok {
+		return col
+	}
+	return "key"
+}
+
+// orderBy 返回 ORDER BY 子句
+func (s ObjectSort) orderBy() string {
+	dir := " ASC"
+	if s.Desc {
+		dir = " DESC"
+	}
+	if col := s.column(); col != "key" {
+		return " ORDER BY " + col + dir + ", key" + dir
+	}
+	return " ORDER BY key" + dir
+}
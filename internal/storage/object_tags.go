@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 对象标签限制，与 S3 一致
+const (
+	MaxObjectTags     = 10  // 每个对象最多的标签数
+	MaxTagKeyLength   = 128 // 标签键最大长度（Unicode 字符）
+	MaxTagValueLength = 256 // 标签值最大长度（Unicode 字符）
+)
+
+// 对象标签校验错误
+var (
+	ErrTooManyTags      = errors.New("object tags cannot be greater than 10")
+	ErrTagKeyLength     = errors.New("the tag key must be between 1 and 128 characters")
+	ErrTagValueLength   = errors.New("the tag value must be at most 256 characters")
+	ErrTagReservedKey   = errors.New("tag keys starting with aws: are reserved")
+	ErrTagInvalidChars  = errors.New("tags may only contain letters, numbers, spaces and + - = . _ : / @")
+	ErrTagDuplicatedKey = errors.New("cannot provide multiple tags with the same key")
+)
+
+// ObjectTag 一个对象标签，保留请求中的顺序用于校验重复键
+type ObjectTag struct {
+	Key   string
+	Value string
+}
+
+// ValidateObjectTags 按 S3 规则校验标签集合，通过后返回键值映射
+func ValidateObjectTags(tags []ObjectTag) (map[string]string, error) {
+	if len(tags) > MaxObjectTags {
+		return nil, ErrTooManyTags
+	}
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if n := utf8.RuneCountInString(tag.Key); n == 0 || n > MaxTagKeyLength {
+			return nil, ErrTagKeyLength
+		}
+		if utf8.RuneCountInString(tag.Value) > MaxTagValueLength {
+			return nil, ErrTagValueLength
+		}
+		if strings.HasPrefix(strings.ToLower(tag.Key), "aws:") {
+			return nil, ErrTagReservedKey
+		}
+		if !validTagString(tag.Key) || !validTagString(tag.Value) {
+			return nil, ErrTagInvalidChars
+		}
+		if _, ok := result[tag.Key]; ok {
+			return nil, ErrTagDuplicatedKey
+		}
+		result[tag.Key] = tag.Value
+	}
+	return result, nil
+}
+
+// validTagString 标签只允许字母、数字、空格和 + - = . _ : / @
+func validTagString(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == ' ' || strings.ContainsRune("+-=._:/@", r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// PutObjectTags 替换对象的全部标签，不修改 ETag 和最后修改时间；tags 为空时清除标签
+// 对象不存在时返回 false
+func (m *MetadataStore) PutObjectTags(bucket, key string, tags map[string]string) (bool, error) {
+	var updated bool
+	err := m.withWriteLock(func() error {
+		// 标签与请求头使用相同的 JSON 编码
+		res, err := m.db.Exec("UPDATE objects SET tags = ? WHERE bucket = ? AND key = ?", encodeHeaders(tags), bucket, key)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		updated = n > 0
+		return err
+	})
+	return updated, err
+}
@@ -0,0 +1,304 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// originFetchTimeout 单次回源拉取的超时时间
+const originFetchTimeout = 5 * time.Minute
+
+// ErrOriginFetch 回源拉取失败（源端不可达或返回非 404 错误）
+var ErrOriginFetch = errors.New("origin fetch failed")
+
+// OriginConfig 桶回源配置：本地不存在的对象在首次读取时从源端拉取并保存到本地
+// 用于迁移切换期间先切流量、后台再补齐数据
+type OriginConfig struct {
+	Bucket       string    `json:"bucket"`
+	Endpoint     string    `json:"endpoint"`
+	AccessKey    string    `json:"accessKey"`
+	SecretKey    string    `json:"secretKey,omitempty"`
+	Region       string    `json:"region"`
+	SourceBucket string    `json:"sourceBucket"`
+	SourcePrefix string    `json:"sourcePrefix"` // 可选：源对象键前缀，本地键 a.txt 对应源键 prefix+a.txt
+	Enabled      bool      `json:"enabled"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// initOriginTable 初始化回源配置表
+func (m *MetadataStore) initOriginTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS bucket_origin (
+		bucket TEXT PRIMARY KEY,
+		endpoint TEXT NOT NULL,
+		access_key TEXT NOT NULL,
+		secret_key TEXT NOT NULL,
+		region TEXT NOT NULL DEFAULT '',
+		source_bucket TEXT NOT NULL,
+		source_prefix TEXT NOT NULL DEFAULT '',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		updated_at DATETIME NOT NULL
+	)`)
+	return err
+}
+
+// GetOriginConfig 获取桶回源配置，不存在返回 nil
+func (m *MetadataStore) GetOriginConfig(bucket string) (*OriginConfig, error) {
+	var cfg OriginConfig
+	var secret string
+	err := m.db.QueryRow(`
+		SELECT bucket, endpoint, access_key, secret_key, region, source_bucket, source_prefix, enabled, updated_at
+		FROM bucket_origin WHERE bucket = ?
+	`, bucket).Scan(&cfg.Bucket, &cfg.Endpoint, &cfg.AccessKey, &secret, &cfg.Region, &cfg.SourceBucket,
+		&cfg.SourcePrefix, &cfg.Enabled, &cfg.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SecretKey, err = m.DecryptSecret(secret); err != nil {
+		return nil, fmt.Errorf("decrypt origin secret failed: %w", err)
+	}
+	return &cfg, nil
+}
+
+// SaveOriginConfig 保存桶回源配置（Secret 加密存储）
+func (m *MetadataStore) SaveOriginConfig(cfg *OriginConfig) error {
+	secret, err := m.EncryptSecret(cfg.SecretKey)
+	if err != nil {
+		return fmt.Errorf("encrypt origin secret failed: %w", err)
+	}
+	cfg.UpdatedAt = time.Now().UTC()
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec(`
+			INSERT OR REPLACE INTO bucket_origin
+			(bucket, endpoint, access_key, secret_key, region, source_bucket, source_prefix, enabled, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, cfg.Bucket, cfg.Endpoint, cfg.AccessKey, secret, cfg.Region, cfg.SourceBucket, cfg.SourcePrefix, cfg.Enabled, cfg.UpdatedAt)
+		return err
+	})
+}
+
+// DeleteOriginConfig 删除桶回源配置
+func (m *MetadataStore) DeleteOriginConfig(bucket string) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("DELETE FROM bucket_origin WHERE bucket = ?", bucket)
+		return err
+	})
+}
+
+// originSource 已加载的回源配置及其客户端
+type originSource struct {
+	cfg    *OriginConfig
+	client *s3.Client
+}
+
+// originCall 进行中的回源拉取，同一对象的并发请求共享结果
+type originCall struct {
+	done chan struct{}
+	obj  *Object
+	err  error
+}
+
+// OriginFetcher 回源拉取器，按桶缓存配置和客户端
+type OriginFetcher struct {
+	mu        sync.Mutex
+	metadata  *MetadataStore
+	fileStore *FileStore
+	sources   map[string]*originSource
+	loaded    map[string]bool // 已从数据库加载过配置的桶（含无配置）
+	inflight  map[string]*originCall
+}
+
+// NewOriginFetcher 创建回源拉取器
+func NewOriginFetcher(metadata *MetadataStore, fileStore *FileStore) *OriginFetcher {
+	return &OriginFetcher{
+		metadata:  metadata,
+		fileStore: fileStore,
+		sources:   make(map[string]*originSource),
+		loaded:    make(map[string]bool),
+		inflight:  make(map[string]*originCall),
+	}
+}
+
+// SetConfig 保存回源配置并立即生效
+func (o *OriginFetcher) SetConfig(cfg *OriginConfig) error {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if err := o.metadata.SaveOriginConfig(cfg); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	saved := *cfg
+	o.sources[cfg.Bucket] = &originSource{cfg: &saved}
+	o.loaded[cfg.Bucket] = true
+	return nil
+}
+
+// DeleteConfig 删除回源配置
+func (o *OriginFetcher) DeleteConfig(bucket string) error {
+	if err := o.metadata.DeleteOriginConfig(bucket); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.sources, bucket)
+	o.loaded[bucket] = true
+	return nil
+}
+
+// ForgetBucket 桶被删除时调用，清除缓存的配置
+func (o *OriginFetcher) ForgetBucket(bucket string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.sources, bucket)
+	delete(o.loaded, bucket)
+}
+
+// sourceLocked 获取桶的回源配置，首次访问时从数据库加载（需持有锁）
+func (o *OriginFetcher) sourceLocked(bucket string) *originSource {
+	if src, ok := o.sources[bucket]; ok {
+		return src
+	}
+	if o.loaded[bucket] {
+		return nil
+	}
+	cfg, err := o.metadata.GetOriginConfig(bucket)
+	if err != nil {
+		// 加载失败不缓存，下次重试
+		return nil
+	}
+	o.loaded[bucket] = true
+	if cfg == nil {
+		return nil
+	}
+	src := &originSource{cfg: cfg}
+	o.sources[bucket] = src
+	return src
+}
+
+// Fetch 从源端拉取本地不存在的对象，保存到本地后返回其元数据
+// 桶未配置或未启用回源、源端也不存在该对象时返回 nil；maxSize > 0 时超出上限返回 ErrObjectTooLarge
+func (o *OriginFetcher) Fetch(bucket, key string, maxSize int64) (*Object, error) {
+	o.mu.Lock()
+	src := o.sourceLocked(bucket)
+	if src == nil || !src.cfg.Enabled {
+		o.mu.Unlock()
+		return nil, nil
+	}
+	callKey := bucket + "/" + key
+	if call, ok := o.inflight[callKey]; ok {
+		o.mu.Unlock()
+		<-call.done
+		return call.obj, call.err
+	}
+	call := &originCall{done: make(chan struct{})}
+	o.inflight[callKey] = call
+	o.mu.Unlock()
+
+	call.obj, call.err = o.fetch(src, key, maxSize)
+
+	o.mu.Lock()
+	delete(o.inflight, callKey)
+	o.mu.Unlock()
+	close(call.done)
+	return call.obj, call.err
+}
+
+// fetch 下载源对象并写入本地
+func (o *OriginFetcher) fetch(src *originSource, key string, maxSize int64) (*Object, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), originFetchTimeout)
+	defer cancel()
+
+	cfg := src.cfg
+	o.mu.Lock()
+	client := src.client
+	o.mu.Unlock()
+	if client == nil {
+		c, err := newS3Client(ctx, cfg.Endpoint, cfg.AccessKey, cfg.SecretKey, cfg.Region)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrOriginFetch, err)
+		}
+		o.mu.Lock()
+		src.client = c
+		o.mu.Unlock()
+		client = c
+	}
+
+	resp, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(cfg.SourceBucket),
+		Key:    aws.String(cfg.SourcePrefix + key),
+	})
+	if err != nil {
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrOriginFetch, err)
+	}
+	defer resp.Body.Close()
+
+	if maxSize > 0 && aws.ToInt64(resp.ContentLength) > maxSize {
+		return nil, ErrObjectTooLarge
+	}
+	staged, err := o.fileStore.PutObjectStaged(cfg.Bucket, key, "", &sourceReader{resp.Body}, maxSize)
+	if err == nil {
+		err = staged.Commit()
+	}
+	if err != nil {
+		var srcErr *sourceReadError
+		if errors.As(err, &srcErr) {
+			return nil, fmt.Errorf("%w: %v", ErrOriginFetch, err)
+		}
+		return nil, err
+	}
+
+	contentType := aws.ToString(resp.ContentType)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	headers := map[string]string{}
+	for name, v := range map[string]*string{
+		"Cache-Control":       resp.CacheControl,
+		"Content-Disposition": resp.ContentDisposition,
+		"Content-Encoding":    resp.ContentEncoding,
+		"Content-Language":    resp.ContentLanguage,
+		"Expires":             resp.ExpiresString,
+	} {
+		if s := aws.ToString(v); s != "" {
+			headers[name] = s
+		}
+	}
+	lastModified := time.Now().UTC()
+	if resp.LastModified != nil {
+		lastModified = resp.LastModified.UTC()
+	}
+	obj := &Object{
+		Bucket:       cfg.Bucket,
+		Key:          key,
+		Size:         staged.Size,
+		ETag:         staged.ETag,
+		ContentType:  contentType,
+		LastModified: lastModified,
+		StoragePath:  staged.Path,
+		Headers:      headers,
+
+		EncryptionNonce: staged.Nonce,
+	}
+	if err := o.metadata.PutObject(obj); err != nil {
+		o.fileStore.DeleteObject(staged.Path)
+		return nil, err
+	}
+	return obj, nil
+}
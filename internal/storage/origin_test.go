@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeOrigin 模拟的回源端，按路径返回对象内容并统计请求次数
+type fakeOrigin struct {
+	mu      sync.Mutex
+	objects map[string]string
+	gets    atomic.Int32
+	fail    atomic.Bool   // 返回 500
+	gate    chan struct{} // 非空时请求阻塞到关闭
+	server  *httptest.Server
+}
+
+func (f *fakeOrigin) setGate(gate chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gate = gate
+}
+
+func newFakeOrigin(t *testing.T, objects map[string]string) *fakeOrigin {
+	t.Helper()
+	f := &fakeOrigin{objects: objects}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.gets.Add(1)
+		f.mu.Lock()
+		gate := f.gate
+		f.mu.Unlock()
+		if gate != nil {
+			<-gate
+		}
+		if f.fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"origin-etag"`)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// TestOriginFetcher 测试回源拉取
+func TestOriginFetcher(t *testing.T) {
+	store, fs := setupReplicationStore(t)
+	origin := newFakeOrigin(t, map[string]string{"/old/site/a.txt": "from origin"})
+
+	o := NewOriginFetcher(store, fs)
+	if obj, err := o.Fetch("src", "a.txt", 0); obj != nil || err != nil {
+		t.Fatalf("未配置回源时应返回 nil: %v, %v", obj, err)
+	}
+
+	if err := o.SetConfig(&OriginConfig{
+		Bucket: "src", Endpoint: origin.server.URL, AccessKey: "ak", SecretKey: "sk",
+		SourceBucket: "old", SourcePrefix: "site/", Enabled: true,
+	}); err != nil {
+		t.Fatalf("设置配置失败: %v", err)
+	}
+
+	t.Run("拉取并保存到本地", func(t *testing.T) {
+		obj, err := o.Fetch("src", "a.txt", 0)
+		if err != nil || obj == nil {
+			t.Fatalf("拉取失败: %v, %v", obj, err)
+		}
+		local, _ := store.GetObject("src", "a.txt")
+		if local == nil || local.Size != int64(len("from origin")) || local.ContentType != "text/plain" {
+			t.Fatalf("本地元数据错误: %+v", local)
+		}
+		if local.Headers["Cache-Control"] != "max-age=60" {
+			t.Errorf("应保留源对象的响应头: %v", local.Headers)
+		}
+		file, err := fs.GetObject(local.StoragePath)
+		if err != nil {
+			t.Fatalf("读取本地文件失败: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		file.Close()
+		if string(data) != "from origin" {
+			t.Errorf("本地内容错误: %q", data)
+		}
+	})
+
+	t.Run("源端不存在", func(t *testing.T) {
+		if obj, err := o.Fetch("src", "missing.txt", 0); obj != nil || err != nil {
+			t.Errorf("源端 404 应返回 nil: %v, %v", obj, err)
+		}
+	})
+
+	t.Run("超出大小上限", func(t *testing.T) {
+		if _, err := o.Fetch("src", "a.txt", 4); !errors.Is(err, ErrObjectTooLarge) {
+			t.Errorf("应返回 ErrObjectTooLarge: %v", err)
+		}
+	})
+
+	t.Run("源端错误", func(t *testing.T) {
+		origin.fail.Store(true)
+		defer origin.fail.Store(false)
+		if _, err := o.Fetch("src", "a.txt", 0); !errors.Is(err, ErrOriginFetch) {
+			t.Errorf("应返回 ErrOriginFetch: %v", err)
+		}
+	})
+
+	t.Run("并发请求只拉取一次", func(t *testing.T) {
+		gate := make(chan struct{})
+		origin.setGate(gate)
+		defer origin.setGate(nil)
+		before := origin.gets.Load()
+
+		var wg sync.WaitGroup
+		results := make([]*Object, 5)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = o.Fetch("src", "a.txt", 0)
+			}(i)
+		}
+		// 等待第一个请求到达源端
+		for origin.gets.Load() == before {
+			time.Sleep(time.Millisecond)
+		}
+		close(gate)
+		wg.Wait()
+		if got := origin.gets.Load() - before; got != 1 {
+			t.Errorf("源端请求次数应为 1: %d", got)
+		}
+		for i, obj := range results {
+			if obj == nil {
+				t.Errorf("第 %d 个请求未得到对象", i)
+			}
+		}
+	})
+
+	t.Run("禁用后不回源", func(t *testing.T) {
+		cfg, _ := store.GetOriginConfig("src")
+		cfg.Enabled = false
+		if err := o.SetConfig(cfg); err != nil {
+			t.Fatalf("更新配置失败: %v", err)
+		}
+		before := origin.gets.Load()
+		if obj, err := o.Fetch("src", "a.txt", 0); obj != nil || err != nil || origin.gets.Load() != before {
+			t.Errorf("禁用后不应回源: %v, %v", obj, err)
+		}
+	})
+
+	// 删除桶时一并删除回源配置
+	store.DeleteObject("src", "a.txt")
+	if err := store.DeleteBucket("src"); err != nil {
+		t.Fatalf("删除桶失败: %v", err)
+	}
+	if cfg, _ := store.GetOriginConfig("src"); cfg != nil {
+		t.Error("删除桶后回源配置应被清除")
+	}
+}
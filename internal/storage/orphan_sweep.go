@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 孤立文件清理默认参数
+const (
+	DefaultOrphanSweepLimit  = 10000     // 单次最多检查的文件数
+	DefaultOrphanSweepBatch  = 500       // 每批与元数据交叉核对的文件数
+	DefaultOrphanSweepMinAge = time.Hour // 只处理早于该时长的文件，避免误删尚未写入元数据的上传
+	MaxOrphanSweepLimit      = 100000
+	MaxOrphanSweepBatch      = 5000
+)
+
+// maxOrphanSweepReport 结果中最多列出的孤立文件数
+const maxOrphanSweepReport = 1000
+
+// orphanSweepCursorKey 续扫游标在系统配置表中的键
+const orphanSweepCursorKey = "storage.orphan_sweep_cursor"
+
+// errOrphanSweepLimit 达到单次检查上限，停止遍历
+var errOrphanSweepLimit = errors.New("orphan sweep limit reached")
+
+// OrphanSweepOptions 孤立文件清理参数
+type OrphanSweepOptions struct {
+	Cursor    string        // 续扫游标（上次检查到的相对路径），空表示从头开始
+	Limit     int           // 本次最多检查的文件数
+	BatchSize int           // 每批交叉核对的文件数
+	RateLimit float64       // 每秒最多删除的文件数，0 表示不限
+	MinAge    time.Duration // 只处理修改时间早于该时长的文件
+	DryRun    bool          // 仅预览，不删除
+}
+
+// normalize 补齐默认值并限制在合法范围内
+func (o *OrphanSweepOptions) normalize() {
+	if o.Limit <= 0 {
+		o.Limit = DefaultOrphanSweepLimit
+	}
+	if o.Limit > MaxOrphanSweepLimit {
+		o.Limit = MaxOrphanSweepLimit
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultOrphanSweepBatch
+	}
+	if o.BatchSize > MaxOrphanSweepBatch {
+		o.BatchSize = MaxOrphanSweepBatch
+	}
+	if o.RateLimit < 0 {
+		o.RateLimit = 0
+	}
+	if o.MinAge <= 0 {
+		o.MinAge = DefaultOrphanSweepMinAge
+	}
+}
+
+// OrphanSweepResult 孤立文件清理结果
+type OrphanSweepResult struct {
+	DryRun      bool         `json:"dry_run"`      // 是否仅预览
+	Cursor      string       `json:"cursor"`       // 本次起始游标
+	NextCursor  string       `json:"next_cursor"`  // 下次续扫游标，遍历完成时为空
+	Done        bool         `json:"done"`         // 是否已遍历完整个数据目录
+	Scanned     int          `json:"scanned"`      // 本次检查的文件数
+	OrphanCount int          `json:"orphan_count"` // 孤立文件数量
+	OrphanSize  int64        `json:"orphan_size"`  // 孤立文件总大小
+	Deleted     int          `json:"deleted"`      // 实际删除的文件数
+	Orphans     []OrphanFile `json:"orphans"`      // 孤立文件列表（最多 1000 条）
+}
+
+// orphanCandidate 待核对的磁盘文件
+type orphanCandidate struct {
+	path string
+	rel  string
+	info fs.FileInfo
+}
+
+// SweepOrphanFiles 按路径顺序流式遍历数据目录，分批核对元数据，删除没有对应记录的文件
+// 只处理桶目录和 .variants 下的文件，跳过 .multipart 等其他隐藏目录及数据目录顶层文件。
+// 每次最多检查 Limit 个文件，未遍历完时返回 NextCursor，下次传入即可从断点继续。
+func SweepOrphanFiles(filestore *FileStore, metadata *MetadataStore, opts OrphanSweepOptions) (*OrphanSweepResult, error) {
+	opts.normalize()
+	result := &OrphanSweepResult{
+		DryRun:  opts.DryRun,
+		Cursor:  opts.Cursor,
+		Orphans: make([]OrphanFile, 0),
+	}
+	var cursor []string
+	if opts.Cursor != "" {
+		cursor = strings.Split(opts.Cursor, "/")
+	}
+	cutoff := time.Now().Add(-opts.MinAge)
+
+	// 限速：按固定间隔删除
+	var tick <-chan time.Time
+	if opts.RateLimit > 0 && !opts.DryRun {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.RateLimit))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	batch := make([]orphanCandidate, 0, opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		paths := make([]string, len(batch))
+		for i, c := range batch {
+			paths[i] = c.path
+		}
+		known, err := metadata.knownStoragePaths(paths)
+		if err != nil {
+			return err
+		}
+		for _, c := range batch {
+			if known[c.path] {
+				continue
+			}
+			result.OrphanCount++
+			result.OrphanSize += c.info.Size()
+			if len(result.Orphans) < maxOrphanSweepReport {
+				result.Orphans = append(result.Orphans, OrphanFile{Path: c.rel, Size: c.info.Size(), ModifiedAt: c.info.ModTime()})
+			}
+			if opts.DryRun {
+				continue
+			}
+			if tick != nil && result.Deleted > 0 {
+				<-tick
+			}
+			if filestore.removeOrphan(c, cutoff) {
+				result.Deleted++
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	lastRel := ""
+	err := filepath.WalkDir(filestore.basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == filestore.basePath {
+			return nil // 忽略无法读取的条目继续
+		}
+		rel, err := filepath.Rel(filestore.basePath, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		parts := strings.Split(rel, "/")
+
+		if d.IsDir() {
+			// .multipart 由过期上传清理处理，其他隐藏目录不属于对象存储
+			if len(parts) == 1 && strings.HasPrefix(d.Name(), ".") && d.Name() != ".variants" {
+				return filepath.SkipDir
+			}
+			// 整个目录都在游标之前，已在之前的批次中处理
+			if comparePathOrder(parts, cursor) < 0 && !hasPathPrefix(cursor, parts) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(parts) == 1 || !d.Type().IsRegular() || comparePathOrder(parts, cursor) <= 0 {
+			return nil
+		}
+		if result.Scanned >= opts.Limit {
+			return errOrphanSweepLimit
+		}
+		result.Scanned++
+		lastRel = rel
+
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			return nil
+		}
+		batch = append(batch, orphanCandidate{path: path, rel: rel, info: info})
+		if len(batch) >= opts.BatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil && err != errOrphanSweepLimit {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if err == errOrphanSweepLimit {
+		result.NextCursor = lastRel
+	} else {
+		result.Done = true
+	}
+	return result, nil
+}
+
+// removeOrphan 删除孤立文件；删除前重新检查，文件在核对后被重新写入时跳过
+func (f *FileStore) removeOrphan(c orphanCandidate, cutoff time.Time) bool {
+	info, err := os.Stat(c.path)
+	if err != nil || info.ModTime().After(cutoff) || !info.ModTime().Equal(c.info.ModTime()) || info.Size() != c.info.Size() {
+		return false
+	}
+	if err := os.Remove(c.path); err != nil {
+		return false
+	}
+	f.cleanEmptyDirs(filepath.Dir(c.path))
+	return true
+}
+
+// comparePathOrder 按 filepath.WalkDir 的遍历顺序比较两个相对路径（逐级按名称比较，父目录在前）
+func comparePathOrder(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// hasPathPrefix path 是否位于目录 dir 之下
+func hasPathPrefix(path, dir []string) bool {
+	if len(path) <= len(dir) {
+		return false
+	}
+	for i := range dir {
+		if path[i] != dir[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// knownStoragePaths 返回在对象、对象变体或对象历史版本元数据中有记录的存储路径
+func (m *MetadataStore) knownStoragePaths(paths []string) (map[string]bool, error) {
+	known := make(map[string]bool, len(paths))
+	if len(paths) == 0 {
+		return known, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(paths)), ",")
+	args := make([]interface{}, 0, len(paths)*3)
+	for i := 0; i < 3; i++ {
+		for _, p := range paths {
+			args = append(args, p)
+		}
+	}
+	rows, err := m.db.Query(
+		"SELECT storage_path FROM objects WHERE storage_path IN ("+placeholders+") "+
+			"UNION SELECT storage_path FROM object_variants WHERE storage_path IN ("+placeholders+") "+
+			"UNION SELECT storage_path FROM object_versions WHERE storage_path IN ("+placeholders+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		known[path] = true
+	}
+	return known, rows.Err()
+}
+
+// OrphanSweepCursor 获取已保存的续扫游标
+func (m *MetadataStore) OrphanSweepCursor() (string, error) {
+	return m.GetSetting(orphanSweepCursorKey)
+}
+
+// SaveOrphanSweepCursor 保存续扫游标，空表示下次从头开始
+func (m *MetadataStore) SaveOrphanSweepCursor(cursor string) error {
+	return m.SetSetting(orphanSweepCursorKey, cursor)
+}
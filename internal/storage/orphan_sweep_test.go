@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSweepOrphanFiles 测试孤立文件分批清理
+func TestSweepOrphanFiles(t *testing.T) {
+	fs, ms, cleanup := setupGCTest(t)
+	defer cleanup()
+
+	old := time.Now().Add(-2 * time.Hour)
+	writeOld := func(path string) {
+		t.Helper()
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("orphan"), 0644); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+		os.Chtimes(path, old, old)
+	}
+
+	bucket := "sweep-bucket"
+	ms.CreateBucket(bucket)
+	var known []string
+	for _, key := range []string{"a.txt", "b.txt"} {
+		path, etag, _ := fs.PutObject(bucket, key, strings.NewReader(key), int64(len(key)))
+		ms.PutObject(&Object{Bucket: bucket, Key: key, Size: int64(len(key)), ETag: etag,
+			ContentType: "text/plain", LastModified: time.Now(), StoragePath: path})
+		os.Chtimes(path, old, old)
+		known = append(known, path)
+	}
+	vpath, vetag, vsize, err := fs.PutVariant(bucket, "a.txt", "etag", "w=10,h=0", strings.NewReader("variant"))
+	if err != nil {
+		t.Fatalf("写入变体失败: %v", err)
+	}
+	ms.SaveObjectVariant(&ObjectVariant{Bucket: bucket, Key: "a.txt", SourceETag: "etag", Params: "w=10,h=0",
+		StoragePath: vpath, Size: vsize, ETag: vetag, ContentType: "image/png", CreatedAt: time.Now()})
+	os.Chtimes(vpath, old, old)
+	known = append(known, vpath)
+
+	orphans := []string{
+		filepath.Join(fs.basePath, bucket, "x1", "orphan1"),
+		filepath.Join(fs.basePath, bucket, "x2", "orphan2"),
+		filepath.Join(fs.basePath, ".variants", bucket, "stale"),
+	}
+	for _, p := range orphans {
+		writeOld(p)
+	}
+	// 新写入的文件和 .multipart 下的文件不处理
+	fresh := filepath.Join(fs.basePath, bucket, "x3", "fresh")
+	os.MkdirAll(filepath.Dir(fresh), 0755)
+	os.WriteFile(fresh, []byte("fresh"), 0644)
+	multipart := filepath.Join(fs.basePath, ".multipart", "upload-1", "1")
+	writeOld(multipart)
+
+	t.Run("预览不删除", func(t *testing.T) {
+		result, err := SweepOrphanFiles(fs, ms, OrphanSweepOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("预览失败: %v", err)
+		}
+		if !result.Done || result.NextCursor != "" {
+			t.Errorf("应遍历完成: %+v", result)
+		}
+		if result.OrphanCount != len(orphans) || result.Deleted != 0 {
+			t.Errorf("孤立文件数量错误: %+v", result)
+		}
+		if result.Scanned != len(known)+len(orphans)+1 {
+			t.Errorf("检查文件数错误: got %d", result.Scanned)
+		}
+		for _, p := range orphans {
+			if _, err := os.Stat(p); err != nil {
+				t.Errorf("预览不应删除文件: %s", p)
+			}
+		}
+	})
+
+	t.Run("分页续扫", func(t *testing.T) {
+		cursor := ""
+		deleted, pages := 0, 0
+		for {
+			result, err := SweepOrphanFiles(fs, ms, OrphanSweepOptions{Cursor: cursor, Limit: 2, BatchSize: 1, RateLimit: 1000})
+			if err != nil {
+				t.Fatalf("清理失败: %v", err)
+			}
+			deleted += result.Deleted
+			pages++
+			if result.Done {
+				break
+			}
+			if result.NextCursor == "" || result.NextCursor == cursor {
+				t.Fatalf("游标未推进: %q -> %q", cursor, result.NextCursor)
+			}
+			cursor = result.NextCursor
+			if pages > 10 {
+				t.Fatal("分页未结束")
+			}
+		}
+		if pages < 3 {
+			t.Errorf("应分多页完成: %d", pages)
+		}
+		if deleted != len(orphans) {
+			t.Errorf("删除数量错误: got %d, want %d", deleted, len(orphans))
+		}
+		for _, p := range orphans {
+			if _, err := os.Stat(p); !os.IsNotExist(err) {
+				t.Errorf("孤立文件应已删除: %s", p)
+			}
+		}
+		if _, err := os.Stat(filepath.Dir(orphans[0])); !os.IsNotExist(err) {
+			t.Error("空目录应已清理")
+		}
+		for _, p := range append(known, fresh, multipart) {
+			if _, err := os.Stat(p); err != nil {
+				t.Errorf("不应删除: %s", p)
+			}
+		}
+	})
+
+	t.Run("游标保存", func(t *testing.T) {
+		if err := ms.SaveOrphanSweepCursor("sweep-bucket/x2"); err != nil {
+			t.Fatalf("保存游标失败: %v", err)
+		}
+		if got, _ := ms.OrphanSweepCursor(); got != "sweep-bucket/x2" {
+			t.Errorf("游标错误: %q", got)
+		}
+	})
+}
+
+// TestComparePathOrder 测试路径遍历顺序比较
+func TestComparePathOrder(t *testing.T) {
+	split := func(s string) []string { return strings.Split(s, "/") }
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"a/b", "a/b", 0},
+		{"a", "a/b", -1},
+		{"a/c", "a/b/z", 1},
+		{"a-b/x", "a/x", 1}, // 逐级比较，与整串字典序不同
+	}
+	for _, tt := range tests {
+		if got := comparePathOrder(split(tt.a), split(tt.b)); got != tt.want {
+			t.Errorf("comparePathOrder(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxPrefixRewrites 每个桶的前缀改写规则数上限
+const MaxPrefixRewrites = 20
+
+// PrefixRewrite 对象键前缀改写规则：S3 API 请求中以 From 开头的键替换为 To 开头后再存取
+// 用于迁移期间保持旧链接可用，如 v1/ → legacy/
+type PrefixRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"` // 可为空，表示去掉前缀
+}
+
+// ValidatePrefixRewrites 校验改写规则：From 不能为空或重复，To 不能再命中任何 From（含自身）
+func ValidatePrefixRewrites(rules []PrefixRewrite) error {
+	if len(rules) > MaxPrefixRewrites {
+		return fmt.Errorf("at most %d rules are allowed", MaxPrefixRewrites)
+	}
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.From == "" {
+			return fmt.Errorf("from must not be empty")
+		}
+		if strings.HasPrefix(rule.From, "/") || strings.HasPrefix(rule.To, "/") {
+			return fmt.Errorf("prefixes must not start with /")
+		}
+		if seen[rule.From] {
+			return fmt.Errorf("duplicate from prefix: %s", rule.From)
+		}
+		seen[rule.From] = true
+	}
+	// 改写只做一次，目标前缀若再命中某条规则，改写后的键就无法通过原路径直接访问
+	for _, rule := range rules {
+		for _, other := range rules {
+			if strings.HasPrefix(rule.To, other.From) {
+				return fmt.Errorf("to prefix %s would be rewritten again by rule for %s", rule.To, other.From)
+			}
+		}
+	}
+	return nil
+}
+
+// encodePrefixRewrites 序列化改写规则，无规则时为空串
+func encodePrefixRewrites(rules []PrefixRewrite) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(rules)
+	return string(data)
+}
+
+// decodePrefixRewrites 反序列化改写规则，格式错误时视为空
+func decodePrefixRewrites(s string) []PrefixRewrite {
+	if s == "" {
+		return nil
+	}
+	var rules []PrefixRewrite
+	if err := json.Unmarshal([]byte(s), &rules); err != nil {
+		return nil
+	}
+	return rules
+}
+
+// RewriteKey 按最长匹配的 From 前缀改写对象键，未命中返回原键
+func (b *Bucket) RewriteKey(key string) string {
+	if b == nil {
+		return key
+	}
+	var match *PrefixRewrite
+	for i := range b.PrefixRewrites {
+		rule := &b.PrefixRewrites[i]
+		if strings.HasPrefix(key, rule.From) && (match == nil || len(rule.From) > len(match.From)) {
+			match = rule
+		}
+	}
+	if match == nil {
+		return key
+	}
+	return match.To + key[len(match.From):]
+}
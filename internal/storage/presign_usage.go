@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PresignUsage Access Key 在当前时间窗口内的预签名用量
+type PresignUsage struct {
+	Count       int       `json:"count"`        // 当前窗口内已生成的预签名 URL 数
+	Limit       int       `json:"limit"`        // 每个窗口的上限，0 表示不限制
+	WindowStart time.Time `json:"window_start"` // 当前窗口起始时间
+	ResetAt     time.Time `json:"reset_at"`     // 计数重置时间
+}
+
+// initPresignUsageTable 初始化预签名用量表，每个 Access Key 只保留当前窗口的计数
+func (m *MetadataStore) initPresignUsageTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS presign_usage (
+		access_key_id TEXT PRIMARY KEY,
+		window_start INTEGER NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0
+	)`)
+	return err
+}
+
+// presignWindowStart 计数窗口按 Unix 时间对齐的固定窗口划分
+func presignWindowStart(now time.Time, window time.Duration) time.Time {
+	return now.Truncate(window).UTC()
+}
+
+// ConsumePresignQuota 为 Access Key 占用 n 次预签名额度
+// 超出上限时不计数并返回 false；返回的用量为占用后（或被拒绝时）的当前窗口用量
+func (m *MetadataStore) ConsumePresignQuota(accessKeyID string, n, limit int, window time.Duration, now time.Time) (*PresignUsage, bool, error) {
+	start := presignWindowStart(now, window)
+	usage := &PresignUsage{Limit: limit, WindowStart: start, ResetAt: start.Add(window)}
+	allowed := false
+	err := m.withWriteLock(func() error {
+		tx, err := m.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var windowStart int64
+		err = tx.QueryRow("SELECT window_start, count FROM presign_usage WHERE access_key_id = ?", accessKeyID).
+			Scan(&windowStart, &usage.Count)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == sql.ErrNoRows || windowStart != start.Unix() {
+			usage.Count = 0
+		}
+		if usage.Count+n > limit {
+			return nil
+		}
+		usage.Count += n
+		allowed = true
+		if _, err := tx.Exec(`INSERT INTO presign_usage (access_key_id, window_start, count) VALUES (?, ?, ?)
+			ON CONFLICT(access_key_id) DO UPDATE SET window_start = excluded.window_start, count = excluded.count`,
+			accessKeyID, start.Unix(), usage.Count); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return usage, allowed, nil
+}
+
+// GetPresignUsage 返回 Access Key 在当前窗口内的预签名用量
+func (m *MetadataStore) GetPresignUsage(accessKeyID string, limit int, window time.Duration, now time.Time) (*PresignUsage, error) {
+	start := presignWindowStart(now, window)
+	usage := &PresignUsage{Limit: limit, WindowStart: start, ResetAt: start.Add(window)}
+	var windowStart int64
+	var count int
+	err := m.db.QueryRow("SELECT window_start, count FROM presign_usage WHERE access_key_id = ?", accessKeyID).
+		Scan(&windowStart, &count)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil && windowStart == start.Unix() {
+		usage.Count = count
+	}
+	return usage, nil
+}
@@ -0,0 +1,30 @@
+package storage
+
+import "time"
+
+// MaxReadAgeDaysLimit 最大可读天数上限（10 年）
+const MaxReadAgeDaysLimit = 3650
+
+// 最大可读天数的计算基准
+const (
+	ReadAgeBasisModified = "modified" // 按最后修改时间，覆盖后重新计算
+	ReadAgeBasisCreated  = "created"  // 按首次写入时间，覆盖不影响
+)
+
+// ValidReadAgeBasis 是否为有效的计算基准，空表示默认（modified）
+func ValidReadAgeBasis(basis string) bool {
+	return basis == "" || basis == ReadAgeBasisModified || basis == ReadAgeBasisCreated
+}
+
+// ReadAgeExceeded 对象写入时间是否已超出桶的最大可读天数
+// 仅限制读取，对象仍保留在存储中，需由外部归档或删除
+func (b *Bucket) ReadAgeExceeded(obj *Object, now time.Time) bool {
+	if b == nil || obj == nil || b.MaxReadAgeDays <= 0 {
+		return false
+	}
+	since := obj.LastModified
+	if b.ReadAgeBasis == ReadAgeBasisCreated && !obj.CreatedAt.IsZero() {
+		since = obj.CreatedAt
+	}
+	return now.Sub(since) > time.Duration(b.MaxReadAgeDays)*24*time.Hour
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RelayoutResult 路径布局迁移结果
+type RelayoutResult struct {
+	Layout  string   `json:"layout"`  // 目标布局
+	DryRun  bool     `json:"dry_run"` // 是否仅预览
+	Total   int      `json:"total"`   // 对象总数
+	Moved   int      `json:"moved"`   // 已迁移（预览模式下为待迁移）数量
+	Skipped int      `json:"skipped"` // 已在目标路径、无需迁移的数量
+	Failed  int      `json:"failed"`  // 失败数量
+	Errors  []string `json:"errors"`  // 失败详情
+}
+
+// maxRelayoutErrors 结果中最多保留的失败详情数
+const maxRelayoutErrors = 100
+
+// RelayoutObjects 把已有对象文件迁移到 FileStore 当前的路径布局，并更新 StoragePath
+// 须在服务停止时运行；已迁移的对象会被跳过，中断后重新执行即可继续。
+// progress 非空时每处理一个对象回调一次。
+func RelayoutObjects(filestore *FileStore, metadata *MetadataStore, dryRun bool, progress func(done, total int)) (*RelayoutResult, error) {
+	buckets, err := metadata.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []Object
+	for _, bucket := range buckets {
+		bucketObjects, err := metadata.ListAllObjects(bucket.Name)
+		if err != nil {
+			return nil, fmt.Errorf("list objects of %s: %w", bucket.Name, err)
+		}
+		objects = append(objects, bucketObjects...)
+	}
+
+	result := &RelayoutResult{
+		Layout: filestore.PathLayout(),
+		DryRun: dryRun,
+		Total:  len(objects),
+		Errors: make([]string, 0),
+	}
+	for i := range objects {
+		moved, err := relayoutObject(filestore, metadata, &objects[i], dryRun)
+		switch {
+		case err != nil:
+			result.Failed++
+			if len(result.Errors) < maxRelayoutErrors {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s/%s: %v", objects[i].Bucket, objects[i].Key, err))
+			}
+		case moved:
+			result.Moved++
+		default:
+			result.Skipped++
+		}
+		if progress != nil {
+			progress(i+1, len(objects))
+		}
+	}
+	return result, nil
+}
+
+// relayoutObject 迁移单个对象，返回是否发生（或将发生）迁移
+func relayoutObject(filestore *FileStore, metadata *MetadataStore, obj *Object, dryRun bool) (bool, error) {
+	// 带版本ID的对象文件不随布局变化，target 与 source 相同时跳过
+	target, err := filestore.getObjectPath(obj.Bucket, obj.Key, obj.VersionID)
+	if err != nil {
+		return false, err
+	}
+	source, err := filepath.Abs(obj.StoragePath)
+	if err != nil {
+		return false, err
+	}
+	if source == target {
+		return false, nil
+	}
+	if !strings.HasPrefix(source, filestore.basePath) {
+		return false, ErrInvalidPath
+	}
+
+	// 上次迁移在更新元数据前中断：文件已在目标位置，只补写元数据
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		if _, err := os.Stat(target); err != nil {
+			return false, fmt.Errorf("file not found: %s", source)
+		}
+		if dryRun {
+			return true, nil
+		}
+		return true, metadata.UpdateObjectStoragePath(obj.Bucket, obj.Key, obj.StoragePath, target)
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return false, err
+	}
+	if err := os.Rename(source, target); err != nil {
+		return false, err
+	}
+	// 元数据更新失败时把文件移回原处，保持两者一致
+	if err := metadata.UpdateObjectStoragePath(obj.Bucket, obj.Key, obj.StoragePath, target); err != nil {
+		os.Rename(target, source)
+		return false, err
+	}
+	removeEmptyParents(filepath.Dir(source), filepath.Join(filestore.basePath, obj.Bucket))
+	return true, nil
+}
+
+// removeEmptyParents 自下而上删除空目录，到 stop（不含）为止
+func removeEmptyParents(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// UpdateObjectStoragePath 在存储路径仍为 oldPath 时更新为 newPath
+func (m *MetadataStore) UpdateObjectStoragePath(bucket, key, oldPath, newPath string) error {
+	res, err := m.db.Exec(`
+		UPDATE objects
+		SET storage_path = ?
+		WHERE bucket = ? AND key = ? AND storage_path = ?
+	`, newPath, bucket, key, oldPath)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("object %s/%s changed during relayout", bucket, key)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRelayoutObjects 测试把 prefix 布局的对象迁移到 hashed 布局
+func TestRelayoutObjects(t *testing.T) {
+	ms, cleanupMS := setupMetadataStore(t)
+	defer cleanupMS()
+	fs, cleanupFS := setupFileStore(t)
+	defer cleanupFS()
+
+	ms.CreateBucket("relayout")
+	keys := []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"}
+	for _, key := range keys {
+		path, etag, err := fs.PutObject("relayout", key, strings.NewReader("data-"+key), int64(len("data-"+key)))
+		if err != nil {
+			t.Fatalf("写入对象失败: %v", err)
+		}
+		ms.PutObject(&Object{Bucket: "relayout", Key: key, Size: int64(len("data-" + key)), ETag: etag, StoragePath: path})
+	}
+
+	if err := fs.SetPathLayout(PathLayoutHashed); err != nil {
+		t.Fatalf("设置布局失败: %v", err)
+	}
+
+	t.Run("预览不移动文件", func(t *testing.T) {
+		result, err := RelayoutObjects(fs, ms, true, nil)
+		if err != nil {
+			t.Fatalf("预览失败: %v", err)
+		}
+		if result.Total != 3 || result.Moved != 3 {
+			t.Errorf("预览结果错误: %+v", result)
+		}
+		obj, _ := ms.GetObject("relayout", "a.txt")
+		if obj.StoragePath == fs.GetStoragePath("relayout", "a.txt") {
+			t.Error("预览不应修改元数据")
+		}
+	})
+
+	// 模拟上次迁移在更新元数据前中断：文件已移动但元数据未更新
+	interrupted, _ := ms.GetObject("relayout", "dir/b.txt")
+	target := fs.GetStoragePath("relayout", "dir/b.txt")
+	os.MkdirAll(filepath.Dir(target), 0755)
+	if err := os.Rename(interrupted.StoragePath, target); err != nil {
+		t.Fatalf("移动文件失败: %v", err)
+	}
+
+	var lastDone int
+	result, err := RelayoutObjects(fs, ms, false, func(done, total int) { lastDone = done })
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if result.Moved != 3 || result.Failed != 0 || lastDone != 3 {
+		t.Errorf("迁移结果错误: %+v, progress=%d", result, lastDone)
+	}
+	for _, key := range keys {
+		obj, _ := ms.GetObject("relayout", key)
+		if obj.StoragePath != fs.GetStoragePath("relayout", key) {
+			t.Errorf("%s 元数据未更新: %s", key, obj.StoragePath)
+		}
+		data, err := os.ReadFile(obj.StoragePath)
+		if err != nil || string(data) != "data-"+key {
+			t.Errorf("%s 文件内容错误: %q %v", key, data, err)
+		}
+	}
+
+	// 再次执行应全部跳过
+	result, err = RelayoutObjects(fs, ms, false, nil)
+	if err != nil || result.Skipped != 3 || result.Moved != 0 {
+		t.Errorf("重复执行应全部跳过: %+v %v", result, err)
+	}
+}
@@ -0,0 +1,441 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// 复制操作类型
+const (
+	ReplicationOpPut    = "put"
+	ReplicationOpDelete = "delete"
+)
+
+// 复制队列参数
+const (
+	replicationQueueSize  = 1000
+	replicationMaxRetries = 3
+)
+
+// ReplicationConfig 桶复制配置
+type ReplicationConfig struct {
+	Bucket       string    `json:"bucket"`
+	Endpoint     string    `json:"endpoint"`
+	AccessKey    string    `json:"accessKey"`
+	SecretKey    string    `json:"secretKey,omitempty"`
+	Region       string    `json:"region"`
+	TargetBucket string    `json:"targetBucket"`
+	Enabled      bool      `json:"enabled"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// ReplicationStatus 桶复制状态
+type ReplicationStatus struct {
+	Bucket       string     `json:"bucket"`
+	Endpoint     string     `json:"endpoint"`
+	TargetBucket string     `json:"targetBucket"`
+	Enabled      bool       `json:"enabled"`
+	Pending      int        `json:"pending"`    // 队列中等待复制的操作数
+	Replicated   int64      `json:"replicated"` // 成功复制的操作数
+	Failed       int64      `json:"failed"`     // 重试后仍失败的操作数
+	LagSeconds   float64    `json:"lagSeconds"` // 最早未完成操作已等待的时间
+	LastSuccess  *time.Time `json:"lastSuccess,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+	LastErrorAt  *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// initReplicationTable 初始化复制配置表
+func (m *MetadataStore) initReplicationTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS bucket_replication (
+		bucket TEXT PRIMARY KEY,
+		endpoint TEXT NOT NULL,
+		access_key TEXT NOT NULL,
+		secret_key TEXT NOT NULL,
+		region TEXT NOT NULL DEFAULT '',
+		target_bucket TEXT NOT NULL,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		updated_at DATETIME NOT NULL
+	)`)
+	return err
+}
+
+// GetReplicationConfig 获取桶复制配置，不存在返回 nil
+func (m *MetadataStore) GetReplicationConfig(bucket string) (*ReplicationConfig, error) {
+	var cfg ReplicationConfig
+	var secret string
+	err := m.db.QueryRow(`
+		SELECT bucket, endpoint, access_key, secret_key, region, target_bucket, enabled, updated_at
+		FROM bucket_replication WHERE bucket = ?
+	`, bucket).Scan(&cfg.Bucket, &cfg.Endpoint, &cfg.AccessKey, &secret, &cfg.Region, &cfg.TargetBucket, &cfg.Enabled, &cfg.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SecretKey, err = m.DecryptSecret(secret); err != nil {
+		return nil, fmt.Errorf("decrypt replication secret failed: %w", err)
+	}
+	return &cfg, nil
+}
+
+// ListReplicationConfigs 列出所有复制配置（不含 Secret）
+func (m *MetadataStore) ListReplicationConfigs() ([]ReplicationConfig, error) {
+	rows, err := m.db.Query(`
+		SELECT bucket, endpoint, access_key, region, target_bucket, enabled, updated_at
+		FROM bucket_replication ORDER BY bucket
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var configs []ReplicationConfig
+	for rows.Next() {
+		var cfg ReplicationConfig
+		if err := rows.Scan(&cfg.Bucket, &cfg.Endpoint, &cfg.AccessKey, &cfg.Region, &cfg.TargetBucket, &cfg.Enabled, &cfg.UpdatedAt); err != nil {
+			return nil, err
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, rows.Err()
+}
+
+// SaveReplicationConfig 保存桶复制配置（Secret 加密存储）
+func (m *MetadataStore) SaveReplicationConfig(cfg *ReplicationConfig) error {
+	secret, err := m.EncryptSecret(cfg.SecretKey)
+	if err != nil {
+		return fmt.Errorf("encrypt replication secret failed: %w", err)
+	}
+	cfg.UpdatedAt = time.Now().UTC()
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec(`
+			INSERT OR REPLACE INTO bucket_replication
+			(bucket, endpoint, access_key, secret_key, region, target_bucket, enabled, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, cfg.Bucket, cfg.Endpoint, cfg.AccessKey, secret, cfg.Region, cfg.TargetBucket, cfg.Enabled, cfg.UpdatedAt)
+		return err
+	})
+}
+
+// DeleteReplicationConfig 删除桶复制配置
+func (m *MetadataStore) DeleteReplicationConfig(bucket string) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec("DELETE FROM bucket_replication WHERE bucket = ?", bucket)
+		return err
+	})
+}
+
+// replicationTask 复制任务
+type replicationTask struct {
+	key        string
+	op         string
+	enqueuedAt time.Time
+}
+
+// bucketReplicator 单个桶的复制工作者，按入队顺序串行执行保证操作顺序
+type bucketReplicator struct {
+	cfg     *ReplicationConfig
+	client  *s3.Client
+	queue   chan replicationTask
+	pending []time.Time // 未完成任务的入队时间（FIFO）
+	stopped bool        // 配置已变更或删除，剩余任务直接丢弃
+
+	replicated  int64
+	failed      int64
+	lastSuccess *time.Time
+	lastError   string
+	lastErrorAt *time.Time
+}
+
+// Replicator 异步复制器，将写操作同步到配置的目标端点
+type Replicator struct {
+	mu        sync.Mutex
+	metadata  *MetadataStore
+	fileStore *FileStore
+	buckets   map[string]*bucketReplicator
+	loaded    map[string]bool // 已从数据库加载过配置的桶（含无配置）
+	retryWait time.Duration
+	workers   sync.WaitGroup
+	closed    bool // 已关闭，不再接受新任务
+}
+
+// NewReplicator 创建复制器
+func NewReplicator(metadata *MetadataStore, fileStore *FileStore) *Replicator {
+	return &Replicator{
+		metadata:  metadata,
+		fileStore: fileStore,
+		buckets:   make(map[string]*bucketReplicator),
+		loaded:    make(map[string]bool),
+		retryWait: time.Second,
+	}
+}
+
+// SetRetryWait 设置重试间隔（第 N 次重试等待 N 倍间隔）
+func (r *Replicator) SetRetryWait(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retryWait = d
+}
+
+// Enqueue 在写操作成功后调用，桶未配置复制时直接返回
+func (r *Replicator) Enqueue(bucket, key, op string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+
+	br := r.bucketLocked(bucket)
+	if br == nil || !br.cfg.Enabled {
+		return
+	}
+
+	task := replicationTask{key: key, op: op, enqueuedAt: time.Now()}
+	select {
+	case br.queue <- task:
+		br.pending = append(br.pending, task.enqueuedAt)
+	default:
+		br.failed++
+		now := time.Now()
+		br.lastError = "replication queue full, dropped " + op + " " + key
+		br.lastErrorAt = &now
+	}
+}
+
+// bucketLocked 获取桶的复制工作者，首次访问时从数据库加载配置（需持有锁）
+func (r *Replicator) bucketLocked(bucket string) *bucketReplicator {
+	if br, ok := r.buckets[bucket]; ok {
+		return br
+	}
+	if r.loaded[bucket] {
+		return nil
+	}
+	cfg, err := r.metadata.GetReplicationConfig(bucket)
+	if err != nil {
+		// 加载失败不缓存，下次重试
+		return nil
+	}
+	r.loaded[bucket] = true
+	if cfg == nil {
+		return nil
+	}
+	return r.startLocked(cfg)
+}
+
+// startLocked 为配置启动工作者（需持有锁）
+func (r *Replicator) startLocked(cfg *ReplicationConfig) *bucketReplicator {
+	br := &bucketReplicator{
+		cfg:   cfg,
+		queue: make(chan replicationTask, replicationQueueSize),
+	}
+	r.buckets[cfg.Bucket] = br
+	r.workers.Add(1)
+	go func() {
+		defer r.workers.Done()
+		r.run(br)
+	}()
+	return br
+}
+
+// SetConfig 保存复制配置并立即生效
+func (r *Replicator) SetConfig(cfg *ReplicationConfig) error {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if err := r.metadata.SaveReplicationConfig(cfg); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.stopLocked(cfg.Bucket)
+	saved := *cfg
+	r.loaded[cfg.Bucket] = true
+	r.startLocked(&saved)
+	return nil
+}
+
+// DeleteConfig 删除复制配置，未完成的任务将被丢弃
+func (r *Replicator) DeleteConfig(bucket string) error {
+	if err := r.metadata.DeleteReplicationConfig(bucket); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopLocked(bucket)
+	r.loaded[bucket] = true
+	return nil
+}
+
+// ForgetBucket 桶被删除时调用，停止工作者并清除缓存
+func (r *Replicator) ForgetBucket(bucket string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopLocked(bucket)
+	delete(r.loaded, bucket)
+}
+
+// stopLocked 停止桶的工作者（需持有锁）
+func (r *Replicator) stopLocked(bucket string) {
+	if br, ok := r.buckets[bucket]; ok {
+		br.stopped = true
+		close(br.queue)
+		delete(r.buckets, bucket)
+	}
+}
+
+// Shutdown 停止接受新任务，等待队列中已有的任务复制完成或 ctx 到期
+func (r *Replicator) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	for bucket, br := range r.buckets {
+		// 只关闭队列不标记 stopped，工作者处理完剩余任务后退出
+		close(br.queue)
+		delete(r.buckets, bucket)
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("replication queue not drained: %w", ctx.Err())
+	}
+}
+
+// Status 获取所有已配置桶的复制状态
+func (r *Replicator) Status() ([]ReplicationStatus, error) {
+	configs, err := r.metadata.ListReplicationConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	result := make([]ReplicationStatus, 0, len(configs))
+	for _, cfg := range configs {
+		st := ReplicationStatus{
+			Bucket:       cfg.Bucket,
+			Endpoint:     cfg.Endpoint,
+			TargetBucket: cfg.TargetBucket,
+			Enabled:      cfg.Enabled,
+		}
+		if br, ok := r.buckets[cfg.Bucket]; ok {
+			st.Pending = len(br.pending)
+			st.Replicated = br.replicated
+			st.Failed = br.failed
+			st.LastSuccess = br.lastSuccess
+			st.LastError = br.lastError
+			st.LastErrorAt = br.lastErrorAt
+			if len(br.pending) > 0 {
+				st.LagSeconds = now.Sub(br.pending[0]).Seconds()
+			}
+		}
+		result = append(result, st)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Bucket < result[j].Bucket })
+	return result, nil
+}
+
+// run 工作者主循环
+func (r *Replicator) run(br *bucketReplicator) {
+	for task := range br.queue {
+		r.mu.Lock()
+		stopped := br.stopped
+		r.mu.Unlock()
+		if stopped {
+			continue
+		}
+
+		var err error
+		for attempt := 0; attempt <= replicationMaxRetries; attempt++ {
+			if attempt > 0 {
+				r.mu.Lock()
+				wait := r.retryWait * time.Duration(attempt)
+				r.mu.Unlock()
+				time.Sleep(wait)
+			}
+			if err = r.apply(br, task); err == nil {
+				break
+			}
+		}
+
+		r.mu.Lock()
+		if len(br.pending) > 0 {
+			br.pending = br.pending[1:]
+		}
+		now := time.Now()
+		if err != nil {
+			br.failed++
+			br.lastError = fmt.Sprintf("%s %s: %v", task.op, task.key, err)
+			br.lastErrorAt = &now
+		} else {
+			br.replicated++
+			br.lastSuccess = &now
+		}
+		r.mu.Unlock()
+	}
+}
+
+// apply 将单个操作应用到目标端点
+func (r *Replicator) apply(br *bucketReplicator, task replicationTask) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if br.client == nil {
+		client, err := newS3Client(ctx, br.cfg.Endpoint, br.cfg.AccessKey, br.cfg.SecretKey, br.cfg.Region)
+		if err != nil {
+			return err
+		}
+		br.client = client
+	}
+
+	if task.op == ReplicationOpDelete {
+		_, err := br.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(br.cfg.TargetBucket),
+			Key:    aws.String(task.key),
+		})
+		return err
+	}
+
+	// 复制对象的当前状态；对象已被删除时由后续的删除操作处理
+	obj, err := r.metadata.GetObject(br.cfg.Bucket, task.key)
+	if err != nil {
+		return err
+	}
+	if obj == nil {
+		return nil
+	}
+	file, err := r.fileStore.OpenObject(obj)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = br.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(br.cfg.TargetBucket),
+		Key:           aws.String(task.key),
+		Body:          file,
+		ContentLength: aws.Int64(obj.Size),
+		ContentType:   aws.String(obj.ContentType),
+	})
+	return err
+}
@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReplicaTarget 模拟的 S3 复制目标，记录收到的写操作
+type fakeReplicaTarget struct {
+	mu       sync.Mutex
+	ops      []string
+	bodies   map[string]string
+	failOnce map[string]bool // 第一次请求该路径时返回错误
+	server   *httptest.Server
+}
+
+func newFakeReplicaTarget(t *testing.T) *fakeReplicaTarget {
+	t.Helper()
+	f := &fakeReplicaTarget{bodies: make(map[string]string), failOnce: make(map[string]bool)}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.failOnce[r.URL.Path] {
+			delete(f.failOnce, r.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f.ops = append(f.ops, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodPut:
+			f.bodies[r.URL.Path] = string(body)
+			w.Header().Set("ETag", `"etag"`)
+			w.WriteHeader(http.StatusOK)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeReplicaTarget) snapshot() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.ops...)
+}
+
+// setupReplicationStore 创建带测试对象的存储环境
+func setupReplicationStore(t *testing.T) (*MetadataStore, *FileStore) {
+	t.Helper()
+	store, cleanup := setupMetadataStore(t)
+	t.Cleanup(cleanup)
+	fs, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建FileStore失败: %v", err)
+	}
+	if err := store.CreateBucket("src"); err != nil {
+		t.Fatalf("创建桶失败: %v", err)
+	}
+	return store, fs
+}
+
+func putReplicationObject(t *testing.T, store *MetadataStore, fs *FileStore, key, content string) {
+	t.Helper()
+	path, etag, err := fs.PutObject("src", key, strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	if err := store.PutObject(&Object{Bucket: "src", Key: key, Size: int64(len(content)), ETag: etag, StoragePath: path, ContentType: "text/plain"}); err != nil {
+		t.Fatalf("保存元数据失败: %v", err)
+	}
+}
+
+// waitForReplication 等待队列清空
+func waitForReplication(t *testing.T, r *Replicator) ReplicationStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		status, err := r.Status()
+		if err != nil {
+			t.Fatalf("获取状态失败: %v", err)
+		}
+		if len(status) == 1 && status[0].Pending == 0 {
+			return status[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("等待复制超时")
+	return ReplicationStatus{}
+}
+
+// TestReplicationConfigStore 测试复制配置存取
+func TestReplicationConfigStore(t *testing.T) {
+	store, _ := setupReplicationStore(t)
+
+	cfg, err := store.GetReplicationConfig("src")
+	if err != nil || cfg != nil {
+		t.Fatalf("未配置时应返回 nil: %v, %v", cfg, err)
+	}
+
+	if err := store.SaveReplicationConfig(&ReplicationConfig{
+		Bucket: "src", Endpoint: "http://dr", AccessKey: "ak", SecretKey: "sk-secret", TargetBucket: "dst", Enabled: true,
+	}); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+
+	var raw string
+	store.db.QueryRow("SELECT secret_key FROM bucket_replication WHERE bucket = ?", "src").Scan(&raw)
+	if raw == "sk-secret" {
+		t.Error("Secret 应加密存储")
+	}
+
+	cfg, err = store.GetReplicationConfig("src")
+	if err != nil || cfg == nil {
+		t.Fatalf("读取配置失败: %v", err)
+	}
+	if cfg.SecretKey != "sk-secret" || cfg.TargetBucket != "dst" || !cfg.Enabled {
+		t.Errorf("配置内容错误: %+v", cfg)
+	}
+
+	configs, _ := store.ListReplicationConfigs()
+	if len(configs) != 1 || configs[0].SecretKey != "" {
+		t.Errorf("列表应不含 Secret: %+v", configs)
+	}
+
+	// 删除桶时一并删除复制配置
+	if err := store.DeleteBucket("src"); err != nil {
+		t.Fatalf("删除桶失败: %v", err)
+	}
+	if cfg, _ := store.GetReplicationConfig("src"); cfg != nil {
+		t.Error("删除桶后复制配置应被清除")
+	}
+}
+
+// TestReplicator 测试异步复制
+func TestReplicator(t *testing.T) {
+	store, fs := setupReplicationStore(t)
+	target := newFakeReplicaTarget(t)
+
+	r := NewReplicator(store, fs)
+	r.SetRetryWait(time.Millisecond)
+
+	// 未配置复制时不产生任何请求
+	putReplicationObject(t, store, fs, "before.txt", "x")
+	r.Enqueue("src", "before.txt", ReplicationOpPut)
+
+	if err := r.SetConfig(&ReplicationConfig{
+		Bucket: "src", Endpoint: target.server.URL, AccessKey: "ak", SecretKey: "sk", TargetBucket: "dst", Enabled: true,
+	}); err != nil {
+		t.Fatalf("设置配置失败: %v", err)
+	}
+
+	t.Run("按顺序复制写入和删除", func(t *testing.T) {
+		putReplicationObject(t, store, fs, "a.txt", "hello")
+		r.Enqueue("src", "a.txt", ReplicationOpPut)
+		r.Enqueue("src", "b.txt", ReplicationOpDelete)
+
+		st := waitForReplication(t, r)
+		ops := target.snapshot()
+		want := []string{"PUT /dst/a.txt", "DELETE /dst/b.txt"}
+		if strings.Join(ops, ",") != strings.Join(want, ",") {
+			t.Errorf("操作顺序错误: %v", ops)
+		}
+		if target.bodies["/dst/a.txt"] != "hello" {
+			t.Errorf("复制内容错误: %q", target.bodies["/dst/a.txt"])
+		}
+		if st.Replicated != 2 || st.Failed != 0 || st.LastSuccess == nil {
+			t.Errorf("状态错误: %+v", st)
+		}
+	})
+
+	t.Run("失败后重试", func(t *testing.T) {
+		putReplicationObject(t, store, fs, "retry.txt", "again")
+		target.mu.Lock()
+		target.failOnce["/dst/retry.txt"] = true
+		target.mu.Unlock()
+
+		r.Enqueue("src", "retry.txt", ReplicationOpPut)
+		st := waitForReplication(t, r)
+		if target.bodies["/dst/retry.txt"] != "again" {
+			t.Error("重试后应复制成功")
+		}
+		if st.Failed != 0 || st.Replicated != 3 {
+			t.Errorf("状态错误: %+v", st)
+		}
+	})
+
+	t.Run("对象已删除时跳过写入", func(t *testing.T) {
+		before := len(target.snapshot())
+		r.Enqueue("src", "missing.txt", ReplicationOpPut)
+		waitForReplication(t, r)
+		if len(target.snapshot()) != before {
+			t.Error("对象不存在时不应发送请求")
+		}
+	})
+
+	t.Run("目标不可用时记录失败", func(t *testing.T) {
+		denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer denied.Close()
+		if err := r.SetConfig(&ReplicationConfig{
+			Bucket: "src", Endpoint: denied.URL, AccessKey: "ak", SecretKey: "sk", TargetBucket: "dst", Enabled: true,
+		}); err != nil {
+			t.Fatalf("设置配置失败: %v", err)
+		}
+		r.Enqueue("src", "gone.txt", ReplicationOpDelete)
+		st := waitForReplication(t, r)
+		if st.Failed != 1 || st.LastError == "" || st.LastErrorAt == nil {
+			t.Errorf("应记录失败: %+v", st)
+		}
+	})
+
+	t.Run("删除配置后停止复制", func(t *testing.T) {
+		if err := r.DeleteConfig("src"); err != nil {
+			t.Fatalf("删除配置失败: %v", err)
+		}
+		r.Enqueue("src", "a.txt", ReplicationOpPut)
+		status, _ := r.Status()
+		if len(status) != 0 {
+			t.Errorf("删除配置后不应有状态: %+v", status)
+		}
+	})
+}
+
+// TestReplicatorShutdownDrains 测试关闭时排空复制队列并拒绝新任务
+func TestReplicatorShutdownDrains(t *testing.T) {
+	store, fs := setupReplicationStore(t)
+	target := newFakeReplicaTarget(t)
+
+	r := NewReplicator(store, fs)
+	if err := r.SetConfig(&ReplicationConfig{
+		Bucket: "src", Endpoint: target.server.URL, AccessKey: "ak", SecretKey: "sk", TargetBucket: "dst", Enabled: true,
+	}); err != nil {
+		t.Fatalf("设置配置失败: %v", err)
+	}
+	for _, key := range []string{"a.txt", "b.txt", "c.txt"} {
+		putReplicationObject(t, store, fs, key, key)
+		r.Enqueue("src", key, ReplicationOpPut)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+	if ops := target.snapshot(); len(ops) != 3 {
+		t.Errorf("关闭前应复制完队列中的任务: %v", ops)
+	}
+
+	r.Enqueue("src", "late.txt", ReplicationOpDelete)
+	time.Sleep(20 * time.Millisecond)
+	if ops := target.snapshot(); len(ops) != 3 {
+		t.Errorf("关闭后不应接受新任务: %v", ops)
+	}
+}
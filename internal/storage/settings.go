@@ -36,15 +36,46 @@ const (
 	SettingServerPort   = "server.port"
 	SettingServerRegion = "server.region"
 
+	SettingServerMaintenance       = "server.maintenance"         // 维护模式，"true" 或 "false"
+	SettingServerMetricsMaxBuckets = "server.metrics_max_buckets" // 按桶请求统计的桶数上限，0 表示不限制
+	SettingServerPrometheusEnabled = "server.prometheus_enabled"  // 是否开放 /metrics，"true" 或 "false"
+
 	// 存储配置
 	SettingStorageDataPath      = "storage.data_path"
 	SettingStorageMaxObjectSize = "storage.max_object_size"
 	SettingStorageMaxUploadSize = "storage.max_upload_size"
+	SettingStorageMaxBuckets    = "storage.max_buckets"        // 最大桶数量，0 表示不限制
+	SettingStorageAutoCreate    = "storage.auto_create_bucket" // PUT 对象时自动建桶，"true" 或 "false"
+	SettingStorageLeadingSlash  = "storage.key_leading_slash"  // 对象键前导斜杠处理，"normalize" 或 "reject"
+	SettingStorageMaxMetadata   = "storage.max_metadata_size"  // 用户元数据总大小上限（字节），0 表示不限制
+	SettingStorageFolderMarkers = "storage.folder_markers"     // 以 / 结尾的零字节对象处理，"object" 或 "placeholder"
+
+	SettingStorageIdempotency  = "storage.idempotency_window_minutes" // PUT 幂等键保留时间（分钟），0 表示忽略幂等键
+	SettingStorageAnonymousCap = "storage.anonymous_daily_bytes"      // 公有桶匿名下载每日流量上限（字节），0 表示不限制
+
+	SettingStorageMaxUploadParts       = "storage.max_upload_parts"           // 单个未完成上传保留的分片数上限，0 表示不限制
+	SettingStorageMaxIncompleteUploads = "storage.max_incomplete_uploads"     // 每个桶未完成上传数上限，0 表示不限制
+	SettingStorageMinPartSize          = "storage.min_part_size"              // 除最后一个外每个分片的最小大小（字节），0 表示不检查
+	SettingStorageMinFreeSpace         = "storage.min_free_bytes"             // 数据盘最低可用空间（字节），0 表示不检查
+	SettingStorageListTimeBudget       = "storage.list_time_budget_ms"        // 单次列举的扫描时间预算（毫秒），0 表示不限制
+	SettingStorageDeleteGrace          = "storage.delete_grace_seconds"       // 管理界面删除对象的撤销窗口（秒），0 表示立即删除
+	SettingStorageLifecycleInterval    = "storage.lifecycle_interval_minutes" // 生命周期过期扫描间隔（分钟），0 表示暂停
 
 	// 安全配置
-	SettingSecurityCORSOrigin     = "security.cors_origin"      // CORS 允许的来源，默认 "*"
-	SettingSecurityPresignScheme  = "security.presign_scheme"   // 预签名URL协议，"http" 或 "https"
-	SettingSecurityTrustedProxies = "security.trusted_proxies"  // 信任的代理 IP/CIDR，逗号分隔
+	SettingSecurityCORSOrigin           = "security.cors_origin"            // CORS 允许的来源，默认 "*"
+	SettingSecurityCORSAllowCredentials = "security.cors_allow_credentials" // 是否允许携带凭证，"true" 或 "false"
+	SettingSecurityPresignScheme        = "security.presign_scheme"         // 预签名URL协议，"http" 或 "https"
+	SettingSecurityTrustedProxies       = "security.trusted_proxies"        // 信任的代理 IP/CIDR，逗号分隔
+	SettingSecurityAuditOverwrite       = "security.audit_overwrite"        // 是否记录对象覆盖审计，"true" 或 "false"
+	SettingSecurityAuditReadPercent     = "security.audit_read_percent"     // 读操作审计采样百分比 0-100，0 表示关闭
+	SettingSecurityPresignLimit         = "security.presign_limit"          // 每个 Access Key 每个窗口的预签名数上限，0 表示不限制
+	SettingSecurityPresignWindow        = "security.presign_window_minutes" // 预签名计数时间窗口（分钟）
+	SettingSecurityHeaderNoSniff        = "security.header_nosniff"         // X-Content-Type-Options: nosniff，"true" 或 "false"
+	SettingSecurityReferrerPolicy       = "security.referrer_policy"        // Referrer-Policy，"off" 表示不发送
+	SettingSecurityFrameOptions         = "security.frame_options"          // X-Frame-Options，"off" 表示不发送
+	SettingSecurityCSP                  = "security.csp"                    // Content-Security-Policy，"off" 表示不发送
+	SettingSecurityHeadersOnPublic      = "security.headers_on_public"      // 公有桶对象是否附加安全头，"true" 或 "false"
+	SettingSecurityTraceHeader          = "security.trace_header"           // 客户端追踪 ID 请求头，"off" 表示不启用
 
 	// 认证配置
 	SettingAuthAdminUsername     = "auth.admin_username"
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+)
+
+// 对象静态加密（SSE，服务端密钥）：AES-256-CTR，主密钥由口令经 PBKDF2 派生，每个对象使用随机 IV 并保存在元数据中
+// CTR 模式可以从任意偏移开始解密，Range 读取无需从头解密；ETag 仍是明文的 MD5
+
+// 主密钥派生参数，修改后已有的加密对象无法解密
+const (
+	encryptionKDFSalt       = "sss-object-encryption"
+	encryptionKDFIterations = 100000
+)
+
+// SettingEncryptionKeyID 主密钥指纹，启动时用于发现口令变更
+const SettingEncryptionKeyID = "system.object_encryption_key_id"
+
+var (
+	// ErrEncryptionKeyMissing 对象已加密，但未配置加密口令
+	ErrEncryptionKeyMissing = errors.New("object is encrypted but no encryption passphrase is configured")
+	// ErrInvalidEncryptionNonce 元数据中的 IV 格式错误
+	ErrInvalidEncryptionNonce = errors.New("invalid object encryption nonce")
+	// ErrEncryptionKeyChanged 加密口令与之前使用的不一致
+	ErrEncryptionKeyChanged = errors.New("encryption passphrase does not match the one used for existing objects")
+)
+
+// ObjectReader 对象内容读取器，加密对象在读取时透明解密
+type ObjectReader interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+}
+
+// Encrypted 对象文件是否加密保存
+func (o *Object) Encrypted() bool {
+	return o.EncryptionNonce != ""
+}
+
+// objectCipher 对象加密使用的主密钥
+type objectCipher struct {
+	block cipher.Block
+	keyID string
+}
+
+// DeriveEncryptionKey 由口令派生 256 位主密钥
+func DeriveEncryptionKey(passphrase string) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, []byte(encryptionKDFSalt), encryptionKDFIterations, 32)
+}
+
+// SetEncryptionPassphrase 设置对象静态加密口令，之后新写入的对象加密保存；空口令关闭加密，已加密的对象无法再读取
+// 应在启动时调用，运行期间不可修改
+func (f *FileStore) SetEncryptionPassphrase(passphrase string) error {
+	if passphrase == "" {
+		f.cipher = nil
+		return nil
+	}
+	key, err := DeriveEncryptionKey(passphrase)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("sss-key-id"))
+	f.cipher = &objectCipher{block: block, keyID: hex.EncodeToString(mac.Sum(nil)[:16])}
+	return nil
+}
+
+// EncryptionEnabled 新写入的对象是否加密保存
+func (f *FileStore) EncryptionEnabled() bool {
+	return f.cipher != nil
+}
+
+// CheckEncryptionKey 核对主密钥指纹：首次启用时保存指纹，之后口令不一致时返回 ErrEncryptionKeyChanged
+// 未启用加密时不检查，已加密对象在读取时返回 ErrEncryptionKeyMissing
+func (m *MetadataStore) CheckEncryptionKey(f *FileStore) error {
+	if f.cipher == nil {
+		return nil
+	}
+	saved, err := m.GetSetting(SettingEncryptionKeyID)
+	if err != nil {
+		return err
+	}
+	if saved == "" {
+		return m.SetSetting(SettingEncryptionKeyID, f.cipher.keyID)
+	}
+	if saved != f.cipher.keyID {
+		return ErrEncryptionKeyChanged
+	}
+	return nil
+}
+
+// encryptWriter 返回把数据加密后写入 w 的 Writer 及新生成的 IV（十六进制）
+func (c *objectCipher) encryptWriter(w io.Writer) (io.Writer, string, error) {
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, "", err
+	}
+	return cipher.StreamWriter{S: cipher.NewCTR(c.block, iv), W: w}, hex.EncodeToString(iv), nil
+}
+
+// OpenObject 打开对象内容，加密对象返回解密读取器，明文对象直接返回文件
+func (f *FileStore) OpenObject(obj *Object) (ObjectReader, error) {
+	if !obj.Encrypted() {
+		return f.GetObject(obj.StoragePath)
+	}
+	iv, err := hex.DecodeString(obj.EncryptionNonce)
+	if err != nil || len(iv) != aes.BlockSize {
+		return nil, ErrInvalidEncryptionNonce
+	}
+	if f.cipher == nil {
+		return nil, ErrEncryptionKeyMissing
+	}
+	file, err := f.GetObject(obj.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{file: file, block: f.cipher.block, iv: iv}, nil
+}
+
+// CopyObjectStaged 把源对象的内容写入目标对象的临时文件，加密源对象先解密，启用加密时按新的 IV 重新加密
+func (f *FileStore) CopyObjectStaged(src *Object, destBucket, destKey, versionID string) (*StagedFile, error) {
+	reader, err := f.OpenObject(src)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return f.PutObjectStaged(destBucket, destKey, versionID, reader, 0)
+}
+
+// decryptReader 解密 AES-CTR 加密的对象文件，支持 Seek 和 ReadAt
+type decryptReader struct {
+	file   *os.File
+	block  cipher.Block
+	iv     []byte
+	offset int64
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	n, err := d.ReadAt(p, d.offset)
+	d.offset += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+func (d *decryptReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := d.file.ReadAt(p, off)
+	if n > 0 {
+		ctrStreamAt(d.block, d.iv, off).XORKeyStream(p[:n], p[:n])
+	}
+	return n, err
+}
+
+func (d *decryptReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += d.offset
+	case io.SeekEnd:
+		info, err := d.file.Stat()
+		if err != nil {
+			return 0, err
+		}
+		offset += info.Size()
+	default:
+		return 0, errors.New("decryptReader.Seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("decryptReader.Seek: negative position")
+	}
+	d.offset = offset
+	return offset, nil
+}
+
+func (d *decryptReader) Close() error {
+	return d.file.Close()
+}
+
+// ctrStreamAt 返回从明文偏移 off 开始的 CTR 密钥流
+// 计数器是 IV 视为 128 位大端整数加上块序号，与 cipher.NewCTR 的递增方式一致
+func ctrStreamAt(block cipher.Block, iv []byte, off int64) cipher.Stream {
+	counter := make([]byte, aes.BlockSize)
+	copy(counter, iv)
+	carry := uint64(off / aes.BlockSize)
+	for i := aes.BlockSize - 1; i >= 0 && carry > 0; i-- {
+		sum := uint64(counter[i]) + carry&0xff
+		counter[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	stream := cipher.NewCTR(block, counter)
+	if skip := off % aes.BlockSize; skip > 0 {
+		var discard [aes.BlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+	return stream
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCtrStreamAt 测试从任意偏移开始的密钥流与从头生成的一致，包括计数器进位
+func TestCtrStreamAt(t *testing.T) {
+	block, _ := aes.NewCipher(make([]byte, 32))
+	ivs := map[string][]byte{
+		"普通 IV": bytes.Repeat([]byte{0x12}, aes.BlockSize),
+		"低位进位":  append(bytes.Repeat([]byte{0x01}, 8), bytes.Repeat([]byte{0xff}, 8)...),
+		"整体溢出":  bytes.Repeat([]byte{0xff}, aes.BlockSize),
+	}
+	for name, iv := range ivs {
+		t.Run(name, func(t *testing.T) {
+			full := make([]byte, 1000)
+			cipher.NewCTR(block, iv).XORKeyStream(full, full)
+			for _, off := range []int64{0, 1, 15, 16, 17, 255, 256, 513, 999} {
+				part := make([]byte, len(full)-int(off))
+				ctrStreamAt(block, iv, off).XORKeyStream(part, part)
+				if !bytes.Equal(part, full[off:]) {
+					t.Errorf("偏移 %d 的密钥流不一致", off)
+				}
+			}
+		})
+	}
+}
+
+// TestFileStoreEncryptionRoundTrip 测试加密写入、解密读取、Range 读取和复制
+func TestFileStoreEncryptionRoundTrip(t *testing.T) {
+	fs, _ := setupFileStore(t)
+	if err := fs.SetEncryptionPassphrase("correct horse battery staple"); err != nil {
+		t.Fatalf("设置口令失败: %v", err)
+	}
+	if !fs.EncryptionEnabled() {
+		t.Fatal("应已启用加密")
+	}
+
+	content := []byte(strings.Repeat("0123456789abcdef-", 300))
+	staged, err := fs.PutObjectStaged("enc", "doc.txt", "", bytes.NewReader(content), 0)
+	if err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if err := staged.Commit(); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+	sum := md5.Sum(content)
+	if staged.ETag != hex.EncodeToString(sum[:]) || staged.Size != int64(len(content)) {
+		t.Errorf("ETag 和大小应按明文计算: %s %d", staged.ETag, staged.Size)
+	}
+	if len(staged.Nonce) != 2*aes.BlockSize {
+		t.Fatalf("应记录 IV: %q", staged.Nonce)
+	}
+
+	raw, _ := os.ReadFile(staged.Path)
+	if len(raw) != len(content) || bytes.Contains(raw, []byte("0123456789abcdef")) {
+		t.Fatal("磁盘上的文件不应为明文")
+	}
+
+	obj := &Object{Bucket: "enc", Key: "doc.txt", Size: staged.Size, StoragePath: staged.Path, EncryptionNonce: staged.Nonce}
+	reader, err := fs.OpenObject(obj)
+	if err != nil {
+		t.Fatalf("打开对象失败: %v", err)
+	}
+	defer reader.Close()
+	got, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("解密内容不一致: %v", err)
+	}
+
+	// Range 读取：Seek 到任意偏移后读取
+	for _, r := range [][2]int64{{0, 10}, {5, 40}, {16, 32}, {1000, 1037}, {int64(len(content)) - 3, int64(len(content))}} {
+		if _, err := reader.Seek(r[0], io.SeekStart); err != nil {
+			t.Fatalf("Seek 失败: %v", err)
+		}
+		part := make([]byte, r[1]-r[0])
+		if _, err := io.ReadFull(reader, part); err != nil || !bytes.Equal(part, content[r[0]:r[1]]) {
+			t.Errorf("范围 %v 内容不一致: %v", r, err)
+		}
+	}
+	part := make([]byte, 20)
+	if n, err := reader.ReadAt(part, 777); err != nil || n != 20 || !bytes.Equal(part, content[777:797]) {
+		t.Errorf("ReadAt 内容不一致: %d %v", n, err)
+	}
+	if pos, _ := reader.Seek(-5, io.SeekEnd); pos != int64(len(content))-5 {
+		t.Errorf("SeekEnd 位置错误: %d", pos)
+	}
+
+	// 复制时解密后用新的 IV 重新加密
+	copied, err := fs.CopyObjectStaged(obj, "enc", "copy.txt", "")
+	if err != nil {
+		t.Fatalf("复制失败: %v", err)
+	}
+	copied.Commit()
+	if copied.Nonce == "" || copied.Nonce == staged.Nonce || copied.ETag != staged.ETag {
+		t.Errorf("复制结果错误: %+v", copied)
+	}
+	copyReader, err := fs.OpenObject(&Object{StoragePath: copied.Path, EncryptionNonce: copied.Nonce})
+	if err != nil {
+		t.Fatalf("打开复制的对象失败: %v", err)
+	}
+	got, _ = io.ReadAll(copyReader)
+	copyReader.Close()
+	if !bytes.Equal(got, content) {
+		t.Error("复制的对象内容不一致")
+	}
+}
+
+// TestFileStoreEncryptionPlaintextObjects 测试启用加密前写入的明文对象仍可读取，未配置口令时无法读取加密对象
+func TestFileStoreEncryptionPlaintextObjects(t *testing.T) {
+	fs, _ := setupFileStore(t)
+	path, _, err := fs.PutObject("enc", "old.txt", strings.NewReader("plain"), 5)
+	if err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	fs.SetEncryptionPassphrase("secret")
+
+	reader, err := fs.OpenObject(&Object{StoragePath: path})
+	if err != nil {
+		t.Fatalf("明文对象应可读取: %v", err)
+	}
+	got, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(got) != "plain" {
+		t.Errorf("明文对象内容错误: %q", got)
+	}
+
+	staged, _ := fs.PutObjectStaged("enc", "new.txt", "", strings.NewReader("secret data"), 0)
+	staged.Commit()
+	obj := &Object{StoragePath: staged.Path, EncryptionNonce: staged.Nonce}
+
+	fs.SetEncryptionPassphrase("")
+	if fs.EncryptionEnabled() {
+		t.Error("空口令应关闭加密")
+	}
+	if _, err := fs.OpenObject(obj); err != ErrEncryptionKeyMissing {
+		t.Errorf("未配置口令时应返回 ErrEncryptionKeyMissing: %v", err)
+	}
+	if _, err := fs.OpenObject(&Object{StoragePath: staged.Path, EncryptionNonce: "zz"}); err != ErrInvalidEncryptionNonce {
+		t.Errorf("IV 格式错误时应返回 ErrInvalidEncryptionNonce: %v", err)
+	}
+
+	// 关闭加密后新写入的对象为明文
+	staged, _ = fs.PutObjectStaged("enc", "later.txt", "", strings.NewReader("x"), 0)
+	if staged.Nonce != "" {
+		t.Error("关闭加密后不应加密")
+	}
+	staged.Discard()
+}
+
+// TestEncryptionMetadata 测试 IV 随对象元数据和历史版本保存，以及口令变更检查
+func TestEncryptionMetadata(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	fs, _ := setupFileStore(t)
+	fs.SetEncryptionPassphrase("first")
+
+	if err := store.CheckEncryptionKey(fs); err != nil {
+		t.Fatalf("首次启用应保存指纹: %v", err)
+	}
+	if err := store.CheckEncryptionKey(fs); err != nil {
+		t.Fatalf("同一口令应通过检查: %v", err)
+	}
+	other, _ := setupFileStore(t)
+	other.SetEncryptionPassphrase("second")
+	if err := store.CheckEncryptionKey(other); err != ErrEncryptionKeyChanged {
+		t.Errorf("口令变更应返回 ErrEncryptionKeyChanged: %v", err)
+	}
+
+	store.CreateBucket("enc")
+	store.UpdateBucketVersioning("enc", true)
+	store.PutObject(&Object{Bucket: "enc", Key: "a.txt", Size: 1, ETag: "e1", StoragePath: "/v1", VersionID: "v1",
+		LastModified: time.Now().UTC(), EncryptionNonce: "00112233445566778899aabbccddeeff"})
+	obj, _ := store.GetObject("enc", "a.txt")
+	if obj == nil || obj.EncryptionNonce != "00112233445566778899aabbccddeeff" || !obj.Encrypted() {
+		t.Fatalf("应读取到 IV: %+v", obj)
+	}
+
+	store.PutObject(&Object{Bucket: "enc", Key: "a.txt", Size: 1, ETag: "e2", StoragePath: "/v2", VersionID: "v2",
+		LastModified: time.Now().UTC()})
+	v, _ := store.GetObjectVersion("enc", "a.txt", "v1")
+	if v == nil || v.EncryptionNonce != "00112233445566778899aabbccddeeff" {
+		t.Fatalf("历史版本应保留 IV: %+v", v)
+	}
+
+	// 删除最新版本后，恢复为当前版本的历史版本仍带 IV
+	store.DeleteObjectVersion("enc", "a.txt", "v2")
+	if obj, _ := store.GetObject("enc", "a.txt"); obj == nil || obj.EncryptionNonce != "00112233445566778899aabbccddeeff" {
+		t.Errorf("恢复的当前版本应带 IV: %+v", obj)
+	}
+}
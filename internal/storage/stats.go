@@ -13,6 +13,10 @@ type StorageStats struct {
 	TotalSize    int64        `json:"total_size"`    // 总大小(字节)
 	BucketStats  []BucketStat `json:"bucket_stats"`  // 各桶统计
 	TypeStats    []TypeStat   `json:"type_stats"`    // 文件类型统计
+
+	IncompleteUploads int   `json:"incomplete_uploads"` // 未完成的多段上传数
+	IncompleteParts   int   `json:"incomplete_parts"`   // 未完成上传已保存的分片数
+	IncompleteSize    int64 `json:"incomplete_size"`    // 未完成上传分片占用(字节)
 }
 
 // BucketStat 单个桶的统计
@@ -21,6 +25,8 @@ type BucketStat struct {
 	ObjectCount int    `json:"object_count"`
 	TotalSize   int64  `json:"total_size"`
 	IsPublic    bool   `json:"is_public"`
+
+	IncompleteUploads int `json:"incomplete_uploads"` // 未完成的多段上传数
 }
 
 // TypeStat 文件类型统计
@@ -41,8 +47,8 @@ func (m *MetadataStore) GetStorageStats() (*StorageStats, error) {
 		return nil, err
 	}
 
-	// 2. 获取对象总数和总大小
-	err = m.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(size), 0) FROM objects").
+	// 2. 获取对象总数和总大小（读取触发器维护的计数器，无需扫描 objects 表）
+	err = m.db.QueryRow("SELECT COALESCE(SUM(object_count), 0), COALESCE(SUM(total_size), 0) FROM bucket_counters").
 		Scan(&stats.TotalObjects, &stats.TotalSize)
 	if err != nil {
 		return nil, err
@@ -51,11 +57,10 @@ func (m *MetadataStore) GetStorageStats() (*StorageStats, error) {
 	// 3. 获取各桶统计
 	rows, err := m.db.Query(`
 		SELECT b.name, b.is_public,
-			   COUNT(o.key) as object_count,
-			   COALESCE(SUM(o.size), 0) as total_size
+			   COALESCE(c.object_count, 0) as object_count,
+			   COALESCE(c.total_size, 0) as total_size
 		FROM buckets b
-		LEFT JOIN objects o ON b.name = o.bucket
-		GROUP BY b.name, b.is_public
+		LEFT JOIN bucket_counters c ON b.name = c.bucket
 		ORDER BY total_size DESC
 	`)
 	if err != nil {
@@ -71,7 +76,34 @@ func (m *MetadataStore) GetStorageStats() (*StorageStats, error) {
 		stats.BucketStats = append(stats.BucketStats, bs)
 	}
 
-	// 4. 获取文件类型统计
+	// 4. 获取未完成的多段上传统计
+	err = m.db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM multipart_uploads), COUNT(*), COALESCE(SUM(size), 0)
+		FROM parts WHERE upload_id IN (SELECT upload_id FROM multipart_uploads)
+	`).Scan(&stats.IncompleteUploads, &stats.IncompleteParts, &stats.IncompleteSize)
+	if err != nil {
+		return nil, err
+	}
+	uploadRows, err := m.db.Query("SELECT bucket, COUNT(*) FROM multipart_uploads GROUP BY bucket")
+	if err != nil {
+		return nil, err
+	}
+	defer uploadRows.Close()
+
+	uploads := make(map[string]int)
+	for uploadRows.Next() {
+		var bucket string
+		var count int
+		if err := uploadRows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		uploads[bucket] = count
+	}
+	for i := range stats.BucketStats {
+		stats.BucketStats[i].IncompleteUploads = uploads[stats.BucketStats[i].Name]
+	}
+
+	// 5. 获取文件类型统计
 	typeRows, err := m.db.Query(`
 		SELECT content_type, COUNT(*) as count, SUM(size) as total_size
 		FROM objects
@@ -147,7 +179,7 @@ func (m *MetadataStore) GetRecentObjects(limit int) ([]Object, error) {
 	}
 
 	rows, err := m.db.Query(`
-		SELECT bucket, key, size, etag, content_type, last_modified, storage_path
+		SELECT bucket, key, size, etag, content_type, last_modified, storage_path, created_at
 		FROM objects
 		ORDER BY last_modified DESC
 		LIMIT ?
@@ -161,7 +193,7 @@ func (m *MetadataStore) GetRecentObjects(limit int) ([]Object, error) {
 	for rows.Next() {
 		var obj Object
 		if err := rows.Scan(&obj.Bucket, &obj.Key, &obj.Size, &obj.ETag,
-			&obj.ContentType, &obj.LastModified, &obj.StoragePath); err != nil {
+			&obj.ContentType, &obj.LastModified, &obj.StoragePath, &obj.CreatedAt); err != nil {
 			return nil, err
 		}
 		objects = append(objects, obj)
@@ -183,3 +215,75 @@ func (f *FileStore) GetDiskUsage() (totalSize int64, fileCount int, err error) {
 	})
 	return
 }
+
+// 前缀用量统计限制
+const (
+	MaxPrefixUsageDepth = 5
+	maxPrefixUsageRows  = 1000
+)
+
+// PrefixUsage 单个前缀的用量
+type PrefixUsage struct {
+	Prefix      string `json:"prefix"` // 完整前缀，等于查询前缀时表示直接位于该层的对象
+	ObjectCount int    `json:"object_count"`
+	TotalSize   int64  `json:"total_size"`
+}
+
+// GetPrefixTotals 统计前缀下的对象总数与总大小
+func (m *MetadataStore) GetPrefixTotals(bucket, prefix string) (int, int64, error) {
+	var count int
+	var size int64
+	err := m.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(size), 0) FROM objects WHERE bucket = ? AND key LIKE ? ESCAPE '\\'",
+		bucket, escapeLikePattern(prefix)+"%").Scan(&count, &size)
+	return count, size, err
+}
+
+// GetPrefixUsage 按前缀之后的前 depth 级路径分组统计对象数与字节数，按大小降序
+// 分组在 SQL 中完成，不在 Go 中遍历对象
+func (m *MetadataStore) GetPrefixUsage(bucket, prefix, delimiter string, depth, limit int) ([]PrefixUsage, error) {
+	if delimiter == "" {
+		delimiter = "/"
+	}
+	if depth <= 0 {
+		depth = 1
+	}
+	if depth > MaxPrefixUsageDepth {
+		depth = MaxPrefixUsageDepth
+	}
+	if limit <= 0 || limit > maxPrefixUsageRows {
+		limit = maxPrefixUsageRows
+	}
+
+	// 每一级从 tail 中截取到下一个分隔符为止的片段追加到 grp
+	level := "SELECT substr(key, length(?) + 1) AS tail, '' AS grp, size FROM objects WHERE bucket = ? AND key LIKE ? ESCAPE '\\'"
+	args := []interface{}{prefix, bucket, escapeLikePattern(prefix) + "%"}
+	for i := 0; i < depth; i++ {
+		level = `SELECT
+			CASE WHEN instr(tail, ?) > 0 THEN substr(tail, instr(tail, ?) + length(?)) ELSE '' END AS tail,
+			grp || CASE WHEN instr(tail, ?) > 0 THEN substr(tail, 1, instr(tail, ?) + length(?) - 1) ELSE '' END AS grp,
+			size
+		FROM (` + level + `)`
+		args = append([]interface{}{delimiter, delimiter, delimiter, delimiter, delimiter, delimiter}, args...)
+	}
+
+	query := "SELECT grp, COUNT(*), COALESCE(SUM(size), 0) AS total FROM (" + level + ") GROUP BY grp ORDER BY total DESC, grp LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []PrefixUsage
+	for rows.Next() {
+		var u PrefixUsage
+		var grp string
+		if err := rows.Scan(&grp, &u.ObjectCount, &u.TotalSize); err != nil {
+			return nil, err
+		}
+		u.Prefix = prefix + grp
+		result = append(result, u)
+	}
+	return result, rows.Err()
+}
@@ -702,3 +702,217 @@ func BenchmarkGetRecentObjects(b *testing.B) {
 		ms.GetRecentObjects(10)
 	}
 }
+
+// TestGetPrefixUsage 测试按前缀分组统计
+func TestGetPrefixUsage(t *testing.T) {
+	ms, _, cleanup := setupStatsTest(t)
+	defer cleanup()
+
+	ms.CreateBucket("usage")
+	ms.CreateBucket("other")
+	objects := map[string]int64{
+		"readme.txt":            5,
+		"logs/2024/a.log":       100,
+		"logs/2024/b.log":       200,
+		"logs/2025/c.log":       50,
+		"images/cat.png":        300,
+		"images/thumbs/cat.png": 10,
+		"data_1/x%y.bin":        1,
+		"data_12/z.bin":         2,
+	}
+	for key, size := range objects {
+		ms.PutObject(&Object{Bucket: "usage", Key: key, Size: size, ETag: "e", StoragePath: "/p/" + key})
+	}
+	ms.PutObject(&Object{Bucket: "other", Key: "logs/x.log", Size: 999, ETag: "e", StoragePath: "/p/x"})
+
+	t.Run("按第一级分组并按大小排序", func(t *testing.T) {
+		groups, err := ms.GetPrefixUsage("usage", "", "/", 1, 0)
+		if err != nil {
+			t.Fatalf("统计失败: %v", err)
+		}
+		want := []PrefixUsage{
+			{"logs/", 3, 350},
+			{"images/", 2, 310},
+			{"", 1, 5},
+			{"data_12/", 1, 2},
+			{"data_1/", 1, 1},
+		}
+		if len(groups) != len(want) {
+			t.Fatalf("分组数量错误: %+v", groups)
+		}
+		for i := range want {
+			if groups[i] != want[i] {
+				t.Errorf("第 %d 组错误: got %+v, want %+v", i, groups[i], want[i])
+			}
+		}
+	})
+
+	t.Run("前缀与深度", func(t *testing.T) {
+		groups, _ := ms.GetPrefixUsage("usage", "logs/", "/", 1, 0)
+		if len(groups) != 2 || groups[0] != (PrefixUsage{"logs/2024/", 2, 300}) {
+			t.Errorf("前缀统计错误: %+v", groups)
+		}
+
+		groups, _ = ms.GetPrefixUsage("usage", "images/", "/", 2, 0)
+		if len(groups) != 2 || groups[0] != (PrefixUsage{"images/", 1, 300}) || groups[1] != (PrefixUsage{"images/thumbs/", 1, 10}) {
+			t.Errorf("多级统计错误: %+v", groups)
+		}
+
+		// LIKE 特殊字符不应误匹配
+		groups, _ = ms.GetPrefixUsage("usage", "data_1/", "/", 1, 0)
+		if len(groups) != 1 || groups[0].ObjectCount != 1 {
+			t.Errorf("前缀转义错误: %+v", groups)
+		}
+	})
+
+	t.Run("限制数量与总量", func(t *testing.T) {
+		groups, _ := ms.GetPrefixUsage("usage", "", "/", 1, 2)
+		if len(groups) != 2 {
+			t.Errorf("limit 未生效: %+v", groups)
+		}
+		count, size, err := ms.GetPrefixTotals("usage", "logs/")
+		if err != nil || count != 3 || size != 350 {
+			t.Errorf("总量错误: %d %d %v", count, size, err)
+		}
+	})
+}
+
+// TestBucketMetricsCollector 测试按桶请求统计与基数上限
+func TestBucketMetricsCollector(t *testing.T) {
+	c := NewBucketMetricsCollector()
+
+	c.Record("a", 200, 100, 0, 2)
+	c.Record("a", 404, 0, 50, 2)
+	c.Record("b", 500, 0, 10, 2)
+	// 超出上限的新桶归入 _other，且 _other 不占用名额
+	c.Record("c", 200, 5, 0, 2)
+	c.Record("d", 200, 5, 0, 2)
+	c.Record("a", 200, 0, 1, 2)
+
+	got := make(map[string]BucketMetrics)
+	for _, m := range c.Snapshot() {
+		got[m.Bucket] = m
+	}
+	if len(got) != 3 {
+		t.Fatalf("应只有 a、b、_other 三项: %v", got)
+	}
+	if a := got["a"]; a.Requests != 3 || a.Errors != 1 || a.BytesIn != 100 || a.BytesOut != 51 {
+		t.Errorf("a 统计错误: %+v", a)
+	}
+	if b := got["b"]; b.ServerErrors != 1 || b.ErrorRate != 1 {
+		t.Errorf("b 统计错误: %+v", b)
+	}
+	if o := got[OtherBucketsLabel]; o.Requests != 2 || o.BytesIn != 10 {
+		t.Errorf("_other 统计错误: %+v", o)
+	}
+	if first := c.Snapshot()[0]; first.Bucket != "a" {
+		t.Errorf("应按请求数降序: %s", first.Bucket)
+	}
+
+	c.Reset()
+	if len(c.Snapshot()) != 0 {
+		t.Error("Reset 后应为空")
+	}
+}
+
+// TestAnonymousDownloads 测试匿名下载次数与每日流量统计
+func TestAnonymousDownloads(t *testing.T) {
+	ms, _, cleanup := setupStatsTest(t)
+	defer cleanup()
+
+	ms.CreateBucket("releases")
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+
+	ms.RecordAnonymousDownload("releases", "app.zip", 100, true, now)
+	ms.RecordAnonymousDownload("releases", "app.zip", 100, true, now)
+	ms.RecordAnonymousDownload("releases", "app.zip", 10, false, now) // Range 请求
+	ms.RecordAnonymousDownload("releases", "notes.txt", 5, true, now)
+	ms.RecordAnonymousDownload("releases", "", 50, false, now) // 列表等无对象的流量
+
+	used, err := ms.AnonymousBytesOn(now)
+	if err != nil || used != 265 {
+		t.Fatalf("当日流量错误: %d %v", used, err)
+	}
+	if next, _ := ms.AnonymousBytesOn(now.Add(2 * time.Hour)); next != 0 {
+		t.Errorf("次日流量应从 0 开始: %d", next)
+	}
+
+	stats, err := ms.ListObjectDownloads("releases", 10)
+	if err != nil {
+		t.Fatalf("列出下载统计失败: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Key != "app.zip" || stats[0].Downloads != 2 || stats[0].Bytes != 210 {
+		t.Fatalf("下载统计错误: %+v", stats)
+	}
+
+	ms.DeleteBucket("releases")
+	if stats, _ := ms.ListObjectDownloads("", 10); len(stats) != 0 {
+		t.Errorf("删除桶后应清除下载统计: %+v", stats)
+	}
+}
+
+// TestObjectCounters 测试对象计数器随写入、覆盖、删除同步更新及重建
+func TestObjectCounters(t *testing.T) {
+	ms, _, cleanup := setupStatsTest(t)
+	defer cleanup()
+
+	ms.CreateBucket("counter-a")
+	ms.CreateBucket("counter-b")
+	put := func(bucket, key string, size int64) {
+		t.Helper()
+		if err := ms.PutObject(&Object{Bucket: bucket, Key: key, Size: size, ETag: "etag", ContentType: "text/plain",
+			LastModified: time.Now(), StoragePath: "/tmp/" + key}); err != nil {
+			t.Fatalf("写入对象失败: %v", err)
+		}
+	}
+	expect := func(name string, objects int, size int64) {
+		t.Helper()
+		stats, err := ms.GetStorageStats()
+		if err != nil {
+			t.Fatalf("获取统计失败: %v", err)
+		}
+		if stats.TotalObjects != objects || stats.TotalSize != size {
+			t.Errorf("%s: got %d 个对象 %d 字节, want %d 个对象 %d 字节", name, stats.TotalObjects, stats.TotalSize, objects, size)
+		}
+	}
+
+	put("counter-a", "1.txt", 100)
+	put("counter-a", "2.txt", 200)
+	put("counter-b", "1.txt", 50)
+	expect("写入后", 3, 350)
+
+	put("counter-a", "1.txt", 10)
+	expect("覆盖后", 3, 260)
+
+	ms.DeleteObject("counter-a", "2.txt")
+	if ok, _ := ms.DeleteObjectIfMatch("counter-b", "1.txt", "etag", -1); !ok {
+		t.Fatal("条件删除失败")
+	}
+	expect("删除后", 1, 10)
+
+	stats, _ := ms.GetStorageStats()
+	for _, bs := range stats.BucketStats {
+		if bs.Name == "counter-b" && (bs.ObjectCount != 0 || bs.TotalSize != 0) {
+			t.Errorf("空桶计数应为 0: %+v", bs)
+		}
+	}
+
+	// 计数器偏差后重建
+	ms.db.Exec("UPDATE bucket_counters SET object_count = object_count + 5, total_size = total_size + 500")
+	result, err := ms.RecomputeObjectCounters()
+	if err != nil {
+		t.Fatalf("重建计数器失败: %v", err)
+	}
+	if result.TotalObjects != 1 || result.TotalSize != 10 || result.ObjectDrift != -10 || result.SizeDrift != -1000 {
+		t.Errorf("重建结果错误: %+v", result)
+	}
+	expect("重建后", 1, 10)
+
+	ms.DeleteObject("counter-a", "1.txt")
+	ms.DeleteBucket("counter-a")
+	var rows int
+	ms.db.QueryRow("SELECT COUNT(*) FROM bucket_counters WHERE bucket = 'counter-a'").Scan(&rows)
+	if rows != 0 {
+		t.Error("删除桶后应清除计数器")
+	}
+}
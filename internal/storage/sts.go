@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"crypto/subtle"
+	"time"
+)
+
+// 临时凭证有效期范围，与 AWS STS 一致
+const (
+	MinSessionDuration     = 15 * time.Minute
+	MaxSessionDuration     = 12 * time.Hour
+	DefaultSessionDuration = time.Hour
+)
+
+// temporaryAccessKeyPrefix 临时凭证 Access Key 前缀，与 AWS 临时凭证的 ASIA 前缀一致，便于在日志中区分
+const temporaryAccessKeyPrefix = "ASIA"
+
+// TemporaryCredential 临时凭证，只保存在内存缓存中，服务重启后全部失效
+// 权限限定在单个桶（"*" 表示所有桶），请求须携带 x-amz-security-token
+type TemporaryCredential struct {
+	AccessKeyID     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key"`
+	SessionToken    string    `json:"session_token"`
+	Expiration      time.Time `json:"expiration"`
+	Bucket          string    `json:"bucket"`
+	CanRead         bool      `json:"can_read"`
+	CanWrite        bool      `json:"can_write"`
+}
+
+// Expired 凭证在 now 时是否已过期
+func (c *TemporaryCredential) Expired(now time.Time) bool {
+	return !now.Before(c.Expiration)
+}
+
+// allows 检查凭证范围是否覆盖该桶的读或写
+func (c *TemporaryCredential) allows(bucket string, needWrite bool) bool {
+	if c.Bucket != "*" && c.Bucket != bucket {
+		return false
+	}
+	if needWrite {
+		return c.CanWrite
+	}
+	return c.CanRead
+}
+
+// IssueTemporaryCredential 签发临时凭证并加入缓存，同时清理已过期的凭证
+func (c *APIKeyCache) IssueTemporaryCredential(bucket string, canRead, canWrite bool, duration time.Duration) *TemporaryCredential {
+	now := time.Now().UTC()
+	cred := &TemporaryCredential{
+		AccessKeyID:     temporaryAccessKeyPrefix + generateRandomKey(16),
+		SecretAccessKey: generateRandomKey(40),
+		SessionToken:    generateRandomKey(64),
+		Expiration:      now.Add(duration).Truncate(time.Second),
+		Bucket:          bucket,
+		CanRead:         canRead,
+		CanWrite:        canWrite,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweepSessionsLocked(now)
+	c.sessions[cred.AccessKeyID] = cred
+	return cred
+}
+
+// TemporarySecretKey 校验会话令牌并返回临时凭证的 SecretKey，凭证不存在、已过期或令牌不符时返回 false
+func (c *APIKeyCache) TemporarySecretKey(accessKeyID, sessionToken string, now time.Time) (string, bool) {
+	cred := c.activeSession(accessKeyID, now)
+	if cred == nil || subtle.ConstantTimeCompare([]byte(cred.SessionToken), []byte(sessionToken)) != 1 {
+		return "", false
+	}
+	return cred.SecretAccessKey, true
+}
+
+// TemporaryCredentialExpired 临时凭证是否存在但已过期（尚未被清理）
+func (c *APIKeyCache) TemporaryCredentialExpired(accessKeyID string, now time.Time) bool {
+	c.mu.RLock()
+	cred, ok := c.sessions[accessKeyID]
+	c.mu.RUnlock()
+	return ok && cred.Expired(now)
+}
+
+// SweepTemporaryCredentials 从缓存中移除已过期的临时凭证，返回移除数量
+func (c *APIKeyCache) SweepTemporaryCredentials(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sweepSessionsLocked(now)
+}
+
+// TemporaryCredentialCount 缓存中未过期的临时凭证数量
+func (c *APIKeyCache) TemporaryCredentialCount(now time.Time) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	count := 0
+	for _, cred := range c.sessions {
+		if !cred.Expired(now) {
+			count++
+		}
+	}
+	return count
+}
+
+// activeSession 获取未过期的临时凭证
+func (c *APIKeyCache) activeSession(accessKeyID string, now time.Time) *TemporaryCredential {
+	c.mu.RLock()
+	cred, ok := c.sessions[accessKeyID]
+	c.mu.RUnlock()
+	if !ok || cred.Expired(now) {
+		return nil
+	}
+	return cred
+}
+
+// sweepSessionsLocked 移除已过期的临时凭证（需持有写锁）
+func (c *APIKeyCache) sweepSessionsLocked(now time.Time) int {
+	removed := 0
+	for id, cred := range c.sessions {
+		if cred.Expired(now) {
+			delete(c.sessions, id)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTemporaryCredentialLookup 测试临时凭证签发、会话令牌校验和权限范围
+func TestTemporaryCredentialLookup(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	cache := NewAPIKeyCache(store)
+	now := time.Now()
+
+	cred := cache.IssueTemporaryCredential("photos", true, false, time.Hour)
+	if !strings.HasPrefix(cred.AccessKeyID, "ASIA") || cred.SecretAccessKey == "" || cred.SessionToken == "" {
+		t.Fatalf("凭证字段错误: %+v", cred)
+	}
+	if secret, ok := cache.TemporarySecretKey(cred.AccessKeyID, cred.SessionToken, now); !ok || secret != cred.SecretAccessKey {
+		t.Error("正确的会话令牌应返回 SecretKey")
+	}
+	if _, ok := cache.TemporarySecretKey(cred.AccessKeyID, "wrong", now); ok {
+		t.Error("错误的会话令牌不应通过")
+	}
+	if _, ok := cache.GetSecretKey(cred.AccessKeyID); ok {
+		t.Error("临时凭证不应作为长期密钥返回")
+	}
+
+	if !cache.CheckPermission(cred.AccessKeyID, "photos", false) {
+		t.Error("应有签发桶的读权限")
+	}
+	if cache.CheckPermission(cred.AccessKeyID, "photos", true) {
+		t.Error("不应有写权限")
+	}
+	if cache.CheckPermission(cred.AccessKeyID, "other", false) {
+		t.Error("不应有其他桶的权限")
+	}
+
+	all := cache.IssueTemporaryCredential("*", false, true, time.Hour)
+	if !cache.CheckPermission(all.AccessKeyID, "any", true) || cache.CheckPermission(all.AccessKeyID, "any", false) {
+		t.Error("通配凭证权限错误")
+	}
+
+	// 重载长期密钥不影响临时凭证
+	if err := cache.Reload(); err != nil {
+		t.Fatalf("重载失败: %v", err)
+	}
+	if cache.TemporaryCredentialCount(now) != 2 {
+		t.Errorf("重载后临时凭证应保留: %d", cache.TemporaryCredentialCount(now))
+	}
+}
+
+// TestTemporaryCredentialExpiry 测试过期凭证被拒绝并在清理时移除
+func TestTemporaryCredentialExpiry(t *testing.T) {
+	store, cleanup := setupMetadataStore(t)
+	defer cleanup()
+	cache := NewAPIKeyCache(store)
+
+	cred := cache.IssueTemporaryCredential("photos", true, true, 15*time.Minute)
+	later := cred.Expiration.Add(time.Second)
+	if _, ok := cache.TemporarySecretKey(cred.AccessKeyID, cred.SessionToken, later); ok {
+		t.Error("过期凭证不应通过")
+	}
+	if !cache.TemporaryCredentialExpired(cred.AccessKeyID, later) || cache.TemporaryCredentialExpired(cred.AccessKeyID, time.Now()) {
+		t.Error("过期判断错误")
+	}
+	if cache.TemporaryCredentialCount(later) != 0 {
+		t.Error("过期凭证不应计数")
+	}
+
+	if n := cache.SweepTemporaryCredentials(later); n != 1 {
+		t.Errorf("应清理 1 个凭证，实际 %d", n)
+	}
+	if cache.TemporaryCredentialExpired(cred.AccessKeyID, later) {
+		t.Error("清理后凭证应不存在")
+	}
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// ObjectVariant 对象的派生变体（如缩放后的图片），按源对象 ETag 和变换参数缓存
+// 源对象被覆盖后 ETag 变化，旧变体不再命中，由 GC 清理
+type ObjectVariant struct {
+	Bucket      string
+	Key         string
+	SourceETag  string // 生成变体时源对象的 ETag
+	Params      string // 规范化的变换参数，如 w=200,h=200
+	StoragePath string
+	Size        int64
+	ETag        string // 变体内容的 MD5
+	ContentType string
+	CreatedAt   time.Time
+}
+
+// initObjectVariantTable 初始化对象变体缓存表
+func (m *MetadataStore) initObjectVariantTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS object_variants (
+		bucket TEXT NOT NULL,
+		key TEXT NOT NULL,
+		source_etag TEXT NOT NULL,
+		params TEXT NOT NULL,
+		storage_path TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		etag TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (bucket, key, source_etag, params)
+	)`)
+	return err
+}
+
+// GetObjectVariant 获取对象变体，不存在返回 nil
+func (m *MetadataStore) GetObjectVariant(bucket, key, sourceETag, params string) (*ObjectVariant, error) {
+	v := ObjectVariant{Bucket: bucket, Key: key, SourceETag: sourceETag, Params: params}
+	err := m.db.QueryRow(`
+		SELECT storage_path, size, etag, content_type, created_at FROM object_variants
+		WHERE bucket = ? AND key = ? AND source_etag = ? AND params = ?
+	`, bucket, key, sourceETag, params).Scan(&v.StoragePath, &v.Size, &v.ETag, &v.ContentType, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// SaveObjectVariant 保存对象变体记录
+func (m *MetadataStore) SaveObjectVariant(v *ObjectVariant) error {
+	return m.withWriteLock(func() error {
+		_, err := m.db.Exec(`
+			INSERT OR REPLACE INTO object_variants (bucket, key, source_etag, params, storage_path, size, etag, content_type, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, v.Bucket, v.Key, v.SourceETag, v.Params, v.StoragePath, v.Size, v.ETag, v.ContentType, v.CreatedAt)
+		return err
+	})
+}
+
+// ListStaleObjectVariants 列出源对象已删除或已被覆盖（ETag 不一致）的变体
+func (m *MetadataStore) ListStaleObjectVariants() ([]ObjectVariant, error) {
+	return m.queryObjectVariants(`
+		SELECT v.bucket, v.key, v.source_etag, v.params, v.storage_path, v.size, v.etag, v.content_type, v.created_at
+		FROM object_variants v
+		LEFT JOIN objects o ON o.bucket = v.bucket AND o.key = v.key
+		WHERE o.key IS NULL OR o.etag != v.source_etag
+	`)
+}
+
+// listCurrentVariantPaths 列出仍与源对象一致的变体文件路径
+func (m *MetadataStore) listCurrentVariantPaths() ([]string, error) {
+	rows, err := m.db.Query(`
+		SELECT v.storage_path FROM object_variants v
+		JOIN objects o ON o.bucket = v.bucket AND o.key = v.key AND o.etag = v.source_etag
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+func (m *MetadataStore) queryObjectVariants(query string, args ...interface{}) ([]ObjectVariant, error) {
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []ObjectVariant
+	for rows.Next() {
+		var v ObjectVariant
+		if err := rows.Scan(&v.Bucket, &v.Key, &v.SourceETag, &v.Params, &v.StoragePath,
+			&v.Size, &v.ETag, &v.ContentType, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
+
+// DeleteObjectVariants 删除变体记录（不删除文件）
+func (m *MetadataStore) DeleteObjectVariants(variants []ObjectVariant) error {
+	return m.withWriteLock(func() error {
+		for _, v := range variants {
+			if _, err := m.db.Exec(
+				"DELETE FROM object_variants WHERE bucket = ? AND key = ? AND source_etag = ? AND params = ?",
+				v.Bucket, v.Key, v.SourceETag, v.Params,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PutVariant 写入对象变体文件，返回存储路径、内容 MD5 和大小
+// 变体存放在 .variants 目录下，文件名由桶、键、源 ETag 和参数哈希得到
+func (f *FileStore) PutVariant(bucket, key, sourceETag, params string, reader io.Reader) (string, string, int64, error) {
+	if err := validateBucket(bucket); err != nil {
+		return "", "", 0, err
+	}
+	h := md5.Sum([]byte(bucket + "\x00" + key + "\x00" + sourceETag + "\x00" + params))
+	sum := hex.EncodeToString(h[:])
+	path := filepath.Join(f.basePath, ".variants", bucket, sum[0:2], sum)
+
+	etag, written, err := f.writeFileAtomic(path, func(w io.Writer) (int64, error) {
+		return io.Copy(w, reader)
+	})
+	if err != nil {
+		return "", "", 0, err
+	}
+	return path, etag, written, nil
+}
@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// NullVersionID 未启用版本控制时写入的对象的版本ID，Object.VersionID 中以空字符串表示
+const NullVersionID = "null"
+
+// ObjectVersion 对象的一个版本：当前版本保存在 objects 表，历史版本和删除标记保存在 object_versions 表
+type ObjectVersion struct {
+	Object
+	DeleteMarker bool // 删除标记，没有文件
+	IsLatest     bool // 是否为最新版本（当前版本，或对象已删除时最新的删除标记）
+}
+
+// VersionIDOrNull 返回对外展示的版本ID，未启用版本控制时写入的对象为 "null"
+func (o *Object) VersionIDOrNull() string {
+	if o.VersionID == "" {
+		return NullVersionID
+	}
+	return o.VersionID
+}
+
+// sqlExecer *sql.DB 与 *sql.Tx 共有的写入方法
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// initObjectVersionTable 初始化对象版本表
+// 启用版本控制后每个版本写入独立的文件（见 FileStore.getVersionPath），被覆盖或删除的当前版本转存到此表
+func (m *MetadataStore) initObjectVersionTable() error {
+	schemas := []string{
+		`CREATE TABLE IF NOT EXISTS object_versions (
+			bucket TEXT NOT NULL,
+			key TEXT NOT NULL,
+			version_id TEXT NOT NULL,
+			size INTEGER NOT NULL DEFAULT 0,
+			etag TEXT NOT NULL DEFAULT '',
+			content_type TEXT DEFAULT '',
+			last_modified DATETIME NOT NULL,
+			storage_path TEXT NOT NULL DEFAULT '',
+			headers TEXT DEFAULT '',
+			created_at DATETIME,
+			tags TEXT DEFAULT '',
+			delete_marker INTEGER NOT NULL DEFAULT 0,
+			archived_at DATETIME NOT NULL,
+			encryption_nonce TEXT DEFAULT '',
+			metadata TEXT DEFAULT '',
+			PRIMARY KEY (bucket, key, version_id)
+		)`,
+		// 孤立文件清理按存储路径批量核对
+		`CREATE INDEX IF NOT EXISTS idx_object_versions_storage_path ON object_versions(storage_path)`,
+	}
+	for _, schema := range schemas {
+		if _, err := m.db.Exec(schema); err != nil {
+			return err
+		}
+	}
+	// 静态加密、用户元数据之前创建的版本表
+	for _, column := range []string{"encryption_nonce", "metadata"} {
+		var exists bool
+		if err := m.db.QueryRow("SELECT COUNT(*) > 0 FROM pragma_table_info('object_versions') WHERE name = ?", column).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := m.db.Exec("ALTER TABLE object_versions ADD COLUMN " + column + " TEXT DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// insertObjectVersion 把对象作为历史版本写入版本表，null 版本以 "null" 保存
+func insertObjectVersion(exec sqlExecer, obj *Object, archivedAt time.Time) error {
+	_, err := exec.Exec(`
+		INSERT OR REPLACE INTO object_versions (bucket, key, version_id, size, etag, content_type, last_modified, storage_path,
+			headers, created_at, tags, delete_marker, archived_at, encryption_nonce, metadata)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?)`,
+		obj.Bucket, obj.Key, obj.VersionIDOrNull(), obj.Size, obj.ETag, obj.ContentType, obj.LastModified, obj.StoragePath,
+		encodeHeaders(obj.Headers), obj.CreatedAt, encodeHeaders(obj.Tags), archivedAt, obj.EncryptionNonce, encodeHeaders(obj.Metadata),
+	)
+	return err
+}
+
+// archiveCurrentLocked 写入新的当前版本前，把将被覆盖的当前版本转为历史版本（需持有写锁）
+// 只有旧版本的文件不会被新写入覆盖（存储路径不同），且旧版本带版本ID或桶已启用版本控制时才保留；
+// 写入 null 版本时替换已有的历史 null 版本，与 S3 暂停版本控制后的行为一致
+func (m *MetadataStore) archiveCurrentLocked(obj *Object) error {
+	if obj.VersionID == "" {
+		if _, err := m.db.Exec("DELETE FROM object_versions WHERE bucket = ? AND key = ? AND version_id = ?",
+			obj.Bucket, obj.Key, NullVersionID); err != nil {
+			return err
+		}
+	}
+	cur, err := m.GetObject(obj.Bucket, obj.Key)
+	if err != nil || cur == nil || cur.StoragePath == obj.StoragePath {
+		return err
+	}
+	keep := cur.VersionID != ""
+	if !keep {
+		if err := m.db.QueryRow("SELECT COALESCE(versioning_enabled, 0) FROM buckets WHERE name = ?", obj.Bucket).
+			Scan(&keep); err != nil && err != sql.ErrNoRows {
+			return err
+		}
+	}
+	if !keep {
+		return nil
+	}
+	return insertObjectVersion(m.db, cur, time.Now().UTC())
+}
+
+// PutDeleteMarker 删除启用版本控制的桶中的对象：当前版本转为历史版本，并写入版本ID为 versionID 的删除标记
+// etag 非空或 size >= 0 时要求当前版本存在且匹配，不满足时不做任何修改并返回 false
+func (m *MetadataStore) PutDeleteMarker(bucket, key, versionID, etag string, size int64) (bool, error) {
+	created := false
+	err := m.withWriteLock(func() error {
+		if m.deletedBuckets[bucket] {
+			return ErrBucketDeleted
+		}
+		cur, err := m.GetObject(bucket, key)
+		if err != nil {
+			return err
+		}
+		if etag != "" || size >= 0 {
+			if cur == nil || etag != "" && cur.ETag != etag || size >= 0 && cur.Size != size {
+				return nil
+			}
+		}
+
+		tx, err := m.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		now := time.Now().UTC()
+		if cur != nil {
+			if err := insertObjectVersion(tx, cur, now); err != nil {
+				return err
+			}
+			if _, err := tx.Exec("DELETE FROM objects WHERE bucket = ? AND key = ?", bucket, key); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO object_versions (bucket, key, version_id, last_modified, delete_marker, archived_at)
+			VALUES (?, ?, ?, ?, 1, ?)`, bucket, key, versionID, now, now); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// objectVersionColumns 版本表查询字段
+const objectVersionColumns = `bucket, key, version_id, size, etag, COALESCE(content_type, ''), last_modified, storage_path,
+	COALESCE(headers, ''), created_at, COALESCE(tags, ''), delete_marker, COALESCE(encryption_nonce, ''), COALESCE(metadata, '')`
+
+// scanObjectVersion 读取一行版本表记录，"null" 版本转换为空版本ID
+func scanObjectVersion(scan func(dest ...interface{}) error) (*ObjectVersion, error) {
+	var v ObjectVersion
+	var headers, tags, metadata string
+	var created sql.NullTime
+	if err := scan(&v.Bucket, &v.Key, &v.VersionID, &v.Size, &v.ETag, &v.ContentType, &v.LastModified, &v.StoragePath,
+		&headers, &created, &tags, &v.DeleteMarker, &v.EncryptionNonce, &metadata); err != nil {
+		return nil, err
+	}
+	if v.VersionID == NullVersionID {
+		v.VersionID = ""
+	}
+	v.Headers = decodeHeaders(headers)
+	v.Tags = decodeHeaders(tags)
+	v.Metadata = decodeHeaders(metadata)
+	v.CreatedAt = created.Time
+	return &v, nil
+}
+
+// GetObjectVersion 按版本ID获取对象版本，versionID 为 "null" 时匹配未启用版本控制时写入的版本，不存在时返回 nil
+func (m *MetadataStore) GetObjectVersion(bucket, key, versionID string) (*ObjectVersion, error) {
+	cur, err := m.GetObject(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if cur != nil && cur.VersionIDOrNull() == versionID {
+		return &ObjectVersion{Object: *cur, IsLatest: true}, nil
+	}
+	row := m.db.QueryRow("SELECT "+objectVersionColumns+" FROM object_versions WHERE bucket = ? AND key = ? AND version_id = ?",
+		bucket, key, versionID)
+	v, err := scanObjectVersion(row.Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+// ListObjectVersions 列出对象的全部版本，最新的在前
+func (m *MetadataStore) ListObjectVersions(bucket, key string) ([]ObjectVersion, error) {
+	versions := make([]ObjectVersion, 0)
+	cur, err := m.GetObject(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if cur != nil {
+		versions = append(versions, ObjectVersion{Object: *cur, IsLatest: true})
+	}
+
+	rows, err := m.db.Query("SELECT "+objectVersionColumns+" FROM object_versions WHERE bucket = ? AND key = ? ORDER BY archived_at DESC, rowid DESC",
+		bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		v, err := scanObjectVersion(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		v.IsLatest = len(versions) == 0
+		versions = append(versions, *v)
+	}
+	return versions, rows.Err()
+}
+
+// DeleteObjectVersion 永久删除对象的指定版本，返回被删除的版本（不存在时为 nil），调用方负责删除其文件
+// 删除的是最新版本（当前版本或最新的删除标记）时，最近的历史版本恢复为当前版本，该版本是删除标记时对象保持已删除
+func (m *MetadataStore) DeleteObjectVersion(bucket, key, versionID string) (*ObjectVersion, error) {
+	var removed *ObjectVersion
+	err := m.withWriteLock(func() error {
+		versions, err := m.ListObjectVersions(bucket, key)
+		if err != nil {
+			return err
+		}
+		var v *ObjectVersion
+		for i := range versions {
+			if versions[i].VersionIDOrNull() == versionID {
+				v = &versions[i]
+				break
+			}
+		}
+		if v == nil {
+			return nil
+		}
+
+		tx, err := m.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		// 版本可能是当前版本，也可能在版本表中
+		if _, err := tx.Exec("DELETE FROM objects WHERE bucket = ? AND key = ? AND COALESCE(version_id, '') = ?",
+			bucket, key, v.VersionID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM object_versions WHERE bucket = ? AND key = ? AND version_id = ?",
+			bucket, key, versionID); err != nil {
+			return err
+		}
+		// 下一个版本成为最新版本，是数据版本时恢复为当前版本
+		if v.IsLatest && len(versions) > 1 && !versions[1].DeleteMarker {
+			prev := &versions[1]
+			if _, err := tx.Exec(`
+				INSERT INTO objects (bucket, key, size, etag, content_type, last_modified, storage_path, headers, created_at, tags, version_id,
+					encryption_nonce, metadata)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				prev.Bucket, prev.Key, prev.Size, prev.ETag, prev.ContentType, prev.LastModified, prev.StoragePath,
+				encodeHeaders(prev.Headers), prev.CreatedAt, encodeHeaders(prev.Tags), prev.VersionID, prev.EncryptionNonce,
+				encodeHeaders(prev.Metadata)); err != nil {
+				return err
+			}
+			if _, err := tx.Exec("DELETE FROM object_versions WHERE bucket = ? AND key = ? AND version_id = ?",
+				bucket, key, prev.VersionIDOrNull()); err != nil {
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		removed = v
+		return nil
+	})
+	return removed, err
+}
+
+// listVersionStoragePaths 返回全部历史版本的存储路径
+func (m *MetadataStore) listVersionStoragePaths() ([]string, error) {
+	rows, err := m.db.Query("SELECT storage_path FROM object_versions WHERE delete_marker = 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
@@ -0,0 +1,29 @@
+package storage
+
+import "strings"
+
+// MaxIndexDocumentLength 索引文档名称长度上限
+const MaxIndexDocumentLength = 255
+
+// IsValidIndexDocument 检查索引文档名称，不能为空、以斜杠开头结尾或包含 ..
+func IsValidIndexDocument(name string) bool {
+	return name != "" && len(name) <= MaxIndexDocumentLength &&
+		!strings.HasPrefix(name, "/") && !strings.HasSuffix(name, "/") &&
+		!strings.Contains(name, "..")
+}
+
+// WebsiteIndexKey 目录请求（桶根目录或以 / 结尾）返回其下的索引文档键，未开启网站时原样返回
+func (b *Bucket) WebsiteIndexKey(key string) string {
+	if b == nil || b.WebsiteIndex == "" || (key != "" && !strings.HasSuffix(key, "/")) {
+		return key
+	}
+	return key + b.WebsiteIndex
+}
+
+// WebsiteFallbackKey 对象不存在时回退的索引文档键，未开启单页应用模式返回空
+func (b *Bucket) WebsiteFallbackKey() string {
+	if b == nil || !b.WebsiteSPA {
+		return ""
+	}
+	return b.WebsiteIndex
+}
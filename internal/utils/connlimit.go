@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minRateChunk 按最低速度分段写出时每段的最小字节数，避免低速率下分段过小
+const minRateChunk = 32 * 1024
+
+// connRejectedKey 超出连接上限的连接在请求 context 中的标记
+type connRejectedKey struct{}
+
+// connLimitListener 限制并发连接总数和单 IP 连接数的监听器
+// 超出上限的连接仍会被接受，但其上的请求一律返回 503 并关闭连接，
+// 客户端得到明确的 SlowDown 错误而不是连接被重置（TLS 连接同样适用）
+type connLimitListener struct {
+	net.Listener
+	maxConns int
+	maxPerIP int
+
+	mu    sync.Mutex
+	total int
+	perIP map[string]int
+}
+
+// limitedConn 计入上限的连接，关闭时释放名额
+type limitedConn struct {
+	net.Conn
+	rejected bool
+	once     sync.Once
+	release  func()
+}
+
+// LimitConnections 按连接数上限包装监听器，并设置 srv 的 ConnContext 和 Handler 以拒绝超出上限的连接
+// 单 IP 上限按 TCP 直连地址计算，反向代理之后所有连接都来自代理地址
+// 两个上限都为 0 时原样返回监听器
+func LimitConnections(srv *http.Server, ln net.Listener, maxConns, maxPerIP int) net.Listener {
+	if maxConns <= 0 && maxPerIP <= 0 {
+		return ln
+	}
+	l := &connLimitListener{Listener: ln, maxConns: maxConns, maxPerIP: maxPerIP, perIP: make(map[string]int)}
+
+	connContext := srv.ConnContext
+	srv.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		// TLS 连接包装了监听器返回的连接
+		if tc, ok := c.(interface{ NetConn() net.Conn }); ok {
+			c = tc.NetConn()
+		}
+		if lc, ok := c.(*limitedConn); ok && lc.rejected {
+			ctx = context.WithValue(ctx, connRejectedKey{}, true)
+		}
+		return ctx
+	}
+
+	next := srv.Handler
+	if next == nil {
+		next = http.DefaultServeMux
+	}
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rejected, _ := r.Context().Value(connRejectedKey{}).(bool); rejected {
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", "1")
+			WriteError(w, ErrTooManyConnections, http.StatusServiceUnavailable, r.URL.Path)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+	return l
+}
+
+// Accept 接受连接并计数，超出上限的连接标记为拒绝且不占用名额
+func (l *connLimitListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	ip := NormalizeIP(c.RemoteAddr().String())
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if (l.maxConns > 0 && l.total >= l.maxConns) || (l.maxPerIP > 0 && l.perIP[ip] >= l.maxPerIP) {
+		Debug("connection limit reached, rejecting", "ip", ip, "total", l.total)
+		return &limitedConn{Conn: c, rejected: true}, nil
+	}
+	l.total++
+	l.perIP[ip]++
+	return &limitedConn{Conn: c, release: func() { l.release(ip) }}, nil
+}
+
+// release 连接关闭后释放名额
+func (l *connLimitListener) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total--
+	if l.perIP[ip]--; l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+}
+
+// Close 关闭连接并释放名额，重复关闭只释放一次
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	if c.release != nil {
+		c.once.Do(c.release)
+	}
+	return err
+}
+
+// ReadFrom 保留底层 TCP 连接的 sendfile 优化
+func (c *limitedConn) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(c.Conn, r)
+}
+
+// CopyWithMinRate 把 src 中的 n 字节写入响应，客户端在一个时间窗口内接收不到 rate*window 字节时中止
+// 每写出一段刷新一次写超时，速度正常的长下载不受服务器 WriteTimeout 限制
+// rate 为 0 或 ResponseWriter 不支持写超时时按普通方式复制
+func CopyWithMinRate(w http.ResponseWriter, src io.Reader, n int64, rate int64, window time.Duration) (int64, error) {
+	if rate <= 0 || window <= 0 {
+		return io.CopyN(w, src, n)
+	}
+	chunk := int64(float64(rate) * window.Seconds())
+	if chunk < minRateChunk {
+		chunk = minRateChunk
+	}
+	timeout := time.Duration(float64(chunk) / float64(rate) * float64(time.Second))
+
+	rc := http.NewResponseController(w)
+	var written int64
+	for written < n {
+		if err := rc.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+			if errors.Is(err, http.ErrNotSupported) {
+				m, err := io.CopyN(w, src, n-written)
+				return written + m, err
+			}
+			return written, err
+		}
+		m, err := io.CopyN(w, src, min(chunk, n-written))
+		written += m
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
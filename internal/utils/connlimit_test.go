@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// zeroReader 无限输出零字节
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// doRawRequest 在已建立的连接上发送 GET 请求并返回状态码
+func doRawRequest(t *testing.T, conn net.Conn, br *bufio.Reader) *http.Response {
+	t.Helper()
+	if _, err := fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: test\r\n\r\n"); err != nil {
+		t.Fatalf("发送请求失败: %v", err)
+	}
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("读取响应失败: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp
+}
+
+func TestLimitConnections(t *testing.T) {
+	InitLogger("error")
+	for name, limits := range map[string][2]int{
+		"总连接数上限": {1, 0},
+		"单IP上限":  {0, 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("监听失败: %v", err)
+			}
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			})}
+			ln = LimitConnections(srv, ln, limits[0], limits[1])
+			go srv.Serve(ln)
+			defer srv.Close()
+			addr := ln.Addr().String()
+
+			first, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatalf("连接失败: %v", err)
+			}
+			if resp := doRawRequest(t, first, bufio.NewReader(first)); resp.StatusCode != http.StatusOK {
+				t.Fatalf("首个连接应成功: %d", resp.StatusCode)
+			}
+
+			second, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatalf("连接失败: %v", err)
+			}
+			resp := doRawRequest(t, second, bufio.NewReader(second))
+			second.Close()
+			if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" || !resp.Close {
+				t.Errorf("超出上限应返回 503 并关闭连接: %d %v", resp.StatusCode, resp.Header)
+			}
+
+			// 首个连接关闭后名额释放
+			first.Close()
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				conn, err := net.Dial("tcp", addr)
+				if err != nil {
+					t.Fatalf("连接失败: %v", err)
+				}
+				resp := doRawRequest(t, conn, bufio.NewReader(conn))
+				conn.Close()
+				if resp.StatusCode == http.StatusOK {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("关闭连接后名额未释放")
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		})
+	}
+
+	t.Run("未配置上限时原样返回", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("监听失败: %v", err)
+		}
+		defer ln.Close()
+		srv := &http.Server{}
+		if got := LimitConnections(srv, ln, 0, 0); got != ln || srv.Handler != nil || srv.ConnContext != nil {
+			t.Error("未配置上限时不应包装监听器")
+		}
+	})
+}
+
+func TestCopyWithMinRate(t *testing.T) {
+	t.Run("不支持写超时时普通复制", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		n, err := CopyWithMinRate(w, strings.NewReader("hello world"), 5, 1024, time.Second)
+		if err != nil || n != 5 || w.Body.String() != "hello" {
+			t.Errorf("复制结果错误: n=%d err=%v body=%q", n, err, w.Body.String())
+		}
+	})
+
+	copied := make(chan error, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := int64(1 << 20)
+		if r.URL.Query().Get("slow") != "" {
+			size = 256 << 20
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(size))
+		_, err := CopyWithMinRate(w, zeroReader{}, size, 1<<20, 100*time.Millisecond)
+		copied <- err
+	}))
+	defer ts.Close()
+
+	t.Run("正常速度的客户端", func(t *testing.T) {
+		resp, err := http.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err := <-copied; err != nil || !bytes.Equal(body, make([]byte, 1<<20)) {
+			t.Errorf("下载应完整完成: err=%v len=%d", err, len(body))
+		}
+	})
+
+	t.Run("慢速客户端被中止", func(t *testing.T) {
+		conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+		if err != nil {
+			t.Fatalf("连接失败: %v", err)
+		}
+		defer conn.Close()
+		// 发送请求后不读取响应
+		fmt.Fprint(conn, "GET /?slow=1 HTTP/1.1\r\nHost: test\r\n\r\n")
+		select {
+		case err := <-copied:
+			if !errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Errorf("应因写超时中止: %v", err)
+			}
+		case <-time.After(30 * time.Second):
+			t.Fatal("慢速客户端未被中止")
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// ContentDisposition 构造 Content-Disposition 响应头
+// filename 为 ASCII 回退名（非 ASCII、控制字符及引号替换为 _）；
+// 文件名无法用 ASCII 原样表示时追加 RFC 5987 的 filename*=UTF-8''...，浏览器据此还原原始文件名
+func ContentDisposition(dispositionType, filename string) string {
+	var fallback strings.Builder
+	for _, r := range filename {
+		if r < 0x20 || r >= 0x7f || r == '"' || r == '\\' {
+			fallback.WriteByte('_')
+		} else {
+			fallback.WriteRune(r)
+		}
+	}
+	v := dispositionType + `; filename="` + fallback.String() + `"`
+	if fallback.String() != filename {
+		v += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return v
+}
+
+// NormalizeContentDisposition 规范化客户端指定的 Content-Disposition（如 response-content-disposition）
+// 带文件名时按 ContentDisposition 重新编码，无法解析时原样返回
+func NormalizeContentDisposition(v string) string {
+	dispositionType, params, err := mime.ParseMediaType(v)
+	if err != nil {
+		return v
+	}
+	filename, ok := params["filename"]
+	if !ok {
+		return v
+	}
+	return ContentDisposition(dispositionType, filename)
+}
+
+// encodeRFC5987 按 RFC 5987 attr-char 百分号编码 UTF-8 字节
+func encodeRFC5987(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -108,8 +108,8 @@ func (s *GeoIPService) Lookup(ipStr string) *GeoIPResult {
 		return nil
 	}
 
-	// 解析 IP
-	ip, err := netip.ParseAddr(ipStr)
+	// 解析 IP，IPv4 映射地址按 IPv4 查询
+	ip, err := netip.ParseAddr(NormalizeIP(ipStr))
 	if err != nil {
 		return nil
 	}
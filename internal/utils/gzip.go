@@ -4,75 +4,228 @@ import (
 	"compress/gzip"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/andybalholm/brotli"
+
+	"sss/internal/config"
 )
 
-// gzip writer 池，减少内存分配
-var gzipPool = sync.Pool{
-	New: func() interface{} {
-		w, _ := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+// compressor 压缩 writer（gzip.Writer 与 brotli.Writer 均满足）
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressionEncoder 一种压缩算法及其 writer 池，减少内存分配
+type compressionEncoder struct {
+	name string
+	pool sync.Pool
+}
+
+// newCompressionEncoder 创建指定算法和级别的压缩器
+func newCompressionEncoder(name string, level int) *compressionEncoder {
+	e := &compressionEncoder{name: name}
+	e.pool.New = func() interface{} {
+		if name == config.CompressionBrotli {
+			return brotli.NewWriterLevel(nil, level)
+		}
+		w, err := gzip.NewWriterLevel(nil, level)
+		if err != nil {
+			w, _ = gzip.NewWriterLevel(nil, gzip.BestSpeed)
+		}
 		return w
-	},
+	}
+	return e
+}
+
+// CompressionOptions 响应压缩配置
+type CompressionOptions struct {
+	Algorithms  []string // 启用的算法（br/gzip），按服务端优先级排列，空表示不压缩
+	GzipLevel   int      // gzip 压缩级别 1-9
+	BrotliLevel int      // brotli 压缩级别 0-11
 }
 
 // gzipResponseWriter 包装 http.ResponseWriter 以支持 gzip 压缩
+// 未按路径确定压缩的响应在写出响应头时再根据响应类型决定
 type gzipResponseWriter struct {
 	http.ResponseWriter
-	gzipWriter *gzip.Writer
+	gzipWriter compressor
+	encoder    *compressionEncoder
+	decided    bool
+}
+
+// start 开始压缩输出
+func (g *gzipResponseWriter) start() {
+	g.decided = true
+	g.gzipWriter = g.encoder.pool.Get().(compressor)
+	g.gzipWriter.Reset(g.ResponseWriter)
+
+	// 设置响应头，保留已有的 Vary（如 CORS 的 Origin）
+	g.Header().Set("Content-Encoding", g.encoder.name)
+	addVary(g.Header(), "Accept-Encoding")
+	// 删除 Content-Length，因为压缩后长度会变化
+	g.Header().Del("Content-Length")
+}
+
+// decide 未声明长度的 XML 响应（如流式输出的对象列表）同样压缩，对象下载等带长度的响应原样输出
+func (g *gzipResponseWriter) decide(status int) {
+	if g.decided {
+		return
+	}
+	g.decided = true
+	h := g.Header()
+	if status == http.StatusOK && strings.HasPrefix(h.Get("Content-Type"), "application/xml") &&
+		h.Get("Content-Length") == "" && h.Get("Content-Encoding") == "" {
+		g.start()
+	}
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	g.decide(status)
+	g.ResponseWriter.WriteHeader(status)
 }
 
 func (g *gzipResponseWriter) Write(data []byte) (int, error) {
+	g.decide(http.StatusOK)
+	if g.gzipWriter == nil {
+		return g.ResponseWriter.Write(data)
+	}
 	return g.gzipWriter.Write(data)
 }
 
-// GzipMiddleware 返回一个 gzip 压缩中间件
-// 只对文本类型的响应进行压缩
-func GzipMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 检查客户端是否支持 gzip
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
-			next.ServeHTTP(w, r)
-			return
+// Flush 先冲刷压缩缓冲区，保证流式响应能及时到达客户端
+func (g *gzipResponseWriter) Flush() {
+	if g.gzipWriter != nil {
+		g.gzipWriter.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// close 结束压缩并归还 writer
+func (g *gzipResponseWriter) close() {
+	if g.gzipWriter != nil {
+		g.gzipWriter.Close()
+		g.encoder.pool.Put(g.gzipWriter)
+	}
+}
+
+// addVary 向 Vary 响应头追加字段（已存在时不重复添加）
+func addVary(h http.Header, field string) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
+
+// negotiateEncoding 按 Accept-Encoding 选择压缩算法：取客户端权重（q 值）最高的已启用算法，
+// 权重相同时按服务端优先级；客户端不接受任何已启用算法时返回空
+func negotiateEncoding(acceptEncoding string, algorithms []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if name == "*" {
+			wildcard = q
+		} else if name != "" {
+			weights[name] = q
 		}
+	}
 
-		// 检查请求路径，只对静态资源和 API 响应压缩
-		path := r.URL.Path
-		shouldCompress := strings.HasPrefix(path, "/assets/") ||
-			strings.HasSuffix(path, ".js") ||
-			strings.HasSuffix(path, ".css") ||
-			strings.HasSuffix(path, ".html") ||
-			strings.HasSuffix(path, ".json") ||
-			strings.HasSuffix(path, ".svg") ||
-			strings.HasPrefix(path, "/api/")
-
-		if !shouldCompress {
-			next.ServeHTTP(w, r)
-			return
+	best, bestQ := "", 0.0
+	for _, a := range algorithms {
+		q, ok := weights[a]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = a, q
 		}
+	}
+	return best
+}
+
+// defaultCompression 默认压缩配置：仅 gzip，最快级别
+var defaultCompression = CompressionOptions{
+	Algorithms: []string{config.CompressionGzip},
+	GzipLevel:  gzip.BestSpeed,
+}
 
-		// 从池中获取 gzip writer
-		gz := gzipPool.Get().(*gzip.Writer)
-		gz.Reset(w)
-		defer func() {
-			gz.Close()
-			gzipPool.Put(gz)
-		}()
-
-		// 设置响应头
-		w.Header().Set("Content-Encoding", "gzip")
-		w.Header().Set("Vary", "Accept-Encoding")
-		// 删除 Content-Length，因为压缩后长度会变化
-		w.Header().Del("Content-Length")
-
-		// 使用 gzip writer 包装响应
-		gzipWriter := &gzipResponseWriter{
-			ResponseWriter: w,
-			gzipWriter:     gz,
+// CompressionMiddleware 返回一个响应压缩中间件
+// 与客户端协商压缩算法，只对文本类型的响应进行压缩
+func CompressionMiddleware(opts CompressionOptions) func(http.Handler) http.Handler {
+	encoders := make(map[string]*compressionEncoder, len(opts.Algorithms))
+	for _, a := range opts.Algorithms {
+		level := opts.GzipLevel
+		if a == config.CompressionBrotli {
+			level = opts.BrotliLevel
 		}
+		encoders[a] = newCompressionEncoder(a, level)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 选择客户端支持的压缩算法
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), opts.Algorithms)
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// 检查请求路径，只对静态资源和 API 响应压缩
+			path := r.URL.Path
+			shouldCompress := strings.HasPrefix(path, "/assets/") ||
+				strings.HasSuffix(path, ".js") ||
+				strings.HasSuffix(path, ".css") ||
+				strings.HasSuffix(path, ".html") ||
+				strings.HasSuffix(path, ".json") ||
+				strings.HasSuffix(path, ".svg") ||
+				strings.HasPrefix(path, "/api/")
+
+			// 其余路径只有 GET 可能返回需要压缩的 XML 列表
+			if !shouldCompress && r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gzipWriter := &gzipResponseWriter{ResponseWriter: w, encoder: encoders[encoding]}
+			if shouldCompress {
+				gzipWriter.start()
+			}
+			defer gzipWriter.close()
 
-		next.ServeHTTP(gzipWriter, r)
-	})
+			next.ServeHTTP(gzipWriter, r)
+		})
+	}
+}
+
+// GzipMiddleware 返回一个 gzip 压缩中间件（默认配置）
+func GzipMiddleware(next http.Handler) http.Handler {
+	return CompressionMiddleware(defaultCompression)(next)
 }
 
 // GzipHandler 包装一个 http.Handler 并添加 gzip 支持
@@ -80,6 +233,12 @@ func GzipHandler(h http.Handler) http.Handler {
 	return GzipMiddleware(h)
 }
 
+// CompressHandler 包装一个 http.Handler 并按配置添加响应压缩
+func CompressHandler(h http.Handler, opts CompressionOptions) http.Handler {
+	return CompressionMiddleware(opts)(h)
+}
+
 // 确保 gzipResponseWriter 实现了必要的接口
 var _ http.ResponseWriter = (*gzipResponseWriter)(nil)
 var _ io.Writer = (*gzipResponseWriter)(nil)
+var _ http.Flusher = (*gzipResponseWriter)(nil)
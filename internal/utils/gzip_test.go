@@ -6,7 +6,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/andybalholm/brotli"
 )
 
 // TestGzipMiddleware_WithGzipSupport 测试支持 gzip 的请求
@@ -171,6 +174,64 @@ func TestGzipMiddleware_VaryHeader(t *testing.T) {
 	}
 }
 
+// TestGzipMiddleware_StreamedXML 测试按响应类型压缩流式 XML
+func TestGzipMiddleware_StreamedXML(t *testing.T) {
+	testCases := []struct {
+		name           string
+		contentLength  string
+		status         int
+		shouldCompress bool
+	}{
+		{"未声明长度的XML列表", "", http.StatusOK, true},
+		{"带长度的XML对象", "20", http.StatusOK, false},
+		{"XML错误响应", "", http.StatusNotFound, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/xml")
+				if tc.contentLength != "" {
+					w.Header().Set("Content-Length", tc.contentLength)
+				}
+				w.WriteHeader(tc.status)
+				w.Write([]byte("<ListBucketResult>"))
+				w.(http.Flusher).Flush()
+				w.Write([]byte("</ListBucketResult>"))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/bucket", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+			GzipMiddleware(handler).ServeHTTP(rec, req)
+
+			if !tc.shouldCompress {
+				if rec.Header().Get("Content-Encoding") == "gzip" {
+					t.Error("不应该压缩该响应")
+				}
+				if rec.Body.String() != "<ListBucketResult></ListBucketResult>" {
+					t.Errorf("内容不匹配: %q", rec.Body.String())
+				}
+				return
+			}
+			if rec.Header().Get("Content-Encoding") != "gzip" {
+				t.Fatalf("期望 Content-Encoding: gzip, 实际: %s", rec.Header().Get("Content-Encoding"))
+			}
+			if !rec.Flushed {
+				t.Error("Flush 应该传递到底层 ResponseWriter")
+			}
+			reader, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("创建 gzip reader 失败: %v", err)
+			}
+			decompressed, _ := io.ReadAll(reader)
+			if string(decompressed) != "<ListBucketResult></ListBucketResult>" {
+				t.Errorf("解压内容不匹配: %q", decompressed)
+			}
+		})
+	}
+}
+
 // BenchmarkGzipMiddleware 基准测试 gzip 中间件
 func BenchmarkGzipMiddleware(b *testing.B) {
 	testContent := bytes.Repeat([]byte("benchmark test content "), 100)
@@ -189,3 +250,74 @@ func BenchmarkGzipMiddleware(b *testing.B) {
 		wrapped.ServeHTTP(rec, req)
 	}
 }
+
+// TestCompressionMiddleware_Brotli 测试按 Accept-Encoding 协商 brotli/gzip
+func TestCompressionMiddleware_Brotli(t *testing.T) {
+	testContent := strings.Repeat("brotli compression test content. ", 20)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		w.Write([]byte(testContent))
+	})
+	wrapped := CompressHandler(handler, CompressionOptions{
+		Algorithms:  []string{"br", "gzip"},
+		GzipLevel:   9,
+		BrotliLevel: 11,
+	})
+
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"gzip, deflate, br", "br"},
+		{"gzip", "gzip"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"*", "br"},
+		{"deflate", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+		req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		rec := httptest.NewRecorder()
+		wrapped.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != tt.want {
+			t.Errorf("%q: Content-Encoding got %q, want %q", tt.acceptEncoding, got, tt.want)
+			continue
+		}
+		var body []byte
+		switch tt.want {
+		case "br":
+			body, _ = io.ReadAll(brotli.NewReader(rec.Body))
+		case "gzip":
+			reader, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("创建 gzip reader 失败: %v", err)
+			}
+			body, _ = io.ReadAll(reader)
+		default:
+			body = rec.Body.Bytes()
+		}
+		if string(body) != testContent {
+			t.Errorf("%q: 内容不匹配", tt.acceptEncoding)
+		}
+		if tt.want != "" && strings.Join(rec.Header().Values("Vary"), ",") != "Accept-Encoding,Origin" {
+			t.Errorf("%q: Vary 应同时包含 Accept-Encoding 和 Origin: %v", tt.acceptEncoding, rec.Header().Values("Vary"))
+		}
+	}
+}
+
+// TestCompressionMiddleware_Disabled 测试关闭压缩
+func TestCompressionMiddleware_Disabled(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("plain"))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	rec := httptest.NewRecorder()
+	CompressHandler(handler, CompressionOptions{}).ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != "plain" {
+		t.Errorf("关闭压缩后不应压缩响应: %q", rec.Header().Get("Content-Encoding"))
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 	"sync"
 )
@@ -73,6 +74,7 @@ func ReloadTrustedProxies(proxyCIDRs string) {
 
 		// 如果没有 /，假设是单个 IP，添加 /32 或 /128
 		if !strings.Contains(part, "/") {
+			part = NormalizeIP(part)
 			ip := net.ParseIP(part)
 			if ip == nil {
 				continue
@@ -93,6 +95,27 @@ func ReloadTrustedProxies(proxyCIDRs string) {
 	}
 }
 
+// NormalizeIP 解析 IP 并返回规范形式，无效时返回空串
+// 接受 IPv4、IPv6、带方括号的 IPv6 以及带端口的 host:port；
+// 去掉 IPv6 zone（如 %eth0），IPv4 映射地址 ::ffff:a.b.c.d 转为 IPv4，保证双栈监听时同一客户端只有一种表示
+func NormalizeIP(s string) string {
+	s = strings.TrimSpace(s)
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		ap, perr := netip.ParseAddrPort(s)
+		if perr == nil {
+			addr = ap.Addr()
+		} else if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+			if addr, err = netip.ParseAddr(s[1 : len(s)-1]); err != nil {
+				return ""
+			}
+		} else {
+			return ""
+		}
+	}
+	return addr.WithZone("").Unmap().String()
+}
+
 // IsTrustedProxy 检查 IP 是否是信任的代理
 func IsTrustedProxy(ipStr string) bool {
 	trustedProxyCache.mu.RLock()
@@ -103,7 +126,7 @@ func IsTrustedProxy(ipStr string) bool {
 		return false
 	}
 
-	ip := net.ParseIP(ipStr)
+	ip := net.ParseIP(NormalizeIP(ipStr))
 	if ip == nil {
 		return false
 	}
@@ -120,8 +143,8 @@ func IsTrustedProxy(ipStr string) bool {
 // GetDirectIP 获取直连 IP（RemoteAddr）
 // 这是 TCP 连接的真实 IP，不受代理头影响
 func GetDirectIP(r *http.Request) string {
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
+	ip := NormalizeIP(r.RemoteAddr)
+	if ip == "" {
 		ip = r.RemoteAddr
 	}
 
@@ -147,21 +170,16 @@ func GetForwardedIP(r *http.Request) string {
 	}
 
 	for _, header := range headers {
-		if ip := r.Header.Get(header); ip != "" {
-			if parsedIP := net.ParseIP(strings.TrimSpace(ip)); parsedIP != nil {
-				return parsedIP.String()
-			}
+		if ip := NormalizeIP(r.Header.Get(header)); ip != "" {
+			return ip
 		}
 	}
 
-	// 检查 X-Forwarded-For（可能包含多个 IP，取第一个）
+	// 检查 X-Forwarded-For（可能包含多个 IP，取第一个，部分代理会附带端口或方括号）
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			ip := strings.TrimSpace(ips[0])
-			if parsedIP := net.ParseIP(ip); parsedIP != nil {
-				return parsedIP.String()
-			}
+		first, _, _ := strings.Cut(xff, ",")
+		if ip := NormalizeIP(first); ip != "" {
+			return ip
 		}
 	}
 
@@ -204,7 +222,7 @@ func GetUserAgent(r *http.Request) string {
 
 // IsPrivateIP 判断是否为内网 IP
 func IsPrivateIP(ipStr string) bool {
-	ip := net.ParseIP(ipStr)
+	ip := net.ParseIP(NormalizeIP(ipStr))
 	if ip == nil {
 		return false
 	}
@@ -13,6 +13,11 @@ type S3Error struct {
 	Message   string   `xml:"Message"`
 	Resource  string   `xml:"Resource"`
 	RequestID string   `xml:"RequestId"`
+
+	// 预签名 URL 过期时附带的信息
+	XAmzExpires string `xml:"X-Amz-Expires,omitempty"`
+	Expires     string `xml:"Expires,omitempty"`
+	ServerTime  string `xml:"ServerTime,omitempty"`
 }
 
 // 预定义错误
@@ -30,14 +35,62 @@ var (
 	ErrInternalError        = S3Error{Code: "InternalError", Message: "We encountered an internal error. Please try again."}
 	ErrMethodNotAllowed     = S3Error{Code: "MethodNotAllowed", Message: "The specified method is not allowed against this resource"}
 	ErrMalformedJSON        = S3Error{Code: "MalformedJSON", Message: "The JSON provided was not well-formed"}
+	ErrMalformedXML         = S3Error{Code: "MalformedXML", Message: "The XML you provided was not well-formed or did not validate against our published schema"}
 	ErrEntityTooLarge      = S3Error{Code: "EntityTooLarge", Message: "Your proposed upload exceeds the maximum allowed size"}
 	ErrBadDigest           = S3Error{Code: "BadDigest", Message: "The Content-MD5 you specified did not match what we received"}
+	ErrInvalidDigest       = S3Error{Code: "InvalidDigest", Message: "The Content-MD5 you specified is not valid"}
+	ErrInvalidBucketName   = S3Error{Code: "InvalidBucketName", Message: "The specified bucket is not valid"}
+	ErrTooManyBuckets      = S3Error{Code: "TooManyBuckets", Message: "You have attempted to create more buckets than allowed"}
+	ErrRequestExpired      = S3Error{Code: "AccessDenied", Message: "Request has expired"}
+	ErrExpiredToken        = S3Error{Code: "ExpiredToken", Message: "The provided token has expired."}
+	ErrContentTypeNotAllowed = S3Error{Code: "InvalidArgument", Message: "The content type is not allowed in this bucket"}
+	ErrKeyNotAllowed         = S3Error{Code: "InvalidArgument", Message: "The object key is reserved in this bucket"}
+	ErrPreconditionFailed    = S3Error{Code: "PreconditionFailed", Message: "At least one of the pre-conditions you specified did not hold"}
+	ErrObjectImmutable       = S3Error{Code: "AccessDenied", Message: "The object is within the bucket's immutability window"}
+	ErrBucketReadOnly        = S3Error{Code: "AccessDenied", Message: "The bucket is read-only; writes and deletes are disabled"}
+	ErrObjectReadAgeExceeded = S3Error{Code: "ObjectReadAgeExceeded", Message: "The object is older than the bucket's maximum read age"}
+	ErrAnonymousBandwidth    = S3Error{Code: "SlowDown", Message: "Daily anonymous download bandwidth exceeded, retry after the reset or use authenticated access"}
+	ErrIdempotencyConflict   = S3Error{Code: "IdempotencyKeyConflict", Message: "The idempotency key was already used for a different object or request body"}
+	ErrNoSuchVersion         = S3Error{Code: "NoSuchVersion", Message: "The specified version does not exist"}
+	ErrTooManyUploadParts    = S3Error{Code: "TooManyParts", Message: "The upload already holds the maximum number of parts, complete or abort it first"}
+	ErrInsufficientStorage   = S3Error{Code: "InsufficientStorage", Message: "Not enough free disk space on the server to store the data"}
+	ErrQuotaExceeded         = S3Error{Code: "InsufficientStorage", Message: "The write would exceed the storage quota of the bucket"}
+	ErrPresignLimit          = S3Error{Code: "SlowDown", Message: "Presigned URL limit for this access key exceeded, retry after the window resets"}
+	ErrTooManyUploads        = S3Error{Code: "SlowDown", Message: "The bucket has too many incomplete multipart uploads, complete or abort some first"}
+	ErrTooManyConnections    = S3Error{Code: "SlowDown", Message: "Too many concurrent connections, please retry later"}
+	ErrOriginUnavailable     = S3Error{Code: "ServiceUnavailable", Message: "The object could not be fetched from the bucket's origin, please retry"}
+	ErrMalformedACL          = S3Error{Code: "MalformedACLError", Message: "The XML you provided was not well-formed or did not validate against our published schema"}
+	ErrUnsupportedACL        = S3Error{Code: "NotImplemented", Message: "Only the private and public-read canned ACLs, AllUsers READ and READ/WRITE/FULL_CONTROL grants to API keys are supported"}
+	ErrUnknownGrantee        = S3Error{Code: "InvalidArgument", Message: "The grantee is not a known API key"}
+	ErrLeadingSlashKey       = S3Error{Code: "InvalidArgument", Message: "Object keys must not start with a slash"}
+	ErrInvalidRange          = S3Error{Code: "InvalidRange", Message: "The requested range is not satisfiable"}
+	ErrInvalidExpiresAt      = S3Error{Code: "InvalidArgument", Message: "x-amz-expires-at must be a future RFC 3339 or HTTP date"}
+	ErrMetadataTooLarge      = S3Error{Code: "MetadataTooLarge", Message: "Your metadata headers exceed the maximum allowed metadata size"}
+	ErrForceDeleteConfirm    = S3Error{Code: "InvalidArgument", Message: "Force delete requires confirm to equal the bucket name"}
+	ErrFolderMarkerNotEmpty  = S3Error{Code: "InvalidArgument", Message: "Keys ending in a slash are folder placeholders and must be empty"}
+	ErrUnsupportedIfNoneMatch = S3Error{Code: "NotImplemented", Message: "If-None-Match only supports * on writes"}
+	ErrInvalidTag             = S3Error{Code: "InvalidTag", Message: "The tag provided was not a valid tag"}
+	ErrUnsupportedTagging     = S3Error{Code: "NotImplemented", Message: "Bucket tagging is not supported, tag objects instead"}
+	ErrCopySourceDeleteMarker = S3Error{Code: "InvalidRequest", Message: "The source of a copy request may not specifically refer to a delete marker by version id"}
+	ErrMalformedPOSTRequest   = S3Error{Code: "MalformedPOSTRequest", Message: "The body of your POST request is not well-formed multipart/form-data"}
+	ErrInvalidPolicyDocument  = S3Error{Code: "InvalidPolicyDocument", Message: "The content of the form does not meet the conditions specified in the policy document"}
+	ErrEntityTooSmall         = S3Error{Code: "EntityTooSmall", Message: "Your proposed upload is smaller than the minimum allowed object size"}
+	ErrMalformedPolicy        = S3Error{Code: "MalformedPolicy", Message: "Policies must be valid JSON and the first byte must be '{'"}
+	ErrNoSuchBucketPolicy     = S3Error{Code: "NoSuchBucketPolicy", Message: "The bucket policy does not exist"}
+	ErrNoSuchLifecycle        = S3Error{Code: "NoSuchLifecycleConfiguration", Message: "The lifecycle configuration does not exist"}
+	ErrContentSHA256Mismatch  = S3Error{Code: "XAmzContentSHA256Mismatch", Message: "The provided 'x-amz-content-sha256' header does not match what was computed"}
+	ErrUnsupportedLifecycle   = S3Error{Code: "NotImplemented", Message: "Only prefix filters and Expiration Days are supported in lifecycle rules"}
+	ErrUnsupportedStreaming   = S3Error{Code: "NotImplemented", Message: "Only STREAMING-AWS4-HMAC-SHA256-PAYLOAD and STREAMING-UNSIGNED-PAYLOAD-TRAILER aws-chunked uploads are supported"}
+	ErrIncompleteBody         = S3Error{Code: "IncompleteBody", Message: "The aws-chunked request body is malformed or does not match x-amz-decoded-content-length"}
 )
 
 // WriteError 写入错误响应
 func WriteError(w http.ResponseWriter, err S3Error, statusCode int, resource string) {
 	err.Resource = resource
-	err.RequestID = GenerateRequestID()
+	// 与响应头中的 x-amz-request-id 保持一致
+	if err.RequestID = w.Header().Get("x-amz-request-id"); err.RequestID == "" {
+		err.RequestID = GenerateRequestID()
+	}
 
 	w.Header().Set("Content-Type", "application/xml")
 	w.WriteHeader(statusCode)
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+)
+
+// requestIDsKey 请求 ID 的上下文键
+type requestIDsKey struct{}
+
+// requestIDs 服务端生成的请求 ID 与客户端传入的追踪 ID
+type requestIDs struct {
+	requestID string
+	traceID   string
+}
+
+// maxTraceIDLength 追踪 ID 最大长度，超出部分截断
+const maxTraceIDLength = 128
+
+// WithRequestIDs 将请求 ID 和追踪 ID 写入请求上下文
+func WithRequestIDs(r *http.Request, requestID, traceID string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestIDsKey{}, requestIDs{requestID: requestID, traceID: traceID}))
+}
+
+// GetRequestID 获取服务端生成的请求 ID（x-amz-request-id）
+func GetRequestID(r *http.Request) string {
+	ids, _ := r.Context().Value(requestIDsKey{}).(requestIDs)
+	return ids.requestID
+}
+
+// GetTraceID 获取客户端传入的追踪 ID
+func GetTraceID(r *http.Request) string {
+	ids, _ := r.Context().Value(requestIDsKey{}).(requestIDs)
+	return ids.traceID
+}
+
+// SanitizeTraceID 清理客户端传入的追踪 ID：仅保留可见 ASCII 字符并限制长度，防止日志注入
+func SanitizeTraceID(v string) string {
+	buf := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(buf) < maxTraceIDLength; i++ {
+		if c := v[i]; c > ' ' && c < 0x7f {
+			buf = append(buf, c)
+		}
+	}
+	return string(buf)
+}
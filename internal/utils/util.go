@@ -3,6 +3,7 @@ package utils
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"net"
 	"time"
 )
 
@@ -20,3 +21,25 @@ func GenerateID(length int) string {
 	}
 	return hex.EncodeToString(b)
 }
+
+// IsValidBucketName 按 S3 规则校验桶名：3-63 位小写字母、数字、点和连字符，
+// 首尾必须是字母或数字，且不能是 IP 地址格式
+func IsValidBucketName(name string) bool {
+	if len(name) < 3 || len(name) > 63 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+		if !isAlnum && c != '.' && c != '-' {
+			return false
+		}
+		if (i == 0 || i == len(name)-1) && !isAlnum {
+			return false
+		}
+		if c == '.' && (name[i-1] == '.' || name[i-1] == '-' || name[i+1] == '-') {
+			return false
+		}
+	}
+	return net.ParseIP(name) == nil
+}
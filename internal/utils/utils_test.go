@@ -144,6 +144,42 @@ func TestGetClientIP(t *testing.T) {
 			remoteAddr: "192.168.1.50",
 			want:       "192.168.1.50",
 		},
+		{
+			name:       "双栈监听下的 IPv4 映射地址",
+			headers:    nil,
+			remoteAddr: "[::ffff:192.168.1.100]:12345",
+			want:       "192.168.1.100",
+		},
+		{
+			name: "IPv4 映射的信任代理",
+			headers: map[string]string{
+				"X-Forwarded-For": "203.0.113.5",
+			},
+			remoteAddr: "[::ffff:10.0.0.1]:12345",
+			want:       "203.0.113.5",
+		},
+		{
+			name: "X-Forwarded-For 带方括号和端口的 IPv6",
+			headers: map[string]string{
+				"X-Forwarded-For": "[2001:db8::5]:443, 10.0.0.2",
+			},
+			remoteAddr: "10.0.0.1:12345",
+			want:       "2001:db8::5",
+		},
+		{
+			name: "X-Forwarded-For 带端口的 IPv4",
+			headers: map[string]string{
+				"X-Forwarded-For": "203.0.113.7:51234",
+			},
+			remoteAddr: "10.0.0.1:12345",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "未信任的 IPv6 直连忽略代理头",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.5"},
+			remoteAddr: "[2001:db8::9]:12345",
+			want:       "2001:db8::9",
+		},
 		{
 			name: "头部值带空格",
 			headers: map[string]string{
@@ -170,6 +206,52 @@ func TestGetClientIP(t *testing.T) {
 	}
 }
 
+// TestGetClientIPv6Proxy 测试 IPv6 信任代理
+func TestGetClientIPv6Proxy(t *testing.T) {
+	ReloadTrustedProxies("2606:4700::/32,[2001:db8::1]")
+	defer ReloadTrustedProxies("")
+
+	tests := []struct {
+		remoteAddr string
+		xff        string
+		want       string
+	}{
+		{"[2606:4700:10::6816:1]:443", "2001:db8:abcd::7", "2001:db8:abcd::7"},
+		{"[2001:db8::1]:8443", "198.51.100.9", "198.51.100.9"},
+		{"[2001:db8::2]:8443", "198.51.100.9", "2001:db8::2"},
+		{"[fe80::1%eth0]:8080", "198.51.100.9", "fe80::1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("X-Forwarded-For", tt.xff)
+		if got := GetClientIP(req); got != tt.want {
+			t.Errorf("GetClientIP(%s, %s) = %v, want %v", tt.remoteAddr, tt.xff, got, tt.want)
+		}
+	}
+}
+
+// TestNormalizeIP 测试 IP 规范化
+func TestNormalizeIP(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.1":        "203.0.113.1",
+		" 203.0.113.1:80 ":   "203.0.113.1",
+		"2001:DB8::1":        "2001:db8::1",
+		"[2001:db8::1]":      "2001:db8::1",
+		"[2001:db8::1]:443":  "2001:db8::1",
+		"::ffff:203.0.113.1": "203.0.113.1",
+		"fe80::1%eth0":       "fe80::1",
+		"not-an-ip":          "",
+		"[2001:db8::1":       "",
+		"":                   "",
+	}
+	for in, want := range tests {
+		if got := NormalizeIP(in); got != want {
+			t.Errorf("NormalizeIP(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
 // TestGetUserAgent 测试获取 User-Agent
 func TestGetUserAgent(t *testing.T) {
 	tests := []struct {
@@ -529,6 +611,59 @@ func TestGenerateRequestID(t *testing.T) {
 	})
 }
 
+// TestSanitizeTraceID 测试追踪 ID 清理
+func TestSanitizeTraceID(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"abc-123", "abc-123"},
+		{"  trace id\r\nforged=1", "traceidforged=1"},
+		{"中文id", "id"},
+		{strings.Repeat("a", 200), strings.Repeat("a", 128)},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := SanitizeTraceID(tt.input); got != tt.want {
+			t.Errorf("SanitizeTraceID(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	r := WithRequestIDs(httptest.NewRequest(http.MethodGet, "/", nil), "rid", "tid")
+	if GetRequestID(r) != "rid" || GetTraceID(r) != "tid" {
+		t.Errorf("上下文中的 ID 错误: %q %q", GetRequestID(r), GetTraceID(r))
+	}
+	if plain := httptest.NewRequest(http.MethodGet, "/", nil); GetRequestID(plain) != "" || GetTraceID(plain) != "" {
+		t.Error("未设置时应返回空")
+	}
+}
+
+// TestIsValidBucketName 测试桶名校验
+func TestIsValidBucketName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"my-bucket", true},
+		{"bucket.v2", true},
+		{"abc", true},
+		{"ab", false},
+		{"My-Bucket", false},
+		{"bucket_name", false},
+		{"-bucket", false},
+		{"bucket-", false},
+		{"bucket..name", false},
+		{"bucket.-name", false},
+		{"192.168.1.1", false},
+		{strings.Repeat("a", 64), false},
+	}
+	for _, tt := range tests {
+		if got := IsValidBucketName(tt.name); got != tt.want {
+			t.Errorf("IsValidBucketName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 // TestParseJSONBody 测试解析 JSON 请求体
 func TestParseJSONBody(t *testing.T) {
 	type TestData struct {
@@ -1061,3 +1196,28 @@ func TestGeoIPClose(t *testing.T) {
 		t.Error("Close 后服务应该被禁用")
 	}
 }
+
+// TestContentDisposition 测试 Content-Disposition 文件名编码
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"report.pdf", `attachment; filename="report.pdf"`},
+		{"中文.txt", `attachment; filename="__.txt"; filename*=UTF-8''%E4%B8%AD%E6%96%87.txt`},
+		{`a "b".txt`, `attachment; filename="a _b_.txt"; filename*=UTF-8''a%20%22b%22.txt`},
+	}
+	for _, tt := range tests {
+		if got := ContentDisposition("attachment", tt.filename); got != tt.want {
+			t.Errorf("ContentDisposition(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+
+	// 客户端指定的值按同样规则重新编码，可解析 filename*
+	if got := NormalizeContentDisposition(`inline; filename*=UTF-8''%E4%B8%AD%E6%96%87.txt`); got != `inline; filename="__.txt"; filename*=UTF-8''%E4%B8%AD%E6%96%87.txt` {
+		t.Errorf("NormalizeContentDisposition 错误: %q", got)
+	}
+	if got := NormalizeContentDisposition("attachment"); got != "attachment" {
+		t.Errorf("无文件名时应原样返回: %q", got)
+	}
+}
@@ -7,6 +7,7 @@ export interface BucketStat {
   object_count: number
   total_size: number
   is_public: boolean
+  incomplete_uploads: number
 }
 
 export interface TypeStat {
@@ -22,18 +23,32 @@ export interface StorageStats {
   total_size: number
   bucket_stats: BucketStat[]
   type_stats: TypeStat[]
+  incomplete_uploads: number
+  incomplete_parts: number
+  incomplete_size: number
+}
+
+// 事件通知投递统计
+export interface NotificationStats {
+  pending: number
+  delivered: number
+  failed: number
+  lastError?: string
+  lastErrorAt?: string
 }
 
 export interface StatsResponse {
   stats: StorageStats
   disk_usage: number
   disk_file_count: number
+  notifications: NotificationStats
 }
 
 export interface RecentObject {
   key: string
   size: number
   last_modified: string
+  created_at: string
   etag: string
 }
 
@@ -60,6 +75,71 @@ export async function getStorageStats(): Promise<StatsResponse> {
   return resp.data
 }
 
+// 重建计数器结果，drift 为重建后与重建前的差值
+export interface CounterRecomputeResult {
+  total_objects: number
+  total_size: number
+  object_drift: number
+  size_drift: number
+}
+
+// 按元数据全量重建对象数/总大小计数器（统计出现偏差时使用）
+export async function recomputeStorageStats(): Promise<CounterRecomputeResult> {
+  const resp = await axios.post(`${getBaseUrl()}/api/admin/stats/recompute`, {}, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 按桶请求统计
+export interface BucketMetrics {
+  bucket: string
+  requests: number
+  errors: number
+  server_errors: number
+  bytes_in: number
+  bytes_out: number
+  error_rate: number
+}
+
+export interface BucketMetricsResponse {
+  since: string
+  max_buckets: number
+  buckets: BucketMetrics[]
+}
+
+// 获取按桶请求统计
+export async function getBucketMetrics(): Promise<BucketMetricsResponse> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/stats/buckets`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 对象匿名下载统计
+export interface ObjectDownloadStat {
+  bucket: string
+  key: string
+  downloads: number
+  bytes: number
+  last_download_at: string
+}
+
+export interface DownloadStatsResponse {
+  today_anonymous_bytes: number
+  daily_limit: number
+  objects: ObjectDownloadStat[]
+}
+
+// 获取匿名下载统计（按下载次数降序）
+export async function getDownloadStats(bucket = '', limit = 50): Promise<DownloadStatsResponse> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/stats/downloads`, {
+    headers: getAdminHeaders(),
+    params: { bucket, limit }
+  })
+  return resp.data
+}
+
 // 获取最近上传的对象
 export async function getRecentObjects(limit = 10): Promise<RecentObject[]> {
   const resp = await axios.get(`${getBaseUrl()}/api/admin/stats/recent`, {
@@ -84,10 +164,52 @@ export interface GCResult {
   expired_uploads: string[]
   expired_count: number
   expired_part_size: number
+  stale_variants: number
+  stale_checksums: number
   cleaned: boolean
   cleaned_at: string | null
 }
 
+// 孤立文件清理结果
+export interface OrphanSweepResult {
+  dry_run: boolean
+  cursor: string
+  next_cursor: string
+  done: boolean
+  scanned: number
+  orphan_count: number
+  orphan_size: number
+  deleted: number
+  orphans: OrphanFile[]
+}
+
+// 孤立文件清理参数
+export interface OrphanSweepOptions {
+  limit?: number
+  batch_size?: number
+  rate?: number
+  min_age_minutes?: number
+  restart?: boolean
+  dry_run?: boolean
+}
+
+// 预览孤立文件（从已保存的游标开始，不删除）
+export async function scanOrphans(limit = 10000, minAgeMinutes = 60): Promise<OrphanSweepResult> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/storage/orphans`, {
+    headers: getAdminHeaders(),
+    params: { limit, min_age: minAgeMinutes }
+  })
+  return resp.data
+}
+
+// 清理一批孤立文件并推进游标
+export async function sweepOrphans(options: OrphanSweepOptions = {}): Promise<OrphanSweepResult> {
+  const resp = await axios.post(`${getBaseUrl()}/api/admin/storage/orphans`, options, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
 // 扫描垃圾（预览模式）
 export async function scanGC(maxUploadAge = 24): Promise<GCResult> {
   const resp = await axios.get(`${getBaseUrl()}/api/admin/storage/gc`, {
@@ -133,27 +255,146 @@ export interface IntegrityResult {
   repaired_count: number
 }
 
+// 完整性检查的并发与限速参数
+export interface IntegrityTuning {
+  workers?: number    // 并行 worker 数
+  buffer_kb?: number  // 每个 worker 的读缓冲（KB）
+  rate?: number       // 每秒最多检查的文件数，0 表示不限
+}
+
+// 完整性检查进度
+export interface IntegrityProgress {
+  running: boolean
+  total: number
+  scanned: number
+  started_at: string
+}
+
 // 扫描完整性问题
-export async function checkIntegrity(verifyEtag = false, limit = 1000): Promise<IntegrityResult> {
+export async function checkIntegrity(verifyEtag = false, limit = 1000, tuning: IntegrityTuning = {}): Promise<IntegrityResult> {
   const resp = await axios.get(`${getBaseUrl()}/api/admin/storage/integrity`, {
     headers: getAdminHeaders(),
-    params: { verify_etag: verifyEtag, limit }
+    params: { verify_etag: verifyEtag, limit, ...tuning }
+  })
+  return resp.data
+}
+
+// 获取完整性检查进度
+export async function getIntegrityProgress(): Promise<IntegrityProgress> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/storage/integrity/progress`, {
+    headers: getAdminHeaders()
   })
   return resp.data
 }
 
 // 修复完整性问题
-export async function repairIntegrity(issues?: IntegrityIssue[], verifyEtag = false, limit = 1000): Promise<IntegrityResult> {
+export async function repairIntegrity(issues?: IntegrityIssue[], verifyEtag = false, limit = 1000, tuning: IntegrityTuning = {}): Promise<IntegrityResult> {
   const resp = await axios.post(`${getBaseUrl()}/api/admin/storage/integrity`, {
     issues: issues || [],
     verify_etag: verifyEtag,
-    limit
+    limit,
+    ...tuning
   }, {
     headers: getAdminHeaders()
   })
   return resp.data
 }
 
+// 生命周期过期一轮扫描的统计
+export interface LifecycleRunStats {
+  started_at: string
+  finished_at: string
+  buckets: number
+  expired: number
+  deleted: number
+  deleted_bytes: number
+  skipped: number
+  deferred: boolean
+  error?: string
+}
+
+// 生命周期过期服务状态
+export interface LifecycleStatus {
+  interval_minutes: number
+  running: boolean
+  last_run: LifecycleRunStats | null
+}
+
+// 获取生命周期过期服务状态和最近一轮统计
+export async function getLifecycleStatus(): Promise<LifecycleStatus> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/storage/lifecycle`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 立即执行一轮生命周期过期扫描
+export async function runLifecycle(): Promise<LifecycleRunStats> {
+  const resp = await axios.post(`${getBaseUrl()}/api/admin/storage/lifecycle`, {}, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// ETag 不一致记录
+export interface EtagCorrection {
+  bucket: string
+  key: string
+  stored: string
+  actual: string
+  fixed: boolean
+}
+
+// ETag 重算任务状态
+export interface EtagRederiveStatus {
+  running: boolean
+  fix: boolean
+  cursor: string
+  done: boolean
+  cancelled: boolean
+  scanned: number
+  mismatched: number
+  fixed: number
+  missing: number
+  failed: number
+  corrections: EtagCorrection[]
+  started_at?: string
+  finished_at?: string
+  error?: string
+}
+
+// ETag 重算参数
+export interface EtagRederiveOptions {
+  fix?: boolean
+  rate?: number       // 每秒最多校验的对象数，0 表示不限
+  buffer_kb?: number
+  restart?: boolean   // 忽略已保存的游标，从头开始
+}
+
+// 获取 ETag 重算任务状态
+export async function getEtagRederiveStatus(): Promise<EtagRederiveStatus> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/storage/etags`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 启动 ETag 重算任务（默认从上次中断处继续）
+export async function startEtagRederive(options: EtagRederiveOptions = {}): Promise<EtagRederiveStatus> {
+  const resp = await axios.post(`${getBaseUrl()}/api/admin/storage/etags`, options, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 取消 ETag 重算任务
+export async function cancelEtagRederive(): Promise<EtagRederiveStatus> {
+  const resp = await axios.delete(`${getBaseUrl()}/api/admin/storage/etags`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
 // 批量删除结果
 export interface BatchDeleteResult {
   deleted_count: number
@@ -171,10 +412,46 @@ export async function batchDeleteObjects(bucket: string, keys: string[]): Promis
   return resp.data
 }
 
+// 批量标签操作结果
+export interface BatchTagResult {
+  updated_count: number
+  failed_count: number
+  failed_keys: string[]
+}
+
+// 批量设置对象标签（与已有标签合并），keys 与 prefix 二选一
+export async function batchTagObjects(bucket: string, target: { keys?: string[]; prefix?: string }, tags: Record<string, string>): Promise<BatchTagResult> {
+  const resp = await axios.post(`${getBaseUrl()}/api/admin/buckets/${bucket}/batch/tag`, {
+    ...target,
+    tags
+  }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 批量移除对象标签，tagKeys 为空时清除全部标签
+export async function batchUntagObjects(bucket: string, target: { keys?: string[]; prefix?: string }, tagKeys: string[] = []): Promise<BatchTagResult> {
+  const resp = await axios.post(`${getBaseUrl()}/api/admin/buckets/${bucket}/batch/untag`, {
+    ...target,
+    tag_keys: tagKeys
+  }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 批量下载条目命名选项
+export interface BatchDownloadOptions {
+  preservePaths?: boolean
+  stripPrefix?: string
+}
+
 // 批量下载对象（返回 ZIP 文件）
-export async function batchDownloadObjects(bucket: string, keys: string[]): Promise<Blob> {
+export async function batchDownloadObjects(bucket: string, keys: string[], options: BatchDownloadOptions = {}): Promise<Blob> {
   const resp = await axios.post(`${getBaseUrl()}/api/admin/buckets/${bucket}/batch/download`, {
-    keys
+    keys,
+    ...options
   }, {
     headers: getAdminHeaders(),
     responseType: 'blob'
@@ -193,6 +470,8 @@ export interface MigrateConfig {
   targetBucket: string
   targetPrefix?: string
   overwriteExist: boolean
+  maxRetries?: number // 源读取失败重试次数，默认 3
+  retryDelayMs?: number // 首次重试等待毫秒数，指数退避
 }
 
 // 迁移进度
@@ -209,6 +488,8 @@ export interface MigrateProgress {
   startTime: string
   endTime?: string
   error?: string
+  retries: number
+  retriedObjects: number
   failedObjects?: string[]
   config: MigrateConfig
 }
@@ -275,6 +556,7 @@ export interface S3Object {
   key: string
   size: number
   last_modified: string
+  created_at: string
   etag: string
 }
 
@@ -287,10 +569,11 @@ export async function listBuckets(): Promise<Bucket[]> {
 }
 
 // 列出对象
-export async function listObjects(bucket: string, prefix = '', marker = ''): Promise<{ objects: S3Object[], is_truncated: boolean, next_marker: string }> {
+export async function listObjects(bucket: string, prefix = '', marker = '', delimiter = ''): Promise<{ objects: S3Object[], prefixes: string[], is_truncated: boolean, next_marker: string }> {
   const params: Record<string, string> = {}
   if (prefix) params.prefix = prefix
   if (marker) params.marker = marker
+  if (delimiter) params.delimiter = delimiter
 
   const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/objects`, {
     headers: getAdminHeaders(),
@@ -298,17 +581,33 @@ export async function listObjects(bucket: string, prefix = '', marker = ''): Pro
   })
   return {
     objects: resp.data.objects || [],
+    prefixes: resp.data.prefixes || [],
     is_truncated: resp.data.is_truncated || false,
     next_marker: resp.data.next_marker || ''
   }
 }
 
+// 删除单个对象结果，开启撤销窗口时带撤销令牌
+export interface DeleteObjectResult {
+  success: boolean
+  undo_token?: string
+  undo_expires_at?: string
+}
+
 // 删除单个对象
-export async function deleteObject(bucket: string, key: string): Promise<void> {
-  await axios.delete(`${getBaseUrl()}/api/admin/buckets/${bucket}/objects`, {
+export async function deleteObject(bucket: string, key: string): Promise<DeleteObjectResult> {
+  const resp = await axios.delete(`${getBaseUrl()}/api/admin/buckets/${bucket}/objects`, {
     headers: getAdminHeaders(),
     params: { key }
   })
+  return resp.data
+}
+
+// 在撤销窗口内恢复被删除的对象（令牌只能使用一次）
+export async function undoDeleteObject(bucket: string, token: string): Promise<void> {
+  await axios.post(`${getBaseUrl()}/api/admin/buckets/${bucket}/undo-delete`, { token }, {
+    headers: getAdminHeaders()
+  })
 }
 
 // 上传对象
@@ -359,6 +658,547 @@ export async function getBucketPublic(bucket: string): Promise<boolean> {
   return resp.data.is_public
 }
 
+// 桶内容类型限制
+export interface BucketContentTypes {
+  mode: '' | 'allow' | 'block'
+  patterns: string[]
+  sniff: boolean
+}
+
+// 获取桶内容类型限制
+export async function getBucketContentTypes(bucket: string): Promise<BucketContentTypes> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/content-types`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 设置桶内容类型限制
+export async function setBucketContentTypes(bucket: string, config: BucketContentTypes): Promise<BucketContentTypes> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/content-types`, config, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 获取桶保留键列表
+export async function getBucketKeyDenylist(bucket: string): Promise<string[]> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/key-denylist`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.patterns
+}
+
+// 设置桶保留键列表
+export async function setBucketKeyDenylist(bucket: string, patterns: string[]): Promise<string[]> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/key-denylist`, { patterns }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.patterns
+}
+
+// 获取桶对象不可变窗口（分钟）
+export async function getBucketImmutability(bucket: string): Promise<number> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/immutability`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.minutes
+}
+
+// 设置桶对象不可变窗口（分钟），0 表示关闭
+export async function setBucketImmutability(bucket: string, minutes: number): Promise<number> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/immutability`, { minutes }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.minutes
+}
+
+// 桶最大可读天数，warning 提示该限制只阻止读取、不删除数据
+// basis 为计算基准：modified 按最后修改时间，created 按首次写入时间
+export interface BucketReadAge {
+  days: number
+  basis: 'modified' | 'created'
+  warning?: string
+}
+
+// 获取桶最大可读天数
+export async function getBucketReadAge(bucket: string): Promise<BucketReadAge> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/read-age`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 设置桶最大可读天数，0 表示关闭
+export async function setBucketReadAge(bucket: string, days: number, basis: BucketReadAge['basis'] = 'modified'): Promise<BucketReadAge> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/read-age`, { days, basis }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 对象校验和
+export type ChecksumAlgorithm = 'md5' | 'sha256' | 'crc32c'
+
+export interface ObjectChecksum {
+  bucket: string
+  key: string
+  etag: string
+  algorithm: ChecksumAlgorithm
+  value: string
+  computed_at: string
+  size: number
+  cached: boolean
+}
+
+export async function getObjectChecksum(bucket: string, key: string, algorithm: ChecksumAlgorithm = 'sha256', refresh = false): Promise<ObjectChecksum> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/checksum`, {
+    params: { key, algorithm, refresh: refresh || undefined },
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 审计日志条目
+export interface AuditLogEntry {
+  id: number
+  timestamp: string
+  action: string
+  actor: string
+  ip: string
+  resource: string
+  detail: string
+  success: boolean
+  request_id: string
+  trace_id: string
+}
+
+// 获取对象的审计时间线（上传、覆盖、复制、删除等），按时间正序
+export async function getObjectHistory(bucket: string, key: string, page = 1, limit = 50): Promise<{ logs: AuditLogEntry[]; total: number }> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/audit/object`, {
+    params: { bucket, key, page, limit },
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 获取桶允许的 HTTP 方法，空数组表示不限制
+export async function getBucketAllowedMethods(bucket: string): Promise<string[]> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/allowed-methods`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.methods
+}
+
+// 设置桶允许的 HTTP 方法（GET/HEAD/PUT/POST/DELETE），允许 GET 时自动包含 HEAD
+export async function setBucketAllowedMethods(bucket: string, methods: string[]): Promise<string[]> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/allowed-methods`, { methods }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.methods
+}
+
+// 获取桶是否只读
+export async function getBucketReadOnly(bucket: string): Promise<boolean> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/read-only`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.read_only
+}
+
+// 设置桶只读（冻结）：S3 API 的写入、覆盖和删除返回 403，读取和列举正常
+export async function setBucketReadOnly(bucket: string, readOnly: boolean): Promise<boolean> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/read-only`, { read_only: readOnly }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.read_only
+}
+
+// 获取桶是否启用版本控制
+export async function getBucketVersioning(bucket: string): Promise<boolean> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/versioning`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.enabled
+}
+
+// 设置桶版本控制：启用后覆盖和删除保留旧版本，关闭后已有版本保留
+export async function setBucketVersioning(bucket: string, enabled: boolean): Promise<boolean> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/versioning`, { enabled }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.enabled
+}
+
+// 桶存储配额，quota_bytes 为 0 表示不限制，used_bytes 为当前对象总大小
+export interface BucketQuota {
+  quota_bytes: number
+  used_bytes: number
+}
+
+// 获取桶存储配额及当前用量
+export async function getBucketQuota(bucket: string): Promise<BucketQuota> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/quota`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 设置桶存储配额（字节）：超出配额的 S3 写入返回 507，0 表示不限制
+export async function setBucketQuota(bucket: string, quotaBytes: number): Promise<number> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/quota`, { quota_bytes: quotaBytes }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.quota_bytes
+}
+
+// 桶策略语句，Principal 为 "*" 或 { AWS: [Access Key ID] }
+export interface BucketPolicyStatement {
+  Sid?: string
+  Effect: 'Allow' | 'Deny'
+  Principal: string | { AWS: string | string[] }
+  Action: string | string[]
+  Resource: string | string[]
+}
+
+// 桶策略文档
+export interface BucketPolicy {
+  Version?: string
+  Statement: BucketPolicyStatement[]
+}
+
+// 获取桶策略，未设置时为 null
+export async function getBucketPolicy(bucket: string): Promise<BucketPolicy | null> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/policy`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.policy
+}
+
+// 设置桶策略，Deny 优先于 Allow
+export async function setBucketPolicy(bucket: string, policy: BucketPolicy): Promise<BucketPolicy | null> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/policy`, { policy }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.policy
+}
+
+// 删除桶策略
+export async function deleteBucketPolicy(bucket: string): Promise<void> {
+  await axios.delete(`${getBaseUrl()}/api/admin/buckets/${bucket}/policy`, {
+    headers: getAdminHeaders()
+  })
+}
+
+// 生命周期过期规则：键以 prefix 开头且超过 days 天未修改的对象被自动删除
+export interface LifecycleRule {
+  id: string
+  prefix: string
+  days: number
+  enabled: boolean
+}
+
+// 获取桶的生命周期规则
+export async function getBucketLifecycle(bucket: string): Promise<LifecycleRule[]> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/lifecycle`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.rules
+}
+
+// 设置桶的生命周期规则，空列表表示删除
+export async function setBucketLifecycle(bucket: string, rules: LifecycleRule[]): Promise<LifecycleRule[]> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/lifecycle`, { rules }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.rules
+}
+
+// 对象版本
+export interface ObjectVersionInfo {
+  version_id: string
+  is_latest: boolean
+  delete_marker: boolean
+  size: number
+  etag: string
+  last_modified: string
+}
+
+// 列出对象的全部版本，最新的在前
+export async function listObjectVersions(bucket: string, key: string): Promise<ObjectVersionInfo[]> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/versions`, {
+    params: { key },
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 未完成的多段上传
+export interface IncompleteUpload {
+  upload_id: string
+  bucket: string
+  key: string
+  initiated: string
+  content_type: string
+  part_count: number
+  total_size: number
+}
+
+// 按键分页列出桶内未完成的多段上传
+export async function listBucketUploads(bucket: string, params: { prefix?: string; key_marker?: string; upload_id_marker?: string; limit?: number } = {}): Promise<{
+  uploads: IncompleteUpload[]
+  is_truncated: boolean
+  next_key_marker?: string
+  next_upload_id_marker?: string
+}> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/uploads`, {
+    params,
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 对象键前缀改写规则：S3 请求中以 from 开头的键改为 to 开头后存取
+export interface PrefixRewrite {
+  from: string
+  to: string
+}
+
+// 获取桶的前缀改写规则
+export async function getBucketPrefixRewrites(bucket: string): Promise<PrefixRewrite[]> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/prefix-rewrites`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.rules
+}
+
+// 设置桶的前缀改写规则，空数组表示关闭
+export async function setBucketPrefixRewrites(bucket: string, rules: PrefixRewrite[]): Promise<PrefixRewrite[]> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/prefix-rewrites`, { rules }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.rules
+}
+
+// 获取桶是否启用图片按需缩放
+export async function getBucketTransform(bucket: string): Promise<boolean> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/transform`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.enabled
+}
+
+// 设置桶图片按需缩放（GET 时 ?w=&h=）
+export async function setBucketTransform(bucket: string, enabled: boolean): Promise<boolean> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/transform`, { enabled }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.enabled
+}
+
+// 获取桶默认响应头
+export async function getBucketDefaultHeaders(bucket: string): Promise<Record<string, string>> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/default-headers`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.headers
+}
+
+// 设置桶默认响应头（如 Cache-Control），对象上传时未指定的头部使用默认值
+export async function setBucketDefaultHeaders(bucket: string, headers: Record<string, string>): Promise<Record<string, string>> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/default-headers`, { headers }, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.headers
+}
+
+// 桶静态网站配置
+export interface BucketWebsite {
+  index_document: string
+  spa: boolean
+}
+
+// 获取桶静态网站配置
+export async function getBucketWebsite(bucket: string): Promise<BucketWebsite> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/website`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 设置桶静态网站配置，index_document 为空表示关闭
+export async function setBucketWebsite(bucket: string, website: BucketWebsite): Promise<BucketWebsite> {
+  const resp = await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/website`, website, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 前缀用量
+export interface PrefixUsage {
+  prefix: string
+  object_count: number
+  total_size: number
+}
+
+// 桶前缀用量统计
+export interface BucketUsage {
+  bucket: string
+  prefix: string
+  delimiter: string
+  depth: number
+  total_objects: number
+  total_size: number
+  groups: PrefixUsage[]
+}
+
+// 获取桶按前缀分组的用量
+export async function getBucketUsage(
+  bucket: string,
+  params: { prefix?: string; delimiter?: string; depth?: number; limit?: number } = {}
+): Promise<BucketUsage> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/usage`, {
+    headers: getAdminHeaders(),
+    params
+  })
+  return resp.data
+}
+
+// 桶复制配置
+export interface BucketReplication {
+  endpoint: string
+  accessKey: string
+  secretKey?: string // 更新时留空保留原值
+  region: string
+  targetBucket: string
+  enabled: boolean
+  updatedAt?: string
+}
+
+// 桶复制状态
+export interface ReplicationStatus {
+  bucket: string
+  endpoint: string
+  targetBucket: string
+  enabled: boolean
+  pending: number
+  replicated: number
+  failed: number
+  lagSeconds: number
+  lastSuccess?: string
+  lastError?: string
+  lastErrorAt?: string
+}
+
+// 获取桶复制配置
+export async function getBucketReplication(bucket: string): Promise<{ configured: boolean; config?: BucketReplication }> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/replication`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 设置桶复制配置
+export async function setBucketReplication(bucket: string, config: BucketReplication): Promise<void> {
+  await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/replication`, config, {
+    headers: getAdminHeaders()
+  })
+}
+
+// 删除桶复制配置
+export async function deleteBucketReplication(bucket: string): Promise<void> {
+  await axios.delete(`${getBaseUrl()}/api/admin/buckets/${bucket}/replication`, {
+    headers: getAdminHeaders()
+  })
+}
+
+// 获取所有桶的复制状态
+export async function getReplicationStatus(): Promise<ReplicationStatus[]> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/replication`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.replication || []
+}
+
+// 桶事件通知 Webhook 目标
+export interface NotificationTarget {
+  url: string
+  events: string[] // 如 s3:ObjectCreated:*、s3:ObjectRemoved:Delete
+}
+
+// 获取桶事件通知配置
+export async function getBucketNotification(bucket: string): Promise<{ configured: boolean; config?: { targets: NotificationTarget[]; updatedAt: string } }> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/notification`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 设置桶事件通知配置
+export async function setBucketNotification(bucket: string, targets: NotificationTarget[]): Promise<void> {
+  await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/notification`, { targets }, {
+    headers: getAdminHeaders()
+  })
+}
+
+// 删除桶事件通知配置
+export async function deleteBucketNotification(bucket: string): Promise<void> {
+  await axios.delete(`${getBaseUrl()}/api/admin/buckets/${bucket}/notification`, {
+    headers: getAdminHeaders()
+  })
+}
+
+// 测试事件投递结果
+export interface NotificationTestResult {
+  url: string
+  success: boolean
+  statusCode?: number
+  latencyMs: number
+  error?: string
+}
+
+// 向桶的每个通知目标同步发送测试事件
+export async function testBucketNotification(bucket: string): Promise<NotificationTestResult[]> {
+  const resp = await axios.post(`${getBaseUrl()}/api/admin/buckets/${bucket}/notification/test`, null, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.results || []
+}
+
+// 桶回源配置（迁移切换期间，本地不存在的对象首次读取时从源端拉取）
+export interface BucketOrigin {
+  endpoint: string
+  accessKey: string
+  secretKey?: string // 更新时留空保留原值
+  region: string
+  sourceBucket: string
+  sourcePrefix: string
+  enabled: boolean
+  updatedAt?: string
+}
+
+// 获取桶回源配置
+export async function getBucketOrigin(bucket: string): Promise<{ configured: boolean; config?: BucketOrigin }> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/buckets/${bucket}/origin`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
+
+// 设置桶回源配置
+export async function setBucketOrigin(bucket: string, config: BucketOrigin): Promise<void> {
+  await axios.put(`${getBaseUrl()}/api/admin/buckets/${bucket}/origin`, config, {
+    headers: getAdminHeaders()
+  })
+}
+
+// 删除桶回源配置
+export async function deleteBucketOrigin(bucket: string): Promise<void> {
+  await axios.delete(`${getBaseUrl()}/api/admin/buckets/${bucket}/origin`, {
+    headers: getAdminHeaders()
+  })
+}
+
 // 获取对象下载 URL
 export function getObjectUrl(bucket: string, key: string): string {
   return `${getBaseUrl()}/${bucket}/${key}`
@@ -386,6 +1226,22 @@ export async function getSettings(): Promise<SystemSettings> {
   return resp.data
 }
 
+// 生效配置项（密钥和密码已脱敏）
+export interface EffectiveSetting {
+  key: string
+  value: unknown
+  default: unknown
+  source: 'default' | 'flag' | 'db'
+}
+
+// 获取合并默认值、命令行参数和数据库设置后的生效配置
+export async function getEffectiveConfig(): Promise<EffectiveSetting[]> {
+  const resp = await axios.get(`${getBaseUrl()}/api/admin/settings/effective`, {
+    headers: getAdminHeaders()
+  })
+  return resp.data.settings
+}
+
 // 预签名URL选项
 interface PresignOptions {
   method?: string
@@ -423,6 +1279,74 @@ export async function generatePresignedUrl(options: PresignOptions): Promise<Pre
   return resp.data
 }
 
+// 批量预签名单项结果（失败时 error/message 非空且不含 url）
+export interface PresignBatchItem {
+  bucket: string
+  key: string
+  url?: string
+  method?: string
+  expires?: number
+  error?: string
+  message?: string
+}
+
+// 批量生成预签名URL（单次最多 100 条，结果与请求顺序一致）
+export async function generatePresignedUrls(items: PresignOptions[]): Promise<PresignBatchItem[]> {
+  const requestBody = items.map(options => ({
+    method: options.method || 'GET',
+    bucket: options.bucket,
+    key: options.key,
+    expiresMinutes: options.expiresMinutes || 60,
+    maxSizeMB: options.maxSizeMB || 0,
+    contentType: options.contentType || ''
+  }))
+
+  const resp = await axios.post(`${getBaseUrl()}/api/presign/batch`, requestBody, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+
+  return resp.data
+}
+
+// 预签名 POST 表单选项，key 可包含 ${filename}
+export interface PresignPostOptions {
+  bucket: string
+  key: string
+  expiresMinutes?: number
+  minSize?: number
+  maxSizeMB?: number
+  contentType?: string
+}
+
+// 预签名 POST 表单：表单提交到 url，fields 作为隐藏字段，文件字段名为 file 且放在最后
+export interface PresignPostResponse {
+  url: string
+  fields: Record<string, string>
+  expires: number
+}
+
+// 生成浏览器表单直传使用的 POST 策略
+export async function generatePresignedPost(options: PresignPostOptions): Promise<PresignPostResponse> {
+  const requestBody = {
+    bucket: options.bucket,
+    key: options.key,
+    expiresMinutes: options.expiresMinutes || 60,
+    minSize: options.minSize || 0,
+    maxSizeMB: options.maxSizeMB || 0,
+    contentType: options.contentType || ''
+  }
+
+  const resp = await axios.post(`${getBaseUrl()}/api/presign/post`, requestBody, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+
+  return resp.data
+}
+
 // ============================================================
 // GeoStats API
 // ============================================================
@@ -521,3 +1445,27 @@ export async function clearGeoStatsData(params?: {
   })
   return resp.data
 }
+
+// 临时凭证
+export interface TemporaryCredential {
+  access_key_id: string
+  secret_access_key: string
+  session_token: string
+  expiration: string
+  bucket: string
+  can_read: boolean
+  can_write: boolean
+}
+
+// 签发临时凭证（bucket_name 为 "*" 表示所有桶，duration_seconds 范围 900-43200）
+export async function issueTemporaryCredential(req: {
+  bucket_name: string
+  can_read: boolean
+  can_write: boolean
+  duration_seconds?: number
+}): Promise<TemporaryCredential> {
+  const resp = await axios.post(`${getBaseUrl()}/api/admin/sts/token`, req, {
+    headers: getAdminHeaders()
+  })
+  return resp.data
+}
@@ -152,8 +152,12 @@ pleaseConfirmPassword: 'Please confirm your password'
     bucket: 'Bucket',
     objectCount: 'Objects',
     capacity: 'Size',
+    incompleteUploads: 'Incomplete Uploads',
     access: 'Access',
     percentage: 'Percentage',
+    requests: 'Requests',
+    errorRate: 'Error Rate',
+    traffic: 'Traffic (in/out)',
     public: 'Public',
     private: 'Private',
     file: 'File',
@@ -198,6 +202,10 @@ pleaseConfirmPassword: 'Please confirm your password'
     deleteTitle: 'Delete Bucket',
     deleteSuccess: 'Bucket deleted',
     deleteFailed: 'Failed to delete bucket',
+    forceDelete: 'Bucket Not Empty',
+    forceDeleteConfirm: 'Bucket "{name}" still contains objects. Type the bucket name to delete all objects, pending uploads and the bucket itself.',
+    forceDeleteMismatch: 'The name does not match the bucket',
+    forceDeleteSuccess: 'Bucket deleted with {count} objects',
     loadFailed: 'Failed to load buckets',
     accessUpdated: 'Bucket is now {access}',
     updateAccessFailed: 'Failed to update access',
@@ -223,6 +231,7 @@ deleteBucket: 'Delete Bucket',
     name: 'Name',
     size: 'Size',
     modified: 'Modified',
+    created: 'Created',
     actions: 'Actions',
     preview: 'Preview',
     download: 'Download',
@@ -259,6 +268,9 @@ deleteBucket: 'Delete Bucket',
     deleteConfirm: 'Are you sure you want to delete "{name}"?',
     deleteTitle: 'Delete File',
     deleteSuccess: 'File deleted',
+    undoDelete: 'Undo',
+    undoDeleteSuccess: '{name} restored',
+    undoDeleteFailed: 'Undo failed',
     deleteFailed: 'Failed to delete file',
     pathUnchanged: 'Path unchanged',
     fileExists: 'File "{name}" already exists. Overwrite?',
@@ -367,6 +379,9 @@ add: 'Add',
     disabled: 'Disabled',
     enabled: 'Enabled',
     noKeys: 'No API keys',
+    searchDescription: 'Search description',
+    newestFirst: 'Newest first',
+    oldestFirst: 'Oldest first',
     permissionAdded: 'Permission added',
     permissionRemoved: 'Permission removed',
     remove: 'Remove',
@@ -374,7 +389,14 @@ add: 'Add',
     resetSecretKey: 'Reset Secret Key',
     saveSecretNow: 'Save your secret key now',
     savedMyKey: 'I have saved my API key',
-    secretOnlyOnce: 'The secret key is only shown once'
+    secretOnlyOnce: 'The secret key is only shown once',
+    namespace: 'Bucket Namespace',
+    namespacePlaceholder: 'e.g., tenant-a-',
+    namespaceHint: 'Optional. Prepended to every bucket name this key uses: "photos" maps to "tenant-a-photos". The key only sees buckets under this prefix',
+    presignUsage: 'Presigned URLs this window',
+    presignUnlimited: 'unlimited',
+    presignResetAt: 'resets at {time}',
+    namespaceSaved: 'Namespace updated'
   },
 
   // Tools
@@ -408,6 +430,10 @@ add: 'Add',
     repair: 'Repair',
     verifyEtag: 'Verify ETag',
     limit: 'Limit',
+    integrityWorkers: 'Workers',
+    integrityBufferKB: 'Buffer (KB)',
+    integrityRate: 'Files/sec (0 = unlimited)',
+    integrityProgress: 'Scanned {scanned} / {total}',
     objects: 'objects',
     allObjects: 'All objects',
     totalChecked: 'Total Checked',
@@ -454,6 +480,8 @@ add: 'Add',
     selectLocalBucket: 'Select local bucket',
     targetPrefix: 'Target Prefix',
     overwriteExisting: 'Overwrite existing files',
+    maxRetries: 'Max retries per object',
+    retryDelayMs: 'Initial retry delay (ms)',
     startMigration: 'Start Migration',
     fillRequiredFields: 'Please fill all required source fields',
     selectTargetBucket: 'Please select a target bucket',
@@ -473,6 +501,7 @@ add: 'Add',
     migrationDeleted: 'Migration record deleted',
     deleteMigrationFailed: 'Failed to delete migration record',
     skipped: 'Skipped',
+    retries: 'retries',
     completed: 'Completed',
     running: 'Running',
     pending: 'Pending',
@@ -560,6 +589,7 @@ add: 'Add',
     time: 'Time',
     action: 'Action',
     resource: 'Resource',
+    traceId: 'Trace / Request ID',
     detail: 'Detail',
     noLogs: 'No logs',
     loadFailed: 'Failed to load audit logs',
@@ -581,9 +611,16 @@ add: 'Add',
       apikey_reset_secret: 'Reset Secret',
       apikey_set_perm: 'Set Permission',
       apikey_del_perm: 'Delete Permission',
+      sts_issue: 'Issue Temporary Credential',
       object_upload: 'Upload Object',
       object_delete: 'Delete Object',
-      batch_delete: 'Batch Delete'
+      object_overwrite: 'Overwrite Object',
+      batch_delete: 'Batch Delete',
+      batch_tag: 'Batch Tag',
+      batch_untag: 'Batch Untag',
+      object_read: 'Read Object',
+      object_head: 'Head Object',
+      bucket_list: 'List Objects'
     },
     actions: {
       login: 'Login',
@@ -600,10 +637,34 @@ add: 'Add',
       apikeyUpdate: 'Update API Key',
       apikeyResetSecret: 'Reset API Key Secret',
       apikeySetPerm: 'Set API Key Permission',
-      apikeyDelPerm: 'Delete API Key Permission'
+      apikeyDelPerm: 'Delete API Key Permission',
+      stsIssue: 'Issue Temporary Credential',
+      objectUpload: 'Upload Object',
+      objectOverwrite: 'Overwrite Object',
+      objectDelete: 'Delete Object',
+      objectUndoDelete: 'Undo Object Delete',
+      batchDelete: 'Batch Delete',
+      batchTag: 'Batch Tag Objects',
+      batchUntag: 'Batch Untag Objects',
+      objectRead: 'Read Object',
+      objectHead: 'Head Object',
+      bucketList: 'List Objects',
+      settingsUpdate: 'Update Settings',
+      passwordChange: 'Change Password',
+      objectCopy: 'Copy Object',
+      migrateCreate: 'Create Migration',
+      migrateCancel: 'Cancel Migration',
+      migrateDelete: 'Delete Migration',
+      gcExecute: 'Run Garbage Collection',
+      integrityRepair: 'Repair Integrity',
+      orphanSweep: 'Sweep Orphan Files',
+      statsRecompute: 'Recompute Storage Stats',
+      etagRederive: 'Rederive ETags'
     },
     apikeyOps: 'API Key Operations',
     authRelated: 'Auth Related',
+    objectOps: 'Object Operations',
+    maintenanceOps: 'Maintenance Operations',
     bucketOps: 'Bucket Operations',
     details: 'Details',
     operation: 'Operation',
@@ -625,6 +686,7 @@ add: 'Add',
     listenAddress: 'Listen Address',
     dataDirectory: 'Data Directory',
     database: 'Database',
+    encryptionAtRest: 'Encryption at rest',
     runtimeHint: 'These parameters are set via command line and require a restart to modify',
     storageConfig: 'Storage Configuration',
     s3Region: 'S3 Region',
@@ -632,6 +694,46 @@ add: 'Add',
     maxUploadSize: 'Presigned Upload Limit',
     maxObjectSizeHint: 'Maximum size allowed for a single object',
     maxUploadSizeHint: 'Maximum size for presigned URL uploads',
+    maxBuckets: 'Max Buckets',
+    maxBucketsHint: 'Maximum number of buckets, 0 means unlimited',
+    maxMetadataSize: 'Max Metadata Size (bytes)',
+    maxMetadataSizeHint: 'Total size of x-amz-meta-* headers per object; larger uploads are rejected with MetadataTooLarge. 0 means unlimited',
+    anonymousDailyBytes: 'Anonymous Daily Bandwidth (bytes)',
+    anonymousDailyBytesHint: 'Total anonymous download traffic per UTC day on public buckets; once exceeded anonymous requests get 429 while signed access continues. 0 means unlimited',
+    idempotencyWindow: 'PUT Idempotency Window (minutes)',
+    idempotencyWindowHint: 'Repeated PUTs with the same Idempotency-Key within this window return the first result; a different body returns 409. 0 ignores the header',
+    maxUploadParts: 'Max Parts per Incomplete Upload',
+    maxUploadPartsHint: 'Parts a single unfinished multipart upload may keep on disk; further new parts are rejected with 403. 0 means unlimited',
+    maxIncompleteUploads: 'Max Incomplete Uploads per Bucket',
+    maxIncompleteUploadsHint: 'Unfinished multipart uploads allowed per bucket at once; new uploads are rejected with 429. 0 means unlimited',
+    minPartSize: 'Min Part Size (bytes)',
+    minPartSizeHint: 'Every part but the last must be at least this large when a multipart upload is completed, otherwise EntityTooSmall is returned. Default 5 MiB, 0 disables the check',
+    minFreeSpace: 'Min Free Space',
+    minFreeSpaceHint: 'Uploads that would leave less free space on the data disk are rejected with 507, and the readiness probe reports unhealthy.',
+    minFreeSpaceOff: 'Disabled',
+    freeSpaceNow: 'Currently free: {size}',
+    listTimeBudget: 'Listing Time Budget (ms)',
+    listTimeBudgetHint: 'A listing that scans longer than this returns the results so far with IsTruncated=true and a continuation token, so clients of very large buckets do not time out. 0 means unlimited',
+    deleteGraceSeconds: 'Delete Undo Window (seconds)',
+    deleteGraceSecondsHint: 'Files deleted in the admin UI disappear at once but stay on disk for this long, and the delete message offers an Undo link. 0 deletes immediately',
+    lifecycleInterval: 'Lifecycle Scan Interval (minutes)',
+    lifecycleIntervalHint: 'How often objects matching bucket lifecycle rules are expired. Never runs alongside an integrity check. 0 pauses expiration',
+    autoCreateBucket: 'Auto-create Bucket on Upload',
+    autoCreateBucketHint: 'PUT to a missing bucket creates it instead of returning 404',
+    keyLeadingSlash: 'Leading Slash in Object Keys',
+    keyLeadingSlashNormalize: 'Normalize (strip leading slashes)',
+    keyLeadingSlashReject: 'Reject (return 400)',
+    keyLeadingSlashHint: 'How keys like /leading or //double are handled; normalized keys are stored and listed without the slashes',
+    folderMarkers: 'Empty Keys Ending in /',
+    folderMarkersObject: 'Regular objects (listed like any object)',
+    folderMarkersPlaceholder: 'Folder placeholders (listed only as folders)',
+    folderMarkersHint: 'How zero-byte uploads such as folder/ are treated; in placeholder mode non-empty uploads to such keys are rejected',
+    maintenance: 'Maintenance Mode',
+    maintenanceHint: '/api/health/ready returns 503 so orchestrators stop routing traffic; S3 requests are still served',
+    metricsMaxBuckets: 'Per-bucket Metrics Limit',
+    metricsMaxBucketsHint: 'Maximum number of buckets tracked individually; extra buckets are grouped as _other. 0 means unlimited',
+    prometheusEnabled: 'Prometheus Metrics',
+    prometheusEnabledHint: 'Serve an unauthenticated GET /metrics with request counts, latency, traffic and per-bucket object counts. Bucket names become visible',
     systemInfo: 'System Information',
     version: 'Version',
     installedAt: 'Installed At',
@@ -639,7 +741,30 @@ add: 'Add',
     running: 'Running',
     securitySettings: 'Security Settings',
     corsOrigin: 'CORS Allowed Origin',
-    corsOriginHint: 'Origins allowed for cross-origin requests, * allows all',
+    corsOriginHint: 'Origins allowed for cross-origin requests, * allows all, separate multiple origins with commas',
+    corsAllowCredentials: 'CORS Allow Credentials',
+    auditOverwrite: 'Audit Object Overwrites',
+    auditOverwriteHint: 'Record an object_overwrite audit entry with old and new ETag when an existing key is replaced',
+    auditReadPercent: 'Read Audit Sampling (%)',
+    auditReadPercentHint: 'Percentage of S3 GET/HEAD/ListObjects requests recorded as audit entries (actor, status, bytes). 0 disables read auditing',
+    presignLimit: 'Presigned URL Limit per Key',
+    presignLimitHint: 'Maximum presigned URLs each access key may generate per window; requests beyond it get 429. 0 means unlimited',
+    presignWindowMinutes: 'Presign Limit Window (minutes)',
+    presignWindowMinutesHint: 'Length of the counting window for the presigned URL limit',
+    headerNoSniff: 'X-Content-Type-Options: nosniff',
+    headerNoSniffHint: 'Prevent browsers from MIME-sniffing responses of the admin console',
+    frameOptions: 'X-Frame-Options',
+    frameOptionsHint: 'Controls whether the admin console may be embedded in frames',
+    headerOff: 'Off',
+    referrerPolicy: 'Referrer-Policy',
+    referrerPolicyHint: 'Leave empty to disable the header',
+    contentSecurityPolicy: 'Content-Security-Policy',
+    contentSecurityPolicyHint: 'Not sent when empty',
+    headersOnPublic: 'Apply to Public Bucket Objects',
+    headersOnPublicHint: 'Also attach the security headers to anonymous reads from public buckets',
+    traceHeader: 'Trace ID Header',
+    traceHeaderHint: 'Client-supplied correlation ID header; it is echoed in the response and recorded in access and audit logs. Leave empty to disable',
+    corsAllowCredentialsHint: 'Send Access-Control-Allow-Credentials; requires explicit origins (not *)',
     presignScheme: 'Presigned URL Scheme',
     presignSchemeHint: 'Protocol used when generating presigned URLs',
     changePassword: 'Change Admin Password',
@@ -152,8 +152,12 @@ pleaseConfirmPassword: '请确认您的密码'
     bucket: '存储桶',
     objectCount: '对象数',
     capacity: '容量',
+    incompleteUploads: '未完成上传',
     access: '访问',
     percentage: '占比',
+    requests: '请求数',
+    errorRate: '错误率',
+    traffic: '流量 (入/出)',
     public: '公开',
     private: '私有',
     file: '文件',
@@ -198,6 +202,10 @@ pleaseConfirmPassword: '请确认您的密码'
     deleteTitle: '删除存储桶',
     deleteSuccess: '删除成功',
     deleteFailed: '删除失败',
+    forceDelete: '存储桶非空',
+    forceDeleteConfirm: '存储桶 "{name}" 中仍有对象。输入桶名以删除全部对象、未完成的上传以及存储桶本身。',
+    forceDeleteMismatch: '输入的名称与存储桶不一致',
+    forceDeleteSuccess: '已删除存储桶及其中 {count} 个对象',
     loadFailed: '加载存储桶失败',
     accessUpdated: '存储桶已设为{access}',
     updateAccessFailed: '更新访问权限失败',
@@ -223,6 +231,7 @@ deleteBucket: '删除存储桶',
     name: '名称',
     size: '大小',
     modified: '修改时间',
+    created: '创建时间',
     actions: '操作',
     preview: '预览',
     download: '下载',
@@ -259,6 +268,9 @@ deleteBucket: '删除存储桶',
     deleteConfirm: '确定要删除 "{name}" 吗？',
     deleteTitle: '删除文件',
     deleteSuccess: '删除成功',
+    undoDelete: '撤销',
+    undoDeleteSuccess: '已恢复 {name}',
+    undoDeleteFailed: '撤销失败',
     deleteFailed: '删除失败',
     pathUnchanged: '路径未更改',
     fileExists: '文件 "{name}" 已存在，是否覆盖？',
@@ -367,6 +379,9 @@ add: '添加',
     disabled: '已禁用',
     enabled: '已启用',
     noKeys: '暂无 API 密钥',
+    searchDescription: '搜索描述',
+    newestFirst: '最新创建在前',
+    oldestFirst: '最早创建在前',
     permissionAdded: '权限已添加',
     permissionRemoved: '权限已移除',
     remove: '移除',
@@ -374,7 +389,14 @@ add: '添加',
     resetSecretKey: '重置密钥',
     saveSecretNow: '请立即保存您的密钥',
     savedMyKey: '我已保存我的 API 密钥',
-    secretOnlyOnce: '密钥只显示一次'
+    secretOnlyOnce: '密钥只显示一次',
+    namespace: '桶命名空间',
+    namespacePlaceholder: '例如 tenant-a-',
+    namespaceHint: '可选。该密钥使用的桶名会自动加上此前缀："photos" 对应实际的 "tenant-a-photos"，且只能看到此前缀下的桶',
+    presignUsage: '本窗口预签名数',
+    presignUnlimited: '不限制',
+    presignResetAt: '{time} 重置',
+    namespaceSaved: '命名空间已更新'
   },
 
   // 工具箱
@@ -408,6 +430,10 @@ add: '添加',
     repair: '修复',
     verifyEtag: '验证 ETag',
     limit: '限制',
+    integrityWorkers: '并发数',
+    integrityBufferKB: '读缓冲 (KB)',
+    integrityRate: '每秒文件数 (0 为不限)',
+    integrityProgress: '已检查 {scanned} / {total}',
     objects: '个对象',
     allObjects: '所有对象',
     totalChecked: '已检查总数',
@@ -454,6 +480,8 @@ add: '添加',
     selectLocalBucket: '选择本地存储桶',
     targetPrefix: '目标前缀',
     overwriteExisting: '覆盖已存在的文件',
+    maxRetries: '单对象最大重试次数',
+    retryDelayMs: '首次重试等待（毫秒）',
     startMigration: '开始迁移',
     fillRequiredFields: '请填写所有必填的来源字段',
     selectTargetBucket: '请选择目标存储桶',
@@ -473,6 +501,7 @@ add: '添加',
     migrationDeleted: '迁移记录已删除',
     deleteMigrationFailed: '删除迁移记录失败',
     skipped: '跳过',
+    retries: '次重试',
     completed: '已完成',
     running: '运行中',
     pending: '等待中',
@@ -560,6 +589,7 @@ add: '添加',
     time: '时间',
     action: '操作',
     resource: '资源',
+    traceId: '追踪 / 请求 ID',
     detail: '详情',
     noLogs: '暂无日志',
     loadFailed: '加载审计日志失败',
@@ -581,9 +611,16 @@ add: '添加',
       apikey_reset_secret: '重置Secret',
       apikey_set_perm: '设置权限',
       apikey_del_perm: '删除权限',
+      sts_issue: '签发临时凭证',
       object_upload: '上传对象',
       object_delete: '删除对象',
-      batch_delete: '批量删除'
+      object_overwrite: '覆盖对象',
+      batch_delete: '批量删除',
+      batch_tag: '批量打标签',
+      batch_untag: '批量移除标签',
+      object_read: '读取对象',
+      object_head: '查询对象元数据',
+      bucket_list: '列举对象'
     },
     actions: {
       login: '登录',
@@ -600,10 +637,34 @@ add: '添加',
       apikeyUpdate: '更新 API 密钥',
       apikeyResetSecret: '重置 API 密钥',
       apikeySetPerm: '设置 API 密钥权限',
-      apikeyDelPerm: '删除 API 密钥权限'
+      apikeyDelPerm: '删除 API 密钥权限',
+      stsIssue: '签发临时凭证',
+      objectUpload: '上传对象',
+      objectOverwrite: '覆盖对象',
+      objectDelete: '删除对象',
+      objectUndoDelete: '撤销删除对象',
+      batchDelete: '批量删除',
+      batchTag: '批量设置对象标签',
+      batchUntag: '批量移除对象标签',
+      objectRead: '读取对象',
+      objectHead: '查询对象元数据',
+      bucketList: '列举对象',
+      settingsUpdate: '更新设置',
+      passwordChange: '修改密码',
+      objectCopy: '复制对象',
+      migrateCreate: '创建迁移任务',
+      migrateCancel: '取消迁移任务',
+      migrateDelete: '删除迁移任务',
+      gcExecute: '执行垃圾回收',
+      integrityRepair: '修复完整性问题',
+      orphanSweep: '清理孤立文件',
+      statsRecompute: '重建存储统计',
+      etagRederive: '重算 ETag'
     },
     apikeyOps: 'API 密钥操作',
     authRelated: '认证相关',
+    objectOps: '对象操作',
+    maintenanceOps: '维护操作',
     bucketOps: '存储桶操作',
     details: '详情',
     operation: '操作',
@@ -625,6 +686,7 @@ add: '添加',
     listenAddress: '监听地址',
     dataDirectory: '数据目录',
     database: '数据库',
+    encryptionAtRest: '静态加密',
     runtimeHint: '这些参数通过命令行设置，需重启服务才能修改',
     storageConfig: '存储配置',
     s3Region: 'S3 区域',
@@ -632,6 +694,46 @@ add: '添加',
     maxUploadSize: '预签名上传限制',
     maxObjectSizeHint: '单个对象允许的最大大小',
     maxUploadSizeHint: '预签名 URL 上传的最大大小',
+    maxBuckets: '最大桶数量',
+    maxBucketsHint: '允许创建的桶数量上限，0 表示不限制',
+    maxMetadataSize: '用户元数据上限 (字节)',
+    maxMetadataSizeHint: '单个对象 x-amz-meta-* 请求头的总大小，超出时以 MetadataTooLarge 拒绝上传，0 表示不限制',
+    anonymousDailyBytes: '匿名下载每日流量上限 (字节)',
+    anonymousDailyBytesHint: '公有桶匿名下载每天（UTC）的总流量，超出后匿名请求返回 429，签名访问不受影响，0 表示不限制',
+    idempotencyWindow: 'PUT 幂等键保留时间 (分钟)',
+    idempotencyWindowHint: '携带 Idempotency-Key 的重复 PUT 在此时间内直接返回首次结果，内容不一致返回 409，0 表示忽略该请求头',
+    maxUploadParts: '单个未完成上传分片数上限',
+    maxUploadPartsHint: '单个未完成的多段上传在磁盘上保留的分片数，超出后新分片返回 403，0 表示不限制',
+    maxIncompleteUploads: '每桶未完成上传数上限',
+    maxIncompleteUploadsHint: '每个桶同时存在的未完成多段上传数，超出后初始化上传返回 429，0 表示不限制',
+    minPartSize: '分片最小大小（字节）',
+    minPartSizeHint: '完成多段上传时，除最后一个外每个分片不得小于该值，否则返回 EntityTooSmall，默认 5MiB，0 表示不检查',
+    minFreeSpace: '最低可用空间',
+    minFreeSpaceHint: '写入后数据盘可用空间将低于该值的上传返回 507，就绪探针同时报告异常。',
+    minFreeSpaceOff: '不检查',
+    freeSpaceNow: '当前可用 {size}',
+    listTimeBudget: '列举时间预算（毫秒）',
+    listTimeBudgetHint: '单次列举扫描超过该时间后返回已列出的部分结果（IsTruncated=true）和续传标记，避免大桶列举导致客户端超时，0 表示不限制',
+    deleteGraceSeconds: '删除撤销窗口（秒）',
+    deleteGraceSecondsHint: '管理界面删除的文件立即隐藏，但在此时间内保留在磁盘上，删除提示中可点击撤销恢复，0 表示立即删除',
+    lifecycleInterval: '生命周期扫描间隔（分钟）',
+    lifecycleIntervalHint: '按此间隔删除命中桶生命周期规则的过期对象，与完整性检查互斥，0 表示暂停',
+    autoCreateBucket: '上传时自动建桶',
+    autoCreateBucketHint: '上传到不存在的桶时自动创建，而不是返回 404',
+    keyLeadingSlash: '对象键前导斜杠',
+    keyLeadingSlashNormalize: '规范化（去除前导斜杠）',
+    keyLeadingSlashReject: '拒绝（返回 400）',
+    keyLeadingSlashHint: '处理 /leading、//double 这类键的方式，规范化后以去除斜杠的键存储和列出',
+    folderMarkers: '以 / 结尾的空对象',
+    folderMarkersObject: '普通对象（与其他对象一样列出）',
+    folderMarkersPlaceholder: '文件夹占位（只以文件夹形式列出）',
+    folderMarkersHint: '处理 folder/ 这类零字节上传的方式，占位模式下拒绝向此类键上传非空内容',
+    maintenance: '维护模式',
+    maintenanceHint: '开启后 /api/health/ready 返回 503，编排系统将摘除该节点流量；S3 请求仍正常处理',
+    metricsMaxBuckets: '按桶统计上限',
+    metricsMaxBucketsHint: '单独统计请求数的最大桶数，超出部分归入 _other，0 表示不限制',
+    prometheusEnabled: 'Prometheus 指标',
+    prometheusEnabledHint: '开放无需认证的 GET /metrics，输出请求数、耗时、流量和各桶对象数，会暴露桶名',
     systemInfo: '系统信息',
     version: '版本',
     installedAt: '安装时间',
@@ -639,7 +741,30 @@ add: '添加',
     running: '运行中',
     securitySettings: '安全设置',
     corsOrigin: 'CORS 允许来源',
-    corsOriginHint: '允许跨域请求的来源，* 表示允许所有来源',
+    corsOriginHint: '允许跨域请求的来源，* 表示允许所有来源，多个来源用逗号分隔',
+    corsAllowCredentials: 'CORS 允许携带凭证',
+    auditOverwrite: '记录对象覆盖审计',
+    auditOverwriteHint: '覆盖已存在的对象时记录 object_overwrite 审计日志（包含新旧 ETag）',
+    auditReadPercent: '读操作审计采样率 (%)',
+    auditReadPercentHint: '按百分比记录 S3 GET/HEAD/ListObjects 请求的审计日志（操作者、状态码、字节数），0 表示关闭',
+    presignLimit: '每个 Key 预签名数上限',
+    presignLimitHint: '每个 Access Key 在一个时间窗口内可生成的预签名 URL 数，超出返回 429，0 表示不限制',
+    presignWindowMinutes: '预签名计数窗口（分钟）',
+    presignWindowMinutesHint: '预签名数量上限的计数时间窗口长度',
+    headerNoSniff: 'X-Content-Type-Options: nosniff',
+    headerNoSniffHint: '禁止浏览器对管理界面响应进行 MIME 类型嗅探',
+    frameOptions: 'X-Frame-Options',
+    frameOptionsHint: '控制管理界面是否允许被嵌入 iframe',
+    headerOff: '关闭',
+    referrerPolicy: 'Referrer-Policy',
+    referrerPolicyHint: '留空则不发送该响应头',
+    contentSecurityPolicy: 'Content-Security-Policy',
+    contentSecurityPolicyHint: '留空则不发送',
+    headersOnPublic: '公有桶对象附加安全头',
+    headersOnPublicHint: '匿名访问公有桶对象时同样附加上述安全响应头',
+    traceHeader: '追踪 ID 请求头',
+    traceHeaderHint: '客户端传入的关联 ID 请求头，会回显到响应并记录到访问日志和审计日志，留空表示不启用',
+    corsAllowCredentialsHint: '返回 Access-Control-Allow-Credentials，需配置具体来源（不能为 *）',
     presignScheme: '预签名 URL 协议',
     presignSchemeHint: '生成预签名 URL 时使用的协议',
     changePassword: '修改管理员密码',